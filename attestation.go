@@ -0,0 +1,264 @@
+package regclient
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/pkg/intoto"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	// attestationMax limits the size of an attestation that is pulled.
+	attestationMax = 16 * 1024 * 1024
+	// annotations used by docker buildx to attach attestations to an index
+	annotationDockerReferenceType   = "vnd.docker.reference.type"
+	annotationDockerReferenceDigest = "vnd.docker.reference.digest"
+	dockerReferenceTypeAttestation  = "attestation-manifest"
+)
+
+// Attestation describes an in-toto attestation attached to an image.
+type Attestation struct {
+	// Manifest is the descriptor of the manifest containing the attestation.
+	Manifest types.Descriptor `json:"manifest"`
+	// Layer is the descriptor of the in-toto statement or DSSE envelope.
+	Layer types.Descriptor `json:"layer"`
+	// Subject is the digest of the image that was attested.
+	Subject digest.Digest `json:"subject"`
+	// Platform is set for attestations of a platform specific image in an index.
+	Platform *platform.Platform `json:"platform,omitempty"`
+	// PredicateType is the type of the attestation, e.g. [intoto.PredicateSLSAProvenance1].
+	PredicateType string `json:"predicateType"`
+	// Source is where the attestation was found, "referrer", "index", or "cosign".
+	Source string `json:"source"`
+	// Signed is true for a DSSE envelope.
+	Signed bool `json:"signed"`
+}
+
+// AttestationContent is the content of an attestation returned by [RegClient.AttestationGet].
+type AttestationContent struct {
+	Attestation
+	Statement intoto.Statement `json:"statement"`
+	// Verified is true when the DSSE signature was verified with [AttestationWithVerifyKey].
+	Verified bool `json:"verified"`
+}
+
+type attestationOpt struct {
+	predicateType string
+	verifyKey     crypto.PublicKey
+}
+
+// AttestationOpts define options for the Attestation* commands.
+type AttestationOpts func(*attestationOpt)
+
+// AttestationWithPredicateType filters attestations to the requested predicate type.
+func AttestationWithPredicateType(predicateType string) AttestationOpts {
+	return func(opt *attestationOpt) {
+		opt.predicateType = predicateType
+	}
+}
+
+// AttestationWithVerifyKey verifies the DSSE envelope signature with the public key in [RegClient.AttestationGet].
+// Unsigned attestations fail to verify.
+func AttestationWithVerifyKey(pub crypto.PublicKey) AttestationOpts {
+	return func(opt *attestationOpt) {
+		opt.verifyKey = pub
+	}
+}
+
+// AttestationList returns the in-toto attestations of an image.
+// Attestations are found as referrers, as attestation manifests in an index created by docker buildx, and in the cosign "sha256-<hex>.att" tag.
+func (rc *RegClient) AttestationList(ctx context.Context, r ref.Ref, opts ...AttestationOpts) ([]Attestation, error) {
+	opt := attestationOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	if !r.IsSet() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	dImage := m.GetDescriptor()
+	rImage := r.SetDigest(dImage.Digest.String())
+	al := []Attestation{}
+
+	// referrers
+	rl, err := rc.ReferrerList(ctx, rImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrers: %w", err)
+	}
+	for _, d := range rl.Descriptors {
+		found, err := rc.attestationManifest(ctx, rImage.SetDigest(d.Digest.String()), dImage.Digest, "referrer")
+		if err != nil {
+			return nil, err
+		}
+		al = append(al, found...)
+	}
+
+	// docker buildx attestation manifests in the index
+	if mi, ok := m.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return nil, err
+		}
+		platforms := map[string]*platform.Platform{}
+		for _, d := range dl {
+			platforms[d.Digest.String()] = d.Platform
+		}
+		for _, d := range dl {
+			if d.Annotations[annotationDockerReferenceType] != dockerReferenceTypeAttestation {
+				continue
+			}
+			subject, err := digest.Parse(d.Annotations[annotationDockerReferenceDigest])
+			if err != nil {
+				return nil, fmt.Errorf("invalid attestation subject %s: %v%.0w", d.Annotations[annotationDockerReferenceDigest], err, types.ErrParsingFailed)
+			}
+			found, err := rc.attestationManifest(ctx, rImage.SetDigest(d.Digest.String()), subject, "index")
+			if err != nil {
+				return nil, err
+			}
+			for i := range found {
+				found[i].Platform = platforms[subject.String()]
+			}
+			al = append(al, found...)
+		}
+	}
+
+	// cosign attestation tag
+	rAtt := rImage.SetTag(fmt.Sprintf("%s-%s.att", dImage.Digest.Algorithm().String(), dImage.Digest.Hex()))
+	found, err := rc.attestationManifest(ctx, rAtt, dImage.Digest, "cosign")
+	if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	al = append(al, found...)
+
+	if opt.predicateType != "" {
+		filtered := []Attestation{}
+		for _, a := range al {
+			if a.PredicateType == opt.predicateType {
+				filtered = append(filtered, a)
+			}
+		}
+		al = filtered
+	}
+	return al, nil
+}
+
+// AttestationGet pulls an attestation returned by [RegClient.AttestationList].
+// The DSSE signature is verified when [AttestationWithVerifyKey] is provided.
+func (rc *RegClient) AttestationGet(ctx context.Context, r ref.Ref, a Attestation, opts ...AttestationOpts) (AttestationContent, error) {
+	opt := attestationOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	ac := AttestationContent{Attestation: a}
+	if !r.IsSetRepo() {
+		return ac, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	s, env, err := rc.attestationPull(ctx, r.SetDigest(a.Manifest.Digest.String()), a.Layer)
+	if err != nil {
+		return ac, err
+	}
+	ac.Statement = s
+	if opt.verifyKey != nil {
+		if env == nil {
+			return ac, fmt.Errorf("attestation is not signed%.0w", intoto.ErrVerifyFailed)
+		}
+		err = env.Verify(opt.verifyKey)
+		if err != nil {
+			return ac, err
+		}
+		ac.Verified = true
+	}
+	if !attestationSubjectMatch(s, a.Subject) {
+		return ac, fmt.Errorf("attestation subject does not include %s%.0w", a.Subject.String(), types.ErrDigestMismatch)
+	}
+	return ac, nil
+}
+
+// attestationManifest returns the in-toto layers of a manifest.
+func (rc *RegClient) attestationManifest(ctx context.Context, r ref.Ref, subject digest.Digest, source string) ([]Attestation, error) {
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return nil, nil
+	}
+	layers, err := mi.GetLayers()
+	if err != nil {
+		return nil, err
+	}
+	al := []Attestation{}
+	for _, l := range layers {
+		if l.MediaType != intoto.MediaTypeStatement && l.MediaType != intoto.MediaTypeDSSE {
+			continue
+		}
+		a := Attestation{
+			Manifest:      m.GetDescriptor(),
+			Layer:         l,
+			Subject:       subject,
+			PredicateType: l.Annotations[intoto.AnnotationPredicateType],
+			Source:        source,
+			Signed:        l.MediaType == intoto.MediaTypeDSSE,
+		}
+		if a.PredicateType == "" {
+			a.PredicateType = l.Annotations[intoto.AnnotationPredicateTypeCosign]
+		}
+		// pull the statement when the predicate type is not annotated
+		if a.PredicateType == "" {
+			s, _, err := rc.attestationPull(ctx, r, l)
+			if err != nil {
+				rc.log.WithFields(logrus.Fields{
+					"ref":    r.CommonName(),
+					"digest": l.Digest.String(),
+					"err":    err,
+				}).Warn("failed to parse attestation")
+				continue
+			}
+			a.PredicateType = s.PredicateType
+		}
+		al = append(al, a)
+	}
+	return al, nil
+}
+
+func (rc *RegClient) attestationPull(ctx context.Context, r ref.Ref, d types.Descriptor) (intoto.Statement, *intoto.Envelope, error) {
+	if d.Size > attestationMax {
+		return intoto.Statement{}, nil, fmt.Errorf("attestation size %d exceeds %d%.0w", d.Size, attestationMax, types.ErrSizeLimitExceeded)
+	}
+	br, err := rc.BlobGet(ctx, r, d)
+	if err != nil {
+		return intoto.Statement{}, nil, err
+	}
+	defer br.Close()
+	b, err := io.ReadAll(br)
+	if err != nil {
+		return intoto.Statement{}, nil, err
+	}
+	return intoto.Parse(d.MediaType, b)
+}
+
+func attestationSubjectMatch(s intoto.Statement, subject digest.Digest) bool {
+	alg := subject.Algorithm().String()
+	for _, sub := range s.Subject {
+		if strings.EqualFold(sub.Digest[alg], subject.Hex()) {
+			return true
+		}
+	}
+	return false
+}
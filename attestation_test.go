@@ -0,0 +1,202 @@
+package regclient
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/pkg/intoto"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestAttestation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo:v3")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+	mh, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	dImage := mh.GetDescriptor()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	al, err := rc.AttestationList(ctx, r)
+	if err != nil {
+		t.Fatalf("failed to list attestations: %v", err)
+	}
+	if len(al) != 0 {
+		t.Errorf("unexpected attestations: %v", al)
+	}
+
+	statement, err := json.Marshal(intoto.Statement{
+		Type:          intoto.StatementTypeV1,
+		Subject:       []intoto.Subject{{Name: "testrepo", Digest: map[string]string{"sha256": dImage.Digest.Hex()}}},
+		PredicateType: intoto.PredicateSLSAProvenance1,
+		Predicate:     json.RawMessage(`{"buildDefinition":{"buildType":"test"}}`),
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal statement: %v", err)
+	}
+	h := sha256.Sum256(intoto.PAE(intoto.PayloadType, statement))
+	sig, err := key.Sign(rand.Reader, h[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	envelope, err := json.Marshal(intoto.Envelope{
+		PayloadType: intoto.PayloadType,
+		Payload:     statement,
+		Signatures:  []intoto.Signature{{Sig: sig}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+	vuln, err := json.Marshal(intoto.Statement{
+		Type:          intoto.StatementTypeV01,
+		Subject:       []intoto.Subject{{Name: "testrepo", Digest: map[string]string{"sha256": dImage.Digest.Hex()}}},
+		PredicateType: intoto.PredicateVuln,
+		Predicate:     json.RawMessage(`{"scanner":{"uri":"test"}}`),
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal statement: %v", err)
+	}
+
+	// push a signed provenance attestation as a referrer
+	dConf := types.Descriptor{
+		MediaType: types.MediaTypeOCI1Empty,
+		Digest:    types.EmptyDigest,
+		Size:      int64(len(types.EmptyData)),
+	}
+	_, err = rc.BlobPut(ctx, r, dConf, bytes.NewReader(types.EmptyData))
+	if err != nil {
+		t.Fatalf("failed to push config: %v", err)
+	}
+	dEnv := types.Descriptor{
+		MediaType:   intoto.MediaTypeDSSE,
+		Digest:      digest.FromBytes(envelope),
+		Size:        int64(len(envelope)),
+		Annotations: map[string]string{intoto.AnnotationPredicateType: intoto.PredicateSLSAProvenance1},
+	}
+	_, err = rc.BlobPut(ctx, r, dEnv, bytes.NewReader(envelope))
+	if err != nil {
+		t.Fatalf("failed to push envelope: %v", err)
+	}
+	mRef, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned:    v1.ManifestSchemaVersion,
+		MediaType:    types.MediaTypeOCI1Manifest,
+		ArtifactType: intoto.MediaTypeDSSE,
+		Config:       dConf,
+		Layers:       []types.Descriptor{dEnv},
+		Subject:      &types.Descriptor{MediaType: dImage.MediaType, Digest: dImage.Digest, Size: dImage.Size},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	err = rc.ManifestPut(ctx, r.SetDigest(mRef.GetDescriptor().Digest.String()), mRef)
+	if err != nil {
+		t.Fatalf("failed to push referrer: %v", err)
+	}
+
+	// push an unsigned vuln attestation without annotations to the cosign tag
+	dVuln := types.Descriptor{
+		MediaType: intoto.MediaTypeStatement,
+		Digest:    digest.FromBytes(vuln),
+		Size:      int64(len(vuln)),
+	}
+	_, err = rc.BlobPut(ctx, r, dVuln, bytes.NewReader(vuln))
+	if err != nil {
+		t.Fatalf("failed to push statement: %v", err)
+	}
+	mAtt, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned: v1.ManifestSchemaVersion,
+		MediaType: types.MediaTypeOCI1Manifest,
+		Config:    dConf,
+		Layers:    []types.Descriptor{dVuln},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	err = rc.ManifestPut(ctx, r.SetTag(fmt.Sprintf("sha256-%s.att", dImage.Digest.Hex())), mAtt)
+	if err != nil {
+		t.Fatalf("failed to push cosign attestation: %v", err)
+	}
+
+	al, err = rc.AttestationList(ctx, r)
+	if err != nil {
+		t.Fatalf("failed to list attestations: %v", err)
+	}
+	if len(al) != 2 {
+		t.Fatalf("unexpected attestations: %v", al)
+	}
+	if al[0].Source != "referrer" || al[0].PredicateType != intoto.PredicateSLSAProvenance1 || !al[0].Signed || al[0].Subject != dImage.Digest {
+		t.Errorf("unexpected referrer attestation: %v", al[0])
+	}
+	if al[1].Source != "cosign" || al[1].PredicateType != intoto.PredicateVuln || al[1].Signed {
+		t.Errorf("unexpected cosign attestation: %v", al[1])
+	}
+
+	al, err = rc.AttestationList(ctx, r, AttestationWithPredicateType(intoto.PredicateVuln))
+	if err != nil {
+		t.Fatalf("failed to list attestations: %v", err)
+	}
+	if len(al) != 1 || al[0].PredicateType != intoto.PredicateVuln {
+		t.Fatalf("unexpected filtered attestations: %v", al)
+	}
+	ac, err := rc.AttestationGet(ctx, r, al[0])
+	if err != nil {
+		t.Fatalf("failed to get attestation: %v", err)
+	}
+	if ac.Verified || string(ac.Statement.Predicate) != `{"scanner":{"uri":"test"}}` {
+		t.Errorf("unexpected attestation content: %v", ac)
+	}
+	_, err = rc.AttestationGet(ctx, r, al[0], AttestationWithVerifyKey(key.Public()))
+	if !errors.Is(err, intoto.ErrVerifyFailed) {
+		t.Errorf("unexpected error verifying unsigned attestation: %v", err)
+	}
+
+	al, err = rc.AttestationList(ctx, r, AttestationWithPredicateType(intoto.PredicateSLSAProvenance1))
+	if err != nil || len(al) != 1 {
+		t.Fatalf("failed to list attestations: %v, %v", al, err)
+	}
+	ac, err = rc.AttestationGet(ctx, r, al[0], AttestationWithVerifyKey(key.Public()))
+	if err != nil {
+		t.Fatalf("failed to verify attestation: %v", err)
+	}
+	if !ac.Verified || ac.Statement.PredicateType != intoto.PredicateSLSAProvenance1 {
+		t.Errorf("unexpected attestation content: %v", ac)
+	}
+	_, err = rc.AttestationGet(ctx, r, al[0], AttestationWithVerifyKey(otherKey.Public()))
+	if !errors.Is(err, intoto.ErrVerifyFailed) {
+		t.Errorf("unexpected error verifying with another key: %v", err)
+	}
+}
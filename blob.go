@@ -3,10 +3,16 @@ package regclient
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 
 	"github.com/regclient/regclient/internal/throttle"
@@ -16,10 +22,30 @@ import (
 	"github.com/regclient/regclient/types/ref"
 )
 
-const blobCBFreq = time.Millisecond * 100
+const (
+	blobCBFreq     = time.Millisecond * 100
+	blobPartialExt = ".partial"
+	// blobParallelSize is the default size of each range in a parallel download
+	blobParallelSize = 64 * 1024 * 1024
+	// blobBulkConcurrency is the default number of concurrent requests in [RegClient.BlobBulkHead]
+	blobBulkConcurrency = 5
+)
+
+// blobCopyResult describes how a blob was handled by blobCopy.
+type blobCopyResult int
+
+const (
+	blobCopySkipped blobCopyResult = iota // blob already exists in the target
+	blobCopyMounted                       // blob was mounted from the source repository
+	blobCopyPushed                        // blob was pulled from the source and pushed to the target
+)
 
 type blobOpt struct {
-	callback func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)
+	callback     func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)
+	concurrency  int
+	parallel     int
+	parallelSize int64
+	verify       bool
 }
 
 // BlobOpts define options for the Image* commands.
@@ -32,15 +58,52 @@ func BlobWithCallback(callback func(kind types.CallbackKind, instance string, st
 	}
 }
 
+// BlobWithConcurrency limits the number of concurrent requests in [RegClient.BlobBulkHead].
+// Requests are also limited by the concurrency setting of the registry host.
+func BlobWithConcurrency(count int) BlobOpts {
+	return func(opts *blobOpt) {
+		opts.concurrency = count
+	}
+}
+
+// BlobWithParallel downloads large blobs with concurrent range requests in [RegClient.BlobGetFile].
+// The blob is split into ranges of the chunk size, with up to count requests running concurrently.
+// A chunk size of 0 uses a default of 64MiB, and only blobs larger than a single chunk are split.
+// Schemes and registries without range request support fall back to a single request.
+func BlobWithParallel(count int, chunkSize int64) BlobOpts {
+	return func(opts *blobOpt) {
+		if chunkSize <= 0 {
+			chunkSize = blobParallelSize
+		}
+		opts.parallel = count
+		opts.parallelSize = chunkSize
+	}
+}
+
+// BlobWithVerify verifies the content of blobs.
+// In [RegClient.BlobGet], closing the reader verifies the size and digest, reading any remaining content.
+// In [RegClient.BlobCopy], a blob that already exists in the target is read and hashed, and a blob with the wrong content is copied again instead of being skipped.
+func BlobWithVerify() BlobOpts {
+	return func(opts *blobOpt) {
+		opts.verify = true
+	}
+}
+
 // BlobCopy copies a blob between two locations.
 // If the blob already exists in the target, the copy is skipped.
 // A server side cross repository blob mount is attempted.
 func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor, opts ...BlobOpts) error {
+	_, err := rc.blobCopy(ctx, refSrc, refTgt, d, opts...)
+	return err
+}
+
+// blobCopy implements BlobCopy, returning how the blob was copied.
+func (rc *RegClient) blobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor, opts ...BlobOpts) (blobCopyResult, error) {
 	if !refSrc.IsSetRepo() {
-		return fmt.Errorf("refSrc is not set: %s%.0w", refSrc.CommonName(), types.ErrInvalidReference)
+		return blobCopySkipped, fmt.Errorf("refSrc is not set: %s%.0w", refSrc.CommonName(), types.ErrInvalidReference)
 	}
 	if !refTgt.IsSetRepo() {
-		return fmt.Errorf("refTgt is not set: %s%.0w", refTgt.CommonName(), types.ErrInvalidReference)
+		return blobCopySkipped, fmt.Errorf("refTgt is not set: %s%.0w", refTgt.CommonName(), types.ErrInvalidReference)
 	}
 	var opt blobOpt
 	for _, optFn := range opts {
@@ -61,28 +124,42 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 			"tgt":    refTgt.Reference,
 			"digest": d.Digest,
 		}).Debug("Blob copy skipped, same repo")
-		return nil
+		return blobCopySkipped, nil
 	}
 	// check if layer already exists
 	if _, err := rc.BlobHead(ctx, refTgt, tDesc); err == nil {
-		if opt.callback != nil {
-			opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackSkipped, 0, d.Size)
+		verified := true
+		if opt.verify {
+			if err := rc.blobVerify(ctx, refTgt, tDesc); err != nil {
+				// a corrupt blob is pushed again to repair the target
+				rc.log.WithFields(logrus.Fields{
+					"tgt":    refTgt.Reference,
+					"digest": d.Digest,
+					"err":    err,
+				}).Warn("Blob verification failed, copying blob again")
+				verified = false
+			}
+		}
+		if verified {
+			if opt.callback != nil {
+				opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackSkipped, 0, d.Size)
+			}
+			rc.log.WithFields(logrus.Fields{
+				"tgt":    refTgt.Reference,
+				"digest": d,
+			}).Debug("Blob copy skipped, already exists")
+			return blobCopySkipped, nil
 		}
-		rc.log.WithFields(logrus.Fields{
-			"tgt":    refTgt.Reference,
-			"digest": d,
-		}).Debug("Blob copy skipped, already exists")
-		return nil
 	}
 	// acquire throttle for both src and tgt to avoid deadlocks
 	tList := []*throttle.Throttle{}
 	schemeSrcAPI, err := rc.schemeGet(refSrc.Scheme)
 	if err != nil {
-		return err
+		return blobCopySkipped, err
 	}
 	schemeTgtAPI, err := rc.schemeGet(refTgt.Scheme)
 	if err != nil {
-		return err
+		return blobCopySkipped, err
 	}
 	if tSrc, ok := schemeSrcAPI.(scheme.Throttler); ok {
 		tList = append(tList, tSrc.Throttle(refSrc, false)...)
@@ -93,7 +170,7 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 	if len(tList) > 0 {
 		ctx, err = throttle.AcquireMulti(ctx, tList)
 		if err != nil {
-			return err
+			return blobCopySkipped, err
 		}
 		defer throttle.ReleaseMulti(ctx, tList)
 	}
@@ -110,7 +187,7 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 				"tgt":    refTgt.Reference,
 				"digest": d,
 			}).Debug("Blob copy performed server side with registry mount")
-			return nil
+			return blobCopyMounted, nil
 		}
 		rc.log.WithFields(logrus.Fields{
 			"err": err,
@@ -126,7 +203,7 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 			"src":    refSrc.Reference,
 			"digest": d,
 		}).Warn("Failed to retrieve blob")
-		return err
+		return blobCopySkipped, err
 	}
 	if opt.callback != nil {
 		opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackStarted, 0, d.Size)
@@ -160,9 +237,9 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 			"src": refSrc.Reference,
 			"tgt": refTgt.Reference,
 		}).Warn("Failed to push blob")
-		return err
+		return blobCopySkipped, err
 	}
-	return nil
+	return blobCopyPushed, nil
 }
 
 // BlobDelete removes a blob from the registry.
@@ -181,10 +258,17 @@ func (rc *RegClient) BlobDelete(ctx context.Context, r ref.Ref, d types.Descript
 
 // BlobGet retrieves a blob, returning a reader.
 // This reader must be closed to free up resources that limit concurrent pulls.
-func (rc *RegClient) BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor) (blob.Reader, error) {
+// The content is verified when read to the EOF, and [BlobWithVerify] also verifies the content when the reader is closed.
+func (rc *RegClient) BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor, opts ...BlobOpts) (blob.Reader, error) {
+	var opt blobOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
 	data, err := d.GetData()
 	if err == nil {
-		return blob.NewReader(blob.WithDesc(d), blob.WithRef(r), blob.WithReader(bytes.NewReader(data))), nil
+		b := blob.NewReader(blob.WithDesc(d), blob.WithRef(r), blob.WithReader(bytes.NewReader(data)))
+		b.SetVerify(opt.verify)
+		return b, nil
 	}
 	if !r.IsSetRepo() {
 		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
@@ -193,7 +277,372 @@ func (rc *RegClient) BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor)
 	if err != nil {
 		return nil, err
 	}
-	return schemeAPI.BlobGet(ctx, r, d)
+	b, err := schemeAPI.BlobGet(ctx, r, d)
+	if err != nil {
+		return nil, err
+	}
+	b.SetVerify(opt.verify)
+	return b, nil
+}
+
+// BlobGetRange returns a reader for part of a blob, starting at the offset, with up to length bytes.
+// A negative length reads to the end of the blob.
+// When the scheme or registry does not support range requests, the blob is pulled and the content before the offset is discarded.
+// The content of a range is not verified against the digest.
+func (rc *RegClient) BlobGetRange(ctx context.Context, r ref.Ref, d types.Descriptor, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid blob range offset %d", offset)
+	}
+	if d.Size > 0 && offset > d.Size {
+		return nil, fmt.Errorf("blob range offset %d exceeds size %d%.0w", offset, d.Size, io.ErrUnexpectedEOF)
+	}
+	rdr, err := rc.blobGetRange(ctx, r, d, offset, length)
+	if err == nil {
+		if length >= 0 {
+			return blobRangeReader{Reader: io.LimitReader(rdr, length), Closer: rdr}, nil
+		}
+		return rdr, nil
+	}
+	if !errors.Is(err, types.ErrUnsupported) {
+		return nil, err
+	}
+	rc.log.WithFields(logrus.Fields{
+		"ref":    r.CommonName(),
+		"digest": d.Digest.String(),
+		"offset": offset,
+		"err":    err,
+	}).Debug("Range requests unsupported, discarding content before the offset")
+	b, err := rc.BlobGet(ctx, r, d)
+	if err != nil {
+		return nil, err
+	}
+	n, err := io.CopyN(io.Discard, b, offset)
+	if err != nil {
+		_ = b.Close()
+		return nil, fmt.Errorf("failed to skip to offset %d, read %d bytes: %w", offset, n, err)
+	}
+	if length >= 0 {
+		return blobRangeReader{Reader: io.LimitReader(b, length), Closer: b}, nil
+	}
+	return b, nil
+}
+
+// blobRangeReader limits the content read from a blob range.
+type blobRangeReader struct {
+	io.Reader
+	io.Closer
+}
+
+// BlobGetFile downloads a blob to a local file.
+// The content is written to the filename with a ".partial" suffix, synced, and renamed after the digest is verified.
+// When a partial file remains from an interrupted download, the download resumes with a range request if the scheme supports it.
+// Large blobs may be downloaded with concurrent range requests using [BlobWithParallel].
+func (rc *RegClient) BlobGetFile(ctx context.Context, r ref.Ref, d types.Descriptor, filename string, opts ...BlobOpts) error {
+	if d.Digest == "" {
+		return fmt.Errorf("digest is required to download a blob to a file%.0w", types.ErrMissingDigest)
+	}
+	if err := d.Digest.Validate(); err != nil {
+		return fmt.Errorf("invalid digest %s: %w", d.Digest.String(), err)
+	}
+	var opt blobOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	partial := filename + blobPartialExt
+	//#nosec G304 the filename is provided by the user of the library
+	fh, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", partial, err)
+	}
+	defer func() {
+		if fh != nil {
+			_ = fh.Close()
+		}
+	}()
+
+	// hash any previously downloaded content so the full file is verified
+	digester := d.Digest.Algorithm().Digester()
+	offset, err := io.Copy(digester.Hash(), fh)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", partial, err)
+	}
+	restart := func() error {
+		if err := fh.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate %s: %w", partial, err)
+		}
+		digester = d.Digest.Algorithm().Digester()
+		offset = 0
+		return nil
+	}
+	if d.Size > 0 && offset > d.Size {
+		if err := restart(); err != nil {
+			return err
+		}
+	}
+	var progress atomic.Int64
+	progress.Store(offset)
+	stopProgress := func() {}
+	if opt.callback != nil {
+		opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackStarted, offset, d.Size)
+		stopProgress = blobProgress(opt, d, &progress)
+		defer stopProgress()
+	}
+
+	// download large blobs with concurrent range requests
+	if opt.parallel > 1 && d.Size > 0 && d.Size-offset > opt.parallelSize {
+		end, err := rc.blobGetParallel(ctx, r, d, fh, offset, opt, &progress)
+		if end > offset {
+			// ranges complete out of order, so the new content is hashed after it is written
+			if _, err := fh.Seek(offset, io.SeekStart); err != nil {
+				return fmt.Errorf("failed to seek %s: %w", partial, err)
+			}
+			if _, err := io.CopyN(digester.Hash(), fh, end-offset); err != nil {
+				return fmt.Errorf("failed to read %s: %w", partial, err)
+			}
+			offset = end
+		}
+		if err != nil && !errors.Is(err, types.ErrUnsupported) {
+			_ = fh.Sync()
+			return fmt.Errorf("failed to download blob %s: %w", d.Digest.String(), err)
+		} else if err != nil {
+			rc.log.WithFields(logrus.Fields{
+				"ref":    r.CommonName(),
+				"digest": d.Digest.String(),
+				"err":    err,
+			}).Debug("Range requests unsupported, falling back to a single request")
+		}
+	}
+
+	var rdr io.ReadCloser
+	if offset > 0 && (d.Size <= 0 || offset < d.Size) {
+		rdr, err = rc.blobGetRange(ctx, r, d, offset, -1)
+		if err != nil {
+			rc.log.WithFields(logrus.Fields{
+				"ref":    r.CommonName(),
+				"digest": d.Digest.String(),
+				"offset": offset,
+				"err":    err,
+			}).Debug("Unable to resume blob download, restarting")
+			rdr = nil
+		}
+	}
+	if rdr == nil && (d.Size <= 0 || offset != d.Size) {
+		// restart the download from the beginning
+		if offset > 0 {
+			if err := restart(); err != nil {
+				return err
+			}
+			progress.Store(0)
+		}
+		br, err := rc.BlobGet(ctx, r, d)
+		if err != nil {
+			return err
+		}
+		rdr = br
+	}
+
+	// copy the remaining content, leaving the partial file for a later resume on failure
+	size := offset
+	if rdr != nil {
+		if _, err := fh.Seek(offset, io.SeekStart); err != nil {
+			_ = rdr.Close()
+			return fmt.Errorf("failed to seek %s: %w", partial, err)
+		}
+		bw := &blobWriteCounter{w: io.MultiWriter(fh, digester.Hash()), progress: &progress}
+		n, err := io.Copy(bw, rdr)
+		errC := rdr.Close()
+		size += n
+		if err == nil {
+			err = errC
+		}
+		if err != nil {
+			_ = fh.Sync()
+			return fmt.Errorf("failed to download blob %s: %w", d.Digest.String(), err)
+		}
+	}
+	err = fh.Sync()
+	if err != nil {
+		return fmt.Errorf("failed to sync %s: %w", partial, err)
+	}
+	err = fh.Close()
+	fh = nil
+	if err != nil {
+		return fmt.Errorf("failed to close %s: %w", partial, err)
+	}
+
+	// verify the content before renaming, corrupt partial files are removed to avoid resuming from them
+	if d.Size > 0 && size != d.Size {
+		_ = os.Remove(partial)
+		return fmt.Errorf("unexpected blob length, expected %d, received %d%.0w", d.Size, size, types.ErrMismatch)
+	}
+	if digester.Digest() != d.Digest {
+		_ = os.Remove(partial)
+		return fmt.Errorf("unexpected digest, expected %s, computed %s%.0w", d.Digest.String(), digester.Digest().String(), types.ErrDigestMismatch)
+	}
+	err = os.Rename(partial, filename)
+	if err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", partial, filename, err)
+	}
+	if opt.callback != nil {
+		stopProgress()
+		opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackFinished, size, size)
+	}
+	return nil
+}
+
+// blobRange is a part of a blob downloaded in parallel.
+type blobRange struct {
+	start, end int64
+	written    atomic.Int64
+}
+
+// blobGetParallel downloads the blob from the offset to the end with concurrent range requests written directly to the file.
+// The returned offset is the end of the content written without any gaps, allowing an interrupted download to be resumed.
+func (rc *RegClient) blobGetParallel(ctx context.Context, r ref.Ref, d types.Descriptor, fh *os.File, offset int64, opt blobOpt, progress *atomic.Int64) (int64, error) {
+	ranges := []*blobRange{}
+	for start := offset; start < d.Size; start += opt.parallelSize {
+		end := start + opt.parallelSize
+		if end > d.Size {
+			end = d.Size
+		}
+		ranges = append(ranges, &blobRange{start: start, end: end})
+	}
+	queue := make(chan *blobRange, len(ranges))
+	for _, br := range ranges {
+		queue <- br
+	}
+	close(queue)
+	workers := opt.parallel
+	if workers > len(ranges) {
+		workers = len(ranges)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var err error
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for br := range queue {
+				if ctx.Err() != nil {
+					return
+				}
+				if errRange := rc.blobGetParallelRange(ctx, r, d, fh, br, progress); errRange != nil {
+					errOnce.Do(func() {
+						err = errRange
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// find the end of the content without any gaps, and discard everything after it
+	end := offset
+	for _, br := range ranges {
+		end = br.start + br.written.Load()
+		if end < br.end {
+			break
+		}
+	}
+	if end < d.Size {
+		if errT := fh.Truncate(end); errT != nil {
+			return offset, fmt.Errorf("failed to truncate partial download: %w", errT)
+		}
+		progress.Store(end)
+		if err == nil {
+			err = fmt.Errorf("download ended at %d of %d bytes%.0w", end, d.Size, io.ErrUnexpectedEOF)
+		}
+	}
+	return end, err
+}
+
+func (rc *RegClient) blobGetParallelRange(ctx context.Context, r ref.Ref, d types.Descriptor, fh *os.File, br *blobRange, progress *atomic.Int64) error {
+	length := br.end - br.start
+	rdr, err := rc.blobGetRange(ctx, r, d, br.start, length)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+	bw := &blobWriteAt{w: fh, offset: br.start, written: &br.written, progress: progress}
+	n, err := io.Copy(bw, io.LimitReader(rdr, length))
+	if err != nil {
+		return err
+	}
+	if n != length {
+		return fmt.Errorf("range %d-%d returned %d bytes%.0w", br.start, br.end-1, n, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// blobProgress sends active callbacks with the current progress until the returned function is called.
+func blobProgress(opt blobOpt, d types.Descriptor, progress *atomic.Int64) func() {
+	ticker := time.NewTicker(blobCBFreq)
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackActive, progress.Load(), d.Size)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			ticker.Stop()
+		})
+	}
+}
+
+// blobGetRange returns part of a blob from schemes that support range requests.
+func (rc *RegClient) blobGetRange(ctx context.Context, r ref.Ref, d types.Descriptor, offset, length int64) (io.ReadCloser, error) {
+	if !r.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	sr, ok := schemeAPI.(scheme.BlobRanger)
+	if !ok {
+		return nil, fmt.Errorf("range requests are not supported for scheme %s%.0w", r.Scheme, types.ErrUnsupported)
+	}
+	return sr.BlobGetRange(ctx, r, d, offset, length)
+}
+
+// blobWriteCounter tracks the bytes written for progress callbacks.
+type blobWriteCounter struct {
+	w        io.Writer
+	progress *atomic.Int64
+}
+
+func (bw *blobWriteCounter) Write(p []byte) (int, error) {
+	n, err := bw.w.Write(p)
+	bw.progress.Add(int64(n))
+	return n, err
+}
+
+// blobWriteAt writes a range of the blob at an offset in the file.
+type blobWriteAt struct {
+	w        io.WriterAt
+	offset   int64
+	written  *atomic.Int64
+	progress *atomic.Int64
+}
+
+func (bw *blobWriteAt) Write(p []byte) (int, error) {
+	n, err := bw.w.WriteAt(p, bw.offset+bw.written.Load())
+	bw.written.Add(int64(n))
+	bw.progress.Add(int64(n))
+	return n, err
 }
 
 // BlobGetOCIConfig retrieves an OCI config from a blob, automatically extracting the JSON.
@@ -205,7 +654,18 @@ func (rc *RegClient) BlobGetOCIConfig(ctx context.Context, r ref.Ref, d types.De
 	if err != nil {
 		return nil, err
 	}
-	return b.ToOCIConfig()
+	oc, err := b.ToOCIConfig()
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range oc.GetWarnings() {
+		rc.log.WithFields(logrus.Fields{
+			"ref":     r.CommonName(),
+			"digest":  d.Digest.String(),
+			"warning": w,
+		}).Warn("Normalized legacy image config")
+	}
+	return oc, nil
 }
 
 // BlobHead is used to verify if a blob exists and is accessible.
@@ -220,6 +680,93 @@ func (rc *RegClient) BlobHead(ctx context.Context, r ref.Ref, d types.Descriptor
 	return schemeAPI.BlobHead(ctx, r, d)
 }
 
+// BlobBulkHead checks if each blob exists in the repository, returning a map from each digest to its presence.
+// Requests run concurrently, see [BlobWithConcurrency].
+// A blob that is not found is false in the map.
+// Any other error, e.g. an authentication failure, or canceling the context stops the remaining requests and is returned.
+func (rc *RegClient) BlobBulkHead(ctx context.Context, r ref.Ref, digests []digest.Digest, opts ...BlobOpts) (map[digest.Digest]bool, error) {
+	if !r.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	opt := blobOpt{
+		concurrency: blobBulkConcurrency,
+	}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	if opt.concurrency <= 0 {
+		opt.concurrency = 1
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	// skip duplicate digests
+	found := map[digest.Digest]bool{}
+	queue := make(chan digest.Digest, len(digests))
+	for _, d := range digests {
+		if _, ok := found[d]; ok {
+			continue
+		}
+		found[d] = false
+		queue <- d
+	}
+	close(queue)
+	workers := opt.concurrency
+	if workers > len(found) {
+		workers = len(found)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errOnce sync.Once
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range queue {
+				if ctx.Err() != nil {
+					return
+				}
+				br, errHead := schemeAPI.BlobHead(ctx, r, types.Descriptor{Digest: d})
+				if errHead == nil {
+					_ = br.Close()
+					mu.Lock()
+					found[d] = true
+					mu.Unlock()
+				} else if !errors.Is(errHead, types.ErrNotFound) && !errors.Is(errHead, fs.ErrNotExist) {
+					errOnce.Do(func() {
+						err = fmt.Errorf("failed to check blob %s in %s: %w", d.String(), r.CommonName(), errHead)
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err == nil {
+		// a canceled context leaves digests unchecked
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// blobVerify reads the full blob, returning an error when the size or digest do not match the descriptor.
+func (rc *RegClient) blobVerify(ctx context.Context, r ref.Ref, d types.Descriptor) error {
+	rdr, err := rc.BlobGet(ctx, r, d)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+	_, err = io.Copy(io.Discard, rdr)
+	return err
+}
+
 // BlobMount attempts to perform a server side copy/mount of the blob between repositories.
 func (rc *RegClient) BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor) error {
 	if !refSrc.IsSetRepo() {
@@ -242,10 +789,20 @@ func (rc *RegClient) BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.R
 // This will attempt an anonymous blob mount first which some registries may support.
 // It will then try doing a full put of the blob without chunking (most widely supported).
 // If the full put fails, it will fall back to a chunked upload (useful for flaky networks).
-func (rc *RegClient) BlobPut(ctx context.Context, r ref.Ref, d types.Descriptor, rdr io.Reader) (types.Descriptor, error) {
+func (rc *RegClient) BlobPut(ctx context.Context, r ref.Ref, d types.Descriptor, rdr io.Reader) (dOut types.Descriptor, err error) {
 	if !r.IsSetRepo() {
 		return types.Descriptor{}, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
 	}
+	if err := d.Validate(types.ValidateOpt{AllowUnset: true}); err != nil {
+		return types.Descriptor{}, fmt.Errorf("failed to put blob to %s: %w", r.CommonName(), err)
+	}
+	ctx, span := rc.traceStart(ctx, "BlobPut", r)
+	defer func() {
+		if err == nil {
+			span.SetAttributes(traceDesc(dOut)...)
+		}
+		span.End(err)
+	}()
 	schemeAPI, err := rc.schemeGet(r.Scheme)
 	if err != nil {
 		return types.Descriptor{}, err
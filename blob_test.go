@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -82,6 +83,16 @@ func TestBlobGet(t *testing.T) {
 				Status: http.StatusNotFound,
 			},
 		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "HEAD Missing",
+				Method: "HEAD",
+				Path:   "/v2" + blobRepo + "/blobs/" + dMissing.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusNotFound,
+			},
+		},
 		// TODO: test unauthorized
 		// TODO: test range read
 		// head for d2
@@ -150,6 +161,16 @@ func TestBlobGet(t *testing.T) {
 				Status: http.StatusForbidden,
 			},
 		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "HEAD Forbidden",
+				Method: "HEAD",
+				Path:   "/v2" + privateRepo + "/blobs/" + d1.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusForbidden,
+			},
+		},
 	}
 	rrs = append(rrs, reqresp.BaseEntries...)
 	// create a server
@@ -244,6 +265,59 @@ func TestBlobGet(t *testing.T) {
 		}
 	})
 
+	t.Run("BulkHead", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		found, err := rc.BlobBulkHead(ctx, r, []digest.Digest{d1, dMissing, d2, d1}, BlobWithConcurrency(2))
+		if err != nil {
+			t.Fatalf("Failed running BlobBulkHead: %v", err)
+		}
+		expect := map[digest.Digest]bool{d1: true, d2: true, dMissing: false}
+		if len(found) != len(expect) {
+			t.Errorf("unexpected result: %v", found)
+		}
+		for d, e := range expect {
+			if f, ok := found[d]; !ok || f != e {
+				t.Errorf("unexpected result for %s: expected %t, received %t, %t", d, e, f, ok)
+			}
+		}
+		rPrivate, err := ref.New(tsURL.Host + privateRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		_, err = rc.BlobBulkHead(ctx, rPrivate, []digest.Digest{d1})
+		if !errors.Is(err, types.ErrHTTPUnauthorized) {
+			t.Errorf("Error does not match \"ErrUnauthorized\": %v", err)
+		}
+		ctxCancel, cancel := context.WithCancel(ctx)
+		cancel()
+		found, err = rc.BlobBulkHead(ctxCancel, r, []digest.Digest{d1, d2})
+		if !errors.Is(err, context.Canceled) || found != nil {
+			t.Errorf("canceled context did not fail: %v, %v", found, err)
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		ref, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := rc.BlobGet(ctx, ref, types.Descriptor{Digest: d1}, BlobWithVerify())
+		if err != nil {
+			t.Fatalf("Failed running BlobGet: %v", err)
+		}
+		_, err = br.Read(make([]byte, 10))
+		if err != nil {
+			t.Errorf("Failed reading blob: %v", err)
+		}
+		err = br.Close()
+		if err != nil {
+			t.Errorf("Failed to verify blob on close: %v", err)
+		}
+	})
+
 	t.Run("Missing", func(t *testing.T) {
 		ref, err := ref.New(tsURL.Host + blobRepo)
 		if err != nil {
@@ -299,6 +373,418 @@ func TestBlobGet(t *testing.T) {
 
 }
 
+func TestBlobGetFile(t *testing.T) {
+	t.Parallel()
+	blobRepo := "/proj/repo"
+	ctx := context.Background()
+	seed := time.Now().UTC().Unix()
+	t.Logf("Using seed %d", seed)
+	blobLen := 1024
+	partLen := 512
+	d1, blob1 := reqresp.NewRandomBlob(blobLen, seed)
+	d2, blob2 := reqresp.NewRandomBlob(blobLen, seed+1)
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GET for d1, range for second part",
+				Method: "GET",
+				Path:   "/v2" + blobRepo + "/blobs/" + d1.String(),
+				Headers: http.Header{
+					"Range": {fmt.Sprintf("bytes=%d-", partLen)},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusPartialContent,
+				Body:   blob1[partLen:],
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", blobLen-partLen)},
+					"Content-Range":  {fmt.Sprintf("bytes %d-%d/%d", partLen, blobLen-1, blobLen)},
+					"Content-Type":   {"application/octet-stream"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GET for d1",
+				Method: "GET",
+				Path:   "/v2" + blobRepo + "/blobs/" + d1.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   blob1,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", blobLen)},
+					"Content-Type":          {"application/octet-stream"},
+					"Docker-Content-Digest": {d1.String()},
+				},
+			},
+		},
+		// d2 ignores range requests
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GET for d2",
+				Method: "GET",
+				Path:   "/v2" + blobRepo + "/blobs/" + d2.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   blob2,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", blobLen)},
+					"Content-Type":          {"application/octet-stream"},
+					"Docker-Content-Digest": {d2.String()},
+				},
+			},
+		},
+	}
+	rrs = append(rrs, reqresp.BaseEntries...)
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []config.Host{
+		{
+			Name:      tsHost,
+			Hostname:  tsHost,
+			TLS:       config.TLSDisabled,
+			ReqPerSec: 100,
+		},
+	}
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.WarnLevel,
+	}
+	delayInit, _ := time.ParseDuration("0.05s")
+	delayMax, _ := time.ParseDuration("0.10s")
+	rc := New(
+		WithConfigHost(rcHosts...),
+		WithLog(log),
+		WithRetryDelay(delayInit, delayMax),
+	)
+	r, err := ref.New(tsHost + blobRepo)
+	if err != nil {
+		t.Fatalf("failed creating ref: %v", err)
+	}
+	rOCI, err := ref.New("ocidir://" + t.TempDir() + "/repo")
+	if err != nil {
+		t.Fatalf("failed creating ref: %v", err)
+	}
+	_, err = rc.BlobPut(ctx, rOCI, types.Descriptor{Digest: d1, Size: int64(blobLen)}, bytes.NewReader(blob1))
+	if err != nil {
+		t.Fatalf("failed to push blob to ocidir: %v", err)
+	}
+
+	tt := []struct {
+		name       string
+		r          ref.Ref
+		d          types.Descriptor
+		partial    []byte
+		opts       []BlobOpts
+		expectBlob []byte
+		expectErr  error
+	}{
+		{
+			name:       "full",
+			r:          r,
+			d:          types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			expectBlob: blob1,
+		},
+		{
+			name:       "resume",
+			r:          r,
+			d:          types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			partial:    blob1[:partLen],
+			expectBlob: blob1,
+		},
+		{
+			name:       "resume unknown size",
+			r:          r,
+			d:          types.Descriptor{Digest: d1},
+			partial:    blob1[:partLen],
+			expectBlob: blob1,
+		},
+		{
+			name:       "complete partial",
+			r:          r,
+			d:          types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			partial:    blob1,
+			expectBlob: blob1,
+		},
+		{
+			name:       "range unsupported",
+			r:          r,
+			d:          types.Descriptor{Digest: d2, Size: int64(blobLen)},
+			partial:    blob2[:partLen],
+			expectBlob: blob2,
+		},
+		{
+			name:       "partial too large",
+			r:          r,
+			d:          types.Descriptor{Digest: d2, Size: int64(blobLen)},
+			partial:    append(append([]byte{}, blob2...), blob2[:partLen]...),
+			expectBlob: blob2,
+		},
+		{
+			name:      "corrupt partial",
+			r:         r,
+			d:         types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			partial:   blob2[:partLen],
+			expectErr: types.ErrDigestMismatch,
+		},
+		{
+			name:      "missing digest",
+			r:         r,
+			d:         types.Descriptor{Size: int64(blobLen)},
+			expectErr: types.ErrMissingDigest,
+		},
+		{
+			name:       "ocidir resume",
+			r:          rOCI,
+			d:          types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			partial:    blob1[:partLen],
+			expectBlob: blob1,
+		},
+		{
+			name:       "ocidir parallel",
+			r:          rOCI,
+			d:          types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			opts:       []BlobOpts{BlobWithParallel(3, 100)},
+			expectBlob: blob1,
+		},
+		{
+			name:       "ocidir parallel resume",
+			r:          rOCI,
+			d:          types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			partial:    blob1[:partLen],
+			opts:       []BlobOpts{BlobWithParallel(2, 100)},
+			expectBlob: blob1,
+		},
+		{
+			name:       "parallel range unsupported",
+			r:          r,
+			d:          types.Descriptor{Digest: d2, Size: int64(blobLen)},
+			opts:       []BlobOpts{BlobWithParallel(3, 100)},
+			expectBlob: blob2,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "blob")
+			if tc.partial != nil {
+				err := os.WriteFile(filename+blobPartialExt, tc.partial, 0600)
+				if err != nil {
+					t.Fatalf("failed to write partial file: %v", err)
+				}
+			}
+			finished := false
+			opts := append([]BlobOpts{BlobWithCallback(func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64) {
+				if state == types.CallbackFinished {
+					finished = true
+				}
+			})}, tc.opts...)
+			err := rc.BlobGetFile(ctx, tc.r, tc.d, filename, opts...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				if _, err := os.Stat(filename); err == nil {
+					t.Errorf("file created after failure")
+				}
+				if _, err := os.Stat(filename + blobPartialExt); err == nil && tc.partial != nil {
+					t.Errorf("corrupt partial file was not removed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get file: %v", err)
+			}
+			b, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+			if !bytes.Equal(b, tc.expectBlob) {
+				t.Errorf("file content does not match")
+			}
+			if _, err := os.Stat(filename + blobPartialExt); err == nil {
+				t.Errorf("partial file was not removed")
+			}
+			if !finished {
+				t.Errorf("finished callback not received")
+			}
+		})
+	}
+}
+
+func TestBlobGetRange(t *testing.T) {
+	t.Parallel()
+	blobRepo := "/proj/repo"
+	ctx := context.Background()
+	seed := time.Now().UTC().Unix()
+	t.Logf("Using seed %d", seed)
+	blobLen := 1024
+	d1, blob1 := reqresp.NewRandomBlob(blobLen, seed)
+	d2, blob2 := reqresp.NewRandomBlob(blobLen, seed+1)
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GET for d1, range",
+				Method: "GET",
+				Path:   "/v2" + blobRepo + "/blobs/" + d1.String(),
+				Headers: http.Header{
+					"Range": {"bytes=100-199"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusPartialContent,
+				Body:   blob1[100:200],
+				Headers: http.Header{
+					"Content-Length": {"100"},
+					"Content-Range":  {fmt.Sprintf("bytes 100-199/%d", blobLen)},
+					"Content-Type":   {"application/octet-stream"},
+				},
+			},
+		},
+		// d2 ignores range requests
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GET for d2",
+				Method: "GET",
+				Path:   "/v2" + blobRepo + "/blobs/" + d2.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   blob2,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", blobLen)},
+					"Content-Type":          {"application/octet-stream"},
+					"Docker-Content-Digest": {d2.String()},
+				},
+			},
+		},
+	}
+	rrs = append(rrs, reqresp.BaseEntries...)
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []config.Host{
+		{
+			Name:      tsHost,
+			Hostname:  tsHost,
+			TLS:       config.TLSDisabled,
+			ReqPerSec: 100,
+		},
+	}
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.WarnLevel,
+	}
+	delayInit, _ := time.ParseDuration("0.05s")
+	delayMax, _ := time.ParseDuration("0.10s")
+	rc := New(
+		WithConfigHost(rcHosts...),
+		WithLog(log),
+		WithRetryDelay(delayInit, delayMax),
+	)
+	r, err := ref.New(tsHost + blobRepo)
+	if err != nil {
+		t.Fatalf("failed creating ref: %v", err)
+	}
+	rOCI, err := ref.New("ocidir://" + t.TempDir() + "/repo")
+	if err != nil {
+		t.Fatalf("failed creating ref: %v", err)
+	}
+	_, err = rc.BlobPut(ctx, rOCI, types.Descriptor{Digest: d1, Size: int64(blobLen)}, bytes.NewReader(blob1))
+	if err != nil {
+		t.Fatalf("failed to push blob to ocidir: %v", err)
+	}
+
+	tt := []struct {
+		name       string
+		r          ref.Ref
+		d          types.Descriptor
+		offset     int64
+		length     int64
+		expectBlob []byte
+		expectErr  bool
+	}{
+		{
+			name:       "registry range",
+			r:          r,
+			d:          types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			offset:     100,
+			length:     100,
+			expectBlob: blob1[100:200],
+		},
+		{
+			name:       "registry range unsupported",
+			r:          r,
+			d:          types.Descriptor{Digest: d2, Size: int64(blobLen)},
+			offset:     100,
+			length:     100,
+			expectBlob: blob2[100:200],
+		},
+		{
+			name:       "registry range unsupported to end",
+			r:          r,
+			d:          types.Descriptor{Digest: d2, Size: int64(blobLen)},
+			offset:     1000,
+			length:     -1,
+			expectBlob: blob2[1000:],
+		},
+		{
+			name:       "ocidir range",
+			r:          rOCI,
+			d:          types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			offset:     512,
+			length:     10,
+			expectBlob: blob1[512:522],
+		},
+		{
+			name:      "offset past end",
+			r:         rOCI,
+			d:         types.Descriptor{Digest: d1, Size: int64(blobLen)},
+			offset:    int64(blobLen) + 1,
+			length:    -1,
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rdr, err := rc.BlobGetRange(ctx, tc.r, tc.d, tc.offset, tc.length)
+			if tc.expectErr {
+				if err == nil {
+					_ = rdr.Close()
+					t.Errorf("did not receive expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get range: %v", err)
+			}
+			b, err := io.ReadAll(rdr)
+			if err != nil {
+				t.Errorf("failed to read range: %v", err)
+			}
+			err = rdr.Close()
+			if err != nil {
+				t.Errorf("failed to close range: %v", err)
+			}
+			if !bytes.Equal(b, tc.expectBlob) {
+				t.Errorf("unexpected content, expected %d bytes, received %d", len(tc.expectBlob), len(b))
+			}
+		})
+	}
+}
+
 func TestBlobPut(t *testing.T) {
 	t.Parallel()
 	blobRepo := "/proj/repo"
@@ -735,6 +1221,18 @@ func TestBlobPut(t *testing.T) {
 		}
 
 	})
+
+	t.Run("InvalidDescriptor", func(t *testing.T) {
+		ref, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Errorf("Failed creating ref: %v", err)
+		}
+		br := bytes.NewReader(blob1)
+		_, err = rc.BlobPut(ctx, ref, types.Descriptor{Digest: d1, Size: -1}, br)
+		if !errors.Is(err, types.ErrInvalidDescriptor) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrInvalidDescriptor, err)
+		}
+	})
 }
 
 func TestBlobCopy(t *testing.T) {
@@ -0,0 +1,57 @@
+package regclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
+)
+
+// ManifestClient is the subset of [RegClient] methods for manifests.
+// Applications may accept this interface to replace regclient with a mock in unit tests.
+type ManifestClient interface {
+	ManifestDelete(ctx context.Context, r ref.Ref, opts ...ManifestOpts) error
+	ManifestGet(ctx context.Context, r ref.Ref, opts ...ManifestOpts) (manifest.Manifest, error)
+	ManifestHead(ctx context.Context, r ref.Ref, opts ...ManifestOpts) (manifest.Manifest, error)
+	ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest, opts ...ManifestOpts) error
+}
+
+// BlobClient is the subset of [RegClient] methods for blobs.
+type BlobClient interface {
+	BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor, opts ...BlobOpts) error
+	BlobDelete(ctx context.Context, r ref.Ref, d types.Descriptor) error
+	BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor, opts ...BlobOpts) (blob.Reader, error)
+	BlobGetOCIConfig(ctx context.Context, r ref.Ref, d types.Descriptor) (blob.OCIConfig, error)
+	BlobHead(ctx context.Context, r ref.Ref, d types.Descriptor) (blob.Reader, error)
+	BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor) error
+	BlobPut(ctx context.Context, r ref.Ref, d types.Descriptor, rdr io.Reader) (types.Descriptor, error)
+}
+
+// TagClient is the subset of [RegClient] methods for tags.
+type TagClient interface {
+	TagDelete(ctx context.Context, r ref.Ref) error
+	TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error)
+}
+
+// ImageClient is the subset of [RegClient] methods for images.
+type ImageClient interface {
+	ImageCheckBase(ctx context.Context, r ref.Ref, opts ...ImageOpts) error
+	ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, opts ...ImageOpts) error
+	ImageExport(ctx context.Context, r ref.Ref, outStream io.Writer, opts ...ImageOpts) error
+	ImageImport(ctx context.Context, r ref.Ref, rs io.ReadSeeker, opts ...ImageOpts) error
+}
+
+// Client combines the per-domain interfaces implemented by [RegClient].
+// Methods outside of these interfaces are only available on the concrete [*RegClient].
+type Client interface {
+	ManifestClient
+	BlobClient
+	TagClient
+	ImageClient
+	Close(ctx context.Context, r ref.Ref) error
+}
@@ -1,8 +1,13 @@
 package sandbox
 
 import (
+	"fmt"
+
 	"github.com/sirupsen/logrus"
 	lua "github.com/yuin/gopher-lua"
+
+	"github.com/regclient/regclient/cmd/regbot/internal/go2lua"
+	"github.com/regclient/regclient/scheme"
 )
 
 func setupTag(s *Sandbox) {
@@ -20,12 +25,28 @@ func setupTag(s *Sandbox) {
 	)
 }
 
+type tagDeleteOpts struct {
+	ManifestReplace bool `json:"manifestReplace"`
+}
+
 func (s *Sandbox) tagDelete(ls *lua.LState) int {
 	err := s.ctx.Err()
 	if err != nil {
 		ls.RaiseError("Context error: %v", err)
 	}
 	r := s.checkReference(ls, 1)
+	opts := tagDeleteOpts{}
+	optsArgs := []scheme.TagOpts{}
+	if ls.GetTop() > 1 {
+		tab := ls.CheckTable(2)
+		err := go2lua.Import(ls, tab, &opts, nil)
+		if err != nil {
+			ls.ArgError(2, fmt.Sprintf("Failed to parse options: %v", err))
+		}
+		if opts.ManifestReplace {
+			optsArgs = append(optsArgs, scheme.WithTagManifestReplace())
+		}
+	}
 	s.log.WithFields(logrus.Fields{
 		"script":  s.name,
 		"image":   r.r.CommonName(),
@@ -34,7 +55,7 @@ func (s *Sandbox) tagDelete(ls *lua.LState) int {
 	if s.dryRun {
 		return 0
 	}
-	err = s.rc.TagDelete(s.ctx, r.r)
+	err = s.rc.TagDeleteWithOpts(s.ctx, r.r, optsArgs...)
 	if err != nil {
 		ls.RaiseError("Failed deleting \"%s\": %v", r.r.CommonName(), err)
 	}
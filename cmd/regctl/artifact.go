@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -19,7 +20,6 @@ import (
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/pkg/archive"
-	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/manifest"
@@ -69,6 +69,7 @@ type artifactCmd struct {
 	formatTree       string
 	index            bool
 	latest           bool
+	metadataFile     string
 	outputDir        string
 	platform         string
 	refers           string
@@ -88,10 +89,16 @@ func NewArtifactCmd(rootOpts *rootCmd) *cobra.Command {
 		Short: "manage artifacts",
 	}
 	var artifactGetCmd = &cobra.Command{
-		Use:       "get <reference>",
-		Aliases:   []string{"pull"},
-		Short:     "download artifacts",
-		Long:      `Download artifacts from the registry.`,
+		Use:     "get <reference>",
+		Aliases: []string{"pull"},
+		Short:   "download artifacts",
+		Long: `Download artifacts from the registry.
+Use "--metadata" to save the artifact type, annotations, subject, and
+descriptors of the downloaded files to a json file.`,
+		Example: `
+# download the files of an artifact to a directory, with the artifact metadata
+regctl artifact get registry.example.org/repo:artifact \
+  --output out --metadata out.json`,
 		Args:      cobra.RangeArgs(0, 1),
 		ValidArgs: []string{}, // do not auto complete repository/tag
 		RunE:      artifactOpts.runArtifactGet,
@@ -129,12 +136,17 @@ func NewArtifactCmd(rootOpts *rootCmd) *cobra.Command {
 	artifactGetCmd.Flags().StringVar(&artifactOpts.filterAT, "filter-artifact-type", "", "Filter referrers by artifactType")
 	artifactGetCmd.Flags().StringArrayVar(&artifactOpts.filterAnnot, "filter-annotation", []string{}, "Filter referrers by annotation (key=value)")
 	artifactGetCmd.Flags().StringVar(&artifactOpts.artifactConfig, "config-file", "", "Config filename to output")
+	artifactGetCmd.Flags().StringVar(&artifactOpts.artifactConfigMT, "config-media-type", "", "Verify the config mediaType of the artifact")
+	_ = artifactGetCmd.RegisterFlagCompletionFunc("config-media-type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return configKnownTypes, cobra.ShellCompDirectiveNoFileComp
+	})
 	artifactGetCmd.Flags().StringArrayVarP(&artifactOpts.artifactFile, "file", "f", []string{}, "Filter by artifact filename")
 	artifactGetCmd.Flags().StringArrayVarP(&artifactOpts.artifactFileMT, "file-media-type", "m", []string{}, "Filter by artifact media-type")
 	_ = artifactGetCmd.RegisterFlagCompletionFunc("file-media-type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return artifactFileKnownTypes, cobra.ShellCompDirectiveNoFileComp
 	})
 	artifactGetCmd.Flags().BoolVar(&artifactOpts.latest, "latest", false, "Get the most recent referrer using the OCI created annotation")
+	artifactGetCmd.Flags().StringVar(&artifactOpts.metadataFile, "metadata", "", "Filename to output the artifact metadata as json")
 	artifactGetCmd.Flags().StringVarP(&artifactOpts.outputDir, "output", "o", "", "Output directory for multiple artifacts")
 	artifactGetCmd.Flags().BoolVar(&artifactOpts.stripDirs, "strip-dirs", false, "Strip directories from filenames in output dir")
 	artifactGetCmd.Flags().StringVar(&artifactOpts.refers, "refers", "", "Deprecated: Get a referrer to the reference")
@@ -308,6 +320,16 @@ func (artifactOpts *artifactCmd) runArtifactGet(cmd *cobra.Command, args []strin
 	if !ok {
 		return fmt.Errorf("manifest does not support image methods%.0w", types.ErrUnsupportedMediaType)
 	}
+	// verify the config media type before downloading any content
+	if artifactOpts.artifactConfigMT != "" {
+		d, err := mi.GetConfig()
+		if err != nil {
+			return err
+		}
+		if d.MediaType != artifactOpts.artifactConfigMT {
+			return fmt.Errorf("artifact config media type %s does not match %s%.0w", d.MediaType, artifactOpts.artifactConfigMT, types.ErrUnsupportedMediaType)
+		}
+	}
 
 	// if config-file defined, create file as writer, perform a blob get
 	if artifactOpts.artifactConfig != "" {
@@ -315,7 +337,7 @@ func (artifactOpts *artifactCmd) runArtifactGet(cmd *cobra.Command, args []strin
 		if err != nil {
 			return err
 		}
-		rdr, err := rc.BlobGet(ctx, r, d)
+		rdr, err := rc.BlobGet(ctx, r, d, regclient.BlobWithVerify())
 		if err != nil {
 			return err
 		}
@@ -381,12 +403,6 @@ func (artifactOpts *artifactCmd) runArtifactGet(cmd *cobra.Command, args []strin
 		for _, l := range layers {
 			// wrap in a closure to trigger defer on each step, avoiding open file handles
 			err = func() error {
-				// perform blob get
-				rdr, err := rc.BlobGet(ctx, r, l)
-				if err != nil {
-					return err
-				}
-				defer rdr.Close()
 				// clean each filename, strip any preceding ..
 				f := l.Annotations[ociAnnotTitle]
 				if f == "" {
@@ -420,20 +436,23 @@ func (artifactOpts *artifactCmd) runArtifactGet(cmd *cobra.Command, args []strin
 				}
 				// if there's a trailing slash, expand the compressed blob into the folder
 				if strings.HasSuffix(f, "/") {
+					rdr, err := rc.BlobGet(ctx, r, l, regclient.BlobWithVerify())
+					if err != nil {
+						return err
+					}
+					defer rdr.Close()
 					err = archive.Extract(ctx, filepath.Join(artifactOpts.outputDir, f), rdr)
 					if err != nil {
 						return err
 					}
-				} else {
-					// create file as writer
-					out := filepath.Join(artifactOpts.outputDir, f)
-					//#nosec G304 command is run by a user accessing their own files
-					fh, err := os.Create(out)
+					// closing verifies the digest of any content after the end of the tar
+					err = rdr.Close()
 					if err != nil {
 						return err
 					}
-					defer fh.Close()
-					_, err = io.Copy(fh, rdr)
+				} else {
+					// download to a partial file that is resumed if interrupted and renamed after verification
+					err = rc.BlobGetFile(ctx, r, l, filepath.Join(artifactOpts.outputDir, f))
 					if err != nil {
 						return err
 					}
@@ -451,7 +470,7 @@ func (artifactOpts *artifactCmd) runArtifactGet(cmd *cobra.Command, args []strin
 			return fmt.Errorf("more than one matching layer found, add filters or specify output dir")
 		}
 		// pull blob, write to stdout
-		rdr, err := rc.BlobGet(ctx, r, layers[0])
+		rdr, err := rc.BlobGet(ctx, r, layers[0], regclient.BlobWithVerify())
 		if err != nil {
 			return err
 		}
@@ -462,9 +481,73 @@ func (artifactOpts *artifactCmd) runArtifactGet(cmd *cobra.Command, args []strin
 		}
 	}
 
+	if artifactOpts.metadataFile != "" {
+		err = artifactMetadataWrite(artifactOpts.metadataFile, r, m, layers)
+		if err != nil {
+			return fmt.Errorf("failed to write metadata: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// artifactMetadata describes a downloaded artifact
+type artifactMetadata struct {
+	Reference    string             `json:"reference"`
+	Descriptor   types.Descriptor   `json:"descriptor"`
+	ArtifactType string             `json:"artifactType,omitempty"`
+	Annotations  map[string]string  `json:"annotations,omitempty"`
+	Subject      *types.Descriptor  `json:"subject,omitempty"`
+	Config       types.Descriptor   `json:"config"`
+	Files        []types.Descriptor `json:"files"`
+}
+
+// artifactMetadataWrite saves the metadata of an artifact and the downloaded layers to a json file
+func artifactMetadataWrite(filename string, r ref.Ref, m manifest.Manifest, layers []types.Descriptor) error {
+	meta := artifactMetadata{
+		Reference:  r.CommonName(),
+		Descriptor: m.GetDescriptor(),
+		Files:      layers,
+	}
+	if mi, ok := m.(manifest.Imager); ok {
+		cd, err := mi.GetConfig()
+		if err != nil {
+			return err
+		}
+		meta.Config = cd
+	}
+	if ma, ok := m.(manifest.Annotator); ok {
+		annotations, err := ma.GetAnnotations()
+		if err != nil {
+			return err
+		}
+		meta.Annotations = annotations
+	}
+	if ms, ok := m.(manifest.Subjecter); ok {
+		subject, err := ms.GetSubject()
+		if err != nil {
+			return err
+		}
+		meta.Subject = subject
+	}
+	// the artifact type defaults to the config media type, matching the referrers API
+	switch mOrig := m.GetOrig().(type) {
+	case v1.Manifest:
+		meta.ArtifactType = mOrig.ArtifactType
+		if meta.ArtifactType == "" {
+			meta.ArtifactType = mOrig.Config.MediaType
+		}
+	case v1.ArtifactManifest:
+		meta.ArtifactType = mOrig.ArtifactType
+	}
+	b, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	//#nosec G306 defer to user umask setting, registry content is often public
+	return os.WriteFile(filename, append(b, '\n'), 0666)
+}
+
 func (artifactOpts *artifactCmd) runArtifactList(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
@@ -555,7 +638,7 @@ func (artifactOpts *artifactCmd) runArtifactList(cmd *cobra.Command, args []stri
 	case "rawHeaders", "raw-headers", "headers":
 		artifactOpts.formatList = "{{ range $key,$vals := .Manifest.RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
 	}
-	return template.Writer(cmd.OutOrStdout(), artifactOpts.formatList, rl)
+	return artifactOpts.rootOpts.writeOutput(cmd, artifactOpts.formatList, rl)
 }
 
 func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []string) error {
@@ -656,6 +739,8 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 	// setup regclient
 	rc := artifactOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
+	alg := rc.DigestAlgorithm(r)
+	mOpts = append(mOpts, manifest.WithDigestAlgorithm(alg))
 
 	var subjectDesc *types.Descriptor
 	if rSubject.IsSet() {
@@ -690,14 +775,14 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 		var configDigest digest.Digest
 		if artifactOpts.artifactConfig == "" {
 			configBytes = types.EmptyData
-			configDigest = types.EmptyDigest
+			configDigest = alg.FromBytes(configBytes)
 		} else {
 			var err error
 			configBytes, err = os.ReadFile(artifactOpts.artifactConfig)
 			if err != nil {
 				return err
 			}
-			configDigest = digest.FromBytes(configBytes)
+			configDigest = alg.FromBytes(configBytes)
 		}
 		// push config to registry
 		_, err = rc.BlobPut(ctx, r, types.Descriptor{Digest: configDigest, Size: int64(len(configBytes))}, bytes.NewReader(configBytes))
@@ -735,7 +820,12 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 					// change the file being opened to the temp file
 					openF = tf.Name()
 					defer os.Remove(openF)
-					err = archive.Tar(ctx, f, tf, archive.TarCompressGzip)
+					// directories are packaged reproducibly, using SOURCE_DATE_EPOCH when defined for timestamps
+					tEpoch, err := archive.SourceDateEpoch()
+					if err != nil {
+						return err
+					}
+					err = archive.Tar(ctx, f, tf, archive.TarCompressGzip, archive.TarReproducible, archive.TarWithModTime(tEpoch))
 					if err != nil {
 						return err
 					}
@@ -750,7 +840,7 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 				}
 				defer rdr.Close()
 				// compute digest on file
-				digester := digest.Canonical.Digester()
+				digester := alg.Digester()
 				l, err := io.Copy(digester.Hash(), rdr)
 				if err != nil {
 					return err
@@ -895,7 +985,7 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 				MediaType: types.MediaTypeOCI1ManifestList,
 				Manifests: []types.Descriptor{d},
 			}
-			mi, err := manifest.New(manifest.WithOrig(mii))
+			mi, err := manifest.New(manifest.WithOrig(mii), manifest.WithDigestAlgorithm(alg))
 			if err != nil {
 				return err
 			}
@@ -907,14 +997,14 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 	}
 
 	result := struct {
-		Manifest manifest.Manifest
+		Manifest manifest.Manifest `json:"manifest"`
 	}{
 		Manifest: mm,
 	}
 	if artifactOpts.byDigest && artifactOpts.formatPut == "" {
 		artifactOpts.formatPut = "{{ printf \"%s\\n\" .Manifest.GetDescriptor.Digest }}"
 	}
-	return template.Writer(cmd.OutOrStdout(), artifactOpts.formatPut, result)
+	return artifactOpts.rootOpts.writeOutput(cmd, artifactOpts.formatPut, result)
 }
 
 func (artifactOpts *artifactCmd) runArtifactTree(cmd *cobra.Command, args []string) error {
@@ -960,7 +1050,7 @@ func (artifactOpts *artifactCmd) runArtifactTree(cmd *cobra.Command, args []stri
 	tr, err := artifactOpts.treeAddResult(ctx, rc, r, seen, referrerOpts, tags)
 	var twErr error
 	if tr != nil {
-		twErr = template.Writer(cmd.OutOrStdout(), artifactOpts.formatTree, tr)
+		twErr = artifactOpts.rootOpts.writeOutput(cmd, artifactOpts.formatTree, tr)
 	}
 	if err != nil {
 		return err
@@ -1084,6 +1174,36 @@ type treeResult struct {
 	Referrer     []*treeResult      `json:"referrer,omitempty"`
 }
 
+// treeOutput is the structured output of a treeResult.
+type treeOutput struct {
+	Ref          outputRef          `json:"reference"`
+	Manifest     manifest.Manifest  `json:"manifest"`
+	Platform     *platform.Platform `json:"platform,omitempty"`
+	ArtifactType string             `json:"artifactType,omitempty"`
+	Child        []*treeOutput      `json:"child,omitempty"`
+	Referrer     []*treeOutput      `json:"referrer,omitempty"`
+}
+
+func (tr *treeResult) outputStructured() interface{} {
+	return tr.treeOutput()
+}
+
+func (tr *treeResult) treeOutput() *treeOutput {
+	to := &treeOutput{
+		Ref:          outputRefNew(tr.Ref),
+		Manifest:     tr.Manifest,
+		Platform:     tr.Platform,
+		ArtifactType: tr.ArtifactType,
+	}
+	for _, c := range tr.Child {
+		to.Child = append(to.Child, c.treeOutput())
+	}
+	for _, r := range tr.Referrer {
+		to.Referrer = append(to.Referrer, r.treeOutput())
+	}
+	return to
+}
+
 func (tr *treeResult) MarshalPretty() ([]byte, error) {
 	mp, err := tr.marshalPretty("")
 	if err != nil {
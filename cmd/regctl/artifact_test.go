@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -40,6 +41,16 @@ func TestArtifactGet(t *testing.T) {
 			args:      []string{"artifact", "get", "ocidir://../../testdata/testrepo:a1"},
 			expectOut: "eggs",
 		},
+		{
+			name:      "Config media type",
+			args:      []string{"artifact", "get", "ocidir://../../testdata/testrepo:a1", "--config-media-type", "application/vnd.oci.empty.v1+json"},
+			expectOut: "eggs",
+		},
+		{
+			name:      "Config media type mismatch",
+			args:      []string{"artifact", "get", "ocidir://../../testdata/testrepo:a1", "--config-media-type", "application/vnd.oci.image.config.v1+json"},
+			expectErr: types.ErrUnsupportedMediaType,
+		},
 		{
 			name:      "By Subject",
 			args:      []string{"artifact", "get", "--subject", "ocidir://../../testdata/testrepo:v2", "--filter-artifact-type", "application/example.sbom"},
@@ -73,6 +84,42 @@ func TestArtifactGet(t *testing.T) {
 	}
 }
 
+func TestArtifactGetMetadata(t *testing.T) {
+	tmpDir := t.TempDir()
+	metaFile := filepath.Join(tmpDir, "meta.json")
+	out, err := cobraTest(t, nil, "artifact", "get", "ocidir://../../testdata/testrepo:a1", "--metadata", metaFile)
+	if err != nil {
+		t.Fatalf("failed to get artifact: %v", err)
+	}
+	if out != "eggs" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	metaBytes, err := os.ReadFile(metaFile)
+	if err != nil {
+		t.Fatalf("failed to read metadata: %v", err)
+	}
+	meta := artifactMetadata{}
+	err = json.Unmarshal(metaBytes, &meta)
+	if err != nil {
+		t.Fatalf("failed to parse metadata: %v", err)
+	}
+	if meta.ArtifactType != "application/example.sbom" {
+		t.Errorf("unexpected artifact type: %s", meta.ArtifactType)
+	}
+	if meta.Subject == nil || meta.Subject.Digest.String() != "sha256:4eef5c8459d36adab1f354e332af99915cb75dfe4b91e19cdd53ccfb33aa0418" {
+		t.Errorf("unexpected subject: %v", meta.Subject)
+	}
+	if meta.Config.MediaType != "application/vnd.oci.empty.v1+json" {
+		t.Errorf("unexpected config: %v", meta.Config)
+	}
+	if len(meta.Files) != 1 || meta.Files[0].MediaType != "application/example.sbom.breakfast" {
+		t.Errorf("unexpected files: %v", meta.Files)
+	}
+	if meta.Descriptor.Digest.String() != "sha256:54dd4e105916f7a95f7911c0328ce753d7bd08034538933c042cfae3f63bc573" {
+		t.Errorf("unexpected descriptor: %v", meta.Descriptor)
+	}
+}
+
 func TestArtifactList(t *testing.T) {
 	tt := []struct {
 		name        string
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/pkg/intoto"
+)
+
+type attestationCmd struct {
+	rootOpts      *rootCmd
+	digest        string
+	format        string
+	key           string
+	predicateType string
+}
+
+func NewAttestationCmd(rootOpts *rootCmd) *cobra.Command {
+	attestationOpts := attestationCmd{
+		rootOpts: rootOpts,
+	}
+	var attestationTopCmd = &cobra.Command{
+		Use:   "attestation <cmd>",
+		Short: "manage in-toto attestations",
+	}
+	var attestationGetCmd = &cobra.Command{
+		Use:   "get <reference>",
+		Short: "get an attestation",
+		Long: `Get the in-toto statement of an attestation attached to an image.
+When more than one attestation is found, select one with --predicate-type or --digest.
+The DSSE envelope signature is verified with --key, which fails for unsigned attestations.`,
+		Example: `
+# show the SLSA provenance of an image
+regctl attestation get --predicate-type https://slsa.dev/provenance/v1 \
+  registry.example.org/repo:v1
+
+# verify the signature and output the predicate
+regctl attestation get --key cosign.pub --format '{{jsonPretty .Statement.Predicate}}' \
+  --predicate-type https://cosign.sigstore.dev/attestation/vuln/v1 \
+  registry.example.org/repo:v1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              attestationOpts.runAttestationGet,
+	}
+	var attestationLsCmd = &cobra.Command{
+		Use:     "ls <reference>",
+		Aliases: []string{"list"},
+		Short:   "list attestations",
+		Long: `List the in-toto attestations attached to an image.
+Attestations are found as referrers, in the index created by docker buildx,
+and in the cosign "sha256-<digest>.att" tag.`,
+		Example: `
+# list attestations
+regctl attestation ls registry.example.org/repo:v1
+
+# list the predicate types
+regctl attestation ls --format '{{range .}}{{println .PredicateType}}{{end}}' \
+  registry.example.org/repo:v1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              attestationOpts.runAttestationLs,
+	}
+
+	attestationGetCmd.Flags().StringVarP(&attestationOpts.digest, "digest", "", "", "Digest of the attestation manifest or layer")
+	attestationGetCmd.Flags().StringVarP(&attestationOpts.format, "format", "", "{{printPretty .Statement}}", "Format output with go template syntax")
+	attestationGetCmd.Flags().StringVarP(&attestationOpts.key, "key", "", "", "File with a PEM encoded public key to verify the signature")
+	attestationGetCmd.Flags().StringVarP(&attestationOpts.predicateType, "predicate-type", "", "", "Predicate type of the attestation")
+	_ = attestationGetCmd.RegisterFlagCompletionFunc("digest", completeArgNone)
+	_ = attestationGetCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = attestationGetCmd.RegisterFlagCompletionFunc("key", completeArgDefault)
+	_ = attestationGetCmd.RegisterFlagCompletionFunc("predicate-type", completeArgNone)
+
+	attestationLsCmd.Flags().StringVarP(&attestationOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	attestationLsCmd.Flags().StringVarP(&attestationOpts.predicateType, "predicate-type", "", "", "Filter by predicate type")
+	_ = attestationLsCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = attestationLsCmd.RegisterFlagCompletionFunc("predicate-type", completeArgNone)
+
+	attestationTopCmd.AddCommand(attestationGetCmd)
+	attestationTopCmd.AddCommand(attestationLsCmd)
+	return attestationTopCmd
+}
+
+func (attestationOpts *attestationCmd) runAttestationGet(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := attestationOpts.rootOpts.refNew(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	aOpts := []regclient.AttestationOpts{}
+	if attestationOpts.predicateType != "" {
+		aOpts = append(aOpts, regclient.AttestationWithPredicateType(attestationOpts.predicateType))
+	}
+	if attestationOpts.key != "" {
+		b, err := os.ReadFile(attestationOpts.key)
+		if err != nil {
+			return err
+		}
+		pub, err := intoto.ParsePublicKey(b)
+		if err != nil {
+			return err
+		}
+		aOpts = append(aOpts, regclient.AttestationWithVerifyKey(pub))
+	}
+	rc := attestationOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	log.WithFields(logrus.Fields{
+		"ref":           r.CommonName(),
+		"predicateType": attestationOpts.predicateType,
+	}).Debug("Attestation get")
+	al, err := rc.AttestationList(ctx, r, aOpts...)
+	if err != nil {
+		return err
+	}
+	if attestationOpts.digest != "" {
+		filtered := []regclient.Attestation{}
+		for _, a := range al {
+			if strings.HasPrefix(a.Manifest.Digest.String(), attestationOpts.digest) || strings.HasPrefix(a.Layer.Digest.String(), attestationOpts.digest) {
+				filtered = append(filtered, a)
+			}
+		}
+		al = filtered
+	}
+	if len(al) == 0 {
+		return fmt.Errorf("no attestation found for %s%.0w", r.CommonName(), ErrNotFound)
+	}
+	if len(al) > 1 {
+		return fmt.Errorf("%d attestations found, select one with --predicate-type or --digest%.0w", len(al), ErrInvalidInput)
+	}
+	ac, err := rc.AttestationGet(ctx, r, al[0], aOpts...)
+	if err != nil {
+		return err
+	}
+	return attestationOpts.rootOpts.writeOutput(cmd, attestationOpts.format, ac)
+}
+
+func (attestationOpts *attestationCmd) runAttestationLs(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := attestationOpts.rootOpts.refNew(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	aOpts := []regclient.AttestationOpts{}
+	if attestationOpts.predicateType != "" {
+		aOpts = append(aOpts, regclient.AttestationWithPredicateType(attestationOpts.predicateType))
+	}
+	rc := attestationOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	log.WithFields(logrus.Fields{
+		"ref": r.CommonName(),
+	}).Debug("Attestation list")
+	al, err := rc.AttestationList(ctx, r, aOpts...)
+	if err != nil {
+		return err
+	}
+	return attestationOpts.rootOpts.writeOutput(cmd, attestationOpts.format, al)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/regclient/regclient/pkg/intoto"
+)
+
+func TestAttestation(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v3"
+	tgtRef := "ocidir://" + tmpDir + "/repo:v3"
+	_, err := cobraTest(t, nil, "image", "copy", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	dig, err := cobraTest(t, nil, "image", "digest", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+
+	out, err := cobraTest(t, nil, "attestation", "ls", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to list attestations: %v", err)
+	}
+	if out != "[]" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	_, err = cobraTest(t, nil, "attestation", "get", tgtRef)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// push an unsigned provenance statement as a referrer
+	statement, err := json.Marshal(intoto.Statement{
+		Type:          intoto.StatementTypeV1,
+		Subject:       []intoto.Subject{{Name: "repo", Digest: map[string]string{"sha256": strings.TrimPrefix(dig, "sha256:")}}},
+		PredicateType: intoto.PredicateSLSAProvenance1,
+		Predicate:     json.RawMessage(`{"buildDefinition":{"buildType":"test"}}`),
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal statement: %v", err)
+	}
+	statementFile := filepath.Join(tmpDir, "statement.json")
+	err = os.WriteFile(statementFile, statement, 0600)
+	if err != nil {
+		t.Fatalf("failed to write statement: %v", err)
+	}
+	_, err = cobraTest(t, nil, "artifact", "put", "--subject", tgtRef,
+		"--artifact-type", intoto.MediaTypeStatement,
+		"--file", statementFile, "--file-media-type", intoto.MediaTypeStatement)
+	if err != nil {
+		t.Fatalf("failed to put attestation: %v", err)
+	}
+
+	out, err = cobraTest(t, nil, "attestation", "ls", "--format", "{{range .}}{{println .PredicateType .Source}}{{end}}", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to list attestations: %v", err)
+	}
+	if out != intoto.PredicateSLSAProvenance1+" referrer" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	out, err = cobraTest(t, nil, "attestation", "ls", "--predicate-type", intoto.PredicateVuln, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to list attestations: %v", err)
+	}
+	if out != "[]" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	out, err = cobraTest(t, nil, "attestation", "get", "--format", "{{json .Statement.Predicate}}", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to get attestation: %v", err)
+	}
+	if out != `{"buildDefinition":{"buildType":"test"}}` {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	// unsigned attestations fail to verify
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	keyFile := filepath.Join(tmpDir, "key.pub")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	_, err = cobraTest(t, nil, "attestation", "get", "--key", keyFile, tgtRef)
+	if !errors.Is(err, intoto.ErrVerifyFailed) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -18,23 +18,31 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/internal/diff"
-	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/pkg/archive"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/ref"
 )
 
 type blobCmd struct {
 	rootOpts       *rootCmd
+	diffContent    bool
+	diffContentMax int64
 	diffCtx        int
 	diffFullCtx    bool
 	diffIgnoreTime bool
+	formatDiff     string
 	formatGet      string
 	formatFile     string
 	formatHead     string
 	formatPut      string
 	mt             string
 	digest         string
+	outputFile     string
+	parallel       int
+	rangeLength    int64
+	rangeOffset    int64
 }
 
 func NewBlobCmd(rootOpts *rootCmd) *cobra.Command {
@@ -47,6 +55,25 @@ func NewBlobCmd(rootOpts *rootCmd) *cobra.Command {
 		Aliases: []string{"layer"},
 		Short:   "manage image blobs/layers",
 	}
+	var blobDiffCmd = &cobra.Command{
+		Use:   "diff <repository> <digest> <repository> <digest>",
+		Short: "compare the files in two layers",
+		Long: `Compare the files in two layers, reporting each file that was added, removed, or changed.
+Files are compared by type, mode, owner, size, link, digest, and modification time.
+Use "--content" to include a diff of changed text files.`,
+		Example: `
+# compare the first layer of two images
+regctl blob diff registry.example.org/repo sha256:a3ed95... \
+  registry.example.org/repo sha256:88c4d3...
+
+# include the changes to small text files
+regctl blob diff --content --ignore-timestamp \
+  registry.example.org/repo sha256:a3ed95... \
+  registry.example.org/repo sha256:88c4d3...`,
+		Args:      cobra.ExactArgs(4),
+		ValidArgs: []string{}, // do not auto complete repository or digest
+		RunE:      blobOpts.runBlobDiff,
+	}
 	var blobDiffConfigCmd = &cobra.Command{
 		Use:       "diff-config <repository> <digest> <repository> <digest>",
 		Short:     "diff two image configs",
@@ -69,7 +96,13 @@ func NewBlobCmd(rootOpts *rootCmd) *cobra.Command {
 		Short:   "download a blob/layer",
 		Long: `Download a blob from the registry. The output is the blob itself which may
 be a compressed tar file, a json config, or any other blob supported by the
-registry. The blob or layer digest can be found in the image manifest.`,
+registry. The blob or layer digest can be found in the image manifest.
+Use "--output" to download the blob to a file. An interrupted download
+is resumed when the command is rerun, and the file is only created after
+the digest is verified.
+Use "--offset" and "--length" to output part of the blob, using a range
+request when supported by the registry. The content of a range is not
+verified.`,
 		Args:      cobra.ExactArgs(2),
 		ValidArgs: []string{}, // do not auto complete repository or digest
 		RunE:      blobOpts.runBlobGet,
@@ -114,6 +147,15 @@ it only sends the manifest with the new tag.`,
 		RunE:      blobOpts.runBlobCopy,
 	}
 
+	blobDiffCmd.Flags().BoolVarP(&blobOpts.diffContent, "content", "", false, "Show a diff of changed text files")
+	blobDiffCmd.Flags().Int64VarP(&blobOpts.diffContentMax, "content-max", "", 1024*64, "Maximum size of a text file to diff")
+	blobDiffCmd.Flags().IntVarP(&blobOpts.diffCtx, "context", "", 3, "Lines of context in a content diff")
+	blobDiffCmd.Flags().StringVarP(&blobOpts.formatDiff, "format", "", "", "Format output with go template syntax")
+	blobDiffCmd.Flags().BoolVarP(&blobOpts.diffIgnoreTime, "ignore-timestamp", "", false, "Ignore timestamps on files")
+	_ = blobDiffCmd.RegisterFlagCompletionFunc("content-max", completeArgNone)
+	_ = blobDiffCmd.RegisterFlagCompletionFunc("context", completeArgNone)
+	_ = blobDiffCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
 	blobDiffConfigCmd.Flags().IntVarP(&blobOpts.diffCtx, "context", "", 3, "Lines of context")
 	blobDiffConfigCmd.Flags().BoolVarP(&blobOpts.diffFullCtx, "context-full", "", false, "Show all lines of context")
 
@@ -123,6 +165,10 @@ it only sends the manifest with the new tag.`,
 
 	blobGetCmd.Flags().StringVarP(&blobOpts.formatGet, "format", "", "{{printPretty .}}", "Format output with go template syntax")
 	blobGetCmd.Flags().StringVarP(&blobOpts.mt, "media-type", "", "", "Set the requested mediaType (deprecated)")
+	blobGetCmd.Flags().StringVarP(&blobOpts.outputFile, "output", "o", "", "Write the blob to a file, resuming an interrupted download")
+	blobGetCmd.Flags().Int64VarP(&blobOpts.rangeLength, "length", "", -1, "Number of bytes to output from the offset, -1 outputs to the end of the blob")
+	blobGetCmd.Flags().Int64VarP(&blobOpts.rangeOffset, "offset", "", 0, "Offset of the first byte to output")
+	blobGetCmd.Flags().IntVarP(&blobOpts.parallel, "parallel", "", 0, "Number of concurrent range requests when downloading a large blob to a file")
 	_ = blobGetCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 	_ = blobGetCmd.RegisterFlagCompletionFunc("media-type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{
@@ -147,6 +193,7 @@ it only sends the manifest with the new tag.`,
 	_ = blobPutCmd.RegisterFlagCompletionFunc("digest", completeArgNone)
 	_ = blobPutCmd.Flags().MarkHidden("content-type")
 
+	blobTopCmd.AddCommand(blobDiffCmd)
 	blobTopCmd.AddCommand(blobDiffConfigCmd)
 	blobTopCmd.AddCommand(blobDiffLayerCmd)
 	blobTopCmd.AddCommand(blobGetCmd)
@@ -158,6 +205,76 @@ it only sends the manifest with the new tag.`,
 	return blobTopCmd
 }
 
+func (blobOpts *blobCmd) runBlobDiff(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r1, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	d1, err := digest.Parse(args[1])
+	if err != nil {
+		return err
+	}
+	r2, err := ref.New(args[2])
+	if err != nil {
+		return err
+	}
+	d2, err := digest.Parse(args[3])
+	if err != nil {
+		return err
+	}
+	aOpts := []archive.DiffOpts{archive.DiffWithContext(blobOpts.diffCtx)}
+	if blobOpts.diffContent {
+		aOpts = append(aOpts, archive.DiffWithContent(blobOpts.diffContentMax))
+	}
+	if blobOpts.diffIgnoreTime {
+		aOpts = append(aOpts, archive.DiffIgnoreTime)
+	}
+	rc := blobOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r1)
+	defer rc.Close(ctx, r2)
+
+	log.WithFields(logrus.Fields{
+		"ref1":    r1.CommonName(),
+		"digest1": d1.String(),
+		"ref2":    r2.CommonName(),
+		"digest2": d2.String(),
+	}).Debug("Blob diff")
+	b1, err := rc.BlobGet(ctx, r1, types.Descriptor{Digest: d1}, regclient.BlobWithVerify())
+	if err != nil {
+		return err
+	}
+	defer b1.Close()
+	b2, err := rc.BlobGet(ctx, r2, types.Descriptor{Digest: d2}, regclient.BlobWithVerify())
+	if err != nil {
+		return err
+	}
+	defer b2.Close()
+	changes, err := archive.Diff(b1, b2, aOpts...)
+	if err != nil {
+		return err
+	}
+	// closing verifies the digest of any content not read by the diff
+	if err := b1.Close(); err != nil {
+		return err
+	}
+	if err := b2.Close(); err != nil {
+		return err
+	}
+
+	if blobOpts.formatDiff != "" || blobOpts.rootOpts.outputStructured() {
+		return blobOpts.rootOpts.writeOutput(cmd, blobOpts.formatDiff, changes)
+	}
+	out := cmd.OutOrStdout()
+	for _, c := range changes {
+		fmt.Fprintln(out, c.String())
+		for _, line := range c.Diff {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+	return nil
+}
+
 func (blobOpts *blobCmd) runBlobDiffConfig(cmd *cobra.Command, args []string) error {
 	diffOpts := []diff.Opt{}
 	if blobOpts.diffCtx > 0 {
@@ -206,10 +323,11 @@ func (blobOpts *blobCmd) runBlobDiffConfig(cmd *cobra.Command, args []string) er
 
 	cDiff := diff.Diff(strings.Split(string(c1Json), "\n"), strings.Split(string(c2Json), "\n"), diffOpts...)
 
+	if ok, err := blobOpts.rootOpts.writeStructured(cmd, cDiff); ok {
+		return err
+	}
 	_, err = fmt.Fprintln(cmd.OutOrStdout(), strings.Join(cDiff, "\n"))
 	return err
-	// TODO: support templating
-	// return template.Writer(cmd.OutOrStdout(), blobOpts.format, cDiff)
 }
 
 func (blobOpts *blobCmd) runBlobDiffLayer(cmd *cobra.Command, args []string) error {
@@ -286,6 +404,9 @@ func (blobOpts *blobCmd) runBlobDiffLayer(cmd *cobra.Command, args []string) err
 
 	// run diff and output result
 	lDiff := diff.Diff(rep1, rep2, diffOpts...)
+	if ok, err := blobOpts.rootOpts.writeStructured(cmd, lDiff); ok {
+		return err
+	}
 	_, err = fmt.Fprintln(cmd.OutOrStdout(), strings.Join(lDiff, "\n"))
 	return err
 }
@@ -313,11 +434,42 @@ func (blobOpts *blobCmd) runBlobGet(cmd *cobra.Command, args []string) error {
 		"repository": r.Repository,
 		"digest":     args[1],
 	}).Debug("Pulling blob")
+	if blobOpts.parallel > 1 && blobOpts.outputFile == "" {
+		return fmt.Errorf("--parallel requires --output")
+	}
+	if blobOpts.rangeOffset != 0 || blobOpts.rangeLength >= 0 {
+		if blobOpts.outputFile != "" {
+			return fmt.Errorf("--offset and --length cannot be used with --output%.0w", ErrInvalidInput)
+		}
+		rdr, err := rc.BlobGetRange(ctx, r, types.Descriptor{Digest: d}, blobOpts.rangeOffset, blobOpts.rangeLength)
+		if err != nil {
+			return err
+		}
+		defer rdr.Close()
+		_, err = io.Copy(cmd.OutOrStdout(), rdr)
+		return err
+	}
+	if blobOpts.outputFile != "" {
+		desc := types.Descriptor{Digest: d}
+		// the size allows a completed partial download to be detected without another request
+		if bh, err := rc.BlobHead(ctx, r, desc); err == nil {
+			desc.Size = bh.GetDescriptor().Size
+			_ = bh.Close()
+		}
+		bOpts := []regclient.BlobOpts{}
+		if blobOpts.parallel > 1 {
+			bOpts = append(bOpts, regclient.BlobWithParallel(blobOpts.parallel, 0))
+		}
+		return rc.BlobGetFile(ctx, r, desc, blobOpts.outputFile, bOpts...)
+	}
 	blob, err := rc.BlobGet(ctx, r, types.Descriptor{Digest: d})
 	if err != nil {
 		return err
 	}
 
+	if ok, err := blobOpts.rootOpts.writeStructured(cmd, blob.GetDescriptor()); ok {
+		return err
+	}
 	switch blobOpts.formatGet {
 	case "raw":
 		blobOpts.formatGet = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}{{printf \"\\n%s\" .RawBody}}"
@@ -331,7 +483,7 @@ func (blobOpts *blobCmd) runBlobGet(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return template.Writer(cmd.OutOrStdout(), blobOpts.formatGet, blob)
+	return blobOpts.rootOpts.writeOutput(cmd, blobOpts.formatGet, blob)
 }
 
 func (blobOpts *blobCmd) runBlobGetFile(cmd *cobra.Command, args []string) error {
@@ -367,15 +519,15 @@ func (blobOpts *blobCmd) runBlobGetFile(cmd *cobra.Command, args []string) error
 	if err != nil {
 		return err
 	}
-	if blobOpts.formatFile != "" {
+	if blobOpts.formatFile != "" || blobOpts.rootOpts.outputStructured() {
 		data := struct {
-			Header *tar.Header
-			Reader io.Reader
+			Header *tar.Header `json:"header"`
+			Reader io.Reader   `json:"-"`
 		}{
 			Header: th,
 			Reader: rdr,
 		}
-		return template.Writer(cmd.OutOrStdout(), blobOpts.formatFile, data)
+		return blobOpts.rootOpts.writeOutput(cmd, blobOpts.formatFile, data)
 	}
 	var w io.Writer
 	if len(args) < 4 {
@@ -422,12 +574,15 @@ func (blobOpts *blobCmd) runBlobHead(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if ok, err := blobOpts.rootOpts.writeStructured(cmd, blob.GetDescriptor()); ok {
+		return err
+	}
 	switch blobOpts.formatHead {
 	case "", "rawHeaders", "raw-headers", "headers":
 		blobOpts.formatHead = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
 	}
 
-	return template.Writer(cmd.OutOrStdout(), blobOpts.formatHead, blob)
+	return blobOpts.rootOpts.writeOutput(cmd, blobOpts.formatHead, blob)
 }
 
 func (blobOpts *blobCmd) runBlobPut(cmd *cobra.Command, args []string) error {
@@ -455,14 +610,14 @@ func (blobOpts *blobCmd) runBlobPut(cmd *cobra.Command, args []string) error {
 	}
 
 	result := struct {
-		Digest digest.Digest
-		Size   int64
+		Digest digest.Digest `json:"digest"`
+		Size   int64         `json:"size"`
 	}{
 		Digest: dOut.Digest,
 		Size:   dOut.Size,
 	}
 
-	return template.Writer(cmd.OutOrStdout(), blobOpts.formatPut, result)
+	return blobOpts.rootOpts.writeOutput(cmd, blobOpts.formatPut, result)
 }
 
 func (blobOpts *blobCmd) runBlobCopy(cmd *cobra.Command, args []string) error {
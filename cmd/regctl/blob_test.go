@@ -2,6 +2,9 @@ package main
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -72,6 +75,31 @@ func TestBlob(t *testing.T) {
 		if out != bufStr {
 			t.Errorf("unexpected blob output, expected %s, received %s", bufStr, out)
 		}
+		// get the blob to a file
+		outFile := filepath.Join(dir, "blob.out")
+		_, err = cobraTest(t, nil, "blob", "get", "--output", outFile, "ocidir://"+dir, dig)
+		if err != nil {
+			t.Errorf("failed to blob get to a file: %v", err)
+		}
+		b, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Errorf("failed to read output file: %v", err)
+		}
+		if string(b) != bufStr {
+			t.Errorf("unexpected blob file content, expected %s, received %s", bufStr, string(b))
+		}
+		_, err = cobraTest(t, nil, "blob", "get", "--parallel", "2", "ocidir://"+dir, dig)
+		if err == nil {
+			t.Errorf("parallel without an output file did not fail")
+		}
+		// get part of the blob
+		out, err = cobraTest(t, nil, "blob", "get", "--offset", "6", "--length", "3", "ocidir://"+dir, dig)
+		if err != nil {
+			t.Errorf("failed to blob get a range: %v", err)
+		}
+		if out != "wor" {
+			t.Errorf("unexpected blob range output, expected wor, received %s", out)
+		}
 	})
 
 	t.Run("Copy", func(t *testing.T) {
@@ -92,8 +120,23 @@ func TestBlob(t *testing.T) {
 	})
 
 	t.Run("Diff", func(t *testing.T) {
+		// compare the files in two layers
+		out, err := cobraTest(t, nil, "blob", "diff", "--content", repo, digBaseA, repo, digBaseB)
+		if err != nil {
+			t.Errorf("failed to diff blobs: %v", err)
+		}
+		if !strings.HasPrefix(out, "M base.txt: digest ") || !strings.Contains(out, "  - A\n  + B") {
+			t.Errorf("unexpected output from diff: %s", out)
+		}
+		out, err = cobraTest(t, nil, "blob", "diff", "--format", "{{range .}}{{println .Type .Name}}{{end}}", repo, digBaseA, repo, digBaseA)
+		if err != nil {
+			t.Errorf("failed to diff blobs: %v", err)
+		}
+		if out != "" {
+			t.Errorf("unexpected output from diff of the same blob: %s", out)
+		}
 		// diff the layers between two images
-		out, err := cobraTest(t, nil, "blob", "diff-layer", repo, digBaseA, repo, digBaseB)
+		out, err = cobraTest(t, nil, "blob", "diff-layer", repo, digBaseA, repo, digBaseB)
 		if err != nil {
 			t.Errorf("failed to diff layers: %v", err)
 		}
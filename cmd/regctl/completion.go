@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -97,12 +98,11 @@ func completeArgDefault(cmd *cobra.Command, args []string, toComplete string) ([
 }
 
 func completeArgPlatform(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	return []string{
-		"linux/amd64", "linux/386",
-		"linux/arm/v6", "linux/arm/v7", "linux/arm64/v8",
-		"linux/mips64le", "linux/ppc64le", "linux/s390x",
-		"windows/amd64/10.0.17763.1577", "windows/amd64/10.0.14393.4046",
-	}, cobra.ShellCompDirectiveNoFileComp
+	result := []string{"local"}
+	for _, p := range platform.Known() {
+		result = append(result, p.String())
+	}
+	return result, cobra.ShellCompDirectiveNoFileComp
 }
 
 func completeArgMediaTypeManifest(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -7,12 +7,19 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/conffile"
-	"github.com/regclient/regclient/pkg/template"
 )
 
 var (
@@ -24,6 +31,9 @@ var (
 	ConfigEnv = "REGCTL_CONFIG"
 )
 
+// configEnvRe matches a "${NAME}" reference to an environment variable in a host secret.
+var configEnvRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
 // Config struct contains contents loaded from / saved to a config file
 type Config struct {
 	Filename      string                  `json:"-"`                 // filename that was loaded
@@ -32,6 +42,8 @@ type Config struct {
 	BlobLimit     int64                   `json:"blobLimit,omitempty"`
 	IncDockerCert *bool                   `json:"incDockerCert,omitempty"`
 	IncDockerCred *bool                   `json:"incDockerCred,omitempty"`
+	TokenCache    string                  `json:"tokenCache,omitempty"`
+	CredStore     string                  `json:"credStore,omitempty"` // OS secret store for logins, e.g. osxkeychain, wincred, secretservice, or auto
 }
 
 type configCmd struct {
@@ -39,7 +51,17 @@ type configCmd struct {
 	blobLimit  int64
 	dockerCert bool
 	dockerCred bool
+	dryRun     bool
 	format     string
+	formatList string
+	tokenCache string
+	credStore  string
+}
+
+// configEntry is a single key and value from the config.
+type configEntry struct {
+	Key   string
+	Value string
 }
 
 func NewConfigCmd(rootOpts *rootCmd) *cobra.Command {
@@ -51,27 +73,84 @@ func NewConfigCmd(rootOpts *rootCmd) *cobra.Command {
 		Short: "read/set configuration options",
 	}
 	var configGetCmd = &cobra.Command{
-		Use:   "get",
+		Use:   "get [key]",
 		Short: "show the config",
-		Long:  `Displays the configuration. Passwords are not included in the output.`,
-		Args:  cobra.ExactArgs(0),
-		RunE:  configOpts.runConfigGet,
+		Long: `Displays the configuration, or the value of a single key.
+Keys are the json field names, e.g. "blobLimit", and host settings are "hosts.<registry>.<field>", e.g. "hosts.docker.io.reqPerSec".
+Passwords are not included in the output.`,
+		Example: `
+# show the config
+regctl config get
+
+# show the TLS setting for a registry
+regctl config get hosts.registry.example.org.tls`,
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: configOpts.completeConfigKey,
+		RunE:              configOpts.runConfigGet,
+	}
+	var configListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "list config keys",
+		Long: `Lists each configuration key that is set with the current value.
+Passwords and tokens are not included in the output.`,
+		Args: cobra.ExactArgs(0),
+		RunE: configOpts.runConfigList,
 	}
 	var configSetCmd = &cobra.Command{
-		Use:   "set",
+		Use:   "set [key value]",
 		Short: "set a configuration option",
-		Long:  `Modifies an option used in future executions.`,
-		Args:  cobra.ExactArgs(0),
-		RunE:  configOpts.runConfigSet,
+		Long: `Modifies an option used in future executions.
+Options may be set with flags, or with a key and value.
+Keys are the json field names, e.g. "blobLimit", and host settings are "hosts.<registry>.<field>".
+Values are parsed as json when possible, otherwise as a string, and an empty value resets the key to the default.
+Passwords and tokens cannot be set, use "regctl registry login" instead.`,
+		Example: `
+# disable TLS for a local registry
+regctl config set hosts.localhost:5000.tls disabled
+
+# configure a mirror
+regctl config set hosts.docker.io.mirrors '["mirror.example.org"]'
+
+# limit the request rate
+regctl config set hosts.docker.io.reqPerSec 2.5
+
+# reset the blob chunk size to the default
+regctl config set hosts.registry.example.org.blobChunk ""
+
+# show the result without saving
+regctl config set --dry-run hosts.registry.example.org.retryLimit 5`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 && len(args) != 2 {
+				return fmt.Errorf("config set requires a key and value, received %d args%.0w", len(args), ErrInvalidInput)
+			}
+			return nil
+		},
+		ValidArgsFunction: configOpts.completeConfigKey,
+		RunE:              configOpts.runConfigSet,
 	}
 
 	configGetCmd.Flags().StringVar(&configOpts.format, "format", "{{ printPretty . }}", "format the output with Go template syntax")
 
+	configListCmd.Flags().StringVar(&configOpts.formatList, "format", "{{ range . }}{{ .Key }}={{ .Value }}\n{{ end }}", "format the output with Go template syntax")
+
+	configSetCmd.Flags().BoolVar(&configOpts.dryRun, "dry-run", false, "output the resulting config without saving")
 	configSetCmd.Flags().Int64Var(&configOpts.blobLimit, "blob-limit", 0, "limit for blob chunks, this is stored in memory")
 	configSetCmd.Flags().BoolVar(&configOpts.dockerCert, "docker-cert", false, "load certificates from docker")
 	configSetCmd.Flags().BoolVar(&configOpts.dockerCred, "docker-cred", false, "load credentials from docker")
+	configSetCmd.Flags().StringVar(&configOpts.tokenCache, "token-cache", "", "file to cache auth tokens between commands, empty to disable")
+	configSetCmd.Flags().StringVar(&configOpts.credStore, "cred-store", "", "secret store for registry logins (auto, osxkeychain, wincred, secretservice, pass), empty to save in the config file")
+	_ = configSetCmd.RegisterFlagCompletionFunc("cred-store", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			config.CredStoreAuto,
+			config.CredStoreOSXKeychain,
+			config.CredStoreWinCred,
+			config.CredStoreSecretService,
+			config.CredStorePass,
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	configTopCmd.AddCommand(configGetCmd)
+	configTopCmd.AddCommand(configListCmd)
 	configTopCmd.AddCommand(configSetCmd)
 	return configTopCmd
 }
@@ -85,8 +164,31 @@ func (configOpts *configCmd) runConfigGet(cmd *cobra.Command, args []string) err
 		c.Hosts[i].Pass = ""
 		c.Hosts[i].Token = ""
 	}
+	if len(args) > 0 {
+		val, err := configKeyGet(c, args[0])
+		if err != nil {
+			return err
+		}
+		if !flagChanged(cmd, "format") {
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), val)
+			return err
+		}
+		return configOpts.rootOpts.writeOutput(cmd, configOpts.format, val)
+	}
 
-	return template.Writer(cmd.OutOrStdout(), configOpts.format, c)
+	return configOpts.rootOpts.writeOutput(cmd, configOpts.format, c)
+}
+
+func (configOpts *configCmd) runConfigList(cmd *cobra.Command, args []string) error {
+	c, err := ConfigLoadDefault()
+	if err != nil {
+		return err
+	}
+	entries, err := configList(c)
+	if err != nil {
+		return err
+	}
+	return configOpts.rootOpts.writeOutput(cmd, configOpts.formatList, entries)
 }
 
 func (configOpts *configCmd) runConfigSet(cmd *cobra.Command, args []string) error {
@@ -113,6 +215,25 @@ func (configOpts *configCmd) runConfigSet(cmd *cobra.Command, args []string) err
 		}
 	}
 
+	if flagChanged(cmd, "token-cache") {
+		c.TokenCache = configOpts.tokenCache
+	}
+	if flagChanged(cmd, "cred-store") {
+		c.CredStore = configOpts.credStore
+	}
+	if len(args) == 2 {
+		if err := configKeySet(c, args[0], args[1]); err != nil {
+			return err
+		}
+	}
+
+	if configOpts.dryRun {
+		for i := range c.Hosts {
+			c.Hosts[i].Pass = ""
+			c.Hosts[i].Token = ""
+		}
+		return configOpts.rootOpts.writeOutput(cmd, "{{ printPretty . }}", c)
+	}
 	err = c.ConfigSave()
 	if err != nil {
 		return err
@@ -211,3 +332,342 @@ func (c *Config) ConfigSave() error {
 	outRdr := bytes.NewReader(out)
 	return cf.Write(outRdr)
 }
+
+// configHostExpandEnv replaces "${NAME}" references in the credentials of a host with the environment variable.
+// This is run on a copy of the host before creating a regclient, so the saved config never includes the secret.
+func configHostExpandEnv(h *config.Host) {
+	for _, field := range []*string{&h.User, &h.Pass, &h.Token, &h.ClientID, &h.ClientSecret, &h.ClientKey} {
+		*field = configExpandEnv(h.Name, *field)
+	}
+}
+
+// configExpandEnv replaces each "${NAME}" in a string with the environment variable, warning on undefined variables.
+func configExpandEnv(host, s string) string {
+	if s == "" {
+		return s
+	}
+	return configEnvRe.ReplaceAllStringFunc(s, func(match string) string {
+		name := configEnvRe.FindStringSubmatch(match)[1]
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			log.WithFields(logrus.Fields{
+				"host": host,
+				"env":  name,
+			}).Warn("Environment variable in config is not defined")
+		}
+		return val
+	})
+}
+
+// configEnvOnly indicates a value only contains references to environment variables.
+func configEnvOnly(s string) bool {
+	return s != "" && configEnvRe.ReplaceAllString(s, "") == ""
+}
+
+// configKeysGlobal returns the json field names of the global settings.
+func configKeysGlobal() []string {
+	keys := []string{}
+	for _, k := range configJSONFields(reflect.TypeOf(Config{})) {
+		if k != "version" && k != "hosts" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// configKeysHost returns the json field names of the host settings.
+func configKeysHost() []string {
+	return configJSONFields(reflect.TypeOf(config.Host{}))
+}
+
+// configJSONFields returns the json field names of a struct, skipping fields that are not marshaled.
+func configJSONFields(t reflect.Type) []string {
+	fields := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// configKeySplit parses a key into the host name and field, the host name is empty for global settings.
+// Host keys are "hosts.<name>.<field>", where the name may include periods.
+func configKeySplit(key string) (string, string, error) {
+	if !strings.HasPrefix(key, "hosts.") {
+		for _, k := range configKeysGlobal() {
+			if k == key {
+				return "", key, nil
+			}
+		}
+		return "", "", fmt.Errorf("unknown config key %s%.0w", key, ErrInvalidInput)
+	}
+	rest := strings.TrimPrefix(key, "hosts.")
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 || i == len(rest)-1 {
+		return "", "", fmt.Errorf("host keys must be hosts.<registry>.<field>, received %s%.0w", key, ErrInvalidInput)
+	}
+	name, field := config.HostNewName(rest[:i]).Name, rest[i+1:]
+	for _, k := range configKeysHost() {
+		if k == field {
+			return name, field, nil
+		}
+	}
+	return "", "", fmt.Errorf("unknown host config field %s%.0w", field, ErrInvalidInput)
+}
+
+// configFields returns the json encoded fields of a value.
+func configFields(v interface{}) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]json.RawMessage{}
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// configValueString converts a json value to a string, json strings are returned without quotes.
+func configValueString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// configKeyGet returns the value of a key, or an empty string when the key is not set.
+func configKeyGet(c *Config, key string) (string, error) {
+	name, field, err := configKeySplit(key)
+	if err != nil {
+		return "", err
+	}
+	var v interface{} = c
+	if name != "" {
+		h, ok := c.Hosts[name]
+		if !ok {
+			return "", fmt.Errorf("host %s is not configured%.0w", name, ErrNotFound)
+		}
+		v = h
+	}
+	m, err := configFields(v)
+	if err != nil {
+		return "", err
+	}
+	raw, ok := m[field]
+	if !ok {
+		return "", nil
+	}
+	return configValueString(raw), nil
+}
+
+// configKeySet updates the value of a key in the config, an empty value resets the key to the default.
+// The value is parsed as json, falling back to a json string, and the result is validated before it is applied.
+func configKeySet(c *Config, key, value string) error {
+	name, field, err := configKeySplit(key)
+	if err != nil {
+		return err
+	}
+	switch field {
+	case "pass", "token":
+		return fmt.Errorf("%s cannot be set in the config, use \"regctl registry login\"%.0w", field, ErrInvalidInput)
+	}
+	if name == "" {
+		// hosts are not included in the round trip so they retain the name and unexported fields
+		cNew := *c
+		cNew.Hosts = nil
+		m, err := configFields(cNew)
+		if err != nil {
+			return err
+		}
+		delete(m, "hosts")
+		cNew = Config{}
+		if err := configFieldSet(m, field, value, &cNew); err != nil {
+			return fmt.Errorf("invalid value for %s: %v%.0w", key, err, ErrInvalidInput)
+		}
+		switch cNew.CredStore {
+		case "", config.CredStoreAuto, config.CredStoreOSXKeychain, config.CredStoreWinCred, config.CredStoreSecretService, config.CredStorePass:
+		default:
+			return fmt.Errorf("unknown credential store %s%.0w", cNew.CredStore, ErrInvalidInput)
+		}
+		cNew.Filename, cNew.Version, cNew.Hosts = c.Filename, c.Version, c.Hosts
+		*c = cNew
+		return nil
+	}
+	h, ok := c.Hosts[name]
+	if !ok {
+		h = config.HostNewName(name)
+	}
+	m, err := configFields(h)
+	if err != nil {
+		return err
+	}
+	hNew := config.Host{}
+	if err := configFieldSet(m, field, value, &hNew); err != nil {
+		return fmt.Errorf("invalid value for %s: %v%.0w", key, err, ErrInvalidInput)
+	}
+	// defaults applied when loading the config
+	hNew.Name = h.Name
+	if hNew.Hostname == "" {
+		hNew.Hostname = h.Name
+	}
+	if hNew.TLS == config.TLSUndefined {
+		hNew.TLS = config.TLSEnabled
+	}
+	if err := configHostValidate(&hNew); err != nil {
+		return err
+	}
+	c.Hosts[name] = &hNew
+	return nil
+}
+
+// configFieldSet sets a field in the json fields and decodes the result into v.
+// A value that is not valid json for the field is retried as a json string.
+func configFieldSet(m map[string]json.RawMessage, field, value string, v interface{}) error {
+	if value == "" {
+		delete(m, field)
+		return configFieldsDecode(m, v)
+	}
+	var err error
+	if json.Valid([]byte(value)) {
+		m[field] = json.RawMessage(value)
+		if err = configFieldsDecode(m, v); err == nil {
+			return nil
+		}
+	}
+	quoted, _ := json.Marshal(value)
+	m[field] = json.RawMessage(quoted)
+	if errQuoted := configFieldsDecode(m, v); errQuoted != nil {
+		if err == nil {
+			err = errQuoted
+		}
+		return err
+	}
+	return nil
+}
+
+// configFieldsDecode decodes the json fields into v.
+func configFieldsDecode(m map[string]json.RawMessage, v interface{}) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// configHostValidate verifies the host settings that are limited to a set of values.
+func configHostValidate(h *config.Host) error {
+	switch h.CredProvider {
+	case "", config.CredProviderECR, config.CredProviderGCP, config.CredProviderGitHub:
+	default:
+		return fmt.Errorf("unknown credential provider %s%.0w", h.CredProvider, ErrInvalidInput)
+	}
+	if len(h.SPKIPins) > 0 {
+		if _, err := config.SPKIPinsVerify(h.SPKIPins); err != nil {
+			return fmt.Errorf("%v%.0w", err, ErrInvalidInput)
+		}
+	}
+	if _, err := config.TLSVersion(h.TLSMinVersion); err != nil {
+		return fmt.Errorf("%v%.0w", err, ErrInvalidInput)
+	}
+	switch h.RepoCreate {
+	case "", config.RepoCreateECR:
+	default:
+		return fmt.Errorf("unknown repository creator %s%.0w", h.RepoCreate, ErrInvalidInput)
+	}
+	switch h.RepoDelete {
+	case "", config.RepoDeleteArtifactory, config.RepoDeleteGitLab, config.RepoDeleteHarbor:
+	default:
+		return fmt.Errorf("unknown repository deleter %s%.0w", h.RepoDelete, ErrInvalidInput)
+	}
+	switch h.RepoList {
+	case "", config.RepoListDockerHub, config.RepoListECR, config.RepoListGCR:
+	default:
+		return fmt.Errorf("unknown repository lister %s%.0w", h.RepoList, ErrInvalidInput)
+	}
+	switch h.DigestAlgorithm {
+	case "", digest.SHA256.String(), digest.SHA512.String():
+	default:
+		return fmt.Errorf("unsupported digest algorithm %s%.0w", h.DigestAlgorithm, ErrInvalidInput)
+	}
+	if h.ReqPerSec < 0 {
+		return fmt.Errorf("reqPerSec must not be negative%.0w", ErrInvalidInput)
+	}
+	if h.RetryJitter < 0 || h.RetryJitter > 1 {
+		return fmt.Errorf("retryJitter must be between 0 and 1%.0w", ErrInvalidInput)
+	}
+	switch h.RedirectAuth {
+	case config.RedirectAuthDefault, config.RedirectAuthStrip, config.RedirectAuthKeep:
+	default:
+		return fmt.Errorf("unknown redirectAuth value \"%s\"%.0w", h.RedirectAuth, ErrInvalidInput)
+	}
+	switch h.IPFamily {
+	case config.IPFamilyDual, config.IPFamilyIPv4, config.IPFamilyIPv6:
+	default:
+		return fmt.Errorf("unknown ipFamily value \"%s\"%.0w", h.IPFamily, ErrInvalidInput)
+	}
+	for name, ips := range h.StaticHosts {
+		for _, ip := range ips {
+			if net.ParseIP(ip) == nil {
+				return fmt.Errorf("static host %s address \"%s\" is not a valid ip%.0w", name, ip, ErrInvalidInput)
+			}
+		}
+	}
+	return nil
+}
+
+// configList returns each key in the config that is set, sorted by key.
+// Passwords, tokens, and client secrets are not included.
+func configList(c *Config) ([]configEntry, error) {
+	entries := []configEntry{}
+	m, err := configFields(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range configKeysGlobal() {
+		if raw, ok := m[k]; ok {
+			entries = append(entries, configEntry{Key: k, Value: configValueString(raw)})
+		}
+	}
+	for name, h := range c.Hosts {
+		hm, err := configFields(h)
+		if err != nil {
+			return nil, err
+		}
+		for k, raw := range hm {
+			switch k {
+			case "pass", "token", "clientKey", "clientSecret":
+				continue
+			}
+			if v := configValueString(raw); v != "" {
+				entries = append(entries, configEntry{Key: "hosts." + name + "." + k, Value: v})
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries, nil
+}
+
+func (configOpts *configCmd) completeConfigKey(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+	keys := configKeysGlobal()
+	c, err := ConfigLoadDefault()
+	if err == nil {
+		for name := range c.Hosts {
+			for _, k := range configKeysHost() {
+				keys = append(keys, "hosts."+name+"."+k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -26,7 +28,8 @@ func TestConfig(t *testing.T) {
 
 	// set options
 	testLimit := "420000000"
-	out, err = cobraTest(t, nil, "config", "set", "--blob-limit", testLimit, "--docker-cert=false", "--docker-cred=false")
+	testTokenCache := filepath.Join(tempDir, "tokens.json")
+	out, err = cobraTest(t, nil, "config", "set", "--blob-limit", testLimit, "--docker-cert=false", "--docker-cred=false", "--token-cache", testTokenCache)
 	if err != nil {
 		t.Errorf("failed to set config: %v", err)
 	}
@@ -57,8 +60,16 @@ func TestConfig(t *testing.T) {
 		t.Errorf("unexpected output for docker-cred, expected: false, received: %s", out)
 	}
 
+	out, err = cobraTest(t, nil, "config", "get", "--format", "{{ .TokenCache }}")
+	if err != nil {
+		t.Errorf("failed to run config get on token-cache: %v", err)
+	}
+	if out != testTokenCache {
+		t.Errorf("unexpected output for token-cache, expected: %s, received: %s", testTokenCache, out)
+	}
+
 	// reset back to zero values
-	out, err = cobraTest(t, nil, "config", "set", "--blob-limit", "0", "--docker-cert", "--docker-cred")
+	out, err = cobraTest(t, nil, "config", "set", "--blob-limit", "0", "--docker-cert", "--docker-cred", "--token-cache", "")
 	if err != nil {
 		t.Errorf("failed to set blob-limit: %v", err)
 	}
@@ -76,3 +87,110 @@ func TestConfig(t *testing.T) {
 	}
 
 }
+
+func TestConfigKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	origEnv, set := os.LookupEnv(ConfigEnv)
+	if set {
+		defer os.Setenv(ConfigEnv, origEnv)
+	}
+	os.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+
+	tt := []struct {
+		name      string
+		key       string
+		value     string
+		expect    string
+		expectErr error
+	}{
+		{name: "blob limit", key: "blobLimit", value: "1024", expect: "1024"},
+		{name: "cred store", key: "credStore", value: "pass", expect: "pass"},
+		{name: "cred store invalid", key: "credStore", value: "vault", expectErr: ErrInvalidInput},
+		{name: "tls", key: "hosts.registry.example.org.tls", value: "disabled", expect: "disabled"},
+		{name: "tls invalid", key: "hosts.registry.example.org.tls", value: "sometimes", expectErr: ErrInvalidInput},
+		{name: "mirrors", key: "hosts.docker.io.mirrors", value: `["mirror.example.org"]`, expect: `["mirror.example.org"]`},
+		{name: "mirrors string", key: "hosts.docker.io.mirrors", value: "mirror.example.org", expectErr: ErrInvalidInput},
+		{name: "req per sec", key: "hosts.docker.io.reqPerSec", value: "2.5", expect: "2.5"},
+		{name: "req per sec invalid", key: "hosts.docker.io.reqPerSec", value: "fast", expectErr: ErrInvalidInput},
+		{name: "blob chunk", key: "hosts.registry.example.org.blobChunk", value: "1048576", expect: "1048576"},
+		{name: "blob chunk reset", key: "hosts.registry.example.org.blobChunk", value: "", expect: ""},
+		{name: "numeric string", key: "hosts.registry.example.org.hostname", value: "127", expect: "127"},
+		{name: "duration", key: "hosts.registry.example.org.retryBudget", value: "90s", expect: "1m30s"},
+		{name: "retry jitter", key: "hosts.registry.example.org.retryJitter", value: "2", expectErr: ErrInvalidInput},
+		{name: "ip family", key: "hosts.registry.example.org.ipFamily", value: "ipv5", expectErr: ErrInvalidInput},
+		{name: "password", key: "hosts.registry.example.org.pass", value: "secret", expectErr: ErrInvalidInput},
+		{name: "unknown field", key: "hosts.registry.example.org.color", value: "blue", expectErr: ErrInvalidInput},
+		{name: "unknown key", key: "color", value: "blue", expectErr: ErrInvalidInput},
+		{name: "missing field", key: "hosts.registry", value: "blue", expectErr: ErrInvalidInput},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := cobraTest(t, nil, "config", "set", tc.key, tc.value)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Fatalf("set did not fail")
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Fatalf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to set %s: %v", tc.key, err)
+			}
+			out, err := cobraTest(t, nil, "config", "get", tc.key)
+			if err != nil {
+				t.Fatalf("failed to get %s: %v", tc.key, err)
+			}
+			if out != tc.expect {
+				t.Errorf("unexpected value, expected %s, received %s", tc.expect, out)
+			}
+		})
+	}
+
+	t.Run("list", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "config", "list")
+		if err != nil {
+			t.Fatalf("failed to list: %v", err)
+		}
+		for _, line := range []string{
+			"blobLimit=1024",
+			"hosts.docker.io.reqPerSec=2.5",
+			"hosts.registry.example.org.tls=disabled",
+		} {
+			if !strings.Contains(out+"\n", line+"\n") {
+				t.Errorf("missing %s in list: %s", line, out)
+			}
+		}
+		if strings.Contains(out, "blobChunk") {
+			t.Errorf("reset key included in list: %s", out)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "config", "set", "--dry-run", "hosts.registry.example.org.retryLimit", "5", "--format", "{{ json . }}")
+		if err == nil {
+			t.Errorf("dry run accepted the get format flag: %s", out)
+		}
+		out, err = cobraTest(t, nil, "config", "set", "--dry-run", "hosts.registry.example.org.retryLimit", "5")
+		if err != nil {
+			t.Fatalf("failed to run dry run: %v", err)
+		}
+		if !strings.Contains(out, `"retryLimit": 5`) {
+			t.Errorf("dry run output missing the change: %s", out)
+		}
+		out, err = cobraTest(t, nil, "config", "get", "hosts.registry.example.org.retryLimit")
+		if err != nil {
+			t.Fatalf("failed to get retryLimit: %v", err)
+		}
+		if out != "" {
+			t.Errorf("dry run saved the config, retryLimit is %s", out)
+		}
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "config", "get", "hosts.missing.example.org.tls")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -3,6 +3,8 @@ package main
 import "errors"
 
 var (
+	// ErrAborted indicates the user did not confirm a change
+	ErrAborted = errors.New("aborted")
 	// ErrCredsNotFound returned when creds needed and cannot be found
 	ErrCredsNotFound = errors.New("auth creds not found")
 	// ErrInvalidInput indicates a required field is invalid
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// outputFormats are the supported values of the --output flag.
+var outputFormats = []string{outputTable, outputJSON, outputYAML}
+
+// formatsRaw are the built-in formats for the raw response of a request.
+var formatsRaw = map[string]string{
+	"raw":      "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}{{printf \"\\n%s\" .RawBody}}",
+	"rawBody":  "{{printf \"%s\" .RawBody}}",
+	"raw-body": "{{printf \"%s\" .RawBody}}",
+	"body":     "{{printf \"%s\" .RawBody}}",
+}
+
+// formatsRawHeaders are the built-in formats for the headers of a request.
+var formatsRawHeaders = map[string]string{
+	"rawHeaders":  "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}",
+	"raw-headers": "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}",
+	"headers":     "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}",
+}
+
+// formatsImageInspect are the built-in formats for the image config.
+var formatsImageInspect = map[string]string{
+	"created":  "{{ with .Created }}{{ printf \"%s\\n\" (.Format \"2006-01-02T15:04:05Z07:00\") }}{{ end }}",
+	"digest":   "{{ printf \"%s\\n\" .GetDescriptor.Digest }}",
+	"env":      "{{ range .Config.Env }}{{ printf \"%s\\n\" . }}{{ end }}",
+	"labels":   "{{ range $key, $val := .Config.Labels }}{{ printf \"%s=%s\\n\" $key $val }}{{ end }}",
+	"platform": "{{ printf \"%s\\n\" .Platform }}",
+}
+
+// formatsManifest are the built-in formats for a manifest.
+var formatsManifest = map[string]string{
+	"digest":     "{{ printf \"%s\\n\" .GetDescriptor.Digest }}",
+	"media-type": "{{ printf \"%s\\n\" .GetDescriptor.MediaType }}",
+	"mediaType":  "{{ printf \"%s\\n\" .GetDescriptor.MediaType }}",
+}
+
+// formatsManifestBody are the built-in formats for a manifest that include the body.
+var formatsManifestBody = map[string]string{
+	"annotations": "{{ range $key, $val := .GetAnnotations }}{{ printf \"%s=%s\\n\" $key $val }}{{ end }}",
+	"created":     "{{ with index .GetAnnotations \"org.opencontainers.image.created\" }}{{ printf \"%s\\n\" . }}{{ end }}",
+	"platforms":   "{{ if .IsList }}{{ range .GetManifestList }}{{ with .Platform }}{{ if ne .OS \"unknown\" }}{{ printf \"%s\\n\" . }}{{ end }}{{ end }}{{ end }}{{ end }}",
+}
+
+// formatBuiltin returns the template for a built-in format, or the format unchanged when it is not a built-in name.
+func formatBuiltin(format string, builtins ...map[string]string) string {
+	for _, b := range builtins {
+		if tmpl, ok := b[format]; ok {
+			return tmpl
+		}
+	}
+	return format
+}
+
+// completeFormat completes the names of built-in formats.
+func completeFormat(builtins ...map[string]string) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names := []string{}
+		for _, b := range builtins {
+			for name := range b {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// outputValidate verifies the value of the --output flag.
+func outputValidate(output string) error {
+	if output == "" {
+		return nil
+	}
+	for _, o := range outputFormats {
+		if o == output {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output %s, expected one of %s%.0w", output, strings.Join(outputFormats, ", "), ErrInvalidInput)
+}
+
+// refResult is the structured output of commands that print the reference to an image they created.
+type refResult struct {
+	Reference string `json:"reference"`
+	Digest    string `json:"digest,omitempty"`
+}
+
+// outputStructured returns true when json or yaml output is selected with --output.
+func (rootOpts *rootCmd) outputStructured() bool {
+	return rootOpts.output == outputJSON || rootOpts.output == outputYAML
+}
+
+// writeRef outputs the reference to an image created by a command.
+func (rootOpts *rootCmd) writeRef(cmd *cobra.Command, r ref.Ref) error {
+	if ok, err := rootOpts.writeStructured(cmd, refResult{Reference: r.CommonName(), Digest: r.Digest}); ok {
+		return err
+	}
+	_, err := fmt.Fprintf(cmd.OutOrStdout(), "%s\n", r.CommonName())
+	return err
+}
+
+// writeOutput renders the result of a command.
+// Table output, the default, uses the go template format of the command.
+func (rootOpts *rootCmd) writeOutput(cmd *cobra.Command, format string, data interface{}) error {
+	if ok, err := rootOpts.writeStructured(cmd, data); ok {
+		return err
+	}
+	return template.Writer(cmd.OutOrStdout(), format, data)
+}
+
+// writeStructured renders data as json or yaml when selected with --output.
+// It returns false for table output, leaving the command to write its own human readable output.
+func (rootOpts *rootCmd) writeStructured(cmd *cobra.Command, data interface{}) (bool, error) {
+	switch rootOpts.output {
+	case outputJSON:
+		b, err := outputMarshalJSON(outputConvert(data))
+		if err != nil {
+			return true, err
+		}
+		_, err = cmd.OutOrStdout().Write(b)
+		return true, err
+	case outputYAML:
+		b, err := outputMarshalJSON(outputConvert(data))
+		if err != nil {
+			return true, err
+		}
+		// yaml is generated from the json to keep the field names consistent between the two outputs
+		var node yaml.Node
+		err = yaml.Unmarshal(b, &node)
+		if err != nil {
+			return true, err
+		}
+		outputYAMLStyle(&node)
+		enc := yaml.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent(2)
+		err = enc.Encode(&node)
+		if err != nil {
+			return true, err
+		}
+		return true, enc.Close()
+	default:
+		return false, nil
+	}
+}
+
+// outputRef is the structured output of a reference.
+// The json of [ref.Ref] is left unchanged for existing consumers of the library.
+type outputRef struct {
+	Scheme     string `json:"scheme"`
+	Reference  string `json:"reference"`
+	Registry   string `json:"registry,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+	Path       string `json:"path,omitempty"`
+}
+
+func outputRefNew(r ref.Ref) outputRef {
+	return outputRef{
+		Scheme:     r.Scheme,
+		Reference:  r.Reference,
+		Registry:   r.Registry,
+		Repository: r.Repository,
+		Tag:        r.Tag,
+		Digest:     r.Digest,
+		Path:       r.Path,
+	}
+}
+
+// outputConverter is implemented by command results that include a reference.
+type outputConverter interface {
+	outputStructured() interface{}
+}
+
+// outputConvert replaces references in the data with [outputRef] for json and yaml output.
+func outputConvert(data interface{}) interface{} {
+	switch v := data.(type) {
+	case ref.Ref:
+		return outputRefNew(v)
+	case *referrer.ReferrerList:
+		if v == nil {
+			return v
+		}
+		return outputConvert(*v)
+	case referrer.ReferrerList:
+		return struct {
+			Subject     outputRef          `json:"subject"`
+			Descriptors []types.Descriptor `json:"descriptors"`
+			Annotations map[string]string  `json:"annotations,omitempty"`
+		}{
+			Subject:     outputRefNew(v.Subject),
+			Descriptors: v.Descriptors,
+			Annotations: v.Annotations,
+		}
+	case []regclient.RenameEntry:
+		type renameEntry struct {
+			Source outputRef `json:"source"`
+			Target outputRef `json:"target"`
+			Digest string    `json:"digest"`
+		}
+		entries := make([]renameEntry, len(v))
+		for i, e := range v {
+			entries[i] = renameEntry{Source: outputRefNew(e.Source), Target: outputRefNew(e.Target), Digest: e.Digest.String()}
+		}
+		return entries
+	case outputConverter:
+		return v.outputStructured()
+	}
+	return data
+}
+
+func outputMarshalJSON(data interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// outputYAMLStyle resets the flow and quoting styles parsed from json to the default yaml block style.
+func outputYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		outputYAMLStyle(child)
+	}
+}
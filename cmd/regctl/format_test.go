@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOutput(t *testing.T) {
+	tt := []struct {
+		name        string
+		args        []string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:      "Invalid output",
+			args:      []string{"version", "--output", "xml"},
+			expectErr: ErrInvalidInput,
+		},
+		{
+			name:      "Table default",
+			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo:v1", "--output", "table"},
+			expectOut: "sha256:0d4ea07d06d42ad1cb756a5d9e71f62288e426b22a230b224410164181998c4c",
+		},
+		{
+			name: "Manifest head json",
+			args: []string{"manifest", "head", "ocidir://../../testdata/testrepo:v1", "--output", "json"},
+			expectOut: `{
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "digest": "sha256:0d4ea07d06d42ad1cb756a5d9e71f62288e426b22a230b224410164181998c4c",
+  "size": 1262,
+  "annotations": {
+    "org.opencontainers.image.ref.name": "v1"
+  }
+}`,
+		},
+		{
+			name: "Manifest head yaml",
+			args: []string{"manifest", "head", "ocidir://../../testdata/testrepo:v1", "--output", "yaml"},
+			expectOut: `mediaType: application/vnd.oci.image.index.v1+json
+digest: sha256:0d4ea07d06d42ad1cb756a5d9e71f62288e426b22a230b224410164181998c4c
+size: 1262
+annotations:
+  org.opencontainers.image.ref.name: v1`,
+		},
+		{
+			name:        "Output ignores format",
+			args:        []string{"image", "inspect", "ocidir://../../testdata/testrepo:v1", "--platform", "linux/amd64", "--format", "digest", "--output", "yaml"},
+			expectOut:   `created: "2021-01-01T00:00:00Z"`,
+			outContains: true,
+		},
+		{
+			name:        "Artifact list json",
+			args:        []string{"artifact", "list", "ocidir://../../testdata/testrepo:v1", "--output", "json"},
+			expectOut:   "\"subject\": {\n    \"scheme\": \"ocidir\",",
+			outContains: true,
+		},
+		{
+			name:        "Artifact list format json",
+			args:        []string{"artifact", "list", "ocidir://../../testdata/testrepo:v1", "--format", "{{ json .Subject }}"},
+			expectOut:   `{"Scheme":"ocidir",`,
+			outContains: true,
+		},
+		{
+			name:      "Tag list json",
+			args:      []string{"tag", "ls", "ocidir://../../testdata/testrepo", "--include", "^v[12]$", "--output", "json"},
+			expectOut: "[\n  \"v1\",\n  \"v2\"\n]",
+		},
+		{
+			name:        "Tag list details yaml",
+			args:        []string{"tag", "ls", "ocidir://../../testdata/testrepo", "--include", "^v1$", "--details", "--output", "yaml"},
+			expectOut:   "- tag: v1\n  digest: sha256:0d4ea07d06d42ad1cb756a5d9e71f62288e426b22a230b224410164181998c4c\n",
+			outContains: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("returned unexpected error: %v", err)
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"archive/tar"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
@@ -16,12 +18,14 @@ import (
 	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/internal/ascii"
 	"github.com/regclient/regclient/internal/units"
 	"github.com/regclient/regclient/mod"
-	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/pkg/notation"
+	"github.com/regclient/regclient/pkg/sign"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/blob"
 	"github.com/regclient/regclient/types/manifest"
@@ -32,26 +36,50 @@ import (
 
 type imageCmd struct {
 	rootOpts        *rootCmd
+	autoRebase      bool
+	blobVerify      int
 	checkBaseRef    string
 	checkBaseDigest string
 	checkSkipConfig bool
 	create          string
+	digestOnly      bool
+	dryRun          bool
+	exportChecksums string
 	exportCompress  bool
 	exportRef       string
 	fastCheck       bool
 	forceRecursive  bool
 	format          string
+	formatCheckBase string
+	formatCompare   string
 	formatFile      string
+	formatFiles     string
+	formatLint      string
+	importChecksums string
 	importName      string
 	includeExternal bool
+	toDocker        bool
+	toOCI           bool
+	externalCopy    bool
 	digestTags      bool
+	lintPolicy      string
 	list            bool
 	modOpts         []mod.Opts
 	platform        string
 	platforms       []string
+	rebaseOld       string
+	rebaseSuffix    string
 	referrers       bool
 	replace         bool
 	requireList     bool
+	signAnnotations []string
+	signCosignTag   bool
+	signEphemeral   bool
+	signIdentity    string
+	signKey         string
+	verifyPolicy    string
+	verifyTrust     string
+	verifyType      string
 }
 
 func NewImageCmd(rootOpts *rootCmd) *cobra.Command {
@@ -76,11 +104,40 @@ If the digest is available, this checks if that matches the base name.
 If the digest is not available, layers of each manifest are compared.
 If the layers match, the config (history and roots) are optionally compared.	
 If the base image does not match, the command exits with a non-zero status.
-Use "-v info" to see more details.`,
+Use "-v info" to see more details, or "--format" to output a report of the check,
+including the base digest, the number of matching layers, and the first changed layer.
+With "--auto-rebase", an image with an outdated base is rebased onto the current
+base digest and pushed to the tag with the "--rebase-suffix" appended. The command
+outputs the rebased reference and exits with a zero status on a successful rebase.`,
+		Example: `
+# check the base image using the annotations on the image
+regctl image check-base registry.example.org/repo:v1
+
+# output a json report of the check
+regctl image check-base --format '{{json .}}' registry.example.org/repo:v1
+
+# rebase the image to registry.example.org/repo:v1-rebased when the base has changed
+regctl image check-base --auto-rebase registry.example.org/repo:v1`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageCheckBase,
 	}
+	var imageCompareLayersCmd = &cobra.Command{
+		Use:   "compare-layers <image_ref> <image_ref>",
+		Short: "compare the layers of two images",
+		Long: `Report the layers shared between two images and the layers unique to each.
+The savings are the bytes of shared layers that are only transferred once when
+both images are copied together, e.g. by copying the image with the most shared
+layers first to allow cross repository mounts in the target registry.
+For a multi-platform image, layers from every platform are included unless a
+platform is selected.`,
+		Example: `
+# compare an image to its base image
+regctl image compare-layers registry.example.com/app:v1 alpine:3`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageCompareLayers,
+	}
 	var imageCopyCmd = &cobra.Command{
 		Use:     "copy <src_image_ref> <dst_image_ref>",
 		Aliases: []string{"cp"},
@@ -133,6 +190,22 @@ Example usage: regctl image export registry:5000/yourimg:v1 >yourimg-v1.tar`,
 		ValidArgsFunction: completeArgList([]completeFunc{rootOpts.completeArgTag, completeArgNone, completeArgNone}),
 		RunE:              imageOpts.runImageGetFile,
 	}
+	var imageFilesCmd = &cobra.Command{
+		Use:   "files <image_ref>",
+		Short: "list the files in an image",
+		Long: `List the files in the filesystem of an image.
+Each layer is applied in order, removing files deleted with whiteouts,
+so the list matches the filesystem of a container run from the image.`,
+		Example: `
+# list the files in an image
+regctl image files alpine
+
+# list the files added by each layer
+regctl image files --format '{{range .}}{{printf "%s %s\n" .Layer .Name}}{{end}}' alpine`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageFiles,
+	}
 	var imageImportCmd = &cobra.Command{
 		Use:   "import <image_ref> <filename>",
 		Short: "import image",
@@ -153,6 +226,33 @@ in docker, and inspecting it, but without pulling any of the image layers.`,
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageInspect,
 	}
+	var imageLintCmd = &cobra.Command{
+		Use:   "lint <image_ref>",
+		Short: "check image annotations, labels, and platforms",
+		Long: `Check an image for recommended annotations and labels, mismatched values between
+the config labels and manifest annotations, and missing platforms.
+By default, the source, revision, created, and licenses keys from the OCI
+annotations are required as either an annotation or label.
+A policy file in yaml or json may replace the required keys and list required platforms:
+
+  required:
+    - org.opencontainers.image.source
+    - org.opencontainers.image.revision
+  platforms:
+    - linux/amd64
+    - linux/arm64
+
+The command fails when any errors are found.`,
+		Example: `
+# lint an image with the default policy
+regctl image lint registry.example.com/app:v1
+
+# lint an image with a policy file
+regctl image lint --policy lint.yaml registry.example.com/app:v1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageLint,
+	}
 	var imageManifestCmd = &cobra.Command{
 		Use:               "manifest <image_ref>",
 		Short:             "show manifest or manifest list, same as \"manifest get\"",
@@ -178,6 +278,27 @@ For time options, the value is a comma separated list of key/value pairs:
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageMod,
 	}
+	var imageRebaseCmd = &cobra.Command{
+		Use:   "rebase <image_ref>",
+		Short: "rebase an image onto the latest base image",
+		Long: `Replace the layers and history of the original base image with the new base image.
+The base image name and original digest are found using annotations, or may be provided with options.
+The new base image is the current digest of the base name.
+Each platform of a multi-platform image is rebased onto the matching platform of the new base.
+The rebased image is pushed to the same tag, and the reference by digest is output.
+Referrers of the original image are optionally copied to the rebased image.`,
+		Example: `
+# rebase an image using the base image annotations
+regctl image rebase registry.example.org/repo:v1
+
+# rebase an image with a base image that has been updated since the build
+regctl image rebase --base registry.example.org/base:latest \
+  --digest sha256:15a3231581118fd8ce33fd581cbae6958e990cbc99a6a7a1f50c63cce95d4ec5 \
+  registry.example.org/repo:v1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageRebase,
+	}
 	var imageRateLimitCmd = &cobra.Command{
 		Use:   "ratelimit <image_ref>",
 		Short: "show the current rate limit",
@@ -189,21 +310,79 @@ The other values may be 0 if not provided by the registry.`,
 		RunE:              imageOpts.runImageRateLimit,
 	}
 
+	var imageSignCmd = &cobra.Command{
+		Use:   "sign <image_ref>",
+		Short: "sign an image",
+		Long: `Create a cosign compatible signature of an image.
+The signature is pushed as a referrer to the image digest, using the referrers fallback tag on registries without the referrers API.
+Use --cosign-tag to push the signature to the "sha256-<hex>.sig" tag used by older versions of cosign.
+The signing key is an unencrypted PEM private key, or an ephemeral key with a self-signed certificate using --ephemeral.
+The ephemeral certificate is not issued by Fulcio or recorded in Rekor, so this is not cosign keyless signing.
+The reference to the signature manifest is output.`,
+		Example: `
+# sign an image with a local key
+regctl image sign --key cosign.key registry.example.org/repo:v1
+
+# sign an image with an ephemeral key and certificate for an identity
+regctl image sign --ephemeral --identity user@example.org registry.example.org/repo:v1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageSign,
+	}
+
+	var imageVerifyCmd = &cobra.Command{
+		Use:   "verify <image_ref>",
+		Short: "verify the signatures of an image",
+		Long: `Verify the signatures attached to an image as referrers.
+The "notation" type verifies Notation signatures in the JWS and COSE formats against the certificates in a trust store directory.
+The trust policy selects the stores for the repository from its trustStores, e.g. "ca:example" loads "x509/ca/example" in the trust store directory.
+The trust policy defaults to "trustpolicy.json" next to the trust store directory, matching the notation CLI configuration.
+The result of each signature is output, and the command fails unless at least one signature is valid.`,
+		Example: `
+# verify the notation signatures of an image
+regctl image verify --type notation --trust-store ./truststore registry.example.org/repo:v1
+
+# verify with a trust policy in another location
+regctl image verify --trust-store ./truststore --trust-policy ./policy.json \
+  registry.example.org/repo:v1
+
+# output the signing certificate of each valid signature
+regctl image verify --type notation --trust-store ./truststore \
+  --format '{{range .}}{{if not .Error}}{{println .Subject}}{{end}}{{end}}' \
+  registry.example.org/repo:v1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageVerify,
+	}
+
 	imageOpts.modOpts = []mod.Opts{}
 
+	imageCheckBaseCmd.Flags().BoolVarP(&imageOpts.autoRebase, "auto-rebase", "", false, "Rebase the image when the base image has changed")
 	imageCheckBaseCmd.Flags().StringVarP(&imageOpts.checkBaseRef, "base", "", "", "Base image reference (including tag)")
 	imageCheckBaseCmd.Flags().StringVarP(&imageOpts.checkBaseDigest, "digest", "", "", "Base image digest (checks if digest matches base)")
+	imageCheckBaseCmd.Flags().StringVarP(&imageOpts.formatCheckBase, "format", "", "", "Format the report with go template syntax")
 	imageCheckBaseCmd.Flags().BoolVarP(&imageOpts.checkSkipConfig, "no-config", "", false, "Skip check of config history")
 	imageCheckBaseCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	imageCheckBaseCmd.Flags().StringVarP(&imageOpts.rebaseSuffix, "rebase-suffix", "", "-rebased", "Suffix added to the tag of an image rebased with --auto-rebase, empty to replace the tag")
 
+	imageCompareLayersCmd.Flags().StringVarP(&imageOpts.formatCompare, "format", "", "", "Format output with go template syntax")
+	imageCompareLayersCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	_ = imageCompareLayersCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = imageCompareLayersCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+
+	imageCopyCmd.Flags().BoolVarP(&imageOpts.digestOnly, "digest-only", "", false, "Copy by digest without creating a tag on the destination, outputs the digest")
+	imageCopyCmd.Flags().BoolVarP(&imageOpts.dryRun, "dry-run", "", false, "Report the manifests and blobs that would be copied without pushing them")
+	imageCopyCmd.Flags().BoolVarP(&imageOpts.externalCopy, "external-urls-copy", "", false, "Copy external layers from their urls as regular layers, changing the image digest")
 	imageCopyCmd.Flags().BoolVarP(&imageOpts.fastCheck, "fast", "", false, "Fast check, skip referrers and digest tag checks when image exists, overrides force-recursive")
+	imageCopyCmd.Flags().IntVarP(&imageOpts.blobVerify, "force-blob-verify", "", 0, "Hash existing blobs in the target, optionally a percent to sample, repairs corrupt blobs")
+	imageCopyCmd.Flags().Lookup("force-blob-verify").NoOptDefVal = "100"
 	imageCopyCmd.Flags().BoolVarP(&imageOpts.forceRecursive, "force-recursive", "", false, "Force recursive copy of image, repairs missing nested blobs and manifests")
 	imageCopyCmd.Flags().StringVarP(&imageOpts.format, "format", "", "", "Format output with go template syntax")
 	imageCopyCmd.Flags().BoolVarP(&imageOpts.includeExternal, "include-external", "", false, "Include external layers")
 	imageCopyCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
-	imageCopyCmd.Flags().StringArrayVarP(&imageOpts.platforms, "platforms", "", []string{}, "Copy only specific platforms, registry validation must be disabled")
-	// platforms should be treated as experimental since it will break many registries
-	_ = imageCopyCmd.Flags().MarkHidden("platforms")
+	imageCopyCmd.Flags().StringArrayVarP(&imageOpts.platforms, "platforms", "", []string{}, "Copy only platforms matching an expression (e.g. linux/*, !windows), rewriting the index")
+	imageCopyCmd.Flags().BoolVarP(&imageOpts.toDocker, "to-docker", "", false, "Convert manifests to Docker schema2 media types, changing the digest of converted manifests")
+	imageCopyCmd.Flags().BoolVarP(&imageOpts.toOCI, "to-oci", "", false, "Convert manifests to OCI media types, changing the digest of converted manifests")
 	imageCopyCmd.Flags().BoolVarP(&imageOpts.digestTags, "digest-tags", "", false, "Include digest tags (\"sha256-<digest>.*\") when copying manifests")
 	imageCopyCmd.Flags().BoolVarP(&imageOpts.referrers, "referrers", "", false, "Include referrers")
 
@@ -218,16 +397,29 @@ The other values may be 0 if not provided by the registry.`,
 	imageGetFileCmd.Flags().StringVarP(&imageOpts.formatFile, "format", "", "", "Format output with go template syntax")
 	imageGetFileCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 
+	imageFilesCmd.Flags().StringVarP(&imageOpts.formatFiles, "format", "", "", "Format output with go template syntax")
+	imageFilesCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	_ = imageFilesCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = imageFilesCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+
+	imageExportCmd.Flags().StringVar(&imageOpts.exportChecksums, "checksums", "", "Write a sha256 checksums file for the exported tar")
 	imageExportCmd.Flags().BoolVar(&imageOpts.exportCompress, "compress", false, "Compress output with gzip")
 	imageExportCmd.Flags().StringVar(&imageOpts.exportRef, "name", "", "Name of image to embed for docker load")
 	imageExportCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	imageExportCmd.Flags().StringArrayVarP(&imageOpts.platforms, "platforms", "", []string{}, "Export only platforms matching an expression from an index (e.g. linux/*, !windows)")
 
+	imageImportCmd.Flags().StringVar(&imageOpts.importChecksums, "checksums", "", "Verify the tar against a checksums file from image export")
 	imageImportCmd.Flags().StringVar(&imageOpts.importName, "name", "", "Name of image or tag to import when multiple images are packaged in the tar")
 
 	imageInspectCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
-	imageInspectCmd.Flags().StringVarP(&imageOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	imageInspectCmd.Flags().StringVarP(&imageOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax or a built-in name (created, digest, env, labels, platform)")
 	_ = imageInspectCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
-	_ = imageInspectCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = imageInspectCmd.RegisterFlagCompletionFunc("format", completeFormat(formatsImageInspect))
+
+	imageLintCmd.Flags().StringVarP(&imageOpts.formatLint, "format", "", "", "Format output with go template syntax")
+	imageLintCmd.Flags().StringVarP(&imageOpts.lintPolicy, "policy", "", "", "Policy file with the required keys and platforms")
+	_ = imageLintCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = imageLintCmd.RegisterFlagCompletionFunc("policy", completeArgDefault)
 
 	imageManifestCmd.Flags().BoolVarP(&manifestOpts.list, "list", "", true, "Output manifest list if available (enabled by default)")
 	imageManifestCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
@@ -390,6 +582,20 @@ The other values may be 0 if not provided by the registry.`,
 		},
 	}, "external-urls-rm", "", `remove external url references from layers (first copy image with "--include-external")`)
 	flagExtURLsRm.NoOptDefVal = "true"
+	flagExtURLsCopy := imageModCmd.Flags().VarPF(&modFlagFunc{
+		t: "bool",
+		f: func(val string) error {
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("unable to parse value %s: %w", val, err)
+			}
+			if b {
+				imageOpts.modOpts = append(imageOpts.modOpts, mod.WithExternalURLsCopy())
+			}
+			return nil
+		},
+	}, "external-urls-copy", "", `copy external layers from their urls and remove the url references`)
+	flagExtURLsCopy.NoOptDefVal = "true"
 	imageModCmd.Flags().VarP(&modFlagFunc{
 		t: "stringArray",
 		f: func(val string) error {
@@ -460,6 +666,20 @@ The other values may be 0 if not provided by the registry.`,
 		},
 	}, "label-to-annotation", "", `set annotations from labels`)
 	flagLabelAnnot.NoOptDefVal = "true"
+	flagLayerEStargz := imageModCmd.Flags().VarPF(&modFlagFunc{
+		t: "bool",
+		f: func(val string) error {
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("unable to parse value %s: %w", val, err)
+			}
+			if b {
+				imageOpts.modOpts = append(imageOpts.modOpts, mod.WithLayerEStargz())
+			}
+			return nil
+		},
+	}, "layer-estargz", "", `convert layers to eStargz for lazy file access`)
+	flagLayerEStargz.NoOptDefVal = "true"
 	imageModCmd.Flags().VarP(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -526,6 +746,24 @@ The other values may be 0 if not provided by the registry.`,
 		},
 	}, "layer-time-max", "", `max timestamp for a layer`)
 	_ = imageModCmd.Flags().MarkHidden("layer-time-max") // TODO: deprecate in favor of layer-time
+	imageModCmd.Flags().VarP(&modFlagFunc{
+		t: "stringArray",
+		f: func(val string) error {
+			platforms := []string{}
+			if val != "" && val != "*" {
+				platforms = strings.Split(val, ",")
+			}
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithPlatformFilter(platforms))
+			return nil
+		},
+	}, "platform-filter", "", `limit the following flags to a comma separated list of platforms, "*" to reset`)
+	imageModCmd.Flags().VarP(&modFlagFunc{
+		t: "stringArray",
+		f: func(val string) error {
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithPlatforms(strings.Split(val, ",")))
+			return nil
+		},
+	}, "platforms", "", `remove index entries not matching a comma separated list of platforms (e.g. "linux/*,!linux/386")`)
 	flagRebase := imageModCmd.Flags().VarPF(&modFlagFunc{
 		t: "bool",
 		f: func(val string) error {
@@ -629,7 +867,7 @@ The other values may be 0 if not provided by the registry.`,
 			}
 			return nil
 		},
-	}, "to-docker", "", `convert to Docker schema2 media types`)
+	}, "to-docker", "", `convert to Docker schema2 media types, including Docker schema1 images`)
 	flagDocker.NoOptDefVal = "true"
 	flagOCI := imageModCmd.Flags().VarPF(&modFlagFunc{
 		t: "bool",
@@ -643,7 +881,7 @@ The other values may be 0 if not provided by the registry.`,
 			}
 			return nil
 		},
-	}, "to-oci", "", `convert to OCI media types`)
+	}, "to-oci", "", `convert to OCI media types, including Docker schema1 images`)
 	flagOCI.NoOptDefVal = "true"
 	flagOCIReferrers := imageModCmd.Flags().VarPF(&modFlagFunc{
 		t: "bool",
@@ -674,20 +912,51 @@ The other values may be 0 if not provided by the registry.`,
 		},
 	}, "volume-rm", "", `delete a volume definition`)
 
+	imageRebaseCmd.Flags().StringVarP(&imageOpts.checkBaseRef, "base", "", "", "New base image reference (including tag)")
+	imageRebaseCmd.Flags().StringVarP(&imageOpts.rebaseOld, "base-old", "", "", "Original base image reference (including digest)")
+	imageRebaseCmd.Flags().StringVarP(&imageOpts.checkBaseDigest, "digest", "", "", "Original base image digest")
+	imageRebaseCmd.Flags().BoolVarP(&imageOpts.referrers, "referrers", "", false, "Copy referrers to the rebased image")
+	_ = imageRebaseCmd.RegisterFlagCompletionFunc("base", completeArgNone)
+	_ = imageRebaseCmd.RegisterFlagCompletionFunc("base-old", completeArgNone)
+	_ = imageRebaseCmd.RegisterFlagCompletionFunc("digest", completeArgNone)
+
 	imageRateLimitCmd.Flags().StringVarP(&imageOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
 	_ = imageRateLimitCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 
+	imageSignCmd.Flags().StringArrayVar(&imageOpts.signAnnotations, "annotation", []string{}, "Annotation to include in the signed payload (name=value)")
+	imageSignCmd.Flags().BoolVarP(&imageOpts.signCosignTag, "cosign-tag", "", false, "Push the signature to the cosign signature tag instead of as a referrer")
+	imageSignCmd.Flags().StringVarP(&imageOpts.signIdentity, "identity", "", "", "Email address or URI of the ephemeral certificate with --ephemeral")
+	imageSignCmd.Flags().StringVarP(&imageOpts.signKey, "key", "", "", "File with a PEM encoded private key")
+	imageSignCmd.Flags().BoolVarP(&imageOpts.signEphemeral, "ephemeral", "", false, "Sign with an ephemeral key and self-signed certificate, this is not cosign keyless signing")
+	_ = imageSignCmd.RegisterFlagCompletionFunc("annotation", completeArgNone)
+	_ = imageSignCmd.RegisterFlagCompletionFunc("identity", completeArgNone)
+
+	imageVerifyCmd.Flags().StringVarP(&imageOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	imageVerifyCmd.Flags().StringVarP(&imageOpts.verifyPolicy, "trust-policy", "", "", "File with the notation trust policy, defaults to trustpolicy.json next to the trust store")
+	imageVerifyCmd.Flags().StringVarP(&imageOpts.verifyTrust, "trust-store", "", "", "Directory with trusted certificates")
+	imageVerifyCmd.Flags().StringVarP(&imageOpts.verifyType, "type", "", "notation", "Signature type, only notation is supported")
+	_ = imageVerifyCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = imageVerifyCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"notation"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
 	imageTopCmd.AddCommand(imageCheckBaseCmd)
+	imageTopCmd.AddCommand(imageCompareLayersCmd)
 	imageTopCmd.AddCommand(imageCopyCmd)
 	imageTopCmd.AddCommand(imageDeleteCmd)
 	imageTopCmd.AddCommand(imageDigestCmd)
 	imageTopCmd.AddCommand(imageExportCmd)
+	imageTopCmd.AddCommand(imageFilesCmd)
 	imageTopCmd.AddCommand(imageGetFileCmd)
 	imageTopCmd.AddCommand(imageImportCmd)
 	imageTopCmd.AddCommand(imageInspectCmd)
+	imageTopCmd.AddCommand(imageLintCmd)
 	imageTopCmd.AddCommand(imageManifestCmd)
 	imageTopCmd.AddCommand(imageModCmd)
 	imageTopCmd.AddCommand(imageRateLimitCmd)
+	imageTopCmd.AddCommand(imageRebaseCmd)
+	imageTopCmd.AddCommand(imageSignCmd)
+	imageTopCmd.AddCommand(imageVerifyCmd)
 	return imageTopCmd
 }
 
@@ -739,6 +1008,9 @@ func (imageOpts *imageCmd) runImageCheckBase(cmd *cobra.Command, args []string)
 	if err != nil {
 		return err
 	}
+	if imageOpts.autoRebase && r.Tag == "" {
+		return fmt.Errorf("--auto-rebase requires a tag to push the rebased image%.0w", ErrInvalidInput)
+	}
 	rc := imageOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
@@ -756,6 +1028,25 @@ func (imageOpts *imageCmd) runImageCheckBase(cmd *cobra.Command, args []string)
 		opts = append(opts, regclient.ImageWithPlatform(imageOpts.platform))
 	}
 
+	if imageOpts.formatCheckBase != "" || imageOpts.rootOpts.outputStructured() {
+		rpt, err := rc.ImageCheckBaseReport(ctx, r, opts...)
+		if err != nil {
+			return err
+		}
+		err = imageOpts.rootOpts.writeOutput(cmd, imageOpts.formatCheckBase, rpt)
+		if err != nil {
+			return err
+		}
+		if rpt.UpdateAvailable {
+			if imageOpts.autoRebase {
+				return imageOpts.imageAutoRebase(cmd, rc, r, opts)
+			}
+			// report includes the details, return empty error message
+			return fmt.Errorf("%.0w", types.ErrMismatch)
+		}
+		return nil
+	}
+
 	err = rc.ImageCheckBase(ctx, r, opts...)
 	if err == nil {
 		log.Info("base image matches")
@@ -764,6 +1055,9 @@ func (imageOpts *imageCmd) runImageCheckBase(cmd *cobra.Command, args []string)
 		log.WithFields(logrus.Fields{
 			"err": err,
 		}).Info("base image mismatch")
+		if imageOpts.autoRebase {
+			return imageOpts.imageAutoRebase(cmd, rc, r, opts)
+		}
 		// return empty error message
 		return fmt.Errorf("%.0w", err)
 	} else {
@@ -771,9 +1065,154 @@ func (imageOpts *imageCmd) runImageCheckBase(cmd *cobra.Command, args []string)
 	}
 }
 
+// imageAutoRebase rebases an image after check-base finds a changed base, pushing the result to the tag with the rebase suffix.
+func (imageOpts *imageCmd) imageAutoRebase(cmd *cobra.Command, rc *regclient.RegClient, r ref.Ref, opts []regclient.ImageOpts) error {
+	ctx := cmd.Context()
+	// rebase by digest to leave the checked tag unchanged
+	m, err := rc.ManifestHead(ctx, r, regclient.WithManifestRequireDigest())
+	if err != nil {
+		return err
+	}
+	rOut, err := rc.ImageRebase(ctx, r.SetDigest(m.GetDescriptor().Digest.String()), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to rebase %s: %w", r.CommonName(), err)
+	}
+	rTgt := r.SetTag(r.Tag + imageOpts.rebaseSuffix)
+	err = rc.ImageCopy(ctx, rOut, rTgt)
+	if err != nil {
+		return fmt.Errorf("failed to tag rebased image %s: %w", rTgt.CommonName(), err)
+	}
+	log.WithFields(logrus.Fields{
+		"ref":    r.CommonName(),
+		"target": rTgt.CommonName(),
+		"digest": rOut.Digest,
+	}).Info("base image rebased")
+	return imageOpts.rootOpts.writeRef(cmd, rTgt)
+}
+
+// imageLayerCompare is the output of the compare-layers command.
+type imageLayerCompare struct {
+	Refs       []string             `json:"refs"`
+	Shared     []types.Descriptor   `json:"shared"`
+	Unique     [][]types.Descriptor `json:"unique"`
+	SharedSize int64                `json:"sharedSize"`
+	UniqueSize []int64              `json:"uniqueSize"`
+	Separate   int64                `json:"separate"` // bytes transferred when copying each image separately
+	Together   int64                `json:"together"` // bytes transferred when copying the images together
+	Savings    int64                `json:"savings"`
+}
+
+func (imageOpts *imageCmd) runImageCompareLayers(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	rc := imageOpts.rootOpts.newRegClient()
+	result := imageLayerCompare{
+		Refs:       []string{},
+		Shared:     []types.Descriptor{},
+		Unique:     [][]types.Descriptor{{}, {}},
+		UniqueSize: []int64{0, 0},
+	}
+	layers := [][]types.Descriptor{}
+	for _, arg := range args {
+		r, err := ref.New(arg)
+		if err != nil {
+			return err
+		}
+		defer rc.Close(ctx, r)
+		log.WithFields(logrus.Fields{
+			"ref":      r.CommonName(),
+			"platform": imageOpts.platform,
+		}).Debug("Image compare layers")
+		l, err := imageLayers(ctx, rc, r, imageOpts.platform)
+		if err != nil {
+			return err
+		}
+		result.Refs = append(result.Refs, r.CommonName())
+		layers = append(layers, l)
+	}
+	found := []map[digest.Digest]bool{{}, {}}
+	for i := range layers {
+		for _, d := range layers[i] {
+			found[i][d.Digest] = true
+		}
+	}
+	for i := range layers {
+		for _, d := range layers[i] {
+			if found[1-i][d.Digest] {
+				if i == 0 {
+					result.Shared = append(result.Shared, d)
+					result.SharedSize += d.Size
+				}
+				continue
+			}
+			result.Unique[i] = append(result.Unique[i], d)
+			result.UniqueSize[i] += d.Size
+		}
+	}
+	result.Together = result.SharedSize + result.UniqueSize[0] + result.UniqueSize[1]
+	result.Separate = result.Together + result.SharedSize
+	result.Savings = result.SharedSize
+
+	if imageOpts.formatCompare != "" || imageOpts.rootOpts.outputStructured() {
+		return imageOpts.rootOpts.writeOutput(cmd, imageOpts.formatCompare, result)
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Shared layers:  %d (%s)\n", len(result.Shared), units.HumanSize(float64(result.SharedSize)))
+	for i := range result.Refs {
+		fmt.Fprintf(out, "Unique layers:  %d (%s) in %s\n", len(result.Unique[i]), units.HumanSize(float64(result.UniqueSize[i])), result.Refs[i])
+	}
+	fmt.Fprintf(out, "Copy separate:  %s\n", units.HumanSize(float64(result.Separate)))
+	fmt.Fprintf(out, "Copy together:  %s\n", units.HumanSize(float64(result.Together)))
+	fmt.Fprintf(out, "Savings:        %s\n", units.HumanSize(float64(result.Savings)))
+	return nil
+}
+
+// imageLayers returns the unique layers of an image, including every platform of a multi-platform image when a platform is not selected.
+func imageLayers(ctx context.Context, rc *regclient.RegClient, r ref.Ref, pStr string) ([]types.Descriptor, error) {
+	m, err := getManifest(ctx, rc, r, pStr, pStr == "", false)
+	if err != nil {
+		return nil, err
+	}
+	manifests := []manifest.Manifest{m}
+	if mi, ok := m.(manifest.Indexer); ok {
+		manifests = []manifest.Manifest{}
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dl {
+			mChild, err := rc.ManifestGet(ctx, r, regclient.WithManifestDesc(d))
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, mChild)
+		}
+	}
+	layers := []types.Descriptor{}
+	seen := map[digest.Digest]bool{}
+	for _, m := range manifests {
+		mi, ok := m.(manifest.Imager)
+		if !ok {
+			// skip nested indexes
+			continue
+		}
+		ml, err := mi.GetLayers()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range ml {
+			if seen[d.Digest] {
+				continue
+			}
+			seen[d.Digest] = true
+			layers = append(layers, d)
+		}
+	}
+	return layers, nil
+}
+
 func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
-	rSrc, err := ref.New(args[0])
+	rSrc, err := imageOpts.rootOpts.refNew(ctx, args[0])
 	if err != nil {
 		return err
 	}
@@ -801,12 +1240,49 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 			rSrc.Digest = d.Digest.String()
 		}
 	}
+	if imageOpts.toDocker && imageOpts.toOCI {
+		return fmt.Errorf("--to-docker and --to-oci cannot be used together%.0w", ErrInvalidInput)
+	}
+	if imageOpts.digestOnly {
+		if len(imageOpts.platforms) > 0 {
+			return fmt.Errorf("--digest-only cannot be used with --platforms since the index is rewritten%.0w", ErrInvalidInput)
+		}
+		if imageOpts.toDocker || imageOpts.toOCI {
+			return fmt.Errorf("--digest-only cannot be used with --to-docker or --to-oci since the manifest is rewritten%.0w", ErrInvalidInput)
+		}
+		mh, err := rc.ManifestHead(ctx, rSrc, regclient.WithManifestRequireDigest())
+		if err != nil {
+			return err
+		}
+		rTgt = rTgt.SetDigest(mh.GetDescriptor().Digest.String())
+	}
 	log.WithFields(logrus.Fields{
 		"source":      rSrc.CommonName(),
 		"target":      rTgt.CommonName(),
 		"recursive":   imageOpts.forceRecursive,
 		"digest-tags": imageOpts.digestTags,
 	}).Debug("Image copy")
+	if imageOpts.externalCopy {
+		// the image is rewritten with mod, which does not support the other copy options
+		if imageOpts.dryRun || imageOpts.digestOnly || imageOpts.digestTags || imageOpts.referrers {
+			return fmt.Errorf("--external-urls-copy cannot be used with --dry-run, --digest-only, --digest-tags, or --referrers since the image is rewritten%.0w", ErrInvalidInput)
+		}
+		modOpts := []mod.Opts{mod.WithRefTgt(rTgt)}
+		if len(imageOpts.platforms) > 0 {
+			modOpts = append(modOpts, mod.WithPlatforms(imageOpts.platforms))
+		}
+		if imageOpts.toDocker {
+			modOpts = append(modOpts, mod.WithManifestToDocker())
+		} else if imageOpts.toOCI {
+			modOpts = append(modOpts, mod.WithManifestToOCI())
+		}
+		modOpts = append(modOpts, mod.WithExternalURLsCopy())
+		rTgt, err = mod.Apply(ctx, rc, rSrc, modOpts...)
+		if err != nil {
+			return err
+		}
+		return imageOpts.writeImageCopy(cmd, rc, rTgt, nil)
+	}
 	opts := []regclient.ImageOpts{}
 	if imageOpts.fastCheck {
 		opts = append(opts, regclient.ImageWithFastCheck())
@@ -814,6 +1290,9 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 	if imageOpts.forceRecursive {
 		opts = append(opts, regclient.ImageWithForceRecursive())
 	}
+	if imageOpts.blobVerify > 0 {
+		opts = append(opts, regclient.ImageWithBlobVerify(imageOpts.blobVerify))
+	}
 	if imageOpts.includeExternal {
 		opts = append(opts, regclient.ImageWithIncludeExternal())
 	}
@@ -826,6 +1305,22 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 	if len(imageOpts.platforms) > 0 {
 		opts = append(opts, regclient.ImageWithPlatforms(imageOpts.platforms))
 	}
+	if imageOpts.toDocker {
+		opts = append(opts, regclient.ImageWithFormatConvert("docker"))
+	} else if imageOpts.toOCI {
+		opts = append(opts, regclient.ImageWithFormatConvert("oci"))
+	}
+	if imageOpts.dryRun {
+		opts = append(opts, regclient.ImageWithDryRun())
+		rpt, err := rc.ImageCopyReport(ctx, rSrc, rTgt, opts...)
+		if err != nil {
+			return err
+		}
+		if !flagChanged(cmd, "format") {
+			imageOpts.format = "{{printPretty .}}"
+		}
+		return imageOpts.rootOpts.writeOutput(cmd, imageOpts.format, rpt)
+	}
 	// check for a tty and attach progress reporter
 	done := make(chan bool)
 	var progress *imageProgress
@@ -851,7 +1346,7 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 		}()
 		opts = append(opts, regclient.ImageWithCallback(progress.callback))
 	}
-	err = rc.ImageCopy(ctx, rSrc, rTgt, opts...)
+	rpt, err := rc.ImageCopyReport(ctx, rSrc, rTgt, opts...)
 	if progress != nil {
 		close(done)
 		progress.display(cmd.ErrOrStderr(), true)
@@ -859,10 +1354,51 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 	if err != nil {
 		return err
 	}
+	return imageOpts.writeImageCopy(cmd, rc, rTgt, rpt)
+}
+
+// imageCopyResult is the output of an image copy.
+type imageCopyResult struct {
+	ref.Ref
+	Digest string                     `json:"digest"`
+	Report *regclient.ImageCopyReport `json:"report"`
+}
+
+func (icr imageCopyResult) outputStructured() interface{} {
+	return struct {
+		outputRef
+		Digest string                     `json:"digest"`
+		Report *regclient.ImageCopyReport `json:"report"`
+	}{
+		outputRef: outputRefNew(icr.Ref),
+		Digest:    icr.Digest,
+		Report:    icr.Report,
+	}
+}
+
+// writeImageCopy outputs the target of a copy, including the destination digest for tooling that tags or deploys by digest.
+func (imageOpts *imageCmd) writeImageCopy(cmd *cobra.Command, rc *regclient.RegClient, rTgt ref.Ref, rpt *regclient.ImageCopyReport) error {
+	ctx := cmd.Context()
+	result := imageCopyResult{
+		Ref:    rTgt,
+		Digest: rTgt.Digest,
+		Report: rpt,
+	}
 	if !flagChanged(cmd, "format") {
 		imageOpts.format = "{{ .CommonName }}\n"
+		if imageOpts.digestOnly {
+			imageOpts.format = "{{ .Digest }}\n"
+		}
 	}
-	return template.Writer(cmd.OutOrStdout(), imageOpts.format, rTgt)
+	// a tagged target is resolved since a template may reference the digest in any form
+	if result.Digest == "" {
+		mh, err := rc.ManifestHead(ctx, rTgt, regclient.WithManifestRequireDigest())
+		if err != nil {
+			return err
+		}
+		result.Digest = mh.GetDescriptor().Digest.String()
+	}
+	return imageOpts.rootOpts.writeOutput(cmd, imageOpts.format, result)
 }
 
 type imageProgress struct {
@@ -998,7 +1534,7 @@ func (ip *imageProgress) display(w io.Writer, final bool) {
 
 func (imageOpts *imageCmd) runImageExport(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
-	r, err := ref.New(args[0])
+	r, err := imageOpts.rootOpts.refNew(ctx, args[0])
 	if err != nil {
 		return err
 	}
@@ -1031,6 +1567,9 @@ func (imageOpts *imageCmd) runImageExport(cmd *cobra.Command, args []string) err
 			r.Digest = d.Digest.String()
 		}
 	}
+	if len(imageOpts.platforms) > 0 {
+		opts = append(opts, regclient.ImageWithPlatforms(imageOpts.platforms))
+	}
 	if imageOpts.exportCompress {
 		opts = append(opts, regclient.ImageWithExportCompress())
 	}
@@ -1041,15 +1580,58 @@ func (imageOpts *imageCmd) runImageExport(cmd *cobra.Command, args []string) err
 		}
 		opts = append(opts, regclient.ImageWithExportRef(eRef))
 	}
+	if imageOpts.exportChecksums != "" {
+		fh, err := os.Create(imageOpts.exportChecksums)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		opts = append(opts, regclient.ImageWithExportChecksums(fh))
+	}
 	log.WithFields(logrus.Fields{
 		"ref": r.CommonName(),
 	}).Debug("Image export")
 	return rc.ImageExport(ctx, r, w, opts...)
 }
 
+func (imageOpts *imageCmd) runImageFiles(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := imageOpts.rootOpts.refNew(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	log.WithFields(logrus.Fields{
+		"ref":      r.CommonName(),
+		"platform": imageOpts.platform,
+	}).Debug("Image files")
+	opts := []regclient.ImageOpts{}
+	if imageOpts.platform != "" {
+		opts = append(opts, regclient.ImageWithPlatform(imageOpts.platform))
+	}
+	fl, err := rc.ImageFileList(ctx, r, opts...)
+	if err != nil {
+		return err
+	}
+	if imageOpts.formatFiles != "" || imageOpts.rootOpts.outputStructured() {
+		return imageOpts.rootOpts.writeOutput(cmd, imageOpts.formatFiles, fl)
+	}
+	out := cmd.OutOrStdout()
+	for _, f := range fl {
+		line := fmt.Sprintf("%s %d/%d %8d %s", f.Mode.String(), f.UID, f.GID, f.Size, f.Name)
+		if f.Linkname != "" {
+			line += " -> " + f.Linkname
+		}
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
+
 func (imageOpts *imageCmd) runImageGetFile(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
-	r, err := ref.New(args[0])
+	r, err := imageOpts.rootOpts.refNew(ctx, args[0])
 	if err != nil {
 		return err
 	}
@@ -1062,107 +1644,40 @@ func (imageOpts *imageCmd) runImageGetFile(cmd *cobra.Command, args []string) er
 		"ref":      r.CommonName(),
 		"filename": filename,
 	}).Debug("Get file")
-
-	// make it recursive for index of index scenarios
-	m, err := rc.ManifestGet(ctx, r)
+	opts := []regclient.ImageOpts{}
+	if imageOpts.platform != "" {
+		opts = append(opts, regclient.ImageWithPlatform(imageOpts.platform))
+	}
+	th, rdr, err := rc.ImageFileGet(ctx, r, filename, opts...)
 	if err != nil {
 		return err
 	}
-	if m.IsList() {
-		if imageOpts.platform == "" {
-			imageOpts.platform = "local"
-		}
-		plat, err := platform.Parse(imageOpts.platform)
-		if err != nil {
-			log.WithFields(logrus.Fields{
-				"platform": imageOpts.platform,
-				"err":      err,
-			}).Warn("Could not parse platform")
+	defer rdr.Close()
+	// file found, output
+	if imageOpts.formatFile != "" || imageOpts.rootOpts.outputStructured() {
+		data := struct {
+			Header *tar.Header `json:"header"`
+			Reader io.Reader   `json:"-"`
+		}{
+			Header: th,
+			Reader: rdr,
 		}
-		desc, err := manifest.GetPlatformDesc(m, &plat)
+		return imageOpts.rootOpts.writeOutput(cmd, imageOpts.formatFile, data)
+	}
+	var w io.Writer
+	if len(args) < 3 {
+		w = cmd.OutOrStdout()
+	} else {
+		w, err = os.Create(args[2])
 		if err != nil {
-			pl, _ := manifest.GetPlatformList(m)
-			var ps []string
-			for _, p := range pl {
-				ps = append(ps, p.String())
-			}
-			log.WithFields(logrus.Fields{
-				"platform":  plat,
-				"err":       err,
-				"platforms": strings.Join(ps, ", "),
-			}).Warn("Platform could not be found in manifest list")
 			return err
 		}
-		m, err = rc.ManifestGet(ctx, r, regclient.WithManifestDesc(*desc))
-		if err != nil {
-			return fmt.Errorf("failed to pull platform specific digest: %w", err)
-		}
-	}
-	// go through layers in reverse
-	mi, ok := m.(manifest.Imager)
-	if !ok {
-		return fmt.Errorf("reference is not a known image media type")
 	}
-	layers, err := mi.GetLayers()
+	_, err = io.Copy(w, rdr)
 	if err != nil {
 		return err
 	}
-	for i := len(layers) - 1; i >= 0; i-- {
-		blob, err := rc.BlobGet(ctx, r, layers[i])
-		if err != nil {
-			return fmt.Errorf("failed pulling layer %d: %w", i, err)
-		}
-		btr, err := blob.ToTarReader()
-		if err != nil {
-			return fmt.Errorf("could not convert layer %d to tar reader: %w", i, err)
-		}
-		th, rdr, err := btr.ReadFile(filename)
-		if err != nil {
-			if errors.Is(err, types.ErrFileNotFound) {
-				if err := btr.Close(); err != nil {
-					return err
-				}
-				if err := blob.Close(); err != nil {
-					return err
-				}
-				continue
-			}
-			return fmt.Errorf("failed pulling from layer %d: %w", i, err)
-		}
-		// file found, output
-		if imageOpts.formatFile != "" {
-			data := struct {
-				Header *tar.Header
-				Reader io.Reader
-			}{
-				Header: th,
-				Reader: rdr,
-			}
-			return template.Writer(cmd.OutOrStdout(), imageOpts.formatFile, data)
-		}
-		var w io.Writer
-		if len(args) < 3 {
-			w = cmd.OutOrStdout()
-		} else {
-			w, err = os.Create(args[2])
-			if err != nil {
-				return err
-			}
-		}
-		_, err = io.Copy(w, rdr)
-		if err != nil {
-			return err
-		}
-		if err := btr.Close(); err != nil {
-			return err
-		}
-		if err := blob.Close(); err != nil {
-			return err
-		}
-		return nil
-	}
-	// all layers exhausted, not found or deleted
-	return types.ErrNotFound
+	return rdr.Close()
 }
 
 func (imageOpts *imageCmd) runImageImport(cmd *cobra.Command, args []string) error {
@@ -1175,6 +1690,14 @@ func (imageOpts *imageCmd) runImageImport(cmd *cobra.Command, args []string) err
 	if imageOpts.importName != "" {
 		opts = append(opts, regclient.ImageWithImportName(imageOpts.importName))
 	}
+	if imageOpts.importChecksums != "" {
+		fh, err := os.Open(imageOpts.importChecksums)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		opts = append(opts, regclient.ImageWithImportChecksums(fh))
+	}
 	rs, err := os.Open(args[1])
 	if err != nil {
 		return err
@@ -1192,7 +1715,7 @@ func (imageOpts *imageCmd) runImageImport(cmd *cobra.Command, args []string) err
 
 func (imageOpts *imageCmd) runImageInspect(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
-	r, err := ref.New(args[0])
+	r, err := imageOpts.rootOpts.refNew(ctx, args[0])
 	if err != nil {
 		return err
 	}
@@ -1230,15 +1753,240 @@ func (imageOpts *imageCmd) runImageInspect(cmd *cobra.Command, args []string) er
 		BOCIConfig: blobConfig,
 		Image:      blobConfig.GetConfig(),
 	}
-	switch imageOpts.format {
-	case "raw":
-		imageOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}{{printf \"\\n%s\" .RawBody}}"
-	case "rawBody", "raw-body", "body":
-		imageOpts.format = "{{printf \"%s\" .RawBody}}"
-	case "rawHeaders", "raw-headers", "headers":
-		imageOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
+	imageOpts.format = formatBuiltin(imageOpts.format, formatsRaw, formatsRawHeaders, formatsImageInspect)
+	return imageOpts.rootOpts.writeOutput(cmd, imageOpts.format, result)
+}
+
+// imageLintPolicy configures the checks run by the lint command.
+type imageLintPolicy struct {
+	Required  []string `yaml:"required" json:"required"`   // keys required as an annotation or label
+	Platforms []string `yaml:"platforms" json:"platforms"` // platforms required in the image
+}
+
+// imageLintDefaultRequired are the keys required when a policy is not provided.
+var imageLintDefaultRequired = []string{
+	types.AnnotationSource,
+	types.AnnotationRevision,
+	types.AnnotationCreated,
+	types.AnnotationLicenses,
+}
+
+// imageLintResult is the output of the lint command.
+type imageLintResult struct {
+	Ref      string             `json:"ref"`
+	Errors   int                `json:"errors"`
+	Warnings int                `json:"warnings"`
+	Findings []imageLintFinding `json:"findings"`
+}
+
+type imageLintFinding struct {
+	Level    string `json:"level"` // "error" or "warn"
+	Platform string `json:"platform,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (result *imageLintResult) add(level, plat, key, msg string) {
+	switch level {
+	case "error":
+		result.Errors++
+	case "warn":
+		result.Warnings++
+	}
+	result.Findings = append(result.Findings, imageLintFinding{Level: level, Platform: plat, Key: key, Message: msg})
+}
+
+func (imageOpts *imageCmd) runImageLint(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	policy := imageLintPolicy{Required: imageLintDefaultRequired}
+	if imageOpts.lintPolicy != "" {
+		//#nosec G304 command is run by a user accessing their own files
+		b, err := os.ReadFile(imageOpts.lintPolicy)
+		if err != nil {
+			return fmt.Errorf("failed to read policy: %w", err)
+		}
+		policy = imageLintPolicy{}
+		if err := yaml.Unmarshal(b, &policy); err != nil {
+			return fmt.Errorf("failed to parse policy %s: %v%.0w", imageOpts.lintPolicy, err, ErrInvalidInput)
+		}
+	}
+	r, err := imageOpts.rootOpts.refNew(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	log.WithFields(logrus.Fields{
+		"ref":    r.CommonName(),
+		"policy": imageOpts.lintPolicy,
+	}).Debug("Image lint")
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return err
+	}
+	result := imageLintResult{
+		Ref:      r.CommonName(),
+		Findings: []imageLintFinding{},
+	}
+	// annotations on an index apply to every platform
+	indexAnnot := map[string]string{}
+	if ma, ok := m.(manifest.Annotator); ok && m.IsList() {
+		indexAnnot, err = ma.GetAnnotations()
+		if err != nil {
+			return err
+		}
+	}
+	found := []platform.Platform{}
+	if mi, ok := m.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return err
+		}
+		for _, d := range dl {
+			// skip attestations and other entries without a platform
+			if d.Platform == nil || d.Platform.OS == "unknown" {
+				continue
+			}
+			plat := d.Platform.String()
+			found = append(found, *d.Platform)
+			if d.Platform.Architecture == "arm" && d.Platform.Variant == "" {
+				result.add("warn", plat, "", "platform variant is missing")
+			}
+			mChild, err := rc.ManifestGet(ctx, r, regclient.WithManifestDesc(d))
+			if err != nil {
+				return err
+			}
+			err = imageLintManifest(ctx, rc, r, mChild, plat, indexAnnot, policy, &result)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		plat := ""
+		if mi, ok := m.(manifest.Imager); ok {
+			cd, err := mi.GetConfig()
+			if err == nil && (cd.MediaType == types.MediaTypeOCI1ImageConfig || cd.MediaType == types.MediaTypeDocker2ImageConfig) {
+				conf, err := rc.BlobGetOCIConfig(ctx, r, cd)
+				if err != nil {
+					return err
+				}
+				p := conf.GetConfig().Platform
+				if p.OS != "" {
+					plat = p.String()
+					found = append(found, p)
+				}
+			}
+		}
+		err = imageLintManifest(ctx, rc, r, m, plat, indexAnnot, policy, &result)
+		if err != nil {
+			return err
+		}
+	}
+	for _, pStr := range policy.Platforms {
+		p, err := platform.Parse(pStr)
+		if err != nil {
+			return fmt.Errorf("failed to parse platform %s from the policy: %v%.0w", pStr, err, ErrInvalidInput)
+		}
+		match := false
+		for _, f := range found {
+			if platform.Match(p, f) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			result.add("error", pStr, "", "required platform is missing")
+		}
+	}
+
+	if imageOpts.formatLint != "" || imageOpts.rootOpts.outputStructured() {
+		err = imageOpts.rootOpts.writeOutput(cmd, imageOpts.formatLint, result)
+		if err != nil {
+			return err
+		}
+	} else {
+		out := cmd.OutOrStdout()
+		for _, f := range result.Findings {
+			line := f.Level + ":"
+			if f.Platform != "" {
+				line += " [" + f.Platform + "]"
+			}
+			if f.Key != "" {
+				line += " " + f.Key + ":"
+			}
+			fmt.Fprintf(out, "%s %s\n", line, f.Message)
+		}
+		fmt.Fprintf(out, "%d errors, %d warnings\n", result.Errors, result.Warnings)
+	}
+	if result.Errors > 0 {
+		return fmt.Errorf("lint found %d errors in %s%.0w", result.Errors, r.CommonName(), types.ErrMismatch)
+	}
+	return nil
+}
+
+// imageLintManifest checks the annotations and labels of a single platform image.
+func imageLintManifest(ctx context.Context, rc *regclient.RegClient, r ref.Ref, m manifest.Manifest, plat string, indexAnnot map[string]string, policy imageLintPolicy, result *imageLintResult) error {
+	annot := map[string]string{}
+	for k, v := range indexAnnot {
+		annot[k] = v
+	}
+	if ma, ok := m.(manifest.Annotator); ok {
+		ml, err := ma.GetAnnotations()
+		if err != nil {
+			return err
+		}
+		for k, v := range ml {
+			annot[k] = v
+		}
+	}
+	labels := map[string]string{}
+	if mi, ok := m.(manifest.Imager); ok {
+		cd, err := mi.GetConfig()
+		if err != nil {
+			return err
+		}
+		if cd.MediaType == types.MediaTypeOCI1ImageConfig || cd.MediaType == types.MediaTypeDocker2ImageConfig {
+			conf, err := rc.BlobGetOCIConfig(ctx, r, cd)
+			if err != nil {
+				return err
+			}
+			if conf.GetConfig().Config.Labels != nil {
+				labels = conf.GetConfig().Config.Labels
+			}
+		}
+	}
+	for _, key := range policy.Required {
+		aVal, aOK := annot[key]
+		lVal, lOK := labels[key]
+		if !aOK && !lOK {
+			result.add("error", plat, key, "missing annotation and label")
+			continue
+		}
+		val := aVal
+		if !aOK {
+			val = lVal
+		}
+		if key == types.AnnotationCreated {
+			if _, err := time.Parse(time.RFC3339, val); err != nil {
+				result.add("error", plat, key, fmt.Sprintf("value %q is not an RFC 3339 time", val))
+			}
+		}
+	}
+	// check every key set as both an annotation and label for a mismatch
+	keys := []string{}
+	for k := range annot {
+		if _, ok := labels[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if annot[k] != labels[k] {
+			result.add("error", plat, k, fmt.Sprintf("annotation %q does not match label %q", annot[k], labels[k]))
+		}
 	}
-	return template.Writer(cmd.OutOrStdout(), imageOpts.format, result)
+	return nil
 }
 
 func (imageOpts *imageCmd) runImageMod(cmd *cobra.Command, args []string) error {
@@ -1275,7 +2023,10 @@ func (imageOpts *imageCmd) runImageMod(cmd *cobra.Command, args []string) error
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(cmd.OutOrStdout(), "%s\n", rOut.CommonName())
+	err = imageOpts.rootOpts.writeRef(cmd, rOut)
+	if err != nil {
+		return err
+	}
 	err = rc.Close(ctx, rOut)
 	if err != nil {
 		return fmt.Errorf("failed to close ref: %w", err)
@@ -1303,7 +2054,154 @@ func (imageOpts *imageCmd) runImageRateLimit(cmd *cobra.Command, args []string)
 		return err
 	}
 
-	return template.Writer(cmd.OutOrStdout(), imageOpts.format, manifest.GetRateLimit(m))
+	return imageOpts.rootOpts.writeOutput(cmd, imageOpts.format, manifest.GetRateLimit(m))
+}
+
+func (imageOpts *imageCmd) runImageRebase(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	opts := []regclient.ImageOpts{}
+	if imageOpts.rebaseOld != "" {
+		if imageOpts.checkBaseRef == "" || imageOpts.checkBaseDigest != "" {
+			return fmt.Errorf("--base-old requires --base and cannot be used with --digest%.0w", ErrInvalidInput)
+		}
+		rOld, err := ref.New(imageOpts.rebaseOld)
+		if err != nil {
+			return err
+		}
+		rNew, err := ref.New(imageOpts.checkBaseRef)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, regclient.ImageWithRebaseRefs(rOld, rNew))
+	}
+	if imageOpts.checkBaseRef != "" {
+		opts = append(opts, regclient.ImageWithCheckBaseRef(imageOpts.checkBaseRef))
+	}
+	if imageOpts.checkBaseDigest != "" {
+		opts = append(opts, regclient.ImageWithCheckBaseDigest(imageOpts.checkBaseDigest))
+	}
+	if imageOpts.referrers {
+		opts = append(opts, regclient.ImageWithReferrers())
+	}
+	log.WithFields(logrus.Fields{
+		"ref": r.CommonName(),
+	}).Debug("Image rebase")
+	rOut, err := rc.ImageRebase(ctx, r, opts...)
+	if err != nil {
+		return err
+	}
+	return imageOpts.rootOpts.writeRef(cmd, rOut)
+}
+
+func (imageOpts *imageCmd) runImageSign(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := imageOpts.rootOpts.refNew(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	var signer sign.Signer
+	switch {
+	case imageOpts.signEphemeral && imageOpts.signKey != "":
+		return fmt.Errorf("--key and --ephemeral cannot both be set%.0w", ErrInvalidInput)
+	case imageOpts.signEphemeral:
+		signer, err = sign.NewEphemeral(imageOpts.signIdentity)
+		if err != nil {
+			return err
+		}
+	case imageOpts.signKey != "":
+		if imageOpts.signIdentity != "" {
+			return fmt.Errorf("--identity requires --ephemeral%.0w", ErrInvalidInput)
+		}
+		keyBytes, err := os.ReadFile(imageOpts.signKey)
+		if err != nil {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+		signer, err = sign.ParseKey(keyBytes)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("--key or --ephemeral is required%.0w", ErrInvalidInput)
+	}
+	opts := []regclient.SignOpts{}
+	if len(imageOpts.signAnnotations) > 0 {
+		annotations := map[string]string{}
+		for _, a := range imageOpts.signAnnotations {
+			aSplit := strings.SplitN(a, "=", 2)
+			if len(aSplit) == 1 {
+				annotations[aSplit[0]] = ""
+			} else {
+				annotations[aSplit[0]] = aSplit[1]
+			}
+		}
+		opts = append(opts, regclient.SignWithAnnotations(annotations))
+	}
+	if imageOpts.signCosignTag {
+		opts = append(opts, regclient.SignWithCosignTag())
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	log.WithFields(logrus.Fields{
+		"ref": r.CommonName(),
+	}).Debug("Image sign")
+	rSig, err := rc.ImageSign(ctx, r, signer, opts...)
+	if err != nil {
+		return err
+	}
+	return imageOpts.rootOpts.writeRef(cmd, rSig)
+}
+
+func (imageOpts *imageCmd) runImageVerify(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := imageOpts.rootOpts.refNew(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if imageOpts.verifyType != "notation" {
+		return fmt.Errorf("signature type %s is not supported%.0w", imageOpts.verifyType, ErrInvalidInput)
+	}
+	if imageOpts.verifyTrust == "" {
+		return fmt.Errorf("--trust-store is required%.0w", ErrInvalidInput)
+	}
+	policyFile := imageOpts.verifyPolicy
+	if policyFile == "" {
+		policyFile = filepath.Join(filepath.Dir(filepath.Clean(imageOpts.verifyTrust)), "trustpolicy.json")
+	}
+	tpd, err := notation.LoadTrustPolicy(policyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load trust policy: %w", err)
+	}
+	tp, err := tpd.PolicyFor(r)
+	if err != nil {
+		return err
+	}
+	ts, err := notation.LoadTrustStore(imageOpts.verifyTrust, tp.TrustStores...)
+	if err != nil {
+		return err
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	log.WithFields(logrus.Fields{
+		"ref":  r.CommonName(),
+		"type": imageOpts.verifyType,
+	}).Debug("Image verify")
+	results, err := rc.ImageVerifyNotation(ctx, r, ts)
+	if len(results) > 0 {
+		errOut := imageOpts.rootOpts.writeOutput(cmd, imageOpts.format, results)
+		if errOut != nil {
+			return errOut
+		}
+	}
+	return err
 }
 
 type modFlagFunc struct {
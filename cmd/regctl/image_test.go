@@ -1,9 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/regclient/regclient/types"
 )
 
 func TestImageExportImport(t *testing.T) {
@@ -39,6 +44,287 @@ func TestImageExportImport(t *testing.T) {
 	if out != "" {
 		t.Errorf("unexpected output: %v", out)
 	}
+
+	importRefB := fmt.Sprintf("ocidir://%s/repo:platforms", tmpDir)
+	_, err = cobraTest(t, nil, "image", "export", "--platforms", "linux/*", "--platforms", "!linux/arm64", srcRef, exportFile)
+	if err != nil {
+		t.Fatalf("failed to run image export with platforms: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "import", importRefB, exportFile)
+	if err != nil {
+		t.Fatalf("failed to run image import with platforms: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "inspect", "--platform", "linux/amd64", importRefB)
+	if err != nil {
+		t.Errorf("failed to inspect linux/amd64: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "inspect", "--platform", "linux/arm64", importRefB)
+	if err == nil {
+		t.Errorf("excluded platform linux/arm64 was exported")
+	}
+
+	sumsFile := tmpDir + "/export.sha256"
+	_, err = cobraTest(t, nil, "image", "export", "--checksums", sumsFile, srcRef, exportFile)
+	if err != nil {
+		t.Fatalf("failed to run image export with checksums: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "import", "--checksums", sumsFile, importRefA, exportFile)
+	if err != nil {
+		t.Fatalf("failed to run image import with checksums: %v", err)
+	}
+	err = os.WriteFile(sumsFile, []byte("0000  oci-layout\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write checksums: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "import", "--checksums", sumsFile, importRefA, exportFile)
+	if err == nil {
+		t.Errorf("import did not fail with invalid checksums")
+	}
+}
+
+func TestImageCompareLayers(t *testing.T) {
+	ref1 := "ocidir://../../testdata/testrepo:v1"
+	ref2 := "ocidir://../../testdata/testrepo:v2"
+	tt := []struct {
+		name      string
+		args      []string
+		expectOut string
+		expectErr bool
+	}{
+		{
+			name:      "same image",
+			args:      []string{"--format", "{{len .Shared}} {{len (index .Unique 0)}} {{len (index .Unique 1)}}", ref1, ref1},
+			expectOut: "3 0 0",
+		},
+		{
+			name:      "platform",
+			args:      []string{"--format", "{{len .Shared}} {{len (index .Unique 0)}} {{len (index .Unique 1)}}", "--platform", "linux/amd64", ref1, ref2},
+			expectOut: "2 0 1",
+		},
+		{
+			name:      "sizes",
+			args:      []string{"--format", "{{.SharedSize}} {{.Together}} {{.Separate}} {{.Savings}}", "--platform", "linux/amd64", ref1, ref2},
+			expectOut: "222 332 554 222",
+		},
+		{
+			name:      "missing",
+			args:      []string{ref1, "ocidir://../../testdata/testrepo:missing"},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, append([]string{"image", "compare-layers"}, tc.args...)...)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to run compare-layers: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestImageLint(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v1"
+	goodRef := fmt.Sprintf("ocidir://%s/repo:good", tmpDir)
+	badRef := fmt.Sprintf("ocidir://%s/repo:bad", tmpDir)
+	policyFile := tmpDir + "/policy.yaml"
+	_, err := cobraTest(t, nil, "image", "mod", srcRef, "--create", goodRef,
+		"--annotation", "org.opencontainers.image.source=https://example.com/src",
+		"--annotation", "org.opencontainers.image.revision=1234",
+		"--annotation", "org.opencontainers.image.created=2024-01-02T03:04:05Z",
+		"--annotation", "org.opencontainers.image.licenses=Apache-2.0",
+	)
+	if err != nil {
+		t.Fatalf("failed to create good image: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "mod", srcRef, "--create", badRef,
+		"--annotation", "org.opencontainers.image.created=yesterday",
+		"--annotation", "version=2",
+	)
+	if err != nil {
+		t.Fatalf("failed to create bad image: %v", err)
+	}
+	err = os.WriteFile(policyFile, []byte("required:\n  - version\nplatforms:\n  - linux/amd64\n  - linux/s390x\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+
+	tt := []struct {
+		name      string
+		args      []string
+		expectOut string
+		expectErr bool
+	}{
+		{
+			name:      "good",
+			args:      []string{"--format", "{{.Errors}} {{.Warnings}}", goodRef},
+			expectOut: "0 0",
+		},
+		{
+			name:      "missing keys",
+			args:      []string{"--format", "{{.Errors}}", srcRef},
+			expectOut: "8",
+			expectErr: true,
+		},
+		{
+			name:      "mismatch and invalid created",
+			args:      []string{"--format", "{{range .Findings}}{{if eq .Platform \"linux/amd64\"}}{{.Level}} {{.Key}},{{end}}{{end}}", badRef},
+			expectOut: "error org.opencontainers.image.source,error org.opencontainers.image.revision,error org.opencontainers.image.created,error org.opencontainers.image.licenses,error version,",
+			expectErr: true,
+		},
+		{
+			name:      "policy",
+			args:      []string{"--policy", policyFile, "--format", "{{range .Findings}}{{.Level}} {{.Platform}} {{.Key}},{{end}}", srcRef},
+			expectOut: "error linux/s390x ,",
+			expectErr: true,
+		},
+		{
+			name:      "missing policy",
+			args:      []string{"--policy", tmpDir + "/missing.yaml", srcRef},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, append([]string{"image", "lint"}, tc.args...)...)
+			if tc.expectErr && err == nil {
+				t.Errorf("did not fail")
+			} else if !tc.expectErr && err != nil {
+				t.Fatalf("failed to run lint: %v", err)
+			}
+			if tc.expectOut != "" && out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestImageCopy(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	tgtRef := fmt.Sprintf("ocidir://%s/repo:v2", tmpDir)
+	digestRef := fmt.Sprintf("ocidir://%s/digest", tmpDir)
+
+	srcDig, err := cobraTest(t, nil, "image", "digest", srcRef)
+	if err != nil {
+		t.Fatalf("failed to get source digest: %v", err)
+	}
+	out, err := cobraTest(t, nil, "image", "copy", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy: %v", err)
+	}
+	if out != tgtRef {
+		t.Errorf("unexpected output, expected %s, received %s", tgtRef, out)
+	}
+	out, err = cobraTest(t, nil, "image", "copy", "--format", "{{ .Digest }} {{ len .Report.Manifests }} {{ .Report.BytesTransferred }}", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy: %v", err)
+	}
+	if out != srcDig+" 0 0" {
+		t.Errorf("unexpected digest output, expected %s 0 0, received %s", srcDig, out)
+	}
+	// the digest is resolved for templates that do not reference the field by name
+	out, err = cobraTest(t, nil, "image", "copy", "--format", "{{ printf \"%+v\" . }}", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy: %v", err)
+	}
+	if !strings.Contains(out, srcDig) {
+		t.Errorf("digest missing from output, expected %s, received %s", srcDig, out)
+	}
+	out, err = cobraTest(t, nil, "image", "copy", "--digest-only", srcRef, digestRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy: %v", err)
+	}
+	if out != srcDig {
+		t.Errorf("unexpected digest-only output, expected %s, received %s", srcDig, out)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", digestRef)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "" {
+		t.Errorf("digest-only copy created tags: %s", out)
+	}
+	platRef := fmt.Sprintf("ocidir://%s/repo:platforms", tmpDir)
+	_, err = cobraTest(t, nil, "image", "copy", "--platforms", "linux/amd64", "--platforms", "linux/arm64", srcRef, platRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy with platforms: %v", err)
+	}
+	out, err = cobraTest(t, nil, "manifest", "get", "--format", "{{range .Manifests}}{{.Platform}} {{end}}", platRef)
+	if err != nil {
+		t.Fatalf("failed to get copied index: %v", err)
+	}
+	if out != "linux/amd64 linux/arm64" {
+		t.Errorf("unexpected platforms in copied index: %s", out)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", "--platforms", "linux/amd64", "--digest-only", srcRef, digestRef)
+	if err == nil {
+		t.Errorf("digest-only copy with platforms did not fail")
+	}
+	dryRef := fmt.Sprintf("ocidir://%s/dry:v2", tmpDir)
+	out, err = cobraTest(t, nil, "image", "copy", "--dry-run", "--format", "{{len .Manifests}}", srcRef, dryRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy dry run: %v", err)
+	}
+	if out != "4" {
+		t.Errorf("unexpected manifest count in dry run, expected 4, received %s", out)
+	}
+	_, err = cobraTest(t, nil, "manifest", "head", dryRef)
+	if err == nil {
+		t.Errorf("dry run pushed the manifest")
+	}
+	out, err = cobraTest(t, nil, "image", "copy", "--dry-run", "--format", "{{len .Manifests}} {{len .Blobs}}", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy dry run: %v", err)
+	}
+	if out != "0 0" {
+		t.Errorf("unexpected dry run output for an existing image: %s", out)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", "--force-blob-verify", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy with blob verify: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", "--force-blob-verify=50", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy with sampled blob verify: %v", err)
+	}
+	dockerRef := fmt.Sprintf("ocidir://%s/docker:v2", tmpDir)
+	_, err = cobraTest(t, nil, "image", "copy", "--to-docker", srcRef, dockerRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy to docker: %v", err)
+	}
+	out, err = cobraTest(t, nil, "manifest", "get", "--format", "{{.GetDescriptor.MediaType}}", dockerRef)
+	if err != nil {
+		t.Fatalf("failed to get converted manifest: %v", err)
+	}
+	if out != types.MediaTypeDocker2ManifestList {
+		t.Errorf("unexpected media type, expected %s, received %s", types.MediaTypeDocker2ManifestList, out)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", "--to-docker", "--to-oci", srcRef, dockerRef)
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("unexpected error with both formats, expected %v, received %v", ErrInvalidInput, err)
+	}
+	extRef := fmt.Sprintf("ocidir://%s/external:v2", tmpDir)
+	out, err = cobraTest(t, nil, "image", "copy", "--external-urls-copy", "--format", "{{ .Digest }}", srcRef, extRef)
+	if err != nil {
+		t.Fatalf("failed to run image copy with external urls copy: %v", err)
+	}
+	if out != srcDig {
+		t.Errorf("image without external layers was changed, expected %s, received %s", srcDig, out)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", "--external-urls-copy", "--referrers", srcRef, extRef)
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("unexpected error with external urls copy and referrers, expected %v, received %v", ErrInvalidInput, err)
+	}
 }
 
 func TestImageInspect(t *testing.T) {
@@ -73,10 +359,77 @@ func TestImageInspect(t *testing.T) {
 			expectOut:   "linux",
 			outContains: false,
 		},
+		{
+			name:      "format builtin created",
+			cmd:       []string{"image", "inspect", srcRef, "--format", "created"},
+			expectOut: "2021-01-01T00:00:00Z",
+		},
+		{
+			name:        "format builtin labels",
+			cmd:         []string{"image", "inspect", srcRef, "--format", "labels"},
+			expectOut:   "version=3",
+			outContains: true,
+		},
+		{
+			name:      "format time",
+			cmd:       []string{"image", "inspect", srcRef, "--format", `{{ (time).Format "2006-01-02" .Created }}`},
+			expectOut: "2021-01-01",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.cmd...)
+			if err != nil {
+				t.Errorf("error: %v", err)
+				return
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestImageFiles(t *testing.T) {
+	srcRef := "ocidir://../../testdata/testrepo:v3"
+	tt := []struct {
+		name        string
+		cmd         []string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:        "default",
+			cmd:         []string{"image", "files", srcRef, "--platform", "linux/amd64"},
+			expectOut:   "-rw-r--r-- 0/0        2 base.txt\n",
+			outContains: true,
+		},
+		{
+			name:      "format",
+			cmd:       []string{"image", "files", srcRef, "--platform", "linux/amd64", "--format", `{{range .}}{{println .Name}}{{end}}`},
+			expectOut: "base.txt\ndir\ndir/layer.tar\nlayer1\nlayer2\nlayer3",
+		},
+		{
+			name:      "get-file",
+			cmd:       []string{"image", "get-file", srcRef, "/layer3", "--platform", "linux/amd64"},
+			expectOut: "3",
+		},
+		{
+			name:      "get-file missing",
+			cmd:       []string{"image", "get-file", srcRef, "missing", "--platform", "linux/amd64"},
+			expectErr: types.ErrFileNotFound,
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			out, err := cobraTest(t, nil, tc.cmd...)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
 			if err != nil {
 				t.Errorf("error: %v", err)
 				return
@@ -102,4 +455,180 @@ func TestImageMod(t *testing.T) {
 	if out == "" {
 		t.Errorf("missing output")
 	}
+
+	esgzRef := fmt.Sprintf("ocidir://%s/repo:estargz", tmpDir)
+	_, err = cobraTest(t, nil, "image", "mod", srcRef, "--create", esgzRef, "--layer-estargz")
+	if err != nil {
+		t.Errorf("failed to run image mod: %v", err)
+		return
+	}
+	out, err = cobraTest(t, nil, "image", "get-file", esgzRef, "/layer3", "--platform", "linux/amd64")
+	if err != nil {
+		t.Errorf("failed to get file: %v", err)
+		return
+	}
+	if out != "3" {
+		t.Errorf("unexpected output, expected 3, received %s", out)
+	}
+}
+
+func TestImageCheckBase(t *testing.T) {
+	testRepo := "ocidir://../../testdata/testrepo"
+	out, err := cobraTest(t, nil, "image", "check-base", "--base", testRepo+":b1", "--format", "{{.UpdateAvailable}} {{len .Platforms}}", testRepo+":v3")
+	if err != nil {
+		t.Fatalf("failed to run check-base: %v", err)
+	}
+	if out != "false 4" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	out, err = cobraTest(t, nil, "image", "check-base", "--base", testRepo+":b3", "--platform", "linux/amd64", "--format", "{{.UpdateAvailable}} {{.MismatchLayer}}", testRepo+":v2")
+	if err == nil {
+		t.Errorf("check-base did not fail on a changed base")
+	}
+	if out != "true 0" {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestImageCheckBaseAutoRebase(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRepo := "ocidir://../../testdata/testrepo"
+	tgtRepo := fmt.Sprintf("ocidir://%s/repo", tmpDir)
+	for _, tag := range []string{"b1", "b3", "v3"} {
+		_, err := cobraTest(t, nil, "image", "copy", srcRepo+":"+tag, tgtRepo+":"+tag)
+		if err != nil {
+			t.Fatalf("failed to copy %s: %v", tag, err)
+		}
+	}
+	digB1, err := cobraTest(t, nil, "manifest", "head", tgtRepo+":b1")
+	if err != nil {
+		t.Fatalf("failed to head b1: %v", err)
+	}
+	digV3, err := cobraTest(t, nil, "manifest", "head", tgtRepo+":v3")
+	if err != nil {
+		t.Fatalf("failed to head v3: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "check-base", "--auto-rebase", "--base", tgtRepo+":b3", tgtRepo+"@"+digV3)
+	if err == nil {
+		t.Errorf("auto-rebase did not fail without a tag")
+	}
+	out, err := cobraTest(t, nil, "image", "check-base", "--auto-rebase", "--base", tgtRepo+":b3", "--digest", digB1, tgtRepo+":v3")
+	if err != nil {
+		t.Fatalf("failed to auto-rebase: %v", err)
+	}
+	if out != tgtRepo+":v3-rebased" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	out, err = cobraTest(t, nil, "manifest", "head", tgtRepo+":v3")
+	if err != nil {
+		t.Fatalf("failed to head v3: %v", err)
+	}
+	if out != digV3 {
+		t.Errorf("original tag was modified")
+	}
+	_, err = cobraTest(t, nil, "image", "check-base", "--base", tgtRepo+":b3", tgtRepo+":v3-rebased")
+	if err != nil {
+		t.Errorf("check-base failed after rebase: %v", err)
+	}
+	// an image with a current base is not rebased
+	out, err = cobraTest(t, nil, "image", "check-base", "--auto-rebase", "--rebase-suffix", "-current", "--base", tgtRepo+":b3", tgtRepo+":v3-rebased")
+	if err != nil {
+		t.Fatalf("failed to run check-base: %v", err)
+	}
+	if out != "" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	_, err = cobraTest(t, nil, "manifest", "head", tgtRepo+":v3-rebased-current")
+	if err == nil {
+		t.Errorf("image with a current base was rebased")
+	}
+}
+
+func TestImageRebase(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRepo := "ocidir://../../testdata/testrepo"
+	tgtRepo := fmt.Sprintf("ocidir://%s/repo", tmpDir)
+	for _, tag := range []string{"b1", "b2", "v2"} {
+		_, err := cobraTest(t, nil, "image", "copy", srcRepo+":"+tag, tgtRepo+":"+tag)
+		if err != nil {
+			t.Fatalf("failed to copy %s: %v", tag, err)
+		}
+	}
+	_, err := cobraTest(t, nil, "image", "rebase", "--base-old", tgtRepo+":b1", tgtRepo+":v2")
+	if err == nil {
+		t.Errorf("rebase did not fail with --base-old and without --base")
+	}
+	out, err := cobraTest(t, nil, "image", "rebase", "--base-old", tgtRepo+":b1", "--base", tgtRepo+":b2", tgtRepo+":v2")
+	if err != nil {
+		t.Fatalf("failed to rebase: %v", err)
+	}
+	if !strings.HasPrefix(out, tgtRepo+"@sha256:") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	_, err = cobraTest(t, nil, "image", "check-base", "--base", tgtRepo+":b2", tgtRepo+":v2")
+	if err != nil {
+		t.Errorf("check-base failed after rebase: %v", err)
+	}
+}
+
+func TestImageSign(t *testing.T) {
+	tmpDir := t.TempDir()
+	tgtRef := fmt.Sprintf("ocidir://%s/repo:v2", tmpDir)
+	_, err := cobraTest(t, nil, "image", "copy", "ocidir://../../testdata/testrepo:v2", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "sign", tgtRef)
+	if err == nil {
+		t.Errorf("sign did not fail without a key")
+	}
+	out, err := cobraTest(t, nil, "image", "sign", "--ephemeral", "--identity", "user@example.org", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !strings.HasPrefix(out, fmt.Sprintf("ocidir://%s/repo@sha256:", tmpDir)) {
+		t.Errorf("unexpected output: %s", out)
+	}
+	out, err = cobraTest(t, nil, "artifact", "list", "--format", "{{len .Descriptors}}", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if out != "1" {
+		t.Errorf("unexpected referrer count: %s", out)
+	}
+}
+
+func TestImageVerify(t *testing.T) {
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	_, err := cobraTest(t, nil, "image", "verify", "--trust-store", t.TempDir(), srcRef)
+	if err == nil {
+		t.Errorf("verify did not fail with an empty trust store")
+	}
+	_, err = cobraTest(t, nil, "image", "verify", srcRef)
+	if err == nil {
+		t.Errorf("verify did not fail without a trust store")
+	}
+	_, err = cobraTest(t, nil, "image", "verify", "--type", "cosign", "--trust-store", t.TempDir(), srcRef)
+	if err == nil {
+		t.Errorf("verify did not fail with an unsupported type")
+	}
+	// the default policy is read next to the trust store and must match the repository
+	dir := t.TempDir()
+	trustDir := filepath.Join(dir, "truststore")
+	err = os.MkdirAll(trustDir, 0755)
+	if err != nil {
+		t.Fatalf("failed to create trust store: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "trustpolicy.json"), []byte(`{"version":"1.0","trustPolicies":[{"name":"other","registryScopes":["registry.example.org/other"],"trustStores":["ca:other"]}]}`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write trust policy: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "verify", "--trust-store", trustDir, srcRef)
+	if !errors.Is(err, types.ErrNotFound) || !strings.Contains(err.Error(), "no trust policy") {
+		t.Errorf("unexpected error without a matching policy: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "verify", "--trust-store", trustDir, "--trust-policy", filepath.Join(dir, "missing.json"), srcRef)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error with a missing policy: %v", err)
+	}
 }
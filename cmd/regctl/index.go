@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"strings"
 
@@ -9,11 +8,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/regclient/regclient"
-	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/scheme/ocidir"
 	"github.com/regclient/regclient/types"
-	"github.com/regclient/regclient/types/docker/schema2"
 	"github.com/regclient/regclient/types/manifest"
-	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -28,10 +25,15 @@ type indexCmd struct {
 	annotations     []string
 	artifactType    string
 	byDigest        bool
+	dedupeLink      bool
+	dedupeRoot      string
 	descAnnotations []string
 	descPlatform    string
 	digests         []string
+	dryRun          bool
 	format          string
+	formatDedupe    string
+	formatGC        string
 	incDigestTags   bool
 	incReferrers    bool
 	mediaType       string
@@ -59,6 +61,27 @@ func NewIndexCmd(rootOpts *rootCmd) *cobra.Command {
 		RunE:      indexOpts.runIndexAdd,
 	}
 
+	var indexAnnotateCmd = &cobra.Command{
+		Use:   "annotate <image_ref>",
+		Short: "annotate index entries",
+		Long: `Set or remove annotations on the descriptors of entries in a manifest list or OCI Index.
+Entries are selected with "--digest" or "--platform", and the command fails when no entries match.
+Annotations are set with "--annotation name=value", and the value is omitted to delete the annotation.
+The index is pushed to the tag, or by digest when the reference does not include a tag.`,
+		Example: `
+# add an annotation to the linux/arm64 entry
+regctl index annotate registry.example.org/repo:v1 \
+  --platform linux/arm64 --annotation org.example.tier=edge
+
+# delete an annotation from an entry selected by digest
+regctl index annotate registry.example.org/repo:v1 \
+  --digest sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef \
+  --annotation org.example.tier`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{}, // do not auto complete digests
+		RunE:      indexOpts.runIndexAnnotate,
+	}
+
 	var indexCreateCmd = &cobra.Command{
 		Use:       "create <image_ref>",
 		Aliases:   []string{"init", "new"},
@@ -69,6 +92,25 @@ func NewIndexCmd(rootOpts *rootCmd) *cobra.Command {
 		RunE:      indexOpts.runIndexCreate,
 	}
 
+	var indexDedupeCmd = &cobra.Command{
+		Use:   "dedupe <image_ref>",
+		Short: "remove duplicate and missing index entries",
+		Long: `Remove duplicate and missing entries from a manifest list or OCI Index.
+An entry is removed when it repeats an earlier entry, when the platform matches
+an earlier entry, or when the manifest it points to does not exist.
+The cleaned index is pushed to the same reference.
+Use "--dry-run" to report the entries that would be removed without pushing.`,
+		Example: `
+# remove duplicate platforms from an index
+regctl index dedupe registry.example.org/repo:v1
+
+# report the entries that would be removed
+regctl index dedupe --dry-run registry.example.org/repo:v1`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{}, // do not auto complete digests
+		RunE:      indexOpts.runIndexDedupe,
+	}
+
 	var indexDeleteCmd = &cobra.Command{
 		Use:       "delete <image_ref>",
 		Aliases:   []string{"del", "rm", "remove"},
@@ -79,6 +121,20 @@ func NewIndexCmd(rootOpts *rootCmd) *cobra.Command {
 		RunE:      indexOpts.runIndexDelete,
 	}
 
+	var indexGCCmd = &cobra.Command{
+		Use:     "gc <ocidir_ref>",
+		Aliases: []string{"prune"},
+		Short:   "garbage collect an OCI Layout",
+		Long: `Remove blobs from an OCI Layout that are not reachable from the index.json.
+This only applies to ocidir references. Use "--dry-run" to report the blobs and
+the space that would be reclaimed without deleting anything.
+Use "--dedupe-root" to also report blobs duplicated across every OCI Layout
+under a directory, and "--dedupe-link" to replace the duplicates with hard links.`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{}, // do not auto complete digests
+		RunE:      indexOpts.runIndexGC,
+	}
+
 	indexAddCmd.Flags().StringArrayVar(&indexOpts.descAnnotations, "desc-annotation", []string{}, "Annotation to add to descriptors of new entries")
 	indexAddCmd.Flags().StringVar(&indexOpts.descPlatform, "desc-platform", "", "Platform to set in descriptors of new entries")
 	indexAddCmd.Flags().StringArrayVar(&indexOpts.digests, "digest", []string{}, "Digest to add")
@@ -87,6 +143,12 @@ func NewIndexCmd(rootOpts *rootCmd) *cobra.Command {
 	indexAddCmd.Flags().StringArrayVar(&indexOpts.refs, "ref", []string{}, "References to add")
 	indexAddCmd.Flags().StringArrayVar(&indexOpts.platforms, "platform", []string{}, "Platforms to include from ref")
 
+	indexAnnotateCmd.Flags().StringArrayVar(&indexOpts.annotations, "annotation", []string{}, "Annotation to set on matching entries, name=value, omit the value to delete")
+	indexAnnotateCmd.Flags().StringArrayVar(&indexOpts.digests, "digest", []string{}, "Digest of entries to annotate")
+	indexAnnotateCmd.Flags().StringVar(&indexOpts.format, "format", "", "Format output with go template syntax")
+	indexAnnotateCmd.Flags().StringArrayVar(&indexOpts.platforms, "platform", []string{}, "Platform of entries to annotate")
+	_ = indexAnnotateCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
 	indexCreateCmd.Flags().StringArrayVar(&indexOpts.annotations, "annotation", []string{}, "Annotation to set on manifest")
 	indexCreateCmd.Flags().StringVar(&indexOpts.artifactType, "artifact-type", "", "Include an artifactType value")
 	indexCreateCmd.Flags().BoolVar(&indexOpts.byDigest, "by-digest", false, "Push manifest by digest instead of tag")
@@ -104,12 +166,25 @@ func NewIndexCmd(rootOpts *rootCmd) *cobra.Command {
 		return indexKnownTypes, cobra.ShellCompDirectiveNoFileComp
 	})
 
+	indexDedupeCmd.Flags().BoolVar(&indexOpts.dryRun, "dry-run", false, "Report entries that would be removed without pushing the index")
+	indexDedupeCmd.Flags().StringVar(&indexOpts.formatDedupe, "format", "{{printPretty .}}", "Format output with go template syntax")
+	_ = indexDedupeCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
 	indexDeleteCmd.Flags().StringArrayVar(&indexOpts.digests, "digest", []string{}, "Digest to delete")
 	indexDeleteCmd.Flags().StringArrayVar(&indexOpts.platforms, "platform", []string{}, "Platform to delete")
 
+	indexGCCmd.Flags().BoolVar(&indexOpts.dedupeLink, "dedupe-link", false, "Replace duplicate blobs found with --dedupe-root with hard links")
+	indexGCCmd.Flags().StringVar(&indexOpts.dedupeRoot, "dedupe-root", "", "Directory with OCI Layouts to check for duplicate blobs")
+	indexGCCmd.Flags().BoolVar(&indexOpts.dryRun, "dry-run", false, "Report blobs that would be removed without deleting them")
+	indexGCCmd.Flags().StringVar(&indexOpts.formatGC, "format", "{{printPretty .}}", "Format output with go template syntax")
+	_ = indexGCCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
 	indexTopCmd.AddCommand(indexAddCmd)
+	indexTopCmd.AddCommand(indexAnnotateCmd)
 	indexTopCmd.AddCommand(indexCreateCmd)
+	indexTopCmd.AddCommand(indexDedupeCmd)
 	indexTopCmd.AddCommand(indexDeleteCmd)
+	indexTopCmd.AddCommand(indexGCCmd)
 	return indexTopCmd
 }
 
@@ -126,53 +201,79 @@ func (indexOpts *indexCmd) runIndexAdd(cmd *cobra.Command, args []string) error
 	rc := indexOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
-	// pull existing index
-	m, err := rc.ManifestGet(ctx, r)
+	// generate a list of entries from CLI args
+	entries, err := indexOpts.indexEntries(r)
 	if err != nil {
 		return err
 	}
-	mi, ok := m.(manifest.Indexer)
-	if !ok {
-		return fmt.Errorf("current manifest is not an index/manifest list, \"%s\": %w", m.GetDescriptor().MediaType, types.ErrUnsupportedMediaType)
-	}
-	curDesc, err := mi.GetManifestList()
+	m, err := rc.ImageIndexAdd(ctx, r, entries, indexOpts.indexCopyOpts())
 	if err != nil {
 		return err
 	}
 
-	// generate a list of descriptors from CLI args
-	descList, err := indexOpts.indexBuildDescList(ctx, rc, r)
-	if err != nil {
-		return err
+	// format output
+	result := struct {
+		Manifest manifest.Manifest `json:"manifest"`
+	}{
+		Manifest: m,
 	}
+	if r.Tag == "" && r.Digest != "" && indexOpts.format == "" {
+		indexOpts.format = "{{ printf \"%s\\n\" .Manifest.GetDescriptor.Digest }}"
+	}
+	return indexOpts.rootOpts.writeOutput(cmd, indexOpts.format, result)
+}
 
-	// append list
-	curDesc = append(curDesc, descList...)
-	curDesc = indexDescListRmDup(curDesc)
-	err = mi.SetManifestList(curDesc)
+func (indexOpts *indexCmd) runIndexAnnotate(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	if len(indexOpts.annotations) == 0 {
+		return fmt.Errorf("at least one annotation is required%.0w", ErrInvalidInput)
+	}
+	if len(indexOpts.digests) == 0 && len(indexOpts.platforms) == 0 {
+		return fmt.Errorf("a digest or platform is required to select entries%.0w", ErrInvalidInput)
+	}
+
+	// parse ref
+	r, err := ref.New(args[0])
 	if err != nil {
 		return err
 	}
 
-	// push the index
-	if r.Tag == "" && r.Digest != "" {
-		r.Digest = m.GetDescriptor().Digest.String()
+	// setup regclient
+	rc := indexOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	// select entries from CLI args
+	matchOpts := []regclient.ImageIndexOpts{}
+	for _, dig := range indexOpts.digests {
+		d, err := digest.Parse(dig)
+		if err != nil {
+			return fmt.Errorf("failed to parse digest %s: %w", dig, err)
+		}
+		matchOpts = append(matchOpts, regclient.ImageIndexWithMatchDigest(d))
+	}
+	for _, platStr := range indexOpts.platforms {
+		plat, err := platform.Parse(platStr)
+		if err != nil {
+			return fmt.Errorf("failed to parse platform %s: %w", platStr, err)
+		}
+		matchOpts = append(matchOpts, regclient.ImageIndexWithMatchPlatform(plat))
 	}
-	err = rc.ManifestPut(ctx, r, m)
+	m, err := rc.ImageIndexAnnotate(ctx, r, indexParseAnnotations(indexOpts.annotations), matchOpts...)
 	if err != nil {
 		return err
 	}
 
 	// format output
 	result := struct {
-		Manifest manifest.Manifest
+		Manifest manifest.Manifest `json:"manifest"`
 	}{
 		Manifest: m,
 	}
 	if r.Tag == "" && r.Digest != "" && indexOpts.format == "" {
 		indexOpts.format = "{{ printf \"%s\\n\" .Manifest.GetDescriptor.Digest }}"
 	}
-	return template.Writer(cmd.OutOrStdout(), indexOpts.format, result)
+	return indexOpts.rootOpts.writeOutput(cmd, indexOpts.format, result)
 }
 
 func (indexOpts *indexCmd) runIndexCreate(cmd *cobra.Command, args []string) error {
@@ -193,25 +294,12 @@ func (indexOpts *indexCmd) runIndexCreate(cmd *cobra.Command, args []string) err
 	rc := indexOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
-	// parse annotations
-	annotations := map[string]string{}
-	for _, a := range indexOpts.annotations {
-		aSplit := strings.SplitN(a, "=", 2)
-		if len(aSplit) == 1 {
-			annotations[aSplit[0]] = ""
-		} else {
-			annotations[aSplit[0]] = aSplit[1]
-		}
+	indexCreateOpts := []regclient.ImageIndexOpts{
+		indexOpts.indexCopyOpts(),
+		regclient.ImageIndexWithMediaType(indexOpts.mediaType),
+		regclient.ImageIndexWithArtifactType(indexOpts.artifactType),
+		regclient.ImageIndexWithAnnotations(indexParseAnnotations(indexOpts.annotations)),
 	}
-
-	// generate a list of descriptors from CLI args
-	descList, err := indexOpts.indexBuildDescList(ctx, rc, r)
-	if err != nil {
-		return err
-	}
-	descList = indexDescListRmDup(descList)
-
-	var subj *types.Descriptor
 	if indexOpts.subject != "" && indexOpts.mediaType == types.MediaTypeOCI1ManifestList {
 		var rSubj ref.Ref
 		dig, err := digest.Parse(indexOpts.subject)
@@ -226,62 +314,35 @@ func (indexOpts *indexCmd) runIndexCreate(cmd *cobra.Command, args []string) err
 		}
 		desc := mSubj.GetDescriptor()
 		desc.Annotations = nil
-		subj = &desc
-	}
-
-	// build the index
-	mOpts := []manifest.Opts{}
-	switch indexOpts.mediaType {
-	case types.MediaTypeOCI1ManifestList:
-		m := v1.Index{
-			Versioned:    v1.IndexSchemaVersion,
-			MediaType:    types.MediaTypeOCI1ManifestList,
-			ArtifactType: indexOpts.artifactType,
-			Manifests:    descList,
-			Subject:      subj,
-		}
-		if len(annotations) > 0 {
-			m.Annotations = annotations
-		}
-		mOpts = append(mOpts, manifest.WithOrig(m))
-	case types.MediaTypeDocker2ManifestList:
-		m := schema2.ManifestList{
-			Versioned: schema2.ManifestListSchemaVersion,
-			Manifests: descList,
-		}
-		if len(annotations) > 0 {
-			m.Annotations = annotations
-		}
-		mOpts = append(mOpts, manifest.WithOrig(m))
+		indexCreateOpts = append(indexCreateOpts, regclient.ImageIndexWithSubject(desc))
 	}
-	mm, err := manifest.New(mOpts...)
+
+	// generate a list of entries from CLI args
+	entries, err := indexOpts.indexEntries(r)
 	if err != nil {
 		return err
 	}
-
-	// push the index
 	if indexOpts.byDigest {
 		r.Tag = ""
-		r.Digest = mm.GetDescriptor().Digest.String()
 	}
-	err = rc.ManifestPut(ctx, r, mm)
+	mm, err := rc.ImageIndexCreate(ctx, r, entries, indexCreateOpts...)
 	if err != nil {
 		return err
 	}
 
 	// format output
 	result := struct {
-		Manifest manifest.Manifest
+		Manifest manifest.Manifest `json:"manifest"`
 	}{
 		Manifest: mm,
 	}
 	if indexOpts.byDigest && indexOpts.format == "" {
 		indexOpts.format = "{{ printf \"%s\\n\" .Manifest.GetDescriptor.Digest }}"
 	}
-	return template.Writer(cmd.OutOrStdout(), indexOpts.format, result)
+	return indexOpts.rootOpts.writeOutput(cmd, indexOpts.format, result)
 }
 
-func (indexOpts *indexCmd) runIndexDelete(cmd *cobra.Command, args []string) error {
+func (indexOpts *indexCmd) runIndexDedupe(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
 	// parse ref
@@ -294,98 +355,85 @@ func (indexOpts *indexCmd) runIndexDelete(cmd *cobra.Command, args []string) err
 	rc := indexOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
-	// pull existing index
-	m, err := rc.ManifestGet(ctx, r)
+	opts := []regclient.ImageOpts{}
+	if indexOpts.dryRun {
+		opts = append(opts, regclient.ImageWithDryRun())
+	}
+	result, err := rc.ImageIndexDedupe(ctx, r, opts...)
 	if err != nil {
 		return err
 	}
-	mi, ok := m.(manifest.Indexer)
-	if !ok {
-		return fmt.Errorf("current manifest is not an index/manifest list, \"%s\": %w", m.GetDescriptor().MediaType, types.ErrUnsupportedMediaType)
-	}
-	curDesc, err := mi.GetManifestList()
+	return indexOpts.rootOpts.writeOutput(cmd, indexOpts.formatDedupe, result)
+}
+
+func (indexOpts *indexCmd) runIndexDelete(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// parse ref
+	r, err := ref.New(args[0])
 	if err != nil {
 		return err
 	}
 
-	// for each CLI arg, find and delete matching entries
+	// setup regclient
+	rc := indexOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	// for each CLI arg, delete matching entries
+	rmOpts := []regclient.ImageIndexOpts{}
 	for _, dig := range indexOpts.digests {
-		i := len(curDesc) - 1
-		for i >= 0 {
-			if curDesc[i].Digest.String() == dig {
-				if i < len(curDesc)-1 {
-					curDesc = append(curDesc[:i], curDesc[i+1:]...)
-				} else {
-					curDesc = curDesc[:i]
-				}
-			}
-			i--
+		d, err := digest.Parse(dig)
+		if err != nil {
+			return fmt.Errorf("failed to parse digest %s: %w", dig, err)
 		}
+		rmOpts = append(rmOpts, regclient.ImageIndexWithRmDigest(d))
 	}
 	for _, platStr := range indexOpts.platforms {
 		plat, err := platform.Parse(platStr)
 		if err != nil {
 			return err
 		}
-		i := len(curDesc) - 1
-		for i >= 0 {
-			if curDesc[i].Platform != nil && platform.Match(plat, *curDesc[i].Platform) {
-				if i < len(curDesc)-1 {
-					curDesc = append(curDesc[:i], curDesc[i+1:]...)
-				} else {
-					curDesc = curDesc[:i]
-				}
-			}
-			i--
-		}
+		rmOpts = append(rmOpts, regclient.ImageIndexWithRmPlatform(plat))
 	}
-
-	// update manifest
-	err = mi.SetManifestList(curDesc)
-	if err != nil {
-		return err
-	}
-
-	// push the index
-	if r.Tag == "" && r.Digest != "" {
-		r.Digest = m.GetDescriptor().Digest.String()
-	}
-	err = rc.ManifestPut(ctx, r, m)
+	m, err := rc.ImageIndexRemove(ctx, r, rmOpts...)
 	if err != nil {
 		return err
 	}
 
 	// format output
 	result := struct {
-		Manifest manifest.Manifest
+		Manifest manifest.Manifest `json:"manifest"`
 	}{
 		Manifest: m,
 	}
 	if r.Tag == "" && r.Digest != "" && indexOpts.format == "" {
 		indexOpts.format = "{{ printf \"%s\\n\" .Manifest.GetDescriptor.Digest }}"
 	}
-	return template.Writer(cmd.OutOrStdout(), indexOpts.format, result)
+	return indexOpts.rootOpts.writeOutput(cmd, indexOpts.format, result)
 }
 
-func (indexOpts *indexCmd) indexBuildDescList(ctx context.Context, rc *regclient.RegClient, r ref.Ref) ([]types.Descriptor, error) {
-	imgCopyOpts := []regclient.ImageOpts{
-		regclient.ImageWithChild(),
-	}
+// indexCopyOpts returns the options for copying images to the repository of the index.
+func (indexOpts *indexCmd) indexCopyOpts() regclient.ImageIndexOpts {
+	imgCopyOpts := []regclient.ImageOpts{}
 	if indexOpts.incDigestTags {
 		imgCopyOpts = append(imgCopyOpts, regclient.ImageWithDigestTags())
 	}
 	if indexOpts.incReferrers {
 		imgCopyOpts = append(imgCopyOpts, regclient.ImageWithReferrers())
 	}
+	return regclient.ImageIndexWithCopyOpts(imgCopyOpts...)
+}
 
-	descAnnotations := map[string]string{}
-	for _, a := range indexOpts.descAnnotations {
-		aSplit := strings.SplitN(a, "=", 2)
-		if len(aSplit) == 1 {
-			descAnnotations[aSplit[0]] = ""
-		} else {
-			descAnnotations[aSplit[0]] = aSplit[1]
+// indexEntries returns the entries to add to an index from the CLI args.
+func (indexOpts *indexCmd) indexEntries(r ref.Ref) ([]regclient.ImageIndexEntry, error) {
+	descAnnotations := indexParseAnnotations(indexOpts.descAnnotations)
+	var descPlatform *platform.Platform
+	if indexOpts.descPlatform != "" {
+		p, err := platform.Parse(indexOpts.descPlatform)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse platform %s: %w", indexOpts.descPlatform, err)
 		}
+		descPlatform = &p
 	}
 	platforms := []platform.Platform{}
 	for _, pStr := range indexOpts.platforms {
@@ -395,141 +443,73 @@ func (indexOpts *indexCmd) indexBuildDescList(ctx context.Context, rc *regclient
 		}
 		platforms = append(platforms, p)
 	}
-
-	// copy each ref by digest to the destination repository
-	if indexOpts.digests == nil {
-		indexOpts.digests = []string{}
+	entries := []regclient.ImageIndexEntry{}
+	for _, dig := range indexOpts.digests {
+		entries = append(entries, regclient.ImageIndexEntry{
+			Ref:         r.SetDigest(dig),
+			Platform:    descPlatform,
+			Annotations: descAnnotations,
+		})
 	}
 	for _, rStr := range indexOpts.refs {
-		srcRef, err := ref.New(rStr)
+		rEntry, err := ref.New(rStr)
 		if err != nil {
 			return nil, err
 		}
-		mCopy, err := rc.ManifestHead(ctx, srcRef, regclient.WithManifestRequireDigest())
-		if err != nil {
-			return nil, err
-		}
-		if !mCopy.IsList() || len(platforms) == 0 {
-			// single manifest
-			desc := mCopy.GetDescriptor()
-			tgtRef := r.SetDigest(desc.Digest.String())
-			err = rc.ImageCopy(ctx, srcRef, tgtRef, imgCopyOpts...)
-			if err != nil {
-				return nil, err
-			}
-			indexOpts.digests = append(indexOpts.digests, desc.Digest.String())
-		} else {
-			// platform specific descriptors are being extracted from a manifest list
-			mCopy, err = rc.ManifestGet(ctx, srcRef)
-			if err != nil {
-				return nil, err
-			}
-			mi, ok := mCopy.(manifest.Indexer)
-			if !ok {
-				return nil, fmt.Errorf("manifest list is not an Indexer")
-			}
-			dl, err := mi.GetManifestList()
-			if err != nil {
-				return nil, fmt.Errorf("failed to get descriptor list: %w", err)
-			}
-			for _, d := range dl {
-				if d.Platform != nil && indexPlatformInList(*d.Platform, platforms) {
-					dRef := srcRef.SetDigest(d.Digest.String())
-					tgtRef := r.SetDigest(d.Digest.String())
-					err = rc.ImageCopy(ctx, dRef, tgtRef, imgCopyOpts...)
-					if err != nil {
-						return nil, err
-					}
-					indexOpts.digests = append(indexOpts.digests, d.Digest.String())
-				}
-			}
-		}
-	}
+		entries = append(entries, regclient.ImageIndexEntry{
+			Ref:         rEntry,
+			Platforms:   platforms,
+			Platform:    descPlatform,
+			Annotations: descAnnotations,
+		})
+	}
+	return entries, nil
+}
 
-	// parse each digest, pull manifest, get config, append to list of descriptors
-	descList := []types.Descriptor{}
-	for _, dig := range indexOpts.digests {
-		rDig := r.SetDigest(dig)
-		mDig, err := rc.ManifestHead(ctx, rDig, regclient.WithManifestRequireDigest())
-		if err != nil {
-			return nil, err
-		}
-		desc := mDig.GetDescriptor()
-		plat := &platform.Platform{}
-		if indexOpts.descPlatform != "" {
-			*plat, err = platform.Parse(indexOpts.descPlatform)
-		} else {
-			plat, err = indexGetPlatform(ctx, rc, rDig, mDig)
-		}
-		if err == nil {
-			desc.Platform = plat
-		}
-		if len(descAnnotations) > 0 {
-			desc.Annotations = map[string]string{}
+// indexParseAnnotations converts a list of key=value annotations to a map.
+func indexParseAnnotations(list []string) map[string]string {
+	annotations := map[string]string{}
+	for _, a := range list {
+		aSplit := strings.SplitN(a, "=", 2)
+		if len(aSplit) == 1 {
+			annotations[aSplit[0]] = ""
 		} else {
-			desc.Annotations = nil
-		}
-		for k, v := range descAnnotations {
-			desc.Annotations[k] = v
+			annotations[aSplit[0]] = aSplit[1]
 		}
-		descList = append(descList, desc)
 	}
-	return descList, nil
+	return annotations
 }
 
-func indexGetPlatform(ctx context.Context, rc *regclient.RegClient, r ref.Ref, m manifest.Manifest) (*platform.Platform, error) {
-	if mi, ok := m.(manifest.Imager); ok {
-		if !m.IsSet() {
-			// fetch the manifest if it wasn't already pulled
-			m, err := rc.ManifestGet(ctx, r)
-			if err != nil {
-				return nil, err
-			}
-			if mi, ok = m.(manifest.Imager); !ok {
-				return nil, nil
-			}
-		}
-		cd, err := mi.GetConfig()
-		if err != nil {
-			return nil, err
-		}
-		blobConfig, err := rc.BlobGetOCIConfig(ctx, r, cd)
-		if err != nil {
-			return nil, err
-		}
-		ociConfig := blobConfig.GetConfig()
-		if ociConfig.OS == "" {
-			return nil, nil
-		}
-		return &ociConfig.Platform, nil
-	}
-	return nil, nil
-}
+func (indexOpts *indexCmd) runIndexGC(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
 
-func indexDescListRmDup(dl []types.Descriptor) []types.Descriptor {
-	i := 0
-	for i < len(dl)-1 {
-		j := len(dl) - 1
-		for j > i {
-			if dl[i].Equal(dl[j]) {
-				if j < len(dl)-1 {
-					dl = append(dl[:j], dl[j+1:]...)
-				} else {
-					dl = dl[:j]
-				}
-			}
-			j--
-		}
-		i++
+	// parse ref
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
 	}
-	return dl
-}
+	if r.Scheme != "ocidir" {
+		return fmt.Errorf("gc is only supported on ocidir references: %s%.0w", r.CommonName(), types.ErrUnsupported)
+	}
+
+	// setup regclient
+	rc := indexOpts.rootOpts.newRegClient()
 
-func indexPlatformInList(p platform.Platform, pl []platform.Platform) bool {
-	for _, cur := range pl {
-		if platform.Match(p, cur) {
-			return true
+	gcOpts := []ocidir.GCOpts{}
+	if indexOpts.dryRun {
+		gcOpts = append(gcOpts, ocidir.GCWithDryRun())
+	}
+	if indexOpts.dedupeRoot != "" {
+		gcOpts = append(gcOpts, ocidir.GCWithDedupe(indexOpts.dedupeRoot))
+		if indexOpts.dedupeLink {
+			gcOpts = append(gcOpts, ocidir.GCWithDedupeLink())
 		}
+	} else if indexOpts.dedupeLink {
+		return fmt.Errorf("--dedupe-link requires --dedupe-root%.0w", ErrInvalidInput)
+	}
+	result, err := rc.OCIDirGC(ctx, r, gcOpts...)
+	if err != nil {
+		return err
 	}
-	return false
+	return indexOpts.rootOpts.writeOutput(cmd, indexOpts.formatGC, result)
 }
@@ -78,3 +78,152 @@ func TestIndex(t *testing.T) {
 		t.Errorf("manifest artifact type, expected %s, received %s", testArtifactType, out)
 	}
 }
+
+func TestIndexDedupe(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	tgtRef := fmt.Sprintf("ocidir://%s/repo:dedupe", tmpDir)
+
+	// create an index with a second entry for the linux/amd64 platform
+	digAmd64, err := cobraTest(t, nil, "manifest", "head", "--platform", "linux/amd64", srcRef)
+	if err != nil {
+		t.Fatalf("failed to head linux/amd64: %v", err)
+	}
+	digArm64, err := cobraTest(t, nil, "manifest", "head", "--platform", "linux/arm64", srcRef)
+	if err != nil {
+		t.Fatalf("failed to head linux/arm64: %v", err)
+	}
+	_, err = cobraTest(t, nil, "index", "create", "--ref", srcRef, "--platform", "linux/amd64", "--platform", "linux/arm64", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run index create: %v", err)
+	}
+	_, err = cobraTest(t, nil, "index", "add", "--ref", srcRef+"@"+digArm64, "--desc-platform", "linux/amd64", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run index add: %v", err)
+	}
+
+	tt := []struct {
+		name   string
+		args   []string
+		expect string
+	}{
+		{
+			name:   "dry run",
+			args:   []string{"index", "dedupe", "--dry-run", "--format", "{{len .Duplicates}} {{.Pushed}}", tgtRef},
+			expect: "1 false",
+		},
+		{
+			name:   "dedupe",
+			args:   []string{"index", "dedupe", "--format", "{{len .Duplicates}} {{.Pushed}}", tgtRef},
+			expect: "1 true",
+		},
+		{
+			name:   "unchanged",
+			args:   []string{"index", "dedupe", "--format", "{{len .Duplicates}} {{.Changed}}", tgtRef},
+			expect: "0 false",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expect {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expect, out)
+			}
+		})
+	}
+
+	out, err := cobraTest(t, nil, "manifest", "head", "--platform", "linux/amd64", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to head linux/amd64: %v", err)
+	}
+	if out != digAmd64 {
+		t.Errorf("unexpected linux/amd64 digest, expected %s, received %s", digAmd64, out)
+	}
+}
+
+func TestIndexAnnotate(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	tgtRef := fmt.Sprintf("ocidir://%s/repo:annotate", tmpDir)
+
+	_, err := cobraTest(t, nil, "index", "create", "--ref", srcRef, "--platform", "linux/amd64", "--platform", "linux/arm64", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to run index create: %v", err)
+	}
+	digAmd64, err := cobraTest(t, nil, "manifest", "head", "--platform", "linux/amd64", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to head linux/amd64: %v", err)
+	}
+	descFormat := `{{range .Manifests}}{{.Platform.Architecture}}={{index .Annotations "tier"}} {{end}}`
+
+	tt := []struct {
+		name      string
+		args      []string
+		expectErr bool
+		expect    string
+	}{
+		{
+			name:   "set by platform",
+			args:   []string{"index", "annotate", "--platform", "linux/arm64", "--annotation", "tier=edge", tgtRef},
+			expect: "amd64= arm64=edge",
+		},
+		{
+			name:   "set by digest",
+			args:   []string{"index", "annotate", "--digest", digAmd64, "--annotation", "tier=core", tgtRef},
+			expect: "amd64=core arm64=edge",
+		},
+		{
+			name:   "delete",
+			args:   []string{"index", "annotate", "--platform", "linux/arm64", "--annotation", "tier", tgtRef},
+			expect: "amd64=core arm64=",
+		},
+		{
+			name:      "no match",
+			args:      []string{"index", "annotate", "--platform", "linux/s390x", "--annotation", "tier=edge", tgtRef},
+			expectErr: true,
+		},
+		{
+			name:      "missing selector",
+			args:      []string{"index", "annotate", "--annotation", "tier=edge", tgtRef},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("did not receive expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			out, err := cobraTest(t, nil, "manifest", "get", "--format", descFormat, tgtRef)
+			if err != nil {
+				t.Fatalf("failed to get index: %v", err)
+			}
+			if out != tc.expect {
+				t.Errorf("unexpected annotations, expected %s, received %s", tc.expect, out)
+			}
+		})
+	}
+
+	// annotating by digest pushes a new index and outputs the digest
+	digIndex, err := cobraTest(t, nil, "manifest", "head", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to head index: %v", err)
+	}
+	digRef := fmt.Sprintf("ocidir://%s/repo@%s", tmpDir, digIndex)
+	out, err := cobraTest(t, nil, "index", "annotate", "--platform", "linux/amd64", "--annotation", "tier=digest", digRef)
+	if err != nil {
+		t.Fatalf("failed to annotate by digest: %v", err)
+	}
+	if out == "" || out == digIndex {
+		t.Errorf("unexpected digest output: %s", out)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -13,7 +12,6 @@ import (
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/internal/diff"
-	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/platform"
@@ -31,10 +29,14 @@ type manifestCmd struct {
 	formatHead    string
 	formatPut     string
 	list          bool
+	member        string
 	platform      string
+	recordPrev    bool
 	referrers     bool
 	requireDigest bool
 	requireList   bool
+	verifyBlobs   bool
+	verifyChild   bool
 }
 
 func NewManifestCmd(rootOpts *rootCmd) *cobra.Command {
@@ -54,7 +56,9 @@ func NewManifestCmd(rootOpts *rootCmd) *cobra.Command {
 manifest. You must specify a digest, not a tag on this command (e.g. 
 image_name@sha256:1234abc...). It is up to the registry whether the delete
 API is supported. Additionally, registries may garbage collect the filesystem
-layers (blobs) separately or not at all. See also the "tag delete" command.`,
+layers (blobs) separately or not at all. See also the "tag delete" command.
+With --referrers, the referrers of the manifest (signatures, SBOMs, etc) are
+also deleted, and the referrers of a deleted artifact's subject are updated.`,
 		Args:      cobra.ExactArgs(1),
 		ValidArgs: []string{}, // do not auto complete digests
 		RunE:      manifestOpts.runManifestDelete,
@@ -69,10 +73,21 @@ layers (blobs) separately or not at all. See also the "tag delete" command.`,
 	}
 
 	var manifestGetCmd = &cobra.Command{
-		Use:               "get <image_ref>",
-		Aliases:           []string{"pull"},
-		Short:             "retrieve manifest or manifest list",
-		Long:              `Shows the manifest or manifest list of the specified image.`,
+		Use:     "get <image_ref>",
+		Aliases: []string{"pull"},
+		Short:   "retrieve manifest or manifest list",
+		Long: `Shows the manifest or manifest list of the specified image.
+Use "--member" to output only the descriptor of a child manifest from a manifest list,
+selected by platform (e.g. linux/arm64) or position (starting from 0).`,
+		Example: `
+# show the manifest list
+regctl manifest get alpine
+
+# show the descriptor of the arm64 image from a manifest list
+regctl manifest get alpine --member linux/arm64
+
+# output the digest of the first image in a manifest list
+regctl manifest get alpine --member 0 --format '{{.Digest}}'`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              manifestOpts.runManifestGet,
@@ -99,31 +114,37 @@ layers (blobs) separately or not at all. See also the "tag delete" command.`,
 	}
 
 	manifestDeleteCmd.Flags().BoolVarP(&manifestOpts.forceTagDeref, "force-tag-dereference", "", false, "Dereference the a tag to a digest, this is unsafe")
-	manifestDeleteCmd.Flags().BoolVarP(&manifestOpts.referrers, "referrers", "", false, "Check for referrers, recommended when deleting artifacts")
+	manifestDeleteCmd.Flags().BoolVarP(&manifestOpts.referrers, "referrers", "", false, "Delete referrers of the manifest and update the referrers of the subject, recommended when deleting images and artifacts")
 
 	manifestDiffCmd.Flags().IntVarP(&manifestOpts.diffCtx, "context", "", 3, "Lines of context")
 	manifestDiffCmd.Flags().BoolVarP(&manifestOpts.diffFullCtx, "context-full", "", false, "Show all lines of context")
 
-	manifestHeadCmd.Flags().StringVarP(&manifestOpts.formatHead, "format", "", "", "Format output with go template syntax (use \"raw-body\" for the original manifest)")
+	manifestHeadCmd.Flags().StringVarP(&manifestOpts.formatHead, "format", "", "", "Format output with go template syntax or a built-in name (digest, media-type, raw-headers)")
 	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.list, "list", "", true, "Do not resolve platform from manifest list (enabled by default)")
 	manifestHeadCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.requireDigest, "require-digest", "", false, "Fallback to get request if digest is not received")
 	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.requireList, "require-list", "", false, "Fail if manifest list is not received")
+	_ = manifestHeadCmd.RegisterFlagCompletionFunc("format", completeFormat(formatsManifest, formatsRawHeaders))
 	_ = manifestHeadCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 	_ = manifestHeadCmd.Flags().MarkHidden("list")
 
 	manifestGetCmd.Flags().BoolVarP(&manifestOpts.list, "list", "", true, "Output manifest list if available (enabled by default)")
+	manifestGetCmd.Flags().StringVarP(&manifestOpts.member, "member", "", "", "Output the descriptor of a manifest list member by platform or position")
 	manifestGetCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 	manifestGetCmd.Flags().BoolVarP(&manifestOpts.requireList, "require-list", "", false, "Fail if manifest list is not received")
-	manifestGetCmd.Flags().StringVarP(&manifestOpts.formatGet, "format", "", "{{printPretty .}}", "Format output with go template syntax (use \"raw-body\" for the original manifest)")
+	manifestGetCmd.Flags().StringVarP(&manifestOpts.formatGet, "format", "", "{{printPretty .}}", "Format output with go template syntax or a built-in name (annotations, created, digest, media-type, platforms, raw-body)")
+	_ = manifestGetCmd.RegisterFlagCompletionFunc("member", completeArgPlatform)
 	_ = manifestGetCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
-	_ = manifestGetCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = manifestGetCmd.RegisterFlagCompletionFunc("format", completeFormat(formatsManifest, formatsManifestBody, formatsRaw, formatsRawHeaders))
 	_ = manifestGetCmd.Flags().MarkHidden("list")
 
 	manifestPutCmd.Flags().BoolVarP(&manifestOpts.byDigest, "by-digest", "", false, "Push manifest by digest instead of tag")
 	manifestPutCmd.Flags().StringVarP(&manifestOpts.contentType, "content-type", "t", "", "Specify content-type (e.g. application/vnd.docker.distribution.manifest.v2+json)")
 	_ = manifestPutCmd.RegisterFlagCompletionFunc("content-type", completeArgMediaTypeManifest)
 	manifestPutCmd.Flags().StringVarP(&manifestOpts.formatPut, "format", "", "", "Format output with go template syntax")
+	manifestPutCmd.Flags().BoolVarP(&manifestOpts.recordPrev, "record-previous", "", false, "Annotate the manifest with the digest previously referenced by the tag (see tag rollback)")
+	manifestPutCmd.Flags().BoolVarP(&manifestOpts.verifyBlobs, "verify-blobs", "", false, "Verify child manifests and blobs exist before the put")
+	manifestPutCmd.Flags().BoolVarP(&manifestOpts.verifyChild, "verify-children", "", false, "Verify child manifests of an index exist before the put")
 
 	manifestTopCmd.AddCommand(manifestDeleteCmd)
 	manifestTopCmd.AddCommand(manifestDiffCmd)
@@ -135,7 +156,7 @@ layers (blobs) separately or not at all. See also the "tag delete" command.`,
 
 func (manifestOpts *manifestCmd) runManifestDelete(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
-	r, err := ref.New(args[0])
+	r, err := manifestOpts.rootOpts.refNew(ctx, args[0])
 	if err != nil {
 		return err
 	}
@@ -161,7 +182,7 @@ func (manifestOpts *manifestCmd) runManifestDelete(cmd *cobra.Command, args []st
 	}).Debug("Manifest delete")
 	mOpts := []regclient.ManifestOpts{}
 	if manifestOpts.referrers {
-		mOpts = append(mOpts, regclient.WithManifestCheckReferrers())
+		mOpts = append(mOpts, regclient.WithManifestCheckReferrers(), regclient.WithManifestReferrerCleanup())
 	}
 
 	err = rc.ManifestDelete(ctx, r, mOpts...)
@@ -180,11 +201,11 @@ func (manifestOpts *manifestCmd) runManifestDiff(cmd *cobra.Command, args []stri
 		diffOpts = append(diffOpts, diff.WithFullContext())
 	}
 	ctx := cmd.Context()
-	r1, err := ref.New(args[0])
+	r1, err := manifestOpts.rootOpts.refNew(ctx, args[0])
 	if err != nil {
 		return err
 	}
-	r2, err := ref.New(args[1])
+	r2, err := manifestOpts.rootOpts.refNew(ctx, args[1])
 	if err != nil {
 		return err
 	}
@@ -216,10 +237,11 @@ func (manifestOpts *manifestCmd) runManifestDiff(cmd *cobra.Command, args []stri
 
 	mDiff := diff.Diff(strings.Split(string(m1Json), "\n"), strings.Split(string(m2Json), "\n"), diffOpts...)
 
+	if ok, err := manifestOpts.rootOpts.writeStructured(cmd, mDiff); ok {
+		return err
+	}
 	_, err = fmt.Fprintln(cmd.OutOrStdout(), strings.Join(mDiff, "\n"))
 	return err
-	// TODO: support templating
-	// return template.Writer(cmd.OutOrStdout(), manifestOpts.format, mDiff)
 }
 
 func (manifestOpts *manifestCmd) runManifestHead(cmd *cobra.Command, args []string) error {
@@ -230,7 +252,7 @@ func (manifestOpts *manifestCmd) runManifestHead(cmd *cobra.Command, args []stri
 		manifestOpts.list = true
 	}
 
-	r, err := ref.New(args[0])
+	r, err := manifestOpts.rootOpts.refNew(ctx, args[0])
 	if err != nil {
 		return err
 	}
@@ -275,24 +297,28 @@ func (manifestOpts *manifestCmd) runManifestHead(cmd *cobra.Command, args []stri
 		}
 	}
 
-	switch manifestOpts.formatHead {
-	case "", "digest":
-		manifestOpts.formatHead = "{{ printf \"%s\\n\" .GetDescriptor.Digest }}"
-	case "rawHeaders", "raw-headers", "headers":
-		manifestOpts.formatHead = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
+	if manifestOpts.formatHead == "" {
+		manifestOpts.formatHead = "digest"
 	}
-	return template.Writer(cmd.OutOrStdout(), manifestOpts.formatHead, m)
+	if ok, err := manifestOpts.rootOpts.writeStructured(cmd, m.GetDescriptor()); ok {
+		return err
+	}
+	manifestOpts.formatHead = formatBuiltin(manifestOpts.formatHead, formatsRawHeaders, formatsManifest)
+	return manifestOpts.rootOpts.writeOutput(cmd, manifestOpts.formatHead, m)
 }
 
 func (manifestOpts *manifestCmd) runManifestGet(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
+	if manifestOpts.member != "" {
+		return manifestOpts.runManifestGetMember(cmd, args)
+	}
 	if manifestOpts.platform != "" && !flagChanged(cmd, "list") {
 		manifestOpts.list = false
 	} else if !manifestOpts.list && !flagChanged(cmd, "list") {
 		manifestOpts.list = true
 	}
 
-	r, err := ref.New(args[0])
+	r, err := manifestOpts.rootOpts.refNew(ctx, args[0])
 	if err != nil {
 		return err
 	}
@@ -304,15 +330,38 @@ func (manifestOpts *manifestCmd) runManifestGet(cmd *cobra.Command, args []strin
 		return err
 	}
 
-	switch manifestOpts.formatGet {
-	case "raw":
-		manifestOpts.formatGet = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}{{printf \"\\n%s\" .RawBody}}"
-	case "rawBody", "raw-body", "body":
-		manifestOpts.formatGet = "{{printf \"%s\" .RawBody}}"
-	case "rawHeaders", "raw-headers", "headers":
-		manifestOpts.formatGet = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
+	manifestOpts.formatGet = formatBuiltin(manifestOpts.formatGet, formatsRaw, formatsRawHeaders, formatsManifest, formatsManifestBody)
+	return manifestOpts.rootOpts.writeOutput(cmd, manifestOpts.formatGet, m)
+}
+
+func (manifestOpts *manifestCmd) runManifestGetMember(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if manifestOpts.platform != "" {
+		return fmt.Errorf("member and platform flags cannot be combined%.0w", ErrInvalidInput)
+	}
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
 	}
-	return template.Writer(cmd.OutOrStdout(), manifestOpts.formatGet, m)
+	rc := manifestOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return err
+	}
+	if !m.IsList() {
+		return fmt.Errorf("manifest is not a list, member %s cannot be selected%.0w", manifestOpts.member, ErrInvalidInput)
+	}
+	desc, err := manifest.GetMemberDesc(m, manifestOpts.member)
+	if err != nil {
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"member": manifestOpts.member,
+		"digest": desc.Digest.String(),
+	}).Debug("Found member in manifest list")
+	return manifestOpts.rootOpts.writeOutput(cmd, manifestOpts.formatGet, desc)
 }
 
 func (manifestOpts *manifestCmd) runManifestPut(cmd *cobra.Command, args []string) error {
@@ -324,7 +373,7 @@ func (manifestOpts *manifestCmd) runManifestPut(cmd *cobra.Command, args []strin
 	rc := manifestOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
-	raw, err := io.ReadAll(os.Stdin)
+	raw, err := io.ReadAll(cmd.InOrStdin())
 	if err != nil {
 		return err
 	}
@@ -346,20 +395,36 @@ func (manifestOpts *manifestCmd) runManifestPut(cmd *cobra.Command, args []strin
 		r.Digest = rcM.GetDescriptor().Digest.String()
 	}
 
-	err = rc.ManifestPut(ctx, r, rcM)
+	putOpts := []regclient.ManifestOpts{}
+	if manifestOpts.recordPrev {
+		putOpts = append(putOpts, regclient.WithManifestRecordPrevious())
+	}
+	if manifestOpts.verifyBlobs {
+		putOpts = append(putOpts, regclient.WithManifestVerifyBlobs())
+	} else if manifestOpts.verifyChild {
+		putOpts = append(putOpts, regclient.WithManifestVerifyChildren())
+	}
+	err = rc.ManifestPut(ctx, r, rcM, putOpts...)
 	if err != nil {
 		return err
 	}
+	if manifestOpts.recordPrev && !manifestOpts.byDigest {
+		// the pushed manifest may include the previous digest annotation
+		rcM, err = rc.ManifestGet(ctx, r)
+		if err != nil {
+			return err
+		}
+	}
 
 	result := struct {
-		Manifest manifest.Manifest
+		Manifest manifest.Manifest `json:"manifest"`
 	}{
 		Manifest: rcM,
 	}
 	if manifestOpts.byDigest && manifestOpts.formatPut == "" {
 		manifestOpts.formatPut = "{{ printf \"%s\\n\" .Manifest.GetDescriptor.Digest }}"
 	}
-	return template.Writer(cmd.OutOrStdout(), manifestOpts.formatPut, result)
+	return manifestOpts.rootOpts.writeOutput(cmd, manifestOpts.formatPut, result)
 }
 
 func getManifest(ctx context.Context, rc *regclient.RegClient, r ref.Ref, pStr string, list, reqList bool) (manifest.Manifest, error) {
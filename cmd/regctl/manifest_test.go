@@ -49,6 +49,21 @@ func TestManifestHead(t *testing.T) {
 			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo:v1", "--platform", "linux/unknown"},
 			expectErr: ErrNotFound,
 		},
+		{
+			name:      "Format media type",
+			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo:v1", "--format", "media-type"},
+			expectOut: "application/vnd.oci.image.index.v1+json",
+		},
+		{
+			name:      "Short digest",
+			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo@sha256:0d4ea07d"},
+			expectOut: "sha256:0d4ea07d06d42ad1cb756a5d9e71f62288e426b22a230b224410164181998c4c",
+		},
+		{
+			name:      "Short digest missing",
+			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo@sha256:00000000"},
+			expectErr: types.ErrNotFound,
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -72,3 +87,159 @@ func TestManifestHead(t *testing.T) {
 	}
 
 }
+
+func TestManifestGet(t *testing.T) {
+	tt := []struct {
+		name      string
+		args      []string
+		expectOut string
+	}{
+		{
+			name:      "Format digest",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo:v1", "--format", "digest"},
+			expectOut: "sha256:0d4ea07d06d42ad1cb756a5d9e71f62288e426b22a230b224410164181998c4c",
+		},
+		{
+			name:      "Format platforms",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo:v3", "--format", "platforms"},
+			expectOut: "linux/amd64\nlinux/arm64\nlinux/arm/v7\nlinux/arm/v6",
+		},
+		{
+			name:      "Format template",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo:v1", "--format", "{{ .GetDescriptor.MediaType }}"},
+			expectOut: "application/vnd.oci.image.index.v1+json",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestManifestGetMember(t *testing.T) {
+	digestAMD64, err := cobraTest(t, nil, "manifest", "head", "ocidir://../../testdata/testrepo:v1", "--platform", "linux/amd64")
+	if err != nil {
+		t.Fatalf("failed to get platform digest: %v", err)
+	}
+	digestAMD64 = strings.TrimSpace(digestAMD64)
+	tt := []struct {
+		name        string
+		args        []string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:        "Platform",
+			args:        []string{"manifest", "get", "ocidir://../../testdata/testrepo:v1", "--member", "linux/amd64"},
+			expectOut:   `"digest": "` + digestAMD64 + `"`,
+			outContains: true,
+		},
+		{
+			name:      "Position",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo:v1", "--member", "0", "--format", "{{.Digest}}"},
+			expectOut: digestAMD64,
+		},
+		{
+			name:      "Platform unknown",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo:v1", "--member", "linux/unknown"},
+			expectErr: types.ErrNotFound,
+		},
+		{
+			name:      "Position out of range",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo:v1", "--member", "100"},
+			expectErr: types.ErrNotFound,
+		},
+		{
+			name:      "Not a list",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo@" + digestAMD64, "--member", "0"},
+			expectErr: ErrInvalidInput,
+		},
+		{
+			name:      "Platform conflict",
+			args:      []string{"manifest", "get", "ocidir://../../testdata/testrepo:v1", "--platform", "linux/amd64", "--member", "0"},
+			expectErr: ErrInvalidInput,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("returned unexpected error: %v", err)
+				return
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestManifestPutVerify(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v1"
+	tgtRef := fmt.Sprintf("ocidir://%s/repo:v1", tmpDir)
+	body, err := cobraTest(t, nil, "manifest", "get", srcRef, "--format", "raw-body")
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader(body)}, "manifest", "put", tgtRef, "--content-type", types.MediaTypeOCI1ManifestList, "--verify-children")
+	if !errors.Is(err, types.ErrMissingContent) {
+		t.Errorf("unexpected error, expected %v, received %v", types.ErrMissingContent, err)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader(body)}, "manifest", "put", tgtRef, "--content-type", types.MediaTypeOCI1ManifestList, "--verify-blobs")
+	if err != nil {
+		t.Errorf("failed to put manifest: %v", err)
+	}
+}
+
+func TestManifestDeleteReferrers(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	tgtRef := fmt.Sprintf("ocidir://%s/repo:v2", tmpDir)
+	_, err := cobraTest(t, nil, "image", "copy", "--referrers", srcRef, tgtRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	dig, err := cobraTest(t, nil, "image", "digest", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	out, err := cobraTest(t, nil, "artifact", "list", "--format", "{{len .Descriptors}}", tgtRef)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if out == "0" {
+		t.Fatalf("test image has no referrers")
+	}
+	_, err = cobraTest(t, nil, "manifest", "rm", "--referrers", tgtRef+"@"+dig)
+	if err != nil {
+		t.Fatalf("failed to delete manifest: %v", err)
+	}
+	out, err = cobraTest(t, nil, "artifact", "list", "--format", "{{len .Descriptors}}", tgtRef+"@"+dig)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if out != "0" {
+		t.Errorf("referrers remain after delete: %s", out)
+	}
+}
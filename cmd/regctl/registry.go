@@ -3,18 +3,28 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/timejson"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -22,18 +32,65 @@ type registryCmd struct {
 	rootOpts             *rootCmd
 	user, pass           string // login opts
 	passStdin            bool
+	skipCheck            bool
+	probeRepo            string // login, inspect, and whoami opts
+	probePush            bool
+	formatInspect        string
+	format               string
 	credHelper           string
+	credProvider         string
 	hostname, pathPrefix string
 	cacert, tls          string // set opts
+	cacertFile           string
+	spkiPins             []string
+	tlsMin               string
 	clientCert           string
 	clientKey            string
+	clientCertFile       string
+	clientKeyFile        string
+	clientKeyPassEnv     string
+	clientID             string
+	clientSecret         string
 	mirrors              []string
 	priority             uint
+	weight               uint
+	mirrorBackoff        time.Duration
 	repoAuth             bool
+	repoCreate           string
+	repoList             string
+	repoDelete           string
 	blobChunk, blobMax   int64
+	digestAlgorithm      string
 	reqPerSec            float64
 	reqConcurrent        int64
+	downloadRate         int64
+	uploadRate           int64
+	retryLimit           int
+	retryDelayInit       time.Duration
+	retryDelayMax        time.Duration
+	retryJitter          float64
+	retryStatus          []int
+	retryBudget          time.Duration
+	connectTimeout       time.Duration
+	tlsTimeout           time.Duration
+	headerTimeout        time.Duration
+	requestTimeout       time.Duration
+	blobTimeoutRate      int64
+	redirectAuth         string
+	redirectMax          int
+	redirectAllow        []string
+	redirectDeny         []string
+	httpProxy            string
+	httpsProxy           string
+	noProxy              []string
+	ipFamily             string
+	dnsServers           []string
+	staticHosts          []string
 	apiOpts              []string
+	migrateState         string // migrate opts
+	migrateReferrers     bool
+	migrateDigestTags    bool
+	migrateSkipVerify    bool
 	scheme               string   // TODO: remove
 	dns                  []string // TODO: remove
 }
@@ -55,11 +112,46 @@ in the output (e.g. passwords, tokens, and TLS keys).`,
 		ValidArgsFunction: registryArgListReg,
 		RunE:              registryOpts.runRegistryConfig,
 	}
+	var registryInspectCmd = &cobra.Command{
+		Use:   "inspect [registry]",
+		Short: "show registry capabilities",
+		Long: `Probe a registry for the supported APIs and output the result as JSON. This
+includes the API version, rate limit headers, and upload chunk sizes. With
+"--repo", the referrers API, push, delete, and tag delete support of the
+repository are probed without modifying content. Adding "--push" pushes an
+empty blob and untagged manifests to detect the supported manifest types and
+blob mounts, and deletes the manifests when the registry allows it.`,
+		Example: `
+# inspect Docker Hub
+regctl registry inspect
+
+# inspect a repository, including the supported manifest types
+regctl registry inspect --repo project/test --push registry.example.org`,
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: registryArgListReg,
+		RunE:              registryOpts.runRegistryInspect,
+	}
 	var registryLoginCmd = &cobra.Command{
 		Use:   "login <registry>",
 		Short: "login to a registry",
 		Long: `Provide login credentials for a registry. This may not be necessary if you
-have already logged in with docker.`,
+have already logged in with docker. The credentials are verified with the
+registry before they are saved, and the registry capabilities are reported.
+Repository features (referrers API, push, delete, and the minimum chunk size)
+are probed when a repository is provided with "--repo".
+Without "--pass" or "--pass-stdin", the password is read from a hidden prompt.
+Passwords on the command line are visible in the shell history and process
+list. A password of "${NAME}" is saved as a reference to the environment
+variable, and expanded each time the config is loaded.`,
+		Example: `
+# login to Docker Hub
+regctl registry login -u user
+
+# login and check the features of a repository
+regctl registry login -u user --pass-stdin --repo project/app registry.example.org
+
+# save a reference to an environment variable instead of the password
+regctl registry login -u user --pass '${REG_PASS}' registry.example.org`,
 		Args:              cobra.RangeArgs(0, 1),
 		ValidArgsFunction: registryArgListReg,
 		RunE:              registryOpts.runRegistryLogin,
@@ -72,6 +164,40 @@ have already logged in with docker.`,
 		ValidArgsFunction: registryArgListReg,
 		RunE:              registryOpts.runRegistryLogout,
 	}
+	var registryMigrateCmd = &cobra.Command{
+		Use:   "migrate <src-registry/namespace> <dst-registry/namespace>",
+		Short: "migrate repositories between registries",
+		Long: `Copy every repository under a namespace to another registry or namespace.
+Repositories are found with the catalog API on the source registry, and every
+tag in each repository is copied. A state file records completed copies so an
+interrupted migration may be resumed by rerunning the same command. After the
+copy, each tag in the target is verified against the source digest.
+The namespace is optional, leaving it empty migrates the full registry.`,
+		Example: `
+# migrate the "team" namespace to a new registry
+regctl registry migrate registry.example.org/team registry.example.com/team \
+  --referrers --state migrate.json`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: registryArgListReg,
+		RunE:              registryOpts.runRegistryMigrate,
+	}
+	var registryWhoamiCmd = &cobra.Command{
+		Use:   "whoami [registry]",
+		Short: "show the login to a registry",
+		Long: `Verify the current login to a registry and report the user and registry
+capabilities. Repository features are probed when a repository is provided
+with "--repo". The upload started to check push access is canceled, and the
+delete API is checked with a digest that does not exist.`,
+		Example: `
+# show the login to Docker Hub
+regctl registry whoami
+
+# check the access to a repository
+regctl registry whoami --repo project/app registry.example.org`,
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: registryArgListReg,
+		RunE:              registryOpts.runRegistryWhoami,
+	}
 	var registrySetCmd = &cobra.Command{
 		Use:   "set <registry>",
 		Short: "set options on a registry",
@@ -85,25 +211,90 @@ the contents of the file, e.g. --cacert "$(cat reg-ca.crt)"`,
 	registryLoginCmd.Flags().StringVarP(&registryOpts.user, "user", "u", "", "Username")
 	registryLoginCmd.Flags().StringVarP(&registryOpts.pass, "pass", "p", "", "Password")
 	registryLoginCmd.Flags().BoolVarP(&registryOpts.passStdin, "pass-stdin", "", false, "Read password from stdin")
+	registryLoginCmd.Flags().BoolVarP(&registryOpts.passStdin, "password-stdin", "", false, "Read password from stdin (alias for --pass-stdin)")
+	registryLoginCmd.Flags().BoolVarP(&registryOpts.skipCheck, "skip-check", "", false, "Save the credentials without verifying them with the registry")
+	registryLoginCmd.Flags().StringVarP(&registryOpts.probeRepo, "repo", "", "", "Repository used to probe push, delete, and referrers support")
+	registryLoginCmd.Flags().StringVarP(&registryOpts.format, "format", "", "", "Format output with go template syntax")
+	_ = registryLoginCmd.RegisterFlagCompletionFunc("repo", completeArgNone)
+	_ = registryLoginCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 	_ = registryLoginCmd.RegisterFlagCompletionFunc("user", completeArgNone)
 	_ = registryLoginCmd.RegisterFlagCompletionFunc("pass", completeArgNone)
 
+	registryMigrateCmd.Flags().StringVarP(&registryOpts.migrateState, "state", "", "", "State file used to resume a migration")
+	registryMigrateCmd.Flags().BoolVarP(&registryOpts.migrateReferrers, "referrers", "", false, "Include referrers")
+	registryMigrateCmd.Flags().BoolVarP(&registryOpts.migrateDigestTags, "digest-tags", "", false, "Include digest tags (\"sha256-<digest>.*\") when copying manifests")
+	registryMigrateCmd.Flags().BoolVarP(&registryOpts.migrateSkipVerify, "skip-verify", "", false, "Skip the final verification of each tag")
+	_ = registryMigrateCmd.RegisterFlagCompletionFunc("state", completeArgDefault)
+
 	registrySetCmd.Flags().StringVarP(&registryOpts.credHelper, "cred-helper", "", "", "Credential helper (full binary name, including docker-credential- prefix)")
+	registrySetCmd.Flags().StringVarP(&registryOpts.credProvider, "cred-provider", "", "", "Credential provider (ecr, gcp, github)")
 	registrySetCmd.Flags().StringVarP(&registryOpts.cacert, "cacert", "", "", "CA Certificate (not a filename, use \"$(cat ca.pem)\" to use a file)")
+	registrySetCmd.Flags().StringVarP(&registryOpts.cacertFile, "cacert-file", "", "", "CA bundle file, read when connecting to the registry")
+	registrySetCmd.Flags().StringArrayVarP(&registryOpts.spkiPins, "spki-pin", "", nil, "List of pinned public keys (sha256/<base64 hash of the subject public key info>)")
+	registrySetCmd.Flags().StringVarP(&registryOpts.tlsMin, "tls-min", "", "", "Minimum TLS version (1.0, 1.1, 1.2, 1.3)")
 	registrySetCmd.Flags().StringVarP(&registryOpts.clientCert, "client-cert", "", "", "Client certificate for mTLS (not a filename, use \"$(cat client.pem)\" to use a file)")
 	registrySetCmd.Flags().StringVarP(&registryOpts.clientKey, "client-key", "", "", "Client key for mTLS (not a filename, use \"$(cat client.key)\" to use a file)")
+	registrySetCmd.Flags().StringVarP(&registryOpts.clientCertFile, "client-cert-file", "", "", "Client certificate file for mTLS, read when connecting to the registry")
+	registrySetCmd.Flags().StringVarP(&registryOpts.clientKeyFile, "client-key-file", "", "", "Client key file for mTLS, read when connecting to the registry")
+	registrySetCmd.Flags().StringVarP(&registryOpts.clientKeyPassEnv, "client-key-pass-env", "", "", "Environment variable with the passphrase for an encrypted client key")
+	registrySetCmd.Flags().StringVarP(&registryOpts.clientID, "client-id", "", "", "OAuth2 client id for the client_credentials grant")
+	registrySetCmd.Flags().StringVarP(&registryOpts.clientSecret, "client-secret", "", "", "OAuth2 client secret for the client_credentials grant")
 	registrySetCmd.Flags().StringVarP(&registryOpts.tls, "tls", "", "", "TLS (enabled, insecure, disabled)")
 	registrySetCmd.Flags().StringVarP(&registryOpts.hostname, "hostname", "", "", "Hostname or ip with port")
 	registrySetCmd.Flags().StringVarP(&registryOpts.pathPrefix, "path-prefix", "", "", "Prefix to all repositories")
 	registrySetCmd.Flags().StringArrayVarP(&registryOpts.mirrors, "mirror", "", nil, "List of mirrors (registry names)")
 	registrySetCmd.Flags().UintVarP(&registryOpts.priority, "priority", "", 0, "Priority (for sorting mirrors)")
+	registrySetCmd.Flags().UintVarP(&registryOpts.weight, "weight", "", 0, "Weight (for selecting between mirrors with the same priority)")
+	registrySetCmd.Flags().DurationVarP(&registryOpts.mirrorBackoff, "mirror-backoff", "", 0, "Time to skip this registry as a mirror after a failure")
 	registrySetCmd.Flags().BoolVarP(&registryOpts.repoAuth, "repo-auth", "", false, "Separate auth requests per repository instead of per registry")
+	registrySetCmd.Flags().StringVarP(&registryOpts.repoCreate, "repo-create", "", "", "Create repositories before the first push (ecr)")
+	registrySetCmd.Flags().StringVarP(&registryOpts.repoDelete, "repo-delete", "", "", "Delete repositories with a vendor API (artifactory, gitlab, harbor)")
+	registrySetCmd.Flags().StringVarP(&registryOpts.repoList, "repo-list", "", "", "List repositories with a provider when _catalog is not supported (dockerhub, ecr, gcr)")
 	registrySetCmd.Flags().Int64VarP(&registryOpts.blobChunk, "blob-chunk", "", 0, "Blob chunk size")
 	registrySetCmd.Flags().Int64VarP(&registryOpts.blobMax, "blob-max", "", 0, "Blob size before switching to chunked push, -1 to disable")
+	registrySetCmd.Flags().StringVarP(&registryOpts.digestAlgorithm, "digest-algorithm", "", "", "Digest algorithm for new content (sha256, sha512), empty for the default")
 	registrySetCmd.Flags().Float64VarP(&registryOpts.reqPerSec, "req-per-sec", "", 0, "Requests per second")
 	registrySetCmd.Flags().Int64VarP(&registryOpts.reqConcurrent, "req-concurrent", "", 0, "Concurrent requests")
+	registrySetCmd.Flags().Int64VarP(&registryOpts.downloadRate, "download-rate", "", 0, "Bandwidth limit for downloads in bytes per second, 0 for unlimited")
+	registrySetCmd.Flags().Int64VarP(&registryOpts.uploadRate, "upload-rate", "", 0, "Bandwidth limit for uploads in bytes per second, 0 for unlimited")
+	registrySetCmd.Flags().IntVarP(&registryOpts.retryLimit, "retry-limit", "", 0, "Maximum retries for a request")
+	registrySetCmd.Flags().DurationVarP(&registryOpts.retryDelayInit, "retry-delay-init", "", 0, "Initial delay between retries, doubled on each retry")
+	registrySetCmd.Flags().DurationVarP(&registryOpts.retryDelayMax, "retry-delay-max", "", 0, "Maximum delay between retries")
+	registrySetCmd.Flags().Float64VarP(&registryOpts.retryJitter, "retry-jitter", "", 0, "Fraction of the retry delay to randomize (0 to 1)")
+	registrySetCmd.Flags().IntSliceVarP(&registryOpts.retryStatus, "retry-status", "", nil, "List of http status codes to retry (defaults to 408,429,500,504)")
+	registrySetCmd.Flags().DurationVarP(&registryOpts.retryBudget, "retry-budget", "", 0, "Maximum time for a request including all retries, 0 to disable")
+	registrySetCmd.Flags().DurationVarP(&registryOpts.connectTimeout, "connect-timeout", "", 0, "Time to establish a connection, 0 for the default")
+	registrySetCmd.Flags().DurationVarP(&registryOpts.tlsTimeout, "tls-timeout", "", 0, "Time to complete a TLS handshake, 0 for the default")
+	registrySetCmd.Flags().DurationVarP(&registryOpts.headerTimeout, "header-timeout", "", 0, "Time to receive response headers, 0 to disable")
+	registrySetCmd.Flags().DurationVarP(&registryOpts.requestTimeout, "request-timeout", "", 0, "Time for each request excluding blob transfers, 0 to disable")
+	registrySetCmd.Flags().Int64VarP(&registryOpts.blobTimeoutRate, "blob-timeout-rate", "", 0, "Minimum bytes per second used to scale the timeout for blob transfers, 0 to disable")
+	registrySetCmd.Flags().StringVarP(&registryOpts.redirectAuth, "redirect-auth", "", "", "Authorization header on redirects to another host (strip, keep), empty for the default")
+	registrySetCmd.Flags().IntVarP(&registryOpts.redirectMax, "redirect-max", "", 0, "Maximum redirects to follow, -1 to disable")
+	registrySetCmd.Flags().StringArrayVarP(&registryOpts.redirectAllow, "redirect-allow", "", nil, "List of hosts allowed in a redirect (*.example.com matches subdomains)")
+	registrySetCmd.Flags().StringArrayVarP(&registryOpts.redirectDeny, "redirect-deny", "", nil, "List of hosts denied in a redirect (*.example.com matches subdomains)")
+	registrySetCmd.Flags().StringVarP(&registryOpts.httpProxy, "http-proxy", "", "", "Proxy url for http requests (http, https, or socks5 scheme)")
+	registrySetCmd.Flags().StringVarP(&registryOpts.httpsProxy, "https-proxy", "", "", "Proxy url for https requests (http, https, or socks5 scheme)")
+	registrySetCmd.Flags().StringArrayVarP(&registryOpts.noProxy, "no-proxy", "", nil, "List of hosts to connect without a proxy (\"*\" for all hosts, domains match subdomains, CIDR ranges)")
+	registrySetCmd.Flags().StringVarP(&registryOpts.ipFamily, "ip-family", "", "", "IP family for connections (ipv4, ipv6), empty for dual-stack")
+	registrySetCmd.Flags().StringArrayVarP(&registryOpts.dnsServers, "dns-server", "", nil, "List of DNS servers to resolve hostnames (ip or ip:port)")
+	registrySetCmd.Flags().StringArrayVarP(&registryOpts.staticHosts, "static-host", "", nil, "List of static host entries (hostname=ip), an empty ip removes the entry")
 	registrySetCmd.Flags().StringArrayVarP(&registryOpts.apiOpts, "api-opts", "", nil, "List of options (key=value))")
 	_ = registrySetCmd.RegisterFlagCompletionFunc("cacert", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("cacert-file", completeArgDefault)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("spki-pin", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("tls-min", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			"1.0",
+			"1.1",
+			"1.2",
+			"1.3",
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("client-cert-file", completeArgDefault)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("client-key-file", completeArgDefault)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("client-key-pass-env", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("client-id", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("client-secret", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("tls", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{
 			"enabled",
@@ -111,12 +302,77 @@ the contents of the file, e.g. --cacert "$(cat reg-ca.crt)"`,
 			"disabled",
 		}, cobra.ShellCompDirectiveNoFileComp
 	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("cred-provider", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			config.CredProviderECR,
+			config.CredProviderGCP,
+			config.CredProviderGitHub,
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("repo-create", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			config.RepoCreateECR,
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("repo-delete", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			config.RepoDeleteArtifactory,
+			config.RepoDeleteGitLab,
+			config.RepoDeleteHarbor,
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("repo-list", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			config.RepoListDockerHub,
+			config.RepoListECR,
+			config.RepoListGCR,
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
 	_ = registrySetCmd.RegisterFlagCompletionFunc("hostname", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("path-prefix", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("mirror", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("priority", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("weight", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("mirror-backoff", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("blob-chunk", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("blob-max", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("retry-limit", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("retry-delay-init", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("retry-delay-max", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("retry-jitter", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("retry-status", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("retry-budget", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("connect-timeout", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("tls-timeout", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("header-timeout", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("request-timeout", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("blob-timeout-rate", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("digest-algorithm", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			digest.SHA256.String(),
+			digest.SHA512.String(),
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("redirect-auth", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			config.RedirectAuthStrip,
+			config.RedirectAuthKeep,
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("redirect-max", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("redirect-allow", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("redirect-deny", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("http-proxy", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("https-proxy", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("no-proxy", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("ip-family", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			config.IPFamilyIPv4,
+			config.IPFamilyIPv6,
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("dns-server", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("static-host", completeArgNone)
 
 	// TODO: eventually remove
 	registrySetCmd.Flags().StringVarP(&registryOpts.scheme, "scheme", "", "", "[Deprecated] Scheme (http, https)")
@@ -124,10 +380,24 @@ the contents of the file, e.g. --cacert "$(cat reg-ca.crt)"`,
 	_ = registrySetCmd.Flags().MarkHidden("scheme")
 	_ = registrySetCmd.Flags().MarkHidden("dns")
 
+	registryInspectCmd.Flags().StringVarP(&registryOpts.probeRepo, "repo", "", "", "Repository used to probe push, delete, and referrers support")
+	registryInspectCmd.Flags().BoolVarP(&registryOpts.probePush, "push", "", false, "Push content to the repository to probe manifest types and blob mounts")
+	registryInspectCmd.Flags().StringVarP(&registryOpts.formatInspect, "format", "", "{{ jsonPretty . }}", "Format output with go template syntax")
+	_ = registryInspectCmd.RegisterFlagCompletionFunc("repo", completeArgNone)
+	_ = registryInspectCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
+	registryWhoamiCmd.Flags().StringVarP(&registryOpts.probeRepo, "repo", "", "", "Repository used to probe push, delete, and referrers support")
+	registryWhoamiCmd.Flags().StringVarP(&registryOpts.format, "format", "", "", "Format output with go template syntax")
+	_ = registryWhoamiCmd.RegisterFlagCompletionFunc("repo", completeArgNone)
+	_ = registryWhoamiCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
 	registryTopCmd.AddCommand(registryConfigCmd)
+	registryTopCmd.AddCommand(registryInspectCmd)
 	registryTopCmd.AddCommand(registryLoginCmd)
 	registryTopCmd.AddCommand(registryLogoutCmd)
+	registryTopCmd.AddCommand(registryMigrateCmd)
 	registryTopCmd.AddCommand(registrySetCmd)
+	registryTopCmd.AddCommand(registryWhoamiCmd)
 	return registryTopCmd
 }
 
@@ -155,6 +425,7 @@ func (registryOpts *registryCmd) runRegistryConfig(cmd *cobra.Command, args []st
 		c.Hosts[i].Pass = ""
 		c.Hosts[i].Token = ""
 		c.Hosts[i].ClientKey = ""
+		c.Hosts[i].ClientSecret = ""
 	}
 	var hj []byte
 	if len(args) > 0 {
@@ -198,15 +469,17 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 	if flagChanged(cmd, "user") {
 		h.User = registryOpts.user
 	} else if registryOpts.passStdin {
-		return fmt.Errorf("user must be provided to read password from stdin")
+		if h.User == "" {
+			return fmt.Errorf("user must be provided to read password from stdin%.0w", ErrMissingInput)
+		}
 	} else {
 		// prompt for username
-		reader := bufio.NewReader(os.Stdin)
+		reader := bufio.NewReader(cmd.InOrStdin())
 		defUser := ""
 		if h.User != "" {
 			defUser = " [" + h.User + "]"
 		}
-		fmt.Printf("Enter Username%s: ", defUser)
+		fmt.Fprintf(cmd.ErrOrStderr(), "Enter Username%s: ", defUser)
 		user, _ := reader.ReadString('\n')
 		user = strings.TrimSpace(user)
 		if user != "" {
@@ -217,13 +490,16 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 		}
 	}
 	if flagChanged(cmd, "pass") {
+		if !configEnvOnly(registryOpts.pass) {
+			log.Warn("Passwords on the command line are insecure, use --pass-stdin or an environment variable reference")
+		}
 		h.Pass = registryOpts.pass
 	} else if registryOpts.passStdin {
-		pass, err := io.ReadAll(os.Stdin)
+		pass, err := io.ReadAll(cmd.InOrStdin())
 		if err != nil {
 			return fmt.Errorf("failed to read password from stdin: %w", err)
 		}
-		passwd := strings.TrimRight(string(pass), "\n")
+		passwd := strings.TrimRight(string(pass), "\r\n")
 		if passwd != "" {
 			h.Pass = passwd
 		} else {
@@ -231,14 +507,17 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 			return ErrMissingInput
 		}
 	} else {
-		// prompt for a password
-		fmt.Print("Enter Password: ")
+		// prompt for a password without echoing the input
+		if !term.IsTerminal(int(syscall.Stdin)) {
+			return fmt.Errorf("unable to prompt for a password without a tty, use --pass-stdin (or winpty on Windows)%.0w", ErrMissingInput)
+		}
+		fmt.Fprint(cmd.ErrOrStderr(), "Enter Password: ")
 		pass, err := term.ReadPassword(int(syscall.Stdin))
+		fmt.Fprint(cmd.ErrOrStderr(), "\n")
 		if err != nil {
-			return fmt.Errorf("unable to read from tty (resolve by using \"-p\" flag, or winpty on Windows): %w", err)
+			return fmt.Errorf("unable to read from tty (resolve by using \"--pass-stdin\" flag, or winpty on Windows): %w", err)
 		}
-		passwd := strings.TrimRight(string(pass), "\n")
-		fmt.Print("\n")
+		passwd := strings.TrimRight(string(pass), "\r\n")
 		if passwd != "" {
 			h.Pass = passwd
 		} else {
@@ -246,6 +525,10 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 			return ErrMissingInput
 		}
 	}
+	// a previous login in the secret store would override the new login
+	if helper := config.CredStoreHelper(c.CredStore); helper != "" && h.CredHelper == helper {
+		h.CredHelper = ""
+	}
 	// if username is <token> then process password as an identity token
 	if h.User == "<token>" {
 		h.Token = h.Pass
@@ -254,29 +537,80 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 	} else {
 		h.Token = ""
 	}
-	err = c.ConfigSave()
+	if registryOpts.skipCheck {
+		err = registryCredStore(c, h)
+		if err != nil {
+			return err
+		}
+		err = c.ConfigSave()
+		if err != nil {
+			return err
+		}
+		registryConfigPermCheck(c.Filename)
+		log.WithFields(logrus.Fields{
+			"registry": args[0],
+		}).Info("Credentials set without verification")
+		return nil
+	}
+
+	// verify the credentials before saving
+	r, err := registryOpts.probeRef(args[0])
 	if err != nil {
 		return err
 	}
-
-	r, err := ref.NewHost(args[0])
+	rc := registryOpts.rootOpts.newRegClientConfig(c)
+	capabilities, err := rc.RegistryCapabilities(ctx, r)
+	if err != nil {
+		return fmt.Errorf("failed to verify credentials, login not saved (use --skip-check to save anyway): %w", err)
+	}
+	err = registryCredStore(c, h)
 	if err != nil {
 		return err
 	}
-	rc := registryOpts.rootOpts.newRegClient()
-	_, err = rc.Ping(ctx, r)
+	err = c.ConfigSave()
 	if err != nil {
-		log.WithFields(logrus.Fields{
-			"err": err,
-		}).Warn("Failed to ping registry with credentials")
+		return err
 	}
-
+	registryConfigPermCheck(c.Filename)
 	log.WithFields(logrus.Fields{
 		"registry": args[0],
 	}).Info("Credentials set")
+	return registryOpts.writeCapabilities(cmd, capabilities)
+}
+
+// registryCredStore moves the login of a host to the secret store selected in the config.
+// Logins that only reference environment variables remain in the config file.
+func registryCredStore(c *Config, h *config.Host) error {
+	helper := config.CredStoreHelper(c.CredStore)
+	if helper == "" {
+		return nil
+	}
+	if (h.Token == "" && configEnvOnly(h.Pass)) || (h.Token != "" && configEnvOnly(h.Token)) {
+		return nil
+	}
+	err := h.CredStore(helper)
+	if err != nil {
+		return fmt.Errorf("failed to save login for %s with %s, login not saved: %w", h.Name, helper, err)
+	}
+	log.WithFields(logrus.Fields{
+		"registry": h.Name,
+		"helper":   helper,
+	}).Debug("Login saved to the secret store")
 	return nil
 }
 
+// registryConfigPermCheck warns when credentials are saved to a config file readable by other users.
+func registryConfigPermCheck(filename string) {
+	fi, err := os.Stat(filename)
+	if err != nil || fi.Mode().Perm()&0077 == 0 {
+		return
+	}
+	log.WithFields(logrus.Fields{
+		"file": filename,
+		"mode": fi.Mode().Perm().String(),
+	}).Warn("Config file with credentials is readable by other users, run chmod 600 to restrict access")
+}
+
 func (registryOpts *registryCmd) runRegistryLogout(cmd *cobra.Command, args []string) error {
 	c, err := ConfigLoadDefault()
 	if err != nil {
@@ -297,7 +631,19 @@ func (registryOpts *registryCmd) runRegistryLogout(cmd *cobra.Command, args []st
 	h.User = ""
 	h.Pass = ""
 	h.Token = ""
-	// TODO: add credHelper calls to erase a password
+	h.ClientSecret = ""
+	// remove logins saved in the secret store
+	if helper := config.CredStoreHelper(c.CredStore); helper != "" && h.CredHelper == helper {
+		err = h.CredErase()
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"registry": h.Name,
+				"helper":   helper,
+				"err":      err,
+			}).Warn("Failed to erase login from the secret store")
+		}
+		h.CredHelper = ""
+	}
 	err = c.ConfigSave()
 	if err != nil {
 		return err
@@ -309,6 +655,291 @@ func (registryOpts *registryCmd) runRegistryLogout(cmd *cobra.Command, args []st
 	return nil
 }
 
+func (registryOpts *registryCmd) runRegistryInspect(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if len(args) < 1 {
+		args = []string{regclient.DockerRegistry}
+	}
+	r, err := registryOpts.probeRef(args[0])
+	if err != nil {
+		return err
+	}
+	if registryOpts.probePush && r.Repository == "" {
+		return fmt.Errorf("push probe requires a repository, use --repo%.0w", ErrInvalidInput)
+	}
+	capOpts := []reg.CapabilitiesOpts{}
+	if registryOpts.probePush {
+		capOpts = append(capOpts, reg.CapabilitiesWithPush())
+	}
+	rc := registryOpts.rootOpts.newRegClient()
+	capabilities, err := rc.RegistryCapabilities(ctx, r, capOpts...)
+	if err != nil {
+		return err
+	}
+	return registryOpts.rootOpts.writeOutput(cmd, registryOpts.formatInspect, capabilities)
+}
+
+func (registryOpts *registryCmd) runRegistryWhoami(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if len(args) < 1 {
+		args = []string{regclient.DockerRegistry}
+	}
+	r, err := registryOpts.probeRef(args[0])
+	if err != nil {
+		return err
+	}
+	rc := registryOpts.rootOpts.newRegClient()
+	capabilities, err := rc.RegistryCapabilities(ctx, r)
+	if err != nil {
+		return err
+	}
+	return registryOpts.writeCapabilities(cmd, capabilities)
+}
+
+// probeRef returns the reference to a registry, including the repository from the --repo flag.
+func (registryOpts *registryCmd) probeRef(registry string) (ref.Ref, error) {
+	if registryOpts.probeRepo == "" {
+		return ref.NewHost(registry)
+	}
+	r, err := ref.New(config.HostNewName(registry).Name + "/" + strings.Trim(registryOpts.probeRepo, "/"))
+	if err != nil {
+		return r, fmt.Errorf("invalid repository %s: %w", registryOpts.probeRepo, err)
+	}
+	return r, nil
+}
+
+// writeCapabilities outputs the login and capabilities of a registry.
+func (registryOpts *registryCmd) writeCapabilities(cmd *cobra.Command, capabilities reg.Capabilities) error {
+	format := registryOpts.format
+	if format == "" {
+		format = `Registry:    {{ .Registry }}
+{{- if .Repository }}
+Repository:  {{ .Repository }}{{ end }}
+User:        {{ if .User }}{{ .User }}{{ else }}anonymous{{ end }}
+{{- if .CredHelper }}
+Cred helper: {{ .CredHelper }}{{ end }}
+{{- if .APIVersion }}
+API version: {{ .APIVersion }}{{ end }}
+{{- if .Referrers }}
+Referrers:   {{ .Referrers }}{{ end }}
+{{- if .Push }}
+Push:        {{ .Push }}{{ end }}
+{{- if .Delete }}
+Delete:      {{ .Delete }}{{ end }}
+{{- if .TagDelete }}
+Tag delete:  {{ .TagDelete }}{{ end }}
+{{- if .ChunkMin }}
+Chunk min:   {{ .ChunkMin }}{{ end }}
+`
+	}
+	return registryOpts.rootOpts.writeOutput(cmd, format, capabilities)
+}
+
+// migrateState tracks the progress of a migration for resuming.
+type migrateState struct {
+	Source string                  `json:"source"`
+	Target string                  `json:"target"`
+	Repos  map[string]*migrateRepo `json:"repos"`
+}
+
+type migrateRepo struct {
+	Tags     map[string]string `json:"tags"` // digest copied from the source for each tag
+	Complete bool              `json:"complete"`
+}
+
+func (registryOpts *registryCmd) runRegistryMigrate(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	srcHost, srcNS, _ := strings.Cut(args[0], "/")
+	tgtHost, tgtNS, _ := strings.Cut(args[1], "/")
+	srcNS = strings.Trim(srcNS, "/")
+	tgtNS = strings.Trim(tgtNS, "/")
+	if srcHost == "" || tgtHost == "" {
+		return fmt.Errorf("registry is required for the source and target%.0w", ErrInvalidInput)
+	}
+	state := migrateState{
+		Source: args[0],
+		Target: args[1],
+		Repos:  map[string]*migrateRepo{},
+	}
+	if registryOpts.migrateState != "" {
+		//#nosec G304 command is run by a local user
+		b, err := os.ReadFile(registryOpts.migrateState)
+		if err == nil {
+			err = json.Unmarshal(b, &state)
+			if err != nil {
+				return fmt.Errorf("failed to parse state file %s: %w", registryOpts.migrateState, err)
+			}
+			if state.Source != args[0] || state.Target != args[1] {
+				return fmt.Errorf("state file %s is for a migration from %s to %s%.0w", registryOpts.migrateState, state.Source, state.Target, ErrInvalidInput)
+			}
+			if state.Repos == nil {
+				state.Repos = map[string]*migrateRepo{}
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	saveState := func() error {
+		if registryOpts.migrateState == "" {
+			return nil
+		}
+		b, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return err
+		}
+		// write to a temp file and rename to avoid a corrupt state file on interrupt
+		tmp := registryOpts.migrateState + ".tmp"
+		err = os.WriteFile(tmp, b, 0600)
+		if err != nil {
+			return err
+		}
+		return os.Rename(tmp, filepath.Clean(registryOpts.migrateState))
+	}
+
+	rc := registryOpts.rootOpts.newRegClient()
+	// list repositories in the source namespace
+	repos := []string{}
+	repoOpts := []scheme.RepoOpts{}
+	if srcNS != "" {
+		repoOpts = append(repoOpts, scheme.WithRepoPrefix(srcNS))
+	}
+	err := rc.RepoWalk(ctx, srcHost, func(repo string) error {
+		if srcNS == "" || repo == srcNS || strings.HasPrefix(repo, srcNS+"/") {
+			repos = append(repos, repo)
+		}
+		return nil
+	}, repoOpts...)
+	if err != nil {
+		return err
+	}
+	if len(repos) == 0 {
+		log.WithFields(logrus.Fields{
+			"source": args[0],
+		}).Warn("No repositories found to migrate")
+		return nil
+	}
+
+	imageOpts := []regclient.ImageOpts{}
+	if registryOpts.migrateReferrers {
+		imageOpts = append(imageOpts, regclient.ImageWithReferrers())
+	}
+	if registryOpts.migrateDigestTags {
+		imageOpts = append(imageOpts, regclient.ImageWithDigestTags())
+	}
+	tgtRepoName := func(repo string) string {
+		rel := repo
+		if srcNS != "" {
+			rel = strings.TrimPrefix(strings.TrimPrefix(repo, srcNS), "/")
+		}
+		if tgtNS == "" {
+			return rel
+		}
+		if rel == "" {
+			return tgtNS
+		}
+		return tgtNS + "/" + rel
+	}
+
+	// copy each repository
+	for i, repo := range repos {
+		rs, ok := state.Repos[repo]
+		if !ok {
+			rs = &migrateRepo{Tags: map[string]string{}}
+			state.Repos[repo] = rs
+		}
+		if rs.Tags == nil {
+			rs.Tags = map[string]string{}
+		}
+		if rs.Complete {
+			log.WithFields(logrus.Fields{
+				"repo":     repo,
+				"progress": fmt.Sprintf("%d/%d", i+1, len(repos)),
+			}).Info("Skipping completed repository")
+			continue
+		}
+		rSrc, err := ref.New(srcHost + "/" + repo)
+		if err != nil {
+			return err
+		}
+		rTgt, err := ref.New(tgtHost + "/" + tgtRepoName(repo))
+		if err != nil {
+			return err
+		}
+		tl, err := rc.TagList(ctx, rSrc)
+		if err != nil {
+			return fmt.Errorf("failed to list tags in %s: %w", rSrc.CommonName(), err)
+		}
+		tags, err := tl.GetTags()
+		if err != nil {
+			return err
+		}
+		log.WithFields(logrus.Fields{
+			"source":   rSrc.CommonName(),
+			"target":   rTgt.CommonName(),
+			"tags":     len(tags),
+			"progress": fmt.Sprintf("%d/%d", i+1, len(repos)),
+		}).Info("Migrating repository")
+		for _, tag := range tags {
+			rSrcTag := rSrc.SetTag(tag)
+			mh, err := rc.ManifestHead(ctx, rSrcTag, regclient.WithManifestRequireDigest())
+			if err != nil {
+				return fmt.Errorf("failed to head %s: %w", rSrcTag.CommonName(), err)
+			}
+			dig := mh.GetDescriptor().Digest.String()
+			if rs.Tags[tag] == dig {
+				continue
+			}
+			// copy by digest to avoid a race with the source tag changing
+			err = rc.ImageCopy(ctx, rSrc.SetDigest(dig), rTgt.SetTag(tag), imageOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to copy %s: %w", rSrcTag.CommonName(), err)
+			}
+			rs.Tags[tag] = dig
+			err = saveState()
+			if err != nil {
+				return err
+			}
+		}
+		rs.Complete = true
+		err = saveState()
+		if err != nil {
+			return err
+		}
+		rc.Close(ctx, rSrc)
+		rc.Close(ctx, rTgt)
+	}
+	if registryOpts.migrateSkipVerify {
+		return nil
+	}
+
+	// verify each tag in the target matches the copied digest
+	failures := 0
+	for _, repo := range repos {
+		rTgt, err := ref.New(tgtHost + "/" + tgtRepoName(repo))
+		if err != nil {
+			return err
+		}
+		for tag, dig := range state.Repos[repo].Tags {
+			mh, err := rc.ManifestHead(ctx, rTgt.SetTag(tag), regclient.WithManifestRequireDigest())
+			if err != nil || mh.GetDescriptor().Digest.String() != dig {
+				failures++
+				log.WithFields(logrus.Fields{
+					"target": rTgt.SetTag(tag).CommonName(),
+					"expect": dig,
+					"err":    err,
+				}).Warn("Verification failed")
+			}
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("verification failed for %d tags%.0w", failures, types.ErrMismatch)
+	}
+	log.WithFields(logrus.Fields{
+		"repos": len(repos),
+	}).Info("Migration complete")
+	return nil
+}
+
 func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	c, err := ConfigLoadDefault()
@@ -339,6 +970,14 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 	if flagChanged(cmd, "cred-helper") {
 		h.CredHelper = registryOpts.credHelper
 	}
+	if flagChanged(cmd, "cred-provider") {
+		switch registryOpts.credProvider {
+		case "", config.CredProviderECR, config.CredProviderGCP, config.CredProviderGitHub:
+		default:
+			return fmt.Errorf("unknown credential provider %s%.0w", registryOpts.credProvider, ErrInvalidInput)
+		}
+		h.CredProvider = registryOpts.credProvider
+	}
 	if flagChanged(cmd, "tls") {
 		if err := h.TLS.UnmarshalText([]byte(registryOpts.tls)); err != nil {
 			return err
@@ -347,12 +986,42 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 	if flagChanged(cmd, "cacert") {
 		h.RegCert = registryOpts.cacert
 	}
+	if flagChanged(cmd, "cacert-file") {
+		h.RegCertFile = registryOpts.cacertFile
+	}
+	if flagChanged(cmd, "spki-pin") {
+		if _, err := config.SPKIPinsVerify(registryOpts.spkiPins); err != nil {
+			return fmt.Errorf("%v%.0w", err, ErrInvalidInput)
+		}
+		h.SPKIPins = registryOpts.spkiPins
+	}
+	if flagChanged(cmd, "tls-min") {
+		if _, err := config.TLSVersion(registryOpts.tlsMin); err != nil {
+			return fmt.Errorf("%v%.0w", err, ErrInvalidInput)
+		}
+		h.TLSMinVersion = registryOpts.tlsMin
+	}
 	if flagChanged(cmd, "client-cert") {
 		h.ClientCert = registryOpts.clientCert
 	}
 	if flagChanged(cmd, "client-key") {
 		h.ClientKey = registryOpts.clientKey
 	}
+	if flagChanged(cmd, "client-cert-file") {
+		h.ClientCertFile = registryOpts.clientCertFile
+	}
+	if flagChanged(cmd, "client-key-file") {
+		h.ClientKeyFile = registryOpts.clientKeyFile
+	}
+	if flagChanged(cmd, "client-key-pass-env") {
+		h.ClientKeyPassEnv = registryOpts.clientKeyPassEnv
+	}
+	if flagChanged(cmd, "client-id") {
+		h.ClientID = registryOpts.clientID
+	}
+	if flagChanged(cmd, "client-secret") {
+		h.ClientSecret = registryOpts.clientSecret
+	}
 	if flagChanged(cmd, "hostname") {
 		h.Hostname = registryOpts.hostname
 	}
@@ -365,21 +1034,165 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 	if flagChanged(cmd, "priority") {
 		h.Priority = registryOpts.priority
 	}
+	if flagChanged(cmd, "weight") {
+		h.Weight = registryOpts.weight
+	}
+	if flagChanged(cmd, "mirror-backoff") {
+		h.MirrorBackoff = timejson.Duration(registryOpts.mirrorBackoff)
+	}
 	if flagChanged(cmd, "repo-auth") {
 		h.RepoAuth = registryOpts.repoAuth
 	}
+	if flagChanged(cmd, "repo-create") {
+		switch registryOpts.repoCreate {
+		case "", config.RepoCreateECR:
+		default:
+			return fmt.Errorf("unknown repository creator %s%.0w", registryOpts.repoCreate, ErrInvalidInput)
+		}
+		h.RepoCreate = registryOpts.repoCreate
+	}
+	if flagChanged(cmd, "repo-delete") {
+		switch registryOpts.repoDelete {
+		case "", config.RepoDeleteArtifactory, config.RepoDeleteGitLab, config.RepoDeleteHarbor:
+		default:
+			return fmt.Errorf("unknown repository deleter %s%.0w", registryOpts.repoDelete, ErrInvalidInput)
+		}
+		h.RepoDelete = registryOpts.repoDelete
+	}
+	if flagChanged(cmd, "repo-list") {
+		switch registryOpts.repoList {
+		case "", config.RepoListDockerHub, config.RepoListECR, config.RepoListGCR:
+		default:
+			return fmt.Errorf("unknown repository lister %s%.0w", registryOpts.repoList, ErrInvalidInput)
+		}
+		h.RepoList = registryOpts.repoList
+	}
 	if flagChanged(cmd, "blob-chunk") {
 		h.BlobChunk = registryOpts.blobChunk
 	}
 	if flagChanged(cmd, "blob-max") {
 		h.BlobMax = registryOpts.blobMax
 	}
+	if flagChanged(cmd, "digest-algorithm") {
+		switch registryOpts.digestAlgorithm {
+		case "", digest.SHA256.String(), digest.SHA512.String():
+		default:
+			return fmt.Errorf("unsupported digest algorithm %s%.0w", registryOpts.digestAlgorithm, ErrInvalidInput)
+		}
+		h.DigestAlgorithm = registryOpts.digestAlgorithm
+	}
 	if flagChanged(cmd, "req-per-sec") {
 		h.ReqPerSec = registryOpts.reqPerSec
 	}
 	if flagChanged(cmd, "req-concurrent") {
 		h.ReqConcurrent = registryOpts.reqConcurrent
 	}
+	if flagChanged(cmd, "download-rate") {
+		h.DownloadRate = registryOpts.downloadRate
+	}
+	if flagChanged(cmd, "upload-rate") {
+		h.UploadRate = registryOpts.uploadRate
+	}
+	if flagChanged(cmd, "retry-limit") {
+		h.RetryLimit = registryOpts.retryLimit
+	}
+	if flagChanged(cmd, "retry-delay-init") {
+		h.RetryDelayInit = timejson.Duration(registryOpts.retryDelayInit)
+	}
+	if flagChanged(cmd, "retry-delay-max") {
+		h.RetryDelayMax = timejson.Duration(registryOpts.retryDelayMax)
+	}
+	if flagChanged(cmd, "retry-jitter") {
+		if registryOpts.retryJitter < 0 || registryOpts.retryJitter > 1 {
+			return fmt.Errorf("retry-jitter must be between 0 and 1%.0w", ErrInvalidInput)
+		}
+		h.RetryJitter = registryOpts.retryJitter
+	}
+	if flagChanged(cmd, "retry-status") {
+		h.RetryStatus = registryOpts.retryStatus
+	}
+	if flagChanged(cmd, "retry-budget") {
+		h.RetryBudget = timejson.Duration(registryOpts.retryBudget)
+	}
+	if flagChanged(cmd, "connect-timeout") {
+		h.ConnectTimeout = timejson.Duration(registryOpts.connectTimeout)
+	}
+	if flagChanged(cmd, "tls-timeout") {
+		h.TLSTimeout = timejson.Duration(registryOpts.tlsTimeout)
+	}
+	if flagChanged(cmd, "header-timeout") {
+		h.HeaderTimeout = timejson.Duration(registryOpts.headerTimeout)
+	}
+	if flagChanged(cmd, "request-timeout") {
+		h.RequestTimeout = timejson.Duration(registryOpts.requestTimeout)
+	}
+	if flagChanged(cmd, "blob-timeout-rate") {
+		h.BlobTimeoutRate = registryOpts.blobTimeoutRate
+	}
+	if flagChanged(cmd, "redirect-auth") {
+		switch registryOpts.redirectAuth {
+		case config.RedirectAuthDefault, config.RedirectAuthStrip, config.RedirectAuthKeep:
+			h.RedirectAuth = registryOpts.redirectAuth
+		default:
+			return fmt.Errorf("unknown redirect-auth value \"%s\"", registryOpts.redirectAuth)
+		}
+	}
+	if flagChanged(cmd, "redirect-max") {
+		h.RedirectMax = registryOpts.redirectMax
+	}
+	if flagChanged(cmd, "redirect-allow") {
+		h.RedirectAllow = registryOpts.redirectAllow
+	}
+	if flagChanged(cmd, "redirect-deny") {
+		h.RedirectDeny = registryOpts.redirectDeny
+	}
+	if flagChanged(cmd, "http-proxy") {
+		h.HTTPProxy = registryOpts.httpProxy
+	}
+	if flagChanged(cmd, "https-proxy") {
+		h.HTTPSProxy = registryOpts.httpsProxy
+	}
+	if flagChanged(cmd, "no-proxy") {
+		h.NoProxy = registryOpts.noProxy
+	}
+	if flagChanged(cmd, "ip-family") {
+		switch registryOpts.ipFamily {
+		case config.IPFamilyDual, config.IPFamilyIPv4, config.IPFamilyIPv6:
+			h.IPFamily = registryOpts.ipFamily
+		default:
+			return fmt.Errorf("unknown ip-family value \"%s\"%.0w", registryOpts.ipFamily, ErrInvalidInput)
+		}
+	}
+	if flagChanged(cmd, "dns-server") {
+		h.DNSServers = registryOpts.dnsServers
+	}
+	if flagChanged(cmd, "static-host") {
+		if h.StaticHosts == nil {
+			h.StaticHosts = map[string][]string{}
+		}
+		// the first entry for a hostname replaces any existing addresses
+		seen := map[string]bool{}
+		for _, kv := range registryOpts.staticHosts {
+			kvArr := strings.SplitN(kv, "=", 2)
+			if kvArr[0] == "" {
+				return fmt.Errorf("static-host requires a hostname=ip value, received \"%s\"%.0w", kv, ErrInvalidInput)
+			}
+			if !seen[kvArr[0]] {
+				seen[kvArr[0]] = true
+				delete(h.StaticHosts, kvArr[0])
+			}
+			if len(kvArr) < 2 || kvArr[1] == "" {
+				continue
+			}
+			if net.ParseIP(kvArr[1]) == nil {
+				return fmt.Errorf("static-host \"%s\" is not a valid ip%.0w", kvArr[1], ErrInvalidInput)
+			}
+			h.StaticHosts[kvArr[0]] = append(h.StaticHosts[kvArr[0]], kvArr[1])
+		}
+		if len(h.StaticHosts) == 0 {
+			h.StaticHosts = nil
+		}
+	}
 	if flagChanged(cmd, "api-opts") {
 		if h.APIOpts == nil {
 			h.APIOpts = map[string]string{}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/regclient/regclient/pkg/regtest"
+)
+
+func TestRegistryMigrate(t *testing.T) {
+	// set a temp dir for storing configs
+	tempDir := t.TempDir()
+	origEnv, set := os.LookupEnv(ConfigEnv)
+	if set {
+		defer os.Setenv(ConfigEnv, origEnv)
+	} else {
+		defer os.Unsetenv(ConfigEnv)
+	}
+	os.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+
+	tsSrc := httptest.NewServer(regtest.New())
+	t.Cleanup(tsSrc.Close)
+	tsTgt := httptest.NewServer(regtest.New())
+	t.Cleanup(tsTgt.Close)
+	uSrc, _ := url.Parse(tsSrc.URL)
+	uTgt, _ := url.Parse(tsTgt.URL)
+	for _, host := range []string{uSrc.Host, uTgt.Host} {
+		_, err := cobraTest(t, nil, "registry", "set", host, "--tls", "disabled", "--req-per-sec", "1000")
+		if err != nil {
+			t.Fatalf("failed to configure registry %s: %v", host, err)
+		}
+	}
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	for _, tgt := range []string{"/team/app:v2", "/team/app:latest", "/team/sub/tool:v2", "/other/app:v2"} {
+		_, err := cobraTest(t, nil, "image", "copy", srcRef, uSrc.Host+tgt)
+		if err != nil {
+			t.Fatalf("failed to copy image to %s: %v", tgt, err)
+		}
+	}
+	stateFile := filepath.Join(tempDir, "state.json")
+
+	_, err := cobraTest(t, nil, "registry", "migrate", uSrc.Host+"/team", uTgt.Host+"/new", "--referrers", "--state", stateFile)
+	if err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	out, err := cobraTest(t, nil, "tag", "ls", uTgt.Host+"/new/app")
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "latest\nv2" {
+		t.Errorf("unexpected tags for new/app: %s", out)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", uTgt.Host+"/new/sub/tool")
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "v2" {
+		t.Errorf("unexpected tags for new/sub/tool: %s", out)
+	}
+	out, err = cobraTest(t, nil, "repo", "ls", uTgt.Host)
+	if err != nil {
+		t.Fatalf("failed to list repos: %v", err)
+	}
+	if out != "new/app\nnew/sub/tool" {
+		t.Errorf("unexpected repositories in target: %s", out)
+	}
+
+	// state records each completed repository
+	b, err := os.ReadFile(stateFile)
+	if err != nil {
+		t.Fatalf("failed to read state: %v", err)
+	}
+	state := migrateState{}
+	err = json.Unmarshal(b, &state)
+	if err != nil {
+		t.Fatalf("failed to parse state: %v", err)
+	}
+	if len(state.Repos) != 2 || state.Repos["team/app"] == nil || !state.Repos["team/app"].Complete || len(state.Repos["team/app"].Tags) != 2 {
+		t.Errorf("unexpected state: %s", string(b))
+	}
+
+	// rerunning resumes from the state file
+	_, err = cobraTest(t, nil, "registry", "migrate", uSrc.Host+"/team", uTgt.Host+"/new", "--state", stateFile)
+	if err != nil {
+		t.Errorf("failed to resume migration: %v", err)
+	}
+	// a state file for a different migration is rejected
+	_, err = cobraTest(t, nil, "registry", "migrate", uSrc.Host+"/other", uTgt.Host+"/new", "--state", stateFile)
+	if err == nil {
+		t.Errorf("state file for a different migration was accepted")
+	}
+}
+
+func TestRegistryLogin(t *testing.T) {
+	// set a temp dir for storing configs
+	tempDir := t.TempDir()
+	origEnv, set := os.LookupEnv(ConfigEnv)
+	if set {
+		defer os.Setenv(ConfigEnv, origEnv)
+	} else {
+		defer os.Unsetenv(ConfigEnv)
+	}
+	os.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+
+	ts := httptest.NewServer(regtest.New(regtest.WithBasicAuth("testuser", "testpass"), regtest.WithDeleteDisabled()))
+	t.Cleanup(ts.Close)
+	u, _ := url.Parse(ts.URL)
+	host := u.Host
+	_, err := cobraTest(t, nil, "registry", "set", host, "--tls", "disabled", "--req-per-sec", "1000")
+	if err != nil {
+		t.Fatalf("failed to configure registry %s: %v", host, err)
+	}
+
+	// invalid credentials are not saved
+	_, err = cobraTest(t, nil, "registry", "login", host, "-u", "testuser", "-p", "badpass")
+	if err == nil {
+		t.Errorf("login with an invalid password did not fail")
+	}
+	c, err := ConfigLoadDefault()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if c.Hosts[host] == nil || c.Hosts[host].Pass != "" {
+		t.Errorf("invalid credentials were saved")
+	}
+	// skipping the check saves the credentials
+	_, err = cobraTest(t, nil, "registry", "login", host, "-u", "testuser", "-p", "badpass", "--skip-check")
+	if err != nil {
+		t.Errorf("failed to login with skip-check: %v", err)
+	}
+
+	out, err := cobraTest(t, nil, "registry", "login", host, "-u", "testuser", "-p", "testpass", "--repo", "project", "--format", "{{.User}} {{.Push}} {{.Delete}} {{.Referrers}}")
+	if err != nil {
+		t.Fatalf("failed to login: %v", err)
+	}
+	if out != "testuser true false true" {
+		t.Errorf("unexpected login output: %s", out)
+	}
+	out, err = cobraTest(t, nil, "registry", "whoami", host, "--format", "{{.User}}")
+	if err != nil {
+		t.Fatalf("failed to run whoami: %v", err)
+	}
+	if out != "testuser" {
+		t.Errorf("unexpected whoami output: %s", out)
+	}
+
+	_, err = cobraTest(t, nil, "registry", "logout", host)
+	if err != nil {
+		t.Fatalf("failed to logout: %v", err)
+	}
+	_, err = cobraTest(t, nil, "registry", "whoami", host)
+	if err == nil {
+		t.Errorf("whoami succeeded after logout")
+	}
+}
+
+func TestRegistryInspect(t *testing.T) {
+	// set a temp dir for storing configs
+	tempDir := t.TempDir()
+	origEnv, set := os.LookupEnv(ConfigEnv)
+	if set {
+		defer os.Setenv(ConfigEnv, origEnv)
+	} else {
+		defer os.Unsetenv(ConfigEnv)
+	}
+	os.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+
+	ts := httptest.NewServer(regtest.New(regtest.WithReferrersDisabled()))
+	t.Cleanup(ts.Close)
+	u, _ := url.Parse(ts.URL)
+	host := u.Host
+	_, err := cobraTest(t, nil, "registry", "set", host, "--tls", "disabled", "--req-per-sec", "1000")
+	if err != nil {
+		t.Fatalf("failed to configure registry %s: %v", host, err)
+	}
+
+	out, err := cobraTest(t, nil, "registry", "inspect", host)
+	if err != nil {
+		t.Fatalf("failed to inspect: %v", err)
+	}
+	result := map[string]interface{}{}
+	err = json.Unmarshal([]byte(out), &result)
+	if err != nil {
+		t.Fatalf("failed to parse output: %v: %s", err, out)
+	}
+	if result["registry"] != host || result["apiVersion"] != "registry/2.0" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if _, ok := result["push"]; ok {
+		t.Errorf("repository probed without --repo: %s", out)
+	}
+
+	_, err = cobraTest(t, nil, "registry", "inspect", host, "--push")
+	if err == nil {
+		t.Errorf("push probe without a repository did not fail")
+	}
+
+	out, err = cobraTest(t, nil, "registry", "inspect", host, "--repo", "project", "--push", "--format", "{{.Referrers}} {{.Push}} {{.TagDelete}} {{.BlobMount}} {{len .ManifestTypes}}")
+	if err != nil {
+		t.Fatalf("failed to inspect with push: %v", err)
+	}
+	if out != "false true true true 4" {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestRegistryLoginSecrets(t *testing.T) {
+	// set a temp dir for storing configs
+	tempDir := t.TempDir()
+	origEnv, set := os.LookupEnv(ConfigEnv)
+	if set {
+		defer os.Setenv(ConfigEnv, origEnv)
+	} else {
+		defer os.Unsetenv(ConfigEnv)
+	}
+	os.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	passEnv := "REGCTL_TEST_LOGIN_PASS"
+	defer os.Unsetenv(passEnv)
+
+	ts := httptest.NewServer(regtest.New(regtest.WithBasicAuth("testuser", "test$pass")))
+	t.Cleanup(ts.Close)
+	u, _ := url.Parse(ts.URL)
+	host := u.Host
+	_, err := cobraTest(t, nil, "registry", "set", host, "--tls", "disabled", "--req-per-sec", "1000")
+	if err != nil {
+		t.Fatalf("failed to configure registry %s: %v", host, err)
+	}
+
+	// password from stdin, including a trailing newline
+	_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("test$pass\n")}, "registry", "login", host, "-u", "testuser", "--password-stdin")
+	if err != nil {
+		t.Fatalf("failed to login with password-stdin: %v", err)
+	}
+	c, err := ConfigLoadDefault()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if c.Hosts[host].Pass != "test$pass" {
+		t.Errorf("unexpected password saved: %s", c.Hosts[host].Pass)
+	}
+	// the saved user is reused with the password from stdin
+	_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("test$pass")}, "registry", "login", host, "--pass-stdin")
+	if err != nil {
+		t.Errorf("failed to login with the saved user: %v", err)
+	}
+
+	// environment variable references are saved and expanded when used
+	os.Setenv(passEnv, "test$pass")
+	_, err = cobraTest(t, nil, "registry", "login", host, "-u", "testuser", "--pass", "${"+passEnv+"}")
+	if err != nil {
+		t.Fatalf("failed to login with an env reference: %v", err)
+	}
+	c, err = ConfigLoadDefault()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if c.Hosts[host].Pass != "${"+passEnv+"}" {
+		t.Errorf("env reference was not saved: %s", c.Hosts[host].Pass)
+	}
+	out, err := cobraTest(t, nil, "registry", "whoami", host, "--format", "{{.User}}")
+	if err != nil || out != "testuser" {
+		t.Errorf("whoami failed with an env reference: %s, %v", out, err)
+	}
+	os.Unsetenv(passEnv)
+	_, err = cobraTest(t, nil, "registry", "whoami", host)
+	if err == nil {
+		t.Errorf("whoami succeeded without the env variable")
+	}
+}
+
+func TestConfigExpandEnv(t *testing.T) {
+	envName := "REGCTL_TEST_EXPAND"
+	os.Setenv(envName, "secret")
+	defer os.Unsetenv(envName)
+	tt := []struct {
+		in, expect string
+		envOnly    bool
+	}{
+		{in: "", expect: ""},
+		{in: "pa$$word", expect: "pa$$word"},
+		{in: "$" + envName, expect: "$" + envName},
+		{in: "${" + envName + "}", expect: "secret", envOnly: true},
+		{in: "prefix-${" + envName + "}", expect: "prefix-secret"},
+		{in: "${REGCTL_TEST_UNDEFINED}", expect: "", envOnly: true},
+	}
+	for _, tc := range tt {
+		if out := configExpandEnv("example.com", tc.in); out != tc.expect {
+			t.Errorf("expand %s: expected %s, received %s", tc.in, tc.expect, out)
+		}
+		if configEnvOnly(tc.in) != tc.envOnly {
+			t.Errorf("env only %s: expected %t", tc.in, tc.envOnly)
+		}
+	}
+}
+
+func TestRegistryLoginCredStore(t *testing.T) {
+	// set a temp dir for storing configs
+	tempDir := t.TempDir()
+	origEnv, set := os.LookupEnv(ConfigEnv)
+	if set {
+		defer os.Setenv(ConfigEnv, origEnv)
+	} else {
+		defer os.Unsetenv(ConfigEnv)
+	}
+	os.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	// use a credential helper that stores logins in a directory
+	credDir := filepath.Join(tempDir, "creds")
+	err := os.Mkdir(credDir, 0700)
+	if err != nil {
+		t.Fatalf("failed to create cred dir: %v", err)
+	}
+	os.Setenv("CRED_TEST_DIR", credDir)
+	defer os.Unsetenv("CRED_TEST_DIR")
+	helperDir, err := filepath.Abs("../../config/testdata")
+	if err != nil {
+		t.Fatalf("failed to find helper: %v", err)
+	}
+	curPath := os.Getenv("PATH")
+	os.Setenv("PATH", helperDir+string(os.PathListSeparator)+curPath)
+	defer os.Setenv("PATH", curPath)
+
+	ts := httptest.NewServer(regtest.New(regtest.WithBasicAuth("testuser", "testpass")))
+	t.Cleanup(ts.Close)
+	u, _ := url.Parse(ts.URL)
+	host := u.Host
+	_, err = cobraTest(t, nil, "config", "set", "--cred-store", "testfile")
+	if err != nil {
+		t.Fatalf("failed to set cred store: %v", err)
+	}
+	_, err = cobraTest(t, nil, "registry", "set", host, "--tls", "disabled", "--req-per-sec", "1000")
+	if err != nil {
+		t.Fatalf("failed to configure registry %s: %v", host, err)
+	}
+
+	_, err = cobraTest(t, nil, "registry", "login", host, "-u", "testuser", "-p", "testpass")
+	if err != nil {
+		t.Fatalf("failed to login: %v", err)
+	}
+	c, err := ConfigLoadDefault()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if c.Hosts[host].User != "" || c.Hosts[host].Pass != "" || c.Hosts[host].CredHelper != "docker-credential-testfile" {
+		t.Errorf("login saved to the config file: %v", c.Hosts[host])
+	}
+	files, err := os.ReadDir(credDir)
+	if err != nil || len(files) != 1 {
+		t.Errorf("login not saved to the secret store: %v, %v", files, err)
+	}
+	// the login is retrieved from the secret store
+	out, err := cobraTest(t, nil, "registry", "whoami", host, "--format", "{{.User}}")
+	if err != nil || out != "testuser" {
+		t.Errorf("whoami failed with the secret store: %s, %v", out, err)
+	}
+
+	_, err = cobraTest(t, nil, "registry", "logout", host)
+	if err != nil {
+		t.Fatalf("failed to logout: %v", err)
+	}
+	files, err = os.ReadDir(credDir)
+	if err != nil || len(files) != 0 {
+		t.Errorf("login not erased from the secret store: %v, %v", files, err)
+	}
+	c, err = ConfigLoadDefault()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if c.Hosts[host].CredHelper != "" {
+		t.Errorf("cred helper not removed on logout: %s", c.Hosts[host].CredHelper)
+	}
+}
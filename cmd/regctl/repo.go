@@ -1,20 +1,32 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/ref"
 )
 
 type repoCmd struct {
-	rootOpts *rootCmd
-	last     string
-	limit    int
-	format   string
+	rootOpts   *rootCmd
+	accessible bool
+	filters    []string
+	last       string
+	limit      int
+	format     string
+	prefix     string
+	dryRun     bool
+	force      bool
+	digestTags bool
+	referrers  bool
+	replace    bool
 }
 
 func NewRepoCmd(rootOpts *rootCmd) *cobra.Command {
@@ -26,51 +38,129 @@ func NewRepoCmd(rootOpts *rootCmd) *cobra.Command {
 		Short: "manage repositories",
 	}
 	var repoLsCmd = &cobra.Command{
-		Use:     "ls <registry>",
+		Use:     "ls <registry>[/<namespace>]",
 		Aliases: []string{"list"},
 		Short:   "list repositories in a registry",
 		Long: `List repositories in a registry.
-Note: Docker Hub does not support this API request.`,
+A namespace limits the output to repositories within that namespace.
+Registries without the _catalog API can be listed with a provider from the
+repoList registry setting, and Docker Hub requires a namespace.`,
+		Example: `
+# list repositories in a registry
+regctl repo ls registry.example.org
+
+# list repositories in a Docker Hub organization
+regctl repo ls docker.io/regclient`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: registryArgListReg,
 		RunE:              repoOpts.runRepoLs,
 	}
+	var repoRenameCmd = &cobra.Command{
+		Use:     "rename <source_repository> <target_repository>",
+		Aliases: []string{"mv"},
+		Short:   "rename a repository",
+		Long: `Rename a repository by moving every tag to another repository.
+All tags are copied and verified before any source tags are deleted.
+Untagged content in the source repository is left for the registry
+garbage collection.
+`,
+		Example: `
+# rename a repository and include referrers
+regctl repo rename --referrers registry.example.org/repo registry.example.org/new-repo`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgNone,
+		RunE:              repoOpts.runRepoRename,
+	}
 
+	var repoRmCmd = &cobra.Command{
+		Use:     "delete <repository>",
+		Aliases: []string{"del", "rm", "remove"},
+		Short:   "delete a repository",
+		Long: `Delete every tag and manifest in a repository.
+Registries with a repoDelete registry setting (artifactory, gitlab, harbor)
+delete the repository with the vendor API. Otherwise each tagged manifest is
+deleted by digest, along with the referrers of that manifest, and the registry
+must support the manifest delete API.
+The manifests and tags are listed and confirmation is requested before any
+changes are made, use --force to skip the confirmation.
+`,
+		Example: `
+# list the manifests that would be deleted
+regctl repo rm --dry-run registry.example.org/repo
+
+# delete a repository without confirmation
+regctl repo rm --force registry.example.org/repo`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgNone,
+		RunE:              repoOpts.runRepoRm,
+	}
+
+	repoLsCmd.Flags().BoolVarP(&repoOpts.accessible, "accessible", "", false, "Only include repositories where the tags can be listed, requires a request per repository")
+	repoLsCmd.Flags().StringArrayVarP(&repoOpts.filters, "filter", "", []string{}, "Regexp of repositories to include (expression is bound to beginning and ending of repository)")
 	repoLsCmd.Flags().StringVarP(&repoOpts.last, "last", "", "", "Specify the last repo from a previous request for pagination")
 	repoLsCmd.Flags().IntVarP(&repoOpts.limit, "limit", "", 0, "Specify the number of repos to retrieve")
 	repoLsCmd.Flags().StringVarP(&repoOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	repoLsCmd.Flags().StringVarP(&repoOpts.prefix, "prefix", "", "", "Only include repositories beginning with a prefix (e.g. library/)")
+	_ = repoLsCmd.RegisterFlagCompletionFunc("filter", completeArgNone)
 	_ = repoLsCmd.RegisterFlagCompletionFunc("last", completeArgNone)
 	_ = repoLsCmd.RegisterFlagCompletionFunc("limit", completeArgNone)
 	_ = repoLsCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = repoLsCmd.RegisterFlagCompletionFunc("prefix", completeArgNone)
+
+	repoRenameCmd.Flags().BoolVarP(&repoOpts.digestTags, "digest-tags", "", false, "Include digest tags (\"sha256-<digest>.*\") when copying manifests")
+	repoRenameCmd.Flags().BoolVarP(&repoOpts.dryRun, "dry-run", "", false, "Check the source and target without making changes")
+	repoRenameCmd.Flags().BoolVarP(&repoOpts.replace, "force-manifest-replace", "", false, "Replace the source tags with a dummy manifest and delete it when the tag delete API is not supported")
+	repoRenameCmd.Flags().BoolVarP(&repoOpts.referrers, "referrers", "", false, "Include referrers")
+
+	repoRmCmd.Flags().BoolVarP(&repoOpts.dryRun, "dry-run", "", false, "List the manifests and tags without making changes")
+	repoRmCmd.Flags().BoolVarP(&repoOpts.force, "force", "", false, "Delete without confirmation")
 
 	repoTopCmd.AddCommand(repoLsCmd)
+	repoTopCmd.AddCommand(repoRmCmd)
+	repoTopCmd.AddCommand(repoRenameCmd)
 	return repoTopCmd
 }
 
 func (repoOpts *repoCmd) runRepoLs(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
-	host := args[0]
+	host, ns, _ := strings.Cut(args[0], "/")
 	// TODO: use regex to validate hostname + port
-	i := strings.IndexRune(host, '/')
-	if i >= 0 {
+	if host == "" || (ns == "" && strings.HasSuffix(args[0], "/")) {
 		log.WithFields(logrus.Fields{
-			"host": host,
+			"host": args[0],
 		}).Error("Hostname invalid")
 		return ErrInvalidInput
 	}
 	rc := repoOpts.rootOpts.newRegClient()
 	log.WithFields(logrus.Fields{
-		"host":  host,
-		"last":  repoOpts.last,
-		"limit": repoOpts.limit,
+		"host":      host,
+		"namespace": ns,
+		"last":      repoOpts.last,
+		"limit":     repoOpts.limit,
 	}).Debug("Listing repositories")
 	opts := []scheme.RepoOpts{}
+	if ns != "" {
+		opts = append(opts, scheme.WithRepoNamespace(ns))
+	}
 	if repoOpts.last != "" {
 		opts = append(opts, scheme.WithRepoLast(repoOpts.last))
 	}
 	if repoOpts.limit != 0 {
 		opts = append(opts, scheme.WithRepoLimit(repoOpts.limit))
 	}
+	if repoOpts.prefix != "" {
+		opts = append(opts, scheme.WithRepoPrefix(repoOpts.prefix))
+	}
+	for _, expr := range repoOpts.filters {
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return fmt.Errorf("failed to parse regexp \"%s\": %w", expr, err)
+		}
+		opts = append(opts, scheme.WithRepoFilter(re))
+	}
+	if repoOpts.accessible {
+		opts = append(opts, scheme.WithRepoAccessible())
+	}
 	rl, err := rc.RepoList(ctx, host, opts...)
 	if err != nil {
 		return err
@@ -83,5 +173,121 @@ func (repoOpts *repoCmd) runRepoLs(cmd *cobra.Command, args []string) error {
 	case "rawHeaders", "raw-headers", "headers":
 		repoOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
 	}
-	return template.Writer(cmd.OutOrStdout(), repoOpts.format, rl)
+	return repoOpts.rootOpts.writeOutput(cmd, repoOpts.format, rl)
+}
+
+func (repoOpts *repoCmd) runRepoRename(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	rSrc, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	rTgt, err := ref.New(args[1])
+	if err != nil {
+		return err
+	}
+	opts := []regclient.RenameOpts{}
+	if repoOpts.digestTags {
+		opts = append(opts, regclient.RenameWithDigestTags())
+	}
+	if repoOpts.dryRun {
+		opts = append(opts, regclient.RenameWithDryRun())
+	}
+	if repoOpts.referrers {
+		opts = append(opts, regclient.RenameWithReferrers())
+	}
+	if repoOpts.replace {
+		opts = append(opts, regclient.RenameWithManifestReplace())
+	}
+	rc := repoOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, rSrc)
+	defer rc.Close(ctx, rTgt)
+	log.WithFields(logrus.Fields{
+		"source": rSrc.CommonName(),
+		"target": rTgt.CommonName(),
+	}).Debug("Rename repository")
+	entries, err := rc.RepoRename(ctx, rSrc, rTgt, opts...)
+	if err != nil {
+		return err
+	}
+	if repoOpts.dryRun {
+		if ok, err := repoOpts.rootOpts.writeStructured(cmd, entries); ok {
+			return err
+		}
+	}
+	for _, e := range entries {
+		if repoOpts.dryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s (%s)\n", e.Source.CommonName(), e.Target.CommonName(), e.Digest.String())
+		} else {
+			log.WithFields(logrus.Fields{
+				"source": e.Source.CommonName(),
+				"target": e.Target.CommonName(),
+				"digest": e.Digest.String(),
+			}).Info("Renamed tag")
+		}
+	}
+	return nil
+}
+
+// repoRmHasTag returns true when the unparsed reference includes a tag or digest.
+// The parsed reference cannot be used since it sets a default tag.
+func repoRmHasTag(arg string) bool {
+	if i := strings.Index(arg, "://"); i >= 0 {
+		arg = arg[i+3:]
+	}
+	if i := strings.LastIndex(arg, "/"); i >= 0 {
+		arg = arg[i+1:]
+	}
+	return strings.ContainsAny(arg, ":@")
+}
+
+func (repoOpts *repoCmd) runRepoRm(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	// an explicit tag or digest, including "latest", is rejected to avoid deleting a repository when one tag was intended
+	if repoRmHasTag(args[0]) || r.Digest != "" {
+		return fmt.Errorf("a repository without a tag or digest is required: %s%.0w", args[0], ErrInvalidInput)
+	}
+	rc := repoOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	log.WithFields(logrus.Fields{
+		"repo": r.CommonName(),
+	}).Debug("Delete repository")
+	entries, err := rc.RepoDelete(ctx, r, regclient.RepoDeleteWithDryRun())
+	if err != nil {
+		return err
+	}
+	if repoOpts.dryRun {
+		if ok, err := repoOpts.rootOpts.writeStructured(cmd, entries); ok {
+			return err
+		}
+		for _, e := range entries {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", e.Digest.String(), strings.Join(e.Tags, ", "))
+		}
+		return nil
+	}
+	if !repoOpts.force {
+		tags := 0
+		for _, e := range entries {
+			tags += len(e.Tags)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Delete %d manifests with %d tags from %s? [y/N] ", len(entries), tags, r.CommonName())
+		answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return fmt.Errorf("repository delete was not confirmed%.0w", ErrAborted)
+		}
+	}
+	_, err = rc.RepoDelete(ctx, r)
+	if err != nil {
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"repo":      r.CommonName(),
+		"manifests": len(entries),
+	}).Info("Repository deleted")
+	return nil
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRepoRm(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v1"
+	tgtRepo := fmt.Sprintf("ocidir://%s/repo", tmpDir)
+	for _, tag := range []string{"v1", "alias"} {
+		_, err := cobraTest(t, nil, "image", "copy", srcRef, tgtRepo+":"+tag)
+		if err != nil {
+			t.Fatalf("failed to copy image: %v", err)
+		}
+	}
+	dig, err := cobraTest(t, nil, "image", "digest", srcRef)
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+
+	out, err := cobraTest(t, nil, "repo", "rm", "--dry-run", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to run dry run: %v", err)
+	}
+	if out != dig+" alias, v1" {
+		t.Errorf("unexpected dry run output: %s", out)
+	}
+	for _, suffix := range []string{":v1", ":latest", "@" + dig} {
+		_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("y\n")}, "repo", "rm", tgtRepo+suffix)
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("unexpected error with %s, expected %v, received %v", suffix, ErrInvalidInput, err)
+		}
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("n\n")}, "repo", "rm", tgtRepo)
+	if !errors.Is(err, ErrAborted) {
+		t.Errorf("unexpected error without confirmation, expected %v, received %v", ErrAborted, err)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "alias\nv1" {
+		t.Errorf("tags changed without confirmation: %s", out)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("y\n")}, "repo", "rm", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to delete repository: %v", err)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "" {
+		t.Errorf("tags remain after delete: %s", out)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"os"
+	"regexp"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -11,8 +13,8 @@ import (
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/strparse"
 	"github.com/regclient/regclient/internal/version"
-	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/types/ref"
 )
 
 const (
@@ -34,6 +36,7 @@ type rootCmd struct {
 	logopts   []string
 	format    string // for Go template formatting of various commands
 	hosts     []string
+	output    string // output renderer, see outputFormats
 	userAgent string
 }
 
@@ -65,6 +68,7 @@ func NewRootCmd() *cobra.Command {
 	rootTopCmd.PersistentFlags().StringVarP(&rootOpts.verbosity, "verbosity", "v", logrus.WarnLevel.String(), "Log level (debug, info, warn, error, fatal, panic)")
 	rootTopCmd.PersistentFlags().StringArrayVar(&rootOpts.logopts, "logopt", []string{}, "Log options")
 	rootTopCmd.PersistentFlags().StringArrayVar(&rootOpts.hosts, "host", []string{}, "Registry hosts to add (reg=registry,user=username,pass=password,tls=enabled)")
+	rootTopCmd.PersistentFlags().StringVarP(&rootOpts.output, "output", "", "", "Output renderer (table, json, yaml), table uses the --format of each command")
 	rootTopCmd.PersistentFlags().StringVarP(&rootOpts.userAgent, "user-agent", "", "", "Override user agent")
 
 	_ = rootTopCmd.RegisterFlagCompletionFunc("verbosity", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -72,6 +76,9 @@ func NewRootCmd() *cobra.Command {
 	})
 	_ = rootTopCmd.RegisterFlagCompletionFunc("logopt", completeArgNone)
 	_ = rootTopCmd.RegisterFlagCompletionFunc("host", completeArgNone)
+	_ = rootTopCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return outputFormats, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	versionCmd.Flags().StringVarP(&rootOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
 	_ = versionCmd.RegisterFlagCompletionFunc("format", completeArgNone)
@@ -80,6 +87,7 @@ func NewRootCmd() *cobra.Command {
 	rootTopCmd.AddCommand(versionCmd)
 	rootTopCmd.AddCommand(
 		NewArtifactCmd(&rootOpts),
+		NewAttestationCmd(&rootOpts),
 		NewBlobCmd(&rootOpts),
 		NewCompletionCmd(&rootOpts),
 		NewConfigCmd(&rootOpts),
@@ -89,6 +97,7 @@ func NewRootCmd() *cobra.Command {
 		NewManifestCmd(&rootOpts),
 		NewRegistryCmd(&rootOpts),
 		NewRepoCmd(&rootOpts),
+		NewServeCmd(&rootOpts),
 		NewTagCmd(&rootOpts),
 	)
 	return rootTopCmd
@@ -105,12 +114,34 @@ func (rootOpts *rootCmd) rootPreRun(cmd *cobra.Command, args []string) error {
 			log.Formatter = new(logrus.JSONFormatter)
 		}
 	}
-	return nil
+	return outputValidate(rootOpts.output)
 }
 
 func (rootOpts *rootCmd) runVersion(cmd *cobra.Command, args []string) error {
 	info := version.GetInfo()
-	return template.Writer(cmd.OutOrStdout(), rootOpts.format, info)
+	return rootOpts.writeOutput(cmd, rootOpts.format, info)
+}
+
+// shortDigestRE matches a reference ending with a short digest, e.g. "repo@sha256:abcd".
+var shortDigestRE = regexp.MustCompile(`^(.+)@((?:[A-Za-z][A-Za-z0-9]*:)?[0-9a-fA-F]{4,})$`)
+
+// refNew parses a reference, resolving a short digest with [regclient.RegClient.ResolveDigestPrefix].
+func (rootOpts *rootCmd) refNew(ctx context.Context, s string) (ref.Ref, error) {
+	r, err := ref.New(s)
+	if err == nil {
+		return r, nil
+	}
+	match := shortDigestRE.FindStringSubmatch(s)
+	if match == nil {
+		return r, err
+	}
+	rRepo, errRepo := ref.New(match[1])
+	if errRepo != nil {
+		return r, err
+	}
+	rc := rootOpts.newRegClient()
+	defer rc.Close(ctx, rRepo)
+	return rc.ResolveDigestPrefix(ctx, rRepo, match[2])
 }
 
 func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
@@ -120,7 +151,14 @@ func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
 			"err": err,
 		}).Warn("Failed to load default config")
 	}
+	return rootOpts.newRegClientConfig(conf)
+}
 
+// newRegClientConfig creates a regclient from a config that may not be saved, e.g. to verify a login.
+func (rootOpts *rootCmd) newRegClientConfig(conf *Config) *regclient.RegClient {
+	if conf == nil {
+		conf = ConfigNew()
+	}
 	rcOpts := []regclient.Opt{
 		regclient.WithLog(log),
 		regclient.WithRegOpts(reg.WithCache(time.Minute*5, 500)),
@@ -138,6 +176,9 @@ func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
 	if conf.BlobLimit != 0 {
 		rcOpts = append(rcOpts, regclient.WithRegOpts(reg.WithBlobLimit(conf.BlobLimit)))
 	}
+	if conf.TokenCache != "" {
+		rcOpts = append(rcOpts, regclient.WithRegOpts(reg.WithTokenCache(conf.TokenCache)))
+	}
 	if conf.IncDockerCred == nil || *conf.IncDockerCred {
 		rcOpts = append(rcOpts, regclient.WithDockerCreds())
 	}
@@ -147,8 +188,10 @@ func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
 
 	rcHosts := []config.Host{}
 	for name, host := range conf.Hosts {
-		host.Name = name
-		rcHosts = append(rcHosts, *host)
+		h := *host
+		h.Name = name
+		configHostExpandEnv(&h)
+		rcHosts = append(rcHosts, h)
 	}
 	for _, h := range rootOpts.hosts {
 		hKV, err := strparse.SplitCSKV(h)
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/regclient/regclient/pkg/regserver"
+)
+
+type serveCmd struct {
+	rootOpts   *rootCmd
+	listen     string
+	tokenFile  string
+	tlsCert    string
+	tlsKey     string
+	allowLocal bool
+	noAuth     bool
+	replace    bool
+}
+
+func NewServeCmd(rootOpts *rootCmd) *cobra.Command {
+	serveOpts := serveCmd{
+		rootOpts: rootOpts,
+	}
+	var serveTopCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "run a REST API for registry operations",
+		Long: `Run a long running REST API server for copying images, inspecting manifests, and managing tags.
+All requests share a single client, reusing connections, auth tokens, and rate limits.
+Clients authenticate with an "Authorization: Bearer <token>" header using a token from the --token-file.
+Running without a token file requires --insecure-no-auth, which accepts every request.
+Only the REST API is provided, there is no gRPC interface.
+
+Endpoints:
+  GET    /healthz
+  POST   /v1/copy                   {"source": "<ref>", "target": "<ref>", "referrers": true}
+  GET    /v1/manifest?ref=<ref>     optional platform=<platform>
+  GET    /v1/tags?repo=<repo>
+  DELETE /v1/tags?ref=<ref>`,
+		Example: `
+# listen on localhost with tokens from a file
+regctl serve --listen 127.0.0.1:8080 --token-file tokens.txt`,
+		Args:              cobra.ExactArgs(0),
+		ValidArgsFunction: completeArgNone,
+		RunE:              serveOpts.runServe,
+	}
+	serveTopCmd.Flags().StringVarP(&serveOpts.listen, "listen", "", "127.0.0.1:8080", "Address to listen on")
+	serveTopCmd.Flags().StringVarP(&serveOpts.tokenFile, "token-file", "", "", "File with bearer tokens accepted by the API, one per line")
+	serveTopCmd.Flags().StringVarP(&serveOpts.tlsCert, "tls-cert", "", "", "TLS certificate file for the API")
+	serveTopCmd.Flags().StringVarP(&serveOpts.tlsKey, "tls-key", "", "", "TLS key file for the API")
+	serveTopCmd.Flags().BoolVarP(&serveOpts.allowLocal, "allow-local", "", false, "Allow local refs (ocidir), giving clients access to the filesystem")
+	serveTopCmd.Flags().BoolVarP(&serveOpts.noAuth, "insecure-no-auth", "", false, "Accept every request without a token, including copies and tag deletes")
+	serveTopCmd.Flags().BoolVarP(&serveOpts.replace, "force-manifest-replace", "", false, "Allow tag deletes to push a temporary manifest to the tag when the registry does not support the tag delete API")
+	_ = serveTopCmd.RegisterFlagCompletionFunc("listen", completeArgNone)
+	_ = serveTopCmd.RegisterFlagCompletionFunc("token-file", completeArgDefault)
+	_ = serveTopCmd.RegisterFlagCompletionFunc("tls-cert", completeArgDefault)
+	_ = serveTopCmd.RegisterFlagCompletionFunc("tls-key", completeArgDefault)
+	return serveTopCmd
+}
+
+func (serveOpts *serveCmd) runServe(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if (serveOpts.tlsCert == "") != (serveOpts.tlsKey == "") {
+		return fmt.Errorf("tls-cert and tls-key must be provided together%.0w", ErrInvalidInput)
+	}
+	if serveOpts.tokenFile == "" && !serveOpts.noAuth {
+		return fmt.Errorf("token-file or insecure-no-auth must be provided%.0w", ErrInvalidInput)
+	}
+	opts := []regserver.Opts{regserver.WithLog(log)}
+	if serveOpts.tokenFile != "" {
+		tokens, err := serveTokens(serveOpts.tokenFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, regserver.WithAuth(regserver.AuthToken(tokens...)))
+	} else {
+		log.WithFields(logrus.Fields{
+			"listen": serveOpts.listen,
+		}).Warn("No token file provided, API requests are not authenticated")
+		opts = append(opts, regserver.WithInsecureNoAuth())
+	}
+	if serveOpts.allowLocal {
+		opts = append(opts, regserver.WithAllowLocal())
+	}
+	if serveOpts.replace {
+		opts = append(opts, regserver.WithTagManifestReplace())
+	}
+	rc := serveOpts.rootOpts.newRegClient()
+	srv := &http.Server{
+		Addr:              serveOpts.listen,
+		Handler:           regserver.New(rc, opts...),
+		ReadHeaderTimeout: time.Second * 30,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errC := make(chan error, 1)
+	go func() {
+		log.WithFields(logrus.Fields{
+			"listen": serveOpts.listen,
+		}).Info("Starting API server")
+		if serveOpts.tlsCert != "" {
+			errC <- srv.ListenAndServeTLS(serveOpts.tlsCert, serveOpts.tlsKey)
+		} else {
+			errC <- srv.ListenAndServe()
+		}
+	}()
+	select {
+	case err := <-errC:
+		return err
+	case <-ctx.Done():
+	}
+	// allow running requests to finish
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	err := srv.Shutdown(ctxShutdown)
+	if err != nil {
+		return err
+	}
+	err = <-errC
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// serveTokens reads the tokens from a file, ignoring empty lines and comments.
+func serveTokens(filename string) ([]string, error) {
+	//#nosec G304 command is run by a local user
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	tokens := []string{}
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no tokens found in %s%.0w", filename, ErrInvalidInput)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	_, err := cobraTest(t, nil, "serve", "--listen", "127.0.0.1:0")
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("unexpected error without a token file, expected %v, received %v", ErrInvalidInput, err)
+	}
+	_, err = cobraTest(t, nil, "serve", "--insecure-no-auth", "--tls-cert", "cert.pem")
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("unexpected error with only a tls cert, expected %v, received %v", ErrInvalidInput, err)
+	}
+}
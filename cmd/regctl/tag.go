@@ -1,24 +1,62 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
 	"regexp"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
 )
 
 type tagCmd struct {
-	rootOpts *rootCmd
-	limit    int
-	last     string
-	include  []string
-	exclude  []string
-	format   string
+	rootOpts    *rootCmd
+	limit       int
+	last        string
+	include     []string
+	exclude     []string
+	format      string
+	formatRb    string
+	formatPrune string
+	formatWatch string
+	prune       bool
+	dryRun      bool
+	digestTags  bool
+	referrers   bool
+	details     bool
+	concurrency int
+	interval    time.Duration
+	replace     bool
+	count       int
+	webhooks    []string
+	match       string
+	keepLast    int
+	keepNewer   time.Duration
+	keepSemver  bool
+}
+
+// tagLsDetail is the output of "tag ls --details" for each tag.
+type tagLsDetail struct {
+	Tag       string `json:"tag"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+	Platforms int    `json:"platforms"`
+	Error     string `json:"error,omitempty"`
 }
 
 func NewTagCmd(rootOpts *rootCmd) *cobra.Command {
@@ -35,14 +73,38 @@ func NewTagCmd(rootOpts *rootCmd) *cobra.Command {
 		Short:   "delete a tag in a repo",
 		Long: `Delete a tag in a repository.
 This avoids deleting the manifest when multiple tags reference the same image.
-For registries that do not support the OCI tag delete API, this is implemented
-by pushing a unique dummy manifest and deleting that by digest.
+The OCI tag delete API is used, and the command fails on registries that do not
+support that API. With --force-manifest-replace, those registries are handled
+by pushing a unique dummy manifest to the tag and deleting that by digest.
 If the registry does not support the delete API, the dummy manifest will remain.
 `,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              tagOpts.runTagDelete,
 	}
+	var tagExportCmd = &cobra.Command{
+		Use:   "export <repository> [filename]",
+		Short: "export tags and digests from a repo",
+		Long: `Export the digest of every tag in a repository as JSON.
+This only includes the tag pointers, use "regctl image copy" to copy content.
+The output is written to stdout when a filename is not provided.
+`,
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completeArgList([]completeFunc{completeArgNone, completeArgDefault}),
+		RunE:              tagOpts.runTagExport,
+	}
+	var tagImportCmd = &cobra.Command{
+		Use:   "import <repository> [filename]",
+		Short: "import tags and digests to a repo",
+		Long: `Import tags from the output of "regctl tag export".
+Each tag is updated to point to the exported digest, and that manifest must
+already exist in the repository. Tags already pointing to the digest are
+not modified. The input is read from stdin when a filename is not provided.
+`,
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completeArgList([]completeFunc{completeArgNone, completeArgDefault}),
+		RunE:              tagOpts.runTagImport,
+	}
 	var tagLsCmd = &cobra.Command{
 		Use:     "ls <repository>",
 		Aliases: []string{"list"},
@@ -50,24 +112,147 @@ If the registry does not support the delete API, the dummy manifest will remain.
 		Long: `List tags in a repository.
 Note: many registries ignore the pagination options.
 For an OCI Layout, the index is available as Index (--format "{{.Index}}").
+With --details, each tag is checked for the digest, media type, and number of
+platforms, and the output is a table or formatted list of those details
+(--format "{{json .}}").
 `,
 		Args:      cobra.ExactArgs(1),
 		ValidArgs: []string{},
 		RunE:      tagOpts.runTagLs,
 	}
+	var tagPruneCmd = &cobra.Command{
+		Use:   "prune <repository>",
+		Short: "delete tags with a retention policy",
+		Long: `Delete tags in a repository that are not kept by a retention policy.
+Only tags matching --match are pruned, and capture groups in the expression
+group the tags for --keep-last. A matching tag is kept when it is one of the
+newest --keep-last tags in the group, was created within --keep-newer, or is a
+semver release with --keep-semver. The creation time is read from the image
+config or the created annotation, and tags with an unknown creation time are
+kept. Digest tags ("sha256-<digest>*") are never pruned. When a pruned tag
+shares a manifest with a kept tag, only the tag is deleted.
+Use --dry-run to output the policy result without deleting any tags.
+`,
+		Example: `
+# show the tags that would be pruned, keeping the newest 5 tags
+regctl tag prune --keep-last 5 --dry-run registry.example.org/repo
+
+# keep the newest 3 tags of each major version, and all semver releases
+regctl tag prune --match 'v(\d+)\..*' --keep-last 3 --keep-semver registry.example.org/repo
+
+# prune nightly tags older than 30 days
+regctl tag prune --match 'nightly-.*' --keep-newer 720h registry.example.org/repo`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{},
+		RunE:      tagOpts.runTagPrune,
+	}
+	var tagRenameCmd = &cobra.Command{
+		Use:     "rename <source_ref> <target_ref>",
+		Aliases: []string{"mv"},
+		Short:   "rename a tag",
+		Long: `Rename a tag, optionally moving it to another repository.
+The image is copied to the target, the target digest is verified, and then
+the source tag is deleted. The rename fails without changes when the target
+tag already exists with a different digest.
+`,
+		Example: `
+# rename a tag in the same repository
+regctl tag rename registry.example.org/repo:v1 registry.example.org/repo:v1-old
+
+# move a tag to another repository with the referrers
+regctl tag rename --referrers registry.example.org/repo:v1 registry.example.org/archive:v1`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              tagOpts.runTagRename,
+	}
+	var tagRollbackCmd = &cobra.Command{
+		Use:   "rollback <image_ref>",
+		Short: "rollback a tag to the previous digest",
+		Long: `Rollback a tag to the previous digest.
+The previous digest is recorded in the "org.regclient.previous.digest" annotation
+when the manifest is pushed with "regctl manifest put --record-previous".
+The tag is updated to point to the previous manifest which must still exist in the repository.
+`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              tagOpts.runTagRollback,
+	}
+	var tagWatchCmd = &cobra.Command{
+		Use:   "watch <image_ref>",
+		Short: "watch a tag for digest changes",
+		Long: `Watch a tag and output an event each time the digest changes.
+The tag is polled with a HEAD request which does not pull the manifest.
+The first poll outputs the current digest, and a deleted tag is output with an
+empty digest. Each event is output as a line of JSON by default, and may also
+be posted to one or more webhooks. The watch runs until interrupted or the
+--count of events is reached.
+`,
+		Example: `
+# output each change to a tag
+regctl tag watch registry.example.org/repo:latest
+
+# post changes to a webhook, polling every 5 minutes
+regctl tag watch --interval 5m --webhook https://ci.example.org/hook registry.example.org/repo:latest`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              tagOpts.runTagWatch,
+	}
 
+	tagDeleteCmd.Flags().BoolVarP(&tagOpts.replace, "force-manifest-replace", "", false, "Replace the tag with a dummy manifest and delete it when the tag delete API is not supported")
+
+	tagImportCmd.Flags().BoolVarP(&tagOpts.replace, "force-manifest-replace", "", false, "Replace pruned tags with a dummy manifest and delete it when the tag delete API is not supported")
+	tagImportCmd.Flags().BoolVarP(&tagOpts.prune, "prune", "", false, "Delete tags in the repository that were not exported")
+
+	tagLsCmd.Flags().IntVarP(&tagOpts.concurrency, "concurrency", "", 5, "Number of concurrent requests with --details")
+	tagLsCmd.Flags().BoolVarP(&tagOpts.details, "details", "", false, "Include the digest, media type, and platform count of each tag")
 	tagLsCmd.Flags().StringVarP(&tagOpts.last, "last", "", "", "Specify the last tag from a previous request for pagination (depends on registry support)")
 	tagLsCmd.Flags().IntVarP(&tagOpts.limit, "limit", "", 0, "Specify the number of tags to retrieve (depends on registry support)")
 	tagLsCmd.Flags().StringArrayVar(&tagOpts.include, "include", []string{}, "Regexp of tags to include (expression is bound to beginning and ending of tag)")
 	tagLsCmd.Flags().StringArrayVar(&tagOpts.exclude, "exclude", []string{}, "Regexp of tags to exclude (expression is bound to beginning and ending of tag)")
 	tagLsCmd.Flags().StringVarP(&tagOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	_ = tagLsCmd.RegisterFlagCompletionFunc("concurrency", completeArgNone)
 	_ = tagLsCmd.RegisterFlagCompletionFunc("last", completeArgNone)
 	_ = tagLsCmd.RegisterFlagCompletionFunc("limit", completeArgNone)
 	_ = tagLsCmd.RegisterFlagCompletionFunc("filter", completeArgNone)
 	_ = tagLsCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 
+	tagPruneCmd.Flags().BoolVarP(&tagOpts.dryRun, "dry-run", "", false, "Output the result without deleting any tags")
+	tagPruneCmd.Flags().BoolVarP(&tagOpts.replace, "force-manifest-replace", "", false, "Replace tags sharing a manifest with a kept tag with a dummy manifest and delete it when the tag delete API is not supported")
+	tagPruneCmd.Flags().StringVarP(&tagOpts.formatPrune, "format", "", "", "Format output with go template syntax")
+	tagPruneCmd.Flags().IntVarP(&tagOpts.keepLast, "keep-last", "", 0, "Keep the newest number of tags in each group")
+	tagPruneCmd.Flags().DurationVarP(&tagOpts.keepNewer, "keep-newer", "", 0, "Keep tags created within the duration")
+	tagPruneCmd.Flags().BoolVarP(&tagOpts.keepSemver, "keep-semver", "", false, "Keep semver release tags (e.g. v1.2.3)")
+	tagPruneCmd.Flags().StringVarP(&tagOpts.match, "match", "", "", "Regexp of tags to prune, capture groups group the tags for --keep-last (expression is bound to beginning and ending of tag)")
+	_ = tagPruneCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = tagPruneCmd.RegisterFlagCompletionFunc("keep-last", completeArgNone)
+	_ = tagPruneCmd.RegisterFlagCompletionFunc("keep-newer", completeArgNone)
+	_ = tagPruneCmd.RegisterFlagCompletionFunc("match", completeArgNone)
+
+	tagRenameCmd.Flags().BoolVarP(&tagOpts.digestTags, "digest-tags", "", false, "Include digest tags (\"sha256-<digest>.*\") when copying to another repository")
+	tagRenameCmd.Flags().BoolVarP(&tagOpts.dryRun, "dry-run", "", false, "Check the source and target without making changes")
+	tagRenameCmd.Flags().BoolVarP(&tagOpts.replace, "force-manifest-replace", "", false, "Replace the source tag with a dummy manifest and delete it when the tag delete API is not supported")
+	tagRenameCmd.Flags().BoolVarP(&tagOpts.referrers, "referrers", "", false, "Include referrers when copying to another repository")
+
+	tagRollbackCmd.Flags().StringVarP(&tagOpts.formatRb, "format", "", "", "Format output with go template syntax")
+	_ = tagRollbackCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
+	tagWatchCmd.Flags().IntVarP(&tagOpts.count, "count", "", 0, "Exit after the number of events, including the initial digest")
+	tagWatchCmd.Flags().StringVarP(&tagOpts.formatWatch, "format", "", "{{json .}}", "Format output with go template syntax")
+	tagWatchCmd.Flags().DurationVarP(&tagOpts.interval, "interval", "", time.Minute, "Time between each poll of the tag")
+	tagWatchCmd.Flags().StringArrayVarP(&tagOpts.webhooks, "webhook", "", []string{}, "URL to post each event as JSON")
+	_ = tagWatchCmd.RegisterFlagCompletionFunc("count", completeArgNone)
+	_ = tagWatchCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	_ = tagWatchCmd.RegisterFlagCompletionFunc("interval", completeArgNone)
+	_ = tagWatchCmd.RegisterFlagCompletionFunc("webhook", completeArgNone)
+
 	tagTopCmd.AddCommand(tagDeleteCmd)
+	tagTopCmd.AddCommand(tagExportCmd)
+	tagTopCmd.AddCommand(tagImportCmd)
 	tagTopCmd.AddCommand(tagLsCmd)
+	tagTopCmd.AddCommand(tagPruneCmd)
+	tagTopCmd.AddCommand(tagRenameCmd)
+	tagTopCmd.AddCommand(tagRollbackCmd)
+	tagTopCmd.AddCommand(tagWatchCmd)
 	return tagTopCmd
 }
 
@@ -84,13 +269,283 @@ func (tagOpts *tagCmd) runTagDelete(cmd *cobra.Command, args []string) error {
 		"repository": r.Repository,
 		"tag":        r.Tag,
 	}).Debug("Delete tag")
-	err = rc.TagDelete(ctx, r)
+	opts := []scheme.TagOpts{}
+	if tagOpts.replace {
+		opts = append(opts, scheme.WithTagManifestReplace())
+	}
+	err = rc.TagDeleteWithOpts(ctx, r, opts...)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func (tagOpts *tagCmd) runTagExport(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	var w io.Writer
+	if len(args) == 2 {
+		fh, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		w = fh
+	} else {
+		w = cmd.OutOrStdout()
+	}
+	rc := tagOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	log.WithFields(logrus.Fields{
+		"host":       r.Registry,
+		"repository": r.Repository,
+	}).Debug("Export tags")
+	ts, err := rc.TagExport(ctx, r)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ts)
+}
+
+func (tagOpts *tagCmd) runTagImport(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	var rdr io.Reader
+	if len(args) == 2 {
+		fh, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		rdr = fh
+	} else {
+		rdr = cmd.InOrStdin()
+	}
+	ts := regclient.TagState{}
+	err = json.NewDecoder(rdr).Decode(&ts)
+	if err != nil {
+		return fmt.Errorf("failed to parse tag state: %w", err)
+	}
+	opts := []regclient.TagImportOpts{}
+	if tagOpts.prune {
+		opts = append(opts, regclient.TagImportWithPrune())
+	}
+	if tagOpts.replace {
+		opts = append(opts, regclient.TagImportWithManifestReplace())
+	}
+	rc := tagOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	log.WithFields(logrus.Fields{
+		"host":       r.Registry,
+		"repository": r.Repository,
+		"tags":       len(ts.Tags),
+	}).Debug("Import tags")
+	return rc.TagImport(ctx, r, ts, opts...)
+}
+
+// tagLsDetails checks each tag with concurrent requests.
+// A HEAD request returns the digest and media type, and an index is pulled to count the platforms.
+// Errors are included in the details of each tag.
+func tagLsDetails(ctx context.Context, rc *regclient.RegClient, r ref.Ref, tags []string, concurrency int) []tagLsDetail {
+	details := make([]tagLsDetail, len(tags))
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(tags) {
+		concurrency = len(tags)
+	}
+	queue := make(chan int, len(tags))
+	for i := range tags {
+		queue <- i
+	}
+	close(queue)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				details[i] = tagLsDetailGet(ctx, rc, r.SetTag(tags[i]))
+			}
+		}()
+	}
+	wg.Wait()
+	return details
+}
+
+func tagLsDetailGet(ctx context.Context, rc *regclient.RegClient, r ref.Ref) tagLsDetail {
+	detail := tagLsDetail{Tag: r.Tag}
+	mh, err := rc.ManifestHead(ctx, r, regclient.WithManifestRequireDigest())
+	if err != nil {
+		detail.Error = err.Error()
+		return detail
+	}
+	desc := mh.GetDescriptor()
+	detail.Digest = desc.Digest.String()
+	detail.MediaType = desc.MediaType
+	if !mh.IsList() {
+		detail.Platforms = 1
+		return detail
+	}
+	m, err := rc.ManifestGet(ctx, r.SetDigest(detail.Digest))
+	if err != nil {
+		detail.Error = err.Error()
+		return detail
+	}
+	mi, ok := m.(manifest.Indexer)
+	if !ok {
+		return detail
+	}
+	dl, err := mi.GetManifestList()
+	if err != nil {
+		detail.Error = err.Error()
+		return detail
+	}
+	for _, d := range dl {
+		// skip attestations and other entries without a platform
+		if d.Platform != nil && d.Platform.OS != "unknown" {
+			detail.Platforms++
+		}
+	}
+	return detail
+}
+
+func (tagOpts *tagCmd) runTagPrune(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	if tagOpts.keepLast <= 0 && tagOpts.keepNewer <= 0 && !tagOpts.keepSemver {
+		return fmt.Errorf("one of --keep-last, --keep-newer, or --keep-semver is required%.0w", ErrMissingInput)
+	}
+	tr := regclient.TagRetention{
+		KeepLast:   tagOpts.keepLast,
+		KeepNewer:  tagOpts.keepNewer,
+		KeepSemver: tagOpts.keepSemver,
+	}
+	if tagOpts.match != "" {
+		tr.Match, err = regexp.Compile("^(?:" + tagOpts.match + ")$")
+		if err != nil {
+			return fmt.Errorf("failed to parse regexp \"%s\": %w", tagOpts.match, err)
+		}
+	}
+	opts := []regclient.TagPruneOpts{}
+	if tagOpts.dryRun {
+		opts = append(opts, regclient.TagPruneWithDryRun())
+	}
+	if tagOpts.replace {
+		opts = append(opts, regclient.TagPruneWithManifestReplace())
+	}
+	rc := tagOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	log.WithFields(logrus.Fields{
+		"host":       r.Registry,
+		"repository": r.Repository,
+		"dry-run":    tagOpts.dryRun,
+	}).Debug("Prune tags")
+	entries, err := rc.TagPrune(ctx, r, tr, opts...)
+	if err != nil {
+		return err
+	}
+	if ok, err := tagOpts.rootOpts.writeStructured(cmd, entries); ok {
+		return err
+	}
+	if tagOpts.formatPrune != "" {
+		return tagOpts.rootOpts.writeOutput(cmd, tagOpts.formatPrune, entries)
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TAG\tACTION\tREASON\tCREATED\tDIGEST\n")
+	for _, e := range entries {
+		action := "keep"
+		if !e.Keep {
+			action = "prune"
+		}
+		created := ""
+		if !e.Created.IsZero() {
+			created = e.Created.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Tag, action, e.Reason, created, e.Digest.String())
+	}
+	return w.Flush()
+}
+
+func (tagOpts *tagCmd) runTagRename(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	rSrc, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	rTgt, err := ref.New(args[1])
+	if err != nil {
+		return err
+	}
+	opts := []regclient.RenameOpts{}
+	if tagOpts.digestTags {
+		opts = append(opts, regclient.RenameWithDigestTags())
+	}
+	if tagOpts.dryRun {
+		opts = append(opts, regclient.RenameWithDryRun())
+	}
+	if tagOpts.referrers {
+		opts = append(opts, regclient.RenameWithReferrers())
+	}
+	if tagOpts.replace {
+		opts = append(opts, regclient.RenameWithManifestReplace())
+	}
+	rc := tagOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, rSrc)
+	defer rc.Close(ctx, rTgt)
+	log.WithFields(logrus.Fields{
+		"source": rSrc.CommonName(),
+		"target": rTgt.CommonName(),
+	}).Debug("Rename tag")
+	e, err := rc.TagRename(ctx, rSrc, rTgt, opts...)
+	if err != nil {
+		return err
+	}
+	if tagOpts.dryRun {
+		if ok, err := tagOpts.rootOpts.writeStructured(cmd, e); ok {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s (%s)\n", e.Source.CommonName(), e.Target.CommonName(), e.Digest.String())
+	}
+	return nil
+}
+
+func (tagOpts *tagCmd) runTagRollback(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	rc := tagOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	log.WithFields(logrus.Fields{
+		"host":       r.Registry,
+		"repository": r.Repository,
+		"tag":        r.Tag,
+	}).Debug("Rollback tag")
+	m, err := rc.TagRollback(ctx, r)
+	if err != nil {
+		return err
+	}
+	result := struct {
+		Manifest manifest.Manifest `json:"manifest"`
+	}{
+		Manifest: m,
+	}
+	return tagOpts.rootOpts.writeOutput(cmd, tagOpts.formatRb, result)
+}
+
 func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	r, err := ref.New(args[0])
@@ -155,6 +610,28 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 		}
 		tl.Tags = filtered
 	}
+	if tagOpts.details {
+		details := tagLsDetails(ctx, rc, r, tl.Tags, tagOpts.concurrency)
+		if ok, err := tagOpts.rootOpts.writeStructured(cmd, details); ok {
+			return err
+		}
+		if !flagChanged(cmd, "format") || tagOpts.format == "table" {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "TAG\tDIGEST\tMEDIA TYPE\tPLATFORMS\n")
+			for _, d := range details {
+				if d.Error != "" {
+					fmt.Fprintf(w, "%s\terror: %s\t\t\n", d.Tag, d.Error)
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", d.Tag, d.Digest, d.MediaType, d.Platforms)
+			}
+			return w.Flush()
+		}
+		return tagOpts.rootOpts.writeOutput(cmd, tagOpts.format, details)
+	}
+	if ok, err := tagOpts.rootOpts.writeStructured(cmd, tl.Tags); ok {
+		return err
+	}
 	switch tagOpts.format {
 	case "raw":
 		tagOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}{{printf \"\\n%s\" .RawBody}}"
@@ -163,5 +640,87 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 	case "rawHeaders", "raw-headers", "headers":
 		tagOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}"
 	}
-	return template.Writer(cmd.OutOrStdout(), tagOpts.format, tl)
+	return tagOpts.rootOpts.writeOutput(cmd, tagOpts.format, tl)
+}
+
+// tagWatchSink receives each event from "tag watch".
+type tagWatchSink func(ctx context.Context, e regclient.TagDigestEvent) error
+
+func (tagOpts *tagCmd) runTagWatch(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	sinks := []tagWatchSink{
+		func(ctx context.Context, e regclient.TagDigestEvent) error {
+			return tagOpts.rootOpts.writeOutput(cmd, tagOpts.formatWatch, e)
+		},
+	}
+	for _, url := range tagOpts.webhooks {
+		sinks = append(sinks, tagWatchWebhook(url))
+	}
+	opts := []regclient.TagWatchOpts{
+		regclient.TagWatchWithInterval(tagOpts.interval),
+	}
+	if tagOpts.count > 0 {
+		opts = append(opts, regclient.TagWatchWithCount(tagOpts.count))
+	}
+	rc := tagOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	log.WithFields(logrus.Fields{
+		"host":       r.Registry,
+		"repository": r.Repository,
+		"tag":        r.Tag,
+		"interval":   tagOpts.interval.String(),
+	}).Debug("Watch tag")
+	err = rc.TagDigestWatch(ctx, r, func(e regclient.TagDigestEvent) error {
+		for _, sink := range sinks {
+			err := sink(ctx, e)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}, opts...)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// tagWatchWebhook returns a sink that posts each event to the url.
+// A failed request is logged without stopping the watch.
+func tagWatchWebhook(url string) tagWatchSink {
+	client := &http.Client{Timeout: time.Minute}
+	return func(ctx context.Context, e regclient.TagDigestEvent) error {
+		body, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", UserAgent)
+		resp, err := client.Do(req)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"url": url,
+				"err": err,
+			}).Warn("Failed to post tag event")
+			return nil
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			log.WithFields(logrus.Fields{
+				"url":    url,
+				"status": resp.StatusCode,
+				"body":   strings.TrimSpace(string(msg)),
+			}).Warn("Tag event rejected by webhook")
+		}
+		return nil
+	}
 }
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/types"
 )
 
@@ -57,6 +63,17 @@ func TestTagList(t *testing.T) {
 			expectOut:   "application/vnd.oci.image.index.v1+json",
 			outContains: true,
 		},
+		{
+			name:        "List tags details",
+			args:        []string{"tag", "ls", "--details", "--include", "v.*", "ocidir://../../testdata/testrepo"},
+			expectOut:   "v1   sha256:0d4ea07d06d42ad1cb756a5d9e71f62288e426b22a230b224410164181998c4c  application/vnd.oci.image.index.v1+json  2",
+			outContains: true,
+		},
+		{
+			name:      "List tags details formatted",
+			args:      []string{"tag", "ls", "--details", "--include", "a1", "--format", "{{range .}}{{.Tag}} {{.Platforms}}{{end}}", "ocidir://../../testdata/testrepo"},
+			expectOut: "a1 1",
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -79,3 +96,146 @@ func TestTagList(t *testing.T) {
 		})
 	}
 }
+
+func TestTagExportImport(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	tgtRepo := fmt.Sprintf("ocidir://%s/repo", tmpDir)
+	exportFile := filepath.Join(tmpDir, "tags.json")
+
+	_, err := cobraTest(t, nil, "image", "copy", srcRef, tgtRepo+":v2")
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", srcRef, tgtRepo+":v2-alias")
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	_, err = cobraTest(t, nil, "tag", "export", tgtRepo, exportFile)
+	if err != nil {
+		t.Fatalf("failed to export tags: %v", err)
+	}
+	_, err = cobraTest(t, nil, "tag", "rm", tgtRepo+":v2-alias")
+	if err != nil {
+		t.Fatalf("failed to delete tag: %v", err)
+	}
+	_, err = cobraTest(t, nil, "tag", "import", tgtRepo, exportFile)
+	if err != nil {
+		t.Fatalf("failed to import tags: %v", err)
+	}
+	out, err := cobraTest(t, nil, "tag", "ls", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "v2\nv2-alias" {
+		t.Errorf("unexpected tags after import: %s", out)
+	}
+}
+
+func TestTagRename(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	tgtRepo := fmt.Sprintf("ocidir://%s/repo", tmpDir)
+	_, err := cobraTest(t, nil, "image", "copy", srcRef, tgtRepo+":v2")
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	out, err := cobraTest(t, nil, "tag", "rename", "--dry-run", tgtRepo+":v2", tgtRepo+":v2-old")
+	if err != nil {
+		t.Fatalf("failed to run dry-run rename: %v", err)
+	}
+	if !strings.HasPrefix(out, tgtRepo+":v2 -> "+tgtRepo+":v2-old") {
+		t.Errorf("unexpected dry-run output: %s", out)
+	}
+	_, err = cobraTest(t, nil, "tag", "rename", tgtRepo+":v2", tgtRepo+":v2-old")
+	if err != nil {
+		t.Fatalf("failed to rename tag: %v", err)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "v2-old" {
+		t.Errorf("unexpected tags after rename: %s", out)
+	}
+}
+
+func TestTagWatch(t *testing.T) {
+	srcRef := "ocidir://../../testdata/testrepo:v2"
+	var mu sync.Mutex
+	received := []regclient.TagDigestEvent{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := regclient.TagDigestEvent{}
+		err := json.NewDecoder(r.Body).Decode(&e)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, e)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(ts.Close)
+
+	digOut, err := cobraTest(t, nil, "image", "digest", srcRef)
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	out, err := cobraTest(t, nil, "tag", "watch", "--count", "1", "--format", "{{.Digest}}", "--webhook", ts.URL, srcRef)
+	if err != nil {
+		t.Fatalf("failed to watch tag: %v", err)
+	}
+	if out != digOut {
+		t.Errorf("unexpected output, expected %s, received %s", digOut, out)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 || received[0].Digest.String() != digOut || received[0].Ref != "ocidir://../../testdata/testrepo:v2" {
+		t.Errorf("unexpected webhook events: %v", received)
+	}
+	_, err = cobraTest(t, nil, "tag", "watch", "--count", "1", srcRef+"@"+digOut)
+	if !errors.Is(err, types.ErrMissingTag) {
+		t.Errorf("unexpected error for a digest, expected %v, received %v", types.ErrMissingTag, err)
+	}
+}
+
+func TestTagPrune(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcRepo := "ocidir://../../testdata/testrepo"
+	tgtRepo := fmt.Sprintf("ocidir://%s/repo", tmpDir)
+	for src, tgt := range map[string]string{"v1": "1.0.0", "v2": "1.1.0-rc1", "v3": "dev", "b1": "keep"} {
+		_, err := cobraTest(t, nil, "image", "copy", srcRepo+":"+src, tgtRepo+":"+tgt)
+		if err != nil {
+			t.Fatalf("failed to copy image: %v", err)
+		}
+	}
+
+	_, err := cobraTest(t, nil, "tag", "prune", tgtRepo)
+	if !errors.Is(err, ErrMissingInput) {
+		t.Errorf("unexpected error without a keep flag, expected %v, received %v", ErrMissingInput, err)
+	}
+	_, err = cobraTest(t, nil, "tag", "prune", "--keep-semver", "--match", "(", tgtRepo)
+	if err == nil {
+		t.Errorf("invalid regexp did not fail")
+	}
+	out, err := cobraTest(t, nil, "tag", "prune", "--keep-semver", "--match", "[0-9].*|dev", "--dry-run", "--format", "{{range .}}{{.Tag}}={{.Reason}} {{end}}", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to run dry run: %v", err)
+	}
+	expect := "1.0.0=semver release 1.1.0-rc1=pruned dev=pruned keep=not matched"
+	if out != expect {
+		t.Errorf("unexpected dry run output, expected %s, received %s", expect, out)
+	}
+	_, err = cobraTest(t, nil, "tag", "prune", "--keep-semver", "--match", "[0-9].*|dev", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to prune tags: %v", err)
+	}
+	out, err = cobraTest(t, nil, "tag", "ls", tgtRepo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if out != "1.0.0\nkeep" {
+		t.Errorf("unexpected tags after prune: %s", out)
+	}
+}
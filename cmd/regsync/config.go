@@ -43,10 +43,14 @@ type ConfigDefaults struct {
 	FastCheck       *bool                  `yaml:"fastCheck" json:"fastCheck"`
 	ForceRecursive  *bool                  `yaml:"forceRecursive" json:"forceRecursive"`
 	IncludeExternal *bool                  `yaml:"includeExternal" json:"includeExternal"`
+	AnnotateOrigin  *bool                  `yaml:"annotateOrigin" json:"annotateOrigin"`
 	MediaTypes      []string               `yaml:"mediaTypes" json:"mediaTypes"`
+	PolicyWebhook   string                 `yaml:"policyWebhook" json:"policyWebhook"`
+	Retain          *ConfigRetain          `yaml:"retain" json:"retain"`
 	Hooks           ConfigHooks            `yaml:"hooks" json:"hooks"`
 	// general options
 	BlobLimit      int64         `yaml:"blobLimit" json:"blobLimit"`
+	Checkpoint     string        `yaml:"checkpoint" json:"checkpoint"`
 	CacheCount     int           `yaml:"cacheCount" json:"cacheCount"`
 	CacheTime      time.Duration `yaml:"cacheTime" json:"cacheTime"`
 	SkipDockerConf bool          `yaml:"skipDockerConfig" json:"skipDockerConfig"`
@@ -74,11 +78,14 @@ type ConfigSync struct {
 	FastCheck       *bool                  `yaml:"fastCheck" json:"fastCheck"`
 	ForceRecursive  *bool                  `yaml:"forceRecursive" json:"forceRecursive"`
 	IncludeExternal *bool                  `yaml:"includeExternal" json:"includeExternal"`
+	AnnotateOrigin  *bool                  `yaml:"annotateOrigin" json:"annotateOrigin"`
 	Backup          string                 `yaml:"backup" json:"backup"`
 	Interval        time.Duration          `yaml:"interval" json:"interval"`
 	Schedule        string                 `yaml:"schedule" json:"schedule"`
 	RateLimit       ConfigRateLimit        `yaml:"ratelimit" json:"ratelimit"`
 	MediaTypes      []string               `yaml:"mediaTypes" json:"mediaTypes"`
+	PolicyWebhook   string                 `yaml:"policyWebhook" json:"policyWebhook"`
+	Retain          *ConfigRetain          `yaml:"retain" json:"retain"`
 	Hooks           ConfigHooks            `yaml:"hooks" json:"hooks"`
 }
 
@@ -106,6 +113,15 @@ type ConfigHook struct {
 	Params []string `yaml:"params" json:"params"`
 }
 
+// ConfigRetain is a tag retention policy for repository and registry syncs
+type ConfigRetain struct {
+	Match           string        `yaml:"match" json:"match"`
+	KeepLast        int           `yaml:"keepLast" json:"keepLast"`
+	KeepNewer       time.Duration `yaml:"keepNewer" json:"keepNewer"`
+	KeepSemver      bool          `yaml:"keepSemver" json:"keepSemver"`
+	ManifestReplace bool          `yaml:"manifestReplace" json:"manifestReplace"`
+}
+
 // ConfigNew creates an empty configuration
 func ConfigNew() *Config {
 	c := Config{
@@ -267,6 +283,16 @@ func syncSetDefaults(s *ConfigSync, d ConfigDefaults) {
 		b := (d.IncludeExternal != nil && *d.IncludeExternal)
 		s.IncludeExternal = &b
 	}
+	if s.AnnotateOrigin == nil {
+		b := (d.AnnotateOrigin != nil && *d.AnnotateOrigin)
+		s.AnnotateOrigin = &b
+	}
+	if s.PolicyWebhook == "" && d.PolicyWebhook != "" {
+		s.PolicyWebhook = d.PolicyWebhook
+	}
+	if s.Retain == nil && d.Retain != nil {
+		s.Retain = d.Retain
+	}
 	if s.Hooks.Pre == nil && d.Hooks.Pre != nil {
 		s.Hooks.Pre = d.Hooks.Pre
 	}
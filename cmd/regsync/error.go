@@ -13,6 +13,8 @@ var (
 	ErrNotImplemented = errors.New("not implemented")
 	// ErrNotFound when anything else isn't found
 	ErrNotFound = errors.New("not found")
+	// ErrPolicyDenied when the policy webhook rejects an image
+	ErrPolicyDenied = errors.New("denied by policy")
 	// ErrUnsupportedConfigVersion happens when config file version is greater than this command supports
 	ErrUnsupportedConfigVersion = errors.New("unsupported config version")
 )
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	// policyWebhookTimeout limits the time waiting for a response from the policy webhook
+	policyWebhookTimeout = time.Minute * 5
+	// policyWebhookRespLimit is the size of the response body included in the error
+	policyWebhookRespLimit = 4096
+)
+
+// policyWebhookReq is the body sent to the policy webhook
+type policyWebhookReq struct {
+	Source     string           `json:"source"`
+	Target     string           `json:"target"`
+	Descriptor types.Descriptor `json:"descriptor"`
+	Manifest   json.RawMessage  `json:"manifest"`
+}
+
+// policyWebhook returns a hook that posts each manifest to the url, blocking the copy on a non-2xx response
+func policyWebhook(url string) regclient.ImageHook {
+	client := &http.Client{Timeout: policyWebhookTimeout}
+	return func(ctx context.Context, refSrc, refTgt ref.Ref, m manifest.Manifest) error {
+		raw, err := m.RawBody()
+		if err != nil {
+			return err
+		}
+		body, err := json.Marshal(policyWebhookReq{
+			Source:     refSrc.CommonName(),
+			Target:     refTgt.CommonName(),
+			Descriptor: m.GetDescriptor(),
+			Manifest:   raw,
+		})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", UserAgent)
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("policy webhook request failed: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, policyWebhookRespLimit))
+			return fmt.Errorf("policy webhook rejected %s: %s [http %d]%.0w", refSrc.CommonName(), strings.TrimSpace(string(msg)), resp.StatusCode, ErrPolicyDenied)
+		}
+		log.WithFields(logrus.Fields{
+			"source": refSrc.CommonName(),
+			"digest": m.GetDescriptor().Digest.String(),
+		}).Debug("Policy webhook allowed manifest")
+		return nil
+	}
+}
@@ -3,9 +3,14 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/regclient/regclient"
@@ -784,6 +789,331 @@ func TestProcessRef(t *testing.T) {
 	}
 }
 
+func TestProcessAnnotate(t *testing.T) {
+	ctx := context.Background()
+	boolTrue := true
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "../../testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc = regclient.New(regclient.WithFS(fsMem))
+	throttleC = throttle.New(1)
+	cs := ConfigSync{
+		Source:         "ocidir://testrepo",
+		Target:         "ocidir://testannotate",
+		Type:           "repository",
+		AnnotateOrigin: &boolTrue,
+	}
+	syncSetDefaults(&cs, conf.Defaults)
+	rootOpts := rootCmd{}
+	tgt, err := ref.New("ocidir://testannotate:latest")
+	if err != nil {
+		t.Fatalf("failed to parse target: %v", err)
+	}
+	// getAnnotated returns the target digest and verifies the origin annotations
+	getAnnotated := func(t *testing.T, src string) string {
+		t.Helper()
+		rSrc, err := ref.New(src)
+		if err != nil {
+			t.Fatalf("failed to parse source: %v", err)
+		}
+		mSrc, err := rc.ManifestHead(ctx, rSrc, regclient.WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to get source: %v", err)
+		}
+		mTgt, err := rc.ManifestGet(ctx, tgt)
+		if err != nil {
+			t.Fatalf("failed to get target: %v", err)
+		}
+		if mTgt.GetDescriptor().Digest == mSrc.GetDescriptor().Digest {
+			t.Errorf("target was not annotated")
+		}
+		annotations, err := mTgt.(manifest.Annotator).GetAnnotations()
+		if err != nil {
+			t.Fatalf("failed to get annotations: %v", err)
+		}
+		if annotations[types.AnnotationSyncSource] != rSrc.CommonName() {
+			t.Errorf("unexpected source annotation, expected %s, received %s", rSrc.CommonName(), annotations[types.AnnotationSyncSource])
+		}
+		if annotations[types.AnnotationSyncSourceDigest] != mSrc.GetDescriptor().Digest.String() {
+			t.Errorf("unexpected source digest annotation, expected %s, received %s", mSrc.GetDescriptor().Digest.String(), annotations[types.AnnotationSyncSourceDigest])
+		}
+		if annotations[types.AnnotationSyncTime] == "" {
+			t.Errorf("sync time annotation missing")
+		}
+		return mTgt.GetDescriptor().Digest.String()
+	}
+
+	srcV1, _ := ref.New("ocidir://testrepo:v1")
+	srcV2, _ := ref.New("ocidir://testrepo:v2")
+	err = rootOpts.processRef(ctx, cs, srcV1, tgt, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to sync v1: %v", err)
+	}
+	dig1 := getAnnotated(t, "ocidir://testrepo:v1")
+	// an unchanged source does not modify the annotated target
+	err = rootOpts.processRef(ctx, cs, srcV1, tgt, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to resync v1: %v", err)
+	}
+	if dig := getAnnotated(t, "ocidir://testrepo:v1"); dig != dig1 {
+		t.Errorf("annotated target changed on an unchanged source, expected %s, received %s", dig1, dig)
+	}
+	// a changed source is copied and annotated
+	err = rootOpts.processRef(ctx, cs, srcV2, tgt, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to sync v2: %v", err)
+	}
+	if dig := getAnnotated(t, "ocidir://testrepo:v2"); dig == dig1 {
+		t.Errorf("annotated target did not change after the source changed")
+	}
+}
+
+func TestProcessPlatforms(t *testing.T) {
+	ctx := context.Background()
+	boolTrue := true
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "../../testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc = regclient.New(regclient.WithFS(fsMem))
+	throttleC = throttle.New(1)
+	src, _ := ref.New("ocidir://testrepo:v1")
+	mSrc, err := rc.ManifestHead(ctx, src, regclient.WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	tt := []struct {
+		name     string
+		tgt      string
+		annotate bool
+	}{
+		{
+			name: "digest",
+			tgt:  "ocidir://testplatforms:latest",
+		},
+		{
+			name:     "annotate",
+			tgt:      "ocidir://testplatformsannotate:latest",
+			annotate: true,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cs := ConfigSync{
+				Source:    src.CommonName(),
+				Target:    tc.tgt,
+				Type:      "image",
+				Platforms: []string{"linux/amd64"},
+				Backup:    "old",
+			}
+			if tc.annotate {
+				cs.AnnotateOrigin = &boolTrue
+			}
+			syncSetDefaults(&cs, ConfigDefaults{})
+			rootOpts := rootCmd{}
+			tgt, _ := ref.New(tc.tgt)
+			err := rootOpts.processRef(ctx, cs, src, tgt, actionCopy)
+			if err != nil {
+				t.Fatalf("failed to sync: %v", err)
+			}
+			mTgt, err := rc.ManifestHead(ctx, tgt, regclient.WithManifestRequireDigest())
+			if err != nil {
+				t.Fatalf("failed to get target: %v", err)
+			}
+			if mTgt.GetDescriptor().Digest == mSrc.GetDescriptor().Digest {
+				t.Errorf("target was not filtered to the platforms")
+			}
+			// an unchanged source is not copied again or backed up
+			err = rootOpts.processRef(ctx, cs, src, tgt, actionCopy)
+			if err != nil {
+				t.Fatalf("failed to resync: %v", err)
+			}
+			mTgtResync, err := rc.ManifestHead(ctx, tgt, regclient.WithManifestRequireDigest())
+			if err != nil {
+				t.Fatalf("failed to get target: %v", err)
+			}
+			if mTgtResync.GetDescriptor().Digest != mTgt.GetDescriptor().Digest {
+				t.Errorf("target changed on an unchanged source, expected %s, received %s", mTgt.GetDescriptor().Digest, mTgtResync.GetDescriptor().Digest)
+			}
+			_, err = rc.ManifestHead(ctx, tgt.SetTag("old"))
+			if err == nil {
+				t.Errorf("backup created for an unchanged source")
+			}
+		})
+	}
+}
+
+func TestProcessPolicyWebhook(t *testing.T) {
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "../../testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc = regclient.New(regclient.WithFS(fsMem))
+	throttleC = throttle.New(1)
+	var mu sync.Mutex
+	reqs := []policyWebhookReq{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req policyWebhookReq
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		reqs = append(reqs, req)
+		mu.Unlock()
+		if strings.Contains(req.Target, "deny") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("critical vulnerability found"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	rootOpts := rootCmd{}
+	src, err := ref.New("ocidir://testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse source: %v", err)
+	}
+
+	t.Run("allow", func(t *testing.T) {
+		cs := ConfigSync{
+			Source:        "ocidir://testrepo:v1",
+			Target:        "ocidir://testpolicy:allow",
+			Type:          "image",
+			PolicyWebhook: ts.URL,
+		}
+		syncSetDefaults(&cs, ConfigDefaults{})
+		tgt, err := ref.New(cs.Target)
+		if err != nil {
+			t.Fatalf("failed to parse target: %v", err)
+		}
+		err = rootOpts.processRef(ctx, cs, src, tgt, actionCopy)
+		if err != nil {
+			t.Fatalf("failed to sync: %v", err)
+		}
+		mSrc, err := rc.ManifestHead(ctx, src, regclient.WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head source: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(reqs) == 0 {
+			t.Fatalf("policy webhook was not called")
+		}
+		if reqs[0].Descriptor.Digest != mSrc.GetDescriptor().Digest || len(reqs[0].Manifest) == 0 {
+			t.Errorf("unexpected webhook request: %v", reqs[0])
+		}
+	})
+	t.Run("deny", func(t *testing.T) {
+		cs := ConfigSync{
+			Source:        "ocidir://testrepo:v1",
+			Target:        "ocidir://testpolicy:deny",
+			Type:          "image",
+			PolicyWebhook: ts.URL,
+		}
+		syncSetDefaults(&cs, ConfigDefaults{})
+		tgt, err := ref.New(cs.Target)
+		if err != nil {
+			t.Fatalf("failed to parse target: %v", err)
+		}
+		err = rootOpts.processRef(ctx, cs, src, tgt, actionCopy)
+		if !errors.Is(err, ErrPolicyDenied) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err = rc.ManifestHead(ctx, tgt)
+		if err == nil {
+			t.Errorf("denied image was copied")
+		}
+	})
+}
+
+func TestProcessRetain(t *testing.T) {
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "../../testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc = regclient.New(regclient.WithFS(fsMem))
+	throttleC = throttle.New(1)
+	copies := map[string]string{
+		"ocidir://testrepo:v1": "ocidir://testretainsrc:1.0.0",
+		"ocidir://testrepo:v2": "ocidir://testretainsrc:1.1.0-rc1",
+		"ocidir://testrepo:v3": "ocidir://testretainsrc:dev",
+		"ocidir://testrepo:b1": "ocidir://testretain:0.9.0-rc1",
+	}
+	for src, tgt := range copies {
+		rSrc, _ := ref.New(src)
+		rTgt, _ := ref.New(tgt)
+		err = rc.ImageCopy(ctx, rSrc, rTgt)
+		if err != nil {
+			t.Fatalf("failed to copy %s: %v", src, err)
+		}
+	}
+	cs := ConfigSync{
+		Source: "ocidir://testretainsrc",
+		Target: "ocidir://testretain",
+		Type:   "repository",
+		Retain: &ConfigRetain{
+			Match:      "[0-9].*",
+			KeepSemver: true,
+		},
+	}
+	syncSetDefaults(&cs, ConfigDefaults{})
+	rootOpts := rootCmd{}
+	listTags := func(t *testing.T) string {
+		t.Helper()
+		r, _ := ref.New(cs.Target)
+		tl, err := rc.TagList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		tags, err := tl.GetTags()
+		if err != nil {
+			t.Fatalf("failed to get tags: %v", err)
+		}
+		return strings.Join(tags, ",")
+	}
+
+	t.Run("check", func(t *testing.T) {
+		err := rootOpts.process(ctx, cs, actionCheck)
+		if err != nil {
+			t.Fatalf("failed to check: %v", err)
+		}
+		if tags := listTags(t); tags != "0.9.0-rc1" {
+			t.Errorf("target modified by check: %s", tags)
+		}
+	})
+	t.Run("copy", func(t *testing.T) {
+		err := rootOpts.process(ctx, cs, actionCopy)
+		if err != nil {
+			t.Fatalf("failed to sync: %v", err)
+		}
+		if tags := listTags(t); tags != "1.0.0,dev" {
+			t.Errorf("unexpected target tags, expected 1.0.0,dev, received %s", tags)
+		}
+	})
+	t.Run("missing keep", func(t *testing.T) {
+		csMissing := cs
+		csMissing.Retain = &ConfigRetain{Match: ".*"}
+		err := rootOpts.process(ctx, csMissing, actionCopy)
+		if !errors.Is(err, ErrMissingInput) {
+			t.Errorf("unexpected error, expected %v, received %v", ErrMissingInput, err)
+		}
+	})
+}
+
 func TestConfigRead(t *testing.T) {
 	// CAUTION: the below yaml is space indented and will not parse with tabs
 	cRead := bytes.NewReader([]byte(`
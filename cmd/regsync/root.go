@@ -23,6 +23,8 @@ import (
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/throttle"
 	"github.com/regclient/regclient/internal/version"
+	"github.com/regclient/regclient/mod"
+	"github.com/regclient/regclient/pkg/checkpoint"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/scheme/reg"
@@ -57,10 +59,11 @@ type rootCmd struct {
 
 // TODO: remove globals, configure tests with t.Parallel
 var (
-	conf      *Config
-	log       *logrus.Logger
-	rc        *regclient.RegClient
-	throttleC *throttle.Throttle
+	checkpointF *checkpoint.File
+	conf        *Config
+	log         *logrus.Logger
+	rc          *regclient.RegClient
+	throttleC   *throttle.Throttle
 )
 
 func init() {
@@ -213,6 +216,19 @@ func (rootOpts *rootCmd) runOnce(cmd *cobra.Command, args []string) error {
 		}
 	}
 	wg.Wait()
+	if checkpointF != nil {
+		// a completed run clears the checkpoint so the next run checks all content
+		if mainErr == nil {
+			err = checkpointF.Reset()
+			if err != nil {
+				log.WithFields(logrus.Fields{
+					"checkpoint": conf.Defaults.Checkpoint,
+					"err":        err,
+				}).Warn("Failed to reset checkpoint")
+			}
+		}
+		_ = checkpointF.Close()
+	}
 	return mainErr
 }
 
@@ -395,6 +411,12 @@ func (rootOpts *rootCmd) loadConf() error {
 		rcOpts = append(rcOpts, regclient.WithConfigHost(rcHosts...))
 	}
 	rc = regclient.New(rcOpts...)
+	if conf.Defaults.Checkpoint != "" {
+		checkpointF, err = checkpoint.NewFile(conf.Defaults.Checkpoint)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -515,6 +537,40 @@ func (rootOpts *rootCmd) processRepo(ctx context.Context, s ConfigSync, src, tgt
 		}).Warn("No matching tags found")
 		return nil
 	}
+	var tr regclient.TagRetention
+	if s.Retain != nil {
+		tr, err = retainTagRetention(*s.Retain)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"source": sRepoRef.CommonName(),
+				"match":  s.Retain.Match,
+				"error":  err,
+			}).Error("Failed processing retain policy")
+			return err
+		}
+		// skip tags the policy prunes from the source, otherwise they are copied and pruned on every sync
+		entries, err := rc.TagPrune(ctx, sRepoRef, tr, regclient.TagPruneWithDryRun())
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"source": sRepoRef.CommonName(),
+				"error":  err,
+			}).Error("Failed evaluating retain policy")
+			return err
+		}
+		pruned := map[string]bool{}
+		for _, e := range entries {
+			if !e.Keep {
+				pruned[e.Tag] = true
+			}
+		}
+		retained := make([]string, 0, len(sTagList))
+		for _, tag := range sTagList {
+			if !pruned[tag] {
+				retained = append(retained, tag)
+			}
+		}
+		sTagList = retained
+	}
 	// if only copying missing entries, delete tags that already exist on target
 	if action == actionMissing {
 		tRepoRef, err := ref.New(tgt)
@@ -571,9 +627,58 @@ func (rootOpts *rootCmd) processRepo(ctx context.Context, s ConfigSync, src, tgt
 			retErr = err
 		}
 	}
+	if s.Retain != nil {
+		if err := rootOpts.processRetain(ctx, tgt, tr, s.Retain.ManifestReplace, action); err != nil {
+			retErr = err
+		}
+	}
 	return retErr
 }
 
+// processRetain prunes the target repository with the retain policy.
+func (rootOpts *rootCmd) processRetain(ctx context.Context, tgt string, tr regclient.TagRetention, manifestReplace bool, action actionType) error {
+	tRepoRef, err := ref.New(tgt)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"target": tgt,
+			"error":  err,
+		}).Error("Failed parsing target")
+		return err
+	}
+	opts := []regclient.TagPruneOpts{}
+	if action == actionCheck {
+		opts = append(opts, regclient.TagPruneWithDryRun())
+	}
+	if manifestReplace {
+		opts = append(opts, regclient.TagPruneWithManifestReplace())
+	}
+	entries, err := rc.TagPrune(ctx, tRepoRef, tr, opts...)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"target": tRepoRef.CommonName(),
+			"error":  err,
+		}).Error("Failed pruning target tags")
+		return err
+	}
+	for _, e := range entries {
+		if e.Keep {
+			continue
+		}
+		fields := logrus.Fields{
+			"target":  tRepoRef.CommonName(),
+			"tag":     e.Tag,
+			"digest":  e.Digest.String(),
+			"created": e.Created,
+		}
+		if action == actionCheck {
+			log.WithFields(fields).Info("Tag prune needed")
+		} else {
+			log.WithFields(fields).Info("Tag pruned")
+		}
+	}
+	return nil
+}
+
 func (rootOpts *rootCmd) processImage(ctx context.Context, s ConfigSync, src, tgt string, action actionType) error {
 	sRef, err := ref.New(src)
 	if err != nil {
@@ -625,12 +730,22 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 	forceRecursive := (s.ForceRecursive != nil && *s.ForceRecursive)
 	referrers := (s.Referrers != nil && *s.Referrers)
 	digestTags := (s.DigestTags != nil && *s.DigestTags)
+	annotateOrigin := (s.AnnotateOrigin != nil && *s.AnnotateOrigin)
+	srcName := src.CommonName()
 	mTgt, err := rc.ManifestHead(ctx, tgt, regclient.WithManifestRequireDigest())
 	tgtExists := (err == nil)
 	tgtMatches := false
 	if err == nil && manifest.GetDigest(mSrc).String() == manifest.GetDigest(mTgt).String() {
 		tgtMatches = true
 	}
+	// an annotated target has a different digest, compare the source digest from the annotation
+	tgtOrigin := ""
+	if tgtExists && !tgtMatches && annotateOrigin {
+		tgtOrigin = getOriginDigest(ctx, tgt)
+		if tgtOrigin == manifest.GetDigest(mSrc).String() {
+			tgtMatches = true
+		}
+	}
 	if tgtMatches && (fastCheck || (!forceRecursive && !referrers && !digestTags)) {
 		log.WithFields(logrus.Fields{
 			"source": src.CommonName(),
@@ -671,7 +786,7 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 			return err
 		}
 		src.Digest = platDigest.String()
-		if tgtExists && platDigest.String() == manifest.GetDigest(mTgt).String() {
+		if tgtExists && (platDigest.String() == manifest.GetDigest(mTgt).String() || platDigest.String() == tgtOrigin) {
 			tgtMatches = true
 		}
 		if tgtMatches && (s.ForceRecursive == nil || !*s.ForceRecursive) {
@@ -683,6 +798,31 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 			return nil
 		}
 	}
+	// a list filtered to platforms is pushed with a new digest, compare the target to the filtered digest
+	platformsDigest := ""
+	if mSrc.IsList() && s.Platform == "" && len(s.Platforms) > 0 {
+		pDig, err := rc.ImagePlatformsDigest(ctx, src, s.Platforms)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"source":    src.CommonName(),
+				"platforms": s.Platforms,
+				"error":     err,
+			}).Error("Failed to resolve platforms")
+			return err
+		}
+		platformsDigest = pDig.String()
+		if tgtExists && (platformsDigest == manifest.GetDigest(mTgt).String() || platformsDigest == tgtOrigin) {
+			tgtMatches = true
+		}
+		if tgtMatches && (fastCheck || (!forceRecursive && !referrers && !digestTags)) {
+			log.WithFields(logrus.Fields{
+				"source":    src.CommonName(),
+				"platforms": s.Platforms,
+				"target":    tgt.CommonName(),
+			}).Debug("Image matches for platforms")
+			return nil
+		}
+	}
 	if tgtMatches {
 		log.WithFields(logrus.Fields{
 			"source":     src.CommonName(),
@@ -697,7 +837,76 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 			"target": tgt.CommonName(),
 		}).Info("Image sync needed")
 	}
+
+	opts := []regclient.ImageOpts{}
+	if s.DigestTags != nil && *s.DigestTags {
+		opts = append(opts, regclient.ImageWithDigestTags())
+	}
+	if s.Referrers != nil && *s.Referrers {
+		if s.ReferrerFilters == nil || len(s.ReferrerFilters) == 0 {
+			opts = append(opts, regclient.ImageWithReferrers())
+		} else {
+			for _, filter := range s.ReferrerFilters {
+				rOpts := []scheme.ReferrerOpts{}
+				if filter.ArtifactType != "" {
+					rOpts = append(rOpts, scheme.WithReferrerMatchOpt(types.MatchOpt{ArtifactType: filter.ArtifactType}))
+				}
+				if filter.Annotations != nil {
+					rOpts = append(rOpts, scheme.WithReferrerMatchOpt(types.MatchOpt{Annotations: filter.Annotations}))
+				}
+				opts = append(opts, regclient.ImageWithReferrers(rOpts...))
+			}
+		}
+	}
+	if s.FastCheck != nil && *s.FastCheck {
+		opts = append(opts, regclient.ImageWithFastCheck())
+	}
+	if s.ForceRecursive != nil && *s.ForceRecursive {
+		opts = append(opts, regclient.ImageWithForceRecursive())
+	}
+	if s.IncludeExternal != nil && *s.IncludeExternal {
+		opts = append(opts, regclient.ImageWithIncludeExternal())
+	}
+	if len(s.Platforms) > 0 {
+		opts = append(opts, regclient.ImageWithPlatforms(s.Platforms))
+	}
+	if s.PolicyWebhook != "" {
+		opts = append(opts, regclient.ImageWithPreCopyHook(policyWebhook(s.PolicyWebhook)))
+	}
+	if checkpointF != nil {
+		opts = append(opts, regclient.ImageWithCheckpoint(checkpointF))
+	}
+
+	// when annotating, copy by digest and push the annotated manifest to the tag
+	tgtCopy := tgt
+	srcDigest := src.Digest
+	if annotateOrigin {
+		if srcDigest == "" && platformsDigest != "" {
+			srcDigest = platformsDigest
+		} else if srcDigest == "" {
+			srcDigest = manifest.GetDigest(mSrc).String()
+		}
+		tgtCopy = tgt.SetDigest(srcDigest)
+	}
+
+	// report the content that would be copied without pushing anything
 	if action == actionCheck {
+		rpt, err := rc.ImageCopyReport(ctx, src, tgtCopy, append(opts, regclient.ImageWithDryRun())...)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"source": src.CommonName(),
+				"target": tgt.CommonName(),
+				"error":  err,
+			}).Error("Failed to check image")
+			return err
+		}
+		log.WithFields(logrus.Fields{
+			"source":    src.CommonName(),
+			"target":    tgt.CommonName(),
+			"manifests": len(rpt.Manifests),
+			"blobs":     len(rpt.Blobs),
+			"size":      rpt.Size,
+		}).Info("Image sync report")
 		return nil
 	}
 
@@ -820,45 +1029,12 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 		}
 	}
 
-	opts := []regclient.ImageOpts{}
-	if s.DigestTags != nil && *s.DigestTags {
-		opts = append(opts, regclient.ImageWithDigestTags())
-	}
-	if s.Referrers != nil && *s.Referrers {
-		if s.ReferrerFilters == nil || len(s.ReferrerFilters) == 0 {
-			opts = append(opts, regclient.ImageWithReferrers())
-		} else {
-			for _, filter := range s.ReferrerFilters {
-				rOpts := []scheme.ReferrerOpts{}
-				if filter.ArtifactType != "" {
-					rOpts = append(rOpts, scheme.WithReferrerMatchOpt(types.MatchOpt{ArtifactType: filter.ArtifactType}))
-				}
-				if filter.Annotations != nil {
-					rOpts = append(rOpts, scheme.WithReferrerMatchOpt(types.MatchOpt{Annotations: filter.Annotations}))
-				}
-				opts = append(opts, regclient.ImageWithReferrers(rOpts...))
-			}
-		}
-	}
-	if s.FastCheck != nil && *s.FastCheck {
-		opts = append(opts, regclient.ImageWithFastCheck())
-	}
-	if s.ForceRecursive != nil && *s.ForceRecursive {
-		opts = append(opts, regclient.ImageWithForceRecursive())
-	}
-	if s.IncludeExternal != nil && *s.IncludeExternal {
-		opts = append(opts, regclient.ImageWithIncludeExternal())
-	}
-	if len(s.Platforms) > 0 {
-		opts = append(opts, regclient.ImageWithPlatforms(s.Platforms))
-	}
-
 	// Copy the image
 	log.WithFields(logrus.Fields{
 		"source": src.CommonName(),
 		"target": tgt.CommonName(),
 	}).Debug("Image sync running")
-	err = rc.ImageCopy(ctx, src, tgt, opts...)
+	rpt, err := rc.ImageCopyReport(ctx, src, tgtCopy, opts...)
 	if err != nil {
 		log.WithFields(logrus.Fields{
 			"source": src.CommonName(),
@@ -867,9 +1043,77 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 		}).Error("Failed to copy image")
 		return err
 	}
+	log.WithFields(logrus.Fields{
+		"source":        src.CommonName(),
+		"target":        tgt.CommonName(),
+		"manifests":     len(rpt.Manifests),
+		"blobs-pushed":  rpt.BlobsPushed,
+		"blobs-mounted": rpt.BlobsMounted,
+		"blobs-skipped": rpt.BlobsSkipped,
+		"bytes":         rpt.BytesTransferred,
+		"elapsed":       rpt.Elapsed,
+	}).Info("Image sync complete")
+	// an existing annotated target only needed a refresh of the referrers or digest tags
+	if annotateOrigin && !tgtMatches {
+		err = annotateImage(ctx, srcName, tgtCopy, tgt)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"source": src.CommonName(),
+				"target": tgt.CommonName(),
+				"error":  err,
+			}).Error("Failed to annotate image")
+			return err
+		}
+	}
 	return nil
 }
 
+// getOriginDigest returns the source digest annotation on the target manifest.
+func getOriginDigest(ctx context.Context, r ref.Ref) string {
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return ""
+	}
+	ma, ok := m.(manifest.Annotator)
+	if !ok {
+		return ""
+	}
+	annotations, err := ma.GetAnnotations()
+	if err != nil || annotations == nil {
+		return ""
+	}
+	return annotations[types.AnnotationSyncSourceDigest]
+}
+
+// annotateImage adds the sync origin annotations to the copied image, pushing the result to the target tag.
+func annotateImage(ctx context.Context, srcName string, rCopy, rTgt ref.Ref) error {
+	m, err := rc.ManifestGet(ctx, rCopy)
+	if err != nil {
+		return err
+	}
+	if _, ok := m.(manifest.Annotator); !ok {
+		// copy the tag without annotations
+		log.WithFields(logrus.Fields{
+			"target":    rTgt.CommonName(),
+			"mediaType": manifest.GetMediaType(m),
+		}).Warn("Media type does not support annotations, skipping annotation of origin")
+		return rc.ManifestPut(ctx, rTgt, m)
+	}
+	info := version.GetInfo()
+	ver := info.VCSTag
+	if ver == "" {
+		ver = info.VCSRef
+	}
+	_, err = mod.Apply(ctx, rc, rCopy,
+		mod.WithRefTgt(rTgt),
+		mod.WithAnnotation(types.AnnotationSyncSource, srcName),
+		mod.WithAnnotation(types.AnnotationSyncSourceDigest, rCopy.Digest),
+		mod.WithAnnotation(types.AnnotationSyncTime, time.Now().UTC().Format(time.RFC3339)),
+		mod.WithAnnotation(types.AnnotationSyncVersion, ver),
+	)
+	return err
+}
+
 func filterList(ad AllowDeny, in []string) ([]string, error) {
 	var result []string
 	// apply allow list
@@ -917,6 +1161,26 @@ func filterList(ad AllowDeny, in []string) ([]string, error) {
 	return compressed, nil
 }
 
+// retainTagRetention converts a retain policy from the config, binding the match to the beginning and ending of the tag.
+func retainTagRetention(cr ConfigRetain) (regclient.TagRetention, error) {
+	tr := regclient.TagRetention{
+		KeepLast:   cr.KeepLast,
+		KeepNewer:  cr.KeepNewer,
+		KeepSemver: cr.KeepSemver,
+	}
+	if cr.KeepLast <= 0 && cr.KeepNewer <= 0 && !cr.KeepSemver {
+		return tr, fmt.Errorf("retain requires one of keepLast, keepNewer, or keepSemver%.0w", ErrMissingInput)
+	}
+	if cr.Match != "" {
+		exp, err := regexp.Compile("^(?:" + cr.Match + ")$")
+		if err != nil {
+			return tr, err
+		}
+		tr.Match = exp
+	}
+	return tr, nil
+}
+
 var manifestCache struct {
 	mu        sync.Mutex
 	manifests map[string]manifest.Manifest
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1" //#nosec G505 required by the default PBKDF2 PRF in PKCS#8
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"os"
+)
+
+var (
+	oidPBES2        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	// pbkdf2PRF maps the PRF OID to the hash
+	pbkdf2PRF = map[string]func() hash.Hash{
+		"1.2.840.113549.2.7":  sha1.New,
+		"1.2.840.113549.2.9":  sha256.New,
+		"1.2.840.113549.2.10": sha512.New384,
+		"1.2.840.113549.2.11": sha512.New,
+	}
+	// aesCBCKeyLen maps the AES-CBC OID to the key length
+	aesCBCKeyLen = map[string]int{
+		"2.16.840.1.101.3.4.1.2":  16,
+		"2.16.840.1.101.3.4.1.22": 24,
+		"2.16.840.1.101.3.4.1.42": 32,
+	}
+)
+
+// ClientCertificate returns the certificate used for mTLS from the inline PEM or file settings.
+// A nil certificate is returned when a client certificate is not configured.
+// An encrypted key is decrypted with the passphrase from the environment variable named by ClientKeyPassEnv.
+func (host *Host) ClientCertificate() (*tls.Certificate, error) {
+	certPEM, err := pemValue(host.ClientCert, host.ClientCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+	keyPEM, err := pemValue(host.ClientKey, host.ClientKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client key: %w", err)
+	}
+	if len(certPEM) == 0 && len(keyPEM) == 0 {
+		return nil, nil
+	}
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return nil, fmt.Errorf("client certificate and key are both required for %s", host.Name)
+	}
+	keyPEM, err = pemDecryptKey(keyPEM, host.ClientKeyPassEnv)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// pemValue returns the inline value, or the content of the file.
+func pemValue(inline, filename string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	if filename == "" {
+		return nil, nil
+	}
+	//#nosec G304 file is configured by the user
+	return os.ReadFile(filename)
+}
+
+// pemDecryptKey returns an unencrypted PEM key, decrypting PKCS#8 and legacy OpenSSL encrypted keys.
+func pemDecryptKey(keyPEM []byte, passEnv string) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	//lint:ignore SA1019 legacy encrypted keys are still generated by older tools
+	legacy := block != nil && x509.IsEncryptedPEMBlock(block)
+	if block == nil || (block.Type != "ENCRYPTED PRIVATE KEY" && !legacy) {
+		return keyPEM, nil
+	}
+	pass := ""
+	if passEnv != "" {
+		pass = os.Getenv(passEnv)
+	}
+	if pass == "" {
+		return nil, fmt.Errorf("client key is encrypted, set clientKeyPassEnv to the name of a variable with the passphrase")
+	}
+	if legacy {
+		//lint:ignore SA1019 legacy encrypted keys are still generated by older tools
+		der, err := x509.DecryptPEMBlock(block, []byte(pass))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt client key: %w", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+	}
+	der, err := pkcs8Decrypt(block.Bytes, []byte(pass))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt client key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+type pkcs8Algorithm struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type pkcs8Encrypted struct {
+	Algo pkcs8Algorithm
+	Data []byte
+}
+
+type pbes2Params struct {
+	KeyDerivation pkcs8Algorithm
+	Encryption    pkcs8Algorithm
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int            `asn1:"optional"`
+	PRF        pkcs8Algorithm `asn1:"optional"`
+}
+
+// pkcs8Decrypt decrypts an encrypted PKCS#8 key using PBES2 with PBKDF2 and AES-CBC, the OpenSSL default.
+func pkcs8Decrypt(der, pass []byte) ([]byte, error) {
+	enc := pkcs8Encrypted{}
+	if _, err := asn1.Unmarshal(der, &enc); err != nil {
+		return nil, err
+	}
+	if !enc.Algo.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported key encryption %s", enc.Algo.Algorithm.String())
+	}
+	params := pbes2Params{}
+	if _, err := asn1.Unmarshal(enc.Algo.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivation.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation %s", params.KeyDerivation.Algorithm.String())
+	}
+	kdf := pbkdf2Params{}
+	if _, err := asn1.Unmarshal(params.KeyDerivation.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+	prf := kdf.PRF.Algorithm
+	if len(prf) == 0 {
+		prf = oidHMACWithSHA1
+	}
+	hashFn, ok := pbkdf2PRF[prf.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key derivation hash %s", prf.String())
+	}
+	keyLen, ok := aesCBCKeyLen[params.Encryption.Algorithm.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key cipher %s", params.Encryption.Algorithm.String())
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.Encryption.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	key := pbkdf2(pass, kdf.Salt, kdf.Iterations, keyLen, hashFn)
+	blockCipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != blockCipher.BlockSize() || len(enc.Data) == 0 || len(enc.Data)%blockCipher.BlockSize() != 0 {
+		return nil, fmt.Errorf("invalid encrypted key length")
+	}
+	out := make([]byte, len(enc.Data))
+	cipher.NewCBCDecrypter(blockCipher, iv).CryptBlocks(out, enc.Data)
+	// remove the PKCS#7 padding, an invalid padding indicates the wrong passphrase
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > blockCipher.BlockSize() {
+		return nil, fmt.Errorf("incorrect passphrase")
+	}
+	for _, b := range out[len(out)-pad:] {
+		if int(b) != pad {
+			return nil, fmt.Errorf("incorrect passphrase")
+		}
+	}
+	return out[:len(out)-pad], nil
+}
+
+// pbkdf2 implements the key derivation from RFC 8018.
+func pbkdf2(pass, salt []byte, iter, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, pass)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+	out := make([]byte, 0, blocks*hashLen)
+	buf := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf, uint32(block))
+		prf.Write(buf)
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/hex"
+	"os"
+	"testing"
+)
+
+func TestClientCertificate(t *testing.T) {
+	// environment variables prevent running in parallel
+	certPEM, err := os.ReadFile("testdata/client.crt")
+	if err != nil {
+		t.Fatalf("failed to read cert: %v", err)
+	}
+	keyPEM, err := os.ReadFile("testdata/client.key")
+	if err != nil {
+		t.Fatalf("failed to read key: %v", err)
+	}
+	t.Setenv("TEST_CLIENT_KEY_PASS", "testpass")
+	t.Setenv("TEST_CLIENT_KEY_WRONG", "wrongpass")
+	tt := []struct {
+		name      string
+		host      Host
+		expectNil bool
+		expectErr bool
+	}{
+		{
+			name:      "unset",
+			host:      Host{},
+			expectNil: true,
+		},
+		{
+			name: "inline",
+			host: Host{ClientCert: string(certPEM), ClientKey: string(keyPEM)},
+		},
+		{
+			name: "files",
+			host: Host{ClientCertFile: "testdata/client.crt", ClientKeyFile: "testdata/client.key"},
+		},
+		{
+			name: "inline cert and key file",
+			host: Host{ClientCert: string(certPEM), ClientKeyFile: "testdata/client.key"},
+		},
+		{
+			name: "encrypted key",
+			host: Host{ClientCertFile: "testdata/client.crt", ClientKeyFile: "testdata/client-enc.key", ClientKeyPassEnv: "TEST_CLIENT_KEY_PASS"},
+		},
+		{
+			name:      "encrypted key without passphrase",
+			host:      Host{ClientCertFile: "testdata/client.crt", ClientKeyFile: "testdata/client-enc.key"},
+			expectErr: true,
+		},
+		{
+			name:      "encrypted key with wrong passphrase",
+			host:      Host{ClientCertFile: "testdata/client.crt", ClientKeyFile: "testdata/client-enc.key", ClientKeyPassEnv: "TEST_CLIENT_KEY_WRONG"},
+			expectErr: true,
+		},
+		{
+			name:      "missing key",
+			host:      Host{ClientCertFile: "testdata/client.crt"},
+			expectErr: true,
+		},
+		{
+			name:      "missing file",
+			host:      Host{ClientCertFile: "testdata/missing.crt", ClientKeyFile: "testdata/client.key"},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cert, err := tc.host.ClientCertificate()
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load certificate: %v", err)
+			}
+			if tc.expectNil {
+				if cert != nil {
+					t.Errorf("unexpected certificate")
+				}
+				return
+			}
+			if cert == nil || len(cert.Certificate) == 0 || cert.PrivateKey == nil {
+				t.Errorf("certificate not loaded")
+			}
+		})
+	}
+}
+
+func TestPBKDF2(t *testing.T) {
+	t.Parallel()
+	// test vector from RFC 6070
+	out := pbkdf2([]byte("password"), []byte("salt"), 2, 20, pbkdf2PRF["1.2.840.113549.2.7"])
+	expect := "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"
+	if hexStr := hex.EncodeToString(out); hexStr != expect {
+		t.Errorf("unexpected output, expected %s, received %s", expect, hexStr)
+	}
+}
@@ -7,9 +7,48 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
+	"time"
 )
 
+const (
+	// CredStoreAuto selects the secret store of the OS with [CredStoreDefault].
+	CredStoreAuto = "auto"
+	// CredStoreOSXKeychain saves logins in the macOS Keychain.
+	CredStoreOSXKeychain = "osxkeychain"
+	// CredStoreWinCred saves logins in the Windows Credential Manager.
+	CredStoreWinCred = "wincred"
+	// CredStoreSecretService saves logins with libsecret, e.g. the GNOME Keyring or KWallet.
+	CredStoreSecretService = "secretservice"
+	// CredStorePass saves logins with the pass password manager.
+	CredStorePass = "pass"
+)
+
+// CredStoreDefault returns the secret store for the current OS.
+func CredStoreDefault() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return CredStoreOSXKeychain
+	case "windows":
+		return CredStoreWinCred
+	default:
+		return CredStoreSecretService
+	}
+}
+
+// CredStoreHelper returns the credential helper command for a secret store, e.g. "docker-credential-osxkeychain".
+// An empty string is returned when the store is not set.
+func CredStoreHelper(store string) string {
+	switch store {
+	case "":
+		return ""
+	case CredStoreAuto:
+		store = CredStoreDefault()
+	}
+	return "docker-credential-" + store
+}
+
 // credHelper wraps a command that manages user credentials.
 type credHelper struct {
 	prog string
@@ -40,13 +79,17 @@ type credStore struct {
 	Secret    string `json:"Secret"`
 }
 
-// get requests a credential from the helper for a given host.
-func (ch *credHelper) get(host *Host) error {
-	hostname := host.Hostname
+// credHelperHostname returns the name used to lookup the host in a credential helper.
+func credHelperHostname(host *Host) string {
 	if host.CredHost != "" {
-		hostname = host.CredHost
+		return host.CredHost
 	}
-	hostIn := strings.NewReader(hostname)
+	return host.Hostname
+}
+
+// get requests a credential from the helper for a given host.
+func (ch *credHelper) get(host *Host) error {
+	hostIn := strings.NewReader(credHelperHostname(host))
 	credOut := credStore{
 		Username: host.User,
 		Secret:   host.Pass,
@@ -94,4 +137,61 @@ func (ch *credHelper) list() ([]Host, error) {
 	return hostList, nil
 }
 
-// TODO: store method not implemented
+// store saves the credential of a host to the helper.
+func (ch *credHelper) store(host *Host) error {
+	credIn := credStore{
+		ServerURL: credHelperHostname(host),
+		Username:  host.User,
+		Secret:    host.Pass,
+	}
+	if host.Token != "" {
+		credIn.Username = tokenUser
+		credIn.Secret = host.Token
+	}
+	inB, err := json.Marshal(credIn)
+	if err != nil {
+		return err
+	}
+	outB, err := ch.run("store", bytes.NewReader(inB))
+	if err != nil {
+		outS := strings.TrimSpace(string(outB))
+		return fmt.Errorf("error storing credentials, output: %s, error: %v", outS, err)
+	}
+	return nil
+}
+
+// erase removes the credential of a host from the helper.
+func (ch *credHelper) erase(host *Host) error {
+	outB, err := ch.run("erase", strings.NewReader(credHelperHostname(host)))
+	if err != nil {
+		outS := strings.TrimSpace(string(outB))
+		return fmt.Errorf("error erasing credentials, output: %s, error: %v", outS, err)
+	}
+	return nil
+}
+
+// CredStore saves the login of the host to a credential helper, e.g. "docker-credential-osxkeychain".
+// On success, the host retrieves the login from the helper, and the user, password, and token are cleared.
+func (host *Host) CredStore(helper string) error {
+	if host.User == "" && host.Pass == "" && host.Token == "" {
+		return fmt.Errorf("no login to store for %s", host.Name)
+	}
+	err := newCredHelper(helper, map[string]string{}).store(host)
+	if err != nil {
+		return err
+	}
+	host.CredHelper = helper
+	host.User = ""
+	host.Pass = ""
+	host.Token = ""
+	host.credRefresh = time.Time{}
+	return nil
+}
+
+// CredErase removes the login of the host from the credential helper.
+func (host *Host) CredErase() error {
+	if host.CredHelper == "" {
+		return fmt.Errorf("no credential helper configured for %s", host.Name)
+	}
+	return newCredHelper(host.CredHelper, map[string]string{}).erase(host)
+}
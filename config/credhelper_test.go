@@ -89,3 +89,96 @@ func TestCredHelper(t *testing.T) {
 		})
 	}
 }
+
+func TestCredStore(t *testing.T) {
+	// cannot run cred helper in parallel because of OS working directory race conditions
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed checking current directory: %v", err)
+	}
+	curPath := os.Getenv("PATH")
+	os.Setenv("PATH", filepath.Join(cwd, "testdata")+string(os.PathListSeparator)+curPath)
+	defer os.Setenv("PATH", curPath)
+	os.Setenv("CRED_TEST_DIR", t.TempDir())
+	defer os.Unsetenv("CRED_TEST_DIR")
+	helper := CredStoreHelper("testfile")
+	if helper != "docker-credential-testfile" {
+		t.Fatalf("unexpected helper: %s", helper)
+	}
+	if CredStoreHelper("") != "" || CredStoreHelper(CredStoreAuto) != CredStoreHelper(CredStoreDefault()) {
+		t.Errorf("unexpected default helper")
+	}
+
+	tt := []struct {
+		name        string
+		host        string
+		user, pass  string
+		token       string
+		expectErr   bool
+		expectUser  string
+		expectPass  string
+		expectToken string
+	}{
+		{
+			name:       "user/pass",
+			host:       "store.example.com",
+			user:       "hello",
+			pass:       "world",
+			expectUser: "hello",
+			expectPass: "world",
+		},
+		{
+			name:        "token",
+			host:        "token.example.com",
+			token:       "deadbeef",
+			expectToken: "deadbeef",
+		},
+		{
+			name:       "docker hub",
+			host:       DockerRegistry,
+			user:       "hubuser",
+			pass:       "password123",
+			expectUser: "hubuser",
+			expectPass: "password123",
+		},
+		{
+			name:      "no login",
+			host:      "empty.example.com",
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			h := HostNewName(tc.host)
+			h.User, h.Pass, h.Token = tc.user, tc.pass, tc.token
+			err := h.CredStore(helper)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("store did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to store: %v", err)
+			}
+			if h.User != "" || h.Pass != "" || h.Token != "" || h.CredHelper != helper {
+				t.Errorf("host login not moved to the helper: %v", h)
+			}
+			// a new host retrieves the login from the helper
+			hGet := HostNewName(tc.host)
+			hGet.CredHelper = helper
+			cred := hGet.GetCred()
+			if cred.User != tc.expectUser || cred.Password != tc.expectPass || cred.Token != tc.expectToken {
+				t.Errorf("unexpected cred: %v", cred)
+			}
+			err = h.CredErase()
+			if err != nil {
+				t.Errorf("failed to erase: %v", err)
+			}
+			err = h.CredErase()
+			if err == nil {
+				t.Errorf("erase of a missing login did not fail")
+			}
+		})
+	}
+}
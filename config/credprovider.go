@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// CredProviderECR requests a token from the AWS ECR API using the AWS credentials from the environment.
+	CredProviderECR = "ecr"
+	// CredProviderGCP requests an access token using Google application default credentials.
+	CredProviderGCP = "gcp"
+	// CredProviderGitHub uses the GITHUB_TOKEN from the environment, e.g. for ghcr.io in GitHub Actions.
+	CredProviderGitHub = "github"
+	// credProviderBuffer is the time before a credential expires to request a new credential.
+	credProviderBuffer = time.Minute * 5
+)
+
+// credProvider returns a user, password, and expiration for a host, expiration is zero when the credential does not expire.
+type credProvider func(host *Host) (string, string, time.Time, error)
+
+// credProviders lists the providers selectable with the credProvider host setting.
+var credProviders = map[string]credProvider{
+	CredProviderECR:    credProviderECR,
+	CredProviderGCP:    credProviderGCP,
+	CredProviderGitHub: credProviderGitHub,
+}
+
+// credProviderValid returns true when the name is a known credential provider.
+func credProviderValid(name string) bool {
+	_, ok := credProviders[name]
+	return ok
+}
+
+// refreshProvider updates the login using the configured credential provider.
+// A failure is retried after defaultCredHelperRetry, and the error is kept until then.
+func (host *Host) refreshProvider() error {
+	cp, ok := credProviders[host.CredProvider]
+	if !ok {
+		// avoid checking an unknown provider on every request
+		host.credRefresh = time.Now().Add(defaultExpire)
+		host.credErr = fmt.Errorf("unknown credential provider %s for %s", host.CredProvider, host.Name)
+		return host.credErr
+	}
+	user, pass, expires, err := cp(host)
+	if err != nil {
+		host.credRefresh = time.Now().Add(defaultCredHelperRetry)
+		host.credErr = fmt.Errorf("credential provider %s failed for %s: %w", host.CredProvider, host.Name, err)
+		return host.credErr
+	}
+	host.User = user
+	host.Pass = pass
+	host.Token = ""
+	host.credErr = nil
+	if expires.IsZero() {
+		host.credRefresh = time.Now().Add(defaultExpire)
+	} else {
+		host.credRefresh = expires.Add(credProviderBuffer * -1)
+	}
+	return nil
+}
+
+// credHostname returns the hostname used to lookup credentials.
+func (host *Host) credHostname() string {
+	if host.Hostname != "" {
+		return host.Hostname
+	}
+	return host.Name
+}
+
+func credProviderECR(host *Host) (string, string, time.Time, error) {
+	endpoint, region, ok := ecrEndpoint(host.credHostname())
+	if !ok {
+		return "", "", time.Time{}, fmt.Errorf("%s is not an ECR registry", host.credHostname())
+	}
+	token, err := ecrTokenGet(endpoint, region)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return token.user, token.pass, token.expires, nil
+}
+
+func credProviderGitHub(host *Host) (string, string, time.Time, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token == "" {
+		return "", "", time.Time{}, fmt.Errorf("GITHUB_TOKEN is not defined")
+	}
+	// the registry only validates the token, GITHUB_ACTOR is set in GitHub Actions
+	user := os.Getenv("GITHUB_ACTOR")
+	if user == "" {
+		user = "github"
+	}
+	return user, token, time.Time{}, nil
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCredProviderGitHub(t *testing.T) {
+	// environment variables prevent running in parallel
+	t.Setenv("GITHUB_TOKEN", "gh-token")
+	t.Setenv("GITHUB_ACTOR", "octocat")
+	h := HostNewName("ghcr.io")
+	h.CredProvider = CredProviderGitHub
+	cred := h.GetCred()
+	if cred.User != "octocat" || cred.Password != "gh-token" {
+		t.Errorf("unexpected cred: %v", cred)
+	}
+	// a changed token is not used until the refresh
+	t.Setenv("GITHUB_TOKEN", "gh-token2")
+	cred = h.GetCred()
+	if cred.Password != "gh-token" {
+		t.Errorf("unexpected cred: %v", cred)
+	}
+	h.credRefresh = time.Now().Add(time.Second * -1)
+	cred = h.GetCred()
+	if cred.Password != "gh-token2" {
+		t.Errorf("unexpected cred after refresh: %v", cred)
+	}
+	// a missing token leaves the host without a login
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	h = HostNewName("ghcr.io")
+	h.CredProvider = CredProviderGitHub
+	cred = h.GetCred()
+	if cred.User != "" || cred.Password != "" {
+		t.Errorf("unexpected cred: %v", cred)
+	}
+	// the provider error is returned until the provider is retried
+	_, err := h.LoadCred()
+	if err == nil || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	t.Setenv("GITHUB_TOKEN", "gh-token3")
+	_, err = h.LoadCred()
+	if err == nil {
+		t.Errorf("error cleared before the retry")
+	}
+	h.credRefresh = time.Now().Add(time.Second * -1)
+	cred, err = h.LoadCred()
+	if err != nil || cred.Password != "gh-token3" {
+		t.Errorf("unexpected cred after retry: %v, %v", cred, err)
+	}
+}
+
+func TestCredProviderGCP(t *testing.T) {
+	// environment variables prevent running in parallel
+	tempDir := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := ""
+		switch req.URL.Path {
+		case "/token":
+			_ = req.ParseForm()
+			switch req.Form.Get("grant_type") {
+			case gcpTokenJWTBearer:
+				parts := strings.Split(req.Form.Get("assertion"), ".")
+				if len(parts) != 3 {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				token = "sa-token"
+			case "refresh_token":
+				if req.Form.Get("refresh_token") != "user-refresh" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				token = "user-token"
+			case gcpTokenExchange:
+				if req.Form.Get("subject_token") != "oidc-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				token = "sts-token"
+			}
+		case "/impersonate":
+			if req.Header.Get("Authorization") != "Bearer sts-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"accessToken":"impersonated-token","expireTime":"%s"}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+			return
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			if req.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			token = "metadata-token"
+		}
+		if token == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"%s","expires_in":3600}`, token)
+	}))
+	t.Cleanup(ts.Close)
+	resetCache := func() {
+		gcpTokens.mu.Lock()
+		gcpTokens.token = ""
+		gcpTokens.expires = time.Time{}
+		gcpTokens.mu.Unlock()
+	}
+	t.Cleanup(resetCache)
+	origTokenURL := gcpTokenURL
+	gcpTokenURL = ts.URL + "/token"
+	t.Cleanup(func() { gcpTokenURL = origTokenURL })
+	oidcFile := filepath.Join(tempDir, "oidc")
+	err = os.WriteFile(oidcFile, []byte(`{"value":"oidc-token"}`), 0600)
+	if err != nil {
+		t.Fatalf("failed to write oidc token: %v", err)
+	}
+
+	tt := []struct {
+		name   string
+		adc    map[string]interface{}
+		expect string
+	}{
+		{
+			name: "service account",
+			adc: map[string]interface{}{
+				"type":         "service_account",
+				"client_email": "sa@example.iam.gserviceaccount.com",
+				"private_key":  string(keyPEM),
+				"token_uri":    ts.URL + "/token",
+			},
+			expect: "sa-token",
+		},
+		{
+			name: "authorized user",
+			adc: map[string]interface{}{
+				"type":          "authorized_user",
+				"client_id":     "client",
+				"client_secret": "secret",
+				"refresh_token": "user-refresh",
+			},
+			expect: "user-token",
+		},
+		{
+			name: "external account",
+			adc: map[string]interface{}{
+				"type":               "external_account",
+				"audience":           "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/github",
+				"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+				"token_url":          ts.URL + "/token",
+				"credential_source": map[string]interface{}{
+					"file":   oidcFile,
+					"format": map[string]string{"type": "json", "subject_token_field_name": "value"},
+				},
+			},
+			expect: "sts-token",
+		},
+		{
+			name: "impersonation",
+			adc: map[string]interface{}{
+				"type":                              "external_account",
+				"audience":                          "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/github",
+				"subject_token_type":                "urn:ietf:params:oauth:token-type:jwt",
+				"token_url":                         ts.URL + "/token",
+				"service_account_impersonation_url": ts.URL + "/impersonate",
+				"credential_source": map[string]interface{}{
+					"file":   oidcFile,
+					"format": map[string]string{"type": "json", "subject_token_field_name": "value"},
+				},
+			},
+			expect: "impersonated-token",
+		},
+		{
+			name:   "metadata",
+			expect: "metadata-token",
+		},
+	}
+	for i, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			resetCache()
+			if tc.adc != nil {
+				b, err := json.Marshal(tc.adc)
+				if err != nil {
+					t.Fatalf("failed to marshal adc: %v", err)
+				}
+				filename := filepath.Join(tempDir, fmt.Sprintf("adc-%d.json", i))
+				err = os.WriteFile(filename, b, 0600)
+				if err != nil {
+					t.Fatalf("failed to write adc: %v", err)
+				}
+				t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filename)
+			} else {
+				t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+				t.Setenv("CLOUDSDK_CONFIG", filepath.Join(tempDir, "missing"))
+				t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(ts.URL, "http://"))
+			}
+			h := HostNewName("us-docker.pkg.dev")
+			h.CredProvider = CredProviderGCP
+			cred := h.GetCred()
+			if cred.User != gcpUser || cred.Password != tc.expect {
+				t.Errorf("unexpected cred: %v", cred)
+			}
+			if h.credRefresh.IsZero() || h.credRefresh.After(time.Now().Add(time.Hour)) {
+				t.Errorf("unexpected refresh time: %v", h.credRefresh)
+			}
+		})
+	}
+}
@@ -64,17 +64,41 @@ func DockerLoad() ([]Host, error) {
 	return dockerParse(cf)
 }
 
-// dockerParse parses a docker config into a slice of Hosts.
-func dockerParse(cf *conffile.File) ([]Host, error) {
+// DockerCredStore returns the credential helper from the credsStore setting in the users docker config.
+// This helper is used by docker for any registry without a matching credHelpers entry.
+// An empty string is returned when the config file or setting does not exist.
+func DockerCredStore() (string, error) {
+	cf := conffile.New(conffile.WithDirName(dockerDir, dockerConfFile), conffile.WithEnvDir(dockerEnv, dockerConfFile))
+	dc, err := dockerRead(cf)
+	if err != nil {
+		return "", err
+	}
+	if dc.CredentialsStore == "" {
+		return "", nil
+	}
+	return dockerHelperPre + dc.CredentialsStore, nil
+}
+
+// dockerRead loads the docker config, returning an empty config if the file does not exist.
+func dockerRead(cf *conffile.File) (dockerConfig, error) {
+	dc := dockerConfig{}
 	rdr, err := cf.Open()
 	if err != nil && errors.Is(err, fs.ErrNotExist) {
-		return []Host{}, nil
+		return dc, nil
 	} else if err != nil {
-		return nil, err
+		return dc, err
 	}
 	defer rdr.Close()
-	dc := dockerConfig{}
 	if err := json.NewDecoder(rdr).Decode(&dc); err != nil && !errors.Is(err, io.EOF) {
+		return dc, err
+	}
+	return dc, nil
+}
+
+// dockerParse parses a docker config into a slice of Hosts.
+func dockerParse(cf *conffile.File) ([]Host, error) {
+	dc, err := dockerRead(cf)
+	if err != nil {
 		return nil, err
 	}
 	hosts := []Host{}
@@ -119,6 +143,10 @@ func dockerAuthToHost(name string, conf dockerConfig, auth dockerAuthConfig) (Ho
 			return Host{}, err
 		}
 	}
+	// docker login with a credsStore leaves an empty auth entry, the secret is in the store
+	if helper == "" && conf.CredentialsStore != "" && (auth.Username == "" || auth.Password == "") && auth.IdentityToken == "" {
+		helper = dockerHelperPre + conf.CredentialsStore
+	}
 	if (auth.Username == "" || auth.Password == "") && auth.IdentityToken == "" && helper == "" {
 		return Host{}, fmt.Errorf("no credentials found for %s", name)
 	}
@@ -93,6 +93,13 @@ func TestDocker(t *testing.T) {
 			expectTLS:        TLSDisabled,
 			expectCredHost:   "http://storehttp.example.com/",
 		},
+		{
+			name:             "storeauth",
+			hostname:         "storeauth.example.com",
+			expectCredHelper: "docker-credential-teststore",
+			expectHostname:   "storeauth.example.com",
+			expectTLS:        TLSEnabled,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -120,6 +127,12 @@ func TestDocker(t *testing.T) {
 			}
 		})
 	}
+	credStore, err := DockerCredStore()
+	if err != nil {
+		t.Errorf("failed to load cred store: %v", err)
+	} else if credStore != "docker-credential-teststore" {
+		t.Errorf("cred store mismatch, expect docker-credential-teststore, received %s", credStore)
+	}
 }
 
 func TestLoadMissing(t *testing.T) {
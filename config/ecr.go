@@ -0,0 +1,522 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/regclient/regclient/internal/sigv4"
+)
+
+const (
+	// ecrRefreshBuffer is the time before a token expires to request a new token.
+	ecrRefreshBuffer = time.Minute * 15
+	// ecrTimeout limits the time for each request to AWS.
+	ecrTimeout = time.Second * 30
+	// imdsTimeout limits requests to the instance metadata service, which is not available outside of EC2.
+	imdsTimeout = time.Second * 2
+	// imdsTokenTTL is the lifetime in seconds of the IMDSv2 session token.
+	imdsTokenTTL      = "300"
+	imdsEndpoint      = "http://169.254.169.254"
+	containerEndpoint = "http://169.254.170.2"
+	ecrTarget         = "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken"
+	ecrCreateTarget   = "AmazonEC2ContainerRegistry_V20150921.CreateRepository"
+	ecrListTarget     = "AmazonEC2ContainerRegistry_V20150921.DescribeRepositories"
+)
+
+// ecrHostRE matches ECR registries, e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com.
+var ecrHostRE = regexp.MustCompile(`^[0-9]{12}\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// ecrTokens caches tokens by the ECR API endpoint, shared by every host in the process.
+var ecrTokens = struct {
+	mu     sync.Mutex
+	tokens map[string]ecrToken
+	creds  *awsCreds
+}{
+	tokens: map[string]ecrToken{},
+}
+
+type ecrToken struct {
+	user, pass string
+	expires    time.Time
+}
+
+type awsCreds struct {
+	creds   sigv4.Creds
+	expires time.Time // zero for credentials that do not expire
+}
+
+// ecrEndpoint returns the ECR API endpoint and region for a registry hostname.
+func ecrEndpoint(hostname string) (string, string, bool) {
+	match := ecrHostRE.FindStringSubmatch(hostname)
+	if match == nil {
+		return "", "", false
+	}
+	region := match[2]
+	if ep := os.Getenv("AWS_ENDPOINT_URL_ECR"); ep != "" {
+		return strings.TrimSuffix(ep, "/"), region, true
+	}
+	if ep := os.Getenv("AWS_ENDPOINT_URL"); ep != "" {
+		return strings.TrimSuffix(ep, "/"), region, true
+	}
+	if match[1] != "" {
+		return "https://ecr-fips." + region + ".amazonaws.com" + match[3], region, true
+	}
+	return "https://api.ecr." + region + ".amazonaws.com" + match[3], region, true
+}
+
+// refreshECR requests a registry login from the ECR API using the AWS credentials from the environment.
+func (host *Host) refreshECR() {
+	if _, _, ok := ecrEndpoint(host.credHostname()); !ok {
+		return
+	}
+	host.credECR = true
+	user, pass, expires, err := credProviderECR(host)
+	if err != nil {
+		host.credRefresh = time.Now().Add(defaultCredHelperRetry)
+		return
+	}
+	host.User = user
+	host.Pass = pass
+	host.credRefresh = expires.Add(ecrRefreshBuffer * -1)
+}
+
+// ecrTokenGet returns a cached token or requests a new token when the cached token will expire soon.
+func ecrTokenGet(endpoint, region string) (ecrToken, error) {
+	ecrTokens.mu.Lock()
+	defer ecrTokens.mu.Unlock()
+	if token, ok := ecrTokens.tokens[endpoint]; ok && time.Now().Add(ecrRefreshBuffer).Before(token.expires) {
+		return token, nil
+	}
+	creds, err := ecrCredsLocked(region)
+	if err != nil {
+		return ecrToken{}, err
+	}
+	token, err := ecrTokenRequest(endpoint, region, creds)
+	if err != nil {
+		return ecrToken{}, err
+	}
+	ecrTokens.tokens[endpoint] = token
+	return token, nil
+}
+
+// ecrCredsLocked returns the cached AWS credentials, loading new credentials when they will expire soon.
+// The ecrTokens lock must be held.
+func ecrCredsLocked(region string) (sigv4.Creds, error) {
+	if ecrTokens.creds == nil || (!ecrTokens.creds.expires.IsZero() && time.Now().Add(ecrRefreshBuffer).After(ecrTokens.creds.expires)) {
+		creds, err := awsCredsLoad(region)
+		if err != nil {
+			return sigv4.Creds{}, err
+		}
+		ecrTokens.creds = &creds
+	}
+	return ecrTokens.creds.creds, nil
+}
+
+// ECRRepoCreate creates a repository in an ECR registry using the AWS credentials from the environment.
+// The hostname is the registry hostname, e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com.
+// An existing repository is not an error.
+func ECRRepoCreate(ctx context.Context, hostname, repo string) error {
+	endpoint, region, ok := ecrEndpoint(hostname)
+	if !ok {
+		return fmt.Errorf("%s is not an ECR registry", hostname)
+	}
+	ecrTokens.mu.Lock()
+	creds, err := ecrCredsLocked(region)
+	ecrTokens.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	registryID, _, _ := strings.Cut(hostname, ".")
+	body, err := json.Marshal(struct {
+		RegistryID     string `json:"registryId"`
+		RepositoryName string `json:"repositoryName"`
+	}{
+		RegistryID:     registryID,
+		RepositoryName: repo,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, ecrTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", ecrCreateTarget)
+	sigv4.Sign(req, creds, region, "ecr", sigv4.Hash(body), time.Now())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	errResp := struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}{}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 65536)).Decode(&errResp)
+	// the type may be prefixed with a namespace, e.g. "com.amazonaws...#RepositoryAlreadyExistsException"
+	if strings.HasSuffix(errResp.Type, "RepositoryAlreadyExistsException") {
+		return nil
+	}
+	if errResp.Type != "" {
+		return fmt.Errorf("ECR create repository %s failed, status %d: %s: %s", repo, resp.StatusCode, errResp.Type, errResp.Message)
+	}
+	return fmt.Errorf("ECR create repository %s failed, status %d", repo, resp.StatusCode)
+}
+
+// ECRRepoList lists the repositories in an ECR registry using the AWS credentials from the environment.
+// The hostname is the registry hostname, e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com.
+func ECRRepoList(ctx context.Context, hostname string) ([]string, error) {
+	endpoint, region, ok := ecrEndpoint(hostname)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ECR registry", hostname)
+	}
+	ecrTokens.mu.Lock()
+	creds, err := ecrCredsLocked(region)
+	ecrTokens.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	registryID, _, _ := strings.Cut(hostname, ".")
+	repos := []string{}
+	nextToken := ""
+	for {
+		body, err := json.Marshal(struct {
+			RegistryID string `json:"registryId"`
+			MaxResults int    `json:"maxResults"`
+			NextToken  string `json:"nextToken,omitempty"`
+		}{
+			RegistryID: registryID,
+			MaxResults: 1000,
+			NextToken:  nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := ecrListRequest(ctx, endpoint, region, creds, body)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range resp.Repositories {
+			repos = append(repos, repo.RepositoryName)
+		}
+		if resp.NextToken == "" {
+			return repos, nil
+		}
+		nextToken = resp.NextToken
+	}
+}
+
+type ecrListResp struct {
+	Repositories []struct {
+		RepositoryName string `json:"repositoryName"`
+	} `json:"repositories"`
+	NextToken string `json:"nextToken"`
+}
+
+// ecrListRequest calls the DescribeRepositories API for a single page of repositories.
+func ecrListRequest(ctx context.Context, endpoint, region string, creds sigv4.Creds, body []byte) (ecrListResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, ecrTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return ecrListResp{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", ecrListTarget)
+	sigv4.Sign(req, creds, region, "ecr", sigv4.Hash(body), time.Now())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return ecrListResp{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ecrListResp{}, fmt.Errorf("ECR list repositories failed, status %d", resp.StatusCode)
+	}
+	listResp := ecrListResp{}
+	err = json.NewDecoder(resp.Body).Decode(&listResp)
+	if err != nil {
+		return ecrListResp{}, fmt.Errorf("failed to parse ECR repository list: %w", err)
+	}
+	return listResp, nil
+}
+
+// ecrTokenRequest calls the GetAuthorizationToken API.
+func ecrTokenRequest(endpoint, region string, creds sigv4.Creds) (ecrToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ecrTimeout)
+	defer cancel()
+	body := []byte("{}")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return ecrToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", ecrTarget)
+	sigv4.Sign(req, creds, region, "ecr", sigv4.Hash(body), time.Now())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return ecrToken{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ecrToken{}, fmt.Errorf("ECR token request failed, status %d", resp.StatusCode)
+	}
+	tokenResp := struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"`
+		} `json:"authorizationData"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&tokenResp)
+	if err != nil {
+		return ecrToken{}, fmt.Errorf("failed to parse ECR token: %w", err)
+	}
+	if len(tokenResp.AuthorizationData) == 0 {
+		return ecrToken{}, fmt.Errorf("ECR token response is empty")
+	}
+	ad := tokenResp.AuthorizationData[0]
+	user, pass, err := decodeAuth(ad.AuthorizationToken)
+	if err != nil {
+		return ecrToken{}, fmt.Errorf("failed to decode ECR token: %w", err)
+	}
+	return ecrToken{
+		user:    user,
+		pass:    pass,
+		expires: time.Unix(int64(ad.ExpiresAt), 0),
+	}, nil
+}
+
+// awsCredsLoad finds AWS credentials from environment variables, a web identity token (EKS),
+// the container credentials endpoint (ECS and EKS pod identity), or the EC2 instance metadata service.
+func awsCredsLoad(region string) (awsCreds, error) {
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "" {
+		return awsCreds{creds: sigv4.Creds{
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}}, nil
+	}
+	if os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" && os.Getenv("AWS_ROLE_ARN") != "" {
+		return awsCredsWebIdentity(region)
+	}
+	if os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		return awsCredsContainer()
+	}
+	if strings.ToLower(os.Getenv("AWS_EC2_METADATA_DISABLED")) != "true" {
+		return awsCredsIMDS()
+	}
+	return awsCreds{}, fmt.Errorf("AWS credentials not found")
+}
+
+// awsCredsJSON is returned by the container and instance metadata credential endpoints.
+type awsCredsJSON struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+func (c awsCredsJSON) toCreds() (awsCreds, error) {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return awsCreds{}, fmt.Errorf("AWS credentials response is missing the access key")
+	}
+	return awsCreds{
+		creds: sigv4.Creds{
+			AccessKey:    c.AccessKeyID,
+			SecretKey:    c.SecretAccessKey,
+			SessionToken: c.Token,
+		},
+		expires: c.Expiration,
+	}, nil
+}
+
+// awsCredsWebIdentity exchanges a web identity token for role credentials with the STS API.
+func awsCredsWebIdentity(region string) (awsCreds, error) {
+	//#nosec G304 file is configured by the environment
+	token, err := os.ReadFile(os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"))
+	if err != nil {
+		return awsCreds{}, err
+	}
+	if r := os.Getenv("AWS_REGION"); r != "" {
+		region = r
+	}
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_STS")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if endpoint == "" {
+		endpoint = "https://sts." + region + ".amazonaws.com"
+		if strings.HasPrefix(region, "cn-") {
+			endpoint = endpoint + ".cn"
+		}
+	}
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = fmt.Sprintf("regclient-%d", time.Now().Unix())
+	}
+	form := url.Values{}
+	form.Set("Action", "AssumeRoleWithWebIdentity")
+	form.Set("Version", "2011-06-15")
+	form.Set("RoleArn", os.Getenv("AWS_ROLE_ARN"))
+	form.Set("RoleSessionName", sessionName)
+	form.Set("WebIdentityToken", strings.TrimSpace(string(token)))
+	ctx, cancel := context.WithTimeout(context.Background(), ecrTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return awsCreds{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return awsCreds{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return awsCreds{}, fmt.Errorf("STS AssumeRoleWithWebIdentity failed, status %d", resp.StatusCode)
+	}
+	stsResp := struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}{}
+	err = xml.NewDecoder(resp.Body).Decode(&stsResp)
+	if err != nil {
+		return awsCreds{}, fmt.Errorf("failed to parse STS response: %w", err)
+	}
+	return awsCredsJSON{
+		AccessKeyID:     stsResp.Credentials.AccessKeyID,
+		SecretAccessKey: stsResp.Credentials.SecretAccessKey,
+		Token:           stsResp.Credentials.SessionToken,
+		Expiration:      stsResp.Credentials.Expiration,
+	}.toCreds()
+}
+
+// awsCredsContainer requests credentials from the ECS or EKS pod identity agent.
+func awsCredsContainer() (awsCreds, error) {
+	u := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		u = containerEndpoint + rel
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ecrTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return awsCreds{}, err
+	}
+	authToken := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		//#nosec G304 file is configured by the environment
+		b, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return awsCreds{}, err
+		}
+		authToken = strings.TrimSpace(string(b))
+	}
+	if authToken != "" {
+		req.Header.Set("Authorization", authToken)
+	}
+	c := awsCredsJSON{}
+	err = awsGetJSON(req, &c)
+	if err != nil {
+		return awsCreds{}, err
+	}
+	return c.toCreds()
+}
+
+// awsCredsIMDS requests the instance role credentials from the EC2 instance metadata service (IMDSv2).
+func awsCredsIMDS() (awsCreds, error) {
+	endpoint := os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT")
+	if endpoint == "" {
+		endpoint = imdsEndpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	ctx, cancel := context.WithTimeout(context.Background(), imdsTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return awsCreds{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", imdsTokenTTL)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return awsCreds{}, err
+	}
+	token, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if err != nil {
+		return awsCreds{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return awsCreds{}, fmt.Errorf("IMDS token request failed, status %d", resp.StatusCode)
+	}
+	imdsGet := func(p string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/latest/meta-data/iam/security-credentials/"+p, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", string(token))
+		return req, nil
+	}
+	req, err = imdsGet("")
+	if err != nil {
+		return awsCreds{}, err
+	}
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return awsCreds{}, err
+	}
+	role, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if err != nil {
+		return awsCreds{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return awsCreds{}, fmt.Errorf("IMDS role request failed, status %d", resp.StatusCode)
+	}
+	roleName, _, _ := strings.Cut(strings.TrimSpace(string(role)), "\n")
+	if roleName == "" {
+		return awsCreds{}, fmt.Errorf("IMDS did not return an instance role")
+	}
+	req, err = imdsGet(roleName)
+	if err != nil {
+		return awsCreds{}, err
+	}
+	c := awsCredsJSON{}
+	err = awsGetJSON(req, &c)
+	if err != nil {
+		return awsCreds{}, err
+	}
+	return c.toCreds()
+}
+
+func awsGetJSON(req *http.Request, v interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed, status %d", req.URL.Redacted(), resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestECR(t *testing.T) {
+	// environment variables prevent running in parallel
+	var ecrCount, credCount atomic.Int64
+	expires := time.Now().Add(time.Hour * 12)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/" && req.Header.Get("X-Amz-Target") == ecrCreateTarget:
+			body := struct {
+				RegistryID     string `json:"registryId"`
+				RepositoryName string `json:"repositoryName"`
+			}{}
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil || body.RegistryID != "123456789012" || !strings.Contains(req.Header.Get("Authorization"), "/us-west-2/ecr/aws4_request") {
+				t.Errorf("unexpected create request: %v, %v", body, err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			switch body.RepositoryName {
+			case "exists":
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, `{"__type":"RepositoryAlreadyExistsException","message":"already exists"}`)
+			case "invalid":
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, `{"__type":"InvalidParameterException","message":"invalid name"}`)
+			default:
+				fmt.Fprintf(w, `{"repository":{"repositoryName":"%s"}}`, body.RepositoryName)
+			}
+		case req.Method == http.MethodPost && req.URL.Path == "/" && req.Header.Get("X-Amz-Target") == ecrListTarget:
+			body := struct {
+				RegistryID string `json:"registryId"`
+				NextToken  string `json:"nextToken"`
+			}{}
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil || body.RegistryID != "123456789012" {
+				t.Errorf("unexpected list request: %v, %v", body, err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if body.NextToken == "" {
+				fmt.Fprintf(w, `{"repositories":[{"repositoryName":"a/repo"},{"repositoryName":"b"}],"nextToken":"page2"}`)
+			} else {
+				fmt.Fprintf(w, `{"repositories":[{"repositoryName":"c"}]}`)
+			}
+		case req.Method == http.MethodPost && req.URL.Path == "/":
+			if req.Header.Get("X-Amz-Target") != ecrTarget {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			auth := req.Header.Get("Authorization")
+			if !strings.Contains(auth, "/us-west-2/ecr/aws4_request") {
+				t.Errorf("unexpected authorization header: %s", auth)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			ecrCount.Add(1)
+			user := "AWS"
+			if strings.Contains(auth, "Credential=containerkey/") {
+				user = "AWS-container"
+			}
+			fmt.Fprintf(w, `{"authorizationData":[{"authorizationToken":"%s","expiresAt":%d}]}`,
+				base64.StdEncoding.EncodeToString([]byte(user+":ecr-pass")), expires.Unix())
+		case req.Method == http.MethodGet && req.URL.Path == "/creds":
+			if req.Header.Get("Authorization") != "container-auth" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			credCount.Add(1)
+			_ = json.NewEncoder(w).Encode(awsCredsJSON{
+				AccessKeyID:     "containerkey",
+				SecretAccessKey: "containersecret",
+				Token:           "containertoken",
+				Expiration:      time.Now().Add(time.Hour),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	resetCache := func() {
+		ecrTokens.mu.Lock()
+		ecrTokens.tokens = map[string]ecrToken{}
+		ecrTokens.creds = nil
+		ecrTokens.mu.Unlock()
+	}
+	t.Cleanup(resetCache)
+	t.Setenv("AWS_ENDPOINT_URL_ECR", ts.URL)
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	t.Run("hostname", func(t *testing.T) {
+		tt := []struct {
+			hostname, endpoint, region string
+		}{
+			{"123456789012.dkr.ecr.us-east-1.amazonaws.com", "https://api.ecr.us-east-1.amazonaws.com", "us-east-1"},
+			{"123456789012.dkr.ecr-fips.us-gov-west-1.amazonaws.com", "https://ecr-fips.us-gov-west-1.amazonaws.com", "us-gov-west-1"},
+			{"123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn", "https://api.ecr.cn-north-1.amazonaws.com.cn", "cn-north-1"},
+			{"registry.example.com", "", ""},
+			{"public.ecr.aws", "", ""},
+		}
+		t.Setenv("AWS_ENDPOINT_URL_ECR", "")
+		for _, tc := range tt {
+			endpoint, region, ok := ecrEndpoint(tc.hostname)
+			if ok != (tc.endpoint != "") || endpoint != tc.endpoint || region != tc.region {
+				t.Errorf("unexpected endpoint for %s: %s, %s, %t", tc.hostname, endpoint, region, ok)
+			}
+		}
+	})
+	t.Run("env", func(t *testing.T) {
+		resetCache()
+		ecrCount.Store(0)
+		t.Setenv("AWS_ACCESS_KEY_ID", "envkey")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "envsecret")
+		h := HostNewName("123456789012.dkr.ecr.us-west-2.amazonaws.com")
+		cred := h.GetCred()
+		if cred.User != "AWS" || cred.Password != "ecr-pass" {
+			t.Errorf("unexpected cred: %v", cred)
+		}
+		// another host in the same region uses the cached token
+		h2 := HostNewName("210987654321.dkr.ecr.us-west-2.amazonaws.com")
+		cred = h2.GetCred()
+		if cred.User != "AWS" || cred.Password != "ecr-pass" {
+			t.Errorf("unexpected cred: %v", cred)
+		}
+		cred = h.GetCred()
+		if cred.User != "AWS" {
+			t.Errorf("unexpected cred: %v", cred)
+		}
+		if ecrCount.Load() != 1 {
+			t.Errorf("unexpected number of token requests: %d", ecrCount.Load())
+		}
+		if !h.credRefresh.Before(expires) {
+			t.Errorf("token refresh is not before expiration: %v", h.credRefresh)
+		}
+	})
+	t.Run("container", func(t *testing.T) {
+		resetCache()
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", ts.URL+"/creds")
+		t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "container-auth")
+		h := HostNewName("123456789012.dkr.ecr.us-west-2.amazonaws.com")
+		cred := h.GetCred()
+		if cred.User != "AWS-container" || cred.Password != "ecr-pass" {
+			t.Errorf("unexpected cred: %v", cred)
+		}
+		if credCount.Load() != 1 {
+			t.Errorf("unexpected number of credential requests: %d", credCount.Load())
+		}
+	})
+	t.Run("static login", func(t *testing.T) {
+		resetCache()
+		ecrCount.Store(0)
+		h := HostNewName("123456789012.dkr.ecr.us-west-2.amazonaws.com")
+		h.User = "user"
+		h.Pass = "pass"
+		cred := h.GetCred()
+		if cred.User != "user" || cred.Password != "pass" {
+			t.Errorf("unexpected cred: %v", cred)
+		}
+		if ecrCount.Load() != 0 {
+			t.Errorf("token requested for a host with a login")
+		}
+	})
+	t.Run("repo create", func(t *testing.T) {
+		resetCache()
+		t.Setenv("AWS_ACCESS_KEY_ID", "envkey")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "envsecret")
+		ctx := context.Background()
+		hostname := "123456789012.dkr.ecr.us-west-2.amazonaws.com"
+		tt := []struct {
+			repo      string
+			expectErr bool
+		}{
+			{repo: "new/repo"},
+			{repo: "exists"},
+			{repo: "invalid", expectErr: true},
+		}
+		for _, tc := range tt {
+			err := ECRRepoCreate(ctx, hostname, tc.repo)
+			if tc.expectErr && err == nil {
+				t.Errorf("create %s did not fail", tc.repo)
+			} else if !tc.expectErr && err != nil {
+				t.Errorf("create %s failed: %v", tc.repo, err)
+			}
+		}
+		err := ECRRepoCreate(ctx, "registry.example.com", "repo")
+		if err == nil {
+			t.Errorf("create on a non-ECR registry did not fail")
+		}
+	})
+	t.Run("repo list", func(t *testing.T) {
+		resetCache()
+		t.Setenv("AWS_ACCESS_KEY_ID", "envkey")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "envsecret")
+		ctx := context.Background()
+		repos, err := ECRRepoList(ctx, "123456789012.dkr.ecr.us-west-2.amazonaws.com")
+		if err != nil {
+			t.Fatalf("failed to list repos: %v", err)
+		}
+		if strings.Join(repos, ",") != "a/repo,b,c" {
+			t.Errorf("unexpected repos: %v", repos)
+		}
+		_, err = ECRRepoList(ctx, "registry.example.com")
+		if err == nil {
+			t.Errorf("list on a non-ECR registry did not fail")
+		}
+	})
+	t.Run("missing creds", func(t *testing.T) {
+		resetCache()
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+		h := HostNewName("123456789012.dkr.ecr.us-west-2.amazonaws.com")
+		cred := h.GetCred()
+		if cred.User != "" || cred.Password != "" {
+			t.Errorf("unexpected cred: %v", cred)
+		}
+	})
+}
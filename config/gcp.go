@@ -0,0 +1,340 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/regclient/regclient/internal/conffile"
+)
+
+const (
+	// gcpUser is the registry username for a Google OAuth2 access token.
+	gcpUser = "oauth2accesstoken"
+	// gcpScope is requested for access tokens used with Artifact Registry.
+	gcpScope          = "https://www.googleapis.com/auth/cloud-platform"
+	gcpADCDir         = ".config/gcloud"
+	gcpADCFile        = "application_default_credentials.json"
+	gcpMetadataHost   = "169.254.169.254"
+	gcpTimeout        = time.Second * 30
+	gcpTokenExchange  = "urn:ietf:params:oauth:grant-type:token-exchange"
+	gcpTokenJWTBearer = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+)
+
+// gcpTokenURL is the default OAuth2 token endpoint.
+var gcpTokenURL = "https://oauth2.googleapis.com/token"
+
+// gcpTokens caches the access token, shared by every host in the process.
+var gcpTokens = struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}{}
+
+// gcpADC are the fields used from an application default credentials file.
+type gcpADC struct {
+	Type string `json:"type"`
+	// service_account
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	// authorized_user
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	// external_account (workload identity federation)
+	Audience                       string `json:"audience"`
+	SubjectTokenType               string `json:"subject_token_type"`
+	TokenURL                       string `json:"token_url"`
+	ServiceAccountImpersonationURL string `json:"service_account_impersonation_url"`
+	CredentialSource               struct {
+		File    string            `json:"file"`
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Format  struct {
+			Type                  string `json:"type"`
+			SubjectTokenFieldName string `json:"subject_token_field_name"`
+		} `json:"format"`
+	} `json:"credential_source"`
+}
+
+type gcpTokenResp struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func credProviderGCP(host *Host) (string, string, time.Time, error) {
+	gcpTokens.mu.Lock()
+	defer gcpTokens.mu.Unlock()
+	if gcpTokens.token != "" && time.Now().Add(credProviderBuffer).Before(gcpTokens.expires) {
+		return gcpUser, gcpTokens.token, gcpTokens.expires, nil
+	}
+	token, expires, err := gcpTokenGet()
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	gcpTokens.token = token
+	gcpTokens.expires = expires
+	return gcpUser, token, expires, nil
+}
+
+// gcpTokenGet requests an access token using the application default credentials file,
+// falling back to the metadata server when the file is not found.
+func gcpTokenGet() (string, time.Time, error) {
+	cf := conffile.New(
+		conffile.WithDirName(gcpADCDir, gcpADCFile),
+		conffile.WithEnvDir("CLOUDSDK_CONFIG", gcpADCFile),
+		conffile.WithEnvFile("GOOGLE_APPLICATION_CREDENTIALS"),
+	)
+	rdr, err := cf.Open()
+	if err != nil {
+		if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "" {
+			return "", time.Time{}, err
+		}
+		return gcpTokenMetadata()
+	}
+	defer rdr.Close()
+	adc := gcpADC{}
+	err = json.NewDecoder(rdr).Decode(&adc)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse %s: %w", cf.Name(), err)
+	}
+	switch adc.Type {
+	case "service_account":
+		return gcpTokenServiceAccount(adc)
+	case "authorized_user":
+		form := url.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", adc.ClientID)
+		form.Set("client_secret", adc.ClientSecret)
+		form.Set("refresh_token", adc.RefreshToken)
+		tokenURI := adc.TokenURI
+		if tokenURI == "" {
+			tokenURI = gcpTokenURL
+		}
+		return gcpTokenPost(tokenURI, form)
+	case "external_account":
+		return gcpTokenExternal(adc)
+	default:
+		return "", time.Time{}, fmt.Errorf("unsupported credential type %q in %s", adc.Type, cf.Name())
+	}
+}
+
+// gcpTokenServiceAccount signs a JWT with the service account key and exchanges it for an access token.
+func gcpTokenServiceAccount(adc gcpADC) (string, time.Time, error) {
+	block, _ := pem.Decode([]byte(adc.PrivateKey))
+	if block == nil {
+		return "", time.Time{}, fmt.Errorf("failed to decode service account private key")
+	}
+	var key *rsa.PrivateKey
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		var ok bool
+		key, ok = k.(*rsa.PrivateKey)
+		if !ok {
+			return "", time.Time{}, fmt.Errorf("service account private key is not an RSA key")
+		}
+	} else {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to parse service account private key: %w", err)
+		}
+	}
+	tokenURI := adc.TokenURI
+	if tokenURI == "" {
+		tokenURI = gcpTokenURL
+	}
+	now := time.Now()
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if adc.PrivateKeyID != "" {
+		header["kid"] = adc.PrivateKeyID
+	}
+	claims := map[string]interface{}{
+		"iss":   adc.ClientEmail,
+		"scope": gcpScope,
+		"aud":   tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	hj, err := json.Marshal(header)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	cj, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(hj) + "." + base64.RawURLEncoding.EncodeToString(cj)
+	hash := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", gcpTokenJWTBearer)
+	form.Set("assertion", signed+"."+base64.RawURLEncoding.EncodeToString(sig))
+	return gcpTokenPost(tokenURI, form)
+}
+
+// gcpTokenExternal exchanges a token from another identity provider (e.g. GitHub Actions OIDC) with the STS API.
+func gcpTokenExternal(adc gcpADC) (string, time.Time, error) {
+	subject, err := gcpSubjectToken(adc)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", gcpTokenExchange)
+	form.Set("audience", adc.Audience)
+	form.Set("scope", gcpScope)
+	form.Set("requested_token_type", "urn:ietf:params:oauth:token-type:access_token")
+	form.Set("subject_token_type", adc.SubjectTokenType)
+	form.Set("subject_token", subject)
+	token, expires, err := gcpTokenPost(adc.TokenURL, form)
+	if err != nil || adc.ServiceAccountImpersonationURL == "" {
+		return token, expires, err
+	}
+	// the federated token is used to generate a token for the service account
+	body, err := json.Marshal(map[string][]string{"scope": {gcpScope}})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), gcpTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, adc.ServiceAccountImpersonationURL, bytes.NewReader(body))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	impResp := struct {
+		AccessToken string    `json:"accessToken"`
+		ExpireTime  time.Time `json:"expireTime"`
+	}{}
+	err = gcpDo(req, &impResp)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if impResp.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("service account impersonation did not return a token")
+	}
+	return impResp.AccessToken, impResp.ExpireTime, nil
+}
+
+// gcpSubjectToken reads the external identity token from a file or url.
+func gcpSubjectToken(adc gcpADC) (string, error) {
+	cs := adc.CredentialSource
+	var raw []byte
+	switch {
+	case cs.File != "":
+		//#nosec G304 file is configured by the credentials file
+		b, err := os.ReadFile(cs.File)
+		if err != nil {
+			return "", err
+		}
+		raw = b
+	case cs.URL != "":
+		ctx, cancel := context.WithTimeout(context.Background(), gcpTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, cs.URL, nil)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range cs.Headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("request for subject token failed, status %d", resp.StatusCode)
+		}
+		raw, err = io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported credential source, file or url is required")
+	}
+	if cs.Format.Type != "json" {
+		return strings.TrimSpace(string(raw)), nil
+	}
+	fields := map[string]interface{}{}
+	err := json.Unmarshal(raw, &fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse subject token: %w", err)
+	}
+	token, ok := fields[cs.Format.SubjectTokenFieldName].(string)
+	if !ok || token == "" {
+		return "", fmt.Errorf("subject token field %q not found", cs.Format.SubjectTokenFieldName)
+	}
+	return token, nil
+}
+
+// gcpTokenMetadata requests a token for the default service account from the GCE metadata server.
+func gcpTokenMetadata() (string, time.Time, error) {
+	mdHost := os.Getenv("GCE_METADATA_HOST")
+	if mdHost == "" {
+		mdHost = gcpMetadataHost
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), imdsTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"http://"+mdHost+"/computeMetadata/v1/instance/service-accounts/default/token?scopes="+url.QueryEscape(gcpScope), nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return gcpTokenDo(req)
+}
+
+func gcpTokenPost(tokenURI string, form url.Values) (string, time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gcpTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return gcpTokenDo(req)
+}
+
+func gcpTokenDo(req *http.Request) (string, time.Time, error) {
+	tr := gcpTokenResp{}
+	err := gcpDo(req, &tr)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if tr.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("token response from %s is missing the access token", req.URL.Redacted())
+	}
+	return tr.AccessToken, time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second), nil
+}
+
+func gcpDo(req *http.Request, v interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed, status %d", req.URL.Redacted(), resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -48,6 +48,47 @@ const (
 	tokenUser = "<token>"
 )
 
+const (
+	// RedirectAuthDefault strips the Authorization header on redirects unless the new host is the same or a subdomain.
+	RedirectAuthDefault = ""
+	// RedirectAuthStrip strips the Authorization header on any redirect to a different host.
+	RedirectAuthStrip = "strip"
+	// RedirectAuthKeep sends the Authorization header to every host in a redirect.
+	RedirectAuthKeep = "keep"
+)
+
+const (
+	// IPFamilyDual connects to a registry over IPv4 or IPv6, this is the default.
+	IPFamilyDual = ""
+	// IPFamilyIPv4 only connects to a registry over IPv4.
+	IPFamilyIPv4 = "ipv4"
+	// IPFamilyIPv6 only connects to a registry over IPv6.
+	IPFamilyIPv6 = "ipv6"
+)
+
+const (
+	// RepoCreateECR creates repositories with the AWS ECR API before the first push.
+	RepoCreateECR = "ecr"
+)
+
+const (
+	// RepoDeleteArtifactory deletes repositories with the JFrog Artifactory API.
+	RepoDeleteArtifactory = "artifactory"
+	// RepoDeleteGitLab deletes repositories with the GitLab container registry API.
+	RepoDeleteGitLab = "gitlab"
+	// RepoDeleteHarbor deletes repositories with the Harbor API.
+	RepoDeleteHarbor = "harbor"
+)
+
+const (
+	// RepoListDockerHub lists the repositories in a Docker Hub namespace with the Hub API.
+	RepoListDockerHub = "dockerhub"
+	// RepoListECR lists repositories with the AWS ECR API.
+	RepoListECR = "ecr"
+	// RepoListGCR lists repositories in a project by walking the child repositories from the tag listing of GCR and Artifact Registry.
+	RepoListGCR = "gcr"
+)
+
 var (
 	mu = sync.Mutex{}
 )
@@ -105,36 +146,77 @@ func (t *TLSConf) UnmarshalText(b []byte) error {
 
 // Host defines settings for connecting to a registry.
 type Host struct {
-	Name          string             `json:"-" yaml:"registry,omitempty"`                  // Name of the registry (required) (yaml configs pass this as a field, json provides this from the object key)
-	TLS           TLSConf            `json:"tls,omitempty" yaml:"tls"`                     // TLS setting: enabled (default), disabled, insecure
-	RegCert       string             `json:"regcert,omitempty" yaml:"regcert"`             // public pem cert of registry
-	ClientCert    string             `json:"clientCert,omitempty" yaml:"clientCert"`       // public pem cert for client (mTLS)
-	ClientKey     string             `json:"clientKey,omitempty" yaml:"clientKey"`         // private pem cert for client (mTLS)
-	Hostname      string             `json:"hostname,omitempty" yaml:"hostname"`           // hostname of registry, default is the registry name
-	User          string             `json:"user,omitempty" yaml:"user"`                   // username, not used with credHelper
-	Pass          string             `json:"pass,omitempty" yaml:"pass"`                   // password, not used with credHelper
-	Token         string             `json:"token,omitempty" yaml:"token"`                 // token, experimental for specific APIs
-	CredHelper    string             `json:"credHelper,omitempty" yaml:"credHelper"`       // credential helper command for requesting logins
-	CredExpire    timejson.Duration  `json:"credExpire,omitempty" yaml:"credExpire"`       // time until credential expires
-	CredHost      string             `json:"credHost" yaml:"credHost"`                     // used when a helper hostname doesn't match Hostname
-	PathPrefix    string             `json:"pathPrefix,omitempty" yaml:"pathPrefix"`       // used for mirrors defined within a repository namespace
-	Mirrors       []string           `json:"mirrors,omitempty" yaml:"mirrors"`             // list of other Host Names to use as mirrors
-	Priority      uint               `json:"priority,omitempty" yaml:"priority"`           // priority when sorting mirrors, higher priority attempted first
-	RepoAuth      bool               `json:"repoAuth,omitempty" yaml:"repoAuth"`           // tracks a separate auth per repo
-	API           string             `json:"api,omitempty" yaml:"api"`                     // experimental: registry API to use
-	APIOpts       map[string]string  `json:"apiOpts,omitempty" yaml:"apiOpts"`             // options for APIs
-	BlobChunk     int64              `json:"blobChunk,omitempty" yaml:"blobChunk"`         // size of each blob chunk
-	BlobMax       int64              `json:"blobMax,omitempty" yaml:"blobMax"`             // threshold to switch to chunked upload, -1 to disable, 0 for regclient.blobMaxPut
-	ReqPerSec     float64            `json:"reqPerSec,omitempty" yaml:"reqPerSec"`         // requests per second, default is defaultReqPerSec(10)
-	ReqConcurrent int64              `json:"reqConcurrent,omitempty" yaml:"reqConcurrent"` // concurrent requests, default is defaultConcurrent(3)
-	Scheme        string             `json:"scheme,omitempty" yaml:"scheme"`               // Deprecated: use TLS instead
-	credRefresh   time.Time          `json:"-" yaml:"-"`                                   // internal use, when to refresh credentials
-	throttle      *throttle.Throttle `json:"-" yaml:"-"`                                   // internal use, limit for concurrent requests
+	Name             string              `json:"-" yaml:"registry,omitempty"`                        // Name of the registry (required) (yaml configs pass this as a field, json provides this from the object key)
+	TLS              TLSConf             `json:"tls,omitempty" yaml:"tls"`                           // TLS setting: enabled (default), disabled, insecure
+	RegCert          string              `json:"regcert,omitempty" yaml:"regcert"`                   // public pem cert of registry
+	RegCertFile      string              `json:"regcertFile,omitempty" yaml:"regcertFile"`           // file with a CA bundle trusted for the registry, added to the system and regcert CAs
+	SPKIPins         []string            `json:"spkiPins,omitempty" yaml:"spkiPins"`                 // base64 sha256 hashes of the subject public key info, the registry certificate chain must include one
+	TLSMinVersion    string              `json:"tlsMinVersion,omitempty" yaml:"tlsMinVersion"`       // minimum TLS version: 1.0, 1.1, 1.2, or 1.3
+	ClientCert       string              `json:"clientCert,omitempty" yaml:"clientCert"`             // public pem cert for client (mTLS)
+	ClientKey        string              `json:"clientKey,omitempty" yaml:"clientKey"`               // private pem cert for client (mTLS)
+	ClientCertFile   string              `json:"clientCertFile,omitempty" yaml:"clientCertFile"`     // file with the public pem cert for client (mTLS), used when ClientCert is not set
+	ClientKeyFile    string              `json:"clientKeyFile,omitempty" yaml:"clientKeyFile"`       // file with the private pem key for client (mTLS), used when ClientKey is not set
+	ClientKeyPassEnv string              `json:"clientKeyPassEnv,omitempty" yaml:"clientKeyPassEnv"` // environment variable with the passphrase for an encrypted client key
+	Hostname         string              `json:"hostname,omitempty" yaml:"hostname"`                 // hostname of registry, default is the registry name
+	User             string              `json:"user,omitempty" yaml:"user"`                         // username, not used with credHelper
+	Pass             string              `json:"pass,omitempty" yaml:"pass"`                         // password, not used with credHelper
+	Token            string              `json:"token,omitempty" yaml:"token"`                       // token, experimental for specific APIs
+	ClientID         string              `json:"clientId,omitempty" yaml:"clientId"`                 // OAuth2 client id, used with ClientSecret for the client_credentials grant
+	ClientSecret     string              `json:"clientSecret,omitempty" yaml:"clientSecret"`         // OAuth2 client secret, used with the client_credentials grant
+	CredHelper       string              `json:"credHelper,omitempty" yaml:"credHelper"`             // credential helper command for requesting logins
+	CredProvider     string              `json:"credProvider,omitempty" yaml:"credProvider"`         // identity based credential provider: ecr, gcp, or github
+	CredExpire       timejson.Duration   `json:"credExpire,omitempty" yaml:"credExpire"`             // time until credential expires
+	CredHost         string              `json:"credHost" yaml:"credHost"`                           // used when a helper hostname doesn't match Hostname
+	PathPrefix       string              `json:"pathPrefix,omitempty" yaml:"pathPrefix"`             // used for mirrors defined within a repository namespace
+	Mirrors          []string            `json:"mirrors,omitempty" yaml:"mirrors"`                   // list of other Host Names to use as mirrors
+	Priority         uint                `json:"priority,omitempty" yaml:"priority"`                 // priority when sorting mirrors, higher priority attempted first
+	Weight           uint                `json:"weight,omitempty" yaml:"weight"`                     // relative weight for selecting between mirrors with the same priority, default is 1
+	MirrorBackoff    timejson.Duration   `json:"mirrorBackoff,omitempty" yaml:"mirrorBackoff"`       // time an unhealthy mirror is skipped after a failed request, default is 1m
+	RepoAuth         bool                `json:"repoAuth,omitempty" yaml:"repoAuth"`                 // tracks a separate auth per repo
+	RepoCreate       string              `json:"repoCreate,omitempty" yaml:"repoCreate"`             // creates repositories before the first push: ecr, or a name registered with the reg scheme
+	RepoList         string              `json:"repoList,omitempty" yaml:"repoList"`                 // lists repositories on registries without the _catalog API: dockerhub, ecr, gcr, or a name registered with the reg scheme
+	RepoDelete       string              `json:"repoDelete,omitempty" yaml:"repoDelete"`             // deletes repositories with a vendor API: artifactory, gitlab, harbor, or a name registered with the reg scheme
+	API              string              `json:"api,omitempty" yaml:"api"`                           // experimental: registry API to use
+	APIOpts          map[string]string   `json:"apiOpts,omitempty" yaml:"apiOpts"`                   // options for APIs
+	BlobChunk        int64               `json:"blobChunk,omitempty" yaml:"blobChunk"`               // size of each blob chunk
+	BlobMax          int64               `json:"blobMax,omitempty" yaml:"blobMax"`                   // threshold to switch to chunked upload, -1 to disable, 0 for regclient.blobMaxPut
+	DigestAlgorithm  string              `json:"digestAlgorithm,omitempty" yaml:"digestAlgorithm"`   // digest algorithm for new content: sha256 or sha512, default is set by the client
+	ReqPerSec        float64             `json:"reqPerSec,omitempty" yaml:"reqPerSec"`               // requests per second, default is defaultReqPerSec(10)
+	ReqConcurrent    int64               `json:"reqConcurrent,omitempty" yaml:"reqConcurrent"`       // concurrent requests, default is defaultConcurrent(3)
+	DownloadRate     int64               `json:"downloadRate,omitempty" yaml:"downloadRate"`         // bytes per second limit for downloads from the registry, default is unlimited
+	UploadRate       int64               `json:"uploadRate,omitempty" yaml:"uploadRate"`             // bytes per second limit for uploads to the registry, default is unlimited
+	RetryLimit       int                 `json:"retryLimit,omitempty" yaml:"retryLimit"`             // backoffs before a request to the host fails, default is set by the client
+	RetryDelayInit   timejson.Duration   `json:"retryDelayInit,omitempty" yaml:"retryDelayInit"`     // initial delay for an exponential backoff, default is set by the client
+	RetryDelayMax    timejson.Duration   `json:"retryDelayMax,omitempty" yaml:"retryDelayMax"`       // maximum delay for an exponential backoff, default is set by the client
+	RetryJitter      float64             `json:"retryJitter,omitempty" yaml:"retryJitter"`           // fraction of each backoff delay that is randomized, 0 to 1
+	RetryStatus      []int               `json:"retryStatus,omitempty" yaml:"retryStatus"`           // http status codes that are retried with a backoff, default is 408, 429, 500, and 504
+	RetryBudget      timejson.Duration   `json:"retryBudget,omitempty" yaml:"retryBudget"`           // maximum time for a request including retries, default has no limit
+	ConnectTimeout   timejson.Duration   `json:"connectTimeout,omitempty" yaml:"connectTimeout"`     // time to establish a connection, default is set by the http transport
+	TLSTimeout       timejson.Duration   `json:"tlsTimeout,omitempty" yaml:"tlsTimeout"`             // time to complete a TLS handshake, default is set by the http transport
+	HeaderTimeout    timejson.Duration   `json:"headerTimeout,omitempty" yaml:"headerTimeout"`       // time to receive the response headers after sending a request, default has no limit
+	RequestTimeout   timejson.Duration   `json:"requestTimeout,omitempty" yaml:"requestTimeout"`     // time for each request and response body, excluding blob transfers, default has no limit
+	BlobTimeoutRate  int64               `json:"blobTimeoutRate,omitempty" yaml:"blobTimeoutRate"`   // minimum bytes per second for blob transfers, each transfer is limited to requestTimeout plus the blob size at this rate, default has no limit
+	RedirectAuth     string              `json:"redirectAuth,omitempty" yaml:"redirectAuth"`         // Authorization header on cross-host redirects: RedirectAuthDefault, RedirectAuthStrip, or RedirectAuthKeep
+	RedirectMax      int                 `json:"redirectMax,omitempty" yaml:"redirectMax"`           // maximum redirects to follow, default is 10, -1 to disable
+	RedirectAllow    []string            `json:"redirectAllow,omitempty" yaml:"redirectAllow"`       // hosts allowed in a redirect, "*.example.com" matches subdomains, empty allows all hosts
+	RedirectDeny     []string            `json:"redirectDeny,omitempty" yaml:"redirectDeny"`         // hosts denied in a redirect, takes precedence over RedirectAllow
+	HTTPProxy        string              `json:"httpProxy,omitempty" yaml:"httpProxy"`               // proxy url for http requests (http, https, or socks5), default uses the environment
+	HTTPSProxy       string              `json:"httpsProxy,omitempty" yaml:"httpsProxy"`             // proxy url for https requests (http, https, or socks5), default uses the environment
+	NoProxy          []string            `json:"noProxy,omitempty" yaml:"noProxy"`                   // hosts connected directly without a proxy, "*" matches all hosts
+	IPFamily         string              `json:"ipFamily,omitempty" yaml:"ipFamily"`                 // IP version for connections: ipv4, ipv6, or empty for dual-stack
+	DNSServers       []string            `json:"dnsServers,omitempty" yaml:"dnsServers"`             // DNS servers (ip or ip:port) used instead of the system resolver
+	StaticHosts      map[string][]string `json:"staticHosts,omitempty" yaml:"staticHosts"`           // hostnames mapped to IP addresses, used instead of DNS
+	Scheme           string              `json:"scheme,omitempty" yaml:"scheme"`                     // Deprecated: use TLS instead
+	credRefresh      time.Time           `json:"-" yaml:"-"`                                         // internal use, when to refresh credentials
+	credErr          error               `json:"-" yaml:"-"`                                         // internal use, error from the credential provider
+	credECR          bool                `json:"-" yaml:"-"`                                         // internal use, credentials are from the ECR token exchange
+	throttle         *throttle.Throttle  `json:"-" yaml:"-"`                                         // internal use, limit for concurrent requests
 }
 
 // Cred defines a user credential for accessing a registry.
 type Cred struct {
-	User, Password, Token string
+	User, Password, Token  string
+	ClientID, ClientSecret string
 }
 
 // HostNew creates a default Host entry.
@@ -157,6 +239,7 @@ func HostNewName(name string) *Host {
 		h.Name = DockerRegistry
 		h.Hostname = DockerRegistryDNS
 		h.CredHost = DockerRegistryAuth
+		h.RepoList = RepoListDockerHub
 		return h
 	}
 	// handle http/https prefix
@@ -182,12 +265,37 @@ func HostNewName(name string) *Host {
 }
 
 // GetCred returns the credential, fetching from a credential helper if needed.
+// Use [Host.LoadCred] to see the error when the credential provider fails.
 func (host *Host) GetCred() Cred {
-	// refresh from credHelper if needed
-	if host.CredHelper != "" && (host.credRefresh.IsZero() || time.Now().After(host.credRefresh)) {
-		host.refreshHelper()
+	cred, _ := host.LoadCred()
+	return cred
+}
+
+// LoadCred returns the credential, fetching from a credential helper if needed.
+// The error from the credential provider is returned until the provider is retried.
+func (host *Host) LoadCred() (Cred, error) {
+	refresh := host.credRefresh.IsZero() || time.Now().After(host.credRefresh)
+	var err error
+	switch {
+	case host.CredProvider != "":
+		// request a login from the credential provider
+		if refresh {
+			err = host.refreshProvider()
+		} else {
+			err = host.credErr
+		}
+	case host.CredHelper != "":
+		// refresh from credHelper if needed
+		if refresh {
+			host.refreshHelper()
+		}
+	case host.credECR || (host.User == "" && host.Pass == "" && host.Token == ""):
+		// ECR hosts without a login request a token using the AWS credentials
+		if refresh {
+			host.refreshECR()
+		}
 	}
-	return Cred{User: host.User, Password: host.Pass, Token: host.Token}
+	return Cred{User: host.User, Password: host.Pass, Token: host.Token, ClientID: host.ClientID, ClientSecret: host.ClientSecret}, err
 }
 
 func (host *Host) refreshHelper() {
@@ -264,6 +372,26 @@ func (host *Host) Merge(newHost Host, log *logrus.Logger) error {
 		host.Token = newHost.Token
 	}
 
+	if newHost.ClientID != "" {
+		if host.ClientID != "" && host.ClientID != newHost.ClientID {
+			log.WithFields(logrus.Fields{
+				"orig": host.ClientID,
+				"new":  newHost.ClientID,
+				"host": name,
+			}).Warn("Changing OAuth client id for registry")
+		}
+		host.ClientID = newHost.ClientID
+	}
+
+	if newHost.ClientSecret != "" {
+		if host.ClientSecret != "" && host.ClientSecret != newHost.ClientSecret {
+			log.WithFields(logrus.Fields{
+				"host": name,
+			}).Warn("Changing OAuth client secret for registry")
+		}
+		host.ClientSecret = newHost.ClientSecret
+	}
+
 	if newHost.CredHelper != "" {
 		if host.CredHelper != "" && host.CredHelper != newHost.CredHelper {
 			log.WithFields(logrus.Fields{
@@ -275,6 +403,23 @@ func (host *Host) Merge(newHost Host, log *logrus.Logger) error {
 		host.CredHelper = newHost.CredHelper
 	}
 
+	if newHost.CredProvider != "" {
+		if host.CredProvider != "" && host.CredProvider != newHost.CredProvider {
+			log.WithFields(logrus.Fields{
+				"host": name,
+				"orig": host.CredProvider,
+				"new":  newHost.CredProvider,
+			}).Warn("Changing credential provider for registry")
+		}
+		if !credProviderValid(newHost.CredProvider) {
+			log.WithFields(logrus.Fields{
+				"host":     name,
+				"provider": newHost.CredProvider,
+			}).Warn("Unknown credential provider for registry")
+		}
+		host.CredProvider = newHost.CredProvider
+	}
+
 	if newHost.CredExpire != 0 {
 		if host.CredExpire != 0 && host.CredExpire != newHost.CredExpire {
 			log.WithFields(logrus.Fields{
@@ -321,6 +466,39 @@ func (host *Host) Merge(newHost Host, log *logrus.Logger) error {
 		host.RegCert = newHost.RegCert
 	}
 
+	if newHost.RegCertFile != "" {
+		if host.RegCertFile != "" && host.RegCertFile != newHost.RegCertFile {
+			log.WithFields(logrus.Fields{
+				"orig": host.RegCertFile,
+				"new":  newHost.RegCertFile,
+				"host": name,
+			}).Warn("Changing certificate file for registry")
+		}
+		host.RegCertFile = newHost.RegCertFile
+	}
+
+	if len(newHost.SPKIPins) > 0 {
+		if len(host.SPKIPins) > 0 && !stringSliceEq(host.SPKIPins, newHost.SPKIPins) {
+			log.WithFields(logrus.Fields{
+				"orig": host.SPKIPins,
+				"new":  newHost.SPKIPins,
+				"host": name,
+			}).Warn("Changing SPKI pins for registry")
+		}
+		host.SPKIPins = newHost.SPKIPins
+	}
+
+	if newHost.TLSMinVersion != "" {
+		if host.TLSMinVersion != "" && host.TLSMinVersion != newHost.TLSMinVersion {
+			log.WithFields(logrus.Fields{
+				"orig": host.TLSMinVersion,
+				"new":  newHost.TLSMinVersion,
+				"host": name,
+			}).Warn("Changing minimum TLS version for registry")
+		}
+		host.TLSMinVersion = newHost.TLSMinVersion
+	}
+
 	if newHost.ClientCert != "" {
 		if host.ClientCert != "" && host.ClientCert != newHost.ClientCert {
 			log.WithFields(logrus.Fields{
@@ -341,6 +519,39 @@ func (host *Host) Merge(newHost Host, log *logrus.Logger) error {
 		host.ClientKey = newHost.ClientKey
 	}
 
+	if newHost.ClientCertFile != "" {
+		if host.ClientCertFile != "" && host.ClientCertFile != newHost.ClientCertFile {
+			log.WithFields(logrus.Fields{
+				"orig": host.ClientCertFile,
+				"new":  newHost.ClientCertFile,
+				"host": name,
+			}).Warn("Changing client certificate file for registry")
+		}
+		host.ClientCertFile = newHost.ClientCertFile
+	}
+
+	if newHost.ClientKeyFile != "" {
+		if host.ClientKeyFile != "" && host.ClientKeyFile != newHost.ClientKeyFile {
+			log.WithFields(logrus.Fields{
+				"orig": host.ClientKeyFile,
+				"new":  newHost.ClientKeyFile,
+				"host": name,
+			}).Warn("Changing client key file for registry")
+		}
+		host.ClientKeyFile = newHost.ClientKeyFile
+	}
+
+	if newHost.ClientKeyPassEnv != "" {
+		if host.ClientKeyPassEnv != "" && host.ClientKeyPassEnv != newHost.ClientKeyPassEnv {
+			log.WithFields(logrus.Fields{
+				"orig": host.ClientKeyPassEnv,
+				"new":  newHost.ClientKeyPassEnv,
+				"host": name,
+			}).Warn("Changing client key passphrase variable for registry")
+		}
+		host.ClientKeyPassEnv = newHost.ClientKeyPassEnv
+	}
+
 	if newHost.Hostname != "" {
 		if host.Hostname != "" && host.Hostname != newHost.Hostname {
 			log.WithFields(logrus.Fields{
@@ -386,10 +597,65 @@ func (host *Host) Merge(newHost Host, log *logrus.Logger) error {
 		host.Priority = newHost.Priority
 	}
 
+	if newHost.Weight != 0 {
+		if host.Weight != 0 && host.Weight != newHost.Weight {
+			log.WithFields(logrus.Fields{
+				"orig": host.Weight,
+				"new":  newHost.Weight,
+				"host": name,
+			}).Warn("Changing weight settings for registry")
+		}
+		host.Weight = newHost.Weight
+	}
+
+	if newHost.MirrorBackoff > 0 {
+		if host.MirrorBackoff != 0 && host.MirrorBackoff != newHost.MirrorBackoff {
+			log.WithFields(logrus.Fields{
+				"orig": time.Duration(host.MirrorBackoff).String(),
+				"new":  time.Duration(newHost.MirrorBackoff).String(),
+				"host": name,
+			}).Warn("Changing mirrorBackoff settings for registry")
+		}
+		host.MirrorBackoff = newHost.MirrorBackoff
+	}
+
 	if newHost.RepoAuth {
 		host.RepoAuth = newHost.RepoAuth
 	}
 
+	if newHost.RepoCreate != "" {
+		if host.RepoCreate != "" && host.RepoCreate != newHost.RepoCreate {
+			log.WithFields(logrus.Fields{
+				"orig": host.RepoCreate,
+				"new":  newHost.RepoCreate,
+				"host": name,
+			}).Warn("Changing repository creator for registry")
+		}
+		host.RepoCreate = newHost.RepoCreate
+	}
+
+	if newHost.RepoList != "" {
+		if host.RepoList != "" && host.RepoList != newHost.RepoList {
+			log.WithFields(logrus.Fields{
+				"orig": host.RepoList,
+				"new":  newHost.RepoList,
+				"host": name,
+			}).Warn("Changing repository lister for registry")
+		}
+		host.RepoList = newHost.RepoList
+	}
+
+	if newHost.RepoDelete != "" {
+		if host.RepoDelete != "" && host.RepoDelete != newHost.RepoDelete {
+			log.WithFields(logrus.Fields{
+				"orig": host.RepoDelete,
+				"new":  newHost.RepoDelete,
+				"host": name,
+			}).Warn("Changing repository deleter for registry")
+		}
+		host.RepoDelete = newHost.RepoDelete
+	}
+
 	if newHost.API != "" {
 		if host.API != "" && host.API != newHost.API {
 			log.WithFields(logrus.Fields{
@@ -443,6 +709,17 @@ func (host *Host) Merge(newHost Host, log *logrus.Logger) error {
 		host.BlobMax = newHost.BlobMax
 	}
 
+	if newHost.DigestAlgorithm != "" {
+		if host.DigestAlgorithm != "" && host.DigestAlgorithm != newHost.DigestAlgorithm {
+			log.WithFields(logrus.Fields{
+				"orig": host.DigestAlgorithm,
+				"new":  newHost.DigestAlgorithm,
+				"host": name,
+			}).Warn("Changing digestAlgorithm settings for registry")
+		}
+		host.DigestAlgorithm = newHost.DigestAlgorithm
+	}
+
 	if newHost.ReqPerSec > 0 {
 		if host.ReqPerSec != 0 && host.ReqPerSec != newHost.ReqPerSec {
 			log.WithFields(logrus.Fields{
@@ -473,6 +750,267 @@ func (host *Host) Merge(newHost Host, log *logrus.Logger) error {
 		host.ReqConcurrent = newHost.ReqConcurrent
 	}
 
+	if newHost.DownloadRate > 0 {
+		if host.DownloadRate != 0 && host.DownloadRate != newHost.DownloadRate {
+			log.WithFields(logrus.Fields{
+				"orig": host.DownloadRate,
+				"new":  newHost.DownloadRate,
+				"host": name,
+			}).Warn("Changing downloadRate settings for registry")
+		}
+		host.DownloadRate = newHost.DownloadRate
+	}
+
+	if newHost.UploadRate > 0 {
+		if host.UploadRate != 0 && host.UploadRate != newHost.UploadRate {
+			log.WithFields(logrus.Fields{
+				"orig": host.UploadRate,
+				"new":  newHost.UploadRate,
+				"host": name,
+			}).Warn("Changing uploadRate settings for registry")
+		}
+		host.UploadRate = newHost.UploadRate
+	}
+
+	if newHost.RedirectAuth != "" {
+		if host.RedirectAuth != "" && host.RedirectAuth != newHost.RedirectAuth {
+			log.WithFields(logrus.Fields{
+				"orig": host.RedirectAuth,
+				"new":  newHost.RedirectAuth,
+				"host": name,
+			}).Warn("Changing redirectAuth settings for registry")
+		}
+		host.RedirectAuth = newHost.RedirectAuth
+	}
+
+	if newHost.RedirectMax != 0 {
+		if host.RedirectMax != 0 && host.RedirectMax != newHost.RedirectMax {
+			log.WithFields(logrus.Fields{
+				"orig": host.RedirectMax,
+				"new":  newHost.RedirectMax,
+				"host": name,
+			}).Warn("Changing redirectMax settings for registry")
+		}
+		host.RedirectMax = newHost.RedirectMax
+	}
+
+	if len(newHost.RedirectAllow) > 0 {
+		if len(host.RedirectAllow) > 0 && !stringSliceEq(host.RedirectAllow, newHost.RedirectAllow) {
+			log.WithFields(logrus.Fields{
+				"orig": host.RedirectAllow,
+				"new":  newHost.RedirectAllow,
+				"host": name,
+			}).Warn("Changing redirectAllow settings for registry")
+		}
+		host.RedirectAllow = newHost.RedirectAllow
+	}
+
+	if len(newHost.RedirectDeny) > 0 {
+		if len(host.RedirectDeny) > 0 && !stringSliceEq(host.RedirectDeny, newHost.RedirectDeny) {
+			log.WithFields(logrus.Fields{
+				"orig": host.RedirectDeny,
+				"new":  newHost.RedirectDeny,
+				"host": name,
+			}).Warn("Changing redirectDeny settings for registry")
+		}
+		host.RedirectDeny = newHost.RedirectDeny
+	}
+
+	if newHost.RetryLimit > 0 {
+		if host.RetryLimit != 0 && host.RetryLimit != newHost.RetryLimit {
+			log.WithFields(logrus.Fields{
+				"orig": host.RetryLimit,
+				"new":  newHost.RetryLimit,
+				"host": name,
+			}).Warn("Changing retryLimit settings for registry")
+		}
+		host.RetryLimit = newHost.RetryLimit
+	}
+
+	if newHost.RetryDelayInit > 0 {
+		if host.RetryDelayInit != 0 && host.RetryDelayInit != newHost.RetryDelayInit {
+			log.WithFields(logrus.Fields{
+				"orig": time.Duration(host.RetryDelayInit).String(),
+				"new":  time.Duration(newHost.RetryDelayInit).String(),
+				"host": name,
+			}).Warn("Changing retryDelayInit settings for registry")
+		}
+		host.RetryDelayInit = newHost.RetryDelayInit
+	}
+
+	if newHost.RetryDelayMax > 0 {
+		if host.RetryDelayMax != 0 && host.RetryDelayMax != newHost.RetryDelayMax {
+			log.WithFields(logrus.Fields{
+				"orig": time.Duration(host.RetryDelayMax).String(),
+				"new":  time.Duration(newHost.RetryDelayMax).String(),
+				"host": name,
+			}).Warn("Changing retryDelayMax settings for registry")
+		}
+		host.RetryDelayMax = newHost.RetryDelayMax
+	}
+
+	if newHost.RetryJitter > 0 {
+		if host.RetryJitter != 0 && host.RetryJitter != newHost.RetryJitter {
+			log.WithFields(logrus.Fields{
+				"orig": host.RetryJitter,
+				"new":  newHost.RetryJitter,
+				"host": name,
+			}).Warn("Changing retryJitter settings for registry")
+		}
+		host.RetryJitter = newHost.RetryJitter
+	}
+
+	if len(newHost.RetryStatus) > 0 {
+		if len(host.RetryStatus) > 0 && !intSliceEq(host.RetryStatus, newHost.RetryStatus) {
+			log.WithFields(logrus.Fields{
+				"orig": host.RetryStatus,
+				"new":  newHost.RetryStatus,
+				"host": name,
+			}).Warn("Changing retryStatus settings for registry")
+		}
+		host.RetryStatus = newHost.RetryStatus
+	}
+
+	if newHost.RetryBudget > 0 {
+		if host.RetryBudget != 0 && host.RetryBudget != newHost.RetryBudget {
+			log.WithFields(logrus.Fields{
+				"orig": time.Duration(host.RetryBudget).String(),
+				"new":  time.Duration(newHost.RetryBudget).String(),
+				"host": name,
+			}).Warn("Changing retryBudget settings for registry")
+		}
+		host.RetryBudget = newHost.RetryBudget
+	}
+
+	if newHost.ConnectTimeout > 0 {
+		if host.ConnectTimeout != 0 && host.ConnectTimeout != newHost.ConnectTimeout {
+			log.WithFields(logrus.Fields{
+				"orig": time.Duration(host.ConnectTimeout).String(),
+				"new":  time.Duration(newHost.ConnectTimeout).String(),
+				"host": name,
+			}).Warn("Changing connectTimeout settings for registry")
+		}
+		host.ConnectTimeout = newHost.ConnectTimeout
+	}
+
+	if newHost.TLSTimeout > 0 {
+		if host.TLSTimeout != 0 && host.TLSTimeout != newHost.TLSTimeout {
+			log.WithFields(logrus.Fields{
+				"orig": time.Duration(host.TLSTimeout).String(),
+				"new":  time.Duration(newHost.TLSTimeout).String(),
+				"host": name,
+			}).Warn("Changing tlsTimeout settings for registry")
+		}
+		host.TLSTimeout = newHost.TLSTimeout
+	}
+
+	if newHost.HeaderTimeout > 0 {
+		if host.HeaderTimeout != 0 && host.HeaderTimeout != newHost.HeaderTimeout {
+			log.WithFields(logrus.Fields{
+				"orig": time.Duration(host.HeaderTimeout).String(),
+				"new":  time.Duration(newHost.HeaderTimeout).String(),
+				"host": name,
+			}).Warn("Changing headerTimeout settings for registry")
+		}
+		host.HeaderTimeout = newHost.HeaderTimeout
+	}
+
+	if newHost.RequestTimeout > 0 {
+		if host.RequestTimeout != 0 && host.RequestTimeout != newHost.RequestTimeout {
+			log.WithFields(logrus.Fields{
+				"orig": time.Duration(host.RequestTimeout).String(),
+				"new":  time.Duration(newHost.RequestTimeout).String(),
+				"host": name,
+			}).Warn("Changing requestTimeout settings for registry")
+		}
+		host.RequestTimeout = newHost.RequestTimeout
+	}
+
+	if newHost.BlobTimeoutRate > 0 {
+		if host.BlobTimeoutRate != 0 && host.BlobTimeoutRate != newHost.BlobTimeoutRate {
+			log.WithFields(logrus.Fields{
+				"orig": host.BlobTimeoutRate,
+				"new":  newHost.BlobTimeoutRate,
+				"host": name,
+			}).Warn("Changing blobTimeoutRate settings for registry")
+		}
+		host.BlobTimeoutRate = newHost.BlobTimeoutRate
+	}
+
+	if newHost.HTTPProxy != "" {
+		if host.HTTPProxy != "" && host.HTTPProxy != newHost.HTTPProxy {
+			log.WithFields(logrus.Fields{
+				"orig": host.HTTPProxy,
+				"new":  newHost.HTTPProxy,
+				"host": name,
+			}).Warn("Changing httpProxy settings for registry")
+		}
+		host.HTTPProxy = newHost.HTTPProxy
+	}
+
+	if newHost.HTTPSProxy != "" {
+		if host.HTTPSProxy != "" && host.HTTPSProxy != newHost.HTTPSProxy {
+			log.WithFields(logrus.Fields{
+				"orig": host.HTTPSProxy,
+				"new":  newHost.HTTPSProxy,
+				"host": name,
+			}).Warn("Changing httpsProxy settings for registry")
+		}
+		host.HTTPSProxy = newHost.HTTPSProxy
+	}
+
+	if len(newHost.NoProxy) > 0 {
+		if len(host.NoProxy) > 0 && !stringSliceEq(host.NoProxy, newHost.NoProxy) {
+			log.WithFields(logrus.Fields{
+				"orig": host.NoProxy,
+				"new":  newHost.NoProxy,
+				"host": name,
+			}).Warn("Changing noProxy settings for registry")
+		}
+		host.NoProxy = newHost.NoProxy
+	}
+
+	if newHost.IPFamily != "" {
+		if host.IPFamily != "" && host.IPFamily != newHost.IPFamily {
+			log.WithFields(logrus.Fields{
+				"orig": host.IPFamily,
+				"new":  newHost.IPFamily,
+				"host": name,
+			}).Warn("Changing ipFamily settings for registry")
+		}
+		host.IPFamily = newHost.IPFamily
+	}
+
+	if len(newHost.DNSServers) > 0 {
+		if len(host.DNSServers) > 0 && !stringSliceEq(host.DNSServers, newHost.DNSServers) {
+			log.WithFields(logrus.Fields{
+				"orig": host.DNSServers,
+				"new":  newHost.DNSServers,
+				"host": name,
+			}).Warn("Changing dnsServers settings for registry")
+		}
+		host.DNSServers = newHost.DNSServers
+	}
+
+	if len(newHost.StaticHosts) > 0 {
+		merged := map[string][]string{}
+		for k, v := range host.StaticHosts {
+			merged[k] = v
+		}
+		for k, v := range newHost.StaticHosts {
+			if len(merged[k]) > 0 && !stringSliceEq(merged[k], v) {
+				log.WithFields(logrus.Fields{
+					"orig":     merged[k],
+					"new":      v,
+					"hostname": k,
+					"host":     name,
+				}).Warn("Changing staticHosts setting for registry")
+			}
+			merged[k] = v
+		}
+		host.StaticHosts = merged
+	}
+
 	return nil
 }
 
@@ -507,3 +1045,15 @@ func stringSliceEq(a, b []string) bool {
 	}
 	return true
 }
+
+func intSliceEq(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if v != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -119,6 +119,8 @@ func TestConfig(t *testing.T) {
 		"hostname": "host2.example.com",
 		"user": "user-ex3",
 		"pass": "secret3",
+		"clientId": "client-ex3",
+		"clientSecret": "client-secret3",
 		"regcert": "` + strings.ReplaceAll(caCert, "\n", "\\n") + `",
 		"clientCert": "` + strings.ReplaceAll(clientCert, "\n", "\\n") + `",
 		"clientKey": "` + strings.ReplaceAll(clientKey, "\n", "\\n") + `",
@@ -229,23 +231,27 @@ func TestConfig(t *testing.T) {
 			name: "exHost2",
 			host: exHost2,
 			hostExpect: Host{
-				TLS:        TLSDisabled,
-				Hostname:   "host2.example.com",
-				User:       "user-ex3",
-				Pass:       "secret3",
-				RegCert:    caCert,
-				ClientCert: clientCert,
-				ClientKey:  clientKey,
-				PathPrefix: "hub3",
-				Mirrors:    []string{"testhost.example.com"},
-				Priority:   42,
-				APIOpts:    map[string]string{"disableHead": "false", "unknownOpt": "3"},
-				BlobChunk:  333333,
-				BlobMax:    333333,
+				TLS:          TLSDisabled,
+				Hostname:     "host2.example.com",
+				User:         "user-ex3",
+				Pass:         "secret3",
+				ClientID:     "client-ex3",
+				ClientSecret: "client-secret3",
+				RegCert:      caCert,
+				ClientCert:   clientCert,
+				ClientKey:    clientKey,
+				PathPrefix:   "hub3",
+				Mirrors:      []string{"testhost.example.com"},
+				Priority:     42,
+				APIOpts:      map[string]string{"disableHead": "false", "unknownOpt": "3"},
+				BlobChunk:    333333,
+				BlobMax:      333333,
 			},
 			credExpect: Cred{
-				User:     "user-ex3",
-				Password: "secret3",
+				User:         "user-ex3",
+				Password:     "secret3",
+				ClientID:     "client-ex3",
+				ClientSecret: "client-secret3",
 			},
 		},
 		{
@@ -287,23 +293,27 @@ func TestConfig(t *testing.T) {
 			name: "mergeHost2",
 			host: exMergeHost2,
 			hostExpect: Host{
-				TLS:        TLSDisabled,
-				Hostname:   "host2.example.com",
-				User:       "user-ex3",
-				Pass:       "secret3",
-				RegCert:    caCert,
-				ClientCert: clientCert,
-				ClientKey:  clientKey,
-				PathPrefix: "hub3",
-				Mirrors:    []string{"testhost.example.com"},
-				Priority:   42,
-				APIOpts:    map[string]string{"disableHead": "false", "unknownOpt": "3"},
-				BlobChunk:  333333,
-				BlobMax:    333333,
+				TLS:          TLSDisabled,
+				Hostname:     "host2.example.com",
+				User:         "user-ex3",
+				Pass:         "secret3",
+				ClientID:     "client-ex3",
+				ClientSecret: "client-secret3",
+				RegCert:      caCert,
+				ClientCert:   clientCert,
+				ClientKey:    clientKey,
+				PathPrefix:   "hub3",
+				Mirrors:      []string{"testhost.example.com"},
+				Priority:     42,
+				APIOpts:      map[string]string{"disableHead": "false", "unknownOpt": "3"},
+				BlobChunk:    333333,
+				BlobMax:      333333,
 			},
 			credExpect: Cred{
-				User:     "user-ex3",
-				Password: "secret3",
+				User:         "user-ex3",
+				Password:     "secret3",
+				ClientID:     "client-ex3",
+				ClientSecret: "client-secret3",
 			},
 		},
 		{
@@ -392,6 +402,12 @@ func TestConfig(t *testing.T) {
 			if tc.host.Token != tc.hostExpect.Token {
 				t.Errorf("token field mismatch, expected %s, found %s", tc.hostExpect.Token, tc.host.Token)
 			}
+			if tc.host.ClientID != tc.hostExpect.ClientID {
+				t.Errorf("clientId field mismatch, expected %s, found %s", tc.hostExpect.ClientID, tc.host.ClientID)
+			}
+			if tc.host.ClientSecret != tc.hostExpect.ClientSecret {
+				t.Errorf("clientSecret field mismatch, expected %s, found %s", tc.hostExpect.ClientSecret, tc.host.ClientSecret)
+			}
 			if tc.host.CredHelper != tc.hostExpect.CredHelper {
 				t.Errorf("credHelper field mismatch, expected %s, found %s", tc.hostExpect.CredHelper, tc.host.CredHelper)
 			}
@@ -438,6 +454,12 @@ func TestConfig(t *testing.T) {
 			if tc.credExpect.Token != cred.Token {
 				t.Errorf("cred token field mismatch, expected %s, found %s", tc.credExpect.Token, cred.Token)
 			}
+			if tc.credExpect.ClientID != cred.ClientID {
+				t.Errorf("cred clientId field mismatch, expected %s, found %s", tc.credExpect.ClientID, cred.ClientID)
+			}
+			if tc.credExpect.ClientSecret != cred.ClientSecret {
+				t.Errorf("cred clientSecret field mismatch, expected %s, found %s", tc.credExpect.ClientSecret, cred.ClientSecret)
+			}
 		})
 	}
 }
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// spkiPrefix is the optional prefix on SPKI pins, e.g. "sha256/<base64 hash>".
+const spkiPrefix = "sha256/"
+
+// TLSVersion parses a TLS version ("1.0", "1.1", "1.2", or "1.3") into the [tls] version constant.
+// An empty string returns 0 to use the Go default.
+func TLSVersion(v string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(v), "tls") {
+	case "":
+		return 0, nil
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unknown TLS version \"%s\"", v)
+	}
+}
+
+// SPKIHash returns the pin for a certificate, the base64 encoded sha256 hash of the subject public key info.
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return spkiPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SPKIPinsVerify returns a function for [tls.Config.VerifyConnection] that requires a certificate in the verified chain to match a pin.
+// When certificate verification is skipped, only the leaf certificate may match a pin,
+// since any other certificates sent by the server have not been verified.
+// Pins are the base64 sha256 hash of the subject public key info, with an optional "sha256/" prefix.
+func SPKIPinsVerify(pins []string) (func(tls.ConnectionState) error, error) {
+	pinSet := map[string]bool{}
+	for _, p := range pins {
+		// accept "sha256/<hash>", "sha256//<hash>" (HPKP style), or the bare hash
+		hash := p
+		if strings.HasPrefix(hash, spkiPrefix) {
+			hash = strings.TrimPrefix(strings.TrimPrefix(hash, spkiPrefix), "/")
+		}
+		b, err := base64.StdEncoding.DecodeString(hash)
+		if err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid SPKI pin \"%s\"", p)
+		}
+		pinSet[spkiPrefix+hash] = true
+	}
+	return func(cs tls.ConnectionState) error {
+		for _, chain := range cs.VerifiedChains {
+			for _, cert := range chain {
+				if pinSet[SPKIHash(cert)] {
+					return nil
+				}
+			}
+		}
+		if len(cs.VerifiedChains) == 0 && len(cs.PeerCertificates) > 0 && pinSet[SPKIHash(cs.PeerCertificates[0])] {
+			return nil
+		}
+		return fmt.Errorf("certificate for %s does not match a pinned public key", cs.ServerName)
+	}, nil
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestTLSVersion(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		in        string
+		expect    uint16
+		expectErr bool
+	}{
+		{in: "", expect: 0},
+		{in: "1.0", expect: tls.VersionTLS10},
+		{in: "1.1", expect: tls.VersionTLS11},
+		{in: "1.2", expect: tls.VersionTLS12},
+		{in: "TLS1.3", expect: tls.VersionTLS13},
+		{in: "13", expect: tls.VersionTLS13},
+		{in: "2.0", expectErr: true},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.in, func(t *testing.T) {
+			v, err := TLSVersion(tc.in)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v != tc.expect {
+				t.Errorf("unexpected version, expected %x, received %x", tc.expect, v)
+			}
+		})
+	}
+}
+
+func TestSPKIPinsVerify(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name      string
+		pins      []string
+		expectErr bool
+	}{
+		{name: "prefix", pins: []string{"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+		{name: "double slash", pins: []string{"sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+		{name: "no prefix", pins: []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+		{name: "invalid base64", pins: []string{"sha256/not-base64!"}, expectErr: true},
+		{name: "short hash", pins: []string{"sha256/AAAA"}, expectErr: true},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			verify, err := SPKIPinsVerify(tc.pins)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// no certificates should never match a pin
+			if err := verify(tls.ConnectionState{}); err == nil {
+				t.Errorf("verify without certificates did not fail")
+			}
+		})
+	}
+}
+
+func TestSPKIPinsChain(t *testing.T) {
+	t.Parallel()
+	newCert := func(name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		t.Helper()
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             time.Now().Add(-1 * time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  parent == nil,
+			BasicConstraintsValid: true,
+		}
+		if parent == nil {
+			parent, parentKey = tmpl, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("failed to parse certificate: %v", err)
+		}
+		return cert, key
+	}
+	ca, caKey := newCert("trusted ca", nil, nil)
+	leaf, _ := newCert("registry", ca, caKey)
+	pinned, _ := newCert("pinned", nil, nil)
+	verify, err := SPKIPinsVerify([]string{SPKIHash(pinned)})
+	if err != nil {
+		t.Fatalf("failed to setup pins: %v", err)
+	}
+	tt := []struct {
+		name      string
+		cs        tls.ConnectionState
+		expectErr bool
+	}{
+		{
+			name: "pinned cert with an untrusted chain",
+			cs: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{leaf, pinned},
+				VerifiedChains:   [][]*x509.Certificate{{leaf, ca}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "pinned cert in the verified chain",
+			cs: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{pinned},
+				VerifiedChains:   [][]*x509.Certificate{{pinned}},
+			},
+		},
+		{
+			name: "insecure with a pinned intermediate",
+			cs: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{leaf, pinned},
+			},
+			expectErr: true,
+		},
+		{
+			name: "insecure with a pinned leaf",
+			cs: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{pinned, ca},
+			},
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := verify(tc.cs)
+			if tc.expectErr && err == nil {
+				t.Errorf("did not fail")
+			} else if !tc.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
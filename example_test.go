@@ -0,0 +1,88 @@
+package regclient_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/pkg/regtest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func ExampleNew() {
+	// a client using the docker credentials with a custom user agent
+	rc := regclient.New(
+		regclient.WithDockerCreds(),
+		regclient.WithUserAgent("example/1.0"),
+	)
+	_ = rc
+}
+
+func ExampleRegClient_ImageCopy() {
+	ctx := context.Background()
+	// start an in-memory registry for the example
+	ts := httptest.NewServer(regtest.New())
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	rc := regclient.New(regclient.WithConfigHost(config.Host{
+		Name:     u.Host,
+		Hostname: u.Host,
+		TLS:      config.TLSDisabled,
+	}))
+
+	// copy an image from an OCI Layout to the registry
+	rSrc, err := ref.New("ocidir://testdata/testrepo:v1")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	rTgt, err := ref.New(u.Host + "/example/repo:v1")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	err = rc.ImageCopy(ctx, rSrc, rTgt)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	mh, err := rc.ManifestHead(ctx, rTgt, regclient.WithManifestRequireDigest())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(mh.GetDescriptor().Digest)
+	// Output: sha256:0d4ea07d06d42ad1cb756a5d9e71f62288e426b22a230b224410164181998c4c
+}
+
+func ExampleRegClient_TagList() {
+	ctx := context.Background()
+	rc := regclient.New()
+	r, err := ref.New("ocidir://testdata/testrepo")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, t := range tags {
+		if t == "v1" || t == "v2" || t == "v3" {
+			fmt.Println(t)
+		}
+	}
+	// Output:
+	// v1
+	// v2
+	// v3
+}
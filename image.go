@@ -2,14 +2,19 @@ package regclient
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"math/rand"
 	"net/url"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -21,7 +26,11 @@ import (
 	digest "github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 
+	"github.com/regclient/regclient/internal/history"
+	"github.com/regclient/regclient/internal/timejson"
 	"github.com/regclient/regclient/pkg/archive"
+	"github.com/regclient/regclient/pkg/checkpoint"
+	"github.com/regclient/regclient/pkg/trace"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/docker/schema2"
@@ -33,6 +42,7 @@ import (
 )
 
 const (
+	checksumsDigestPrefix  = "# digest:"
 	dockerManifestFilename = "manifest.json"
 	ociLayoutVersion       = "1.0.0"
 	ociIndexFilename       = "index.json"
@@ -68,30 +78,51 @@ type tarReadData struct {
 	dockerManifest      schema2.Manifest
 }
 type tarWriteData struct {
-	tw    *tar.Writer
-	dirs  map[string]bool
-	files map[string]bool
+	tw        *tar.Writer
+	dirs      map[string]bool
+	files     map[string]bool
+	sums      []tarFileSum // checksums of each file, only tracked when sumEnable is set
+	sumEnable bool
 	// uid, gid  int
 	mode      int64
 	timestamp time.Time
 }
 
+// tarFileSum is the sha256 digest of a file in the tar.
+type tarFileSum struct {
+	name     string
+	digester digest.Digester
+}
+
 type imageOpt struct {
+	blobVerify      int
+	blobVerifyRand  *rand.Rand
 	callback        func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)
 	checkBaseDigest string
 	checkBaseRef    string
 	checkSkipConfig bool
+	checkpoint      checkpoint.Checkpoint
 	child           bool
+	exportChecksums io.Writer
 	exportCompress  bool
 	exportRef       ref.Ref
 	fastCheck       bool
 	forceRecursive  bool
+	formatConvert   string
+	formatConverted map[digest.Digest]types.Descriptor
+	importChecksums io.Reader
 	importName      string
 	includeExternal bool
 	digestTags      bool
+	dryRun          bool
 	platform        string
 	platforms       []string
+	preCopyHooks    []ImageHook
+	prePushHooks    []ImageHook
+	rebaseNew       ref.Ref
+	rebaseOld       ref.Ref
 	referrerConfs   []scheme.ReferrerConfig
+	report          *ImageCopyReport
 	tagList         []string
 	mu              sync.Mutex
 	seen            map[string]*imageSeen
@@ -106,6 +137,23 @@ type imageSeen struct {
 // ImageOpts define options for the Image* commands.
 type ImageOpts func(*imageOpt)
 
+// ImageHook is called with each manifest in ImageCopy, e.g. to integrate a vulnerability scanner or policy engine.
+// Returning an error stops the copy.
+type ImageHook func(ctx context.Context, refSrc, refTgt ref.Ref, m manifest.Manifest) error
+
+// ImageWithBlobVerify reads and hashes blobs that already exist in the target in ImageCopy.
+// The percent (1-100) selects a random sample of blobs to verify, detecting silent corruption in a mirror.
+// Blobs that fail verification are copied again.
+// Manifests that already exist in the target are recursively checked, similar to [ImageWithForceRecursive].
+func ImageWithBlobVerify(percent int) ImageOpts {
+	return func(opts *imageOpt) {
+		if percent > 100 {
+			percent = 100
+		}
+		opts.blobVerify = percent
+	}
+}
+
 // ImageWithCallback provides progress data to a callback function.
 func ImageWithCallback(callback func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)) ImageOpts {
 	return func(opts *imageOpt) {
@@ -113,6 +161,16 @@ func ImageWithCallback(callback func(kind types.CallbackKind, instance string, s
 	}
 }
 
+// ImageWithCheckpoint records the blobs and manifests copied to the target in ImageCopy, allowing an interrupted copy to resume.
+// Content found in the checkpoint is skipped without a request to the target.
+// Manifests are only skipped when pushed by digest, and when referrers, digest tags, and force recursive are not enabled.
+// Blobs are always checked when [ImageWithBlobVerify] is set.
+func ImageWithCheckpoint(cp checkpoint.Checkpoint) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.checkpoint = cp
+	}
+}
+
 // ImageWithCheckBaseDigest provides a base digest to compare in ImageCheckBase.
 func ImageWithCheckBaseDigest(d string) ImageOpts {
 	return func(opts *imageOpt) {
@@ -141,6 +199,22 @@ func ImageWithChild() ImageOpts {
 	}
 }
 
+// ImageWithDryRun reports changes without pushing them in ImageCopyReport and ImageIndexDedupe.
+func ImageWithDryRun() ImageOpts {
+	return func(opts *imageOpt) {
+		opts.dryRun = true
+	}
+}
+
+// ImageWithExportChecksums writes a checksums file for the tar generated by ImageExport.
+// Each line contains the sha256 of a file in the tar in the format of the sha256sum command.
+// A "# digest:" comment line includes the digest of the exported manifest.
+func ImageWithExportChecksums(w io.Writer) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.exportChecksums = w
+	}
+}
+
 // ImageWithExportCompress adds gzip compression to tar export output in ImageExport.
 func ImageWithExportCompress() ImageOpts {
 	return func(opts *imageOpt) {
@@ -169,6 +243,25 @@ func ImageWithForceRecursive() ImageOpts {
 	}
 }
 
+// ImageWithFormatConvert converts the media types of manifests and configs to "oci" or "docker" in ImageCopy.
+// A Docker manifest list becomes an OCI index, Docker images become OCI images, and the reverse for "docker".
+// Parent manifests are rewritten with the digests of converted children, and unchanged manifests keep their digest.
+// Referrers and digest tags of a converted manifest are not copied since they refer to the source digest.
+// Artifacts without an image config are not converted to "docker".
+func ImageWithFormatConvert(format string) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.formatConvert = format
+	}
+}
+
+// ImageWithImportChecksums verifies the tar against a checksums file from [ImageWithExportChecksums] in ImageImport.
+// The import fails before pushing any content when a file is missing, added, or modified.
+func ImageWithImportChecksums(rdr io.Reader) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.importChecksums = rdr
+	}
+}
+
 // ImageWithImportName selects the name of the image to import when multiple images are included in ImageImport.
 func ImageWithImportName(name string) ImageOpts {
 	return func(opts *imageOpt) {
@@ -191,22 +284,44 @@ func ImageWithDigestTags() ImageOpts {
 	}
 }
 
-// ImageWithPlatform requests specific platforms from a manifest list in ImageCheckBase.
+// ImageWithPlatform requests specific platforms from a manifest list in ImageCheckBase, ImageFileList, and ImageFileGet.
 func ImageWithPlatform(p string) ImageOpts {
 	return func(opts *imageOpt) {
 		opts.platform = p
 	}
 }
 
-// ImageWithPlatforms only copies specific platforms from a manifest list in ImageCopy.
-// This will result in a failure on many registries that validate manifests.
-// Use the empty string to indicate images without a platform definition should be copied.
+// ImageWithPlatforms only includes specific platforms from a manifest list in ImageCopy and ImageExport.
+// Entries are platform match expressions, see [platform.NewMatcher], e.g. "linux/*" or "!windows".
+// The copied or exported manifest list is rewritten to the matching entries, changing the digest.
+// Entries referencing another entry, like docker attestations, are included when the referenced entry is included.
+// When ImageCopy matches a single image, that image is copied to the target without a manifest list.
+// Use [RegClient.ImagePlatformsDigest] to compare the rewritten digest with a target.
+// Referrers of the rewritten manifest list are not copied, referrers of the included entries are copied.
+// Use the empty string to indicate images without a platform definition should be included.
 func ImageWithPlatforms(p []string) ImageOpts {
 	return func(opts *imageOpt) {
 		opts.platforms = p
 	}
 }
 
+// ImageWithPreCopyHook calls the hook in ImageCopy with each source manifest before the content is copied.
+// An error returned by the hook stops the copy before any content of the manifest is pushed.
+// Manifests that already exist in the target are skipped.
+func ImageWithPreCopyHook(hook ImageHook) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.preCopyHooks = append(opts.preCopyHooks, hook)
+	}
+}
+
+// ImageWithPrePushHook calls the hook in ImageCopy with each manifest before it is pushed to the target.
+// Blobs and child manifests have already been copied, and an error returned by the hook stops the copy.
+func ImageWithPrePushHook(hook ImageHook) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.prePushHooks = append(opts.prePushHooks, hook)
+	}
+}
+
 // ImageWithReferrers recursively recursively includes referrer images in ImageCopy.
 func ImageWithReferrers(rOpts ...scheme.ReferrerOpts) ImageOpts {
 	return func(opts *imageOpt) {
@@ -221,9 +336,43 @@ func ImageWithReferrers(rOpts ...scheme.ReferrerOpts) ImageOpts {
 	}
 }
 
+// ImageCheckBaseReport is the result of comparing an image to its base image.
+type ImageCheckBaseReport struct {
+	Ref             string                 `json:"ref"`                    // image reference checked
+	Platform        string                 `json:"platform,omitempty"`     // platform of an image in a manifest list
+	Base            string                 `json:"base"`                   // base image reference
+	BaseDigest      string                 `json:"baseDigest,omitempty"`   // current digest of the base image
+	ExpectDigest    string                 `json:"expectDigest,omitempty"` // base digest from the image annotation or option
+	LayersMatched   int                    `json:"layersMatched"`          // count of base image layers found in the image
+	MismatchLayer   int                    `json:"mismatchLayer"`          // index of the first changed base layer, -1 when layers were unchanged or not compared
+	UpdateAvailable bool                   `json:"updateAvailable"`        // true when the base image has changed
+	Reason          string                 `json:"reason,omitempty"`       // description of the change to the base image
+	Platforms       []ImageCheckBaseReport `json:"platforms,omitempty"`    // reports for each image in a manifest list
+}
+
 // ImageCheckBase returns nil if the base image is unchanged.
 // A base image mismatch returns an error that wraps types.ErrMismatch.
 func (rc *RegClient) ImageCheckBase(ctx context.Context, r ref.Ref, opts ...ImageOpts) error {
+	rpt, err := rc.ImageCheckBaseReport(ctx, r, opts...)
+	if err != nil {
+		return err
+	}
+	if !rpt.UpdateAvailable {
+		return nil
+	}
+	for _, pRpt := range rpt.Platforms {
+		if pRpt.UpdateAvailable {
+			return fmt.Errorf("platform %s mismatch: %s%.0w", pRpt.Platform, pRpt.Reason, types.ErrMismatch)
+		}
+	}
+	return fmt.Errorf("%s%.0w", rpt.Reason, types.ErrMismatch)
+}
+
+// ImageCheckBaseReport compares an image to its base image, returning a report of the changes.
+// Each image in a manifest list is compared unless a platform is selected with [ImageWithPlatform].
+// An error is only returned when the comparison cannot be made,
+// a changed base image is reported with UpdateAvailable.
+func (rc *RegClient) ImageCheckBaseReport(ctx context.Context, r ref.Ref, opts ...ImageOpts) (*ImageCheckBaseReport, error) {
 	var opt imageOpt
 	for _, optFn := range opts {
 		optFn(&opt)
@@ -235,20 +384,20 @@ func (rc *RegClient) ImageCheckBase(ctx context.Context, r ref.Ref, opts ...Imag
 	if opt.checkBaseRef == "" {
 		m, err = rc.ManifestGet(ctx, r)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		ma, ok := m.(manifest.Annotator)
 		if !ok {
-			return fmt.Errorf("image does not support annotations, base image must be provided%.0w", types.ErrMissingAnnotation)
+			return nil, fmt.Errorf("image does not support annotations, base image must be provided%.0w", types.ErrMissingAnnotation)
 		}
 		annot, err := ma.GetAnnotations()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if baseName, ok := annot[types.AnnotationBaseImageName]; ok {
 			opt.checkBaseRef = baseName
 		} else {
-			return fmt.Errorf("image does not have a base annotation, base image must be provided%.0w", types.ErrMissingAnnotation)
+			return nil, fmt.Errorf("image does not have a base annotation, base image must be provided%.0w", types.ErrMissingAnnotation)
 		}
 		if baseDig, ok := annot[types.AnnotationBaseImageDigest]; ok {
 			opt.checkBaseDigest = baseDig
@@ -256,123 +405,141 @@ func (rc *RegClient) ImageCheckBase(ctx context.Context, r ref.Ref, opts ...Imag
 	}
 	baseR, err := ref.New(opt.checkBaseRef)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rc.Close(ctx, baseR)
+	rpt := &ImageCheckBaseReport{
+		Ref:           r.CommonName(),
+		Base:          baseR.CommonName(),
+		ExpectDigest:  opt.checkBaseDigest,
+		MismatchLayer: -1,
+	}
 
 	// if the digest is available, check if that matches the base name
 	if opt.checkBaseDigest != "" {
 		baseMH, err := rc.ManifestHead(ctx, baseR, WithManifestRequireDigest())
 		if err != nil {
-			return err
+			return nil, err
 		}
 		expectDig, err := digest.Parse(opt.checkBaseDigest)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		rpt.BaseDigest = baseMH.GetDescriptor().Digest.String()
 		if baseMH.GetDescriptor().Digest == expectDig {
 			rc.log.WithFields(logrus.Fields{
 				"name":   baseR.CommonName(),
 				"digest": baseMH.GetDescriptor().Digest.String(),
 			}).Debug("base image digest matches")
-			return nil
 		} else {
 			rc.log.WithFields(logrus.Fields{
 				"name":     baseR.CommonName(),
 				"digest":   baseMH.GetDescriptor().Digest.String(),
 				"expected": expectDig.String(),
 			}).Debug("base image digest changed")
-			return fmt.Errorf("base digest changed, %s, expected %s, received %s%.0w",
-				baseR.CommonName(), expectDig.String(), baseMH.GetDescriptor().Digest.String(), types.ErrMismatch)
+			rpt.UpdateAvailable = true
+			rpt.Reason = fmt.Sprintf("base digest changed, %s, expected %s, received %s",
+				baseR.CommonName(), expectDig.String(), baseMH.GetDescriptor().Digest.String())
 		}
+		return rpt, nil
 	}
 
 	// if the digest is not available, compare layers of each manifest
 	if m == nil {
 		m, err = rc.ManifestGet(ctx, r)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if m.IsList() && opt.platform != "" {
 		p, err := platform.Parse(opt.platform)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		d, err := manifest.GetPlatformDesc(m, &p)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		rp := r
 		rp.Digest = d.Digest.String()
 		m, err = rc.ManifestGet(ctx, rp)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if m.IsList() {
 		// loop through each platform
 		ml, ok := m.(manifest.Indexer)
 		if !ok {
-			return fmt.Errorf("manifest list is not an Indexer")
+			return nil, fmt.Errorf("manifest list is not an Indexer")
 		}
 		dl, err := ml.GetManifestList()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		rp := r
 		for _, d := range dl {
 			rp.Digest = d.Digest.String()
-			optP := append(opts, ImageWithPlatform(d.Platform.String()))
-			err = rc.ImageCheckBase(ctx, rp, optP...)
+			// the base reference found in the annotations of the list applies to each platform
+			optP := append(opts, ImageWithCheckBaseRef(opt.checkBaseRef), ImageWithPlatform(d.Platform.String()))
+			pRpt, err := rc.ImageCheckBaseReport(ctx, rp, optP...)
 			if err != nil {
-				return fmt.Errorf("platform %s mismatch: %w", d.Platform.String(), err)
+				return nil, fmt.Errorf("platform %s: %w", d.Platform.String(), err)
+			}
+			pRpt.Platform = d.Platform.String()
+			if pRpt.UpdateAvailable {
+				rpt.UpdateAvailable = true
+			}
+			if rpt.BaseDigest == "" {
+				rpt.BaseDigest = pRpt.BaseDigest
 			}
+			rpt.Platforms = append(rpt.Platforms, *pRpt)
 		}
-		return nil
+		return rpt, nil
 	}
 	img, ok := m.(manifest.Imager)
 	if !ok {
-		return fmt.Errorf("manifest must be an image")
+		return nil, fmt.Errorf("manifest must be an image")
 	}
 	layers, err := img.GetLayers()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	baseM, err := rc.ManifestGet(ctx, baseR)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	rpt.BaseDigest = baseM.GetDescriptor().Digest.String()
 	if baseM.IsList() && opt.platform != "" {
 		p, err := platform.Parse(opt.platform)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		d, err := manifest.GetPlatformDesc(baseM, &p)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		rp := baseR
 		rp.Digest = d.Digest.String()
 		baseM, err = rc.ManifestGet(ctx, rp)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	baseImg, ok := baseM.(manifest.Imager)
 	if !ok {
-		return fmt.Errorf("base image manifest must be an image")
+		return nil, fmt.Errorf("base image manifest must be an image")
 	}
 	baseLayers, err := baseImg.GetLayers()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(baseLayers) <= 0 {
-		return fmt.Errorf("base image has no layers")
+		return nil, fmt.Errorf("base image has no layers")
 	}
 	for i := range baseLayers {
 		if i >= len(layers) {
-			return fmt.Errorf("image has fewer layers than base image")
+			return nil, fmt.Errorf("image has fewer layers than base image")
 		}
 		if !layers[i].Same(baseLayers[i]) {
 			rc.log.WithFields(logrus.Fields{
@@ -380,57 +547,532 @@ func (rc *RegClient) ImageCheckBase(ctx context.Context, r ref.Ref, opts ...Imag
 				"expected": layers[i].Digest.String(),
 				"digest":   baseLayers[i].Digest.String(),
 			}).Debug("image layer changed")
-			return fmt.Errorf("base layer changed, %s[%d], expected %s, received %s%.0w",
-				baseR.CommonName(), i, layers[i].Digest.String(), baseLayers[i].Digest.String(), types.ErrMismatch)
+			rpt.MismatchLayer = i
+			rpt.UpdateAvailable = true
+			rpt.Reason = fmt.Sprintf("base layer changed, %s[%d], expected %s, received %s",
+				baseR.CommonName(), i, layers[i].Digest.String(), baseLayers[i].Digest.String())
+			return rpt, nil
 		}
+		rpt.LayersMatched++
 	}
 
 	if opt.checkSkipConfig {
-		return nil
+		return rpt, nil
 	}
 
 	// if the layers match, compare the config history
 	confDesc, err := img.GetConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	conf, err := rc.BlobGetOCIConfig(ctx, r, confDesc)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	confOCI := conf.GetConfig()
 	baseConfDesc, err := baseImg.GetConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	baseConf, err := rc.BlobGetOCIConfig(ctx, baseR, baseConfDesc)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	baseConfOCI := baseConf.GetConfig()
 	for i := range baseConfOCI.History {
 		if i >= len(confOCI.History) {
-			return fmt.Errorf("image has fewer history entries than base image")
+			return nil, fmt.Errorf("image has fewer history entries than base image")
 		}
-		if baseConfOCI.History[i].Author != confOCI.History[i].Author ||
-			baseConfOCI.History[i].Comment != confOCI.History[i].Comment ||
-			!baseConfOCI.History[i].Created.Equal(*confOCI.History[i].Created) ||
-			baseConfOCI.History[i].CreatedBy != confOCI.History[i].CreatedBy ||
-			baseConfOCI.History[i].EmptyLayer != confOCI.History[i].EmptyLayer {
+		if !history.Equal(baseConfOCI.History[i], confOCI.History[i]) {
 			rc.log.WithFields(logrus.Fields{
 				"index":    i,
 				"expected": confOCI.History[i],
 				"history":  baseConfOCI.History[i],
 			}).Debug("image history changed")
-			return fmt.Errorf("base history changed, %s[%d], expected %v, received %v%.0w",
-				baseR.CommonName(), i, confOCI.History[i], baseConfOCI.History[i], types.ErrMismatch)
+			rpt.UpdateAvailable = true
+			rpt.Reason = fmt.Sprintf("base history changed, %s[%d], expected %v, received %v",
+				baseR.CommonName(), i, confOCI.History[i], baseConfOCI.History[i])
+			return rpt, nil
 		}
 	}
 
 	rc.log.WithFields(logrus.Fields{
 		"base": baseR.CommonName(),
 	}).Debug("base image layers and history matches")
-	return nil
+	return rpt, nil
+}
+
+// ImageRebase replaces the layers and history of the original base image with the new base image, and pushes the result.
+// By default, the base image name and original digest are read from the image annotations,
+// and the new base is the current digest of that name.
+// The base may be set with [ImageWithCheckBaseRef] and [ImageWithCheckBaseDigest], or [ImageWithRebaseRefs].
+// Each image in a manifest list is rebased onto the matching platform of the new base.
+// The result is pushed to the tag of r, and returned as a reference by digest.
+// Referrers to the original image are copied to the rebased image with [ImageWithReferrers].
+// An image that does not include the original base image returns an error wrapping [types.ErrMismatch].
+func (rc *RegClient) ImageRebase(ctx context.Context, r ref.Ref, opts ...ImageOpts) (ref.Ref, error) {
+	var opt imageOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return r, err
+	}
+	rOrig := r.SetDigest(m.GetDescriptor().Digest.String())
+	rOld, rNew, err := imageRebaseRefs(m, &opt)
+	if err != nil {
+		return r, err
+	}
+	defer rc.Close(ctx, rNew)
+	mbOld, err := rc.ManifestGet(ctx, rOld)
+	if err != nil {
+		return r, fmt.Errorf("failed to get original base %s: %w", rOld.CommonName(), err)
+	}
+	mbNew, err := rc.ManifestGet(ctx, rNew)
+	if err != nil {
+		return r, fmt.Errorf("failed to get new base %s: %w", rNew.CommonName(), err)
+	}
+	if mbOld.GetDescriptor().Digest == mbNew.GetDescriptor().Digest {
+		rc.log.WithFields(logrus.Fields{
+			"base":   rNew.CommonName(),
+			"digest": mbNew.GetDescriptor().Digest.String(),
+		}).Info("base image is unchanged")
+		return rOrig, nil
+	}
+	rb := imageRebase{
+		rOld:  rOld,
+		rNew:  rNew,
+		mbOld: mbOld,
+		mbNew: mbNew,
+	}
+
+	if m.IsList() {
+		mi, ok := m.(manifest.Indexer)
+		if !ok {
+			return r, fmt.Errorf("manifest list is not an Indexer")
+		}
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return r, err
+		}
+		for i, d := range dl {
+			// skip entries without a platform, e.g. attestations
+			if d.Platform == nil || d.Platform.OS == "unknown" {
+				continue
+			}
+			rp := r.SetDigest(d.Digest.String())
+			mp, err := rc.ManifestGet(ctx, rp)
+			if err != nil {
+				return r, err
+			}
+			err = rc.imageRebaseImage(ctx, r, mp, rb)
+			if err != nil {
+				return r, fmt.Errorf("platform %s: %w", d.Platform.String(), err)
+			}
+			dNew := mp.GetDescriptor()
+			err = rc.ManifestPut(ctx, r.SetDigest(dNew.Digest.String()), mp, WithManifestChild())
+			if err != nil {
+				return r, err
+			}
+			dl[i].MediaType = dNew.MediaType
+			dl[i].Digest = dNew.Digest
+			dl[i].Size = dNew.Size
+		}
+		err = mi.SetManifestList(dl)
+		if err != nil {
+			return r, err
+		}
+		err = imageRebaseAnnotate(m, rb)
+		if err != nil {
+			return r, err
+		}
+	} else {
+		err = rc.imageRebaseImage(ctx, r, m, rb)
+		if err != nil {
+			return r, err
+		}
+	}
+
+	rTgt := r.SetDigest(m.GetDescriptor().Digest.String())
+	if r.Tag != "" {
+		rTgt = r.SetTag(r.Tag)
+	}
+	err = rc.ManifestPut(ctx, rTgt, m)
+	if err != nil {
+		return r, err
+	}
+	rOut := r.SetDigest(m.GetDescriptor().Digest.String())
+
+	// copy referrers to the rebased image
+	if opt.referrerConfs != nil {
+		rl, err := rc.ReferrerList(ctx, rOrig)
+		if err != nil {
+			return rOut, err
+		}
+		descList := []types.Descriptor{}
+		if len(opt.referrerConfs) == 0 {
+			descList = rl.Descriptors
+		} else {
+			for _, rConf := range opt.referrerConfs {
+				rlFilter := scheme.ReferrerFilter(rConf, rl)
+				descList = append(descList, rlFilter.Descriptors...)
+			}
+		}
+		subject := m.GetDescriptor()
+		for _, rDesc := range descList {
+			mr, err := rc.ManifestGet(ctx, r.SetDigest(rDesc.Digest.String()))
+			if err != nil {
+				return rOut, err
+			}
+			ms, ok := mr.(manifest.Subjecter)
+			if !ok {
+				continue
+			}
+			err = ms.SetSubject(&types.Descriptor{MediaType: subject.MediaType, Digest: subject.Digest, Size: subject.Size})
+			if err != nil {
+				return rOut, err
+			}
+			err = rc.ManifestPut(ctx, r.SetDigest(mr.GetDescriptor().Digest.String()), mr)
+			if err != nil {
+				return rOut, fmt.Errorf("failed to copy referrer %s: %w", rDesc.Digest.String(), err)
+			}
+		}
+	}
+	return rOut, nil
+}
+
+// ImageWithRebaseRefs sets the original and new base image in [RegClient.ImageRebase].
+func ImageWithRebaseRefs(rOld, rNew ref.Ref) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.rebaseOld = rOld
+		opts.rebaseNew = rNew
+	}
+}
+
+// imageRebase contains the original and new base images.
+type imageRebase struct {
+	rOld, rNew   ref.Ref
+	mbOld, mbNew manifest.Manifest
+}
+
+// imageRebaseRefs returns the original and new base from the options or the image annotations.
+func imageRebaseRefs(m manifest.Manifest, opt *imageOpt) (ref.Ref, ref.Ref, error) {
+	if opt.rebaseOld.IsSet() && opt.rebaseNew.IsSet() {
+		return opt.rebaseOld, opt.rebaseNew, nil
+	}
+	annot := map[string]string{}
+	if ma, ok := m.(manifest.Annotator); ok {
+		a, err := ma.GetAnnotations()
+		if err != nil {
+			return ref.Ref{}, ref.Ref{}, err
+		}
+		if a != nil {
+			annot = a
+		}
+	}
+	baseName := opt.checkBaseRef
+	if baseName == "" {
+		baseName = annot[types.AnnotationBaseImageName]
+	}
+	baseDig := opt.checkBaseDigest
+	if baseDig == "" {
+		baseDig = annot[types.AnnotationBaseImageDigest]
+	}
+	if baseName == "" || baseDig == "" {
+		return ref.Ref{}, ref.Ref{}, fmt.Errorf("annotation for base image is missing (%s or %s)%.0w", types.AnnotationBaseImageName, types.AnnotationBaseImageDigest, types.ErrMissingAnnotation)
+	}
+	rNew, err := ref.New(baseName)
+	if err != nil {
+		return ref.Ref{}, ref.Ref{}, fmt.Errorf("failed to parse base name: %w", err)
+	}
+	dig, err := digest.Parse(baseDig)
+	if err != nil {
+		return ref.Ref{}, ref.Ref{}, fmt.Errorf("failed to parse base digest: %w", err)
+	}
+	return rNew.SetDigest(dig.String()), rNew, nil
+}
+
+// imageRebaseAnnotate updates the base digest annotation when it is set on the manifest.
+func imageRebaseAnnotate(m manifest.Manifest, rb imageRebase) error {
+	ma, ok := m.(manifest.Annotator)
+	if !ok {
+		return nil
+	}
+	annot, err := ma.GetAnnotations()
+	if err != nil {
+		return err
+	}
+	if _, ok := annot[types.AnnotationBaseImageDigest]; !ok {
+		return nil
+	}
+	return ma.SetAnnotation(types.AnnotationBaseImageDigest, rb.mbNew.GetDescriptor().Digest.String())
+}
+
+// imageRebaseImage updates the layers and config of a single platform image to the new base image.
+// The config and new base layers are pushed to the repository, the manifest is modified but not pushed.
+func (rc *RegClient) imageRebaseImage(ctx context.Context, r ref.Ref, m manifest.Manifest, rb imageRebase) error {
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return fmt.Errorf("manifest is not an image")
+	}
+	layers, err := mi.GetLayers()
+	if err != nil {
+		return err
+	}
+	confDesc, err := mi.GetConfig()
+	if err != nil {
+		return err
+	}
+	conf, err := rc.BlobGetOCIConfig(ctx, r, confDesc)
+	if err != nil {
+		return err
+	}
+	confOCI := conf.GetConfig()
+	p := platform.Platform{
+		OS:           confOCI.OS,
+		Architecture: confOCI.Architecture,
+		Variant:      confOCI.Variant,
+		OSVersion:    confOCI.OSVersion,
+		OSFeatures:   confOCI.OSFeatures,
+	}
+	imgOld, confOld, err := rc.imageRebaseBase(ctx, rb.rOld, rb.mbOld, p)
+	if err != nil {
+		return fmt.Errorf("original base %s: %w", rb.rOld.CommonName(), err)
+	}
+	imgNew, confNew, err := rc.imageRebaseBase(ctx, rb.rNew, rb.mbNew, p)
+	if err != nil {
+		return fmt.Errorf("new base %s: %w", rb.rNew.CommonName(), err)
+	}
+	layersOld, err := imgOld.GetLayers()
+	if err != nil {
+		return err
+	}
+	layersNew, err := imgNew.GetLayers()
+	if err != nil {
+		return err
+	}
+
+	// validate the image includes the original base
+	if len(layersOld) > len(layers) {
+		return fmt.Errorf("base image has more layers than the image%.0w", types.ErrMismatch)
+	}
+	for i := range layersOld {
+		if !layers[i].Same(layersOld[i]) {
+			return fmt.Errorf("original base image does not match image layers, layer %d, base %s, image %s%.0w", i, layersOld[i].Digest.String(), layers[i].Digest.String(), types.ErrMismatch)
+		}
+	}
+	if len(confOld.History) > len(confOCI.History) {
+		return fmt.Errorf("base image has more history entries than the image%.0w", types.ErrMismatch)
+	}
+	for i := range confOld.History {
+		if !history.Equal(confOCI.History[i], confOld.History[i]) {
+			return fmt.Errorf("original base image does not match image history, entry %d%.0w", i, types.ErrMismatch)
+		}
+	}
+	if len(confOld.RootFS.DiffIDs) != len(layersOld) || len(confOld.RootFS.DiffIDs) > len(confOCI.RootFS.DiffIDs) {
+		return fmt.Errorf("original base image rootfs does not match the layers%.0w", types.ErrMismatch)
+	}
+	for i := range confOld.RootFS.DiffIDs {
+		if confOCI.RootFS.DiffIDs[i] != confOld.RootFS.DiffIDs[i] {
+			return fmt.Errorf("original base image does not match image rootfs, entry %d%.0w", i, types.ErrMismatch)
+		}
+	}
+	if len(confNew.RootFS.DiffIDs) != len(layersNew) {
+		return fmt.Errorf("new base image rootfs does not match the layers")
+	}
+
+	// copy the new base layers into the repository
+	for _, d := range layersNew {
+		err = rc.BlobCopy(ctx, rb.rNew, r, d)
+		if err != nil {
+			return fmt.Errorf("failed copying blobs for rebase: %w", err)
+		}
+	}
+
+	// replace the base layers, history, and rootfs
+	layers = append(append([]types.Descriptor{}, layersNew...), layers[len(layersOld):]...)
+	confOCI.History = append(append([]v1.History{}, confNew.History...), confOCI.History[len(confOld.History):]...)
+	confOCI.RootFS.DiffIDs = append(append([]digest.Digest{}, confNew.RootFS.DiffIDs...), confOCI.RootFS.DiffIDs[len(confOld.RootFS.DiffIDs):]...)
+	conf.SetConfig(confOCI)
+	confBody, err := conf.RawBody()
+	if err != nil {
+		return err
+	}
+	confDesc.Digest = conf.GetDescriptor().Digest
+	confDesc.Size = conf.GetDescriptor().Size
+	_, err = rc.BlobPut(ctx, r, confDesc, bytes.NewReader(confBody))
+	if err != nil {
+		return fmt.Errorf("failed to push config: %w", err)
+	}
+	err = mi.SetConfig(confDesc)
+	if err != nil {
+		return err
+	}
+	err = mi.SetLayers(layers)
+	if err != nil {
+		return err
+	}
+	return imageRebaseAnnotate(m, rb)
+}
+
+// imageRebaseBase returns the image and config of a base image for a platform.
+func (rc *RegClient) imageRebaseBase(ctx context.Context, r ref.Ref, m manifest.Manifest, p platform.Platform) (manifest.Imager, v1.Image, error) {
+	if m.IsList() {
+		d, err := manifest.GetPlatformDesc(m, &p)
+		if err != nil {
+			return nil, v1.Image{}, err
+		}
+		r = r.SetDigest(d.Digest.String())
+		m, err = rc.ManifestGet(ctx, r)
+		if err != nil {
+			return nil, v1.Image{}, err
+		}
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return nil, v1.Image{}, fmt.Errorf("base is not an image")
+	}
+	cd, err := mi.GetConfig()
+	if err != nil {
+		return nil, v1.Image{}, err
+	}
+	conf, err := rc.BlobGetOCIConfig(ctx, r, cd)
+	if err != nil {
+		return nil, v1.Image{}, err
+	}
+	return mi, conf.GetConfig(), nil
+}
+
+// ImageIndexDedupeReport lists the entries removed from an index by ImageIndexDedupe.
+type ImageIndexDedupeReport struct {
+	Ref        string             `json:"ref"`                  // reference to the index
+	Digest     digest.Digest      `json:"digest"`               // digest of the original index
+	DigestNew  digest.Digest      `json:"digestNew,omitempty"`  // digest of the cleaned index, when changed
+	Duplicates []types.Descriptor `json:"duplicates,omitempty"` // entries repeating an earlier entry or platform
+	Missing    []types.Descriptor `json:"missing,omitempty"`    // entries pointing to manifests that do not exist
+	Changed    bool               `json:"changed"`              // true when entries were removed
+	Pushed     bool               `json:"pushed"`               // true when the cleaned index was pushed
+}
+
+// ImageIndexDedupe removes duplicate and missing entries from an index, and pushes the cleaned index.
+// An entry is a duplicate when it is identical to an earlier entry, or the platform matches an earlier entry.
+// Entries without a platform, e.g. attestations, are only removed when identical.
+// An entry is missing when the manifest it points to does not exist in the repository.
+// The result is pushed to the tag of r, or by digest when r does not have a tag.
+// Use [ImageWithDryRun] to report the changes without pushing.
+func (rc *RegClient) ImageIndexDedupe(ctx context.Context, r ref.Ref, opts ...ImageOpts) (*ImageIndexDedupeReport, error) {
+	var opt imageOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	mi, ok := m.(manifest.Indexer)
+	if !ok || !m.IsList() {
+		return nil, fmt.Errorf("manifest is not an index: %s%.0w", r.CommonName(), types.ErrUnsupportedMediaType)
+	}
+	dl, err := mi.GetManifestList()
+	if err != nil {
+		return nil, err
+	}
+	rpt := &ImageIndexDedupeReport{
+		Ref:    r.CommonName(),
+		Digest: m.GetDescriptor().Digest,
+	}
+	exists := map[digest.Digest]bool{}
+	seenPlat := []platform.Platform{}
+	dlNew := []types.Descriptor{}
+	for _, d := range dl {
+		dup := false
+		for _, dPrev := range dlNew {
+			if d.Equal(dPrev) {
+				dup = true
+				break
+			}
+		}
+		if !dup && d.Platform != nil && d.Platform.OS != "unknown" {
+			for _, p := range seenPlat {
+				if platform.Match(p, *d.Platform) {
+					dup = true
+					break
+				}
+			}
+		}
+		if dup {
+			rpt.Duplicates = append(rpt.Duplicates, d)
+			continue
+		}
+		if _, ok := exists[d.Digest]; !ok {
+			_, err := rc.ManifestHead(ctx, r.SetDigest(d.Digest.String()))
+			if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to check manifest %s: %w", d.Digest.String(), err)
+			}
+			exists[d.Digest] = err == nil
+		}
+		if !exists[d.Digest] {
+			rpt.Missing = append(rpt.Missing, d)
+			continue
+		}
+		if d.Platform != nil && d.Platform.OS != "unknown" {
+			seenPlat = append(seenPlat, *d.Platform)
+		}
+		dlNew = append(dlNew, d)
+	}
+	if len(dlNew) == len(dl) {
+		return rpt, nil
+	}
+	rpt.Changed = true
+	err = mi.SetManifestList(dlNew)
+	if err != nil {
+		return nil, err
+	}
+	rpt.DigestNew = m.GetDescriptor().Digest
+	rc.log.WithFields(logrus.Fields{
+		"ref":        r.CommonName(),
+		"duplicates": len(rpt.Duplicates),
+		"missing":    len(rpt.Missing),
+	}).Info("index entries removed")
+	if opt.dryRun {
+		return rpt, nil
+	}
+	rTgt := r.SetDigest(rpt.DigestNew.String())
+	if r.Tag != "" {
+		rTgt = r.SetTag(r.Tag)
+	}
+	err = rc.ManifestPut(ctx, rTgt, m)
+	if err != nil {
+		return rpt, err
+	}
+	rpt.Pushed = true
+	return rpt, nil
+}
+
+// ImageCopyReport lists the content pushed to the target by ImageCopyReport.
+type ImageCopyReport struct {
+	Source           string                 `json:"source"`              // source image reference
+	Target           string                 `json:"target"`              // target image reference
+	DryRun           bool                   `json:"dryRun"`              // true when the content was not pushed
+	Manifests        []ImageCopyReportEntry `json:"manifests,omitempty"` // manifests pushed to the target
+	Blobs            []ImageCopyReportEntry `json:"blobs,omitempty"`     // blobs pushed or mounted to the target
+	BlobsSkipped     int                    `json:"blobsSkipped"`        // count of blobs that already existed in the target
+	BlobsMounted     int                    `json:"blobsMounted"`        // count of blobs mounted from the source repository
+	BlobsPushed      int                    `json:"blobsPushed"`         // count of blobs pulled from the source and pushed
+	Size             int64                  `json:"size"`                // total size of the manifests and blobs
+	BytesTransferred int64                  `json:"bytesTransferred"`    // size of the manifests and blobs pushed, excluding mounted blobs
+	Elapsed          timejson.Duration      `json:"elapsed"`             // duration of the copy, e.g. "1.5s"
+}
+
+// ImageCopyReportEntry is a manifest or blob in an ImageCopyReport.
+type ImageCopyReportEntry struct {
+	Ref       string            `json:"ref"`                 // target reference
+	MediaType string            `json:"mediaType,omitempty"` // media type of the content
+	Digest    digest.Digest     `json:"digest"`              // digest of the content
+	Size      int64             `json:"size"`                // size of the content in bytes
+	Mounted   bool              `json:"mounted,omitempty"`   // true when a blob is mounted from the source repository
+	Elapsed   timejson.Duration `json:"elapsed,omitempty"`   // duration of the push or mount
 }
 
 // ImageCopy copies an image.
@@ -439,13 +1081,61 @@ func (rc *RegClient) ImageCheckBase(ctx context.Context, r ref.Ref, opts ...Imag
 // Blobs are only pulled when they don't exist on the target and a blob mount fails.
 // Referrers are optionally copied recursively.
 func (rc *RegClient) ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, opts ...ImageOpts) error {
+	_, err := rc.ImageCopyReport(ctx, refSrc, refTgt, opts...)
+	return err
+}
+
+// ImagePlatformsDigest returns the digest [RegClient.ImageCopy] pushes for r with [ImageWithPlatforms].
+// This is the digest of the rewritten manifest list, the single matching image, or the unmodified source.
+func (rc *RegClient) ImagePlatformsDigest(ctx context.Context, r ref.Ref, platforms []string) (digest.Digest, error) {
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	if len(platforms) == 0 || !m.IsList() {
+		return m.GetDescriptor().Digest, nil
+	}
+	mPruned, dSingle, err := imagePlatformsManifest(r, m, platforms)
+	if err != nil {
+		return "", err
+	}
+	if dSingle != nil {
+		return dSingle.Digest, nil
+	}
+	return mPruned.GetDescriptor().Digest, nil
+}
+
+// ImageCopyReport copies an image with [ImageCopy], returning a report of the manifests and blobs pushed to the target.
+// Use [ImageWithDryRun] to walk the source and check the target without writing anything,
+// reporting the content that would be copied.
+func (rc *RegClient) ImageCopyReport(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, opts ...ImageOpts) (rpt *ImageCopyReport, err error) {
+	ctx, span := rc.traceStart(ctx, "ImageCopy", refSrc, trace.String(trace.AttrRefTarget, refTgt.CommonName()))
+	defer func() { span.End(err) }()
 	opt := imageOpt{
-		seen:    map[string]*imageSeen{},
-		finalFn: []func(context.Context) error{},
+		seen:            map[string]*imageSeen{},
+		finalFn:         []func(context.Context) error{},
+		formatConverted: map[digest.Digest]types.Descriptor{},
 	}
 	for _, optFn := range opts {
 		optFn(&opt)
 	}
+	if opt.formatConvert != "" && opt.formatConvert != imageFormatOCI && opt.formatConvert != imageFormatDocker {
+		return nil, fmt.Errorf("unsupported format %s, use %s or %s%.0w", opt.formatConvert, imageFormatOCI, imageFormatDocker, types.ErrUnsupported)
+	}
+	start := time.Now()
+	rpt = &ImageCopyReport{
+		Source: refSrc.CommonName(),
+		Target: refTgt.CommonName(),
+		DryRun: opt.dryRun,
+	}
+	opt.report = rpt
+	defer func() { rpt.Elapsed = timejson.Duration(time.Since(start)) }()
+	if opt.blobVerify > 0 {
+		// existing manifests must be walked to find the blobs to verify
+		opt.forceRecursive = true
+		//#nosec G404 sampling blobs does not require a secure random source
+		opt.blobVerifyRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
 	// dedup warnings
 	if w := warning.FromContext(ctx); w == nil {
 		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
@@ -453,7 +1143,7 @@ func (rc *RegClient) ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.R
 	// block GC from running (in OCIDir) during the copy
 	schemeTgtAPI, err := rc.schemeGet(refTgt.Scheme)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if tgtGCLocker, isGCLocker := schemeTgtAPI.(scheme.GCLocker); isGCLocker {
 		tgtGCLocker.GCLock(refTgt)
@@ -462,22 +1152,28 @@ func (rc *RegClient) ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.R
 	// run the copy of manifests and blobs recursively
 	err = rc.imageCopyOpt(ctx, refSrc, refTgt, types.Descriptor{}, opt.child, []digest.Digest{}, &opt)
 	if err != nil {
-		return err
+		return rpt, err
 	}
 	// run any final functions, digest-tags and referrers that detected loops are retried here
 	for _, fn := range opt.finalFn {
 		err := fn(ctx)
 		if err != nil {
-			return err
+			return rpt, err
 		}
 	}
-	return nil
+	return rpt, nil
 }
 
 // imageCopyOpt is a thread safe copy of a manifest and nested content.
 func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor, child bool, parents []digest.Digest, opt *imageOpt) (err error) {
 	var mSrc, mTgt manifest.Manifest
 	var sDig digest.Digest
+	// the top level index is rewritten when platforms are selected
+	prune := len(opt.platforms) > 0 && !child && len(parents) == 0
+	pruned := false
+	// manifests are rewritten when converting the format
+	convert := opt.formatConvert != ""
+	converted := false
 	seenCB := func(error) {}
 	defer func() {
 		if seenCB != nil {
@@ -494,6 +1190,13 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		if seenCB, err = imageSeenOrWait(ctx, opt, refTgt.Tag, sDig, parents); seenCB == nil {
 			return err
 		}
+		// skip manifests recorded by an earlier copy
+		if refTgt.Tag == "" && imageCheckpointManifests(opt) && opt.checkpoint.Has(imageCheckpointRepo(refTgt), sDig) {
+			if opt.callback != nil {
+				opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackSkipped, d.Size, d.Size)
+			}
+			return nil
+		}
 	}
 	// check target with head request
 	mTgt, err = rc.ManifestHead(ctx, refTgt, WithManifestRequireDigest())
@@ -502,7 +1205,7 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		return fmt.Errorf("failed to access target registry: %w", err)
 	}
 	// for non-recursive copies, compare to source digest
-	if err == nil && (opt.fastCheck || (!opt.forceRecursive && opt.referrerConfs == nil && !opt.digestTags)) {
+	if err == nil && !prune && !convert && (opt.fastCheck || (!opt.forceRecursive && opt.referrerConfs == nil && !opt.digestTags)) {
 		if sDig == "" {
 			mSrc, err = rc.ManifestHead(ctx, refSrc, WithManifestRequireDigest())
 			if err != nil {
@@ -532,7 +1235,7 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		}
 	}
 	// get the source manifest when a copy is needed or recursion into the content is needed
-	if sDig == "" || mTgt == nil || sDig != mTgt.GetDescriptor().Digest || opt.forceRecursive || mTgt.IsList() {
+	if sDig == "" || mTgt == nil || sDig != mTgt.GetDescriptor().Digest || opt.forceRecursive || mTgt.IsList() || convert {
 		mSrc, err = rc.ManifestGet(ctx, refSrc, WithManifestDesc(d))
 		if err != nil {
 			return fmt.Errorf("copy failed, error getting source: %w", err)
@@ -544,6 +1247,58 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 			}
 		}
 	}
+	// rewrite the index to the selected platforms, copying a single image without an index
+	if prune && mSrc.IsSet() && mSrc.IsList() {
+		mPruned, dSingle, err := imagePlatformsManifest(refSrc, mSrc, opt.platforms)
+		if err != nil {
+			return err
+		}
+		if dSingle != nil {
+			rc.log.WithFields(logrus.Fields{
+				"platform": dSingle.Platform,
+				"digest":   dSingle.Digest.String(),
+			}).Debug("Copy single platform without an index")
+			if refTgt.Digest != "" {
+				refTgt = refTgt.SetDigest(dSingle.Digest.String())
+			}
+			return rc.imageCopyOpt(ctx, refSrc.SetDigest(dSingle.Digest.String()), refTgt, *dSingle, child, parents, opt)
+		}
+		if mPruned != mSrc {
+			mSrc = mPruned
+			sDig = mSrc.GetDescriptor().Digest
+			pruned = true
+			if refTgt.Digest != "" {
+				refTgt = refTgt.SetDigest(sDig.String())
+			}
+		}
+	}
+	// convert an image now, an index is converted after the children are copied
+	if convert && mSrc.IsSet() {
+		if mSrc.IsList() {
+			converted, err = imageFormatConvertIndexNeeded(mSrc, opt.formatConvert)
+			if err != nil {
+				return err
+			}
+		} else {
+			mConv, err := imageFormatConvert(mSrc, opt.formatConvert, nil)
+			if err != nil {
+				return fmt.Errorf("failed to convert %s: %w", refSrc.CommonName(), err)
+			}
+			if mConv != mSrc {
+				mSrc, sDig, refTgt, mTgt = rc.imageFormatConverted(ctx, opt, mSrc, mConv, refTgt, mTgt)
+				converted = true
+			}
+		}
+	}
+	// run policy hooks before copying any content
+	if mTgt == nil || sDig != mTgt.GetDescriptor().Digest || opt.forceRecursive {
+		for _, hook := range opt.preCopyHooks {
+			err = hook(ctx, refSrc, refTgt, mSrc)
+			if err != nil {
+				return fmt.Errorf("pre-copy hook failed for %s: %w", refSrc.CommonName(), err)
+			}
+		}
+	}
 	// setup vars for a copy
 	mOpts := []ManifestOpts{}
 	if child {
@@ -564,26 +1319,13 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackStarted, 0, d.Size)
 	}
 	// process entries in an index
-	if mSrcIndex, ok := mSrc.(manifest.Indexer); ok && mSrc.IsSet() && !ref.EqualRepository(refSrc, refTgt) {
+	if mSrcIndex, ok := mSrc.(manifest.Indexer); ok && mSrc.IsSet() && (!ref.EqualRepository(refSrc, refTgt) || convert) {
 		// manifest lists need to recursively copy nested images by digest
 		dList, err := mSrcIndex.GetManifestList()
 		if err != nil {
 			return err
 		}
 		for _, dEntry := range dList {
-			// skip copy of platforms not specifically included
-			if len(opt.platforms) > 0 {
-				match, err := imagePlatformInList(dEntry.Platform, opt.platforms)
-				if err != nil {
-					return err
-				}
-				if !match {
-					rc.log.WithFields(logrus.Fields{
-						"platform": dEntry.Platform,
-					}).Debug("Platform excluded from copy")
-					continue
-				}
-			}
 			dEntry := dEntry
 			waitCount++
 			go func() {
@@ -604,13 +1346,13 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 					types.MediaTypeDocker2LayerGzip, types.MediaTypeOCI1Layer, types.MediaTypeOCI1LayerGzip,
 					types.MediaTypeBuildkitCacheConfig:
 					// known blob media type
-					err = rc.imageCopyBlob(ctx, entrySrc, entryTgt, dEntry, opt, bOpt...)
+					err = rc.imageCopyBlob(ctx, entrySrc, entryTgt, dEntry, nil, opt, bOpt...)
 				default:
 					// unknown media type, first try an image copy
 					err = rc.imageCopyOpt(ctx, entrySrc, entryTgt, dEntry, true, parentsNew, opt)
 					if err != nil {
 						// fall back to trying to copy a blob
-						err = rc.imageCopyBlob(ctx, entrySrc, entryTgt, dEntry, opt, bOpt...)
+						err = rc.imageCopyBlob(ctx, entrySrc, entryTgt, dEntry, nil, opt, bOpt...)
 					}
 				}
 				waitCh <- err
@@ -618,9 +1360,16 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		}
 	}
 
-	// copy referrers
+	// copy referrers, skipped for a rewritten manifest since they refer to the source digest
+	// referrers of the entries in a pruned index are copied with each entry
 	referrerTags := []string{}
-	if opt.referrerConfs != nil {
+	if opt.referrerConfs != nil && (pruned || converted) {
+		rc.log.WithFields(logrus.Fields{
+			"src":    refSrc.CommonName(),
+			"digest": sDig.String(),
+		}).Info("Skipping referrers of a rewritten manifest")
+	}
+	if opt.referrerConfs != nil && !pruned && !converted {
 		rl, err := rc.ReferrerList(ctx, refSrc)
 		if err != nil {
 			return err
@@ -671,7 +1420,7 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 	}
 
 	// lookup digest tags to include artifacts with image
-	if opt.digestTags {
+	if opt.digestTags && !converted {
 		// load tag listing for digest tag copy
 		opt.mu.Lock()
 		if opt.tagList == nil {
@@ -772,6 +1521,14 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 
 	// If source is image, copy blobs
 	if mSrcImg, ok := mSrc.(manifest.Imager); ok && mSrc.IsSet() && !ref.EqualRepository(refSrc, refTgt) {
+		// a dry run checks every blob of the image in the target with a single batch
+		var found map[digest.Digest]bool
+		if opt.dryRun {
+			found, err = rc.BlobBulkHead(ctx, refTgt, imageBlobDigests(mSrcImg, opt.includeExternal))
+			if err != nil {
+				return fmt.Errorf("failed to check blobs in %s: %w", refTgt.CommonName(), err)
+			}
+		}
 		// copy the config
 		cd, err := mSrcImg.GetConfig()
 		if err != nil {
@@ -791,7 +1548,7 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 					"target": refTgt.Reference,
 					"digest": cd.Digest.String(),
 				}).Info("Copy config")
-				err := rc.imageCopyBlob(ctx, refSrc, refTgt, cd, opt, bOpt...)
+				err := rc.imageCopyBlob(ctx, refSrc, refTgt, cd, found, opt, bOpt...)
 				if err != nil {
 					rc.log.WithFields(logrus.Fields{
 						"source": refSrc.Reference,
@@ -828,7 +1585,7 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 					"target": refTgt.Reference,
 					"layer":  layerSrc.Digest.String(),
 				}).Info("Copy layer")
-				err := rc.imageCopyBlob(ctx, refSrc, refTgt, layerSrc, opt, bOpt...)
+				err := rc.imageCopyBlob(ctx, refSrc, refTgt, layerSrc, found, opt, bOpt...)
 				if err != nil {
 					rc.log.WithFields(logrus.Fields{
 						"source": refSrc.Reference,
@@ -859,15 +1616,52 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		return err
 	}
 
+	// convert an index with the digests of the converted children
+	if convert && mSrc.IsSet() && mSrc.IsList() {
+		opt.mu.Lock()
+		children := make(map[digest.Digest]types.Descriptor, len(opt.formatConverted))
+		for k, v := range opt.formatConverted {
+			children[k] = v
+		}
+		opt.mu.Unlock()
+		mConv, err := imageFormatConvert(mSrc, opt.formatConvert, children)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s: %w", refSrc.CommonName(), err)
+		}
+		if mConv != mSrc {
+			mSrc, sDig, refTgt, mTgt = rc.imageFormatConverted(ctx, opt, mSrc, mConv, refTgt, mTgt)
+		}
+	}
+
 	// push manifest
 	if mTgt == nil || sDig != mTgt.GetDescriptor().Digest || opt.forceRecursive {
-		err = rc.ManifestPut(ctx, refTgt, mSrc, mOpts...)
-		if err != nil {
-			rc.log.WithFields(logrus.Fields{
-				"target": refTgt.Reference,
-				"err":    err,
-			}).Warn("Failed to push manifest")
-			return err
+		for _, hook := range opt.prePushHooks {
+			err = hook(ctx, refSrc, refTgt, mSrc)
+			if err != nil {
+				return fmt.Errorf("pre-push hook failed for %s: %w", refTgt.CommonName(), err)
+			}
+		}
+		start := time.Now()
+		if !opt.dryRun {
+			mPut := mSrc
+			if refTgt.Tag == "" && len(mSrc.GetDescriptor().Annotations) > 0 {
+				// a copy by digest does not carry the annotations of the source index entry, like the tag in an OCI Layout
+				mPut, err = imageManifestStripDesc(mSrc)
+				if err != nil {
+					return err
+				}
+			}
+			err = rc.ManifestPut(ctx, refTgt, mPut, mOpts...)
+			if err != nil {
+				rc.log.WithFields(logrus.Fields{
+					"target": refTgt.Reference,
+					"err":    err,
+				}).Warn("Failed to push manifest")
+				return err
+			}
+		}
+		if mTgt == nil || sDig != mTgt.GetDescriptor().Digest {
+			imageReportAdd(opt, true, blobCopyPushed, refTgt, mSrc.GetDescriptor(), time.Since(start))
 		}
 		if opt.callback != nil {
 			opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackFinished, d.Size, d.Size)
@@ -877,6 +1671,9 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 			opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackSkipped, d.Size, d.Size)
 		}
 	}
+	if opt.checkpoint != nil && !opt.dryRun {
+		imageCheckpointAdd(rc, opt, refTgt, sDig)
+	}
 	if seenCB != nil {
 		seenCB(nil)
 		seenCB = nil
@@ -885,16 +1682,148 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 	return nil
 }
 
-func (rc *RegClient) imageCopyBlob(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor, opt *imageOpt, bOpt ...BlobOpts) error {
+// imageCheckpointManifests returns true when manifests in the checkpoint may be skipped.
+// Referrers and digest tags can be added to an existing manifest, so those copies always check the target.
+func imageCheckpointManifests(opt *imageOpt) bool {
+	return opt.checkpoint != nil && !opt.forceRecursive && opt.referrerConfs == nil && !opt.digestTags && opt.formatConvert == ""
+}
+
+// imageCheckpointRepo returns the repository name used for checkpoint entries.
+func imageCheckpointRepo(r ref.Ref) string {
+	return r.SetTag("").CommonName()
+}
+
+// imageCheckpointAdd records the digest in the checkpoint, a failure is logged without failing the copy.
+func imageCheckpointAdd(rc *RegClient, opt *imageOpt, r ref.Ref, d digest.Digest) {
+	err := opt.checkpoint.Add(imageCheckpointRepo(r), d)
+	if err != nil {
+		rc.log.WithFields(logrus.Fields{
+			"repo":   imageCheckpointRepo(r),
+			"digest": d.String(),
+			"err":    err,
+		}).Warn("Failed to update checkpoint")
+	}
+}
+
+// imageCopyBlob copies a single blob, found contains the blobs already checked in the target during a dry run.
+func (rc *RegClient) imageCopyBlob(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor, found map[digest.Digest]bool, opt *imageOpt, bOpt ...BlobOpts) error {
 	seenCB, err := imageSeenOrWait(ctx, opt, "", d.Digest, []digest.Digest{})
 	if seenCB == nil {
 		return err
 	}
-	err = rc.BlobCopy(ctx, refSrc, refTgt, d, bOpt...)
+	if opt.checkpoint != nil && opt.blobVerify == 0 && opt.checkpoint.Has(imageCheckpointRepo(refTgt), d.Digest) {
+		imageReportAdd(opt, false, blobCopySkipped, refTgt, d, 0)
+		if opt.callback != nil {
+			opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackSkipped, d.Size, d.Size)
+		}
+		seenCB(nil)
+		return nil
+	}
+	if opt.dryRun {
+		err = rc.imageCopyBlobDryRun(ctx, refSrc, refTgt, d, found, opt)
+		seenCB(err)
+		return err
+	}
+	if opt.blobVerify > 0 {
+		opt.mu.Lock()
+		sample := opt.blobVerifyRand.Intn(100) < opt.blobVerify
+		opt.mu.Unlock()
+		if sample {
+			bOpt = append(bOpt[:len(bOpt):len(bOpt)], BlobWithVerify())
+		}
+	}
+	start := time.Now()
+	result, err := rc.blobCopy(ctx, refSrc, refTgt, d, bOpt...)
+	if err == nil {
+		imageReportAdd(opt, false, result, refTgt, d, time.Since(start))
+	}
+	if err == nil && opt.checkpoint != nil {
+		imageCheckpointAdd(rc, opt, refTgt, d.Digest)
+	}
 	seenCB(err)
 	return err
 }
 
+// imageBlobDigests returns the config and layer digests of an image, skipping external layers unless they are included.
+func imageBlobDigests(mi manifest.Imager, includeExternal bool) []digest.Digest {
+	digests := []digest.Digest{}
+	if cd, err := mi.GetConfig(); err == nil {
+		digests = append(digests, cd.Digest)
+	}
+	if l, err := mi.GetLayers(); err == nil {
+		for _, ld := range l {
+			if len(ld.URLs) > 0 && !includeExternal {
+				continue
+			}
+			digests = append(digests, ld.Digest)
+		}
+	}
+	return digests
+}
+
+// imageCopyBlobDryRun checks for a blob in the target, reporting the blob when it would be copied.
+// The result of the batch check in found is used when available.
+func (rc *RegClient) imageCopyBlobDryRun(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor, found map[digest.Digest]bool, opt *imageOpt) error {
+	if !ref.EqualRepository(refSrc, refTgt) {
+		exists, ok := found[d.Digest]
+		if !ok {
+			_, err := rc.BlobHead(ctx, refTgt, d)
+			if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to check blob %s: %w", d.Digest.String(), err)
+			}
+			exists = err == nil
+		}
+		if !exists {
+			// blobs on the same registry are expected to be mounted
+			result := blobCopyPushed
+			if ref.EqualRegistry(refSrc, refTgt) {
+				result = blobCopyMounted
+			}
+			imageReportAdd(opt, false, result, refTgt, d, 0)
+			return nil
+		}
+	}
+	imageReportAdd(opt, false, blobCopySkipped, refTgt, d, 0)
+	if opt.callback != nil {
+		opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackSkipped, d.Size, d.Size)
+	}
+	return nil
+}
+
+// imageReportAdd adds a manifest or blob to the copy report, skipped blobs are only counted.
+func imageReportAdd(opt *imageOpt, isManifest bool, result blobCopyResult, r ref.Ref, d types.Descriptor, elapsed time.Duration) {
+	if opt.report == nil {
+		return
+	}
+	entry := ImageCopyReportEntry{
+		Ref:       r.CommonName(),
+		MediaType: d.MediaType,
+		Digest:    d.Digest,
+		Size:      d.Size,
+		Mounted:   result == blobCopyMounted,
+		Elapsed:   timejson.Duration(elapsed),
+	}
+	opt.mu.Lock()
+	defer opt.mu.Unlock()
+	switch {
+	case isManifest:
+		opt.report.Manifests = append(opt.report.Manifests, entry)
+	case result == blobCopySkipped:
+		opt.report.BlobsSkipped++
+		return
+	case result == blobCopyMounted:
+		opt.report.Blobs = append(opt.report.Blobs, entry)
+		opt.report.BlobsMounted++
+	default:
+		opt.report.Blobs = append(opt.report.Blobs, entry)
+		opt.report.BlobsPushed++
+	}
+	opt.report.Size += d.Size
+	if result != blobCopyMounted {
+		opt.report.BytesTransferred += d.Size
+	}
+}
+
 // imageSeenOrWait returns either a callback to report the error when the digest hasn't been seen before
 // or it will wait for the previous copy to run and return the error from that copy
 func imageSeenOrWait(ctx context.Context, opt *imageOpt, tag string, dig digest.Digest, parents []digest.Digest) (func(error), error) {
@@ -981,10 +1910,11 @@ func (rc *RegClient) ImageExport(ctx context.Context, r ref.Ref, outStream io.Wr
 	tw := tar.NewWriter(out)
 	defer tw.Close()
 	twd := &tarWriteData{
-		tw:    tw,
-		dirs:  map[string]bool{},
-		files: map[string]bool{},
-		mode:  0644,
+		tw:        tw,
+		dirs:      map[string]bool{},
+		files:     map[string]bool{},
+		mode:      0644,
+		sumEnable: opt.exportChecksums != nil,
 	}
 
 	// retrieve image manifest
@@ -997,6 +1927,26 @@ func (rc *RegClient) ImageExport(ctx context.Context, r ref.Ref, outStream io.Wr
 		return err
 	}
 
+	// prune a manifest list to the requested platforms
+	var dlPruned []types.Descriptor
+	if mi, ok := m.(manifest.Indexer); ok && len(opt.platforms) > 0 {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return err
+		}
+		dlNew, err := imagePlatformPrune(dl, opt.platforms)
+		if err != nil {
+			return err
+		}
+		if len(dlNew) < len(dl) {
+			err = mi.SetManifestList(dlNew)
+			if err != nil {
+				return err
+			}
+			dlPruned = dlNew
+		}
+	}
+
 	// build/write oci-layout
 	ociLayout := v1.ImageLayout{Version: ociLayoutVersion}
 	err = twd.tarWriteFileJSON(ociLayoutFilename, ociLayout)
@@ -1056,9 +2006,38 @@ func (rc *RegClient) ImageExport(ctx context.Context, r ref.Ref, outStream io.Wr
 	}
 
 	// recursively include manifests and nested blobs
-	err = rc.imageExportDescriptor(ctx, r, mDesc, twd)
-	if err != nil {
-		return err
+	if dlPruned != nil {
+		// the pruned manifest list is not in the source, write it before the remaining entries
+		mBody, err := m.RawBody()
+		if err != nil {
+			return err
+		}
+		err = twd.tarWriteHeader(tarOCILayoutDescPath(mDesc), int64(len(mBody)))
+		if err != nil {
+			return err
+		}
+		_, err = twd.Write(mBody)
+		if err != nil {
+			return err
+		}
+		for _, d := range dlPruned {
+			err = rc.imageExportDescriptor(ctx, r, d, twd)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err = rc.imageExportDescriptor(ctx, r, mDesc, twd)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opt.exportChecksums != nil {
+		err = twd.tarWriteChecksums(opt.exportChecksums, mDesc.Digest)
+		if err != nil {
+			return fmt.Errorf("failed to write checksums: %w", err)
+		}
 	}
 
 	return nil
@@ -1092,7 +2071,7 @@ func (rc *RegClient) imageExportDescriptor(ctx context.Context, r ref.Ref, desc
 		if err != nil {
 			return err
 		}
-		_, err = twd.tw.Write(mBody)
+		_, err = twd.Write(mBody)
 		if err != nil {
 			return err
 		}
@@ -1145,7 +2124,7 @@ func (rc *RegClient) imageExportDescriptor(ctx context.Context, r ref.Ref, desc
 		if err != nil {
 			return err
 		}
-		_, err = twd.tw.Write(mBody)
+		_, err = twd.Write(mBody)
 		if err != nil {
 			return err
 		}
@@ -1173,7 +2152,7 @@ func (rc *RegClient) imageExportDescriptor(ctx context.Context, r ref.Ref, desc
 		if err != nil {
 			return err
 		}
-		size, err := io.Copy(twd.tw, blobR)
+		size, err := io.Copy(twd, blobR)
 		if err != nil {
 			return fmt.Errorf("failed to export blob %s: %w", desc.Digest.String(), err)
 		}
@@ -1195,6 +2174,13 @@ func (rc *RegClient) ImageImport(ctx context.Context, r ref.Ref, rs io.ReadSeeke
 		optFn(&opt)
 	}
 
+	if opt.importChecksums != nil {
+		err := imageImportChecksums(rs, opt.importChecksums)
+		if err != nil {
+			return err
+		}
+	}
+
 	trd := &tarReadData{
 		name:      opt.importName,
 		handlers:  map[string]tarFileHandler{},
@@ -1221,7 +2207,7 @@ func (rc *RegClient) ImageImport(ctx context.Context, r ref.Ref, rs io.ReadSeeke
 			return fmt.Errorf("failed to import layers from docker tar: %w", err)
 		}
 		// push docker manifest
-		m, err := manifest.New(manifest.WithOrig(trd.dockerManifest))
+		m, err := manifest.New(manifest.WithOrig(trd.dockerManifest), manifest.WithDigestAlgorithm(rc.DigestAlgorithm(r)))
 		if err != nil {
 			return err
 		}
@@ -1242,6 +2228,109 @@ func (rc *RegClient) ImageImport(ctx context.Context, r ref.Ref, rs io.ReadSeeke
 	return nil
 }
 
+// imageImportChecksums verifies every file in the tar matches the checksums file.
+func imageImportChecksums(rs io.ReadSeeker, sumsRdr io.Reader) error {
+	// parse the checksums file
+	sums := map[string]string{}
+	top := ""
+	scanner := bufio.NewScanner(sumsRdr)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, checksumsDigestPrefix) {
+			top = strings.TrimSpace(line[len(checksumsDigestPrefix):])
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		sum, name, ok := strings.Cut(line, " ")
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		if !ok || sum == "" || name == "" {
+			return fmt.Errorf("invalid checksums entry \"%s\"%.0w", line, types.ErrParsingFailed)
+		}
+		sums[filepath.Clean(name)] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read checksums: %w", err)
+	}
+
+	// compare each file in the tar
+	_, err := rs.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	dr, err := archive.Decompress(rs)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(dr)
+	var index v1.Index
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := filepath.Clean(header.Name)
+		sum, ok := sums[name]
+		if !ok {
+			return fmt.Errorf("file is not included in checksums: %s%.0w", name, types.ErrMismatch)
+		}
+		delete(sums, name)
+		digester := digest.SHA256.Digester()
+		var rdr io.Reader = tr
+		var indexBuf strings.Builder
+		if name == ociIndexFilename {
+			rdr = io.TeeReader(tr, &indexBuf)
+		}
+		_, err = io.Copy(digester.Hash(), rdr)
+		if err != nil {
+			return err
+		}
+		if digester.Digest().Encoded() != sum {
+			return fmt.Errorf("checksum mismatch for %s, expected %s, received %s%.0w", name, sum, digester.Digest().Encoded(), types.ErrDigestMismatch)
+		}
+		if name == ociIndexFilename {
+			err = json.Unmarshal([]byte(indexBuf.String()), &index)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(sums) > 0 {
+		missing := make([]string, 0, len(sums))
+		for name := range sums {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("files are missing from the tar: %s%.0w", strings.Join(missing, ", "), types.ErrMismatch)
+	}
+
+	// verify the top level digest is referenced by the index
+	if top != "" {
+		found := false
+		for _, d := range index.Manifests {
+			if d.Digest.String() == top {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("digest %s is not found in %s%.0w", top, ociIndexFilename, types.ErrDigestMismatch)
+		}
+	}
+
+	_, err = rs.Seek(0, io.SeekStart)
+	return err
+}
+
 func (rc *RegClient) imageImportBlob(ctx context.Context, r ref.Ref, desc types.Descriptor, trd *tarReadData) error {
 	// skip if blob already exists
 	_, err := rc.BlobHead(ctx, r, desc)
@@ -1589,21 +2678,272 @@ func imagePlatformInList(target *platform.Platform, list []string) (bool, error)
 		}
 		return false, nil
 	}
+	exprs := []string{}
 	for _, entry := range list {
-		if entry == "" {
-			continue
+		if entry != "" {
+			exprs = append(exprs, entry)
+		}
+	}
+	if len(exprs) == 0 {
+		return false, nil
+	}
+	pm, err := platform.NewMatcher(exprs...)
+	if err != nil {
+		return false, err
+	}
+	return pm.Match(*target), nil
+}
+
+// imageFormatMT maps media types between the OCI and Docker formats.
+var imageFormatMT = map[string]map[string]string{
+	imageFormatOCI: {
+		types.MediaTypeDocker2ManifestList: types.MediaTypeOCI1ManifestList,
+		types.MediaTypeDocker2Manifest:     types.MediaTypeOCI1Manifest,
+		types.MediaTypeDocker2ImageConfig:  types.MediaTypeOCI1ImageConfig,
+		types.MediaTypeDocker2LayerGzip:    types.MediaTypeOCI1LayerGzip,
+		types.MediaTypeDocker2ForeignLayer: types.MediaTypeOCI1ForeignLayerGzip,
+	},
+	imageFormatDocker: {
+		types.MediaTypeOCI1ManifestList:     types.MediaTypeDocker2ManifestList,
+		types.MediaTypeOCI1Manifest:         types.MediaTypeDocker2Manifest,
+		types.MediaTypeOCI1ImageConfig:      types.MediaTypeDocker2ImageConfig,
+		types.MediaTypeOCI1LayerGzip:        types.MediaTypeDocker2LayerGzip,
+		types.MediaTypeOCI1ForeignLayerGzip: types.MediaTypeDocker2ForeignLayer,
+	},
+}
+
+const (
+	imageFormatOCI    = "oci"
+	imageFormatDocker = "docker"
+)
+
+// imageFormatConvert returns the manifest converted to the format, or the same manifest when nothing changes.
+// The entries of an index are updated with the descriptors of converted children, keyed by the source digest.
+func imageFormatConvert(m manifest.Manifest, format string, children map[digest.Digest]types.Descriptor) (manifest.Manifest, error) {
+	mtMap := imageFormatMT[format]
+	changed := false
+	var om interface{}
+	switch orig := m.GetOrig().(type) {
+	case v1.Index, schema2.ManifestList:
+		ociI, err := manifest.OCIIndexFromAny(orig)
+		if err != nil {
+			return nil, err
+		}
+		// copy the list since the source manifest may be cached
+		ociI.Manifests = append([]types.Descriptor{}, ociI.Manifests...)
+		for i, d := range ociI.Manifests {
+			if dNew, ok := children[d.Digest]; ok {
+				ociI.Manifests[i].MediaType = dNew.MediaType
+				ociI.Manifests[i].Digest = dNew.Digest
+				ociI.Manifests[i].Size = dNew.Size
+				changed = true
+			}
+			// docker attestations reference another entry by digest
+			if refDig := d.Annotations[annotationDockerReferenceDigest]; refDig != "" {
+				if dNew, ok := children[digest.Digest(refDig)]; ok {
+					annot := make(map[string]string, len(d.Annotations))
+					for k, v := range d.Annotations {
+						annot[k] = v
+					}
+					annot[annotationDockerReferenceDigest] = dNew.Digest.String()
+					ociI.Manifests[i].Annotations = annot
+					changed = true
+				}
+			}
 		}
-		plat, err := platform.Parse(entry)
+		if _, ok := mtMap[m.GetDescriptor().MediaType]; ok {
+			changed = true
+		}
+		if !changed {
+			return m, nil
+		}
+		if format == imageFormatDocker {
+			if ociI.ArtifactType != "" || ociI.Subject != nil {
+				return nil, fmt.Errorf("unable to convert an index with an artifactType or subject to docker%.0w", types.ErrUnsupportedMediaType)
+			}
+			ml := schema2.ManifestList{}
+			err = manifest.OCIIndexToAny(ociI, &ml)
+			if err != nil {
+				return nil, err
+			}
+			om = ml
+		} else {
+			ociI.Versioned = v1.IndexSchemaVersion
+			ociI.MediaType = types.MediaTypeOCI1ManifestList
+			om = ociI
+		}
+	case v1.Manifest, schema2.Manifest:
+		ociM, err := manifest.OCIManifestFromAny(orig)
 		if err != nil {
-			return false, err
+			return nil, err
+		}
+		if format == imageFormatDocker {
+			if ociM.ArtifactType != "" || ociM.Subject != nil || ociM.Config.MediaType != types.MediaTypeOCI1ImageConfig {
+				// artifacts have no docker equivalent
+				return m, nil
+			}
+			for _, l := range ociM.Layers {
+				switch l.MediaType {
+				case types.MediaTypeOCI1Layer, types.MediaTypeOCI1LayerZstd, types.MediaTypeOCI1ForeignLayer, types.MediaTypeOCI1ForeignLayerZstd:
+					return nil, fmt.Errorf("unable to convert layer media type %s to docker%.0w", l.MediaType, types.ErrUnsupportedMediaType)
+				case types.MediaTypeOCI1LayerGzip, types.MediaTypeOCI1ForeignLayerGzip, types.MediaTypeDocker2LayerGzip, types.MediaTypeDocker2ForeignLayer:
+				default:
+					// unknown layers are artifacts, e.g. docker attestations
+					return m, nil
+				}
+			}
+		}
+		if mt, ok := mtMap[ociM.Config.MediaType]; ok {
+			ociM.Config.MediaType = mt
+			changed = true
+		}
+		ociM.Layers = append([]types.Descriptor{}, ociM.Layers...)
+		for i, l := range ociM.Layers {
+			if mt, ok := mtMap[l.MediaType]; ok {
+				ociM.Layers[i].MediaType = mt
+				changed = true
+			}
+		}
+		if _, ok := mtMap[m.GetDescriptor().MediaType]; ok {
+			changed = true
 		}
-		if platform.Match(*target, plat) {
+		if !changed {
+			return m, nil
+		}
+		if format == imageFormatDocker {
+			sm := schema2.Manifest{}
+			err = manifest.OCIManifestToAny(ociM, &sm)
+			if err != nil {
+				return nil, err
+			}
+			om = sm
+		} else {
+			om = ociM
+		}
+	default:
+		return nil, fmt.Errorf("unable to convert media type %s%.0w", m.GetDescriptor().MediaType, types.ErrUnsupportedMediaType)
+	}
+	return manifest.New(manifest.WithOrig(om))
+}
+
+// imageFormatConvertIndexNeeded returns true when an index or any of the child manifests will be converted.
+// Children are checked by the media type in the descriptor.
+func imageFormatConvertIndexNeeded(m manifest.Manifest, format string) (bool, error) {
+	mtMap := imageFormatMT[format]
+	if _, ok := mtMap[m.GetDescriptor().MediaType]; ok {
+		return true, nil
+	}
+	dl, err := m.(manifest.Indexer).GetManifestList()
+	if err != nil {
+		return false, err
+	}
+	for _, d := range dl {
+		if _, ok := mtMap[d.MediaType]; ok {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
+// imageFormatConverted records a converted manifest for the parent and returns the values used to push it.
+// A target referenced by digest is updated to the converted digest and checked again.
+func (rc *RegClient) imageFormatConverted(ctx context.Context, opt *imageOpt, mSrc, mConv manifest.Manifest, refTgt ref.Ref, mTgt manifest.Manifest) (manifest.Manifest, digest.Digest, ref.Ref, manifest.Manifest) {
+	dConv := mConv.GetDescriptor()
+	opt.mu.Lock()
+	opt.formatConverted[mSrc.GetDescriptor().Digest] = dConv
+	opt.mu.Unlock()
+	rc.log.WithFields(logrus.Fields{
+		"source":    mSrc.GetDescriptor().Digest.String(),
+		"converted": dConv.Digest.String(),
+		"mediaType": dConv.MediaType,
+	}).Debug("Converted manifest format")
+	if refTgt.Digest != "" {
+		refTgt = refTgt.SetDigest(dConv.Digest.String())
+		var err error
+		mTgt, err = rc.ManifestHead(ctx, refTgt, WithManifestRequireDigest())
+		if err != nil {
+			mTgt = nil
+		}
+	}
+	return mConv, dConv.Digest, refTgt, mTgt
+}
+
+// imageManifestStripDesc returns a copy of the manifest without the annotations on the descriptor.
+func imageManifestStripDesc(m manifest.Manifest) (manifest.Manifest, error) {
+	raw, err := m.RawBody()
+	if err != nil {
+		return nil, err
+	}
+	desc := m.GetDescriptor()
+	desc.Annotations = nil
+	return manifest.New(manifest.WithRef(m.GetRef()), manifest.WithDesc(desc), manifest.WithRaw(raw))
+}
+
+// imagePlatformsManifest returns the manifest list filtered to the platforms.
+// The source manifest is returned when every entry matches, and a single image is returned as a descriptor without a manifest.
+func imagePlatformsManifest(refSrc ref.Ref, mSrc manifest.Manifest, platforms []string) (manifest.Manifest, *types.Descriptor, error) {
+	mSrcIndex, ok := mSrc.(manifest.Indexer)
+	if !ok {
+		return mSrc, nil, nil
+	}
+	dl, err := mSrcIndex.GetManifestList()
+	if err != nil {
+		return nil, nil, err
+	}
+	dlNew, err := imagePlatformPrune(dl, platforms)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(dlNew) == 0 {
+		return nil, nil, fmt.Errorf("no platforms matched in %s%.0w", refSrc.CommonName(), types.ErrNotFound)
+	}
+	if len(dlNew) == 1 && (dlNew[0].MediaType == types.MediaTypeDocker2Manifest || dlNew[0].MediaType == types.MediaTypeOCI1Manifest) {
+		return nil, &dlNew[0], nil
+	}
+	if len(dlNew) == len(dl) {
+		return mSrc, nil, nil
+	}
+	// modify a copy since the source manifest may be cached
+	mBody, err := mSrc.RawBody()
+	if err != nil {
+		return nil, nil, err
+	}
+	mNew, err := manifest.New(manifest.WithRef(refSrc), manifest.WithDesc(mSrc.GetDescriptor()), manifest.WithRaw(mBody))
+	if err != nil {
+		return nil, nil, err
+	}
+	err = mNew.(manifest.Indexer).SetManifestList(dlNew)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mNew, nil, nil
+}
+
+// imagePlatformPrune returns the entries of a manifest list matching the platforms.
+// Entries referencing another entry, like docker attestations, are included when the referenced entry is included.
+func imagePlatformPrune(dl []types.Descriptor, platforms []string) ([]types.Descriptor, error) {
+	keep := map[digest.Digest]bool{}
+	for _, d := range dl {
+		if d.Annotations[annotationDockerReferenceDigest] != "" {
+			continue
+		}
+		match, err := imagePlatformInList(d.Platform, platforms)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			keep[d.Digest] = true
+		}
+	}
+	dlNew := []types.Descriptor{}
+	for _, d := range dl {
+		if keep[d.Digest] || keep[digest.Digest(d.Annotations[annotationDockerReferenceDigest])] {
+			dlNew = append(dlNew, d)
+		}
+	}
+	return dlNew, nil
+}
+
 // tarReadAll processes the tar file in a loop looking for matching filenames in the list of handlers.
 // Handlers for filenames are added at the top level, and by manifest imports.
 func (trd *tarReadData) tarReadAll(rs io.ReadSeeker) error {
@@ -1752,6 +3092,9 @@ func (td *tarWriteData) tarWriteHeader(filename string, size int64) error {
 		return fmt.Errorf("%w: %s", errTarFileExists, filename)
 	}
 	td.files[filename] = true
+	if td.sumEnable {
+		td.sums = append(td.sums, tarFileSum{name: filename, digester: digest.SHA256.Digester()})
+	}
 	header := tar.Header{
 		Format:     tar.FormatPAX,
 		Typeflag:   tar.TypeReg,
@@ -1765,6 +3108,30 @@ func (td *tarWriteData) tarWriteHeader(filename string, size int64) error {
 	return td.tw.WriteHeader(&header)
 }
 
+// Write sends content for the current file to the tar, updating the checksum when enabled.
+func (td *tarWriteData) Write(p []byte) (int, error) {
+	if td.sumEnable && len(td.sums) > 0 {
+		_, _ = td.sums[len(td.sums)-1].digester.Hash().Write(p)
+	}
+	return td.tw.Write(p)
+}
+
+// tarWriteChecksums outputs the checksum of every file written to the tar and the top level digest.
+func (td *tarWriteData) tarWriteChecksums(w io.Writer, top digest.Digest) error {
+	bw := bufio.NewWriter(w)
+	_, err := fmt.Fprintf(bw, "%s %s\n", checksumsDigestPrefix, top.String())
+	if err != nil {
+		return err
+	}
+	for _, sum := range td.sums {
+		_, err = fmt.Fprintf(bw, "%s  %s\n", sum.digester.Digest().Encoded(), sum.name)
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
 func (td *tarWriteData) tarWriteFileJSON(filename string, data interface{}) error {
 	dataJSON, err := json.Marshal(data)
 	if err != nil {
@@ -1774,7 +3141,7 @@ func (td *tarWriteData) tarWriteFileJSON(filename string, data interface{}) erro
 	if err != nil {
 		return err
 	}
-	_, err = td.tw.Write(dataJSON)
+	_, err = td.Write(dataJSON)
 	if err != nil {
 		return err
 	}
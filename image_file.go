@@ -0,0 +1,327 @@
+package regclient
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/pkg/estargz"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// ImageFile is an entry in the filesystem of an image returned by [RegClient.ImageFileList].
+type ImageFile struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"` // "file", "dir", "symlink", "link", or "other"
+	Mode     fs.FileMode `json:"mode"`
+	UID      int         `json:"uid"`
+	GID      int         `json:"gid"`
+	Size     int64       `json:"size"`
+	ModTime  time.Time   `json:"modTime"`
+	Linkname string      `json:"linkname,omitempty"`
+	// Layer is the digest of the layer containing the file.
+	Layer digest.Digest `json:"layer"`
+}
+
+// ImageFileList returns the files in the filesystem of an image, sorted by name.
+// Each layer is applied in order, removing files with whiteouts, so the result matches the filesystem of a container.
+// A platform may be selected with [ImageWithPlatform], defaulting to the local platform for a manifest list.
+func (rc *RegClient) ImageFileList(ctx context.Context, r ref.Ref, opts ...ImageOpts) ([]ImageFile, error) {
+	var opt imageOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	rImg, layers, err := rc.imageFileLayers(ctx, r, &opt)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]ImageFile{}
+	for i, l := range layers {
+		err = rc.imageFileLayer(ctx, rImg, l, files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read layer %d: %w", i, err)
+		}
+	}
+	fl := make([]ImageFile, 0, len(files))
+	for _, f := range files {
+		fl = append(fl, f)
+	}
+	sort.Slice(fl, func(i, j int) bool {
+		return fl[i].Name < fl[j].Name
+	})
+	return fl, nil
+}
+
+// ImageFileGet returns the header and content of a file in the filesystem of an image.
+// Layers are searched from the top, so only the layers above the one containing the file are pulled.
+// The returned reader must be closed.
+// A platform may be selected with [ImageWithPlatform], defaulting to the local platform for a manifest list.
+func (rc *RegClient) ImageFileGet(ctx context.Context, r ref.Ref, filename string, opts ...ImageOpts) (*tar.Header, io.ReadCloser, error) {
+	var opt imageOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	rImg, layers, err := rc.imageFileLayers(ctx, r, &opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		if tocDig, ok := layers[i].Annotations[estargz.AnnotationTOCDigest]; ok && layers[i].Size > 0 {
+			th, rdr, err := rc.imageFileGetEStargz(ctx, rImg, layers[i], tocDig, filename)
+			switch {
+			case err == nil:
+				return th, rdr, nil
+			case errors.Is(err, types.ErrFileNotFound):
+				continue
+			case errors.Is(err, types.ErrFileDeleted):
+				return nil, nil, fmt.Errorf("file %s deleted in layer %d%.0w", filename, i, types.ErrFileNotFound)
+			}
+			// fall back to reading the full layer
+			rc.log.WithFields(logrus.Fields{
+				"layer": layers[i].Digest.String(),
+				"err":   err,
+			}).Debug("failed to read eStargz layer")
+		}
+		b, err := rc.BlobGet(ctx, rImg, layers[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed pulling layer %d: %w", i, err)
+		}
+		btr, err := b.ToTarReader()
+		if err != nil {
+			_ = b.Close()
+			return nil, nil, fmt.Errorf("failed reading layer %d: %w", i, err)
+		}
+		th, rdr, err := btr.ReadFile(filename)
+		if err != nil {
+			_ = btr.Close()
+			if errors.Is(err, types.ErrFileNotFound) {
+				continue
+			}
+			if errors.Is(err, types.ErrFileDeleted) {
+				return nil, nil, fmt.Errorf("file %s deleted in layer %d%.0w", filename, i, types.ErrFileNotFound)
+			}
+			return nil, nil, fmt.Errorf("failed reading layer %d: %w", i, err)
+		}
+		return th, imageFileReader{Reader: rdr, btr: btr}, nil
+	}
+	return nil, nil, fmt.Errorf("file %s not found in %s%.0w", filename, r.CommonName(), types.ErrFileNotFound)
+}
+
+// imageFileGetEStargz reads a file from an eStargz layer using range requests for the TOC and file content.
+func (rc *RegClient) imageFileGetEStargz(ctx context.Context, r ref.Ref, d types.Descriptor, tocDig, filename string) (*tar.Header, io.ReadCloser, error) {
+	dig, err := digest.Parse(tocDig)
+	if err != nil {
+		return nil, nil, err
+	}
+	er, err := estargz.Open(blobReaderAt{ctx: ctx, rc: rc, r: r, d: d}, d.Size, estargz.WithTOCDigest(dig))
+	if err != nil {
+		return nil, nil, err
+	}
+	filename = strings.TrimPrefix(path.Clean("/"+filename), "/")
+	e, ok := er.Lookup(filename)
+	if !ok {
+		for _, e := range er.TOC().Entries {
+			if strings.HasPrefix(path.Base(e.Name), whiteoutPrefix) && imageFileWhiteout(e.Name, filename) {
+				return nil, nil, types.ErrFileDeleted
+			}
+		}
+		return nil, nil, fmt.Errorf("file %s%.0w", filename, types.ErrFileNotFound)
+	}
+	// match the tar reader, where only regular files have content
+	if e.Type != "reg" {
+		return e.Header(), io.NopCloser(strings.NewReader("")), nil
+	}
+	rdr, err := er.OpenFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e.Header(), io.NopCloser(rdr), nil
+}
+
+// imageFileWhiteout returns true when the whiteout removes the file or one of its parents.
+func imageFileWhiteout(whiteout, filename string) bool {
+	dir, base := path.Split(whiteout)
+	if base == whiteoutOpaque {
+		return strings.HasPrefix(filename, dir)
+	}
+	target := dir + strings.TrimPrefix(base, whiteoutPrefix)
+	return filename == target || strings.HasPrefix(filename, target+"/")
+}
+
+// blobReaderAt implements [io.ReaderAt] with a range request for each read.
+type blobReaderAt struct {
+	ctx context.Context
+	rc  *RegClient
+	r   ref.Ref
+	d   types.Descriptor
+}
+
+func (b blobReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	rdr, err := b.rc.BlobGetRange(b.ctx, b.r, b.d, off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	defer rdr.Close()
+	return io.ReadFull(rdr, p)
+}
+
+type imageFileReader struct {
+	io.Reader
+	btr *blob.BTarReader
+}
+
+func (ifr imageFileReader) Close() error {
+	return ifr.btr.Close()
+}
+
+// imageFileLayers returns the image ref and layers, resolving the platform of a manifest list.
+func (rc *RegClient) imageFileLayers(ctx context.Context, r ref.Ref, opt *imageOpt) (ref.Ref, []types.Descriptor, error) {
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return r, nil, err
+	}
+	if m.IsList() {
+		p := platform.Local()
+		if opt.platform != "" {
+			p, err = platform.Parse(opt.platform)
+			if err != nil {
+				return r, nil, err
+			}
+		}
+		d, err := manifest.GetPlatformDesc(m, &p)
+		if err != nil {
+			return r, nil, err
+		}
+		m, err = rc.ManifestGet(ctx, r, WithManifestDesc(*d))
+		if err != nil {
+			return r, nil, err
+		}
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return r, nil, fmt.Errorf("reference is not an image: %s%.0w", r.CommonName(), types.ErrUnsupportedMediaType)
+	}
+	layers, err := mi.GetLayers()
+	if err != nil {
+		return r, nil, err
+	}
+	return r.SetDigest(m.GetDescriptor().Digest.String()), layers, nil
+}
+
+// imageFileLayer applies the changes in a layer to the files.
+func (rc *RegClient) imageFileLayer(ctx context.Context, r ref.Ref, d types.Descriptor, files map[string]ImageFile) error {
+	b, err := rc.BlobGet(ctx, r, d)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+	btr, err := b.ToTarReader()
+	if err != nil {
+		return err
+	}
+	defer btr.Close()
+	tr, err := btr.GetTarReader()
+	if err != nil {
+		return err
+	}
+	// whiteouts only apply to lower layers, so the entries are applied after reading the full layer
+	added := []ImageFile{}
+	removed := []string{}
+	opaque := []string{}
+	for {
+		th, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		name := strings.TrimPrefix(path.Clean("/"+th.Name), "/")
+		if name == "" {
+			continue
+		}
+		dir, base := path.Split(name)
+		switch {
+		case base == whiteoutOpaque:
+			opaque = append(opaque, strings.TrimSuffix(dir, "/"))
+		case strings.HasPrefix(base, whiteoutPrefix):
+			removed = append(removed, dir+strings.TrimPrefix(base, whiteoutPrefix))
+		default:
+			added = append(added, ImageFile{
+				Name:     name,
+				Type:     imageFileType(th.Typeflag),
+				Mode:     th.FileInfo().Mode(),
+				UID:      th.Uid,
+				GID:      th.Gid,
+				Size:     th.Size,
+				ModTime:  th.ModTime.UTC(),
+				Linkname: th.Linkname,
+				Layer:    d.Digest,
+			})
+		}
+	}
+	for _, dir := range opaque {
+		imageFileRemove(files, dir, false)
+	}
+	for _, name := range removed {
+		imageFileRemove(files, name, true)
+	}
+	for _, f := range added {
+		// replacing a directory with another type removes the content of the directory
+		if prev, ok := files[f.Name]; ok && prev.Type == "dir" && f.Type != "dir" {
+			imageFileRemove(files, f.Name, false)
+		}
+		files[f.Name] = f
+	}
+	return nil
+}
+
+// imageFileRemove deletes the content of a directory, and the entry itself when self is true.
+func imageFileRemove(files map[string]ImageFile, name string, self bool) {
+	if self {
+		delete(files, name)
+	}
+	prefix := name + "/"
+	if name == "" {
+		prefix = ""
+	}
+	for f := range files {
+		if strings.HasPrefix(f, prefix) {
+			delete(files, f)
+		}
+	}
+}
+
+func imageFileType(flag byte) string {
+	switch flag {
+	case tar.TypeReg:
+		return "file"
+	case tar.TypeDir:
+		return "dir"
+	case tar.TypeSymlink:
+		return "symlink"
+	case tar.TypeLink:
+		return "link"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,206 @@
+package regclient
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/pkg/estargz"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestImageFile(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testfiles:v1")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+
+	// each layer is a list of name and content, directories end with a slash
+	layerFiles := [][][2]string{
+		{{"etc/", ""}, {"etc/a", "a1"}, {"etc/b", "b1"}, {"dir/", ""}, {"dir/x", "x1"}, {"replaced/", ""}, {"replaced/z", "z1"}},
+		{{"etc/.wh.a", ""}, {"dir/.wh..wh..opq", ""}, {"dir/y", "y2"}, {"etc/c", "c2"}, {"replaced", "file2"}},
+		{{"./etc/b", "b3"}},
+	}
+	rEStargz, err := ref.New("ocidir://testfiles:estargz")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+	layers := []types.Descriptor{}
+	layersEStargz := []types.Descriptor{}
+	diffIDs := []digest.Digest{}
+	for _, files := range layerFiles {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		for _, f := range files {
+			hdr := &tar.Header{Name: f[0], Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(f[1]))}
+			if f[0][len(f[0])-1] == '/' {
+				hdr.Mode = 0755
+				hdr.Typeflag = tar.TypeDir
+			}
+			err = tw.WriteHeader(hdr)
+			if err != nil {
+				t.Fatalf("failed to write header: %v", err)
+			}
+			_, err = tw.Write([]byte(f[1]))
+			if err != nil {
+				t.Fatalf("failed to write content: %v", err)
+			}
+		}
+		err = tw.Close()
+		if err != nil {
+			t.Fatalf("failed to close tar: %v", err)
+		}
+		d := types.Descriptor{
+			MediaType: types.MediaTypeOCI1Layer,
+			Digest:    digest.FromBytes(buf.Bytes()),
+			Size:      int64(buf.Len()),
+		}
+		_, err = rc.BlobPut(ctx, r, d, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to push layer: %v", err)
+		}
+		layers = append(layers, d)
+		diffIDs = append(diffIDs, d.Digest)
+		// the same layer converted to eStargz
+		esgz := &bytes.Buffer{}
+		res, err := estargz.Build(esgz, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to build eStargz layer: %v", err)
+		}
+		dEStargz := types.Descriptor{
+			MediaType:   types.MediaTypeOCI1LayerGzip,
+			Digest:      digest.FromBytes(esgz.Bytes()),
+			Size:        res.Size,
+			Annotations: res.Annotations(),
+		}
+		_, err = rc.BlobPut(ctx, rEStargz, dEStargz, bytes.NewReader(esgz.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to push layer: %v", err)
+		}
+		layersEStargz = append(layersEStargz, dEStargz)
+	}
+	conf, err := json.Marshal(v1.Image{
+		Platform: platform.Platform{OS: "linux", Architecture: "amd64"},
+		RootFS:   v1.RootFS{Type: "layers", DiffIDs: diffIDs},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	dConf := types.Descriptor{
+		MediaType: types.MediaTypeOCI1ImageConfig,
+		Digest:    digest.FromBytes(conf),
+		Size:      int64(len(conf)),
+	}
+	for _, img := range []struct {
+		r      ref.Ref
+		layers []types.Descriptor
+	}{{r, layers}, {rEStargz, layersEStargz}} {
+		_, err = rc.BlobPut(ctx, img.r, dConf, bytes.NewReader(conf))
+		if err != nil {
+			t.Fatalf("failed to push config: %v", err)
+		}
+		m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+			Versioned: v1.ManifestSchemaVersion,
+			MediaType: types.MediaTypeOCI1Manifest,
+			Config:    dConf,
+			Layers:    img.layers,
+		}))
+		if err != nil {
+			t.Fatalf("failed to create manifest: %v", err)
+		}
+		err = rc.ManifestPut(ctx, img.r, m)
+		if err != nil {
+			t.Fatalf("failed to push manifest: %v", err)
+		}
+	}
+
+	t.Run("List", func(t *testing.T) {
+		fl, err := rc.ImageFileList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list files: %v", err)
+		}
+		expect := []struct {
+			name  string
+			typ   string
+			layer int
+		}{
+			{"dir", "dir", 0},
+			{"dir/y", "file", 1},
+			{"etc", "dir", 0},
+			{"etc/b", "file", 2},
+			{"etc/c", "file", 1},
+			{"replaced", "file", 1},
+		}
+		if len(fl) != len(expect) {
+			t.Fatalf("unexpected files, expected %d, received %v", len(expect), fl)
+		}
+		for i, e := range expect {
+			if fl[i].Name != e.name || fl[i].Type != e.typ || fl[i].Layer != layers[e.layer].Digest {
+				t.Errorf("unexpected file %d, expected %s %s in layer %d, received %v", i, e.typ, e.name, e.layer, fl[i])
+			}
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		tt := []struct {
+			name      string
+			filename  string
+			expect    string
+			expectErr error
+		}{
+			{name: "top layer", filename: "etc/b", expect: "b3"},
+			{name: "lower layer", filename: "/etc/c", expect: "c2"},
+			{name: "whiteout", filename: "etc/a", expectErr: types.ErrFileNotFound},
+			{name: "opaque", filename: "dir/x", expectErr: types.ErrFileNotFound},
+			{name: "missing", filename: "missing", expectErr: types.ErrFileNotFound},
+		}
+		for _, tc := range tt {
+			for _, tr := range []ref.Ref{r, rEStargz} {
+				tr := tr
+				t.Run(tc.name+" "+tr.Tag, func(t *testing.T) {
+					th, rdr, err := rc.ImageFileGet(ctx, tr, tc.filename)
+					if tc.expectErr != nil {
+						if !errors.Is(err, tc.expectErr) {
+							t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+						}
+						return
+					}
+					if err != nil {
+						t.Fatalf("failed to get file: %v", err)
+					}
+					b, err := io.ReadAll(rdr)
+					if err != nil {
+						t.Fatalf("failed to read file: %v", err)
+					}
+					err = rdr.Close()
+					if err != nil {
+						t.Errorf("failed to close file: %v", err)
+					}
+					if string(b) != tc.expect || th.Size != int64(len(tc.expect)) {
+						t.Errorf("unexpected content, expected %s, received %s", tc.expect, string(b))
+					}
+				})
+			}
+		}
+	})
+}
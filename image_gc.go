@@ -0,0 +1,269 @@
+package regclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// imageGCDigestTagRE matches digest tags, returning the encoded digest of the subject
+var imageGCDigestTagRE = regexp.MustCompile(`^sha256-([0-9a-f]{64})`)
+
+// repoManifestLister is implemented by schemes that can list every manifest in a repository, including untagged manifests.
+type repoManifestLister interface {
+	RepoManifests(ctx context.Context, r ref.Ref) ([]types.Descriptor, error)
+}
+
+// ImageGCReport is the result of [RegClient.ImageGC].
+type ImageGCReport struct {
+	Ref        string         `json:"ref"`        // repository that was collected
+	DryRun     bool           `json:"dryRun"`     // true when nothing was deleted
+	Referenced int            `json:"referenced"` // number of manifests reachable from the tags
+	Removed    []ImageGCEntry `json:"removed"`    // unreferenced manifests, deleted unless this is a dry run
+	Size       int64          `json:"size"`       // size of the removed manifests and the blobs only they use
+}
+
+// ImageGCEntry is an unreferenced manifest found by [RegClient.ImageGC].
+type ImageGCEntry struct {
+	Digest    digest.Digest `json:"digest"`
+	MediaType string        `json:"mediaType"`
+	Size      int64         `json:"size"`
+	Tags      []string      `json:"tags,omitempty"` // digest tags of a missing subject pointing to the manifest
+}
+
+// imageGCWalk tracks the manifests and blobs found walking from a set of manifests.
+type imageGCWalk struct {
+	order     []digest.Digest
+	manifests map[digest.Digest]types.Descriptor
+	blobs     map[digest.Digest]int64
+	skip      map[digest.Digest]types.Descriptor
+}
+
+// ImageGC deletes the manifests in a repository that are not referenced.
+// A manifest is referenced when it is reachable from a tag, including child manifests and referrers.
+// Digest tags ("sha256-<digest>*") are only referenced when the subject digest is referenced.
+// Candidates for removal are the manifests of digest tags with a missing subject,
+// and every untagged manifest on schemes that can list them, e.g. an OCI Layout.
+// Registries only remove the blobs of the deleted manifests when their own garbage collection runs.
+// Use [ImageWithDryRun] to report the reclaimable content without deleting anything.
+func (rc *RegClient) ImageGC(ctx context.Context, r ref.Ref, opts ...ImageOpts) (*ImageGCReport, error) {
+	var opt imageOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	r = r.SetTag("")
+	if !r.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tags)
+	// walk everything reachable from the tags
+	walkRef := &imageGCWalk{
+		manifests: map[digest.Digest]types.Descriptor{},
+		blobs:     map[digest.Digest]int64{},
+	}
+	digestTags := map[string]digest.Digest{}
+	for _, t := range tags {
+		if match := imageGCDigestTagRE.FindStringSubmatch(t); match != nil {
+			digestTags[t] = digest.NewDigestFromEncoded(digest.SHA256, match[1])
+			continue
+		}
+		err = rc.imageGCWalkTag(ctx, r.SetTag(t), walkRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// digest tags are referenced by their subject, which may be another digest tag
+	for changed := true; changed; {
+		changed = false
+		for _, t := range tags {
+			subject, ok := digestTags[t]
+			if !ok {
+				continue
+			}
+			if _, ok := walkRef.manifests[subject]; !ok {
+				continue
+			}
+			err = rc.imageGCWalkTag(ctx, r.SetTag(t), walkRef)
+			if err != nil {
+				return nil, err
+			}
+			delete(digestTags, t)
+			changed = true
+		}
+	}
+	// collect the candidates for removal
+	candidates := []digest.Digest{}
+	candidateTags := map[digest.Digest][]string{}
+	for _, t := range tags {
+		if _, ok := digestTags[t]; !ok {
+			continue
+		}
+		m, err := rc.ManifestHead(ctx, r.SetTag(t), WithManifestRequireDigest())
+		if err != nil {
+			return nil, fmt.Errorf("failed to head %s: %w", t, err)
+		}
+		d := m.GetDescriptor().Digest
+		if _, ok := walkRef.manifests[d]; ok {
+			continue
+		}
+		if _, ok := candidateTags[d]; !ok {
+			candidates = append(candidates, d)
+		}
+		candidateTags[d] = append(candidateTags[d], t)
+	}
+	if rml, ok := schemeAPI.(repoManifestLister); ok {
+		dl, err := rml.RepoManifests(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dl {
+			if _, ok := walkRef.manifests[d.Digest]; ok {
+				continue
+			}
+			if _, ok := candidateTags[d.Digest]; ok {
+				continue
+			}
+			candidateTags[d.Digest] = nil
+			candidates = append(candidates, d.Digest)
+		}
+	}
+	// walk the candidates for the content only they reference
+	walkUnref := &imageGCWalk{
+		manifests: map[digest.Digest]types.Descriptor{},
+		blobs:     map[digest.Digest]int64{},
+		skip:      walkRef.manifests,
+	}
+	for _, d := range candidates {
+		err = rc.imageGCWalkDigest(ctx, r, d, walkUnref)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rpt := &ImageGCReport{
+		Ref:        r.CommonName(),
+		DryRun:     opt.dryRun,
+		Referenced: len(walkRef.manifests),
+		Removed:    []ImageGCEntry{},
+	}
+	for _, d := range walkUnref.order {
+		desc := walkUnref.manifests[d]
+		rpt.Removed = append(rpt.Removed, ImageGCEntry{
+			Digest:    d,
+			MediaType: desc.MediaType,
+			Size:      desc.Size,
+			Tags:      candidateTags[d],
+		})
+		rpt.Size += desc.Size
+	}
+	for d, size := range walkUnref.blobs {
+		if _, ok := walkRef.blobs[d]; !ok {
+			rpt.Size += size
+		}
+	}
+	if opt.dryRun {
+		return rpt, nil
+	}
+	for _, e := range rpt.Removed {
+		err = rc.ManifestDelete(ctx, r.SetDigest(e.Digest.String()))
+		if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+			return rpt, fmt.Errorf("failed to delete %s: %w", e.Digest.String(), err)
+		}
+		rc.log.WithFields(logrus.Fields{
+			"repo":   r.CommonName(),
+			"digest": e.Digest.String(),
+		}).Debug("Removed unreferenced manifest")
+	}
+	return rpt, nil
+}
+
+// imageGCWalkTag walks the manifest of a tag.
+func (rc *RegClient) imageGCWalkTag(ctx context.Context, r ref.Ref, w *imageGCWalk) error {
+	m, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+	if err != nil {
+		return fmt.Errorf("failed to head %s: %w", r.Tag, err)
+	}
+	return rc.imageGCWalkDigest(ctx, r.SetTag(""), m.GetDescriptor().Digest, w)
+}
+
+// imageGCWalkDigest records a manifest with the child manifests, referrers, and blobs it references.
+// Missing manifests are skipped, e.g. a platform that was not copied.
+func (rc *RegClient) imageGCWalkDigest(ctx context.Context, r ref.Ref, d digest.Digest, w *imageGCWalk) error {
+	if _, ok := w.manifests[d]; ok {
+		return nil
+	}
+	if _, ok := w.skip[d]; ok {
+		return nil
+	}
+	rd := r.SetDigest(d.String())
+	m, err := rc.ManifestGet(ctx, rd)
+	if err != nil && (errors.Is(err, types.ErrNotFound) || errors.Is(err, fs.ErrNotExist)) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get %s: %w", d.String(), err)
+	}
+	w.manifests[d] = m.GetDescriptor()
+	w.order = append(w.order, d)
+	if mi, ok := m.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return err
+		}
+		for _, child := range dl {
+			err = rc.imageGCWalkDigest(ctx, r, child.Digest, w)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if mi, ok := m.(manifest.Imager); ok {
+		if cd, err := mi.GetConfig(); err == nil {
+			w.blobs[cd.Digest] = cd.Size
+		}
+		layers, err := mi.GetLayers()
+		if err != nil {
+			return err
+		}
+		for _, l := range layers {
+			w.blobs[l.Digest] = l.Size
+		}
+	}
+	rl, err := rc.ReferrerList(ctx, rd)
+	if err != nil && errors.Is(err, types.ErrUnsupportedMediaType) {
+		// a digest tag that is not a referrers list, e.g. a signature
+		rc.log.WithFields(logrus.Fields{
+			"digest": d.String(),
+			"err":    err,
+		}).Debug("Skipping referrers")
+	} else if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to list referrers of %s: %w", d.String(), err)
+	}
+	for _, referrer := range rl.Descriptors {
+		err = rc.imageGCWalkDigest(ctx, r, referrer.Digest, w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
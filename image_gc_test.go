@@ -0,0 +1,151 @@
+package regclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestImageGC(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	// newArtifact creates a unique artifact manifest with an optional subject
+	newArtifact := func(t *testing.T, name string, subject *types.Descriptor) manifest.Manifest {
+		t.Helper()
+		m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+			Versioned:    v1.ManifestSchemaVersion,
+			MediaType:    types.MediaTypeOCI1Manifest,
+			ArtifactType: "application/example.gc",
+			Config:       types.Descriptor{MediaType: types.MediaTypeOCI1Empty, Digest: types.EmptyDigest, Size: int64(len(types.EmptyData))},
+			Layers:       []types.Descriptor{},
+			Subject:      subject,
+			Annotations:  map[string]string{"name": name},
+		}))
+		if err != nil {
+			t.Fatalf("failed to create manifest: %v", err)
+		}
+		return m
+	}
+
+	t.Run("clean", func(t *testing.T) {
+		rpt, err := rc.ImageGC(ctx, r, ImageWithDryRun())
+		if err != nil {
+			t.Fatalf("failed to run gc: %v", err)
+		}
+		if len(rpt.Removed) != 0 || rpt.Referenced == 0 {
+			t.Errorf("unexpected report: %v", rpt)
+		}
+	})
+	t.Run("unreferenced", func(t *testing.T) {
+		// an untagged manifest
+		mUntagged := newArtifact(t, "untagged", nil)
+		err := rc.ManifestPut(ctx, r.SetDigest(mUntagged.GetDescriptor().Digest.String()), mUntagged)
+		if err != nil {
+			t.Fatalf("failed to put untagged manifest: %v", err)
+		}
+		// a referrer to a subject that is untagged
+		mSubject := newArtifact(t, "subject", nil)
+		err = rc.ManifestPut(ctx, r.SetTag("gc-subject"), mSubject)
+		if err != nil {
+			t.Fatalf("failed to put subject: %v", err)
+		}
+		subjectDesc := mSubject.GetDescriptor()
+		mReferrer := newArtifact(t, "referrer", &subjectDesc)
+		err = rc.ManifestPut(ctx, r.SetDigest(mReferrer.GetDescriptor().Digest.String()), mReferrer)
+		if err != nil {
+			t.Fatalf("failed to put referrer: %v", err)
+		}
+		err = rc.TagDelete(ctx, r.SetTag("gc-subject"))
+		if err != nil {
+			t.Fatalf("failed to delete subject tag: %v", err)
+		}
+		rl, err := rc.ReferrerList(ctx, r.SetDigest(subjectDesc.Digest.String()))
+		if err != nil || len(rl.Tags) != 1 {
+			t.Fatalf("failed to get referrer digest tag: %v, %v", err, rl.Tags)
+		}
+		digestTag := rl.Tags[0]
+		expect := map[digest.Digest]bool{
+			mUntagged.GetDescriptor().Digest: true,
+			mReferrer.GetDescriptor().Digest: true,
+		}
+
+		rpt, err := rc.ImageGC(ctx, r, ImageWithDryRun())
+		if err != nil {
+			t.Fatalf("failed to run gc: %v", err)
+		}
+		// the untagged manifest, referrer, and the referrers list of the digest tag
+		if len(rpt.Removed) != 3 || rpt.Size == 0 {
+			t.Fatalf("unexpected report: %v", rpt)
+		}
+		foundTag := false
+		for _, e := range rpt.Removed {
+			if len(e.Tags) == 1 && e.Tags[0] == digestTag {
+				foundTag = true
+			} else if !expect[e.Digest] {
+				t.Errorf("unexpected entry: %v", e)
+			}
+		}
+		if !foundTag {
+			t.Errorf("digest tag %s not found in %v", digestTag, rpt.Removed)
+		}
+
+		rpt, err = rc.ImageGC(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to run gc: %v", err)
+		}
+		for _, e := range rpt.Removed {
+			_, err = rc.ManifestHead(ctx, r.SetDigest(e.Digest.String()))
+			if err == nil {
+				t.Errorf("manifest was not deleted: %s", e.Digest.String())
+			}
+		}
+		tl, err := rc.TagList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		tags, _ := tl.GetTags()
+		for _, tag := range tags {
+			if tag == digestTag {
+				t.Errorf("digest tag was not deleted: %s", tag)
+			}
+		}
+		for _, tag := range []string{"v1", "v2", "v3"} {
+			_, err = rc.ManifestHead(ctx, r.SetTag(tag))
+			if err != nil {
+				t.Errorf("referenced tag %s was deleted: %v", tag, err)
+			}
+		}
+		rpt, err = rc.ImageGC(ctx, r, ImageWithDryRun())
+		if err != nil {
+			t.Fatalf("failed to run gc: %v", err)
+		}
+		if len(rpt.Removed) != 0 {
+			t.Errorf("unreferenced content remains: %v", rpt.Removed)
+		}
+	})
+	t.Run("missing repo", func(t *testing.T) {
+		_, err := rc.ImageGC(ctx, ref.Ref{})
+		if !errors.Is(err, types.ErrInvalidReference) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrInvalidReference, err)
+		}
+	})
+}
@@ -0,0 +1,415 @@
+package regclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/docker/schema2"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// ImageIndexEntry selects manifests to add to an index with [RegClient.ImageIndexCreate] and [RegClient.ImageIndexAdd].
+type ImageIndexEntry struct {
+	// Ref is the image to add, copied to the repository of the index when it is in another repository.
+	Ref ref.Ref
+	// Platforms selects entries from a Ref that is an index, the index itself is added when empty.
+	Platforms []platform.Platform
+	// Platform overrides the platform of the descriptor, by default the platform is read from the image config.
+	Platform *platform.Platform
+	// Annotations are set on the descriptor.
+	Annotations map[string]string
+}
+
+type imageIndexOpt struct {
+	annotations    map[string]string
+	artifactType   string
+	copyOpts       []ImageOpts
+	matchDigests   []digest.Digest
+	matchPlatforms []platform.Platform
+	mediaType      string
+	rmDigests      []digest.Digest
+	rmPlatforms    []platform.Platform
+	subject        *types.Descriptor
+}
+
+// ImageIndexOpts define options for the ImageIndex* commands.
+type ImageIndexOpts func(*imageIndexOpt)
+
+// ImageIndexWithAnnotations sets annotations on the index in ImageIndexCreate.
+func ImageIndexWithAnnotations(annotations map[string]string) ImageIndexOpts {
+	return func(opts *imageIndexOpt) {
+		opts.annotations = annotations
+	}
+}
+
+// ImageIndexWithArtifactType sets the artifactType of an OCI index in ImageIndexCreate.
+func ImageIndexWithArtifactType(artifactType string) ImageIndexOpts {
+	return func(opts *imageIndexOpt) {
+		opts.artifactType = artifactType
+	}
+}
+
+// ImageIndexWithCopyOpts passes options to [RegClient.ImageCopy] when entries are copied from another repository.
+func ImageIndexWithCopyOpts(copyOpts ...ImageOpts) ImageIndexOpts {
+	return func(opts *imageIndexOpt) {
+		opts.copyOpts = append(opts.copyOpts, copyOpts...)
+	}
+}
+
+// ImageIndexWithMatchDigest selects entries with the digest in ImageIndexAnnotate.
+func ImageIndexWithMatchDigest(d digest.Digest) ImageIndexOpts {
+	return func(opts *imageIndexOpt) {
+		opts.matchDigests = append(opts.matchDigests, d)
+	}
+}
+
+// ImageIndexWithMatchPlatform selects entries matching the platform in ImageIndexAnnotate.
+func ImageIndexWithMatchPlatform(p platform.Platform) ImageIndexOpts {
+	return func(opts *imageIndexOpt) {
+		opts.matchPlatforms = append(opts.matchPlatforms, p)
+	}
+}
+
+// ImageIndexWithMediaType sets the media type in ImageIndexCreate, defaulting to an OCI index.
+func ImageIndexWithMediaType(mediaType string) ImageIndexOpts {
+	return func(opts *imageIndexOpt) {
+		opts.mediaType = mediaType
+	}
+}
+
+// ImageIndexWithRmDigest removes entries with the digest in ImageIndexRemove.
+func ImageIndexWithRmDigest(d digest.Digest) ImageIndexOpts {
+	return func(opts *imageIndexOpt) {
+		opts.rmDigests = append(opts.rmDigests, d)
+	}
+}
+
+// ImageIndexWithRmPlatform removes entries matching the platform in ImageIndexRemove.
+func ImageIndexWithRmPlatform(p platform.Platform) ImageIndexOpts {
+	return func(opts *imageIndexOpt) {
+		opts.rmPlatforms = append(opts.rmPlatforms, p)
+	}
+}
+
+// ImageIndexWithSubject sets the subject of an OCI index in ImageIndexCreate.
+func ImageIndexWithSubject(d types.Descriptor) ImageIndexOpts {
+	return func(opts *imageIndexOpt) {
+		opts.subject = &d
+	}
+}
+
+// ImageIndexCreate creates an index from a list of entries and pushes it.
+// The result is pushed to the tag of r, or by digest when r does not have a tag.
+func (rc *RegClient) ImageIndexCreate(ctx context.Context, r ref.Ref, entries []ImageIndexEntry, opts ...ImageIndexOpts) (manifest.Manifest, error) {
+	opt := imageIndexOpt{mediaType: types.MediaTypeOCI1ManifestList}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	if opt.mediaType != types.MediaTypeOCI1ManifestList && opt.mediaType != types.MediaTypeDocker2ManifestList {
+		return nil, fmt.Errorf("unsupported index media type: %s%.0w", opt.mediaType, types.ErrUnsupportedMediaType)
+	}
+	dl, err := rc.imageIndexDescList(ctx, r, entries, opt)
+	if err != nil {
+		return nil, err
+	}
+	var orig interface{}
+	switch opt.mediaType {
+	case types.MediaTypeOCI1ManifestList:
+		idx := v1.Index{
+			Versioned:    v1.IndexSchemaVersion,
+			MediaType:    types.MediaTypeOCI1ManifestList,
+			ArtifactType: opt.artifactType,
+			Manifests:    dl,
+			Subject:      opt.subject,
+		}
+		if len(opt.annotations) > 0 {
+			idx.Annotations = opt.annotations
+		}
+		orig = idx
+	case types.MediaTypeDocker2ManifestList:
+		ml := schema2.ManifestList{
+			Versioned: schema2.ManifestListSchemaVersion,
+			Manifests: dl,
+		}
+		if len(opt.annotations) > 0 {
+			ml.Annotations = opt.annotations
+		}
+		orig = ml
+	}
+	m, err := manifest.New(manifest.WithOrig(orig))
+	if err != nil {
+		return nil, err
+	}
+	return m, rc.imageIndexPut(ctx, r, m)
+}
+
+// ImageIndexAdd adds entries to an existing index and pushes it.
+// Entries identical to an existing entry are not repeated.
+// The result is pushed to the tag of r, or by digest when r does not have a tag.
+func (rc *RegClient) ImageIndexAdd(ctx context.Context, r ref.Ref, entries []ImageIndexEntry, opts ...ImageIndexOpts) (manifest.Manifest, error) {
+	var opt imageIndexOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	m, mi, dl, err := rc.imageIndexGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	dlAdd, err := rc.imageIndexDescList(ctx, r, entries, opt)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dlAdd {
+		if !imageIndexDescInList(d, dl) {
+			dl = append(dl, d)
+		}
+	}
+	err = mi.SetManifestList(dl)
+	if err != nil {
+		return nil, err
+	}
+	return m, rc.imageIndexPut(ctx, r, m)
+}
+
+// ImageIndexRemove removes entries from an existing index and pushes it.
+// Entries are selected with [ImageIndexWithRmDigest] and [ImageIndexWithRmPlatform].
+// The result is pushed to the tag of r, or by digest when r does not have a tag.
+func (rc *RegClient) ImageIndexRemove(ctx context.Context, r ref.Ref, opts ...ImageIndexOpts) (manifest.Manifest, error) {
+	var opt imageIndexOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	m, mi, dl, err := rc.imageIndexGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	dlNew := []types.Descriptor{}
+	for _, d := range dl {
+		if !imageIndexMatch(d, opt.rmDigests, opt.rmPlatforms) {
+			dlNew = append(dlNew, d)
+		}
+	}
+	err = mi.SetManifestList(dlNew)
+	if err != nil {
+		return nil, err
+	}
+	return m, rc.imageIndexPut(ctx, r, m)
+}
+
+// ImageIndexAnnotate sets annotations on the descriptors of entries in an existing index and pushes it.
+// An annotation with an empty value is deleted.
+// Entries are selected with [ImageIndexWithMatchDigest] and [ImageIndexWithMatchPlatform], and an error is returned when no entries match.
+// The result is pushed to the tag of r, or by digest when r does not have a tag.
+// The index is not pushed when the annotations are unchanged.
+func (rc *RegClient) ImageIndexAnnotate(ctx context.Context, r ref.Ref, annotations map[string]string, opts ...ImageIndexOpts) (manifest.Manifest, error) {
+	var opt imageIndexOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	if len(opt.matchDigests) == 0 && len(opt.matchPlatforms) == 0 {
+		return nil, fmt.Errorf("a digest or platform is required to select index entries")
+	}
+	m, mi, dl, err := rc.imageIndexGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	digOrig := m.GetDescriptor().Digest
+	found := false
+	for i, d := range dl {
+		if !imageIndexMatch(d, opt.matchDigests, opt.matchPlatforms) {
+			continue
+		}
+		found = true
+		// the map is copied since entries may share the annotations
+		dAnnotations := map[string]string{}
+		for k, v := range d.Annotations {
+			dAnnotations[k] = v
+		}
+		for k, v := range annotations {
+			if v == "" {
+				delete(dAnnotations, k)
+			} else {
+				dAnnotations[k] = v
+			}
+		}
+		if len(dAnnotations) == 0 {
+			dAnnotations = nil
+		}
+		dl[i].Annotations = dAnnotations
+	}
+	if !found {
+		return nil, fmt.Errorf("no entries matched in %s%.0w", r.CommonName(), types.ErrNotFound)
+	}
+	err = mi.SetManifestList(dl)
+	if err != nil {
+		return nil, err
+	}
+	if m.GetDescriptor().Digest == digOrig {
+		return m, nil
+	}
+	return m, rc.imageIndexPut(ctx, r, m)
+}
+
+// imageIndexGet returns an existing index and its entries.
+func (rc *RegClient) imageIndexGet(ctx context.Context, r ref.Ref) (manifest.Manifest, manifest.Indexer, []types.Descriptor, error) {
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	mi, ok := m.(manifest.Indexer)
+	if !ok || !m.IsList() {
+		return nil, nil, nil, fmt.Errorf("manifest is not an index: %s%.0w", r.CommonName(), types.ErrUnsupportedMediaType)
+	}
+	dl, err := mi.GetManifestList()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return m, mi, dl, nil
+}
+
+// imageIndexPut pushes the index to the tag of r, or by digest.
+func (rc *RegClient) imageIndexPut(ctx context.Context, r ref.Ref, m manifest.Manifest) error {
+	rTgt := r.SetDigest(m.GetDescriptor().Digest.String())
+	if r.Tag != "" {
+		rTgt = r.SetTag(r.Tag)
+	}
+	return rc.ManifestPut(ctx, rTgt, m)
+}
+
+// imageIndexDescList copies each entry to the repository of r and returns the descriptors.
+func (rc *RegClient) imageIndexDescList(ctx context.Context, r ref.Ref, entries []ImageIndexEntry, opt imageIndexOpt) ([]types.Descriptor, error) {
+	copyOpts := append([]ImageOpts{ImageWithChild()}, opt.copyOpts...)
+	dl := []types.Descriptor{}
+	for _, e := range entries {
+		mh, err := rc.ManifestHead(ctx, e.Ref, WithManifestRequireDigest())
+		if err != nil {
+			return nil, err
+		}
+		digests := []digest.Digest{mh.GetDescriptor().Digest}
+		if mh.IsList() && len(e.Platforms) > 0 {
+			// platform specific descriptors are extracted from the index
+			_, _, dlSrc, err := rc.imageIndexGet(ctx, e.Ref)
+			if err != nil {
+				return nil, err
+			}
+			digests = []digest.Digest{}
+			for _, d := range dlSrc {
+				if d.Platform != nil && imageIndexPlatformInList(*d.Platform, e.Platforms) {
+					digests = append(digests, d.Digest)
+				}
+			}
+			if len(digests) == 0 {
+				return nil, fmt.Errorf("no platforms matched in %s%.0w", e.Ref.CommonName(), types.ErrNotFound)
+			}
+		}
+		for _, dig := range digests {
+			rSrc := e.Ref.SetDigest(dig.String())
+			rDig := r.SetDigest(dig.String())
+			if !ref.EqualRepository(rSrc, rDig) {
+				err = rc.ImageCopy(ctx, rSrc, rDig, copyOpts...)
+				if err != nil {
+					return nil, err
+				}
+			}
+			mDig, err := rc.ManifestHead(ctx, rDig, WithManifestRequireDigest())
+			if err != nil {
+				return nil, err
+			}
+			d := mDig.GetDescriptor()
+			d.Platform = e.Platform
+			if d.Platform == nil {
+				// entries without a platform, like artifacts, are added without one
+				d.Platform, err = rc.imageIndexPlatform(ctx, rDig, mDig)
+				if err != nil {
+					rc.log.WithFields(logrus.Fields{
+						"ref": rDig.CommonName(),
+						"err": err,
+					}).Debug("failed to get platform")
+				}
+			}
+			d.Annotations = nil
+			if len(e.Annotations) > 0 {
+				d.Annotations = map[string]string{}
+				for k, v := range e.Annotations {
+					d.Annotations[k] = v
+				}
+			}
+			if !imageIndexDescInList(d, dl) {
+				dl = append(dl, d)
+			}
+		}
+	}
+	return dl, nil
+}
+
+// imageIndexPlatform returns the platform from the config of an image, or nil for other manifests.
+func (rc *RegClient) imageIndexPlatform(ctx context.Context, r ref.Ref, m manifest.Manifest) (*platform.Platform, error) {
+	if _, ok := m.(manifest.Imager); !ok {
+		return nil, nil
+	}
+	if !m.IsSet() {
+		// fetch the manifest if it wasn't already pulled
+		var err error
+		m, err = rc.ManifestGet(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return nil, nil
+	}
+	cd, err := mi.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	bc, err := rc.BlobGetOCIConfig(ctx, r, cd)
+	if err != nil {
+		return nil, err
+	}
+	oc := bc.GetConfig()
+	if oc.OS == "" {
+		return nil, nil
+	}
+	return &oc.Platform, nil
+}
+
+// imageIndexMatch returns true when the descriptor has one of the digests or matches one of the platforms.
+func imageIndexMatch(d types.Descriptor, digests []digest.Digest, platforms []platform.Platform) bool {
+	for _, dig := range digests {
+		if d.Digest == dig {
+			return true
+		}
+	}
+	for _, p := range platforms {
+		if d.Platform != nil && platform.Match(p, *d.Platform) {
+			return true
+		}
+	}
+	return false
+}
+
+func imageIndexDescInList(d types.Descriptor, dl []types.Descriptor) bool {
+	for _, cur := range dl {
+		if d.Equal(cur) {
+			return true
+		}
+	}
+	return false
+}
+
+func imageIndexPlatformInList(p platform.Platform, pl []platform.Platform) bool {
+	for _, cur := range pl {
+		if platform.Match(p, cur) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package regclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestImageIndex(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	rSrc, err := ref.New("ocidir://testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	r, err := ref.New("ocidir://testindex:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	pAMD64 := platform.Platform{OS: "linux", Architecture: "amd64"}
+	pARM64 := platform.Platform{OS: "linux", Architecture: "arm64"}
+	pARMv7 := platform.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}
+	// platforms returns the platforms of each entry in the pushed index
+	platforms := func(t *testing.T, r ref.Ref) []platform.Platform {
+		t.Helper()
+		m, err := rc.ManifestGet(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get index: %v", err)
+		}
+		dl, err := m.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get entries: %v", err)
+		}
+		pl := []platform.Platform{}
+		for _, d := range dl {
+			if d.Platform == nil {
+				t.Fatalf("entry is missing a platform: %v", d)
+			}
+			pl = append(pl, *d.Platform)
+		}
+		return pl
+	}
+	checkPlatforms := func(t *testing.T, received, expect []platform.Platform) {
+		t.Helper()
+		if len(received) != len(expect) {
+			t.Fatalf("unexpected platforms, expected %v, received %v", expect, received)
+		}
+		for i := range expect {
+			if !platform.Match(expect[i], received[i]) {
+				t.Errorf("unexpected platform %d, expected %s, received %s", i, expect[i].String(), received[i].String())
+			}
+		}
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		m, err := rc.ImageIndexCreate(ctx, r, []ImageIndexEntry{
+			{Ref: rSrc, Platforms: []platform.Platform{pAMD64, pARMv7}, Annotations: map[string]string{"entry": "1"}},
+		}, ImageIndexWithAnnotations(map[string]string{"index": "1"}))
+		if err != nil {
+			t.Fatalf("failed to create index: %v", err)
+		}
+		checkPlatforms(t, platforms(t, r), []platform.Platform{pAMD64, pARMv7})
+		ma, ok := m.(manifest.Annotator)
+		if !ok {
+			t.Fatalf("index does not support annotations")
+		}
+		annotations, err := ma.GetAnnotations()
+		if err != nil || annotations["index"] != "1" {
+			t.Errorf("unexpected annotations: %v, %v", annotations, err)
+		}
+		dl, _ := m.(manifest.Indexer).GetManifestList()
+		for _, d := range dl {
+			if d.Annotations["entry"] != "1" {
+				t.Errorf("unexpected descriptor annotations: %v", d.Annotations)
+			}
+		}
+	})
+	t.Run("Add", func(t *testing.T) {
+		_, err := rc.ImageIndexAdd(ctx, r, []ImageIndexEntry{
+			{Ref: rSrc, Platforms: []platform.Platform{pARM64}},
+			{Ref: rSrc, Platforms: []platform.Platform{pARM64}},
+		})
+		if err != nil {
+			t.Fatalf("failed to add to index: %v", err)
+		}
+		checkPlatforms(t, platforms(t, r), []platform.Platform{pAMD64, pARMv7, pARM64})
+	})
+	t.Run("Remove", func(t *testing.T) {
+		_, err := rc.ImageIndexRemove(ctx, r, ImageIndexWithRmPlatform(pARMv7))
+		if err != nil {
+			t.Fatalf("failed to remove from index: %v", err)
+		}
+		checkPlatforms(t, platforms(t, r), []platform.Platform{pAMD64, pARM64})
+	})
+	t.Run("Annotate", func(t *testing.T) {
+		m, err := rc.ImageIndexAnnotate(ctx, r, map[string]string{"entry": "", "arch": "arm64"}, ImageIndexWithMatchPlatform(pARM64))
+		if err != nil {
+			t.Fatalf("failed to annotate index: %v", err)
+		}
+		dl, _ := m.(manifest.Indexer).GetManifestList()
+		if len(dl) != 2 {
+			t.Fatalf("unexpected entries: %v", dl)
+		}
+		if dl[0].Annotations["entry"] != "1" || dl[0].Annotations["arch"] != "" {
+			t.Errorf("unexpected amd64 annotations: %v", dl[0].Annotations)
+		}
+		if dl[1].Annotations["arch"] != "arm64" || len(dl[1].Annotations) != 1 {
+			t.Errorf("unexpected arm64 annotations: %v", dl[1].Annotations)
+		}
+		_, err = rc.ImageIndexAnnotate(ctx, r, map[string]string{"entry": ""}, ImageIndexWithMatchDigest(dl[0].Digest))
+		if err != nil {
+			t.Fatalf("failed to annotate index: %v", err)
+		}
+		mGet, err := rc.ManifestGet(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get index: %v", err)
+		}
+		dl, _ = mGet.(manifest.Indexer).GetManifestList()
+		if len(dl[0].Annotations) != 0 {
+			t.Errorf("annotation was not deleted: %v", dl[0].Annotations)
+		}
+		_, err = rc.ImageIndexAnnotate(ctx, r, map[string]string{"a": "b"}, ImageIndexWithMatchPlatform(pARMv7))
+		if !errors.Is(err, types.ErrNotFound) {
+			t.Errorf("unexpected error for unmatched platform, received %v", err)
+		}
+		_, err = rc.ImageIndexAnnotate(ctx, r, map[string]string{"a": "b"})
+		if err == nil {
+			t.Errorf("annotate without a selector did not fail")
+		}
+	})
+	t.Run("Docker by digest", func(t *testing.T) {
+		rDig := r.SetTag("")
+		m, err := rc.ImageIndexCreate(ctx, rDig, []ImageIndexEntry{
+			{Ref: rSrc, Platforms: []platform.Platform{pAMD64}},
+		}, ImageIndexWithMediaType(types.MediaTypeDocker2ManifestList))
+		if err != nil {
+			t.Fatalf("failed to create index: %v", err)
+		}
+		if m.GetDescriptor().MediaType != types.MediaTypeDocker2ManifestList {
+			t.Errorf("unexpected media type: %s", m.GetDescriptor().MediaType)
+		}
+		checkPlatforms(t, platforms(t, rDig.SetDigest(m.GetDescriptor().Digest.String())), []platform.Platform{pAMD64})
+	})
+	t.Run("Platform override", func(t *testing.T) {
+		pOverride := platform.Platform{OS: "linux", Architecture: "riscv64"}
+		rOverride := r.SetTag("override")
+		_, err := rc.ImageIndexCreate(ctx, rOverride, []ImageIndexEntry{
+			{Ref: rSrc, Platforms: []platform.Platform{pAMD64}, Platform: &pOverride},
+		})
+		if err != nil {
+			t.Fatalf("failed to create index: %v", err)
+		}
+		checkPlatforms(t, platforms(t, rOverride), []platform.Platform{pOverride})
+	})
+	t.Run("Errors", func(t *testing.T) {
+		_, err := rc.ImageIndexCreate(ctx, r.SetTag("bad"), nil, ImageIndexWithMediaType(types.MediaTypeOCI1Manifest))
+		if !errors.Is(err, types.ErrUnsupportedMediaType) {
+			t.Errorf("unexpected error for media type, received %v", err)
+		}
+		_, err = rc.ImageIndexCreate(ctx, r.SetTag("bad"), []ImageIndexEntry{
+			{Ref: rSrc, Platforms: []platform.Platform{{OS: "plan9", Architecture: "amd64"}}},
+		})
+		if !errors.Is(err, types.ErrNotFound) {
+			t.Errorf("unexpected error for missing platform, received %v", err)
+		}
+		mSrc, err := rc.ManifestGet(ctx, rSrc)
+		if err != nil {
+			t.Fatalf("failed to get source: %v", err)
+		}
+		dlSrc, _ := mSrc.(manifest.Indexer).GetManifestList()
+		_, err = rc.ImageIndexAdd(ctx, rSrc.SetDigest(dlSrc[0].Digest.String()), nil)
+		if !errors.Is(err, types.ErrUnsupportedMediaType) {
+			t.Errorf("unexpected error adding to an image, received %v", err)
+		}
+	})
+}
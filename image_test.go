@@ -2,14 +2,24 @@ package regclient
 
 import (
 	"archive/tar"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	digest "github.com/opencontainers/go-digest"
+
 	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/pkg/checkpoint"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -129,6 +139,268 @@ func TestImageCheckBase(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("report", func(t *testing.T) {
+		// layer mismatch on a single platform
+		rpt, err := rc.ImageCheckBaseReport(ctx, r2, ImageWithCheckBaseRef(rb3.CommonName()), ImageWithPlatform("linux/amd64"))
+		if err != nil {
+			t.Fatalf("check base report failed: %v", err)
+		}
+		if !rpt.UpdateAvailable || rpt.MismatchLayer != 0 || rpt.LayersMatched != 0 || rpt.Base != rb3.CommonName() || rpt.BaseDigest != dig3.String() || len(rpt.Platforms) != 0 {
+			t.Errorf("unexpected report: %+v", rpt)
+		}
+		// matching layers on every platform
+		rpt, err = rc.ImageCheckBaseReport(ctx, r3, ImageWithCheckBaseRef(rb1.CommonName()))
+		if err != nil {
+			t.Fatalf("check base report failed: %v", err)
+		}
+		if rpt.UpdateAvailable || len(rpt.Platforms) == 0 {
+			t.Errorf("unexpected report: %+v", rpt)
+		}
+		for _, pRpt := range rpt.Platforms {
+			if pRpt.UpdateAvailable || pRpt.Platform == "" || pRpt.LayersMatched != 1 || pRpt.MismatchLayer != -1 {
+				t.Errorf("unexpected platform report: %+v", pRpt)
+			}
+		}
+		// digest from the annotation has changed
+		rpt, err = rc.ImageCheckBaseReport(ctx, r3)
+		if err != nil {
+			t.Fatalf("check base report failed: %v", err)
+		}
+		if !rpt.UpdateAvailable || rpt.ExpectDigest == "" || rpt.BaseDigest != dig3.String() || rpt.Reason == "" {
+			t.Errorf("unexpected report: %+v", rpt)
+		}
+	})
+}
+
+func TestImageRebase(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	rb1, err := ref.New("ocidir://testrepo:b1")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+	rb2, err := ref.New("ocidir://testrepo:b2")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+	rb3, err := ref.New("ocidir://testrepo:b3")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+	tt := []struct {
+		name      string
+		r         string
+		opts      []ImageOpts
+		checkBase bool
+		expectErr error
+	}{
+		{
+			name:      "missing annotation",
+			r:         "ocidir://testrepo:v1",
+			expectErr: types.ErrMissingAnnotation,
+		},
+		{
+			name:      "annotation v3",
+			r:         "ocidir://testrepo:v3",
+			checkBase: true,
+		},
+		{
+			name:      "mismatch",
+			r:         "ocidir://testrepo:v3",
+			opts:      []ImageOpts{ImageWithRebaseRefs(rb2, rb3)},
+			expectErr: types.ErrMismatch,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ref.New(tc.r)
+			if err != nil {
+				t.Fatalf("failed to setup ref: %v", err)
+			}
+			mOrig, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+			if err != nil {
+				t.Fatalf("failed to head image: %v", err)
+			}
+			rOut, err := rc.ImageRebase(ctx, r, tc.opts...)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to rebase: %v", err)
+			}
+			if rOut.Digest == "" || rOut.Digest == mOrig.GetDescriptor().Digest.String() {
+				t.Errorf("image was not rebased: %s", rOut.CommonName())
+			}
+			mTag, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+			if err != nil {
+				t.Fatalf("failed to head image: %v", err)
+			}
+			if mTag.GetDescriptor().Digest.String() != rOut.Digest {
+				t.Errorf("tag was not updated, expected %s, received %s", rOut.Digest, mTag.GetDescriptor().Digest.String())
+			}
+			if tc.checkBase {
+				err = rc.ImageCheckBase(ctx, rOut)
+				if err != nil {
+					t.Errorf("check base failed after rebase: %v", err)
+				}
+			}
+		})
+	}
+
+	t.Run("refs with referrers", func(t *testing.T) {
+		r, err := ref.New("ocidir://testrepo:v2")
+		if err != nil {
+			t.Fatalf("failed to setup ref: %v", err)
+		}
+		rlOrig, err := rc.ReferrerList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if len(rlOrig.Descriptors) == 0 {
+			t.Fatalf("test image has no referrers")
+		}
+		rOut, err := rc.ImageRebase(ctx, r, ImageWithRebaseRefs(rb1, rb2), ImageWithReferrers())
+		if err != nil {
+			t.Fatalf("failed to rebase: %v", err)
+		}
+		rlOut, err := rc.ReferrerList(ctx, rOut)
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if len(rlOut.Descriptors) != len(rlOrig.Descriptors) {
+			t.Errorf("referrers not copied, expected %d, received %d", len(rlOrig.Descriptors), len(rlOut.Descriptors))
+		}
+	})
+}
+
+func TestImageIndexDedupe(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	rSrc, err := ref.New("ocidir://testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+	rDup, err := ref.New("ocidir://testrepo:dup")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+	mSrc, err := rc.ManifestGet(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	dlSrc, err := mSrc.(manifest.Indexer).GetManifestList()
+	if err != nil {
+		t.Fatalf("failed to get manifest list: %v", err)
+	}
+	if len(dlSrc) < 2 {
+		t.Fatalf("test index has too few entries: %d", len(dlSrc))
+	}
+	// build an index with a repeated digest, a repeated platform, and a missing manifest
+	dMissing := dlSrc[1]
+	dMissing.Digest = digest.FromString("missing manifest")
+	dMissing.Platform = &platform.Platform{OS: "linux", Architecture: "s390x"}
+	dPlat := dlSrc[0]
+	dPlat.Digest = dlSrc[1].Digest
+	dlDup := append([]types.Descriptor{}, dlSrc...)
+	dlDup = append(dlDup, dlSrc[0], dPlat, dMissing)
+	mDup, err := manifest.New(manifest.WithOrig(mSrc.GetOrig()))
+	if err != nil {
+		t.Fatalf("failed to copy manifest: %v", err)
+	}
+	err = mDup.(manifest.Indexer).SetManifestList(dlDup)
+	if err != nil {
+		t.Fatalf("failed to set manifest list: %v", err)
+	}
+	err = rc.ManifestPut(ctx, rDup, mDup)
+	if err != nil {
+		t.Fatalf("failed to put manifest: %v", err)
+	}
+	dupDigest := mDup.GetDescriptor().Digest
+
+	t.Run("dry run", func(t *testing.T) {
+		rpt, err := rc.ImageIndexDedupe(ctx, rDup, ImageWithDryRun())
+		if err != nil {
+			t.Fatalf("failed to dedupe: %v", err)
+		}
+		if !rpt.Changed || rpt.Pushed {
+			t.Errorf("unexpected report state, changed %t, pushed %t", rpt.Changed, rpt.Pushed)
+		}
+		if len(rpt.Duplicates) != 2 || len(rpt.Missing) != 1 {
+			t.Errorf("unexpected entries, duplicates %d, missing %d", len(rpt.Duplicates), len(rpt.Missing))
+		}
+		mh, err := rc.ManifestHead(ctx, rDup, WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head manifest: %v", err)
+		}
+		if mh.GetDescriptor().Digest != dupDigest {
+			t.Errorf("index was modified in dry run")
+		}
+	})
+	t.Run("dedupe", func(t *testing.T) {
+		rpt, err := rc.ImageIndexDedupe(ctx, rDup)
+		if err != nil {
+			t.Fatalf("failed to dedupe: %v", err)
+		}
+		if !rpt.Changed || !rpt.Pushed {
+			t.Errorf("unexpected report state, changed %t, pushed %t", rpt.Changed, rpt.Pushed)
+		}
+		if rpt.Digest != dupDigest {
+			t.Errorf("unexpected original digest, expected %s, received %s", dupDigest, rpt.Digest)
+		}
+		mNew, err := rc.ManifestGet(ctx, rDup)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		if mNew.GetDescriptor().Digest != rpt.DigestNew {
+			t.Errorf("tag was not updated, expected %s, received %s", rpt.DigestNew, mNew.GetDescriptor().Digest)
+		}
+		dlNew, err := mNew.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		if len(dlNew) != len(dlSrc) {
+			t.Fatalf("unexpected entries, expected %d, received %d", len(dlSrc), len(dlNew))
+		}
+		for i := range dlNew {
+			if !dlNew[i].Equal(dlSrc[i]) {
+				t.Errorf("entry %d changed, expected %v, received %v", i, dlSrc[i], dlNew[i])
+			}
+		}
+	})
+	t.Run("unchanged", func(t *testing.T) {
+		rpt, err := rc.ImageIndexDedupe(ctx, rSrc)
+		if err != nil {
+			t.Fatalf("failed to dedupe: %v", err)
+		}
+		if rpt.Changed || rpt.Pushed || len(rpt.Duplicates) > 0 || len(rpt.Missing) > 0 {
+			t.Errorf("unexpected changes: %v", rpt)
+		}
+	})
+	t.Run("not an index", func(t *testing.T) {
+		_, err := rc.ImageIndexDedupe(ctx, rSrc.SetDigest(dlSrc[0].Digest.String()))
+		if !errors.Is(err, types.ErrUnsupportedMediaType) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
 }
 
 func TestCopy(t *testing.T) {
@@ -213,6 +485,593 @@ func TestCopy(t *testing.T) {
 	}
 }
 
+func TestCopyHooks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rc := New()
+	tempDir := t.TempDir()
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse src ref: %v", err)
+	}
+	errDeny := errors.New("denied by policy")
+
+	t.Run("pre-copy deny", func(t *testing.T) {
+		rTgt, err := ref.New("ocidir://" + tempDir + "/deny:v1")
+		if err != nil {
+			t.Fatalf("failed to parse tgt ref: %v", err)
+		}
+		count := 0
+		err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithPreCopyHook(func(ctx context.Context, refSrc, refTgt ref.Ref, m manifest.Manifest) error {
+			count++
+			if !m.IsList() {
+				t.Errorf("hook was not called with the index first")
+			}
+			return errDeny
+		}))
+		if !errors.Is(err, errDeny) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("unexpected hook count: %d", count)
+		}
+		_, err = rc.ManifestHead(ctx, rTgt)
+		if err == nil {
+			t.Errorf("denied image was pushed")
+		}
+	})
+	t.Run("pre-push deny child", func(t *testing.T) {
+		rTgt, err := ref.New("ocidir://" + tempDir + "/child:v1")
+		if err != nil {
+			t.Fatalf("failed to parse tgt ref: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithPrePushHook(func(ctx context.Context, refSrc, refTgt ref.Ref, m manifest.Manifest) error {
+			if !m.IsList() {
+				return errDeny
+			}
+			return nil
+		}))
+		if !errors.Is(err, errDeny) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		_, err = rc.ManifestHead(ctx, rTgt)
+		if err == nil {
+			t.Errorf("index was pushed after a child was denied")
+		}
+	})
+	t.Run("allow", func(t *testing.T) {
+		rTgt, err := ref.New("ocidir://" + tempDir + "/allow:v1")
+		if err != nil {
+			t.Fatalf("failed to parse tgt ref: %v", err)
+		}
+		var mu sync.Mutex
+		preCopy, prePush := map[string]bool{}, map[string]bool{}
+		err = rc.ImageCopy(ctx, rSrc, rTgt,
+			ImageWithPreCopyHook(func(ctx context.Context, refSrc, refTgt ref.Ref, m manifest.Manifest) error {
+				mu.Lock()
+				defer mu.Unlock()
+				preCopy[m.GetDescriptor().Digest.String()] = true
+				return nil
+			}),
+			ImageWithPrePushHook(func(ctx context.Context, refSrc, refTgt ref.Ref, m manifest.Manifest) error {
+				mu.Lock()
+				defer mu.Unlock()
+				prePush[m.GetDescriptor().Digest.String()] = true
+				return nil
+			}),
+		)
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		mTgt, err := rc.ManifestGet(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to get target: %v", err)
+		}
+		dl, err := mTgt.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		for _, d := range append(dl, mTgt.GetDescriptor()) {
+			if !preCopy[d.Digest.String()] || !prePush[d.Digest.String()] {
+				t.Errorf("hooks not called for %s", d.Digest.String())
+			}
+		}
+	})
+}
+
+func TestCopyCheckpoint(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rc := New()
+	tempDir := t.TempDir()
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse src ref: %v", err)
+	}
+	mSrc, err := rc.ManifestGet(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	dl, err := mSrc.(manifest.Indexer).GetManifestList()
+	if err != nil || len(dl) < 2 {
+		t.Fatalf("failed to get source manifest list: %v", err)
+	}
+
+	t.Run("record", func(t *testing.T) {
+		rTgt, err := ref.New("ocidir://" + tempDir + "/record:v2")
+		if err != nil {
+			t.Fatalf("failed to parse tgt ref: %v", err)
+		}
+		cp := checkpoint.NewMemory()
+		err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithCheckpoint(cp))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		repo := rTgt.SetTag("").CommonName()
+		for _, d := range dl {
+			if !cp.Has(repo, d.Digest) {
+				t.Errorf("checkpoint is missing manifest %s", d.Digest.String())
+			}
+			m, err := rc.ManifestGet(ctx, rSrc.SetDigest(d.Digest.String()))
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			layers, err := m.(manifest.Imager).GetLayers()
+			if err != nil {
+				t.Fatalf("failed to get layers: %v", err)
+			}
+			for _, l := range layers {
+				if !cp.Has(repo, l.Digest) {
+					t.Errorf("checkpoint is missing layer %s", l.Digest.String())
+				}
+			}
+		}
+	})
+	t.Run("resume", func(t *testing.T) {
+		rTgt, err := ref.New("ocidir://" + tempDir + "/resume:v2")
+		if err != nil {
+			t.Fatalf("failed to parse tgt ref: %v", err)
+		}
+		// a manifest in the checkpoint is not checked or copied again
+		cp := checkpoint.NewMemory()
+		err = cp.Add(rTgt.SetTag("").CommonName(), dl[0].Digest)
+		if err != nil {
+			t.Fatalf("failed to add to checkpoint: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithCheckpoint(cp))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		_, err = rc.ManifestHead(ctx, rTgt.SetDigest(dl[0].Digest.String()))
+		if err == nil {
+			t.Errorf("manifest in the checkpoint was copied")
+		}
+		_, err = rc.ManifestHead(ctx, rTgt.SetDigest(dl[1].Digest.String()))
+		if err != nil {
+			t.Errorf("manifest not in the checkpoint was not copied: %v", err)
+		}
+		// force recursive ignores the checkpoint for manifests
+		err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithCheckpoint(cp), ImageWithForceRecursive())
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		_, err = rc.ManifestHead(ctx, rTgt.SetDigest(dl[0].Digest.String()))
+		if err != nil {
+			t.Errorf("manifest was not copied with force recursive: %v", err)
+		}
+	})
+}
+
+func TestCopyDryRun(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rc := New()
+	tempDir := t.TempDir()
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse src ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://" + tempDir + "/testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse tgt ref: %v", err)
+	}
+	mSrc, err := rc.ManifestGet(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	dl, err := mSrc.(manifest.Indexer).GetManifestList()
+	if err != nil {
+		t.Fatalf("failed to get source manifest list: %v", err)
+	}
+	// dry run reports every manifest and blob without pushing
+	rptDry, err := rc.ImageCopyReport(ctx, rSrc, rTgt, ImageWithDryRun())
+	if err != nil {
+		t.Fatalf("failed to run dry run: %v", err)
+	}
+	if !rptDry.DryRun {
+		t.Errorf("report is not flagged as a dry run")
+	}
+	if len(rptDry.Manifests) != len(dl)+1 {
+		t.Errorf("unexpected manifest count, expected %d, received %d", len(dl)+1, len(rptDry.Manifests))
+	}
+	if len(rptDry.Blobs) == 0 || rptDry.Size <= 0 {
+		t.Errorf("blobs missing from report: %v", rptDry)
+	}
+	_, err = rc.ManifestHead(ctx, rTgt)
+	if err == nil {
+		t.Errorf("target was pushed in a dry run")
+	}
+	for _, d := range rptDry.Blobs {
+		_, err = rc.BlobHead(ctx, rTgt, types.Descriptor{Digest: d.Digest})
+		if err == nil {
+			t.Errorf("blob %s was pushed in a dry run", d.Digest.String())
+		}
+	}
+	// the copy pushes the reported content
+	rpt, err := rc.ImageCopyReport(ctx, rSrc, rTgt)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	if rpt.DryRun || len(rpt.Manifests) != len(rptDry.Manifests) || len(rpt.Blobs) != len(rptDry.Blobs) || rpt.Size != rptDry.Size {
+		t.Errorf("copy report does not match dry run, expected %v, received %v", rptDry, rpt)
+	}
+	if rpt.BlobsPushed != len(rpt.Blobs) || rpt.BlobsMounted != 0 || rpt.BlobsSkipped != 0 {
+		t.Errorf("unexpected blob counts, pushed %d, mounted %d, skipped %d", rpt.BlobsPushed, rpt.BlobsMounted, rpt.BlobsSkipped)
+	}
+	if rpt.BytesTransferred != rpt.Size || rpt.Elapsed <= 0 {
+		t.Errorf("unexpected transfer totals, bytes %d, elapsed %s", rpt.BytesTransferred, rpt.Elapsed)
+	}
+	// durations are output as a string in json
+	rptJSON, err := json.Marshal(rpt)
+	if err != nil {
+		t.Fatalf("failed to marshal report: %v", err)
+	}
+	if !strings.Contains(string(rptJSON), `"elapsed":"`+rpt.Elapsed.String()+`"`) {
+		t.Errorf("elapsed is not a duration string: %s", string(rptJSON))
+	}
+	// nothing remains to be copied
+	rptDry, err = rc.ImageCopyReport(ctx, rSrc, rTgt, ImageWithDryRun(), ImageWithForceRecursive())
+	if err != nil {
+		t.Fatalf("failed to run dry run: %v", err)
+	}
+	if len(rptDry.Manifests) != 0 || len(rptDry.Blobs) != 0 || rptDry.Size != 0 {
+		t.Errorf("dry run after copy reported content: %v", rptDry)
+	}
+	if rptDry.BlobsSkipped != len(rpt.Blobs) {
+		t.Errorf("unexpected skipped blobs, expected %d, received %d", len(rpt.Blobs), rptDry.BlobsSkipped)
+	}
+}
+
+func TestCopyPlatforms(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rc := New()
+	tempDir := t.TempDir()
+	rSrc1, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse src ref: %v", err)
+	}
+	rSrc2, err := ref.New("ocidir://./testdata/testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse src ref: %v", err)
+	}
+	tt := []struct {
+		name       string
+		src        ref.Ref
+		platforms  []string
+		expectList bool
+		expectLen  int
+		expectErr  error
+	}{
+		{
+			name:       "all platforms",
+			src:        rSrc2,
+			platforms:  []string{"linux/*"},
+			expectList: true,
+			expectLen:  3,
+		},
+		{
+			name:       "exclude",
+			src:        rSrc2,
+			platforms:  []string{"linux/*", "!linux/arm/*"},
+			expectList: true,
+			expectLen:  2,
+		},
+		{
+			name:       "referenced entries",
+			src:        rSrc1,
+			platforms:  []string{"linux/amd64"},
+			expectList: true,
+			expectLen:  2,
+		},
+		{
+			name:      "single platform",
+			src:       rSrc2,
+			platforms: []string{"linux/arm/v7+"},
+		},
+		{
+			name:      "no match",
+			src:       rSrc2,
+			platforms: []string{"windows/*"},
+			expectErr: types.ErrNotFound,
+		},
+	}
+	for i, tc := range tt {
+		tc := tc
+		rTgt, err := ref.New(fmt.Sprintf("ocidir://%s/platforms:%d", tempDir, i))
+		if err != nil {
+			t.Fatalf("failed to parse tgt ref: %v", err)
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			err := rc.ImageCopy(ctx, tc.src, rTgt, ImageWithPlatforms(tc.platforms))
+			dig, errDig := rc.ImagePlatformsDigest(ctx, tc.src, tc.platforms)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				if !errors.Is(errDig, tc.expectErr) {
+					t.Errorf("unexpected digest error, expected %v, received %v", tc.expectErr, errDig)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to copy: %v", err)
+			}
+			if errDig != nil {
+				t.Fatalf("failed to get platforms digest: %v", errDig)
+			}
+			m, err := rc.ManifestGet(ctx, rTgt)
+			if err != nil {
+				t.Fatalf("failed to get target: %v", err)
+			}
+			if m.GetDescriptor().Digest != dig {
+				t.Errorf("unexpected platforms digest, expected %s, received %s", m.GetDescriptor().Digest, dig)
+			}
+			if m.IsList() != tc.expectList {
+				t.Fatalf("unexpected manifest list result, expected %t, received %t", tc.expectList, m.IsList())
+			}
+			if !tc.expectList {
+				return
+			}
+			dl, err := m.(manifest.Indexer).GetManifestList()
+			if err != nil {
+				t.Fatalf("failed to get manifest list: %v", err)
+			}
+			if len(dl) != tc.expectLen {
+				t.Errorf("unexpected number of entries, expected %d, received %d", tc.expectLen, len(dl))
+			}
+			for _, d := range dl {
+				_, err = rc.ManifestHead(ctx, rTgt.SetDigest(d.Digest.String()))
+				if err != nil {
+					t.Errorf("entry %s was not copied: %v", d.Digest.String(), err)
+				}
+			}
+		})
+	}
+	t.Run("referrers", func(t *testing.T) {
+		rTgt, err := ref.New(fmt.Sprintf("ocidir://%s/platforms:referrers", tempDir))
+		if err != nil {
+			t.Fatalf("failed to parse tgt ref: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc2, rTgt, ImageWithPlatforms([]string{"linux/amd64", "linux/arm64"}), ImageWithReferrers())
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		// referrers of the included entries are copied
+		mSrc, err := rc.ManifestGet(ctx, rSrc2)
+		if err != nil {
+			t.Fatalf("failed to get source: %v", err)
+		}
+		dAMD, err := manifest.GetPlatformDesc(mSrc, &platform.Platform{OS: "linux", Architecture: "amd64"})
+		if err != nil {
+			t.Fatalf("failed to get amd64 entry: %v", err)
+		}
+		rlSrc, err := rc.ReferrerList(ctx, rSrc2.SetDigest(dAMD.Digest.String()))
+		if err != nil {
+			t.Fatalf("failed to list source referrers: %v", err)
+		}
+		rlTgt, err := rc.ReferrerList(ctx, rTgt.SetDigest(dAMD.Digest.String()))
+		if err != nil {
+			t.Fatalf("failed to list target referrers: %v", err)
+		}
+		if len(rlSrc.Descriptors) == 0 || len(rlTgt.Descriptors) != len(rlSrc.Descriptors) {
+			t.Errorf("referrers of the entry were not copied, expected %d, received %d", len(rlSrc.Descriptors), len(rlTgt.Descriptors))
+		}
+		// referrers of the source index refer to a digest that is not pushed
+		rlTgt, err = rc.ReferrerList(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to list target referrers: %v", err)
+		}
+		if len(rlTgt.Descriptors) != 0 {
+			t.Errorf("unexpected referrers on the rewritten index: %v", rlTgt.Descriptors)
+		}
+	})
+}
+
+func TestCopyFormatConvert(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rc := New()
+	tempDir := t.TempDir()
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse src ref: %v", err)
+	}
+	rDocker, err := ref.New(fmt.Sprintf("ocidir://%s/convert:docker", tempDir))
+	if err != nil {
+		t.Fatalf("failed to parse tgt ref: %v", err)
+	}
+	// checkIndex verifies the media types of the index, images, and configs in the target
+	checkIndex := func(t *testing.T, r ref.Ref, mtIndex, mtImage, mtConfig string) {
+		t.Helper()
+		m, err := rc.ManifestGet(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get target: %v", err)
+		}
+		if m.GetDescriptor().MediaType != mtIndex {
+			t.Errorf("unexpected index media type, expected %s, received %s", mtIndex, m.GetDescriptor().MediaType)
+		}
+		dl, err := m.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		digests := map[string]bool{}
+		for _, d := range dl {
+			digests[d.Digest.String()] = true
+		}
+		for _, d := range dl {
+			mc, err := rc.ManifestGet(ctx, r.SetDigest(d.Digest.String()))
+			if err != nil {
+				t.Fatalf("entry %s was not copied: %v", d.Digest.String(), err)
+			}
+			if mc.GetDescriptor().MediaType != d.MediaType {
+				t.Errorf("entry %s media type mismatch, descriptor %s, manifest %s", d.Digest.String(), d.MediaType, mc.GetDescriptor().MediaType)
+			}
+			if refDig := d.Annotations["vnd.docker.reference.digest"]; refDig != "" {
+				// attestations are artifacts that are not converted, but reference the converted image
+				if !digests[refDig] {
+					t.Errorf("attestation %s references a missing entry %s", d.Digest.String(), refDig)
+				}
+				continue
+			}
+			if d.MediaType != mtImage {
+				t.Errorf("unexpected image media type, expected %s, received %s", mtImage, d.MediaType)
+			}
+			cd, err := mc.(manifest.Imager).GetConfig()
+			if err != nil || cd.MediaType != mtConfig {
+				t.Errorf("unexpected config: %v, %v", cd, err)
+			}
+		}
+	}
+
+	t.Run("to docker", func(t *testing.T) {
+		err := rc.ImageCopy(ctx, rSrc, rDocker, ImageWithFormatConvert("docker"))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		checkIndex(t, rDocker, types.MediaTypeDocker2ManifestList, types.MediaTypeDocker2Manifest, types.MediaTypeDocker2ImageConfig)
+		// copying again does not change the converted image
+		mh1, err := rc.ManifestHead(ctx, rDocker, WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head target: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc, rDocker, ImageWithFormatConvert("docker"))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		mh2, err := rc.ManifestHead(ctx, rDocker, WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head target: %v", err)
+		}
+		if mh1.GetDescriptor().Digest != mh2.GetDescriptor().Digest {
+			t.Errorf("digest changed on a second copy, %s to %s", mh1.GetDescriptor().Digest, mh2.GetDescriptor().Digest)
+		}
+	})
+	t.Run("to oci in the same repo", func(t *testing.T) {
+		rOCI := rDocker.SetTag("oci")
+		err := rc.ImageCopy(ctx, rDocker, rOCI, ImageWithFormatConvert("oci"))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		checkIndex(t, rOCI, types.MediaTypeOCI1ManifestList, types.MediaTypeOCI1Manifest, types.MediaTypeOCI1ImageConfig)
+	})
+	t.Run("unchanged", func(t *testing.T) {
+		rTgt, err := ref.New(fmt.Sprintf("ocidir://%s/convert:unchanged", tempDir))
+		if err != nil {
+			t.Fatalf("failed to parse tgt ref: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithFormatConvert("oci"))
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		mhSrc, err := rc.ManifestHead(ctx, rSrc, WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head source: %v", err)
+		}
+		mhTgt, err := rc.ManifestHead(ctx, rTgt, WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head target: %v", err)
+		}
+		if mhSrc.GetDescriptor().Digest != mhTgt.GetDescriptor().Digest {
+			t.Errorf("digest changed, expected %s, received %s", mhSrc.GetDescriptor().Digest, mhTgt.GetDescriptor().Digest)
+		}
+	})
+	t.Run("invalid format", func(t *testing.T) {
+		err := rc.ImageCopy(ctx, rSrc, rDocker, ImageWithFormatConvert("schema1"))
+		if !errors.Is(err, types.ErrUnsupported) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrUnsupported, err)
+		}
+	})
+}
+
+func TestCopyBlobVerify(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	rSrc, err := ref.New("ocidir://testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://testverify:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	err = rc.ImageCopy(ctx, rSrc, rTgt)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	// corrupt a layer in the target
+	mList, err := rc.ManifestGet(ctx, rTgt)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	dImg, err := manifest.GetPlatformDesc(mList, &platform.Platform{OS: "linux", Architecture: "amd64"})
+	if err != nil {
+		t.Fatalf("failed to get platform: %v", err)
+	}
+	m, err := rc.ManifestGet(ctx, rTgt, WithManifestDesc(*dImg))
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		t.Fatalf("manifest is not an image")
+	}
+	layers, err := mi.GetLayers()
+	if err != nil || len(layers) == 0 {
+		t.Fatalf("failed to get layers: %v", err)
+	}
+	dLayer := layers[0]
+	err = rwfs.WriteFile(fsMem, "testverify/blobs/sha256/"+dLayer.Digest.Encoded(), bytes.Repeat([]byte{0}, int(dLayer.Size)), 0644)
+	if err != nil {
+		t.Fatalf("failed to corrupt layer: %v", err)
+	}
+	// a copy without verification skips the existing blob
+	err = rc.ImageCopy(ctx, rSrc, rTgt)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	err = rc.blobVerify(ctx, rTgt, dLayer)
+	if !errors.Is(err, types.ErrDigestMismatch) {
+		t.Errorf("corrupt blob was not detected: %v", err)
+	}
+	// verification detects and repairs the blob
+	err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithBlobVerify(100))
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	err = rc.blobVerify(ctx, rTgt, dLayer)
+	if err != nil {
+		t.Errorf("blob was not repaired: %v", err)
+	}
+}
+
 func TestExportImport(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -326,3 +1185,101 @@ func TestExportImport(t *testing.T) {
 		t.Errorf("failed to import: %v", err)
 	}
 }
+
+func TestExportImportChecksums(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	rIn, err := ref.New("ocidir://testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rOut, err := ref.New("ocidir://testout:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	mh, err := rc.ManifestHead(ctx, rIn)
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	tarBuf := &bytes.Buffer{}
+	sumsBuf := &bytes.Buffer{}
+	err = rc.ImageExport(ctx, rIn, tarBuf, ImageWithExportCompress(), ImageWithExportChecksums(sumsBuf))
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	sums := sumsBuf.String()
+	if !strings.HasPrefix(sums, checksumsDigestPrefix+" "+mh.GetDescriptor().Digest.String()+"\n") {
+		t.Errorf("checksums missing the top digest: %s", sums)
+	}
+	for _, name := range []string{ociLayoutFilename, ociIndexFilename} {
+		if !strings.Contains(sums, "  "+name+"\n") {
+			t.Errorf("checksums missing %s: %s", name, sums)
+		}
+	}
+	firstSum := strings.SplitN(strings.SplitN(sums, "\n", 3)[1], " ", 2)[0]
+
+	tt := []struct {
+		name      string
+		sums      string
+		expectErr error
+	}{
+		{
+			name: "valid",
+			sums: sums,
+		},
+		{
+			name:      "modified",
+			sums:      strings.Replace(sums, firstSum, strings.Repeat("0", len(firstSum)), 1),
+			expectErr: types.ErrDigestMismatch,
+		},
+		{
+			name:      "missing file",
+			sums:      sums + strings.Repeat("0", len(firstSum)) + "  blobs/sha256/missing\n",
+			expectErr: types.ErrMismatch,
+		},
+		{
+			name:      "extra file",
+			sums:      strings.Replace(sums, "  "+ociLayoutFilename+"\n", "  other\n", 1),
+			expectErr: types.ErrMismatch,
+		},
+		{
+			name:      "top digest",
+			sums:      strings.Replace(sums, mh.GetDescriptor().Digest.Encoded(), strings.Repeat("0", 64), 1),
+			expectErr: types.ErrDigestMismatch,
+		},
+		{
+			name:      "invalid",
+			sums:      "invalid\n",
+			expectErr: types.ErrParsingFailed,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := rc.ImageImport(ctx, rOut, bytes.NewReader(tarBuf.Bytes()), ImageWithImportChecksums(strings.NewReader(tc.sums)))
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to import: %v", err)
+			}
+			mhOut, err := rc.ManifestHead(ctx, rOut)
+			if err != nil {
+				t.Fatalf("failed to head import: %v", err)
+			}
+			if mhOut.GetDescriptor().Digest != mh.GetDescriptor().Digest {
+				t.Errorf("digest mismatch, expected %s, received %s", mh.GetDescriptor().Digest, mhOut.GetDescriptor().Digest)
+			}
+		})
+	}
+}
@@ -53,7 +53,9 @@ type CredsFn func(string) Cred
 
 // Cred is returned by the CredsFn
 type Cred struct {
-	User, Password, Token string
+	User, Password, Token  string
+	ClientID, ClientSecret string // OAuth2 client_credentials grant
+	Err                    error  // failure looking up the credential, returned instead of trying anonymous access
 }
 
 // Auth manages authorization requests/responses for http requests
@@ -90,6 +92,8 @@ type auth struct {
 	hs         map[string]map[string]Handler // handlers based on url and authType
 	authTypes  []string
 	log        *logrus.Logger
+	tokenCache *TokenCache
+	tokenHook  func(host string)
 	mu         sync.Mutex
 }
 
@@ -162,6 +166,13 @@ func WithDefaultHandlers() Opts {
 	}
 }
 
+// WithTokenHook calls the function each time a bearer token is received from a token server
+func WithTokenHook(fn func(host string)) Opts {
+	return func(a *auth) {
+		a.tokenHook = fn
+	}
+}
+
 // WithLog injects a logrus Logger
 func WithLog(log *logrus.Logger) Opts {
 	return func(a *auth) {
@@ -240,6 +251,10 @@ func (a *auth) HandleResponse(resp *http.Response) error {
 			if h == nil {
 				continue
 			}
+			if bh, ok := h.(*BearerHandler); ok {
+				bh.cache = a.tokenCache
+				bh.tokenHook = a.tokenHook
+			}
 			a.hs[host][c.authType] = h
 		}
 		// process the challenge with that handler
@@ -489,6 +504,9 @@ func (b *BasicHandler) ProcessChallenge(c Challenge) error {
 // GenerateAuth for BasicHandler generates base64 encoded user/pass for a host
 func (b *BasicHandler) GenerateAuth() (string, error) {
 	cred := b.credsFn(b.host)
+	if cred.Err != nil {
+		return "", cred.Err
+	}
 	if cred.User == "" || cred.Password == "" {
 		return "", fmt.Errorf("no credentials available: %w", types.ErrHTTPUnauthorized)
 	}
@@ -505,6 +523,8 @@ type BearerHandler struct {
 	credsFn        CredsFn
 	scopes         []string
 	token          BearerToken
+	cache          *TokenCache
+	tokenHook      func(host string)
 	log            *logrus.Logger
 }
 
@@ -604,8 +624,23 @@ func (b *BearerHandler) GenerateAuth() (string, error) {
 		return fmt.Sprintf("Bearer %s", b.token.Token), nil
 	}
 
+	// check for a token saved by another process
+	if b.cache != nil && b.token.RefreshToken == "" {
+		if token, ok := b.cache.get(b.cacheKey()); ok {
+			b.token = token
+			return fmt.Sprintf("Bearer %s", b.token.Token), nil
+		}
+	}
+
 	// attempt to post with oauth form, this also uses refresh tokens
-	if err := b.tryPost(); err == nil {
+	err := b.tryPost()
+	if err == ErrUnauthorized && b.token.RefreshToken != "" {
+		// a revoked or expired refresh token falls back to the configured credentials
+		b.token = BearerToken{}
+		err = b.tryPost()
+	}
+	if err == nil {
+		b.tokenReceived()
 		return fmt.Sprintf("Bearer %s", b.token.Token), nil
 	} else if err != ErrUnauthorized {
 		return "", fmt.Errorf("failed to request auth token (post): %v%.0w", err, types.ErrHTTPUnauthorized)
@@ -613,6 +648,7 @@ func (b *BearerHandler) GenerateAuth() (string, error) {
 
 	// attempt a get (with basic auth if user/pass available)
 	if err := b.tryGet(); err == nil {
+		b.tokenReceived()
 		return fmt.Sprintf("Bearer %s", b.token.Token), nil
 	} else if err != ErrUnauthorized {
 		return "", fmt.Errorf("failed to request auth token (get): %v%.0w", err, types.ErrHTTPUnauthorized)
@@ -621,20 +657,40 @@ func (b *BearerHandler) GenerateAuth() (string, error) {
 	return "", ErrUnauthorized
 }
 
+// tokenReceived saves a new token to the cache and calls the token hook
+func (b *BearerHandler) tokenReceived() {
+	b.cacheSave()
+	if b.tokenHook != nil {
+		b.tokenHook(b.host)
+	}
+}
+
 // isExpired returns true when token issue date is either 0, token has expired,
 // or will expire within buffer time
 func (b *BearerHandler) isExpired() bool {
-	if b.token.IssuedAt.IsZero() {
-		return true
+	return tokenExpired(b.token)
+}
+
+// cacheSave stores the current token in the cache, failures are logged and otherwise ignored.
+func (b *BearerHandler) cacheSave() {
+	if b.cache == nil {
+		return
+	}
+	err := b.cache.set(b.cacheKey(), b.token)
+	if err != nil && b.log != nil {
+		b.log.WithFields(logrus.Fields{
+			"err":  err,
+			"host": b.host,
+		}).Warn("Failed to save token cache")
 	}
-	expireSec := b.token.IssuedAt.Add(time.Duration(b.token.ExpiresIn) * time.Second)
-	expireSec = expireSec.Add(tokenBuffer * -1)
-	return time.Now().After(expireSec)
 }
 
 // tryGet requests a new token with a GET request
 func (b *BearerHandler) tryGet() error {
 	cred := b.credsFn(b.host)
+	if cred.Err != nil {
+		return cred.Err
+	}
 	req, err := http.NewRequest("GET", b.realm, nil)
 	if err != nil {
 		return err
@@ -671,6 +727,9 @@ func (b *BearerHandler) tryGet() error {
 // tryPost requests a new token via a POST request
 func (b *BearerHandler) tryPost() error {
 	cred := b.credsFn(b.host)
+	if cred.Err != nil {
+		return cred.Err
+	}
 	form := url.Values{}
 	if len(b.scopes) > 0 {
 		form.Set("scope", strings.Join(b.scopes, " "))
@@ -683,8 +742,15 @@ func (b *BearerHandler) tryPost() error {
 		form.Set("grant_type", "refresh_token")
 		form.Set("refresh_token", b.token.RefreshToken)
 	} else if cred.Token != "" {
+		// identity tokens (from docker login) are exchanged as a refresh token
 		form.Set("grant_type", "refresh_token")
 		form.Set("refresh_token", cred.Token)
+	} else if cred.ClientSecret != "" {
+		form.Set("grant_type", "client_credentials")
+		if cred.ClientID != "" {
+			form.Set("client_id", cred.ClientID)
+		}
+		form.Set("client_secret", cred.ClientSecret)
 	} else if cred.User != "" && cred.Password != "" {
 		form.Set("grant_type", "password")
 		form.Set("username", cred.User)
@@ -799,6 +865,9 @@ func (j *JWTHubHandler) AddScope(scope string) error {
 // ProcessChallenge handles WWW-Authenticate header for JWT auth on Docker Hub
 func (j *JWTHubHandler) ProcessChallenge(c Challenge) error {
 	cred := j.credsFn(j.host)
+	if cred.Err != nil {
+		return cred.Err
+	}
 	// use token if provided
 	if cred.Token != "" {
 		j.jwt = cred.Token
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -484,3 +485,155 @@ func TestBearer(t *testing.T) {
 		t.Errorf("token2 (push) expires early, expected %d, received %d", minTokenLife, bearer.token.ExpiresIn)
 	}
 }
+
+func TestBearerOAuth(t *testing.T) {
+	t.Parallel()
+	useragent := "regclient/test"
+	tokenResp, _ := json.Marshal(BearerToken{
+		Token:     "token-oauth",
+		ExpiresIn: 900,
+		IssuedAt:  time.Now(),
+	})
+	newForm := func(kv ...string) string {
+		form := url.Values{}
+		form.Set("scope", "repository:reponame:pull")
+		form.Set("service", "test")
+		form.Set("client_id", useragent)
+		for i := 0; i+1 < len(kv); i += 2 {
+			form.Set(kv[i], kv[i+1])
+		}
+		return form.Encode()
+	}
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "client credentials",
+				Method: "POST",
+				Path:   "/client",
+				Body:   []byte(newForm("grant_type", "client_credentials", "client_id", "app-id", "client_secret", "app-secret")),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: 200,
+				Body:   tokenResp,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "identity token",
+				Method: "POST",
+				Path:   "/identity",
+				Body:   []byte(newForm("grant_type", "refresh_token", "refresh_token", "identity-token")),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: 200,
+				Body:   tokenResp,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "stale refresh token",
+				Method: "POST",
+				Path:   "/stale",
+				Body:   []byte(newForm("grant_type", "refresh_token", "refresh_token", "stale-token")),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: 401,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "stale fallback to password",
+				Method: "POST",
+				Path:   "/stale",
+				Body:   []byte(newForm("grant_type", "password", "username", "user", "password", "testpass")),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: 200,
+				Body:   tokenResp,
+			},
+		},
+	}
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tt := []struct {
+		name    string
+		path    string
+		cred    Cred
+		refresh string
+	}{
+		{
+			name: "client credentials",
+			path: "/client",
+			cred: Cred{ClientID: "app-id", ClientSecret: "app-secret"},
+		},
+		{
+			name: "identity token",
+			path: "/identity",
+			cred: Cred{Token: "identity-token"},
+		},
+		{
+			name:    "stale refresh token",
+			path:    "/stale",
+			cred:    Cred{User: "user", Password: "testpass"},
+			refresh: "stale-token",
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			bearer := NewBearerHandler(&http.Client{}, useragent, tsURL.Host,
+				func(h string) Cred { return tc.cred },
+				&logrus.Logger{},
+			).(*BearerHandler)
+			err := bearer.AddScope("repository:reponame:pull")
+			if err != nil {
+				t.Fatalf("failed adding scope: %v", err)
+			}
+			c, err := ParseAuthHeader(`Bearer realm="` + tsURL.String() + tc.path + `",service="test",scope="repository:reponame:pull"`)
+			if err != nil {
+				t.Fatalf("failed to parse challenge: %v", err)
+			}
+			err = bearer.ProcessChallenge(c[0])
+			if err != nil {
+				t.Fatalf("failed to process challenge: %v", err)
+			}
+			bearer.token.RefreshToken = tc.refresh
+			resp, err := bearer.GenerateAuth()
+			if err != nil {
+				t.Fatalf("failed to generate auth: %v", err)
+			}
+			if resp != "Bearer token-oauth" {
+				t.Errorf("unexpected auth, expected %s, received %s", "Bearer token-oauth", resp)
+			}
+		})
+	}
+}
+
+func TestCredErr(t *testing.T) {
+	t.Parallel()
+	errCred := errors.New("credential provider failed")
+	credsFn := func(h string) Cred {
+		return Cred{Err: errCred}
+	}
+	bh := NewBasicHandler(http.DefaultClient, "testClient", "registry.example.org", credsFn, nil)
+	_, err := bh.GenerateAuth()
+	if !errors.Is(err, errCred) {
+		t.Errorf("basic auth did not return the credential error: %v", err)
+	}
+	jh := NewJWTHandler(http.DefaultClient, "testClient", "hub.docker.com", credsFn, nil)
+	err = jh.ProcessChallenge(Challenge{authType: "jwt", params: map[string]string{}})
+	if !errors.Is(err, errCred) {
+		t.Errorf("jwt auth did not return the credential error: %v", err)
+	}
+	// the bearer token request fails before contacting the realm
+	bt := NewBearerHandler(http.DefaultClient, "testClient", "registry.example.org", credsFn, nil)
+	err = bt.ProcessChallenge(Challenge{authType: "bearer", params: map[string]string{"realm": "http://127.0.0.1:1/token", "service": "test"}})
+	if err != nil {
+		t.Fatalf("failed to process challenge: %v", err)
+	}
+	_, err = bt.GenerateAuth()
+	if err == nil || !strings.Contains(err.Error(), errCred.Error()) {
+		t.Errorf("bearer auth did not return the credential error: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TokenCache persists bearer tokens to a file to reuse them across processes.
+// Only unexpired access tokens are saved, refresh tokens are never written to the file.
+type TokenCache struct {
+	filename string
+	mu       sync.Mutex
+}
+
+type tokenCacheFile struct {
+	Tokens map[string]BearerToken `json:"tokens"`
+}
+
+// NewTokenCache returns a cache backed by the file, the file is created with 0600 permissions when a token is saved.
+func NewTokenCache(filename string) *TokenCache {
+	return &TokenCache{filename: filename}
+}
+
+// WithTokenCache saves bearer tokens in the cache and reuses unexpired tokens from the cache.
+func WithTokenCache(tc *TokenCache) Opts {
+	return func(a *auth) {
+		a.tokenCache = tc
+	}
+}
+
+// tokenCacheKey returns a hash of the values used to request a token, avoiding credentials in the cache file.
+// The secrets are included so a changed password or token does not reuse a token issued for the previous login.
+func tokenCacheKey(host, realm, service string, cred Cred, scopes []string) string {
+	h := sha256.New()
+	for _, s := range append([]string{host, realm, service, cred.User, cred.Password, cred.Token, cred.ClientID, cred.ClientSecret}, scopes...) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns an unexpired token from the cache.
+func (tc *TokenCache) get(key string) (BearerToken, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tcf, err := tc.load()
+	if err != nil {
+		return BearerToken{}, false
+	}
+	token, ok := tcf.Tokens[key]
+	if !ok || token.Token == "" || tokenExpired(token) {
+		return BearerToken{}, false
+	}
+	return token, true
+}
+
+// set saves a token to the cache, pruning any expired tokens.
+func (tc *TokenCache) set(key string, token BearerToken) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tcf, err := tc.load()
+	if err != nil {
+		// replace a corrupt cache file
+		tcf = tokenCacheFile{}
+	}
+	if tcf.Tokens == nil {
+		tcf.Tokens = map[string]BearerToken{}
+	}
+	for k, t := range tcf.Tokens {
+		if tokenExpired(t) {
+			delete(tcf.Tokens, k)
+		}
+	}
+	token.RefreshToken = ""
+	tcf.Tokens[key] = token
+	return tc.save(tcf)
+}
+
+func (tc *TokenCache) load() (tokenCacheFile, error) {
+	tcf := tokenCacheFile{}
+	//#nosec G304 file is configured by the user
+	b, err := os.ReadFile(tc.filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tcf, nil
+	} else if err != nil {
+		return tcf, err
+	}
+	err = json.Unmarshal(b, &tcf)
+	return tcf, err
+}
+
+// save writes to a temp file and renames it to avoid other processes reading a partial file.
+func (tc *TokenCache) save(tcf tokenCacheFile) error {
+	b, err := json.Marshal(tcf)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(tc.filename)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(tc.filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(b)
+	errC := tmp.Close()
+	if err != nil {
+		return err
+	}
+	if errC != nil {
+		return errC
+	}
+	// CreateTemp uses 0600, this enforces the permission on any OS specific defaults
+	err = os.Chmod(tmp.Name(), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), tc.filename)
+}
+
+// tokenExpired returns true when token issue date is either 0, token has expired,
+// or will expire within buffer time
+func tokenExpired(token BearerToken) bool {
+	if token.IssuedAt.IsZero() {
+		return true
+	}
+	expireSec := token.IssuedAt.Add(time.Duration(token.ExpiresIn) * time.Second)
+	expireSec = expireSec.Add(tokenBuffer * -1)
+	return time.Now().After(expireSec)
+}
+
+// cacheKey returns the key for the current handler settings.
+func (b *BearerHandler) cacheKey() string {
+	scopes := append([]string{}, b.scopes...)
+	// scopes are sorted so the order requests were made does not matter
+	sort.Strings(scopes)
+	return tokenCacheKey(b.host, b.realm, b.service, b.credsFn(b.host), scopes)
+}
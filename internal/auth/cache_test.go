@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTokenCache(t *testing.T) {
+	t.Parallel()
+	reqCount := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqCount++
+		_ = json.NewEncoder(w).Encode(BearerToken{
+			Token:        "token-" + r.FormValue("username"),
+			ExpiresIn:    900,
+			IssuedAt:     time.Now().UTC(),
+			RefreshToken: "refresh-token-value",
+		})
+	}))
+	t.Cleanup(ts.Close)
+	filename := filepath.Join(t.TempDir(), "cache", "tokens.json")
+	c, err := ParseAuthHeader(`Bearer realm="` + ts.URL + `/tokens",service="test",scope="repository:reponame:pull"`)
+	if err != nil {
+		t.Fatalf("failed to parse challenge: %v", err)
+	}
+	// each handler uses a separate cache to simulate separate processes
+	newBearerPass := func(user, pass string) *BearerHandler {
+		b := NewBearerHandler(&http.Client{}, "regclient/test", "registry.example.com",
+			func(h string) Cred { return Cred{User: user, Password: pass} },
+			&logrus.Logger{},
+		).(*BearerHandler)
+		b.cache = NewTokenCache(filename)
+		err := b.ProcessChallenge(c[0])
+		if err != nil {
+			t.Fatalf("failed to process challenge: %v", err)
+		}
+		return b
+	}
+	newBearer := func(user string) *BearerHandler {
+		return newBearerPass(user, "pass")
+	}
+
+	ah, err := newBearer("user1").GenerateAuth()
+	if err != nil {
+		t.Fatalf("failed to generate auth: %v", err)
+	}
+	if ah != "Bearer token-user1" || reqCount != 1 {
+		t.Errorf("unexpected auth %s, requests %d", ah, reqCount)
+	}
+	fi, err := os.Stat(filename)
+	if err != nil {
+		t.Fatalf("failed to stat cache: %v", err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("unexpected cache permissions: %o", fi.Mode().Perm())
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read cache: %v", err)
+	}
+	if strings.Contains(string(b), "refresh-token-value") {
+		t.Errorf("cache contains sensitive values: %s", string(b))
+	}
+
+	// a new handler reuses the cached token
+	ah, err = newBearer("user1").GenerateAuth()
+	if err != nil {
+		t.Fatalf("failed to generate auth: %v", err)
+	}
+	if ah != "Bearer token-user1" || reqCount != 1 {
+		t.Errorf("cached token not used, auth %s, requests %d", ah, reqCount)
+	}
+
+	// a different user requests a new token
+	ah, err = newBearer("user2").GenerateAuth()
+	if err != nil {
+		t.Fatalf("failed to generate auth: %v", err)
+	}
+	if ah != "Bearer token-user2" || reqCount != 2 {
+		t.Errorf("unexpected auth %s, requests %d", ah, reqCount)
+	}
+
+	// a changed password requests a new token
+	_, err = newBearerPass("user1", "pass2").GenerateAuth()
+	if err != nil {
+		t.Fatalf("failed to generate auth: %v", err)
+	}
+	if reqCount != 3 {
+		t.Errorf("token for the previous password was used, requests %d", reqCount)
+	}
+	b, err = os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read cache: %v", err)
+	}
+	if strings.Contains(string(b), "pass2") {
+		t.Errorf("cache contains the password: %s", string(b))
+	}
+
+	// expired tokens are ignored and pruned
+	tc := NewTokenCache(filename)
+	bExp := newBearer("user1")
+	tcf, err := tc.load()
+	if err != nil {
+		t.Fatalf("failed to load cache: %v", err)
+	}
+	token := tcf.Tokens[bExp.cacheKey()]
+	token.IssuedAt = time.Now().Add(time.Hour * -1)
+	tcf.Tokens[bExp.cacheKey()] = token
+	err = tc.save(tcf)
+	if err != nil {
+		t.Fatalf("failed to save cache: %v", err)
+	}
+	_, err = bExp.GenerateAuth()
+	if err != nil {
+		t.Fatalf("failed to generate auth: %v", err)
+	}
+	if reqCount != 4 {
+		t.Errorf("expired token was used, requests %d", reqCount)
+	}
+}
@@ -0,0 +1,128 @@
+// Package bwlimit limits the bandwidth of readers that share a token bucket.
+package bwlimit
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxChunk limits the size of each read so transfers are spread evenly over time.
+const maxChunk = 32 * 1024
+
+// Limiter is a token bucket measured in bytes.
+// A nil Limiter does not limit the transfer rate.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+// New returns a limiter for the number of bytes per second, returning nil when rate is not positive.
+func New(rate int64) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &Limiter{
+		rate:   rate,
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// Rate returns the bytes per second allowed by the limiter.
+func (l *Limiter) Rate() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.rate
+}
+
+// WaitN blocks until n bytes may be transferred or the context is done.
+// Bytes are deducted immediately, so concurrent callers wait in turn.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	// the burst is limited to one second of transfers
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// chunk returns the largest read size for the limiter.
+func (l *Limiter) chunk() int {
+	if l.rate < maxChunk {
+		return int(l.rate)
+	}
+	return maxChunk
+}
+
+// Reader returns a reader limited by the bandwidth of l.
+// When l is nil, the original reader is returned.
+func (l *Limiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &reader{ctx: ctx, l: l, r: r}
+}
+
+// ReadCloser returns a read closer limited by the bandwidth of l.
+// When l is nil, the original read closer is returned.
+func (l *Limiter) ReadCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	if l == nil {
+		return rc
+	}
+	return &readCloser{reader: reader{ctx: ctx, l: l, r: rc}, c: rc}
+}
+
+type reader struct {
+	ctx context.Context
+	l   *Limiter
+	r   io.Reader
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if chunk := r.l.chunk(); len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.l.WaitN(r.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
+
+type readCloser struct {
+	reader
+	c io.Closer
+}
+
+func (rc *readCloser) Close() error {
+	return rc.c.Close()
+}
@@ -0,0 +1,72 @@
+package bwlimit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	t.Run("nil", func(t *testing.T) {
+		l := New(0)
+		if l != nil {
+			t.Fatalf("limiter created without a rate")
+		}
+		src := bytes.NewReader([]byte("hello"))
+		if l.Reader(ctx, src) != src {
+			t.Errorf("nil limiter wrapped the reader")
+		}
+		if err := l.WaitN(ctx, 1000); err != nil {
+			t.Errorf("nil limiter returned an error: %v", err)
+		}
+	})
+	t.Run("reader", func(t *testing.T) {
+		// the first second of data is the burst, the remaining bytes take ~200ms
+		l := New(10000)
+		src := bytes.Repeat([]byte("x"), 12000)
+		start := time.Now()
+		out, err := io.ReadAll(l.Reader(ctx, bytes.NewReader(src)))
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		if !bytes.Equal(out, src) {
+			t.Errorf("content mismatch")
+		}
+		if elapsed := time.Since(start); elapsed < time.Millisecond*150 || elapsed > time.Second {
+			t.Errorf("unexpected read time: %s", elapsed)
+		}
+	})
+	t.Run("shared", func(t *testing.T) {
+		l := New(20000)
+		_ = l.WaitN(ctx, 20000)
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = io.Copy(io.Discard, l.Reader(ctx, bytes.NewReader(make([]byte, 2000))))
+			}()
+		}
+		wg.Wait()
+		if elapsed := time.Since(start); elapsed < time.Millisecond*150 {
+			t.Errorf("concurrent readers were not limited: %s", elapsed)
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		l := New(100)
+		_ = l.WaitN(ctx, 100)
+		ctxC, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := io.ReadAll(l.ReadCloser(ctxC, io.NopCloser(bytes.NewReader(make([]byte, 1000)))))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+// Package history compares the history entries of image configs
+package history
+
+import (
+	"time"
+
+	v1 "github.com/regclient/regclient/types/oci/v1"
+)
+
+// Equal returns true when two history entries match.
+func Equal(a, b v1.History) bool {
+	return a.Author == b.Author &&
+		a.Comment == b.Comment &&
+		CreatedEqual(a.Created, b.Created) &&
+		a.CreatedBy == b.CreatedBy &&
+		a.EmptyLayer == b.EmptyLayer
+}
+
+// CreatedEqual compares history timestamps that may be missing in configs from legacy builders.
+func CreatedEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
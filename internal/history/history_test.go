@@ -0,0 +1,34 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/regclient/regclient/types/oci/v1"
+)
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+	t1 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	t1Local := t1.In(time.FixedZone("test", 3600))
+	t2 := t1.Add(time.Second)
+	tt := []struct {
+		name   string
+		a, b   v1.History
+		expect bool
+	}{
+		{name: "empty", expect: true},
+		{name: "same time", a: v1.History{Created: &t1}, b: v1.History{Created: &t1Local}, expect: true},
+		{name: "different time", a: v1.History{Created: &t1}, b: v1.History{Created: &t2}, expect: false},
+		{name: "missing time", a: v1.History{Created: &t1}, b: v1.History{}, expect: false},
+		{name: "created by", a: v1.History{CreatedBy: "RUN a"}, b: v1.History{CreatedBy: "RUN b"}, expect: false},
+		{name: "empty layer", a: v1.History{EmptyLayer: true}, b: v1.History{}, expect: false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := Equal(tc.a, tc.b); result != tc.expect {
+				t.Errorf("unexpected result, expected %t, received %t", tc.expect, result)
+			}
+		})
+	}
+}
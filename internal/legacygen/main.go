@@ -0,0 +1,222 @@
+// Command legacygen generates the deprecated forwarding declarations of the legacy regclient packages.
+//
+// Each legacy package has an alias file with the imports and declarations to forward:
+//
+//	import topTypes github.com/regclient/regclient/types
+//	const MediaTypeOCI1Manifest = topTypes.MediaTypeOCI1Manifest
+//	type Ref = topRef.Ref
+//	var NewRef = topRef.New
+//
+// Blank lines and lines beginning with "#" are ignored.
+// Lines beginning with "//" are added to the doc comment of the following declaration.
+// Every generated declaration is marked as deprecated, linking to the replacement when it is not internal.
+//
+// The generator is run with "go generate" from the legacy package directory.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"io"
+	"os"
+	"strings"
+)
+
+const genHeader = "// Code generated by legacygen. DO NOT EDIT."
+
+type spec struct {
+	imports []specImport
+	decls   []specDecl
+}
+
+type specImport struct {
+	alias string
+	path  string
+}
+
+type specDecl struct {
+	kind     string // const, type, or var
+	name     string
+	expr     string
+	comments []string
+}
+
+func main() {
+	in := flag.String("in", "legacy.txt", "alias file to read")
+	out := flag.String("out", "legacy_gen.go", "go file to generate")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	flag.Parse()
+	if *pkg == "" {
+		fmt.Fprintf(os.Stderr, "package name is required, set -pkg or run with go generate\n")
+		os.Exit(1)
+	}
+	err := run(*in, *out, *pkg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	fh, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	s, err := parse(fh)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", in, err)
+	}
+	src, err := generate(pkg, s)
+	if err != nil {
+		return fmt.Errorf("failed to generate %s: %w", out, err)
+	}
+	return os.WriteFile(out, src, 0644)
+}
+
+// parse reads an alias file.
+func parse(r io.Reader) (spec, error) {
+	s := spec{}
+	comments := []string{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "//"):
+			comments = append(comments, line)
+			continue
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "import":
+			if len(fields) != 3 {
+				return s, fmt.Errorf("line %d: import requires an alias and path", lineNum)
+			}
+			s.imports = append(s.imports, specImport{alias: fields[1], path: fields[2]})
+		case "const", "type", "var":
+			if len(fields) < 4 || fields[2] != "=" {
+				return s, fmt.Errorf("line %d: expected \"%s <name> = <expr>\"", lineNum, fields[0])
+			}
+			expr := strings.Join(fields[3:], " ")
+			if _, err := parser.ParseExpr(expr); err != nil {
+				return s, fmt.Errorf("line %d: invalid expression %q: %w", lineNum, expr, err)
+			}
+			s.decls = append(s.decls, specDecl{
+				kind:     fields[0],
+				name:     fields[1],
+				expr:     expr,
+				comments: comments,
+			})
+			comments = []string{}
+		default:
+			return s, fmt.Errorf("line %d: unknown keyword %s", lineNum, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return s, err
+	}
+	if len(comments) > 0 {
+		return s, fmt.Errorf("comment without a declaration at end of file")
+	}
+	return s, nil
+}
+
+// generate outputs the formatted go source for the alias file.
+func generate(pkg string, s spec) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s\n\n//go:build !nolegacy\n// +build !nolegacy\n\npackage %s\n\n", genHeader, pkg)
+	if len(s.imports) > 0 {
+		fmt.Fprintf(buf, "import (\n")
+		for _, imp := range s.imports {
+			fmt.Fprintf(buf, "\t%s %q\n", imp.alias, imp.path)
+		}
+		fmt.Fprintf(buf, ")\n")
+	}
+	// types are declared individually, consts and vars are grouped
+	for _, d := range s.decls {
+		if d.kind != "type" {
+			continue
+		}
+		fmt.Fprintf(buf, "\n")
+		writeComments(buf, s, d, "")
+		fmt.Fprintf(buf, "type %s = %s\n", d.name, d.expr)
+	}
+	for _, kind := range []string{"const", "var"} {
+		first := true
+		for _, d := range s.decls {
+			if d.kind != kind {
+				continue
+			}
+			if first {
+				fmt.Fprintf(buf, "\n%s (\n", kind)
+				first = false
+			}
+			writeComments(buf, s, d, "\t")
+			fmt.Fprintf(buf, "\t%s = %s\n", d.name, d.expr)
+		}
+		if !first {
+			fmt.Fprintf(buf, ")\n")
+		}
+	}
+	return format.Source(buf.Bytes())
+}
+
+// writeComments outputs the doc comment of a declaration.
+// Directives like "//lint:ignore" are placed directly before the declaration.
+func writeComments(w io.Writer, s spec, d specDecl, indent string) {
+	directives := []string{}
+	text := false
+	for _, c := range d.comments {
+		if !strings.HasPrefix(c, "// ") {
+			directives = append(directives, c)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s\n", indent, c)
+		text = true
+	}
+	if text {
+		fmt.Fprintf(w, "%s//\n", indent)
+	}
+	fmt.Fprintf(w, "%s// Deprecated: %s\n", indent, replacement(s, d.expr))
+	for _, c := range directives {
+		fmt.Fprintf(w, "%s%s\n", indent, c)
+	}
+}
+
+// replacement returns the deprecation message with a doc link to the forwarded declaration.
+func replacement(s spec, expr string) string {
+	e, err := parser.ParseExpr(expr)
+	if err != nil {
+		return "this legacy declaration has no replacement."
+	}
+	if star, ok := e.(*ast.StarExpr); ok {
+		e = star.X
+	}
+	sel, ok := e.(*ast.SelectorExpr)
+	if !ok {
+		return "this legacy declaration has no replacement."
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "this legacy declaration has no replacement."
+	}
+	for _, imp := range s.imports {
+		if imp.alias != ident.Name {
+			continue
+		}
+		if strings.Contains(imp.path, "/internal/") {
+			break
+		}
+		return fmt.Sprintf("replace with [%s.%s].", imp.path, sel.Sel.Name)
+	}
+	return "this legacy declaration has no replacement."
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name   string
+		in     string
+		expect []string
+		errStr string
+	}{
+		{
+			name: "forward",
+			in: `# comment
+import topTypes github.com/regclient/regclient/types
+import reghttp github.com/regclient/regclient/internal/reghttp
+
+// Ref is a reference
+type Ref = *topTypes.Ref
+//lint:ignore ST1003 legacy name
+var ErrHttpStatus = topTypes.ErrHTTPStatus
+const DefaultRetryLimit = reghttp.DefaultRetryLimit
+`,
+			expect: []string{
+				"// Code generated by legacygen. DO NOT EDIT.",
+				"//go:build !nolegacy",
+				"package legacy",
+				"// Ref is a reference\n//\n// Deprecated: replace with [github.com/regclient/regclient/types.Ref].\ntype Ref = *topTypes.Ref",
+				"\t// Deprecated: replace with [github.com/regclient/regclient/types.ErrHTTPStatus].\n\t//lint:ignore ST1003 legacy name\n\tErrHttpStatus = topTypes.ErrHTTPStatus",
+				"\t// Deprecated: this legacy declaration has no replacement.\n\tDefaultRetryLimit = reghttp.DefaultRetryLimit",
+			},
+		},
+		{
+			name:   "missing equals",
+			in:     "type Ref topTypes.Ref\n",
+			errStr: "expected",
+		},
+		{
+			name:   "unknown keyword",
+			in:     "func New = topTypes.New\n",
+			errStr: "unknown keyword",
+		},
+		{
+			name:   "trailing comment",
+			in:     "var A = topTypes.A\n// dangling\n",
+			errStr: "comment without a declaration",
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s, err := parse(strings.NewReader(tc.in))
+			if tc.errStr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errStr) {
+					t.Errorf("expected error %s, received %v", tc.errStr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			out, err := generate("legacy", s)
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+			for _, e := range tc.expect {
+				if !strings.Contains(string(out), e) {
+					t.Errorf("output missing %q:\n%s", e, out)
+				}
+			}
+		})
+	}
+}
+
+// TestLegacyCurrent verifies the generated files of the legacy packages match their alias files.
+func TestLegacyCurrent(t *testing.T) {
+	t.Parallel()
+	files, err := filepath.Glob("../../regclient/*/legacy.txt")
+	if err != nil {
+		t.Fatalf("failed to glob: %v", err)
+	}
+	files = append(files, "../../regclient/legacy.txt")
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		fh, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file, err)
+		}
+		s, err := parse(fh)
+		fh.Close()
+		if err != nil {
+			t.Errorf("failed to parse %s: %v", file, err)
+			continue
+		}
+		out, err := generate(filepath.Base(dir), s)
+		if err != nil {
+			t.Errorf("failed to generate %s: %v", file, err)
+			continue
+		}
+		cur, err := os.ReadFile(filepath.Join(dir, "legacy_gen.go"))
+		if err != nil {
+			t.Errorf("failed to read generated file in %s: %v", dir, err)
+			continue
+		}
+		if !bytes.Equal(out, cur) {
+			t.Errorf("generated file in %s is out of date, run go generate", dir)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package reghttp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/regclient/regclient/config"
+)
+
+// defaultDialTimeout and defaultDialKeepAlive match the settings of [http.DefaultTransport].
+var (
+	defaultDialTimeout   = 30 * time.Second
+	defaultDialKeepAlive = 30 * time.Second
+)
+
+// dialPolicy returns the dial function for a host, or nil when the host uses the default dialer.
+// The dialer applies the connect timeout, IP family, DNS servers, and static host entries.
+func dialPolicy(h *config.Host) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	if h.ConnectTimeout <= 0 && h.IPFamily == "" && len(h.DNSServers) == 0 && len(h.StaticHosts) == 0 {
+		return nil, nil
+	}
+	dialer := &net.Dialer{
+		Timeout:   defaultDialTimeout,
+		KeepAlive: defaultDialKeepAlive,
+	}
+	if h.ConnectTimeout > 0 {
+		dialer.Timeout = time.Duration(h.ConnectTimeout)
+	}
+	suffix := ""
+	switch h.IPFamily {
+	case config.IPFamilyDual:
+	case config.IPFamilyIPv4:
+		suffix = "4"
+	case config.IPFamilyIPv6:
+		suffix = "6"
+	default:
+		return nil, fmt.Errorf("unsupported ipFamily \"%s\", expected %s or %s", h.IPFamily, config.IPFamilyIPv4, config.IPFamilyIPv6)
+	}
+	if len(h.DNSServers) > 0 {
+		servers := make([]string, len(h.DNSServers))
+		for i, s := range h.DNSServers {
+			if _, _, err := net.SplitHostPort(s); err != nil {
+				s = net.JoinHostPort(s, "53")
+			}
+			servers[i] = s
+		}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: dialer.Timeout}
+				var err error
+				for _, s := range servers {
+					var conn net.Conn
+					conn, err = d.DialContext(ctx, network, s)
+					if err == nil {
+						return conn, nil
+					}
+				}
+				return nil, err
+			},
+		}
+	}
+	staticHosts := h.StaticHosts
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if suffix != "" && (network == "tcp" || network == "udp") {
+			network = network + suffix
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, ok := staticHosts[host]
+		if !ok || len(ips) == 0 {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		// try each static address, returning the last error when all fail
+		for _, ip := range ips {
+			var conn net.Conn
+			conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}, nil
+}
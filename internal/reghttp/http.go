@@ -10,12 +10,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,13 +31,20 @@ import (
 
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/auth"
+	"github.com/regclient/regclient/internal/bwlimit"
 	"github.com/regclient/regclient/internal/throttle"
+	"github.com/regclient/regclient/pkg/metrics"
+	"github.com/regclient/regclient/pkg/trace"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/warning"
 )
 
 var defaultDelayInit, _ = time.ParseDuration("1s")
 var defaultDelayMax, _ = time.ParseDuration("30s")
+
+// defaultRetryStatus are the http status codes retried with a backoff, other errors drop the host.
+var defaultRetryStatus = []int{http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusGatewayTimeout}
+
 var warnRegexp = regexp.MustCompile(`^299\s+-\s+"([^"]+)"`)
 
 const (
@@ -52,25 +60,57 @@ type Client struct {
 	rootCAPool    [][]byte
 	rootCADirs    []string
 	retryLimit    int
+	retryJitter   float64
+	retryFull     bool
+	retryPolicy   RetryPolicy
+	retryStatus   []int
+	retryBudget   time.Duration
+	tracer        trace.Tracer
+	metrics       metrics.Metrics
 	delayInit     time.Duration
 	delayMax      time.Duration
+	rand          *rand.Rand
 	log           *logrus.Logger
+	tokenCache    *auth.TokenCache
 	userAgent     string
 	mu            sync.Mutex
 }
 
 type clientHost struct {
-	initialized  bool
-	backoffCur   int
-	backoffUntil time.Time
-	config       *config.Host
-	httpClient   *http.Client
-	auth         map[string]auth.Auth
-	newAuth      func() auth.Auth
-	mu           sync.Mutex
-	ratelimit    *time.Ticker
+	initialized    bool
+	backoffCur     int
+	backoffUntil   time.Time
+	failures       int
+	lastErr        error
+	lastFailure    time.Time
+	lastSuccess    time.Time
+	unhealthyUntil time.Time
+	retry          retryPolicy
+	config         *config.Host
+	httpClient     *http.Client
+	auth           map[string]auth.Auth
+	newAuth        func() auth.Auth
+	mu             sync.Mutex
+	ratelimit      *time.Ticker
+	bwDown         *bwlimit.Limiter
+	bwUp           *bwlimit.Limiter
 }
 
+// retryPolicy is the retry settings for a host, combining the client options and host config.
+type retryPolicy struct {
+	limit     int
+	delayInit time.Duration
+	delayMax  time.Duration
+	jitter    float64
+	full      bool
+	policy    RetryPolicy
+	status    []int
+	budget    time.Duration
+}
+
+// RetryPolicy returns true when a request to the host that failed with the status code should be retried with a backoff.
+type RetryPolicy func(host string, statusCode int) bool
+
 // Req is a request to send to a registry
 type Req struct {
 	Host      string
@@ -92,6 +132,7 @@ type ReqAPI struct {
 	Headers    http.Header
 	Digest     digest.Digest
 	IgnoreErr  bool
+	BlobLen    int64 // BlobLen is the size of a blob transfer used to scale the request timeout, -1 when the size is unknown
 }
 
 // Resp is used to handle the result of a request
@@ -102,6 +143,7 @@ type Resp interface {
 
 type clientResp struct {
 	ctx              context.Context
+	start            time.Time
 	client           *Client
 	req              *Req
 	resp             *http.Response
@@ -112,6 +154,9 @@ type clientResp struct {
 	reader           io.Reader
 	readCur, readMax int64
 	throttle         *throttle.Throttle
+	attempts         int
+	wait             time.Duration
+	cancel           context.CancelFunc
 }
 
 // Opts is used to configure client options
@@ -120,15 +165,18 @@ type Opts func(*Client)
 // NewClient returns a client for handling requests
 func NewClient(opts ...Opts) *Client {
 	c := Client{
-		httpClient: &http.Client{},
-		host:       map[string]*clientHost{},
-		retryLimit: DefaultRetryLimit,
-		delayInit:  defaultDelayInit,
-		delayMax:   defaultDelayMax,
-		log:        &logrus.Logger{Out: io.Discard},
-		rootCAPool: [][]byte{},
-		rootCADirs: []string{},
+		httpClient:  &http.Client{},
+		host:        map[string]*clientHost{},
+		retryLimit:  DefaultRetryLimit,
+		retryStatus: defaultRetryStatus,
+		delayInit:   defaultDelayInit,
+		delayMax:    defaultDelayMax,
+		log:         &logrus.Logger{Out: io.Discard},
+		rootCAPool:  [][]byte{},
+		rootCADirs:  []string{},
 	}
+	//#nosec G404 jitter on the backoff delay does not need a secure random source
+	c.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
 	for _, opt := range opts {
 		opt(&c)
 	}
@@ -207,6 +255,64 @@ func WithRetryLimit(rl int) Opts {
 	}
 }
 
+// WithRetryBudget limits the time for a request, including all retries and backoff delays.
+// A request that exceeds the budget returns the last error, and 0 disables the limit.
+func WithRetryBudget(d time.Duration) Opts {
+	return func(c *Client) {
+		if d >= 0 {
+			c.retryBudget = d
+		}
+	}
+}
+
+// WithRetryJitter randomizes each backoff delay by a fraction (0 to 1) of the delay.
+// This spreads out retries from concurrent requests to the same host.
+func WithRetryJitter(jitter float64) Opts {
+	return func(c *Client) {
+		if jitter >= 0 && jitter <= 1 {
+			c.retryJitter = jitter
+		}
+	}
+}
+
+// WithRetryFullJitter randomizes each backoff delay between 0 and the exponential delay.
+// This replaces the jitter fraction from [WithRetryJitter].
+func WithRetryFullJitter() Opts {
+	return func(c *Client) {
+		c.retryFull = true
+	}
+}
+
+// WithRetryPolicy sets a function to select the http status codes that are retried with a backoff.
+// This replaces the status codes from [WithRetryStatus] and the host configuration.
+func WithRetryPolicy(policy RetryPolicy) Opts {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithRetryStatus sets the http status codes that are retried with a backoff.
+// Other error status codes drop the host from the request, trying the next mirror.
+func WithRetryStatus(status []int) Opts {
+	return func(c *Client) {
+		c.retryStatus = status
+	}
+}
+
+// WithMetrics reports request counts, bytes transferred, retries, and token refreshes to the metrics hooks.
+func WithMetrics(m metrics.Metrics) Opts {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// WithTracer creates a span for each http request and propagates the trace context in the request headers.
+func WithTracer(t trace.Tracer) Opts {
+	return func(c *Client) {
+		c.tracer = t
+	}
+}
+
 // WithLog injects a logrus Logger configuration
 func WithLog(log *logrus.Logger) Opts {
 	return func(c *Client) {
@@ -214,6 +320,15 @@ func WithLog(log *logrus.Logger) Opts {
 	}
 }
 
+// WithTokenCache saves bearer tokens to a file for reuse by other processes
+func WithTokenCache(filename string) Opts {
+	return func(c *Client) {
+		if filename != "" {
+			c.tokenCache = auth.NewTokenCache(filename)
+		}
+	}
+}
+
 // WithTransport uses a specific http transport with retryable requests
 func WithTransport(t *http.Transport) Opts {
 	return func(c *Client) {
@@ -232,6 +347,7 @@ func WithUserAgent(ua string) Opts {
 func (c *Client) Do(ctx context.Context, req *Req) (Resp, error) {
 	resp := &clientResp{
 		ctx:      ctx,
+		start:    time.Now(),
 		client:   c,
 		req:      req,
 		digester: digest.Canonical.Digester(),
@@ -247,15 +363,23 @@ func (resp *clientResp) Next() error {
 	req := resp.req
 	// lookup reqHost entry
 	reqHost := c.getHost(req.Host)
-	// create sorted list of mirrors, based on backoffs, upstream, and priority
+	// create sorted list of mirrors, based on backoffs, upstream, priority, and weight
+	// unhealthy mirrors are skipped until their backoff period expires
 	hosts := make([]*clientHost, 0, 1+len(reqHost.config.Mirrors))
 	if !req.NoMirrors {
 		for _, m := range reqHost.config.Mirrors {
-			hosts = append(hosts, c.getHost(m))
+			if mh := c.getHost(m); mh.config.Name != reqHost.config.Name && c.hostHealthy(mh) {
+				hosts = append(hosts, mh)
+			}
 		}
 	}
 	hosts = append(hosts, reqHost)
-	sort.Slice(hosts, sortHostsCmp(hosts, reqHost.config.Name))
+	hosts = c.sortHosts(hosts, reqHost.config.Name)
+	// the retry budget of the requested host applies to the request, including mirrors
+	var deadline time.Time
+	if reqHost.retry.budget > 0 {
+		deadline = resp.start.Add(reqHost.retry.budget)
+	}
 	// loop over requests to mirrors and retries
 	curHost := 0
 	for {
@@ -264,7 +388,7 @@ func (resp *clientResp) Next() error {
 		retryHost := false
 		if len(hosts) == 0 {
 			if err != nil {
-				return err
+				return resp.retryErr(err)
 			}
 			return types.ErrAllRequestsFailed
 		}
@@ -284,6 +408,12 @@ func (resp *clientResp) Next() error {
 		if ctxErr != nil {
 			return ctxErr
 		}
+		if err != nil && !deadline.IsZero() && time.Now().After(deadline) {
+			return resp.retryErr(fmt.Errorf("retry budget of %s exceeded: %w", reqHost.retry.budget.String(), err))
+		}
+		if err != nil && okAPI && c.metrics != nil {
+			c.metrics.Retry(h.config.Name, api.Method)
+		}
 		// wait for other concurrent requests to this host
 		throttleErr := h.config.Throttle().Acquire(resp.ctx)
 		if throttleErr != nil {
@@ -291,8 +421,7 @@ func (resp *clientResp) Next() error {
 		}
 
 		// try each host in a closure to handle all the backoff/dropHost from one place
-		err = func() error {
-			var err error
+		err = func() (err error) {
 			if !okAPI {
 				dropHost = true
 				return fmt.Errorf("failed looking up api \"%s\" for host \"%s\": %w", h.config.API, h.config.Name, types.ErrAPINotFound)
@@ -306,8 +435,11 @@ func (resp *clientResp) Next() error {
 				}
 			}
 
-			// store the desired digest
+			// store the desired digest, verified with the same algorithm
 			resp.digest = api.Digest
+			if resp.readCur == 0 && resp.digest != "" && resp.digest.Algorithm().Available() {
+				resp.digester = resp.digest.Algorithm().Digester()
+			}
 
 			// build the url
 			var u url.URL
@@ -339,22 +471,40 @@ func (resp *clientResp) Next() error {
 			if resp.resp != nil && resp.resp.Body != nil {
 				_ = resp.resp.Body.Close()
 			}
+			resp.cancelReq()
 			// delay for backoff if needed
 			bu := resp.backoffUntil()
 			if !bu.IsZero() && bu.After(time.Now()) {
+				if !deadline.IsZero() && bu.After(deadline) {
+					dropHost = true
+					return fmt.Errorf("backoff for host %s exceeds the retry budget of %s%.0w", h.config.Name, reqHost.retry.budget.String(), types.ErrBackoffLimit)
+				}
 				sleepTime := time.Until(bu)
 				c.log.WithFields(logrus.Fields{
-					"Host":    h.config.Name,
-					"Seconds": sleepTime.Seconds(),
+					"Host":      h.config.Name,
+					"Seconds":   sleepTime.Seconds(),
+					"Attempt":   resp.attempts + 1,
+					"Waited":    resp.wait.String(),
+					"repo":      api.Repository,
+					"operation": apiOperation(api),
 				}).Warn("Sleeping for backoff")
+				if rw, ok := c.metrics.(metrics.RetryWaiter); ok {
+					rw.RetryWait(h.config.Name, resp.attempts+1, sleepTime)
+				}
 				select {
 				case <-resp.ctx.Done():
 					return types.ErrCanceled
 				case <-time.After(sleepTime):
 				}
+				resp.wait += sleepTime
+			}
+			// limit the time for the request and response body
+			reqCtx := resp.ctx
+			if timeout := h.requestTimeout(api); timeout > 0 {
+				reqCtx, resp.cancel = context.WithTimeout(resp.ctx, timeout)
 			}
 			var httpReq *http.Request
-			httpReq, err = http.NewRequestWithContext(resp.ctx, api.Method, u.String(), nil)
+			httpReq, err = http.NewRequestWithContext(reqCtx, api.Method, u.String(), nil)
 			if err != nil {
 				dropHost = true
 				return err
@@ -374,6 +524,18 @@ func (resp *clientResp) Next() error {
 				httpReq.GetBody = func() (io.ReadCloser, error) { return body, nil }
 				httpReq.ContentLength = api.BodyLen
 			}
+			// limit the upload bandwidth to the host
+			if h.bwUp != nil && httpReq.Body != nil {
+				httpReq.Body = h.bwUp.ReadCloser(resp.ctx, httpReq.Body)
+				getBody := httpReq.GetBody
+				httpReq.GetBody = func() (io.ReadCloser, error) {
+					body, err := getBody()
+					if err != nil {
+						return nil, err
+					}
+					return h.bwUp.ReadCloser(resp.ctx, body), nil
+				}
+			}
 			if len(api.Headers) > 0 {
 				httpReq.Header = api.Headers.Clone()
 			}
@@ -418,21 +580,67 @@ func (resp *clientResp) Next() error {
 
 			// update http client for insecure requests and root certs
 			httpClient := *h.httpClient
+			if checkRedirect := redirectPolicy(h.config); checkRedirect != nil {
+				httpClient.CheckRedirect = checkRedirect
+			}
+
+			// trace the request and propagate the trace context to the registry
+			if c.tracer != nil {
+				spanCtx, span := c.tracer.Start(resp.ctx, "HTTP "+api.Method,
+					trace.String(trace.AttrHost, h.config.Hostname),
+					trace.String(trace.AttrMethod, api.Method),
+					trace.String(trace.AttrRepository, api.Repository),
+				)
+				httpReq = httpReq.WithContext(spanCtx)
+				c.tracer.Inject(spanCtx, httpReq.Header)
+				if resp.attempts > 0 {
+					span.SetAttributes(trace.Int64(trace.AttrResendCount, int64(resp.attempts)))
+				}
+				defer func() {
+					if resp.resp != nil {
+						span.SetAttributes(trace.Int64(trace.AttrStatusCode, int64(resp.resp.StatusCode)))
+					}
+					span.End(err)
+				}()
+			}
 
 			// send request
 			resp.client.log.WithFields(logrus.Fields{
-				"url":      httpReq.URL.String(),
-				"method":   httpReq.Method,
-				"withAuth": (len(httpReq.Header.Values("Authorization")) > 0),
+				"url":       httpReq.URL.String(),
+				"method":    httpReq.Method,
+				"withAuth":  (len(httpReq.Header.Values("Authorization")) > 0),
+				"host":      h.config.Name,
+				"repo":      api.Repository,
+				"operation": apiOperation(api),
 			}).Debug("http req")
+			reqStart := time.Now()
+			resp.attempts++
 			resp.resp, err = httpClient.Do(httpReq)
+			if c.metrics != nil {
+				status := 0
+				if resp.resp != nil {
+					status = resp.resp.StatusCode
+				}
+				c.metrics.Request(h.config.Name, api.Method, status, time.Since(reqStart))
+				if err == nil && httpReq.ContentLength > 0 {
+					c.metrics.BytesSent(h.config.Name, httpReq.ContentLength)
+				}
+			}
 
 			if err != nil {
 				c.log.WithFields(logrus.Fields{
-					"URL": u.String(),
-					"err": err,
+					"URL":       u.String(),
+					"err":       err,
+					"host":      h.config.Name,
+					"repo":      api.Repository,
+					"operation": apiOperation(api),
 				}).Debug("Request failed")
-				backoff = true
+				if errors.Is(err, types.ErrRedirectDenied) {
+					// retrying the same host would be redirected again
+					dropHost = true
+				} else {
+					backoff = true
+				}
 				return err
 			}
 			// extract any warnings
@@ -476,17 +684,20 @@ func (resp *clientResp) Next() error {
 				case http.StatusRequestedRangeNotSatisfiable:
 					// if range request error (blob push), drop mirror for this req, but other requests don't need backoff
 					dropHost = true
-				case http.StatusTooManyRequests, http.StatusRequestTimeout, http.StatusGatewayTimeout, http.StatusInternalServerError:
-					// server is likely overloaded, backoff but still retry
-					backoff = true
 				default:
-					// all other errors indicate a bigger issue, don't retry and set backoff
 					backoff = true
-					dropHost = true
+					if !h.retry.retryStatus(h.config.Name, statusCode) {
+						// all other errors indicate a bigger issue, don't retry and set backoff
+						dropHost = true
+					}
+					// otherwise the server is likely overloaded, backoff but still retry
 				}
 				c.log.WithFields(logrus.Fields{
-					"URL":    u.String(),
-					"Status": http.StatusText(statusCode),
+					"URL":       u.String(),
+					"Status":    http.StatusText(statusCode),
+					"host":      h.config.Name,
+					"repo":      api.Repository,
+					"operation": apiOperation(api),
 				}).Debug("Request failed")
 				errHTTP := HTTPError(resp.resp.StatusCode)
 				errBody, _ := io.ReadAll(resp.resp.Body)
@@ -495,7 +706,7 @@ func (resp *clientResp) Next() error {
 			}
 
 			// update digester
-			resp.reader = io.TeeReader(resp.resp.Body, resp.digester.Hash())
+			resp.reader = io.TeeReader(h.bwDown.Reader(resp.ctx, resp.resp.Body), resp.digester.Hash())
 			resp.done = false
 			// set variables from headers if found
 			if resp.readCur == 0 && resp.readMax == 0 && resp.resp.Header.Get("Content-Length") != "" {
@@ -508,16 +719,18 @@ func (resp *clientResp) Next() error {
 			if httpReq.Header.Get("Range") != "" && resp.resp.Header.Get("Content-Range") == "" {
 				dropHost = true
 				_ = resp.resp.Body.Close()
-				return fmt.Errorf("range request not supported by server")
+				return fmt.Errorf("range request not supported by server%.0w", types.ErrUnsupported)
 			}
 			return nil
 		}()
 		// return on success
 		if err == nil {
 			resp.throttle = h.config.Throttle()
+			c.hostSuccess(h)
 			return nil
 		}
 		// backoff, dropHost, and/or go to next host in the list
+		resp.cancelReq()
 		throttleErr = h.config.Throttle().Release(resp.ctx)
 		if throttleErr != nil {
 			return throttleErr
@@ -526,7 +739,12 @@ func (resp *clientResp) Next() error {
 			if api.IgnoreErr {
 				// don't set a backoff, immediately drop the host when errors ignored
 				dropHost = true
+			} else if h.config.Name != reqHost.config.Name {
+				// mark the mirror unhealthy and fall back to the remaining hosts
+				c.hostFailure(h, err, true)
+				dropHost = true
 			} else {
+				c.hostFailure(h, err, false)
 				boErr := resp.backoffSet()
 				if boErr != nil {
 					// reached backoff limit
@@ -542,6 +760,14 @@ func (resp *clientResp) Next() error {
 	}
 }
 
+// retryErr includes the attempts and backoff delays in the error when the request was retried.
+func (resp *clientResp) retryErr(err error) error {
+	if resp.attempts <= 1 && resp.wait == 0 {
+		return err
+	}
+	return &types.RetryError{Attempts: resp.attempts, Wait: resp.wait, Err: err}
+}
+
 func (resp *clientResp) HTTPResponse() *http.Response {
 	return resp.resp
 }
@@ -556,6 +782,9 @@ func (resp *clientResp) Read(b []byte) (int, error) {
 	// perform the read
 	i, err := resp.reader.Read(b)
 	resp.readCur += int64(i)
+	if i > 0 && resp.client.metrics != nil {
+		resp.client.metrics.BytesReceived(resp.mirror, int64(i))
+	}
 	if err == io.EOF || err == io.ErrUnexpectedEOF {
 		if resp.resp.Request.Method == "HEAD" || resp.readCur >= resp.readMax {
 			resp.backoffClear()
@@ -613,7 +842,54 @@ func (resp *clientResp) Close() error {
 		resp.backoffClear()
 	}
 	resp.done = true
-	return resp.resp.Body.Close()
+	err := resp.resp.Body.Close()
+	resp.cancelReq()
+	return err
+}
+
+// cancelReq releases the context of the current request when a timeout was set.
+func (resp *clientResp) cancelReq() {
+	if resp.cancel != nil {
+		resp.cancel()
+		resp.cancel = nil
+	}
+}
+
+// requestTimeout returns the time allowed for a request to the host, including the response body.
+// Blob transfers are only limited when BlobTimeoutRate is set, adding the time to transfer the blob at that rate.
+func (h *clientHost) requestTimeout(api ReqAPI) time.Duration {
+	timeout := time.Duration(h.config.RequestTimeout)
+	if api.BlobLen != 0 {
+		if h.config.BlobTimeoutRate <= 0 || api.BlobLen < 0 {
+			return 0
+		}
+		timeout += time.Duration(float64(api.BlobLen) / float64(h.config.BlobTimeoutRate) * float64(time.Second))
+	}
+	return timeout
+}
+
+// apiOperation returns the name of the registry operation for an API request, included in the log fields.
+func apiOperation(api ReqAPI) string {
+	method := strings.ToLower(api.Method)
+	seg, _, _ := strings.Cut(api.Path, "/")
+	switch {
+	case api.Repository == "" && api.Path == "":
+		return "ping"
+	case seg == "manifests":
+		return "manifest-" + method
+	case strings.HasPrefix(api.Path, "blobs/uploads"):
+		return "blob-upload"
+	case seg == "blobs":
+		return "blob-" + method
+	case api.Path == "tags/list":
+		return "tag-list"
+	case seg == "referrers":
+		return "referrer-list"
+	case api.Path == "_catalog":
+		return "repo-list"
+	default:
+		return method
+	}
 }
 
 func (resp *clientResp) Seek(offset int64, whence int) (int64, error) {
@@ -636,6 +912,9 @@ func (resp *clientResp) Seek(offset int64, whence int) (int64, error) {
 	if newOffset == 0 {
 		// reset digester
 		resp.digester = digest.Canonical.Digester()
+		if resp.digest != "" && resp.digest.Algorithm().Available() {
+			resp.digester = resp.digest.Algorithm().Digester()
+		}
 		resp.readCur = 0
 		// rerun the request to restart
 		err := resp.Next()
@@ -653,8 +932,8 @@ func (resp *clientResp) backoffClear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	ch := c.host[resp.mirror]
-	if ch.backoffCur > c.retryLimit {
-		ch.backoffCur = c.retryLimit
+	if ch.backoffCur > ch.retry.limit {
+		ch.backoffCur = ch.retry.limit
 	}
 	if ch.backoffCur > 0 {
 		ch.backoffCur--
@@ -671,17 +950,25 @@ func (resp *clientResp) backoffSet() error {
 	ch := c.host[resp.mirror]
 	ch.backoffCur++
 	// sleep for backoff time
-	sleepTime := c.delayInit << ch.backoffCur
+	sleepTime := ch.retry.delayInit << ch.backoffCur
+	// randomize the delay with the jitter
+	if ch.retry.jitter > 0 && !ch.retry.full {
+		sleepTime = time.Duration(float64(sleepTime) * (1 + ch.retry.jitter*(2*c.rand.Float64()-1)))
+	}
 	// limit to max delay
-	if sleepTime > c.delayMax {
-		sleepTime = c.delayMax
+	if sleepTime > ch.retry.delayMax {
+		sleepTime = ch.retry.delayMax
+	}
+	// full jitter selects a random delay up to the limit
+	if ch.retry.full && sleepTime > 0 {
+		sleepTime = time.Duration(c.rand.Int63n(int64(sleepTime) + 1))
 	}
 	// check rate limit header
 	if resp.resp != nil && resp.resp.Header.Get("Retry-After") != "" {
 		ras := resp.resp.Header.Get("Retry-After")
 		ra, _ := time.ParseDuration(ras + "s")
-		if ra > c.delayMax {
-			sleepTime = c.delayMax
+		if ra > ch.retry.delayMax {
+			sleepTime = ch.retry.delayMax
 		} else if ra > sleepTime {
 			sleepTime = ra
 		}
@@ -689,7 +976,7 @@ func (resp *clientResp) backoffSet() error {
 
 	ch.backoffUntil = time.Now().Add(sleepTime)
 
-	if ch.backoffCur >= c.retryLimit {
+	if ch.backoffCur >= ch.retry.limit {
 		return fmt.Errorf("%w: backoffs %d", types.ErrBackoffLimit, ch.backoffCur)
 	}
 
@@ -732,14 +1019,56 @@ func (c *Client) getHost(host string) *clientHost {
 	if h.auth == nil {
 		h.auth = map[string]auth.Auth{}
 	}
+	h.retry = retryPolicy{
+		limit:     c.retryLimit,
+		delayInit: c.delayInit,
+		delayMax:  c.delayMax,
+		jitter:    c.retryJitter,
+		full:      c.retryFull,
+		policy:    c.retryPolicy,
+		status:    c.retryStatus,
+		budget:    c.retryBudget,
+	}
+	if h.config.RetryLimit > 0 {
+		h.retry.limit = h.config.RetryLimit
+	}
+	if h.config.RetryDelayInit > 0 {
+		h.retry.delayInit = time.Duration(h.config.RetryDelayInit)
+	}
+	if h.config.RetryDelayMax > 0 {
+		h.retry.delayMax = time.Duration(h.config.RetryDelayMax)
+	}
+	if h.retry.delayMax < h.retry.delayInit {
+		h.retry.delayMax = h.retry.delayInit
+	}
+	if h.config.RetryJitter > 0 && h.config.RetryJitter <= 1 {
+		h.retry.jitter = h.config.RetryJitter
+	}
+	if len(h.config.RetryStatus) > 0 {
+		h.retry.status = h.config.RetryStatus
+	}
+	if h.config.RetryBudget > 0 {
+		h.retry.budget = time.Duration(h.config.RetryBudget)
+	}
 	if h.ratelimit == nil && h.config.ReqPerSec > 0 {
 		h.ratelimit = time.NewTicker(time.Duration(float64(time.Second) / h.config.ReqPerSec))
 	}
+	if h.bwDown == nil {
+		h.bwDown = bwlimit.New(h.config.DownloadRate)
+	}
+	if h.bwUp == nil {
+		h.bwUp = bwlimit.New(h.config.UploadRate)
+	}
 
 	if h.httpClient == nil {
 		h.httpClient = c.httpClient
 		// update http client for insecure requests and root certs
-		if h.config.TLS == config.TLSInsecure || len(c.rootCAPool) > 0 || len(c.rootCADirs) > 0 || h.config.RegCert != "" || (h.config.ClientCert != "" && h.config.ClientKey != "") {
+		if h.config.TLS == config.TLSInsecure || len(c.rootCAPool) > 0 || len(c.rootCADirs) > 0 || h.config.RegCert != "" || h.config.RegCertFile != "" ||
+			h.config.ClientCert != "" || h.config.ClientKey != "" || h.config.ClientCertFile != "" || h.config.ClientKeyFile != "" ||
+			len(h.config.SPKIPins) > 0 || h.config.TLSMinVersion != "" ||
+			h.config.HTTPProxy != "" || h.config.HTTPSProxy != "" || len(h.config.NoProxy) > 0 ||
+			h.config.ConnectTimeout > 0 || h.config.TLSTimeout > 0 || h.config.HeaderTimeout > 0 ||
+			h.config.IPFamily != "" || len(h.config.DNSServers) > 0 || len(h.config.StaticHosts) > 0 {
 			// create a new client and modify the transport
 			httpClient := *c.httpClient
 			if httpClient.Transport == nil {
@@ -757,7 +1086,20 @@ func (c *Client) getHost(host string) *clientHost {
 				if h.config.TLS == config.TLSInsecure {
 					tlsc.InsecureSkipVerify = true
 				} else {
-					rootPool, err := makeRootPool(c.rootCAPool, c.rootCADirs, h.config.Hostname, h.config.RegCert)
+					hostCert := h.config.RegCert
+					if h.config.RegCertFile != "" {
+						//#nosec G304 file is configured by the user
+						certFile, err := os.ReadFile(h.config.RegCertFile)
+						if err != nil {
+							c.log.WithFields(logrus.Fields{
+								"err":  err,
+								"file": h.config.RegCertFile,
+							}).Warn("failed to read CA bundle")
+						} else {
+							hostCert = strings.TrimSpace(hostCert + "\n" + string(certFile))
+						}
+					}
+					rootPool, err := makeRootPool(c.rootCAPool, c.rootCADirs, h.config.Hostname, hostCert)
 					if err != nil {
 						c.log.WithFields(logrus.Fields{
 							"err": err,
@@ -766,17 +1108,64 @@ func (c *Client) getHost(host string) *clientHost {
 						tlsc.RootCAs = rootPool
 					}
 				}
-				if h.config.ClientCert != "" && h.config.ClientKey != "" {
-					cert, err := tls.X509KeyPair([]byte(h.config.ClientCert), []byte(h.config.ClientKey))
+				cert, err := h.config.ClientCertificate()
+				if err != nil {
+					c.log.WithFields(logrus.Fields{
+						"err": err,
+					}).Warn("failed to configure client certs")
+				} else if cert != nil {
+					tlsc.Certificates = []tls.Certificate{*cert}
+				}
+				if h.config.TLSMinVersion != "" {
+					minVer, err := config.TLSVersion(h.config.TLSMinVersion)
 					if err != nil {
 						c.log.WithFields(logrus.Fields{
 							"err": err,
-						}).Warn("failed to configure client certs")
+						}).Warn("failed to configure minimum TLS version")
 					} else {
-						tlsc.Certificates = []tls.Certificate{cert}
+						tlsc.MinVersion = minVer
 					}
 				}
+				if len(h.config.SPKIPins) > 0 {
+					verify, err := config.SPKIPinsVerify(h.config.SPKIPins)
+					if err != nil {
+						// fail closed, an invalid pin must not disable pinning
+						c.log.WithFields(logrus.Fields{
+							"err": err,
+						}).Warn("failed to configure SPKI pins")
+						verify = func(tls.ConnectionState) error { return err }
+					}
+					tlsc.VerifyConnection = verify
+				}
 				t.TLSClientConfig = tlsc
+				proxy, err := proxyPolicy(h.config, t.Proxy)
+				if err != nil {
+					// fail closed, an invalid proxy must not fall back to a direct connection
+					c.log.WithFields(logrus.Fields{
+						"err": err,
+					}).Warn("failed to configure proxy")
+					proxy = func(*http.Request) (*url.URL, error) { return nil, err }
+				}
+				if proxy != nil {
+					t.Proxy = proxy
+				}
+				dial, dialErr := dialPolicy(h.config)
+				if dialErr != nil {
+					// fail closed, an invalid setting must not fall back to the default dialer
+					c.log.WithFields(logrus.Fields{
+						"err": dialErr,
+					}).Warn("failed to configure dialer")
+					dial = func(context.Context, string, string) (net.Conn, error) { return nil, dialErr }
+				}
+				if dial != nil {
+					t.DialContext = dial
+				}
+				if h.config.TLSTimeout > 0 {
+					t.TLSHandshakeTimeout = time.Duration(h.config.TLSTimeout)
+				}
+				if h.config.HeaderTimeout > 0 {
+					t.ResponseHeaderTimeout = time.Duration(h.config.HeaderTimeout)
+				}
 				httpClient.Transport = t
 			}
 			h.httpClient = &httpClient
@@ -785,12 +1174,20 @@ func (c *Client) getHost(host string) *clientHost {
 
 	if h.newAuth == nil {
 		h.newAuth = func() auth.Auth {
-			return auth.NewAuth(
+			authOpts := []auth.Opts{
 				auth.WithLog(c.log),
 				auth.WithHTTPClient(h.httpClient),
 				auth.WithCreds(h.AuthCreds()),
 				auth.WithClientID(c.userAgent),
-			)
+			}
+			if c.tokenCache != nil {
+				authOpts = append(authOpts, auth.WithTokenCache(c.tokenCache))
+			}
+			if c.metrics != nil {
+				name := h.config.Name
+				authOpts = append(authOpts, auth.WithTokenHook(func(string) { c.metrics.TokenRefresh(name) }))
+			}
+			return auth.NewAuth(authOpts...)
 		}
 	}
 
@@ -799,6 +1196,28 @@ func (c *Client) getHost(host string) *clientHost {
 	return h
 }
 
+// HTTPClient returns a client with the transport configured for the host, used for vendor APIs outside of the registry API.
+// Redirects to a different host are refused so credentials set on the request are not sent to another host.
+func (c *Client) HTTPClient(host string) *http.Client {
+	h := c.getHost(host)
+	hc := *h.httpClient
+	hc.CheckRedirect = redirectSameHost
+	return &hc
+}
+
+// retryStatus returns true when the status code should be retried with a backoff.
+func (rp retryPolicy) retryStatus(host string, statusCode int) bool {
+	if rp.policy != nil {
+		return rp.policy(host, statusCode)
+	}
+	for _, s := range rp.status {
+		if s == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
 // getAuth returns an auth, which may be repository specific
 func (ch *clientHost) getAuth(repo string) auth.Auth {
 	ch.mu.Lock()
@@ -817,8 +1236,8 @@ func (ch *clientHost) AuthCreds() func(h string) auth.Cred {
 		return auth.DefaultCredsFn
 	}
 	return func(h string) auth.Cred {
-		hCred := ch.config.GetCred()
-		return auth.Cred{User: hCred.User, Password: hCred.Password, Token: hCred.Token}
+		hCred, err := ch.config.LoadCred()
+		return auth.Cred{User: hCred.User, Password: hCred.Password, Token: hCred.Token, ClientID: hCred.ClientID, ClientSecret: hCred.ClientSecret, Err: err}
 	}
 }
 
@@ -888,18 +1307,3 @@ func makeRootPool(rootCAPool [][]byte, rootCADirs []string, hostname string, hos
 	}
 	return pool, nil
 }
-
-// sortHostCmp to sort host list of mirrors
-func sortHostsCmp(hosts []*clientHost, upstream string) func(i, j int) bool {
-	now := time.Now()
-	// sort by backoff first, then priority decending, then upstream name last
-	return func(i, j int) bool {
-		if now.Before(hosts[i].backoffUntil) || now.Before(hosts[j].backoffUntil) {
-			return hosts[i].backoffUntil.Before(hosts[j].backoffUntil)
-		}
-		if hosts[i].config.Priority != hosts[j].config.Priority {
-			return hosts[i].config.Priority < hosts[j].config.Priority
-		}
-		return hosts[i].config.Name != upstream
-	}
-}
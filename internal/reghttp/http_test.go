@@ -3,14 +3,22 @@ package reghttp
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +27,9 @@ import (
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/auth"
 	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/internal/timejson"
+	"github.com/regclient/regclient/pkg/metrics"
+	"github.com/regclient/regclient/pkg/trace"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/warning"
 )
@@ -1723,3 +1734,1354 @@ func TestRegHttp(t *testing.T) {
 	})
 	// TODO: test various TLS configs (custom root for all hosts, custom root for one host, insecure)
 }
+
+func TestRedirect(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobBody := []byte("redirected blob")
+	blobDigest := digest.FromBytes(blobBody)
+	storageAuth := ""
+	tsStorage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		storageAuth = req.Header.Get("Authorization")
+		_, _ = w.Write(blobBody)
+	}))
+	t.Cleanup(tsStorage.Close)
+	tsStorageURL, _ := url.Parse(tsStorage.URL)
+	// localhost is a different hostname from the 127.0.0.1 registry
+	storageLocal := "http://localhost:" + tsStorageURL.Port() + "/blob"
+	storageIP := "http://127.0.0.1:" + tsStorageURL.Port() + "/blob"
+	tsReg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v2/project/blobs/local":
+			http.Redirect(w, req, storageLocal, http.StatusTemporaryRedirect)
+		case "/v2/project/blobs/ip":
+			http.Redirect(w, req, storageIP, http.StatusTemporaryRedirect)
+		case "/v2/project/blobs/chain":
+			http.Redirect(w, req, "/v2/project/blobs/ip", http.StatusTemporaryRedirect)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tsReg.Close)
+	tsRegURL, _ := url.Parse(tsReg.URL)
+	tsHost := tsRegURL.Host
+
+	tt := []struct {
+		name       string
+		path       string
+		auth       string
+		max        int
+		allow      []string
+		deny       []string
+		expectErr  error
+		expectAuth string
+	}{
+		{
+			name: "default cross host",
+			path: "blobs/local",
+		},
+		{
+			name:       "default same hostname",
+			path:       "blobs/ip",
+			expectAuth: "Bearer testtoken",
+		},
+		{
+			name:       "keep",
+			path:       "blobs/local",
+			auth:       config.RedirectAuthKeep,
+			expectAuth: "Bearer testtoken",
+		},
+		{
+			name: "strip",
+			path: "blobs/ip",
+			auth: config.RedirectAuthStrip,
+		},
+		{
+			name:  "allowed",
+			path:  "blobs/local",
+			allow: []string{"LocalHost"},
+		},
+		{
+			name:      "not allowed",
+			path:      "blobs/local",
+			allow:     []string{"*.example.com"},
+			expectErr: types.ErrRedirectDenied,
+		},
+		{
+			name:      "denied",
+			path:      "blobs/local",
+			allow:     []string{"localhost"},
+			deny:      []string{"localhost"},
+			expectErr: types.ErrRedirectDenied,
+		},
+		{
+			name:      "disabled",
+			path:      "blobs/local",
+			max:       -1,
+			expectErr: types.ErrRedirectDenied,
+		},
+		{
+			name:      "max",
+			path:      "blobs/chain",
+			max:       2,
+			expectErr: types.ErrRedirectDenied,
+		},
+		{
+			name:       "max chain",
+			path:       "blobs/chain",
+			max:        3,
+			expectAuth: "Bearer testtoken",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			hc := NewClient(
+				WithConfigHost(func(name string) *config.Host {
+					h := config.HostNewName(name)
+					h.TLS = config.TLSDisabled
+					h.RedirectAuth = tc.auth
+					h.RedirectMax = tc.max
+					h.RedirectAllow = tc.allow
+					h.RedirectDeny = tc.deny
+					return h
+				}),
+				WithDelay(time.Millisecond, time.Millisecond),
+			)
+			storageAuth = ""
+			resp, err := hc.Do(ctx, &Req{
+				Host: tsHost,
+				APIs: map[string]ReqAPI{
+					"": {
+						Method:     "GET",
+						Repository: "project",
+						Path:       tc.path,
+						Headers:    http.Header{"Authorization": []string{"Bearer testtoken"}},
+						Digest:     blobDigest,
+					},
+				},
+			})
+			if tc.expectErr != nil {
+				if err == nil {
+					resp.Close()
+					t.Fatalf("redirect did not fail")
+				}
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to run get: %v", err)
+			}
+			body, err := io.ReadAll(resp)
+			_ = resp.Close()
+			if err != nil {
+				t.Fatalf("body read failure: %v", err)
+			}
+			if !bytes.Equal(body, blobBody) {
+				t.Errorf("body mismatch, expected %s, received %s", blobBody, body)
+			}
+			if storageAuth != tc.expectAuth {
+				t.Errorf("unexpected auth header, expected %q, received %q", tc.expectAuth, storageAuth)
+			}
+		})
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobBody := []byte("tls blob")
+	blobDigest := digest.FromBytes(blobBody)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write(blobBody)
+	}))
+	ts.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	// handshake failures are expected
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	pin := config.SPKIHash(ts.Certificate())
+
+	tt := []struct {
+		name      string
+		certFile  string
+		pins      []string
+		minVer    string
+		expectErr bool
+	}{
+		{
+			name:      "untrusted",
+			expectErr: true,
+		},
+		{
+			name:     "ca file",
+			certFile: caFile,
+		},
+		{
+			name:     "pin",
+			certFile: caFile,
+			pins:     []string{pin},
+		},
+		{
+			name:     "pin without prefix",
+			certFile: caFile,
+			pins:     []string{"sha256/" + base64.StdEncoding.EncodeToString(make([]byte, 32)), strings.TrimPrefix(pin, "sha256/")},
+		},
+		{
+			name:      "pin mismatch",
+			certFile:  caFile,
+			pins:      []string{"sha256/" + base64.StdEncoding.EncodeToString(make([]byte, 32))},
+			expectErr: true,
+		},
+		{
+			name:      "invalid pin",
+			certFile:  caFile,
+			pins:      []string{"invalid"},
+			expectErr: true,
+		},
+		{
+			name:     "min version",
+			certFile: caFile,
+			minVer:   "1.2",
+		},
+		{
+			name:      "min version unsupported by server",
+			certFile:  caFile,
+			minVer:    "1.3",
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			hc := NewClient(
+				WithConfigHost(func(name string) *config.Host {
+					h := config.HostNewName(name)
+					h.RegCertFile = tc.certFile
+					h.SPKIPins = tc.pins
+					h.TLSMinVersion = tc.minVer
+					return h
+				}),
+				WithDelay(time.Millisecond, time.Millisecond),
+			)
+			resp, err := hc.Do(ctx, &Req{
+				Host: tsHost,
+				APIs: map[string]ReqAPI{
+					"": {
+						Method:     "GET",
+						Repository: "project",
+						Path:       "blobs/tls",
+						Digest:     blobDigest,
+					},
+				},
+			})
+			if tc.expectErr {
+				if err == nil {
+					resp.Close()
+					t.Fatalf("request did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to run get: %v", err)
+			}
+			body, err := io.ReadAll(resp)
+			_ = resp.Close()
+			if err != nil {
+				t.Fatalf("body read failure: %v", err)
+			}
+			if !bytes.Equal(body, blobBody) {
+				t.Errorf("body mismatch, expected %s, received %s", blobBody, body)
+			}
+		})
+	}
+}
+
+func TestProxy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobBody := []byte("proxy blob")
+	blobDigest := digest.FromBytes(blobBody)
+	// the proxy receives requests with the absolute url of the registry
+	proxyHost := ""
+	tsProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxyHost = req.URL.Host
+		_, _ = w.Write(blobBody)
+	}))
+	t.Cleanup(tsProxy.Close)
+	tsReg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write(blobBody)
+	}))
+	t.Cleanup(tsReg.Close)
+	tsRegURL, _ := url.Parse(tsReg.URL)
+
+	tt := []struct {
+		name        string
+		host        string
+		httpProxy   string
+		noProxy     []string
+		expectProxy string
+		expectErr   bool
+	}{
+		{
+			name:        "proxy",
+			host:        "registry.example.invalid:5000",
+			httpProxy:   tsProxy.URL,
+			expectProxy: "registry.example.invalid:5000",
+		},
+		{
+			name:      "no proxy",
+			host:      tsRegURL.Host,
+			httpProxy: tsProxy.URL,
+			noProxy:   []string{"127.0.0.0/8"},
+		},
+		{
+			name:      "invalid proxy",
+			host:      tsRegURL.Host,
+			httpProxy: "ftp://proxy.example.com",
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			hc := NewClient(
+				WithConfigHost(func(name string) *config.Host {
+					h := config.HostNewName(name)
+					h.TLS = config.TLSDisabled
+					h.HTTPProxy = tc.httpProxy
+					h.NoProxy = tc.noProxy
+					return h
+				}),
+				WithDelay(time.Millisecond, time.Millisecond),
+			)
+			proxyHost = ""
+			resp, err := hc.Do(ctx, &Req{
+				Host: tc.host,
+				APIs: map[string]ReqAPI{
+					"": {
+						Method:     "GET",
+						Repository: "project",
+						Path:       "blobs/proxy",
+						Digest:     blobDigest,
+					},
+				},
+			})
+			if tc.expectErr {
+				if err == nil {
+					resp.Close()
+					t.Fatalf("request did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to run get: %v", err)
+			}
+			body, err := io.ReadAll(resp)
+			_ = resp.Close()
+			if err != nil {
+				t.Fatalf("body read failure: %v", err)
+			}
+			if !bytes.Equal(body, blobBody) {
+				t.Errorf("body mismatch, expected %s, received %s", blobBody, body)
+			}
+			if proxyHost != tc.expectProxy {
+				t.Errorf("unexpected proxy request, expected %q, received %q", tc.expectProxy, proxyHost)
+			}
+		})
+	}
+}
+
+func TestProxyHostMatch(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		url    string
+		list   []string
+		expect bool
+	}{
+		{url: "https://registry.example.com/v2/", list: []string{"*"}, expect: true},
+		{url: "https://registry.example.com/v2/", list: []string{"example.com"}, expect: true},
+		{url: "https://example.com/v2/", list: []string{"example.com"}, expect: true},
+		{url: "https://example.com/v2/", list: []string{".example.com"}, expect: false},
+		{url: "https://registry.example.com/v2/", list: []string{"*.example.com"}, expect: true},
+		{url: "https://registry.example.org/v2/", list: []string{"example.com"}, expect: false},
+		{url: "https://badexample.com/v2/", list: []string{"example.com"}, expect: false},
+		{url: "https://registry.example.com/v2/", list: []string{"registry.example.com:443"}, expect: true},
+		{url: "https://registry.example.com:5000/v2/", list: []string{"registry.example.com:443"}, expect: false},
+		{url: "http://10.1.2.3:5000/v2/", list: []string{"10.0.0.0/8"}, expect: true},
+		{url: "http://192.168.1.2/v2/", list: []string{"10.0.0.0/8"}, expect: false},
+		{url: "http://[::1]:5000/v2/", list: []string{"::1"}, expect: true},
+		{url: "https://registry.example.com/v2/", list: []string{}, expect: false},
+	}
+	for _, tc := range tt {
+		u, err := url.Parse(tc.url)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", tc.url, err)
+		}
+		if result := proxyHostMatch(u, tc.list); result != tc.expect {
+			t.Errorf("unexpected result for %s with %v, expected %t", tc.url, tc.list, tc.expect)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobBody := []byte("retry blob")
+	blobDigest := digest.FromBytes(blobBody)
+	tt := []struct {
+		name           string
+		failStatus     int
+		failCount      int
+		retryStatus    []int
+		retryPolicy    RetryPolicy
+		retryBudget    time.Duration
+		retryLimit     int
+		expectReqs     int
+		expectErr      bool
+		expectBudget   bool
+		expectRetryErr bool
+	}{
+		{
+			name:       "default retry",
+			failStatus: http.StatusTooManyRequests,
+			failCount:  2,
+			expectReqs: 3,
+		},
+		{
+			name:       "default no retry",
+			failStatus: http.StatusServiceUnavailable,
+			failCount:  2,
+			expectReqs: 1,
+			expectErr:  true,
+		},
+		{
+			name:        "retry status",
+			failStatus:  http.StatusServiceUnavailable,
+			failCount:   2,
+			retryStatus: []int{http.StatusServiceUnavailable},
+			expectReqs:  3,
+		},
+		{
+			name:           "retry limit",
+			failStatus:     http.StatusTooManyRequests,
+			failCount:      100,
+			retryLimit:     3,
+			expectReqs:     3,
+			expectErr:      true,
+			expectRetryErr: true,
+		},
+		{
+			name:       "retry policy",
+			failStatus: http.StatusServiceUnavailable,
+			failCount:  2,
+			retryPolicy: func(host string, statusCode int) bool {
+				return statusCode == http.StatusServiceUnavailable
+			},
+			expectReqs: 3,
+		},
+		{
+			name:        "retry policy overrides status",
+			failStatus:  http.StatusTooManyRequests,
+			failCount:   2,
+			retryStatus: []int{http.StatusTooManyRequests},
+			retryPolicy: func(host string, statusCode int) bool {
+				return false
+			},
+			expectReqs: 1,
+			expectErr:  true,
+		},
+		{
+			name:         "budget exceeded",
+			failStatus:   http.StatusInternalServerError,
+			failCount:    100,
+			retryBudget:  time.Millisecond * 30,
+			expectErr:    true,
+			expectBudget: true,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var mu sync.Mutex
+			reqs := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mu.Lock()
+				reqs++
+				cur := reqs
+				mu.Unlock()
+				if cur <= tc.failCount {
+					w.WriteHeader(tc.failStatus)
+					return
+				}
+				_, _ = w.Write(blobBody)
+			}))
+			t.Cleanup(ts.Close)
+			tsURL, _ := url.Parse(ts.URL)
+			hc := NewClient(
+				WithConfigHost(func(name string) *config.Host {
+					h := config.HostNewName(name)
+					h.TLS = config.TLSDisabled
+					h.RetryStatus = tc.retryStatus
+					h.RetryBudget = timejson.Duration(tc.retryBudget)
+					h.RetryLimit = tc.retryLimit
+					return h
+				}),
+				WithDelay(time.Millisecond*10, time.Millisecond*10),
+				WithRetryLimit(100),
+				WithRetryPolicy(tc.retryPolicy),
+			)
+			start := time.Now()
+			resp, err := hc.Do(ctx, &Req{
+				Host: tsURL.Host,
+				APIs: map[string]ReqAPI{
+					"": {
+						Method:     "GET",
+						Repository: "project",
+						Path:       "blobs/retry",
+						Digest:     blobDigest,
+					},
+				},
+			})
+			if tc.expectErr {
+				if err == nil {
+					resp.Close()
+					t.Fatalf("request did not fail")
+				}
+				if tc.expectBudget {
+					if !strings.Contains(err.Error(), "retry budget") {
+						t.Errorf("unexpected error: %v", err)
+					}
+					if time.Since(start) > time.Second {
+						t.Errorf("request exceeded the budget, elapsed %s", time.Since(start))
+					}
+				}
+				if tc.expectRetryErr {
+					var retryErr *types.RetryError
+					if !errors.As(err, &retryErr) {
+						t.Errorf("error does not include retries: %v", err)
+					} else if retryErr.Attempts != tc.expectReqs || retryErr.Wait <= 0 {
+						t.Errorf("unexpected retries, attempts %d, wait %s", retryErr.Attempts, retryErr.Wait)
+					}
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("failed to run get: %v", err)
+				}
+				body, err := io.ReadAll(resp)
+				_ = resp.Close()
+				if err != nil {
+					t.Fatalf("body read failure: %v", err)
+				}
+				if !bytes.Equal(body, blobBody) {
+					t.Errorf("body mismatch, expected %s, received %s", blobBody, body)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if tc.expectReqs > 0 && reqs != tc.expectReqs {
+				t.Errorf("unexpected number of requests, expected %d, received %d", tc.expectReqs, reqs)
+			}
+		})
+	}
+}
+
+func TestRetryJitter(t *testing.T) {
+	t.Parallel()
+	delay := time.Millisecond * 100
+	c := NewClient(WithRetryJitter(0.5), WithDelay(delay, time.Second))
+	c.host["jitter"] = &clientHost{
+		retry: retryPolicy{limit: 100, delayInit: delay, delayMax: time.Second, jitter: c.retryJitter},
+	}
+	resp := &clientResp{client: c, mirror: "jitter"}
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		c.host["jitter"].backoffCur = 0
+		start := time.Now()
+		if err := resp.backoffSet(); err != nil {
+			t.Fatalf("backoff failed: %v", err)
+		}
+		// first backoff is delayInit<<1 with +/- 50% jitter
+		sleep := c.host["jitter"].backoffUntil.Sub(start)
+		if sleep < delay || sleep > delay*3+time.Millisecond*10 {
+			t.Errorf("backoff outside of jitter range: %s", sleep)
+		}
+		seen[sleep.Round(time.Millisecond)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("backoff delay was not randomized")
+	}
+}
+
+func TestRetryFullJitter(t *testing.T) {
+	t.Parallel()
+	delay := time.Millisecond * 100
+	c := NewClient(WithRetryFullJitter(), WithRetryJitter(0.5), WithDelay(delay, time.Second))
+	c.host["jitter"] = &clientHost{
+		retry: retryPolicy{limit: 100, delayInit: delay, delayMax: time.Second, jitter: c.retryJitter, full: c.retryFull},
+	}
+	resp := &clientResp{client: c, mirror: "jitter"}
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		c.host["jitter"].backoffCur = 3
+		start := time.Now()
+		if err := resp.backoffSet(); err != nil {
+			t.Fatalf("backoff failed: %v", err)
+		}
+		// fourth backoff is delayInit<<4, limited to delayMax, with a random delay up to that limit
+		sleep := c.host["jitter"].backoffUntil.Sub(start)
+		if sleep < 0 || sleep > time.Second+time.Millisecond*10 {
+			t.Errorf("backoff outside of jitter range: %s", sleep)
+		}
+		seen[sleep.Round(time.Millisecond)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("backoff delay was not randomized")
+	}
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	names []string
+	attrs []map[string]interface{}
+	errs  []error
+}
+
+type testSpan struct {
+	t *testTracer
+	i int
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, attrs ...trace.Attr) (context.Context, trace.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.names = append(t.names, name)
+	t.attrs = append(t.attrs, map[string]interface{}{})
+	t.errs = append(t.errs, nil)
+	s := testSpan{t: t, i: len(t.names) - 1}
+	s.SetAttributes(attrs...)
+	return ctx, s
+}
+
+func (t *testTracer) Inject(ctx context.Context, header http.Header) {
+	header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+}
+
+func (s testSpan) SetAttributes(attrs ...trace.Attr) {
+	for _, a := range attrs {
+		s.t.attrs[s.i][a.Key] = a.Value
+	}
+}
+
+func (s testSpan) End(err error) {
+	s.t.errs[s.i] = err
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobBody := []byte("trace blob")
+	blobDigest := digest.FromBytes(blobBody)
+	traceHeader := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		traceHeader = req.Header.Get("traceparent")
+		if req.URL.Path == "/v2/project/blobs/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(blobBody)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tracer := &testTracer{}
+	hc := NewClient(
+		WithConfigHost(func(name string) *config.Host {
+			h := config.HostNewName(name)
+			h.TLS = config.TLSDisabled
+			return h
+		}),
+		WithDelay(time.Millisecond, time.Millisecond),
+		WithTracer(tracer),
+	)
+	resp, err := hc.Do(ctx, &Req{
+		Host: tsURL.Host,
+		APIs: map[string]ReqAPI{
+			"": {
+				Method:     "GET",
+				Repository: "project",
+				Path:       "blobs/trace",
+				Digest:     blobDigest,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to run get: %v", err)
+	}
+	_ = resp.Close()
+	if traceHeader == "" {
+		t.Errorf("trace header was not sent")
+	}
+	if len(tracer.names) != 1 || tracer.names[0] != "HTTP GET" {
+		t.Fatalf("unexpected spans: %v", tracer.names)
+	}
+	expect := map[string]interface{}{
+		trace.AttrHost:       tsURL.Host,
+		trace.AttrMethod:     "GET",
+		trace.AttrRepository: "project",
+		trace.AttrStatusCode: int64(http.StatusOK),
+	}
+	for k, v := range expect {
+		if tracer.attrs[0][k] != v {
+			t.Errorf("unexpected attribute %s, expected %v, received %v", k, v, tracer.attrs[0][k])
+		}
+	}
+	_, err = hc.Do(ctx, &Req{
+		Host: tsURL.Host,
+		APIs: map[string]ReqAPI{
+			"": {
+				Method:     "GET",
+				Repository: "project",
+				Path:       "blobs/missing",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatalf("request for missing blob did not fail")
+	}
+	if len(tracer.names) != 2 || tracer.errs[1] == nil || tracer.attrs[1][trace.AttrStatusCode] != int64(http.StatusNotFound) {
+		t.Errorf("failed request not recorded, spans %v, attrs %v, errs %v", tracer.names, tracer.attrs, tracer.errs)
+	}
+}
+
+type testMetrics struct {
+	metrics.Nop
+	mu       sync.Mutex
+	requests map[string]int
+	sent     int64
+	received int64
+	retries  int
+	tokens   int
+}
+
+func (m *testMetrics) Request(host, method string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[fmt.Sprintf("%s %d", method, status)]++
+}
+
+func (m *testMetrics) BytesSent(host string, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent += n
+}
+
+func (m *testMetrics) BytesReceived(host string, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received += n
+}
+
+func (m *testMetrics) Retry(host, method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+}
+
+func (m *testMetrics) TokenRefresh(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens++
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobBody := []byte("metrics blob")
+	blobDigest := digest.FromBytes(blobBody)
+	putBody := []byte("metrics upload")
+	token := "metrics-token"
+	var mu sync.Mutex
+	failed := false
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			tokenResp, _ := json.Marshal(auth.BearerToken{Token: token, IssuedAt: time.Now(), ExpiresIn: 900})
+			_, _ = w.Write(tokenResp)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+ts.URL+`/token",service=test,scope="repository:project:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		fail := !failed
+		failed = true
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if req.Method == "PUT" {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		_, _ = w.Write(blobBody)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	m := &testMetrics{requests: map[string]int{}}
+	hc := NewClient(
+		WithConfigHost(func(name string) *config.Host {
+			h := config.HostNewName(name)
+			h.TLS = config.TLSDisabled
+			return h
+		}),
+		WithDelay(time.Millisecond, time.Millisecond),
+		WithMetrics(m),
+	)
+	resp, err := hc.Do(ctx, &Req{
+		Host: tsURL.Host,
+		APIs: map[string]ReqAPI{
+			"": {
+				Method:     "GET",
+				Repository: "project",
+				Path:       "blobs/metrics",
+				Digest:     blobDigest,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to run get: %v", err)
+	}
+	_, err = io.ReadAll(resp)
+	_ = resp.Close()
+	if err != nil {
+		t.Fatalf("body read failure: %v", err)
+	}
+	resp, err = hc.Do(ctx, &Req{
+		Host: tsURL.Host,
+		APIs: map[string]ReqAPI{
+			"": {
+				Method:     "PUT",
+				Repository: "project",
+				Path:       "blobs/uploads/metrics",
+				BodyBytes:  putBody,
+				BodyLen:    int64(len(putBody)),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to run put: %v", err)
+	}
+	_ = resp.Close()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expectReq := map[string]int{"GET 401": 1, "GET 500": 1, "GET 200": 1, "PUT 201": 1}
+	for k, v := range expectReq {
+		if m.requests[k] != v {
+			t.Errorf("unexpected request count for %s, expected %d, received %d", k, v, m.requests[k])
+		}
+	}
+	if m.retries != 2 {
+		t.Errorf("unexpected retries, expected 2, received %d", m.retries)
+	}
+	// the push scope on the put requests a second token
+	if m.tokens != 2 {
+		t.Errorf("unexpected token refreshes, expected 2, received %d", m.tokens)
+	}
+	if m.sent != int64(len(putBody)) {
+		t.Errorf("unexpected bytes sent, expected %d, received %d", len(putBody), m.sent)
+	}
+	if m.received != int64(len(blobBody)) {
+		t.Errorf("unexpected bytes received, expected %d, received %d", len(blobBody), m.received)
+	}
+}
+
+func TestSortHosts(t *testing.T) {
+	t.Parallel()
+	c := NewClient()
+	newHost := func(name string, priority, weight uint) *clientHost {
+		return &clientHost{config: &config.Host{Name: name, Priority: priority, Weight: weight}}
+	}
+	upstream := newHost("upstream", 0, 0)
+	high := newHost("high", 10, 0)
+	low := newHost("low", 0, 0)
+	backoff := newHost("backoff", 20, 0)
+	backoff.backoffUntil = time.Now().Add(time.Minute)
+	hosts := c.sortHosts([]*clientHost{upstream, low, backoff, high}, "upstream")
+	names := []string{}
+	for _, h := range hosts {
+		names = append(names, h.config.Name)
+	}
+	if strings.Join(names, ",") != "high,low,upstream,backoff" {
+		t.Errorf("unexpected order: %v", names)
+	}
+	// weights select the heavier mirror more often
+	light := newHost("light", 0, 1)
+	heavy := newHost("heavy", 0, 9)
+	count := 0
+	for i := 0; i < 1000; i++ {
+		hosts := c.sortHosts([]*clientHost{light, heavy, upstream}, "upstream")
+		if hosts[0] == heavy {
+			count++
+		}
+		if hosts[2] != upstream {
+			t.Fatalf("upstream not sorted last")
+		}
+	}
+	if count < 800 || count == 1000 {
+		t.Errorf("unexpected weighted selection, heavy first %d of 1000", count)
+	}
+}
+
+func TestMirrorHealth(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	body := []byte("manifest")
+	var mu sync.Mutex
+	hits := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/v2/mirror-down/project/manifests/tag":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/v2/mirror-missing/project/manifests/tag":
+			w.WriteHeader(http.StatusNotFound)
+		case "/v2/project/manifests/tag":
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(body)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	configHosts := map[string]*config.Host{
+		"upstream." + tsHost: {
+			Name:     "upstream." + tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			Mirrors:  []string{"down." + tsHost, "missing." + tsHost},
+		},
+		"down." + tsHost: {
+			Name:          "down." + tsHost,
+			Hostname:      tsHost,
+			TLS:           config.TLSDisabled,
+			PathPrefix:    "mirror-down",
+			Priority:      10,
+			MirrorBackoff: timejson.Duration(time.Hour),
+		},
+		"missing." + tsHost: {
+			Name:       "missing." + tsHost,
+			Hostname:   tsHost,
+			TLS:        config.TLSDisabled,
+			PathPrefix: "mirror-missing",
+			Priority:   5,
+		},
+	}
+	hc := NewClient(
+		WithConfigHost(func(name string) *config.Host {
+			if configHosts[name] == nil {
+				configHosts[name] = config.HostNewName(name)
+			}
+			return configHosts[name]
+		}),
+		WithDelay(time.Millisecond*10, time.Millisecond*50),
+	)
+	req := &Req{
+		Host: "upstream." + tsHost,
+		APIs: map[string]ReqAPI{
+			"": {
+				Method:     "GET",
+				Repository: "project",
+				Path:       "manifests/tag",
+			},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := hc.Do(ctx, req)
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		out, err := io.ReadAll(resp)
+		if err != nil || !bytes.Equal(out, body) {
+			t.Errorf("unexpected body %s: %v", out, err)
+		}
+		_ = resp.Close()
+	}
+	mu.Lock()
+	if hits["/v2/mirror-down/project/manifests/tag"] != 1 {
+		t.Errorf("unhealthy mirror was not skipped, requests: %d", hits["/v2/mirror-down/project/manifests/tag"])
+	}
+	if hits["/v2/mirror-missing/project/manifests/tag"] != 2 {
+		t.Errorf("missing mirror should be retried, requests: %d", hits["/v2/mirror-missing/project/manifests/tag"])
+	}
+	mu.Unlock()
+	health := hc.HostHealth("upstream." + tsHost)
+	if len(health) != 3 {
+		t.Fatalf("unexpected health entries: %v", health)
+	}
+	if health[0].Mirror || !health[0].Healthy || health[0].LastSuccess.IsZero() {
+		t.Errorf("unexpected upstream health: %v", health[0])
+	}
+	if !health[1].Mirror || health[1].Healthy || health[1].Failures != 1 || health[1].LastError == "" || time.Until(health[1].UnhealthyUntil) < time.Minute {
+		t.Errorf("unexpected down mirror health: %v", health[1])
+	}
+	if !health[2].Mirror || !health[2].Healthy || health[2].Failures != 0 {
+		t.Errorf("unexpected missing mirror health: %v", health[2])
+	}
+}
+
+func TestBandwidth(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	body := bytes.Repeat([]byte("x"), 60000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(body)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(body)
+		case http.MethodPut:
+			in, _ := io.ReadAll(r.Body)
+			if !bytes.Equal(in, body) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	configHosts := map[string]*config.Host{
+		"limited." + tsHost: {
+			Name:         "limited." + tsHost,
+			Hostname:     tsHost,
+			TLS:          config.TLSDisabled,
+			DownloadRate: 50000,
+			UploadRate:   50000,
+		},
+	}
+	hc := NewClient(
+		WithConfigHost(func(name string) *config.Host {
+			if configHosts[name] == nil {
+				configHosts[name] = config.HostNewName(name)
+			}
+			return configHosts[name]
+		}),
+	)
+	// the first 50000 bytes are sent as a burst, the remaining bytes are delayed
+	t.Run("Download", func(t *testing.T) {
+		start := time.Now()
+		resp, err := hc.Do(ctx, &Req{
+			Host: "limited." + tsHost,
+			APIs: map[string]ReqAPI{
+				"": {Method: "GET", Repository: "project", Path: "blobs/download"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to get: %v", err)
+		}
+		out, err := io.ReadAll(resp)
+		_ = resp.Close()
+		if err != nil || !bytes.Equal(out, body) {
+			t.Errorf("unexpected body, len %d: %v", len(out), err)
+		}
+		if elapsed := time.Since(start); elapsed < time.Millisecond*150 {
+			t.Errorf("download was not limited: %s", elapsed)
+		}
+	})
+	t.Run("Upload", func(t *testing.T) {
+		start := time.Now()
+		resp, err := hc.Do(ctx, &Req{
+			Host: "limited." + tsHost,
+			APIs: map[string]ReqAPI{
+				"": {Method: "PUT", Repository: "project", Path: "blobs/upload", BodyBytes: body, BodyLen: int64(len(body))},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to put: %v", err)
+		}
+		_ = resp.Close()
+		if resp.HTTPResponse().StatusCode != http.StatusCreated {
+			t.Errorf("unexpected status: %d", resp.HTTPResponse().StatusCode)
+		}
+		if elapsed := time.Since(start); elapsed < time.Millisecond*150 {
+			t.Errorf("upload was not limited: %s", elapsed)
+		}
+	})
+}
+
+func TestTimeouts(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	body := []byte("slow response")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// delay the headers on the slow path, and the body on all other paths
+		if strings.HasSuffix(r.URL.Path, "/slow-header") {
+			select {
+			case <-time.After(time.Millisecond * 300):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(body)))
+		w.WriteHeader(http.StatusOK)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		select {
+		case <-time.After(time.Millisecond * 300):
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	configHosts := map[string]*config.Host{
+		"request." + tsHost: {
+			Name:           "request." + tsHost,
+			Hostname:       tsHost,
+			TLS:            config.TLSDisabled,
+			RequestTimeout: timejson.Duration(time.Millisecond * 100),
+			RetryLimit:     1,
+		},
+		"blob." + tsHost: {
+			Name:            "blob." + tsHost,
+			Hostname:        tsHost,
+			TLS:             config.TLSDisabled,
+			RequestTimeout:  timejson.Duration(time.Millisecond * 100),
+			BlobTimeoutRate: 1000000,
+			RetryLimit:      1,
+		},
+		"header." + tsHost: {
+			Name:          "header." + tsHost,
+			Hostname:      tsHost,
+			TLS:           config.TLSDisabled,
+			HeaderTimeout: timejson.Duration(time.Millisecond * 100),
+			RetryLimit:    1,
+		},
+	}
+	hc := NewClient(
+		WithConfigHost(func(name string) *config.Host {
+			if configHosts[name] == nil {
+				configHosts[name] = config.HostNewName(name)
+			}
+			return configHosts[name]
+		}),
+		WithDelay(time.Millisecond*10, time.Millisecond*50),
+	)
+	get := func(host, path string, blobLen int64) ([]byte, error) {
+		resp, err := hc.Do(ctx, &Req{
+			Host: host,
+			APIs: map[string]ReqAPI{
+				"": {Method: "GET", Repository: "project", Path: path, BlobLen: blobLen},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Close()
+		return io.ReadAll(resp)
+	}
+	tt := []struct {
+		name    string
+		host    string
+		path    string
+		blobLen int64
+		expErr  bool
+	}{
+		{name: "request", host: "request." + tsHost, path: "manifests/tag", expErr: true},
+		{name: "request blob without rate", host: "request." + tsHost, path: "blobs/digest", blobLen: 1000},
+		{name: "blob scaled", host: "blob." + tsHost, path: "blobs/digest", blobLen: 1000, expErr: true},
+		{name: "blob unknown size", host: "blob." + tsHost, path: "blobs/digest", blobLen: -1},
+		{name: "blob large", host: "blob." + tsHost, path: "blobs/digest", blobLen: 1000000},
+		{name: "header", host: "header." + tsHost, path: "manifests/slow-header", expErr: true},
+		{name: "header slow body", host: "header." + tsHost, path: "manifests/tag"},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			out, err := get(tc.host, tc.path, tc.blobLen)
+			if tc.expErr {
+				if err == nil {
+					t.Errorf("request did not time out")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if !bytes.Equal(out, body) {
+				t.Errorf("unexpected body: %s", out)
+			}
+		})
+	}
+}
+
+func TestDialPolicy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	_, tsPort, _ := net.SplitHostPort(tsURL.Host)
+	// minimal DNS server that resolves every A query to 127.0.0.1
+	dnsConn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for dns: %v", err)
+	}
+	t.Cleanup(func() { _ = dnsConn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := dnsConn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 12 {
+				continue
+			}
+			// find the end of the question name, followed by the type and class
+			i := 12
+			for i < n && buf[i] != 0 {
+				i += int(buf[i]) + 1
+			}
+			if i+5 > n {
+				continue
+			}
+			qEnd := i + 5
+			qType := int(buf[i+1])<<8 | int(buf[i+2])
+			resp := append([]byte{}, buf[:qEnd]...)
+			resp[2], resp[3] = 0x81, 0x80
+			resp[6], resp[7], resp[8], resp[9], resp[10], resp[11] = 0, 0, 0, 0, 0, 0
+			if qType == 1 {
+				resp[7] = 1
+				resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 1)
+			}
+			_, _ = dnsConn.WriteTo(resp, addr)
+		}
+	}()
+	configHosts := map[string]*config.Host{
+		"static.example.invalid": {
+			Name:        "static.example.invalid",
+			Hostname:    "static.example.invalid:" + tsPort,
+			TLS:         config.TLSDisabled,
+			StaticHosts: map[string][]string{"static.example.invalid": {"192.0.2.1", "127.0.0.1"}},
+			IPFamily:    config.IPFamilyIPv4,
+			RetryLimit:  1,
+		},
+		"static-ipv6.example.invalid": {
+			Name:        "static-ipv6.example.invalid",
+			Hostname:    "static-ipv6.example.invalid:" + tsPort,
+			TLS:         config.TLSDisabled,
+			StaticHosts: map[string][]string{"static-ipv6.example.invalid": {"127.0.0.1"}},
+			IPFamily:    config.IPFamilyIPv6,
+			RetryLimit:  1,
+		},
+		"dns.example.invalid": {
+			Name:       "dns.example.invalid",
+			Hostname:   "dns.example.invalid:" + tsPort,
+			TLS:        config.TLSDisabled,
+			DNSServers: []string{dnsConn.LocalAddr().String()},
+			IPFamily:   config.IPFamilyIPv4,
+			RetryLimit: 1,
+		},
+		"family.example.invalid": {
+			Name:       "family.example.invalid",
+			Hostname:   "127.0.0.1:" + tsPort,
+			TLS:        config.TLSDisabled,
+			IPFamily:   "ipv5",
+			RetryLimit: 1,
+		},
+	}
+	hc := NewClient(
+		WithConfigHost(func(name string) *config.Host {
+			if configHosts[name] == nil {
+				configHosts[name] = config.HostNewName(name)
+			}
+			return configHosts[name]
+		}),
+		WithDelay(time.Millisecond*10, time.Millisecond*50),
+	)
+	tt := []struct {
+		host   string
+		expErr bool
+	}{
+		{host: "static.example.invalid"},
+		{host: "static-ipv6.example.invalid", expErr: true},
+		{host: "dns.example.invalid"},
+		{host: "family.example.invalid", expErr: true},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.host, func(t *testing.T) {
+			t.Parallel()
+			ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
+			defer cancel()
+			resp, err := hc.Do(ctxTimeout, &Req{
+				Host: tc.host,
+				APIs: map[string]ReqAPI{
+					"": {Method: "GET", Path: ""},
+				},
+			})
+			if tc.expErr {
+				if err == nil {
+					_ = resp.Close()
+					t.Errorf("request did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			_ = resp.Close()
+		})
+	}
+}
+
+func TestAPIOperation(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		api    ReqAPI
+		expect string
+	}{
+		{api: ReqAPI{Method: "GET"}, expect: "ping"},
+		{api: ReqAPI{Method: "HEAD", Repository: "project", Path: "manifests/latest"}, expect: "manifest-head"},
+		{api: ReqAPI{Method: "PUT", Repository: "project", Path: "manifests/latest"}, expect: "manifest-put"},
+		{api: ReqAPI{Method: "GET", Repository: "project", Path: "blobs/sha256:1234"}, expect: "blob-get"},
+		{api: ReqAPI{Method: "POST", Repository: "project", Path: "blobs/uploads/"}, expect: "blob-upload"},
+		{api: ReqAPI{Method: "PATCH", Repository: "project", Path: "blobs/uploads/1234"}, expect: "blob-upload"},
+		{api: ReqAPI{Method: "GET", Repository: "project", Path: "tags/list"}, expect: "tag-list"},
+		{api: ReqAPI{Method: "GET", Repository: "project", Path: "referrers/sha256:1234"}, expect: "referrer-list"},
+		{api: ReqAPI{Method: "GET", Path: "_catalog"}, expect: "repo-list"},
+		{api: ReqAPI{Method: "DELETE", Path: "repositories/project/tags/latest/"}, expect: "delete"},
+	}
+	for _, tc := range tt {
+		result := apiOperation(tc.api)
+		if result != tc.expect {
+			t.Errorf("unexpected operation for %s %s, expected %s, received %s", tc.api.Method, tc.api.Path, tc.expect, result)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package reghttp
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// defaultMirrorBackoff is the time an unhealthy mirror is skipped when the host config does not set MirrorBackoff.
+var defaultMirrorBackoff = time.Minute
+
+// HostHealth is the health of a registry or mirror tracked by the client.
+type HostHealth struct {
+	Name           string    // Name of the host config.
+	Mirror         bool      // Mirror is true when the host is a mirror of the requested registry.
+	Priority       uint      // Priority used to sort mirrors, highest first.
+	Weight         uint      // Weight used to select between mirrors with the same priority.
+	Healthy        bool      // Healthy is false while a failed mirror is skipped.
+	Failures       int       // Failures is the count of consecutive failed requests.
+	LastError      string    // LastError is the most recent failure.
+	LastFailure    time.Time // LastFailure is the time of the most recent failure.
+	LastSuccess    time.Time // LastSuccess is the time of the most recent successful request.
+	UnhealthyUntil time.Time // UnhealthyUntil is when a failed mirror will be attempted again.
+	BackoffUntil   time.Time // BackoffUntil is when the next request to the host may be sent after a retryable error.
+}
+
+// HostHealth returns the health of a registry followed by each of the mirrors.
+func (c *Client) HostHealth(host string) []HostHealth {
+	reqHost := c.getHost(host)
+	hosts := []*clientHost{reqHost}
+	for _, m := range reqHost.config.Mirrors {
+		hosts = append(hosts, c.getHost(m))
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]HostHealth, 0, len(hosts))
+	for i, h := range hosts {
+		hh := HostHealth{
+			Name:           h.config.Name,
+			Mirror:         i > 0,
+			Priority:       h.config.Priority,
+			Weight:         hostWeight(h),
+			Healthy:        !now.Before(h.unhealthyUntil),
+			Failures:       h.failures,
+			LastFailure:    h.lastFailure,
+			LastSuccess:    h.lastSuccess,
+			UnhealthyUntil: h.unhealthyUntil,
+			BackoffUntil:   h.backoffUntil,
+		}
+		if h.lastErr != nil {
+			hh.LastError = h.lastErr.Error()
+		}
+		ret = append(ret, hh)
+	}
+	return ret
+}
+
+// hostHealthy returns false when a mirror is skipped after a failure.
+func (c *Client) hostHealthy(h *clientHost) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !time.Now().Before(h.unhealthyUntil)
+}
+
+// hostSuccess records a successful request to the host.
+func (c *Client) hostSuccess(h *clientHost) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h.failures = 0
+	h.unhealthyUntil = time.Time{}
+	h.lastSuccess = time.Now()
+}
+
+// hostFailure records a failed request, a mirror is skipped until the backoff period expires.
+func (c *Client) hostFailure(h *clientHost, err error, mirror bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h.failures++
+	h.lastErr = err
+	h.lastFailure = time.Now()
+	if mirror {
+		backoff := defaultMirrorBackoff
+		if h.config.MirrorBackoff > 0 {
+			backoff = time.Duration(h.config.MirrorBackoff)
+		}
+		h.unhealthyUntil = h.lastFailure.Add(backoff)
+	}
+}
+
+// hostWeight returns the configured weight, defaulting to 1.
+func hostWeight(h *clientHost) uint {
+	if h.config.Weight == 0 {
+		return 1
+	}
+	return h.config.Weight
+}
+
+// sortHosts orders the registry and mirrors for a request.
+// Hosts in a backoff are sorted last, followed by priority (highest first), the upstream after mirrors with the same priority,
+// and a weighted random order between the remaining mirrors.
+func (c *Client) sortHosts(hosts []*clientHost, upstream string) []*clientHost {
+	type hostKey struct {
+		h   *clientHost
+		key float64
+	}
+	now := time.Now()
+	keys := make([]hostKey, len(hosts))
+	c.mu.Lock()
+	for i, h := range hosts {
+		// weighted random sampling, larger weights tend toward a key closer to 1
+		keys[i] = hostKey{h: h, key: math.Pow(c.rand.Float64(), 1/float64(hostWeight(h)))}
+	}
+	c.mu.Unlock()
+	sort.SliceStable(keys, func(i, j int) bool {
+		hi, hj := keys[i].h, keys[j].h
+		if now.Before(hi.backoffUntil) || now.Before(hj.backoffUntil) {
+			return hi.backoffUntil.Before(hj.backoffUntil)
+		}
+		if hi.config.Priority != hj.config.Priority {
+			return hi.config.Priority > hj.config.Priority
+		}
+		if (hi.config.Name == upstream) != (hj.config.Name == upstream) {
+			return hj.config.Name == upstream
+		}
+		return keys[i].key > keys[j].key
+	})
+	for i := range keys {
+		hosts[i] = keys[i].h
+	}
+	return hosts
+}
@@ -0,0 +1,119 @@
+package reghttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/regclient/regclient/config"
+)
+
+// proxyPolicy returns a Proxy function for the transport using the host settings.
+// Requests to a host in the NoProxy list connect directly, and a scheme without a proxy setting uses the fallback,
+// which is typically [http.ProxyFromEnvironment].
+// A nil function is returned when the host does not configure a proxy.
+func proxyPolicy(h *config.Host, fallback func(*http.Request) (*url.URL, error)) (func(*http.Request) (*url.URL, error), error) {
+	if h.HTTPProxy == "" && h.HTTPSProxy == "" && len(h.NoProxy) == 0 {
+		return nil, nil
+	}
+	httpProxy, err := proxyParse(h.HTTPProxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid httpProxy for host %s: %w", h.Name, err)
+	}
+	httpsProxy, err := proxyParse(h.HTTPSProxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid httpsProxy for host %s: %w", h.Name, err)
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if proxyHostMatch(req.URL, h.NoProxy) {
+			return nil, nil
+		}
+		switch {
+		case req.URL.Scheme == "https" && httpsProxy != nil:
+			return httpsProxy, nil
+		case req.URL.Scheme == "http" && httpProxy != nil:
+			return httpProxy, nil
+		case fallback != nil:
+			return fallback(req)
+		default:
+			return nil, nil
+		}
+	}, nil
+}
+
+// proxyParse parses and validates a proxy url, returning nil for an empty string.
+func proxyParse(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme \"%s\"", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy host is missing from \"%s\"", proxy)
+	}
+	return u, nil
+}
+
+// proxyHostMatch returns true when the url matches an entry in the no proxy list.
+// Entries are "*" to match all hosts, an IP or CIDR, a domain that also matches any subdomain,
+// or a "." or "*." prefixed domain that only matches subdomains.
+// An entry may include a port to only match requests to that port.
+func proxyHostMatch(u *url.URL, list []string) bool {
+	hostname := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	ip := net.ParseIP(hostname)
+	for _, entry := range list {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if eHost, ePort, err := net.SplitHostPort(entry); err == nil {
+			if ePort != port {
+				continue
+			}
+			entry = eHost
+		}
+		if entryIP := net.ParseIP(strings.Trim(entry, "[]")); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(entry, "*."):
+			if strings.HasSuffix(hostname, entry[1:]) {
+				return true
+			}
+		case strings.HasPrefix(entry, "."):
+			if strings.HasSuffix(hostname, entry) {
+				return true
+			}
+		case hostname == entry || strings.HasSuffix(hostname, "."+entry):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package reghttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/types"
+)
+
+// defaultRedirectMax matches the limit of the net/http client.
+const defaultRedirectMax = 10
+
+// redirectPolicy returns a CheckRedirect function for the host settings.
+// Registries often redirect blob requests to object storage, and the host settings control which hosts
+// are followed and whether the Authorization header is sent to them.
+// A nil function is returned when the host uses the default policy.
+func redirectPolicy(h *config.Host) func(req *http.Request, via []*http.Request) error {
+	if h.RedirectAuth == config.RedirectAuthDefault && h.RedirectMax == 0 && len(h.RedirectAllow) == 0 && len(h.RedirectDeny) == 0 {
+		return nil
+	}
+	max := h.RedirectMax
+	if max == 0 {
+		max = defaultRedirectMax
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if max < 0 {
+			return fmt.Errorf("redirects disabled for host %s, redirect to %s%.0w", h.Name, req.URL.Host, types.ErrRedirectDenied)
+		}
+		if len(via) >= max {
+			return fmt.Errorf("stopped after %d redirects for host %s%.0w", max, h.Name, types.ErrRedirectDenied)
+		}
+		hostname := req.URL.Hostname()
+		if redirectHostMatch(hostname, h.RedirectDeny) {
+			return fmt.Errorf("redirect to %s is denied for host %s%.0w", hostname, h.Name, types.ErrRedirectDenied)
+		}
+		if len(h.RedirectAllow) > 0 && !redirectHostMatch(hostname, h.RedirectAllow) {
+			return fmt.Errorf("redirect to %s is not allowed for host %s%.0w", hostname, h.Name, types.ErrRedirectDenied)
+		}
+		// the net/http client has already copied or stripped headers from the original request
+		orig := via[0]
+		if req.URL.Host != orig.URL.Host {
+			switch h.RedirectAuth {
+			case config.RedirectAuthStrip:
+				req.Header.Del("Authorization")
+			case config.RedirectAuthKeep:
+				if auth := orig.Header.Values("Authorization"); len(auth) > 0 && len(req.Header.Values("Authorization")) == 0 {
+					req.Header["Authorization"] = auth
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// redirectSameHost limits redirects to the host of the original request.
+func redirectSameHost(req *http.Request, via []*http.Request) error {
+	if len(via) >= defaultRedirectMax {
+		return fmt.Errorf("stopped after %d redirects%.0w", defaultRedirectMax, types.ErrRedirectDenied)
+	}
+	if req.URL.Host != via[0].URL.Host {
+		return fmt.Errorf("redirect from %s to %s is not allowed%.0w", via[0].URL.Host, req.URL.Host, types.ErrRedirectDenied)
+	}
+	return nil
+}
+
+// redirectHostMatch returns true when the hostname matches an entry in the list.
+// Entries are either a hostname or a "*." prefixed domain that matches any subdomain.
+func redirectHostMatch(hostname string, list []string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, entry := range list {
+		entry = strings.ToLower(entry)
+		if strings.HasPrefix(entry, "*.") {
+			if strings.HasSuffix(hostname, entry[1:]) {
+				return true
+			}
+		} else if hostname == entry {
+			return true
+		}
+	}
+	return false
+}
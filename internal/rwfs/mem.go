@@ -433,7 +433,7 @@ func (mfp *MemFileFP) Seek(offset int64, whence int) (int64, error) {
 	defer mfp.f.mu.Unlock()
 	switch whence {
 	case io.SeekStart:
-		mfp.cur = whence
+		mfp.cur = int(offset)
 	case io.SeekEnd:
 		mfp.cur = int(int64(len(mfp.f.b)) + offset)
 	case io.SeekCurrent:
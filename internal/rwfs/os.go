@@ -129,6 +129,19 @@ func (o *OSFS) Remove(name string) error {
 	return os.Remove(full)
 }
 
+// Link creates newName as a hard link to the oldName file
+func (o *OSFS) Link(oldName, newName string) error {
+	oldFile, err := o.join("link", oldName)
+	if err != nil {
+		return err
+	}
+	newFile, err := o.join("link", newName)
+	if err != nil {
+		return err
+	}
+	return os.Link(oldFile, newFile)
+}
+
 // Rename moves a file or directory to a new name
 func (o *OSFS) Rename(oldName, newName string) error {
 	oldFile, err := o.join("rename", oldName)
@@ -41,6 +41,12 @@ type RWPerms interface {
 	Chown(filename string, uid, gid int) error
 }
 
+// LinkFS is implemented by filesystems that support hard links
+type LinkFS interface {
+	// Link creates newName as a hard link to the oldName file
+	Link(oldName, newName string) error
+}
+
 // WriteFS is an interface for a writable filesystem
 type WriteFS interface {
 	// Create creates a new file
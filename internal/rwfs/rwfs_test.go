@@ -217,6 +217,22 @@ func testRWFS(t *testing.T, rwfs RWFS) {
 		if !bytes.Equal(exSubTxt1, b) {
 			t.Errorf("contents mismatch %s, expected %s, received %s", exSubFile1, string(exSubTxt1), string(b))
 		}
+		// verify seek from the start
+		if seeker, ok := fh.(io.Seeker); ok {
+			_, err = seeker.Seek(6, io.SeekStart)
+			if err != nil {
+				t.Errorf("seek %s: %v", exSubFile1, err)
+				return
+			}
+			b, err = io.ReadAll(fh)
+			if err != nil {
+				t.Errorf("readall %s: %v", exSubFile1, err)
+				return
+			}
+			if !bytes.Equal(exSubTxt1[6:], b) {
+				t.Errorf("contents mismatch after seek %s, expected %s, received %s", exSubFile1, string(exSubTxt1[6:]), string(b))
+			}
+		}
 		err = fh.Close()
 		if err != nil {
 			t.Errorf("close %s: %v", exSubFile1, err)
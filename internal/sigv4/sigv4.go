@@ -0,0 +1,148 @@
+// Package sigv4 signs requests with the AWS Signature Version 4 algorithm.
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Algorithm is the prefix of the Authorization header.
+	Algorithm  = "AWS4-HMAC-SHA256"
+	dateFormat = "20060102"
+	timeFormat = "20060102T150405Z"
+	hdrDate    = "X-Amz-Date"
+	hdrToken   = "X-Amz-Security-Token"
+)
+
+// Creds are the access keys used to sign requests.
+// Requests are sent anonymously when the AccessKey is empty.
+type Creds struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// Sign adds the date, session token, and Authorization headers to a request.
+// The payload hash is the hex encoded sha256 of the request body.
+// Every header already set on the request is included in the signature.
+func Sign(req *http.Request, creds Creds, region, service, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set(hdrDate, now.Format(timeFormat))
+	if creds.SessionToken != "" {
+		req.Header.Set(hdrToken, creds.SessionToken)
+	}
+	if creds.AccessKey == "" {
+		return
+	}
+
+	// canonical headers include the host and every header set on the request
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.Join(trimAll(v), ",")
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	canonHeaders := strings.Builder{}
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonReq := strings.Join([]string{
+		req.Method,
+		EscapePath(req.URL.Path),
+		Query(req.URL.Query()),
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{now.Format(dateFormat), region, service, "aws4_request"}, "/")
+	toSign := strings.Join([]string{
+		Algorithm,
+		now.Format(timeFormat),
+		scope,
+		Hash([]byte(canonReq)),
+	}, "\n")
+
+	key := hmacSum([]byte("AWS4"+creds.SecretKey), now.Format(dateFormat))
+	key = hmacSum(key, region)
+	key = hmacSum(key, service)
+	key = hmacSum(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSum(key, toSign))
+
+	req.Header.Set("Authorization", Algorithm+" Credential="+creds.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// Hash returns the hex encoded sha256 of the content.
+func Hash(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSum(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escape percent encodes everything other than unreserved characters.
+func escape(s string, keepSlash bool) string {
+	b := strings.Builder{}
+	for _, c := range []byte(s) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~' || (keepSlash && c == '/') {
+			b.WriteByte(c)
+		} else {
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+// EscapePath returns the canonical encoding of a path.
+func EscapePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return escape(p, true)
+}
+
+// Query returns the canonical query string, sorted by key and value.
+// The same encoding should be used when sending the request.
+func Query(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := []string{}
+	for _, k := range keys {
+		vals := append([]string{}, q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, escape(k, false)+"="+escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func trimAll(vals []string) []string {
+	ret := make([]string, len(vals))
+	for i, v := range vals {
+		ret[i] = strings.Join(strings.Fields(v), " ")
+	}
+	return ret
+}
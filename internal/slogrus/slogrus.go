@@ -0,0 +1,125 @@
+//go:build go1.21
+
+// Package slogrus sends logrus entries to a log/slog handler.
+// This allows packages that log with logrus to be configured with a [slog.Logger].
+package slogrus
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/types/ref"
+)
+
+// LevelTrace is the slog level used for logrus trace messages.
+const LevelTrace = slog.LevelDebug - 4
+
+// New returns a logrus logger that sends every entry to the handler of the slog logger.
+// The logrus level is set to the lowest level enabled in the handler.
+func New(logger *slog.Logger) *logrus.Logger {
+	h := logger.Handler()
+	log := &logrus.Logger{
+		Out:       io.Discard,
+		Formatter: discardFormatter{},
+		Hooks:     logrus.LevelHooks{},
+		Level:     logrus.PanicLevel,
+	}
+	// find the lowest level enabled in the handler to skip creating entries that would be discarded
+	for _, l := range []logrus.Level{logrus.TraceLevel, logrus.DebugLevel, logrus.InfoLevel, logrus.WarnLevel, logrus.ErrorLevel} {
+		if h.Enabled(context.Background(), slogLevel(l)) {
+			log.Level = l
+			break
+		}
+	}
+	log.AddHook(&hook{handler: h})
+	return log
+}
+
+type hook struct {
+	handler slog.Handler
+}
+
+// Levels returns all levels, filtering is done by the logger and handler.
+func (h *hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire converts the logrus entry to a slog record.
+func (h *hook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := slogLevel(entry.Level)
+	if !h.handler.Enabled(ctx, level) {
+		return nil
+	}
+	rec := slog.NewRecord(entry.Time, level, entry.Message, 0)
+	// sort keys since logrus fields are an unordered map
+	keys := make([]string, 0, len(entry.Data))
+	seen := map[string]bool{}
+	for k := range entry.Data {
+		keys = append(keys, k)
+		seen[k] = true
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		rec.AddAttrs(attrs(k, entry.Data[k], seen)...)
+	}
+	return h.handler.Handle(ctx, rec)
+}
+
+// attrs converts a logrus field to slog attributes.
+// A reference is expanded to include the host, repository, and digest as separate fields,
+// unless a field with that name has already been seen.
+func attrs(k string, v interface{}, seen map[string]bool) []slog.Attr {
+	switch val := v.(type) {
+	case ref.Ref:
+		a := []slog.Attr{slog.String(k, val.CommonName())}
+		for _, f := range []struct{ key, val string }{
+			{key: "host", val: val.Registry},
+			{key: "repo", val: val.Repository},
+			{key: "digest", val: val.Digest},
+		} {
+			if !seen[f.key] && f.val != "" {
+				a = append(a, slog.String(f.key, f.val))
+				seen[f.key] = true
+			}
+		}
+		return a
+	case error:
+		return []slog.Attr{slog.String(k, val.Error())}
+	case fmt.Stringer:
+		return []slog.Attr{slog.String(k, val.String())}
+	default:
+		return []slog.Attr{slog.Any(k, v)}
+	}
+}
+
+// slogLevel maps logrus levels to slog levels.
+func slogLevel(l logrus.Level) slog.Level {
+	switch l {
+	case logrus.TraceLevel:
+		return LevelTrace
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// discardFormatter skips formatting entries that are only sent to the hook.
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,80 @@
+//go:build go1.21
+
+package slogrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestSlogrus(t *testing.T) {
+	t.Parallel()
+	buf := &bytes.Buffer{}
+	log := New(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	if log.Level != logrus.InfoLevel {
+		t.Errorf("unexpected level, expected %s, received %s", logrus.InfoLevel, log.Level)
+	}
+	r, err := ref.New("registry.example.com/project/repo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	log.Debug("debug message")
+	log.WithFields(logrus.Fields{
+		"ref":       r,
+		"err":       errors.New("test error"),
+		"count":     3,
+		"operation": "manifest-get",
+	}).Warn("warn message")
+	if buf.Len() == 0 {
+		t.Fatalf("no output")
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("failed to parse output, expected a single json entry: %v\n%s", err, buf.String())
+	}
+	expect := map[string]interface{}{
+		"level":     "WARN",
+		"msg":       "warn message",
+		"ref":       "registry.example.com/project/repo:v1",
+		"host":      "registry.example.com",
+		"repo":      "project/repo",
+		"err":       "test error",
+		"count":     float64(3),
+		"operation": "manifest-get",
+	}
+	for k, v := range expect {
+		if out[k] != v {
+			t.Errorf("unexpected value for %s, expected %v, received %v", k, v, out[k])
+		}
+	}
+	if _, ok := out["digest"]; ok {
+		t.Errorf("digest field included without a digest in the ref")
+	}
+}
+
+func TestSlogrusLevel(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		level  slog.Level
+		expect logrus.Level
+	}{
+		{level: LevelTrace, expect: logrus.TraceLevel},
+		{level: slog.LevelDebug, expect: logrus.DebugLevel},
+		{level: slog.LevelInfo, expect: logrus.InfoLevel},
+		{level: slog.LevelWarn, expect: logrus.WarnLevel},
+		{level: slog.LevelError, expect: logrus.ErrorLevel},
+	}
+	for _, tc := range tt {
+		log := New(slog.New(slog.NewTextHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: tc.level})))
+		if log.Level != tc.expect {
+			t.Errorf("unexpected level for %s, expected %s, received %s", tc.level, tc.expect, log.Level)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 var errInvalid = errors.New("invalid duration")
@@ -13,6 +15,11 @@ var errInvalid = errors.New("invalid duration")
 // Implementation taken from https://stackoverflow.com/questions/48050945/how-to-unmarshal-json-into-durations
 type Duration time.Duration
 
+// String formats the duration like time.Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
 // MarshalJSON converts a duration to json
 func (d Duration) MarshalJSON() ([]byte, error) {
 	return json.Marshal(time.Duration(d).String())
@@ -39,3 +46,30 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 		return errInvalid
 	}
 }
+
+// MarshalYAML converts a duration to yaml
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// UnmarshalYAML converts yaml to a duration
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case int:
+		*d = Duration(time.Duration(value))
+		return nil
+	case string:
+		timeDur, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration(timeDur)
+		return nil
+	default:
+		return errInvalid
+	}
+}
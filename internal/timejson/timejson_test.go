@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestMarshal(t *testing.T) {
@@ -106,3 +108,68 @@ func TestUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestUnmarshalYAML(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		str     string
+		expect  Duration
+		expFail bool
+	}{
+		{
+			name:   "unquoted string",
+			str:    `d: 1s`,
+			expect: Duration(time.Second),
+		},
+		{
+			name:   "quoted string",
+			str:    `d: "5m"`,
+			expect: Duration(time.Minute * 5),
+		},
+		{
+			name:   "int",
+			str:    fmt.Sprintf("d: %d", time.Second),
+			expect: Duration(time.Second),
+		},
+		{
+			name:    "bool",
+			str:     `d: true`,
+			expFail: true,
+		},
+		{
+			name:    "invalid duration",
+			str:     `d: 42 years`,
+			expFail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			v := struct {
+				D Duration `yaml:"d"`
+			}{}
+			err := yaml.Unmarshal([]byte(tt.str), &v)
+			if tt.expFail {
+				if err == nil {
+					t.Errorf("error not encountered")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed unmarshaling: %v", err)
+			}
+			if v.D != tt.expect {
+				t.Errorf("duration mismatch, expected %s, received %s", time.Duration(tt.expect).String(), time.Duration(v.D).String())
+			}
+			out, err := yaml.Marshal(v)
+			if err != nil {
+				t.Fatalf("failed marshaling: %v", err)
+			}
+			if string(out) != "d: "+time.Duration(tt.expect).String()+"\n" {
+				t.Errorf("unexpected yaml output: %s", out)
+			}
+		})
+	}
+}
@@ -2,8 +2,19 @@ package regclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 
+	"github.com/opencontainers/go-digest"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/internal/throttle"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/manifest"
@@ -11,9 +22,13 @@ import (
 )
 
 type manifestOpt struct {
-	d             types.Descriptor
-	schemeOpts    []scheme.ManifestOpts
-	requireDigest bool
+	d               types.Descriptor
+	schemeOpts      []scheme.ManifestOpts
+	recordPrevious  bool
+	referrerCleanup bool
+	requireDigest   bool
+	verifyChildren  bool
+	verifyBlobs     bool
 }
 
 // ManifestOpts define options for the Manifest* commands.
@@ -50,6 +65,26 @@ func WithManifestDesc(d types.Descriptor) ManifestOpts {
 	}
 }
 
+// WithManifestRecordPrevious records the digest previously referenced by a tag in ManifestPut.
+// When the tag exists and points to a different manifest, the previous digest is added to the
+// [types.AnnotationPreviousDigest] annotation of the new manifest, modifying the pushed digest.
+// Manifests that do not support annotations are pushed without the annotation.
+// See [RegClient.TagRollback] to restore the previous digest.
+func WithManifestRecordPrevious() ManifestOpts {
+	return func(opts *manifestOpt) {
+		opts.recordPrevious = true
+	}
+}
+
+// WithManifestReferrerCleanup deletes the referrers of the manifest in ManifestDelete.
+// Referrers are found with the referrers API or the fallback tag, and the referrers of each referrer are also deleted.
+// This avoids leaving orphaned signatures and SBOMs after deleting an image.
+func WithManifestReferrerCleanup() ManifestOpts {
+	return func(opts *manifestOpt) {
+		opts.referrerCleanup = true
+	}
+}
+
 // WithManifestRequireDigest falls back from a HEAD to a GET request when digest headers aren't received.
 func WithManifestRequireDigest() ManifestOpts {
 	return func(opts *manifestOpt) {
@@ -57,9 +92,28 @@ func WithManifestRequireDigest() ManifestOpts {
 	}
 }
 
+// WithManifestVerifyChildren verifies the child manifests of an index exist before ManifestPut.
+// An error wrapping [types.MissingContentError] lists any missing manifests.
+func WithManifestVerifyChildren() ManifestOpts {
+	return func(opts *manifestOpt) {
+		opts.verifyChildren = true
+	}
+}
+
+// WithManifestVerifyBlobs verifies the child manifests and blobs exist before ManifestPut.
+// For an index, the blobs of each child manifest are checked.
+// An error wrapping [types.MissingContentError] lists any missing content.
+func WithManifestVerifyBlobs() ManifestOpts {
+	return func(opts *manifestOpt) {
+		opts.verifyChildren = true
+		opts.verifyBlobs = true
+	}
+}
+
 // ManifestDelete removes a manifest, including all tags pointing to that registry.
 // The reference must include the digest to delete (see TagDelete for deleting a tag).
 // All tags pointing to the manifest will be deleted.
+// Use [WithManifestReferrerCleanup] to also delete the referrers of the manifest.
 func (rc *RegClient) ManifestDelete(ctx context.Context, r ref.Ref, opts ...ManifestOpts) error {
 	if !r.IsSet() {
 		return fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
@@ -72,14 +126,42 @@ func (rc *RegClient) ManifestDelete(ctx context.Context, r ref.Ref, opts ...Mani
 	if err != nil {
 		return err
 	}
+	if opt.referrerCleanup {
+		if r.Digest == "" {
+			return fmt.Errorf("referrer cleanup requires a digest: %s%.0w", r.CommonName(), types.ErrMissingDigest)
+		}
+		rl, err := rc.ReferrerList(ctx, r)
+		if err != nil {
+			return fmt.Errorf("failed to list referrers of %s: %w", r.CommonName(), err)
+		}
+		// delete each referrer before the subject, updating the fallback tag
+		for _, d := range rl.Descriptors {
+			rReferrer := r.SetDigest(d.Digest.String())
+			rc.log.WithFields(logrus.Fields{
+				"subject":  r.CommonName(),
+				"referrer": rReferrer.CommonName(),
+			}).Debug("Deleting referrer")
+			err = rc.ManifestDelete(ctx, rReferrer, WithManifestCheckReferrers(), WithManifestReferrerCleanup())
+			if err != nil {
+				return fmt.Errorf("failed to delete referrer %s: %w", rReferrer.CommonName(), err)
+			}
+		}
+	}
 	return schemeAPI.ManifestDelete(ctx, r, opt.schemeOpts...)
 }
 
 // ManifestGet retrieves a manifest.
-func (rc *RegClient) ManifestGet(ctx context.Context, r ref.Ref, opts ...ManifestOpts) (manifest.Manifest, error) {
+func (rc *RegClient) ManifestGet(ctx context.Context, r ref.Ref, opts ...ManifestOpts) (m manifest.Manifest, err error) {
 	if !r.IsSet() {
 		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
 	}
+	ctx, span := rc.traceStart(ctx, "ManifestGet", r)
+	defer func() {
+		if err == nil && m != nil {
+			span.SetAttributes(traceDesc(m.GetDescriptor())...)
+		}
+		span.End(err)
+	}()
 	opt := manifestOpt{schemeOpts: []scheme.ManifestOpts{}}
 	for _, fn := range opts {
 		fn(&opt)
@@ -139,5 +221,295 @@ func (rc *RegClient) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Mani
 	if err != nil {
 		return err
 	}
+	if opt.verifyChildren {
+		missing := []types.Descriptor{}
+		err = rc.manifestVerify(ctx, r, m, opt.verifyBlobs, map[digest.Digest]bool{}, &missing)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("failed to put manifest %s: %w", r.CommonName(), &types.MissingContentError{Descriptors: missing})
+		}
+	}
+	if opt.recordPrevious && r.Tag != "" && r.Digest == "" {
+		m, err = rc.manifestRecordPrevious(ctx, schemeAPI, r, m)
+		if err != nil {
+			return err
+		}
+	}
 	return schemeAPI.ManifestPut(ctx, r, m, opt.schemeOpts...)
 }
+
+// manifestVerify appends the descriptors of any content referenced by m that does not exist to missing.
+// Blobs are only checked when blobs is true, including the blobs of child manifests.
+func (rc *RegClient) manifestVerify(ctx context.Context, r ref.Ref, m manifest.Manifest, blobs bool, seen map[digest.Digest]bool, missing *[]types.Descriptor) error {
+	if mi, ok := m.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return err
+		}
+		for _, d := range dl {
+			if seen[d.Digest] {
+				continue
+			}
+			seen[d.Digest] = true
+			rChild := r.SetDigest(d.Digest.String())
+			_, err = rc.ManifestHead(ctx, rChild)
+			if err != nil {
+				if !manifestVerifyNotFound(err) {
+					return fmt.Errorf("failed to verify manifest %s: %w", d.Digest.String(), err)
+				}
+				*missing = append(*missing, d)
+				continue
+			}
+			if !blobs {
+				continue
+			}
+			mChild, err := rc.ManifestGet(ctx, rChild, WithManifestDesc(d))
+			if err != nil {
+				return fmt.Errorf("failed to get manifest %s: %w", d.Digest.String(), err)
+			}
+			err = rc.manifestVerify(ctx, r, mChild, blobs, seen, missing)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if mi, ok := m.(manifest.Imager); ok && blobs {
+		dl := []types.Descriptor{}
+		if d, err := mi.GetConfig(); err == nil {
+			dl = append(dl, d)
+		}
+		layers, err := mi.GetLayers()
+		if err != nil {
+			return err
+		}
+		dl = append(dl, layers...)
+		for _, d := range dl {
+			// external layers are not pushed to the registry
+			if seen[d.Digest] || len(d.URLs) > 0 {
+				continue
+			}
+			seen[d.Digest] = true
+			br, err := rc.BlobHead(ctx, r, d)
+			if err != nil {
+				if !manifestVerifyNotFound(err) {
+					return fmt.Errorf("failed to verify blob %s: %w", d.Digest.String(), err)
+				}
+				*missing = append(*missing, d)
+				continue
+			}
+			_ = br.Close()
+		}
+	}
+	return nil
+}
+
+func manifestVerifyNotFound(err error) bool {
+	return errors.Is(err, types.ErrNotFound) || errors.Is(err, fs.ErrNotExist)
+}
+
+// manifestRecordPrevious returns a copy of m with an annotation of the digest currently referenced by the tag in r.
+// The original manifest is returned when the tag does not exist or the manifest does not support annotations.
+func (rc *RegClient) manifestRecordPrevious(ctx context.Context, schemeAPI scheme.API, r ref.Ref, m manifest.Manifest) (manifest.Manifest, error) {
+	mPrev, err := schemeAPI.ManifestHead(ctx, r)
+	if err == nil && mPrev.GetDescriptor().Digest == "" {
+		mPrev, err = schemeAPI.ManifestGet(ctx, r)
+	}
+	if err != nil {
+		if !manifestVerifyNotFound(err) {
+			return m, fmt.Errorf("failed to get previous manifest %s: %w", r.CommonName(), err)
+		}
+		// the tag does not exist yet, there is nothing to record
+		rc.log.WithFields(logrus.Fields{
+			"ref": r.CommonName(),
+		}).Debug("previous manifest not found")
+		return m, nil
+	}
+	prevDig := mPrev.GetDescriptor().Digest
+	if prevDig == m.GetDescriptor().Digest {
+		return m, nil
+	}
+	if _, ok := m.(manifest.Annotator); !ok {
+		rc.log.WithFields(logrus.Fields{
+			"ref":       r.CommonName(),
+			"mediaType": m.GetDescriptor().MediaType,
+		}).Warn("manifest does not support annotations, previous digest not recorded")
+		return m, nil
+	}
+	// annotate a copy to avoid changing the caller's manifest and digest
+	raw, err := m.RawBody()
+	if err != nil {
+		return m, err
+	}
+	mCopy, err := manifest.New(manifest.WithRaw(raw), manifest.WithDesc(types.Descriptor{
+		MediaType: m.GetDescriptor().MediaType,
+	}))
+	if err != nil {
+		return m, fmt.Errorf("failed to copy manifest: %w", err)
+	}
+	err = mCopy.(manifest.Annotator).SetAnnotation(types.AnnotationPreviousDigest, prevDig.String())
+	if err != nil {
+		return m, fmt.Errorf("failed to set previous digest annotation: %w", err)
+	}
+	return mCopy, nil
+}
+
+// digestPrefixMin is the minimum number of hex characters in a digest prefix.
+const digestPrefixMin = 4
+
+var digestHexRE = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// ResolveDigestPrefix finds the manifest in a repository with a digest starting with prefix, similar to a short git commit hash.
+// The prefix may include the algorithm ("sha256:abcd"), and defaults to sha256.
+// Tagged manifests are searched, walking the entries of indexes and the referrers of every manifest found.
+// Untagged manifests are only found when they are referenced from one of these.
+// Requests run concurrently, limited by the concurrency setting of the registry host.
+// The returned ref has the full digest set.
+// An error wrapping [types.ErrDigestAmbiguous] is returned when more than one manifest matches.
+func (rc *RegClient) ResolveDigestPrefix(ctx context.Context, r ref.Ref, prefix string) (ref.Ref, error) {
+	if !r.IsSetRepo() {
+		return r, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	rRepo := r.SetTag("")
+	alg, hex, ok := strings.Cut(prefix, ":")
+	if !ok {
+		alg, hex = digest.SHA256.String(), prefix
+	}
+	hex = strings.ToLower(hex)
+	if len(hex) < digestPrefixMin || !digestHexRE.MatchString(hex) {
+		return r, fmt.Errorf("digest prefix must have at least %d hex characters: %s%.0w", digestPrefixMin, prefix, types.ErrParsingFailed)
+	}
+	prefix = alg + ":" + hex
+	// a complete digest only needs to be checked
+	if _, err := digest.Parse(prefix); err == nil {
+		rDig := rRepo.SetDigest(prefix)
+		_, err = rc.ManifestHead(ctx, rDig, WithManifestRequireDigest())
+		if err != nil {
+			return r, err
+		}
+		return rDig, nil
+	}
+
+	tl, err := rc.TagList(ctx, rRepo)
+	if err != nil {
+		return r, fmt.Errorf("failed to list tags: %w", err)
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return r, err
+	}
+	schemeAPI, err := rc.schemeGet(rRepo.Scheme)
+	if err != nil {
+		return r, err
+	}
+	tList := []*throttle.Throttle{}
+	if t, ok := schemeAPI.(scheme.Throttler); ok {
+		tList = t.Throttle(rRepo, false)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errOnce sync.Once
+	seen := map[string]bool{}
+	matches := []string{}
+	// walk runs each tag or digest in a goroutine, requests are limited by the throttle of the host
+	var walk func(tag string, d types.Descriptor)
+	walk = func(tag string, d types.Descriptor) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctxT, errT := throttle.AcquireMulti(ctx, tList)
+			if errT == nil {
+				var children []types.Descriptor
+				children, errT = rc.resolveDigestPrefixEntry(ctxT, rRepo, tag, d, func(dig string) bool {
+					mu.Lock()
+					defer mu.Unlock()
+					if seen[dig] {
+						return false
+					}
+					seen[dig] = true
+					if strings.HasPrefix(dig, prefix) {
+						matches = append(matches, dig)
+					}
+					return true
+				})
+				throttle.ReleaseMulti(ctxT, tList)
+				for _, child := range children {
+					walk("", child)
+				}
+			}
+			if errT != nil {
+				errOnce.Do(func() {
+					err = errT
+					cancel()
+				})
+			}
+		}()
+	}
+	for _, t := range tags {
+		walk(t, types.Descriptor{})
+	}
+	wg.Wait()
+	if err != nil {
+		return r, err
+	}
+	if ctx.Err() != nil {
+		return r, ctx.Err()
+	}
+	switch len(matches) {
+	case 0:
+		return r, fmt.Errorf("no manifest found matching %s in %s%.0w", prefix, rRepo.CommonName(), types.ErrNotFound)
+	case 1:
+		return rRepo.SetDigest(matches[0]), nil
+	default:
+		sort.Strings(matches)
+		return r, fmt.Errorf("digest prefix %s matches %s%.0w", prefix, strings.Join(matches, ", "), types.ErrDigestAmbiguous)
+	}
+}
+
+// resolveDigestPrefixEntry checks a tag or a digest of the repository, returning the index entries and referrers to walk.
+// The add function returns false for a digest that has already been seen.
+func (rc *RegClient) resolveDigestPrefixEntry(ctx context.Context, rRepo ref.Ref, tag string, d types.Descriptor, add func(string) bool) ([]types.Descriptor, error) {
+	if tag != "" {
+		rTag := rRepo.SetTag(tag)
+		mh, err := rc.ManifestHead(ctx, rTag, WithManifestRequireDigest())
+		if err != nil {
+			rc.log.WithFields(logrus.Fields{
+				"ref": rTag.CommonName(),
+				"err": err,
+			}).Debug("failed to head tag while resolving digest prefix")
+			return nil, nil
+		}
+		d = mh.GetDescriptor()
+	}
+	if !add(d.Digest.String()) {
+		return nil, nil
+	}
+	rDig := rRepo.SetDigest(d.Digest.String())
+	children := []types.Descriptor{}
+	switch d.MediaType {
+	case types.MediaTypeOCI1ManifestList, types.MediaTypeDocker2ManifestList:
+		m, err := rc.ManifestGet(ctx, rDig)
+		if err != nil {
+			return nil, err
+		}
+		if mi, ok := m.(manifest.Indexer); ok {
+			dl, err := mi.GetManifestList()
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, dl...)
+		}
+	}
+	rl, err := rc.ReferrerList(ctx, rDig)
+	if err != nil {
+		rc.log.WithFields(logrus.Fields{
+			"digest": d.Digest.String(),
+			"err":    err,
+		}).Debug("failed to list referrers while resolving digest prefix")
+		return children, nil
+	}
+	return append(children, rl.Descriptors...), nil
+}
@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,9 +19,11 @@ import (
 
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/internal/rwfs"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/docker/schema2"
 	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -394,3 +397,355 @@ func TestManifest(t *testing.T) {
 		}
 	})
 }
+
+func TestResolveDigestPrefix(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	mh, err := rc.ManifestHead(ctx, r.SetTag("v1"), WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head v1: %v", err)
+	}
+	dig := mh.GetDescriptor().Digest.String()
+	m, err := rc.ManifestGet(ctx, r.SetTag("v1"))
+	if err != nil {
+		t.Fatalf("failed to get v1: %v", err)
+	}
+	dl, err := m.(manifest.Indexer).GetManifestList()
+	if err != nil || len(dl) == 0 {
+		t.Fatalf("failed to get manifest list: %v", err)
+	}
+	digChild := dl[0].Digest.String()
+
+	tt := []struct {
+		name      string
+		prefix    string
+		expect    string
+		expectErr error
+	}{
+		{
+			name:   "tagged",
+			prefix: dig[:14],
+			expect: dig,
+		},
+		{
+			name:   "without algorithm",
+			prefix: dig[7:15],
+			expect: dig,
+		},
+		{
+			name:   "platform",
+			prefix: digChild[:12],
+			expect: digChild,
+		},
+		{
+			name:   "full digest",
+			prefix: dig,
+			expect: dig,
+		},
+		{
+			name:      "short",
+			prefix:    dig[:9],
+			expectErr: types.ErrParsingFailed,
+		},
+		{
+			name:      "invalid",
+			prefix:    "sha256:xyz123",
+			expectErr: types.ErrParsingFailed,
+		},
+		{
+			name:      "missing",
+			prefix:    "sha256:00000000",
+			expectErr: types.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rOut, err := rc.ResolveDigestPrefix(ctx, r, tc.prefix)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to resolve: %v", err)
+			}
+			if rOut.Digest != tc.expect || rOut.Tag != "" {
+				t.Errorf("unexpected ref, expected %s, received %s", tc.expect, rOut.CommonName())
+			}
+		})
+	}
+
+	t.Run("nested referrer", func(t *testing.T) {
+		// a referrer of a referrer of a platform entry is only found by walking each level
+		subject := dl[0]
+		var mRef manifest.Manifest
+		for i := 0; i < 2; i++ {
+			mRef, err = manifest.New(manifest.WithOrig(v1.Manifest{
+				Versioned:    v1.ManifestSchemaVersion,
+				MediaType:    types.MediaTypeOCI1Manifest,
+				ArtifactType: "application/example.nested",
+				Config:       types.Descriptor{MediaType: types.MediaTypeOCI1Empty, Digest: types.EmptyDigest, Size: int64(len(types.EmptyData))},
+				Layers:       []types.Descriptor{},
+				Subject:      &subject,
+				Annotations:  map[string]string{"level": fmt.Sprintf("%d", i)},
+			}))
+			if err != nil {
+				t.Fatalf("failed to create manifest: %v", err)
+			}
+			err = rc.ManifestPut(ctx, r.SetDigest(mRef.GetDescriptor().Digest.String()), mRef)
+			if err != nil {
+				t.Fatalf("failed to put referrer: %v", err)
+			}
+			subject = mRef.GetDescriptor()
+		}
+		digRef := mRef.GetDescriptor().Digest.String()
+		rOut, err := rc.ResolveDigestPrefix(ctx, r, digRef[:16])
+		if err != nil || rOut.Digest != digRef {
+			t.Errorf("failed to resolve nested referrer: %s, %v", rOut.CommonName(), err)
+		}
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		// push a manifest with a digest sharing the first 4 hex characters
+		for i := 0; ; i++ {
+			m, err = manifest.New(manifest.WithOrig(v1.Manifest{
+				Versioned:   v1.ManifestSchemaVersion,
+				MediaType:   types.MediaTypeOCI1Manifest,
+				Config:      types.Descriptor{MediaType: types.MediaTypeOCI1Empty, Digest: types.EmptyDigest, Size: int64(len(types.EmptyData))},
+				Layers:      []types.Descriptor{},
+				Annotations: map[string]string{"count": fmt.Sprintf("%d", i)},
+			}))
+			if err != nil {
+				t.Fatalf("failed to create manifest: %v", err)
+			}
+			if strings.HasPrefix(m.GetDescriptor().Digest.String(), dig[:11]) {
+				break
+			}
+		}
+		err = rc.ManifestPut(ctx, r.SetTag("ambiguous"), m)
+		if err != nil {
+			t.Fatalf("failed to put manifest: %v", err)
+		}
+		_, err = rc.ResolveDigestPrefix(ctx, r, dig[:11])
+		if !errors.Is(err, types.ErrDigestAmbiguous) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		rOut, err := rc.ResolveDigestPrefix(ctx, r, dig[:14])
+		if err != nil || rOut.Digest != dig {
+			t.Errorf("failed to resolve longer prefix: %s, %v", rOut.CommonName(), err)
+		}
+	})
+}
+
+func TestManifestPutVerify(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		t.Fatalf("failed to get v1: %v", err)
+	}
+	idx, ok := m.GetOrig().(v1.Index)
+	if !ok {
+		t.Fatalf("unexpected manifest type %T", m.GetOrig())
+	}
+	mChild, err := rc.ManifestGet(ctx, r, WithManifestDesc(idx.Manifests[0]))
+	if err != nil {
+		t.Fatalf("failed to get child: %v", err)
+	}
+	img, ok := mChild.GetOrig().(v1.Manifest)
+	if !ok {
+		t.Fatalf("unexpected manifest type %T", mChild.GetOrig())
+	}
+	missingDesc := types.Descriptor{
+		MediaType: types.MediaTypeOCI1Manifest,
+		Digest:    digest.FromString("missing manifest"),
+		Size:      16,
+	}
+	// duplicate children are only reported once
+	digChildren := []digest.Digest{}
+	seen := map[digest.Digest]bool{}
+	for _, d := range idx.Manifests {
+		if !seen[d.Digest] {
+			seen[d.Digest] = true
+			digChildren = append(digChildren, d.Digest)
+		}
+	}
+	idx.Manifests = append(idx.Manifests, missingDesc)
+	mIdxMissing, err := manifest.New(manifest.WithOrig(idx))
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+	missingLayer := types.Descriptor{
+		MediaType: types.MediaTypeOCI1LayerGzip,
+		Digest:    digest.FromString("missing layer"),
+		Size:      13,
+	}
+	img.Layers = append(img.Layers, missingLayer)
+	mImgMissing, err := manifest.New(manifest.WithOrig(img))
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+	rEmpty, err := ref.New("ocidir://testverify:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+
+	tt := []struct {
+		name          string
+		r             ref.Ref
+		m             manifest.Manifest
+		opts          []ManifestOpts
+		expectMissing []digest.Digest
+	}{
+		{
+			name: "index children",
+			r:    r.SetTag("verify"),
+			m:    m,
+			opts: []ManifestOpts{WithManifestVerifyChildren()},
+		},
+		{
+			name: "index blobs",
+			r:    r.SetTag("verify"),
+			m:    m,
+			opts: []ManifestOpts{WithManifestVerifyBlobs()},
+		},
+		{
+			name:          "index missing child",
+			r:             r.SetTag("verify"),
+			m:             mIdxMissing,
+			opts:          []ManifestOpts{WithManifestVerifyChildren()},
+			expectMissing: []digest.Digest{missingDesc.Digest},
+		},
+		{
+			name:          "index in empty repo",
+			r:             rEmpty,
+			m:             m,
+			opts:          []ManifestOpts{WithManifestVerifyChildren()},
+			expectMissing: digChildren,
+		},
+		{
+			name: "image children only",
+			r:    r.SetTag("verify"),
+			m:    mImgMissing,
+			opts: []ManifestOpts{WithManifestVerifyChildren()},
+		},
+		{
+			name:          "image missing layer",
+			r:             r.SetTag("verify"),
+			m:             mImgMissing,
+			opts:          []ManifestOpts{WithManifestVerifyBlobs()},
+			expectMissing: []digest.Digest{missingLayer.Digest},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := rc.ManifestPut(ctx, tc.r, tc.m, tc.opts...)
+			if len(tc.expectMissing) == 0 {
+				if err != nil {
+					t.Errorf("failed to put manifest: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, types.ErrMissingContent) {
+				t.Fatalf("unexpected error, expected %v, received %v", types.ErrMissingContent, err)
+			}
+			var missingErr *types.MissingContentError
+			if !errors.As(err, &missingErr) {
+				t.Fatalf("error is not a MissingContentError: %v", err)
+			}
+			if len(missingErr.Descriptors) != len(tc.expectMissing) {
+				t.Fatalf("unexpected missing descriptors, expected %v, received %v", tc.expectMissing, missingErr.Descriptors)
+			}
+			for i, d := range tc.expectMissing {
+				if missingErr.Descriptors[i].Digest != d {
+					t.Errorf("unexpected missing descriptor %d, expected %s, received %s", i, d.String(), missingErr.Descriptors[i].Digest.String())
+				}
+			}
+		})
+	}
+}
+
+func TestManifestDeleteReferrers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://cleanup:v2")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	err = rc.ImageCopy(ctx, r, rTgt, ImageWithReferrers())
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	m, err := rc.ManifestHead(ctx, rTgt, WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head image: %v", err)
+	}
+	rTgt = rTgt.SetDigest(m.GetDescriptor().Digest.String())
+	rl, err := rc.ReferrerList(ctx, rTgt)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if len(rl.Descriptors) == 0 {
+		t.Fatalf("test image has no referrers")
+	}
+
+	t.Run("missing digest", func(t *testing.T) {
+		err := rc.ManifestDelete(ctx, rTgt.SetTag("v2"), WithManifestReferrerCleanup())
+		if !errors.Is(err, types.ErrMissingDigest) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrMissingDigest, err)
+		}
+	})
+	t.Run("cleanup", func(t *testing.T) {
+		err := rc.ManifestDelete(ctx, rTgt, WithManifestReferrerCleanup())
+		if err != nil {
+			t.Fatalf("failed to delete manifest: %v", err)
+		}
+		for _, d := range rl.Descriptors {
+			_, err = rc.ManifestHead(ctx, rTgt.SetDigest(d.Digest.String()))
+			if err == nil {
+				t.Errorf("referrer was not deleted: %s", d.Digest.String())
+			}
+		}
+		rlAfter, err := rc.ReferrerList(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if len(rlAfter.Descriptors) > 0 {
+			t.Errorf("referrers remain after delete: %v", rlAfter.Descriptors)
+		}
+	})
+}
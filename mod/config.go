@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/internal/history"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/platform"
@@ -82,8 +83,8 @@ func WithConfigTimestamp(optTime OptTime) Opts {
 			// offset startHistory by base layer count
 			if optTime.BaseLayers > 0 {
 				layersCount := 0
-				for i, history := range oc.History {
-					if !history.EmptyLayer {
+				for i, h := range oc.History {
+					if !h.EmptyLayer {
 						layersCount++
 					}
 					if layersCount == optTime.BaseLayers {
@@ -129,13 +130,8 @@ func WithConfigTimestamp(optTime OptTime) Opts {
 					return fmt.Errorf("failed to get base image config: %w", err)
 				}
 				// exclude matching history lines from base image
-				for i, history := range baseConfig.GetConfig().History {
-					if len(oc.History) <= i ||
-						oc.History[i].Author != history.Author ||
-						oc.History[i].Comment != history.Comment ||
-						!oc.History[i].Created.Equal(*history.Created) ||
-						oc.History[i].CreatedBy != history.CreatedBy ||
-						oc.History[i].EmptyLayer != history.EmptyLayer {
+				for i, h := range baseConfig.GetConfig().History {
+					if len(oc.History) <= i || !history.Equal(oc.History[i], h) {
 						break
 					}
 					startHistory = i + 1
@@ -147,6 +143,10 @@ func WithConfigTimestamp(optTime OptTime) Opts {
 				*oc.Created, changed = timeModOpt(*oc.Created, optTime)
 			}
 			for i := startHistory; i < len(oc.History); i++ {
+				if oc.History[i].Created == nil {
+					// legacy builders may not include a timestamp
+					continue
+				}
 				*oc.History[i].Created, cCur = timeModOpt(*oc.History[i].Created, optTime)
 				changed = changed || cCur
 			}
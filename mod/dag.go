@@ -15,6 +15,7 @@ import (
 	"github.com/regclient/regclient/types/blob"
 	"github.com/regclient/regclient/types/manifest"
 	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -32,7 +33,10 @@ type dagConfig struct {
 	stepsOCIConfig []func(context.Context, *regclient.RegClient, ref.Ref, ref.Ref, *dagOCIConfig) error
 	stepsLayerFile []func(context.Context, *regclient.RegClient, ref.Ref, ref.Ref, *dagLayer, *tar.Header, io.Reader) (*tar.Header, io.Reader, changes, error)
 	maxDataSize    int64
+	layerEStargz   []*platform.Matcher // convert layers to eStargz when rewritten, limited to the platform filter of each option
+	externalCopy   []*platform.Matcher // copy external layers into the target, limited to the platform filter of each option
 	rTgt           ref.Ref
+	platformFilter *platform.Matcher // restricts steps added by later options
 }
 
 type dagManifest struct {
@@ -58,6 +62,7 @@ type dagLayer struct {
 	newDesc  types.Descriptor
 	ucDigest digest.Digest // uncompressed descriptor
 	desc     types.Descriptor
+	platform *platform.Platform // platform from the image config, nil when unknown
 }
 
 func dagGet(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, d types.Descriptor) (*dagManifest, error) {
@@ -105,9 +110,15 @@ func dagGet(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, d types.
 		if err != nil {
 			return nil, err
 		}
+		var p *platform.Platform
+		if dm.config != nil {
+			pc := dm.config.oc.GetConfig().Platform
+			p = &pc
+		}
 		for _, layer := range layers {
 			dl := dagLayer{
-				desc: layer,
+				desc:     layer,
+				platform: p,
 			}
 			dm.layers = append(dm.layers, &dl)
 		}
@@ -135,6 +146,7 @@ func dagGet(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, d types.
 
 func dagPut(ctx context.Context, rc *regclient.RegClient, mc dagConfig, rSrc, rTgt ref.Ref, dm *dagManifest) error {
 	var err error
+	alg := rc.DigestAlgorithm(rTgt)
 	// recursively push children to get new digests to include in the modified manifest
 	om := dm.m.GetOrig()
 	changed := false
@@ -324,6 +336,18 @@ func dagPut(ctx context.Context, rc *regclient.RegClient, mc dagConfig, rSrc, rT
 			dm.config.oc.SetConfig(oc)
 			dm.config.modified = true
 		}
+		// compute the digest of a modified config with the algorithm for the target
+		if dm.config != nil && dm.config.modified && dm.config.oc.GetDescriptor().Digest.Algorithm() != alg {
+			cBytes, err := dm.config.oc.RawBody()
+			if err != nil {
+				return err
+			}
+			dm.config.oc = blob.NewOCIConfig(
+				blob.WithRawBody(cBytes),
+				blob.WithDesc(types.Descriptor{MediaType: dm.config.oc.GetDescriptor().MediaType}),
+				blob.WithDigestAlgorithm(alg),
+			)
+		}
 		if dm.config != nil {
 			dm.config.newDesc = dm.config.oc.GetDescriptor()
 			cBytes, err := dm.config.oc.RawBody()
@@ -381,6 +405,17 @@ func dagPut(ctx context.Context, rc *regclient.RegClient, mc dagConfig, rSrc, rT
 	}
 	// update descriptor and update subject descriptor on all referrers
 	if dm.mod == replaced || dm.mod == added {
+		if dm.m.GetDescriptor().Digest.Algorithm() != alg {
+			// recompute the digest with the algorithm for the target
+			dm.m, err = manifest.New(
+				manifest.WithOrig(dm.m.GetOrig()),
+				manifest.WithRef(dm.m.GetRef()),
+				manifest.WithDigestAlgorithm(alg),
+			)
+			if err != nil {
+				return err
+			}
+		}
 		dm.newDesc = dm.m.GetDescriptor()
 	}
 	for i := range dm.referrers {
@@ -467,7 +502,8 @@ func dagWalkOCIConfig(dm *dagManifest, fn func(*dagOCIConfig) (*dagOCIConfig, er
 	return nil
 }
 
-func dagWalkLayers(dm *dagManifest, fn func(*dagLayer) (*dagLayer, error)) error {
+// dagWalkLayers calls fn with each layer and the manifest containing the layer.
+func dagWalkLayers(dm *dagManifest, fn func(*dagManifest, *dagLayer) (*dagLayer, error)) error {
 	var err error
 	if dm.manifests != nil {
 		for _, child := range dm.manifests {
@@ -482,7 +518,7 @@ func dagWalkLayers(dm *dagManifest, fn func(*dagLayer) (*dagLayer, error)) error
 			if layer.mod == deleted {
 				continue
 			}
-			mlNew, err := fn(layer)
+			mlNew, err := fn(dm, layer)
 			if err != nil {
 				return err
 			}
@@ -14,6 +14,7 @@ import (
 	"github.com/opencontainers/go-digest"
 
 	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/pkg/archive"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -42,6 +43,36 @@ func WithLayerReproducible() Opts {
 	}
 }
 
+// WithLayerTarOpts modifies the tar headers of files in the layer using the same options as [archive.Tar].
+// This supports [archive.TarWithModTime], [archive.TarStripXattrs], [archive.TarZeroOwner], and [archive.TarReproducible].
+// The order of the files in the layer is not changed.
+func WithLayerTarOpts(opts ...archive.TarOpts) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.stepsLayerFile = append(dc.stepsLayerFile,
+			func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dl *dagLayer, th *tar.Header, tr io.Reader) (*tar.Header, io.Reader, changes, error) {
+				if archive.TarHeader(th, opts...) {
+					return th, tr, replaced, nil
+				}
+				return th, tr, unchanged, nil
+			})
+		return nil
+	}
+}
+
+// WithLayerEStargz converts tar layers to eStargz, allowing individual files to be read with range requests.
+// Layers that are already eStargz are only rebuilt when changed by another option.
+func WithLayerEStargz() Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.layerEStargz = append(dc.layerEStargz, dc.platformFilter)
+		// an empty step forces each layer to be rewritten
+		dc.stepsLayerFile = append(dc.stepsLayerFile,
+			func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dl *dagLayer, th *tar.Header, tr io.Reader) (*tar.Header, io.Reader, changes, error) {
+				return th, tr, unchanged, nil
+			})
+		return nil
+	}
+}
+
 // WithLayerRmCreatedBy deletes a layer based on a regex of the created by field
 // in the config history for that layer.
 func WithLayerRmCreatedBy(re regexp.Regexp) Opts {
@@ -2,15 +2,23 @@ package mod
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 
 	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/internal/history"
+	"github.com/regclient/regclient/pkg/archive"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/docker/schema1"
 	"github.com/regclient/regclient/types/docker/schema2"
 	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -211,12 +219,17 @@ func WithLabelToAnnotation() Opts {
 }
 
 // WithManifestToDocker converts the manifest to Docker schema2 media types.
+// Docker schema1 manifests are converted with a config generated from the history, see [WithManifestToOCI].
 func WithManifestToDocker() Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
 		dc.stepsManifest = append(dc.stepsManifest, func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
 			if dm.mod == deleted {
 				return nil
 			}
+			err := manifestFromSchema1(c, rc, rSrc, dm, false)
+			if err != nil {
+				return err
+			}
 			changed := false
 			om := dm.m.GetOrig()
 			if dm.m.IsList() {
@@ -252,6 +265,9 @@ func WithManifestToDocker() Opts {
 					if l.MediaType == types.MediaTypeOCI1LayerGzip {
 						ociM.Layers[i].MediaType = types.MediaTypeDocker2LayerGzip
 						changed = true
+					} else if l.MediaType == types.MediaTypeOCI1Layer {
+						ociM.Layers[i].MediaType = types.MediaTypeDocker2Layer
+						changed = true
 					} else if l.MediaType == types.MediaTypeOCI1ForeignLayerGzip {
 						ociM.Layers[i].MediaType = types.MediaTypeDocker2ForeignLayer
 						changed = true
@@ -283,12 +299,19 @@ func WithManifestToDocker() Opts {
 }
 
 // WithManifestToOCI converts the manifest to OCI media types.
+// Docker schema1 manifests are converted with a config generated from the v1Compatibility history,
+// which pulls every layer to compute the uncompressed digest.
+// Run this before other options since they do not support schema1 manifests.
 func WithManifestToOCI() Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
 		dc.stepsManifest = append(dc.stepsManifest, func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
 			if dm.mod == deleted {
 				return nil
 			}
+			err := manifestFromSchema1(c, rc, rSrc, dm, true)
+			if err != nil {
+				return err
+			}
 			changed := false
 			om := dm.m.GetOrig()
 			if dm.m.IsList() {
@@ -316,6 +339,9 @@ func WithManifestToOCI() Opts {
 					if l.MediaType == types.MediaTypeDocker2LayerGzip {
 						ociM.Layers[i].MediaType = types.MediaTypeOCI1LayerGzip
 						changed = true
+					} else if l.MediaType == types.MediaTypeDocker2Layer {
+						ociM.Layers[i].MediaType = types.MediaTypeOCI1Layer
+						changed = true
 					} else if l.MediaType == types.MediaTypeDocker2ForeignLayer {
 						ociM.Layers[i].MediaType = types.MediaTypeOCI1ForeignLayerGzip
 						changed = true
@@ -341,6 +367,146 @@ func WithManifestToOCI() Opts {
 	}
 }
 
+// schema1V1Compat contains the fields of the schema1 v1Compatibility history used to generate the config history.
+type schema1V1Compat struct {
+	Created         *time.Time `json:"created,omitempty"`
+	Author          string     `json:"author,omitempty"`
+	Comment         string     `json:"comment,omitempty"`
+	ContainerConfig struct {
+		Cmd []string `json:"Cmd,omitempty"`
+	} `json:"container_config,omitempty"`
+	ThrowAway bool `json:"throwaway,omitempty"`
+}
+
+// schema1ConfigRm are the v1Compatibility fields that are not included in the generated config.
+var schema1ConfigRm = []string{"id", "parent", "parent_id", "layer_id", "Size", "throwaway"}
+
+// manifestFromSchema1 replaces a Docker schema1 manifest with an OCI or Docker schema2 image.
+// The config is generated from the newest v1Compatibility history entry, and layers are pulled for the diff ids.
+// Other manifests are not changed.
+func manifestFromSchema1(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, dm *dagManifest, oci bool) error {
+	var s1 schema1.Manifest
+	switch orig := dm.m.GetOrig().(type) {
+	case schema1.Manifest:
+		s1 = orig
+	case schema1.SignedManifest:
+		s1 = orig.Manifest
+	default:
+		return nil
+	}
+	if len(s1.History) == 0 || len(s1.History) != len(s1.FSLayers) {
+		return fmt.Errorf("schema1 manifest has %d history entries and %d layers, ref %s%.0w", len(s1.History), len(s1.FSLayers), rSrc.CommonName(), types.ErrParsingFailed)
+	}
+	mtConfig, mtLayer := types.MediaTypeDocker2ImageConfig, types.MediaTypeDocker2LayerGzip
+	if oci {
+		mtConfig, mtLayer = types.MediaTypeOCI1ImageConfig, types.MediaTypeOCI1LayerGzip
+	}
+	conf := map[string]json.RawMessage{}
+	err := json.Unmarshal([]byte(s1.History[0].V1Compatibility), &conf)
+	if err != nil {
+		return fmt.Errorf("failed to parse schema1 history: %w", err)
+	}
+	for _, k := range schema1ConfigRm {
+		delete(conf, k)
+	}
+	// schema1 lists the newest entry first
+	rootFS := v1.RootFS{Type: "layers", DiffIDs: []digest.Digest{}}
+	history := []v1.History{}
+	layers := []*dagLayer{}
+	for i := len(s1.History) - 1; i >= 0; i-- {
+		v1c := schema1V1Compat{}
+		err = json.Unmarshal([]byte(s1.History[i].V1Compatibility), &v1c)
+		if err != nil {
+			return fmt.Errorf("failed to parse schema1 history: %w", err)
+		}
+		history = append(history, v1.History{
+			Created:    v1c.Created,
+			CreatedBy:  strings.Join(v1c.ContainerConfig.Cmd, " "),
+			Author:     v1c.Author,
+			Comment:    v1c.Comment,
+			EmptyLayer: v1c.ThrowAway,
+		})
+		if v1c.ThrowAway {
+			continue
+		}
+		d, diffID, err := schema1Layer(ctx, rc, rSrc, s1.FSLayers[i].BlobSum)
+		if err != nil {
+			return err
+		}
+		d.MediaType = mtLayer
+		rootFS.DiffIDs = append(rootFS.DiffIDs, diffID)
+		layers = append(layers, &dagLayer{desc: d, ucDigest: diffID})
+	}
+	for k, v := range map[string]interface{}{"rootfs": rootFS, "history": history} {
+		conf[k], err = json.Marshal(v)
+		if err != nil {
+			return err
+		}
+	}
+	confRaw, err := json.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	oc := blob.NewOCIConfig(
+		blob.WithDesc(types.Descriptor{MediaType: mtConfig}),
+		blob.WithRawBody(confRaw),
+	)
+	p := oc.GetConfig().Platform
+	ociM := v1.Manifest{
+		Versioned: v1.ManifestSchemaVersion,
+		MediaType: types.MediaTypeOCI1Manifest,
+		Config:    oc.GetDescriptor(),
+		Layers:    make([]types.Descriptor, len(layers)),
+	}
+	for i, dl := range layers {
+		dl.platform = &p
+		ociM.Layers[i] = dl.desc
+	}
+	var om interface{} = ociM
+	if !oci {
+		dm := schema2.Manifest{}
+		err = manifest.OCIManifestToAny(ociM, &dm)
+		if err != nil {
+			return err
+		}
+		om = dm
+	}
+	newM, err := manifest.New(manifest.WithOrig(om))
+	if err != nil {
+		return err
+	}
+	dm.m = newM
+	dm.config = &dagOCIConfig{modified: true, oc: oc}
+	dm.layers = layers
+	dm.newDesc = dm.m.GetDescriptor()
+	dm.mod = replaced
+	return nil
+}
+
+// schema1Layer pulls a schema1 layer, returning the descriptor and the uncompressed digest.
+func schema1Layer(ctx context.Context, rc *regclient.RegClient, r ref.Ref, d digest.Digest) (types.Descriptor, digest.Digest, error) {
+	br, err := rc.BlobGet(ctx, r, types.Descriptor{Digest: d})
+	if err != nil {
+		return types.Descriptor{}, "", err
+	}
+	defer br.Close()
+	dr, err := archive.Decompress(br)
+	if err != nil {
+		return types.Descriptor{}, "", fmt.Errorf("failed to decompress layer %s: %w", d.String(), err)
+	}
+	digUC := digest.Canonical.Digester()
+	_, err = io.Copy(digUC.Hash(), dr)
+	if err != nil {
+		return types.Descriptor{}, "", fmt.Errorf("failed to read layer %s: %w", d.String(), err)
+	}
+	// read any trailing data to verify the compressed digest and size
+	_, err = io.Copy(io.Discard, br)
+	if err != nil {
+		return types.Descriptor{}, "", fmt.Errorf("failed to read layer %s: %w", d.String(), err)
+	}
+	return types.Descriptor{Digest: d, Size: br.GetDescriptor().Size}, digUC.Digest(), nil
+}
+
 const (
 	dockerReferenceType   = "vnd.docker.reference.type"
 	dockerReferenceDigest = "vnd.docker.reference.digest"
@@ -490,6 +656,54 @@ func WithExternalURLsRm() Opts {
 	}
 }
 
+// WithExternalURLsCopy copies external layers from their URLs into the target and converts them to regular layers.
+// This is needed for registries that refuse foreign layers, e.g. when mirroring Windows base images.
+// External URLs are stripped from the descriptors, see [WithExternalURLsRm].
+func WithExternalURLsCopy() Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.externalCopy = append(dc.externalCopy, dc.platformFilter)
+		return WithExternalURLsRm()(dc, dm)
+	}
+}
+
+// WithPlatforms removes entries from a manifest list that do not match the platforms.
+// Entries are platform match expressions, see [platform.NewMatcher], e.g. "linux/*" or "!windows".
+// Entries without a known platform, like attestations, are not removed.
+func WithPlatforms(platforms []string) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		pm, err := platform.NewMatcher(platforms...)
+		if err != nil {
+			return fmt.Errorf("failed to parse platforms %s: %w", strings.Join(platforms, ","), err)
+		}
+		dc.stepsManifest = append(dc.stepsManifest, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if dm.mod == deleted || !dm.m.IsList() {
+				return nil
+			}
+			mi, ok := dm.m.(manifest.Indexer)
+			if !ok {
+				return nil
+			}
+			dl, err := mi.GetManifestList()
+			if err != nil {
+				return err
+			}
+			for i, child := range dm.manifests {
+				if i >= len(dl) || child.mod == added || child.mod == deleted {
+					continue
+				}
+				if dl[i].Platform == nil || dl[i].Platform.OS == "" || dl[i].Platform.OS == "unknown" {
+					continue
+				}
+				if !pm.Match(*dl[i].Platform) {
+					child.mod = deleted
+				}
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithRebase attempts to rebase the image using OCI annotations identifying the base image.
 func WithRebase() Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
@@ -655,11 +869,7 @@ func rebaseAddStep(dc *dagConfig, rBaseOld, rBaseNew ref.Ref) error {
 		}
 		historyLayers := 0
 		for i := range confOCIOld.History {
-			if confOCI.History[i].Author != confOCIOld.History[i].Author ||
-				confOCI.History[i].Comment != confOCIOld.History[i].Comment ||
-				!confOCI.History[i].Created.Equal(*confOCIOld.History[i].Created) ||
-				confOCI.History[i].CreatedBy != confOCIOld.History[i].CreatedBy ||
-				confOCI.History[i].EmptyLayer != confOCIOld.History[i].EmptyLayer {
+			if !history.Equal(confOCI.History[i], confOCIOld.History[i]) {
 				return fmt.Errorf("old base image does not match image history, entry %d, base %v, image %v%.0w", i, confOCIOld.History[i], confOCI.History[i], types.ErrMismatch)
 			}
 			if !confOCIOld.History[i].EmptyLayer {
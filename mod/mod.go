@@ -7,14 +7,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"time"
 
-	"github.com/opencontainers/go-digest"
-
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/pkg/archive"
+	"github.com/regclient/regclient/pkg/estargz"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -33,6 +35,7 @@ type OptTime struct {
 var (
 	// whitelist of tar media types
 	mtWLTar = []string{
+		types.MediaTypeDocker2Layer,
 		types.MediaTypeDocker2LayerGzip,
 		types.MediaTypeOCI1Layer,
 		types.MediaTypeOCI1LayerGzip,
@@ -72,9 +75,13 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 		rTgt:           rTgt,
 	}
 	for _, opt := range opts {
+		lenM, lenC, lenL := len(dc.stepsManifest), len(dc.stepsOCIConfig), len(dc.stepsLayerFile)
 		if err := opt(&dc, dm); err != nil {
 			return rSrc, err
 		}
+		if dc.platformFilter != nil {
+			dc.platformFilterSteps(lenM, lenC, lenL)
+		}
 	}
 	rTgt = dc.rTgt
 
@@ -107,10 +114,19 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 			return rTgt, err
 		}
 	}
-	if len(dc.stepsLayerFile) > 0 || !ref.EqualRepository(rSrc, rTgt) {
-		err = dagWalkLayers(dm, func(dl *dagLayer) (*dagLayer, error) {
-			if dl.mod == deleted || len(dl.desc.URLs) > 0 {
-				// skip deleted or external layers
+	if len(dc.stepsLayerFile) > 0 || !ref.EqualRepository(rSrc, rTgt) || len(dc.externalCopy) > 0 {
+		err = dagWalkLayers(dm, func(dmLayer *dagManifest, dl *dagLayer) (*dagLayer, error) {
+			if dl.mod == deleted {
+				return dl, nil
+			}
+			if len(dl.desc.URLs) > 0 {
+				// external layers are skipped unless they were converted to regular layers
+				if platformFilterMatch(dc.externalCopy, dl.platform) && dl.newDesc.Digest != "" && len(dl.newDesc.URLs) == 0 {
+					err := layerExternalCopy(ctx, rc, rSrc, rTgt, dl)
+					if err != nil {
+						return nil, err
+					}
+				}
 				return dl, nil
 			}
 			if len(dc.stepsLayerFile) > 0 && dl.mod != deleted && inListStr(dl.desc.MediaType, mtWLTar) {
@@ -122,7 +138,7 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 				changed := false
 				empty := true
 				// setup tar reader to process layer
-				dr, err := archive.Decompress(br)
+				dr, err := archive.DecompressMediaType(br, dl.desc.MediaType)
 				if err != nil {
 					return nil, err
 				}
@@ -137,9 +153,32 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 				// create tar writer, optional recompress
 				var tw *tar.Writer
 				var gw *gzip.Writer
-				digRaw := digest.Canonical.Digester() // raw/compressed digest
-				digUC := digest.Canonical.Digester()  // uncompressed digest
-				if dl.desc.MediaType == types.MediaTypeDocker2LayerGzip || dl.desc.MediaType == types.MediaTypeOCI1LayerGzip {
+				var ew *io.PipeWriter
+				var ewResult estargz.Result
+				var ewErr error
+				var ewDone chan struct{}
+				alg := rc.DigestAlgorithm(rTgt)
+				digRaw := alg.Digester() // raw/compressed digest
+				digUC := alg.Digester()  // uncompressed digest
+				if platformFilterMatch(dc.layerEStargz, dl.platform) {
+					// the tar is converted to eStargz in a goroutine
+					if _, ok := dl.desc.Annotations[estargz.AnnotationTOCDigest]; !ok {
+						changed = true
+					}
+					pr, pw := io.Pipe()
+					ew = pw
+					ewDone = make(chan struct{})
+					go func() {
+						defer close(ewDone)
+						ewResult, ewErr = estargz.Build(io.MultiWriter(fh, digRaw.Hash()), pr)
+						_ = pr.CloseWithError(ewErr)
+					}()
+					defer func() {
+						_ = pw.Close()
+						<-ewDone
+					}()
+					tw = tar.NewWriter(pw)
+				} else if dl.desc.MediaType == types.MediaTypeDocker2LayerGzip || dl.desc.MediaType == types.MediaTypeOCI1LayerGzip {
 					cw := io.MultiWriter(fh, digRaw.Hash())
 					gw = gzip.NewWriter(cw)
 					defer gw.Close()
@@ -211,15 +250,51 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 							return nil, fmt.Errorf("failed to close gzip writer: %w", err)
 						}
 					}
+					if ew != nil {
+						err = ew.Close()
+						if err != nil {
+							return nil, err
+						}
+						<-ewDone
+						if ewErr != nil {
+							return nil, fmt.Errorf("failed to convert layer to eStargz: %w", ewErr)
+						}
+					}
 					// get the file size
 					l, err := fh.Seek(0, 1)
 					if err != nil {
 						return nil, err
 					}
 					dl.newDesc = dl.desc
+					// annotations are copied to avoid changing the source descriptor
+					dl.newDesc.Annotations = map[string]string{}
+					for k, v := range dl.desc.Annotations {
+						if k != estargz.AnnotationTOCDigest && k != estargz.AnnotationUncompressedSize {
+							dl.newDesc.Annotations[k] = v
+						}
+					}
+					if ew != nil {
+						if dl.newDesc.MediaType != types.MediaTypeDocker2LayerGzip {
+							dl.newDesc.MediaType = types.MediaTypeOCI1LayerGzip
+						}
+						for k, v := range ewResult.Annotations() {
+							dl.newDesc.Annotations[k] = v
+						}
+					} else if gw == nil && dmLayer.m.GetDescriptor().MediaType == types.MediaTypeDocker2Manifest {
+						// layers in other compression formats are pushed uncompressed
+						dl.newDesc.MediaType = types.MediaTypeDocker2Layer
+					} else if gw == nil {
+						dl.newDesc.MediaType = types.MediaTypeOCI1Layer
+					}
+					if len(dl.newDesc.Annotations) == 0 {
+						dl.newDesc.Annotations = nil
+					}
 					dl.newDesc.Digest = digRaw.Digest()
 					dl.newDesc.Size = l
 					dl.ucDigest = digUC.Digest()
+					if ew != nil {
+						dl.ucDigest = ewResult.DiffID
+					}
 					_, err = fh.Seek(0, 0)
 					if err != nil {
 						return nil, err
@@ -263,15 +338,136 @@ func WithRefTgt(rTgt ref.Ref) Opts {
 	}
 }
 
+// WithPlatformFilter restricts the steps from any later options to the matching platforms.
+// Entries are platform match expressions, see [platform.NewMatcher], e.g. "linux/*" or "!windows".
+// Steps on a manifest list, and images without a known platform, are skipped while the filter is set.
+// Settings like [WithLayerEStargz] and [WithExternalURLsCopy] are also limited to the matching platforms.
+// Earlier options are not affected, and an empty list removes the filter for later options.
+func WithPlatformFilter(platforms []string) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		if len(platforms) == 0 {
+			dc.platformFilter = nil
+			return nil
+		}
+		for _, entry := range platforms {
+			if _, err := platform.NewMatcher(entry); err != nil {
+				return fmt.Errorf("failed to parse filter platform %s: %w", entry, err)
+			}
+		}
+		pm, err := platform.NewMatcher(platforms...)
+		if err != nil {
+			return err
+		}
+		dc.platformFilter = &pm
+		return nil
+	}
+}
+
 // WithData sets the descriptor data field max size.
 // This also strips the data field off descriptors above the max size.
+// The setting applies to every manifest and cannot follow [WithPlatformFilter].
 func WithData(maxDataSize int64) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
+		if dc.platformFilter != nil {
+			return fmt.Errorf("data field size applies to every manifest and cannot be limited by a platform filter%.0w", types.ErrUnsupported)
+		}
 		dc.maxDataSize = maxDataSize
 		return nil
 	}
 }
 
+// platformFilterSteps wraps the steps added after the given offsets with the current platform filter.
+func (dc *dagConfig) platformFilterSteps(lenM, lenC, lenL int) {
+	filter := dc.platformFilter
+	for i := lenM; i < len(dc.stepsManifest); i++ {
+		fn := dc.stepsManifest[i]
+		dc.stepsManifest[i] = func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if dm.m.IsList() || dm.config == nil || dm.config.oc == nil || !filter.Match(dm.config.oc.GetConfig().Platform) {
+				return nil
+			}
+			return fn(ctx, rc, rSrc, rTgt, dm)
+		}
+	}
+	for i := lenC; i < len(dc.stepsOCIConfig); i++ {
+		fn := dc.stepsOCIConfig[i]
+		dc.stepsOCIConfig[i] = func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, doc *dagOCIConfig) error {
+			if !filter.Match(doc.oc.GetConfig().Platform) {
+				return nil
+			}
+			return fn(ctx, rc, rSrc, rTgt, doc)
+		}
+	}
+	for i := lenL; i < len(dc.stepsLayerFile); i++ {
+		fn := dc.stepsLayerFile[i]
+		dc.stepsLayerFile[i] = func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dl *dagLayer, th *tar.Header, rdr io.Reader) (*tar.Header, io.Reader, changes, error) {
+			if dl.platform == nil || !filter.Match(*dl.platform) {
+				return th, rdr, unchanged, nil
+			}
+			return fn(ctx, rc, rSrc, rTgt, dl, th, rdr)
+		}
+	}
+}
+
+// platformFilterMatch returns true when any of the filters recorded for a setting matches the platform.
+// A nil filter was set without a platform filter and matches every platform.
+func platformFilterMatch(filters []*platform.Matcher, p *platform.Platform) bool {
+	for _, filter := range filters {
+		if filter == nil || (p != nil && filter.Match(*p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// layerExternalCopy pushes the content of an external layer to the target.
+// The source is tried first, registries fall back to the external URLs,
+// and other schemes fetch the layer directly from the URLs.
+func layerExternalCopy(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dl *dagLayer) error {
+	if _, err := rc.BlobHead(ctx, rTgt, dl.newDesc); err == nil {
+		return nil
+	}
+	br, err := rc.BlobGet(ctx, rSrc, dl.desc)
+	if err != nil {
+		br, err = layerExternalGet(ctx, dl.desc)
+		if err != nil {
+			return err
+		}
+	}
+	defer br.Close()
+	_, err = rc.BlobPut(ctx, rTgt, dl.newDesc, br)
+	if err != nil {
+		return fmt.Errorf("failed to push external layer %s: %w", dl.desc.Digest.String(), err)
+	}
+	return nil
+}
+
+// layerExternalGet fetches an external layer from the first URL that responds, verifying the digest as it is read.
+func layerExternalGet(ctx context.Context, d types.Descriptor) (blob.Reader, error) {
+	err := fmt.Errorf("no external urls for layer %s%.0w", d.Digest.String(), types.ErrNotFound)
+	for _, u := range d.URLs {
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse external url \"%s\": %w", u, err)
+		}
+		var resp *http.Response
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			err = fmt.Errorf("failed to get external layer %s from %s, status %d%.0w", d.Digest.String(), u, resp.StatusCode, types.ErrNotFound)
+			continue
+		}
+		return blob.NewReader(
+			blob.WithDesc(d),
+			blob.WithReader(resp.Body),
+		), nil
+	}
+	return nil, err
+}
+
 func inListStr(str string, list []string) bool {
 	for _, s := range list {
 		if str == s {
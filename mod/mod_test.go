@@ -1,9 +1,15 @@
 package mod
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"testing"
 	"time"
@@ -12,8 +18,14 @@ import (
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/pkg/archive"
+	"github.com/regclient/regclient/pkg/estargz"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/docker/schema1"
+	"github.com/regclient/regclient/types/docker/schema2"
 	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -251,6 +263,116 @@ func TestMod(t *testing.T) {
 			},
 			ref: "ocidir://testrepo:v1",
 		},
+		{
+			name: "Platform Filter Label",
+			opts: []Opts{
+				WithPlatformFilter([]string{"linux/amd64"}),
+				WithLabel("test", "hello"),
+			},
+			ref: "ocidir://testrepo:v1",
+		},
+		{
+			name: "Platform Filter Layer",
+			opts: []Opts{
+				WithPlatformFilter([]string{"linux/arm64"}),
+				WithLayerStripFile("/layer2"),
+			},
+			ref: "ocidir://testrepo:v3",
+		},
+		{
+			name: "Platform Filter Missing",
+			opts: []Opts{
+				WithPlatformFilter([]string{"linux/s390x"}),
+				WithLabel("test", "hello"),
+				WithLayerStripFile("/layer2"),
+				WithAnnotation("[*]test", "hello"),
+			},
+			ref:      "ocidir://testrepo:v3",
+			wantSame: true,
+		},
+		{
+			name: "Platform Filter Reset",
+			opts: []Opts{
+				WithPlatformFilter([]string{"linux/s390x"}),
+				WithPlatformFilter([]string{}),
+				WithLabel("test", "hello"),
+			},
+			ref: "ocidir://testrepo:v1",
+		},
+		{
+			name: "Platform Filter Wildcard",
+			opts: []Opts{
+				WithPlatformFilter([]string{"linux/*", "!linux/amd64"}),
+				WithLabel("test", "hello"),
+			},
+			ref: "ocidir://testrepo:v1",
+		},
+		{
+			name: "Platform Filter Exclude All",
+			opts: []Opts{
+				WithPlatformFilter([]string{"!*"}),
+				WithLabel("test", "hello"),
+			},
+			ref:      "ocidir://testrepo:v1",
+			wantSame: true,
+		},
+		{
+			name: "Platforms",
+			opts: []Opts{
+				WithPlatforms([]string{"linux/*", "!linux/arm64"}),
+			},
+			ref: "ocidir://testrepo:v1",
+		},
+		{
+			name: "Platforms Unchanged",
+			opts: []Opts{
+				WithPlatforms([]string{"linux/*"}),
+			},
+			ref:      "ocidir://testrepo:v1",
+			wantSame: true,
+		},
+		{
+			name: "Platforms Parse Error",
+			opts: []Opts{
+				WithPlatforms([]string{"linux/arm/beta+"}),
+			},
+			ref:     "ocidir://testrepo:v1",
+			wantErr: fmt.Errorf("failed to parse platforms linux/arm/beta+: invalid variant beta in platform expression linux/arm/beta+"),
+		},
+		{
+			name: "Platform Filter EStargz",
+			opts: []Opts{
+				WithPlatformFilter([]string{"linux/arm64"}),
+				WithLayerEStargz(),
+			},
+			ref: "ocidir://testrepo:v3",
+		},
+		{
+			name: "Platform Filter EStargz Missing",
+			opts: []Opts{
+				WithPlatformFilter([]string{"linux/s390x"}),
+				WithLayerEStargz(),
+			},
+			ref:      "ocidir://testrepo:v3",
+			wantSame: true,
+		},
+		{
+			name: "Platform Filter Data",
+			opts: []Opts{
+				WithPlatformFilter([]string{"linux/arm64"}),
+				WithData(0),
+			},
+			ref:     "ocidir://testrepo:v3",
+			wantErr: types.ErrUnsupported,
+		},
+		{
+			name: "Platform Filter Parse Error",
+			opts: []Opts{
+				WithPlatformFilter([]string{"linux/invalid.arch!"}),
+			},
+			ref:     "ocidir://testrepo:v1",
+			wantErr: fmt.Errorf("failed to parse filter platform linux/invalid.arch!: invalid platform component invalid.arch! in linux/invalid.arch!"),
+		},
 		{
 			name: "Time",
 			opts: []Opts{
@@ -388,6 +510,21 @@ func TestMod(t *testing.T) {
 			},
 			ref: "ocidir://testrepo:v3",
 		},
+		{
+			name: "Layer Tar Opts",
+			opts: []Opts{
+				WithLayerTarOpts(archive.TarReproducible, archive.TarWithModTime(tTime)),
+			},
+			ref: "ocidir://testrepo:v1",
+		},
+		{
+			name: "Layer Tar Opts Unchanged",
+			opts: []Opts{
+				WithLayerTarOpts(),
+			},
+			ref:      "ocidir://testrepo:v1",
+			wantSame: true,
+		},
 		{
 			name: "Layer Timestamp Missing Label",
 			opts: []Opts{
@@ -745,6 +882,494 @@ func TestMod(t *testing.T) {
 	}
 }
 
+func TestLayerEStargz(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "../testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := regclient.New(regclient.WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://testrepo:estargz")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rMod, err := Apply(ctx, rc, r, WithLayerEStargz(), WithRefTgt(rTgt))
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+	plat := regclient.ImageWithPlatform("linux/amd64")
+	m, err := rc.ManifestGet(ctx, rMod)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	d, err := manifest.GetPlatformDesc(m, &platform.Platform{OS: "linux", Architecture: "amd64"})
+	if err != nil {
+		t.Fatalf("failed to get platform: %v", err)
+	}
+	m, err = rc.ManifestGet(ctx, rMod, regclient.WithManifestDesc(*d))
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	layers, err := m.(manifest.Imager).GetLayers()
+	if err != nil {
+		t.Fatalf("failed to get layers: %v", err)
+	}
+	for i, l := range layers {
+		if _, ok := l.Annotations[estargz.AnnotationTOCDigest]; !ok {
+			t.Errorf("layer %d is missing the TOC annotation: %v", i, l.Annotations)
+		}
+	}
+	// files are unchanged
+	fl, err := rc.ImageFileList(ctx, r, plat)
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+	for _, f := range fl {
+		if f.Type != "file" {
+			continue
+		}
+		_, rdrSrc, err := rc.ImageFileGet(ctx, r, f.Name, plat)
+		if err != nil {
+			t.Fatalf("failed to get %s: %v", f.Name, err)
+		}
+		bSrc, _ := io.ReadAll(rdrSrc)
+		_ = rdrSrc.Close()
+		_, rdrTgt, err := rc.ImageFileGet(ctx, rMod, f.Name, plat)
+		if err != nil {
+			t.Fatalf("failed to get %s from the eStargz image: %v", f.Name, err)
+		}
+		bTgt, err := io.ReadAll(rdrTgt)
+		if err != nil {
+			t.Fatalf("failed to read %s from the eStargz image: %v", f.Name, err)
+		}
+		_ = rdrTgt.Close()
+		if !bytes.Equal(bSrc, bTgt) {
+			t.Errorf("content of %s changed", f.Name)
+		}
+	}
+	// converting again does not change the image
+	mh1, err := rc.ManifestHead(ctx, rMod, regclient.WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	rMod2, err := Apply(ctx, rc, rMod, WithLayerEStargz())
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+	mh2, err := rc.ManifestHead(ctx, rMod2, regclient.WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	if !mh1.GetDescriptor().Equal(mh2.GetDescriptor()) {
+		t.Errorf("converting an eStargz image changed the digest")
+	}
+}
+
+func TestLayerUncompressedDocker(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rc := regclient.New(regclient.WithFS(rwfs.MemNew()))
+	r, err := ref.New("ocidir://testdocker:uncompressed")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	// an uncompressed docker layer with a file to strip
+	layerBuf := &bytes.Buffer{}
+	tw := tar.NewWriter(layerBuf)
+	for _, name := range []string{"keep", "remove"} {
+		err = tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(name))})
+		if err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+		_, err = tw.Write([]byte(name))
+		if err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	err = tw.Close()
+	if err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	layer := layerBuf.Bytes()
+	conf := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":["%s"]}}`, digest.FromBytes(layer)))
+	dConf := types.Descriptor{MediaType: types.MediaTypeDocker2ImageConfig, Digest: digest.FromBytes(conf), Size: int64(len(conf))}
+	dLayer := types.Descriptor{MediaType: types.MediaTypeDocker2Layer, Digest: digest.FromBytes(layer), Size: int64(len(layer))}
+	_, err = rc.BlobPut(ctx, r, dConf, bytes.NewReader(conf))
+	if err != nil {
+		t.Fatalf("failed to put config: %v", err)
+	}
+	_, err = rc.BlobPut(ctx, r, dLayer, bytes.NewReader(layer))
+	if err != nil {
+		t.Fatalf("failed to put layer: %v", err)
+	}
+	m, err := manifest.New(manifest.WithOrig(schema2.Manifest{
+		Versioned: schema2.ManifestSchemaVersion,
+		Config:    dConf,
+		Layers:    []types.Descriptor{dLayer},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	err = rc.ManifestPut(ctx, r, m)
+	if err != nil {
+		t.Fatalf("failed to put manifest: %v", err)
+	}
+	rMod, err := Apply(ctx, rc, r, WithLayerStripFile("remove"), WithRefTgt(r.SetTag("stripped")))
+	if err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	mMod, err := rc.ManifestGet(ctx, rMod)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	layers, err := mMod.(manifest.Imager).GetLayers()
+	if err != nil {
+		t.Fatalf("failed to get layers: %v", err)
+	}
+	if len(layers) != 1 || layers[0].Digest == dLayer.Digest {
+		t.Fatalf("layer was not modified: %v", layers)
+	}
+	if layers[0].MediaType != types.MediaTypeDocker2Layer {
+		t.Errorf("unexpected layer media type, expected %s, received %s", types.MediaTypeDocker2Layer, layers[0].MediaType)
+	}
+}
+
+func TestExternalURLsCopy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	layer := []byte("external layer content")
+	layerDesc := types.Descriptor{
+		MediaType: types.MediaTypeOCI1ForeignLayerGzip,
+		Digest:    digest.FromBytes(layer),
+		Size:      int64(len(layer)),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/layer" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(layer)
+	}))
+	t.Cleanup(ts.Close)
+	fsMem := rwfs.MemNew()
+	rc := regclient.New(regclient.WithFS(fsMem))
+	// newImage pushes an image with an external layer that is not included in the repository
+	newImage := func(t *testing.T, r ref.Ref, url string) {
+		t.Helper()
+		conf := blob.NewOCIConfig(blob.WithImage(v1.Image{
+			Platform: platform.Platform{OS: "windows", Architecture: "amd64"},
+			RootFS:   v1.RootFS{Type: "layers", DiffIDs: []digest.Digest{layerDesc.Digest}},
+		}))
+		confRaw, err := conf.RawBody()
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		confDesc, err := rc.BlobPut(ctx, r, types.Descriptor{MediaType: types.MediaTypeOCI1ImageConfig}, bytes.NewReader(confRaw))
+		if err != nil {
+			t.Fatalf("failed to put config: %v", err)
+		}
+		confDesc.MediaType = types.MediaTypeOCI1ImageConfig
+		l := layerDesc
+		l.URLs = []string{url}
+		m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+			Versioned: v1.ManifestSchemaVersion,
+			MediaType: types.MediaTypeOCI1Manifest,
+			Config:    confDesc,
+			Layers:    []types.Descriptor{l},
+		}))
+		if err != nil {
+			t.Fatalf("failed to create manifest: %v", err)
+		}
+		err = rc.ManifestPut(ctx, r, m)
+		if err != nil {
+			t.Fatalf("failed to put manifest: %v", err)
+		}
+	}
+	// checkImage verifies the layer was converted and copied
+	checkImage := func(t *testing.T, r ref.Ref) {
+		t.Helper()
+		m, err := rc.ManifestGet(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		layers, err := m.(manifest.Imager).GetLayers()
+		if err != nil || len(layers) != 1 {
+			t.Fatalf("failed to get layers: %v, %v", err, layers)
+		}
+		if len(layers[0].URLs) > 0 || layers[0].MediaType != types.MediaTypeOCI1LayerGzip || layers[0].Digest != layerDesc.Digest {
+			t.Errorf("layer was not converted: %v", layers[0])
+		}
+		br, err := rc.BlobGet(ctx, r, layers[0])
+		if err != nil {
+			t.Fatalf("failed to get layer: %v", err)
+		}
+		b, err := io.ReadAll(br)
+		_ = br.Close()
+		if err != nil || !bytes.Equal(b, layer) {
+			t.Errorf("unexpected layer content: %s, %v", string(b), err)
+		}
+	}
+
+	t.Run("same repo", func(t *testing.T) {
+		r, err := ref.New("ocidir://external:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		newImage(t, r, ts.URL+"/layer")
+		rMod, err := Apply(ctx, rc, r, WithExternalURLsCopy())
+		if err != nil {
+			t.Fatalf("failed to apply: %v", err)
+		}
+		checkImage(t, rMod)
+	})
+	t.Run("target repo", func(t *testing.T) {
+		r, err := ref.New("ocidir://external:v2")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rTgt, err := ref.New("ocidir://external-mirror:v2")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		newImage(t, r, ts.URL+"/layer")
+		rMod, err := Apply(ctx, rc, r, WithRefTgt(rTgt), WithExternalURLsCopy())
+		if err != nil {
+			t.Fatalf("failed to apply: %v", err)
+		}
+		checkImage(t, rMod)
+	})
+	t.Run("missing url", func(t *testing.T) {
+		r, err := ref.New("ocidir://external-missing:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		newImage(t, r, ts.URL+"/missing")
+		_, err = Apply(ctx, rc, r, WithExternalURLsCopy())
+		if err == nil {
+			t.Errorf("apply did not fail with a missing external layer")
+		}
+	})
+}
+
+func TestManifestFromSchema1(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsMem := rwfs.MemNew()
+	rc := regclient.New(regclient.WithFS(fsMem))
+	r, err := ref.New("ocidir://schema1:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	// push two gzip layers, listed newest first with a throwaway entry between them
+	layerDigests := []digest.Digest{}
+	diffIDs := []digest.Digest{}
+	for _, name := range []string{"top.txt", "base.txt"} {
+		tarBuf := &bytes.Buffer{}
+		tw := tar.NewWriter(tarBuf)
+		content := []byte("content of " + name)
+		err = tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		_, _ = tw.Write(content)
+		_ = tw.Close()
+		diffIDs = append(diffIDs, digest.FromBytes(tarBuf.Bytes()))
+		gzBuf := &bytes.Buffer{}
+		gw := gzip.NewWriter(gzBuf)
+		_, _ = gw.Write(tarBuf.Bytes())
+		_ = gw.Close()
+		d, err := rc.BlobPut(ctx, r, types.Descriptor{}, bytes.NewReader(gzBuf.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to put layer: %v", err)
+		}
+		layerDigests = append(layerDigests, d.Digest)
+	}
+	m1, err := manifest.New(manifest.WithOrig(schema1.Manifest{
+		Versioned:    schema1.ManifestSchemaVersion,
+		Name:         "schema1",
+		Tag:          "v1",
+		Architecture: "amd64",
+		FSLayers: []schema1.FSLayer{
+			{BlobSum: layerDigests[0]},
+			{BlobSum: types.EmptyDigest},
+			{BlobSum: layerDigests[1]},
+		},
+		History: []schema1.History{
+			{V1Compatibility: `{"id":"c","parent":"b","architecture":"amd64","os":"linux","created":"2016-01-03T00:00:00Z","config":{"Cmd":["/bin/sh"]},"container_config":{"Cmd":["/bin/sh","-c","echo top"]}}`},
+			{V1Compatibility: `{"id":"b","parent":"a","created":"2016-01-02T00:00:00Z","container_config":{"Cmd":["/bin/sh","-c","#(nop) ENV A=b"]},"throwaway":true}`},
+			{V1Compatibility: `{"id":"a","created":"2016-01-01T00:00:00Z","container_config":{"Cmd":["/bin/sh","-c","#(nop) ADD base.txt"]}}`},
+		},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	err = rc.ManifestPut(ctx, r, m1)
+	if err != nil {
+		t.Fatalf("failed to put manifest: %v", err)
+	}
+
+	tt := []struct {
+		name       string
+		opt        Opts
+		tgt        string
+		mtConfig   string
+		mtLayer    string
+		mtManifest string
+	}{
+		{
+			name:       "to OCI",
+			opt:        WithManifestToOCI(),
+			tgt:        "ocidir://schema1-oci:v1",
+			mtConfig:   types.MediaTypeOCI1ImageConfig,
+			mtLayer:    types.MediaTypeOCI1LayerGzip,
+			mtManifest: types.MediaTypeOCI1Manifest,
+		},
+		{
+			name:       "to Docker",
+			opt:        WithManifestToDocker(),
+			tgt:        "ocidir://schema1-docker:v1",
+			mtConfig:   types.MediaTypeDocker2ImageConfig,
+			mtLayer:    types.MediaTypeDocker2LayerGzip,
+			mtManifest: types.MediaTypeDocker2Manifest,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rTgt, err := ref.New(tc.tgt)
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+			rOut, err := Apply(ctx, rc, r, tc.opt, WithRefTgt(rTgt))
+			if err != nil {
+				t.Fatalf("failed to convert: %v", err)
+			}
+			m, err := rc.ManifestGet(ctx, rOut)
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			if m.GetDescriptor().MediaType != tc.mtManifest {
+				t.Errorf("unexpected media type, expected %s, received %s", tc.mtManifest, m.GetDescriptor().MediaType)
+			}
+			mi := m.(manifest.Imager)
+			cd, err := mi.GetConfig()
+			if err != nil || cd.MediaType != tc.mtConfig {
+				t.Fatalf("unexpected config: %v, %v", cd, err)
+			}
+			layers, err := mi.GetLayers()
+			if err != nil || len(layers) != 2 {
+				t.Fatalf("unexpected layers: %v, %v", layers, err)
+			}
+			// layers are ordered base first
+			for i, l := range layers {
+				if l.MediaType != tc.mtLayer || l.Digest != layerDigests[1-i] || l.Size == 0 {
+					t.Errorf("unexpected layer %d: %v", i, l)
+				}
+				_, err = rc.BlobHead(ctx, rOut, l)
+				if err != nil {
+					t.Errorf("layer %d missing from the target: %v", i, err)
+				}
+			}
+			oc, err := rc.BlobGetOCIConfig(ctx, rOut, cd)
+			if err != nil {
+				t.Fatalf("failed to get config: %v", err)
+			}
+			c := oc.GetConfig()
+			if c.Architecture != "amd64" || c.OS != "linux" || len(c.Config.Cmd) != 1 {
+				t.Errorf("unexpected config: %v", c)
+			}
+			if len(c.RootFS.DiffIDs) != 2 || c.RootFS.DiffIDs[0] != diffIDs[1] || c.RootFS.DiffIDs[1] != diffIDs[0] {
+				t.Errorf("unexpected diff ids: %v", c.RootFS.DiffIDs)
+			}
+			if len(c.History) != 3 || !c.History[1].EmptyLayer || c.History[0].CreatedBy != "/bin/sh -c #(nop) ADD base.txt" {
+				t.Errorf("unexpected history: %v", c.History)
+			}
+			raw, err := oc.RawBody()
+			if err != nil || bytes.Contains(raw, []byte(`"parent"`)) || bytes.Contains(raw, []byte(`"id"`)) {
+				t.Errorf("v1 fields were not removed: %s, %v", string(raw), err)
+			}
+		})
+	}
+}
+
+func TestDigestAlgorithm(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "../testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := regclient.New(regclient.WithFS(fsMem), regclient.WithDigestAlgorithm(digest.SHA512))
+	r, err := ref.New("ocidir://testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://tgtrepo:sha512")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rOut, err := Apply(ctx, rc, r, WithRefTgt(rTgt), WithLabel("digest", "sha512"), WithLayerTimestamp(OptTime{Set: time.Unix(0, 0)}))
+	if err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	mi, err := rc.ManifestGet(ctx, rOut)
+	if err != nil {
+		t.Fatalf("failed to get index: %v", err)
+	}
+	if mi.GetDescriptor().Digest.Algorithm() != digest.SHA512 {
+		t.Errorf("unexpected index digest: %s", mi.GetDescriptor().Digest)
+	}
+	ml, err := mi.(manifest.Indexer).GetManifestList()
+	if err != nil || len(ml) == 0 {
+		t.Fatalf("failed to get manifest list: %v", err)
+	}
+	for _, d := range ml {
+		if d.Digest.Algorithm() != digest.SHA512 {
+			t.Errorf("unexpected child digest: %s", d.Digest)
+			continue
+		}
+		m, err := rc.ManifestGet(ctx, rOut.SetDigest(d.Digest.String()))
+		if err != nil {
+			t.Fatalf("failed to get child %s: %v", d.Digest, err)
+		}
+		cd, err := m.(manifest.Imager).GetConfig()
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		if cd.Digest.Algorithm() != digest.SHA512 {
+			t.Errorf("unexpected config digest: %s", cd.Digest)
+		}
+		oc, err := rc.BlobGetOCIConfig(ctx, rOut, cd)
+		if err != nil {
+			t.Fatalf("failed to get config %s: %v", cd.Digest, err)
+		}
+		if oc.GetConfig().Config.Labels["digest"] != "sha512" {
+			t.Errorf("label missing from config %s", cd.Digest)
+		}
+		// rewritten image layers include new digests and diff ids
+		if len(oc.GetConfig().RootFS.DiffIDs) == 0 {
+			continue
+		}
+		ll, err := m.(manifest.Imager).GetLayers()
+		if err != nil {
+			t.Fatalf("failed to get layers: %v", err)
+		}
+		for i, l := range ll {
+			if l.Digest.Algorithm() != digest.SHA512 || oc.GetConfig().RootFS.DiffIDs[i].Algorithm() != digest.SHA512 {
+				t.Errorf("unexpected layer digest %s, diff id %s", l.Digest, oc.GetConfig().RootFS.DiffIDs[i])
+			}
+		}
+	}
+}
+
 func TestInList(t *testing.T) {
 	t.Parallel()
 	t.Run("match", func(t *testing.T) {
@@ -5,8 +5,12 @@ import (
 	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/ulikunitz/xz"
 )
 
@@ -22,6 +26,8 @@ const (
 	CompressGzip
 	// CompressXz compression
 	CompressXz
+	// CompressZstd compression
+	CompressZstd
 )
 
 // compressHeaders are used to detect the compression type
@@ -29,8 +35,11 @@ var compressHeaders = map[CompressType][]byte{
 	CompressBzip2: []byte("\x42\x5A\x68"),
 	CompressGzip:  []byte("\x1F\x8B\x08"),
 	CompressXz:    []byte("\xFD\x37\x7A\x58\x5A\x00"),
+	CompressZstd:  []byte("\x28\xB5\x2F\xFD"),
 }
 
+// Compress converts a stream to the requested compression, decompressing the input when needed.
+// Compressing with bzip2 or zstd requires a compressor registered with [RegisterCompressor].
 func Compress(r io.Reader, oComp CompressType) (io.Reader, error) {
 	br := bufio.NewReader(r)
 	head, err := br.Peek(10)
@@ -41,34 +50,113 @@ func Compress(r io.Reader, oComp CompressType) (io.Reader, error) {
 	if rComp == oComp {
 		return br, nil
 	}
-	switch oComp {
-	case CompressGzip:
-		switch rComp {
-		case CompressNone:
-			return compressGzip(br)
-		case CompressBzip2:
-			return compressGzip(bzip2.NewReader(br))
-		case CompressXz:
-			cbr, _ := xz.NewReader(br)
-			return compressGzip(cbr)
-		}
+	dr, err := Decompress(br)
+	if err != nil {
+		return nil, err
+	}
+	if oComp == CompressNone {
+		return dr, nil
+	}
+	c, err := compressorGet(oComp)
+	if err != nil {
+		return nil, err
 	}
-	// No other types currently supported
-	return nil, ErrUnknownType
-}
-
-func compressGzip(src io.Reader) (io.Reader, error) {
 	pipeR, pipeW := io.Pipe()
 	go func() {
-		defer pipeW.Close()
-		gzipW := gzip.NewWriter(pipeW)
-		defer gzipW.Close()
-		_, _ = io.Copy(gzipW, src)
+		// some compressors write a header when created, so the writer is created after the reader is returned
+		cw, err := c(pipeW)
+		if err != nil {
+			_ = pipeW.CloseWithError(err)
+			return
+		}
+		_, err = io.Copy(cw, dr)
+		errC := cw.Close()
+		if err == nil {
+			err = errC
+		}
+		_ = pipeW.CloseWithError(err)
 	}()
 	return pipeR, nil
 }
 
-// Decompress extracts gzip and bzip streams
+// Compressor returns a writer that compresses content to w.
+// Closing the returned writer must flush the compressed stream without closing w.
+type Compressor func(w io.Writer) (io.WriteCloser, error)
+
+var (
+	compressMu  sync.RWMutex
+	compressors = map[string]Compressor{
+		"+gzip": func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+		"+xz":   func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) },
+	}
+)
+
+// RegisterCompressor adds a compressor for the suffix, e.g. "+zstd".
+// The standard library does not include a bzip2 or zstd compressor, so those are provided by the caller.
+// Registering an existing suffix replaces the previous compressor.
+func RegisterCompressor(suffix string, c Compressor) {
+	compressMu.Lock()
+	defer compressMu.Unlock()
+	compressors[suffix] = c
+}
+
+// NewCompressWriter returns a writer that compresses content to w using the registered compressor for the type.
+// An unregistered type returns [ErrUnknownType].
+func NewCompressWriter(w io.Writer, ct CompressType) (io.WriteCloser, error) {
+	c, err := compressorGet(ct)
+	if err != nil {
+		return nil, err
+	}
+	return c(w)
+}
+
+func compressorGet(ct CompressType) (Compressor, error) {
+	compressMu.RLock()
+	c, ok := compressors["+"+ct.String()]
+	compressMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no compressor registered for %s%.0w", ct.String(), ErrUnknownType)
+	}
+	return c, nil
+}
+
+// Decompressor returns a reader of the uncompressed content from a compressed stream.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+type decompressor struct {
+	suffix string
+	magic  []byte
+	fn     Decompressor
+}
+
+var (
+	decompressMu  sync.RWMutex
+	decompressors = []decompressor{
+		{suffix: "+bzip2", magic: compressHeaders[CompressBzip2], fn: func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil }},
+		{suffix: "+gzip", magic: compressHeaders[CompressGzip], fn: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{suffix: "+xz", magic: compressHeaders[CompressXz], fn: func(r io.Reader) (io.Reader, error) { return xz.NewReader(r) }},
+		{suffix: "+zstd", magic: compressHeaders[CompressZstd], fn: zstdDecompress},
+	}
+)
+
+// RegisterDecompressor adds a decompressor for media types ending with the suffix, e.g. "+zstd".
+// The magic header, up to 10 bytes, is used to detect the compression of streams without a media type, and may be nil.
+// Registering an existing suffix replaces the previous decompressor.
+func RegisterDecompressor(suffix string, magic []byte, d Decompressor) {
+	decompressMu.Lock()
+	defer decompressMu.Unlock()
+	for i := range decompressors {
+		if decompressors[i].suffix == suffix {
+			decompressors[i].magic = magic
+			decompressors[i].fn = d
+			return
+		}
+	}
+	decompressors = append(decompressors, decompressor{suffix: suffix, magic: magic, fn: d})
+}
+
+// Decompress extracts streams compressed with any registered decompressor, detected by the magic header.
+// Streams without a known header are returned uncompressed.
 func Decompress(r io.Reader) (io.Reader, error) {
 	// create bufio to peak on first few bytes
 	br := bufio.NewReader(r)
@@ -77,17 +165,45 @@ func Decompress(r io.Reader) (io.Reader, error) {
 		return br, err
 	}
 
-	// compare peaked data against known compression types
-	switch DetectCompression(head) {
-	case CompressBzip2:
-		return bzip2.NewReader(br), nil
-	case CompressGzip:
-		return gzip.NewReader(br)
-	case CompressXz:
-		return xz.NewReader(br)
-	default:
-		return br, nil
+	// compare peaked data against registered compression types
+	decompressMu.RLock()
+	for _, d := range decompressors {
+		if len(d.magic) > 0 && bytes.HasPrefix(head, d.magic) {
+			decompressMu.RUnlock()
+			return d.fn(br)
+		}
+	}
+	decompressMu.RUnlock()
+	return br, nil
+}
+
+// DecompressMediaType extracts a stream using the decompressor registered for the suffix of the media type.
+// Media types without a "+" suffix, like docker layers, fall back to detecting the compression with [Decompress].
+// An unregistered suffix returns [ErrUnknownType].
+func DecompressMediaType(r io.Reader, mediaType string) (io.Reader, error) {
+	i := strings.LastIndex(mediaType, "+")
+	if i < 0 {
+		return Decompress(r)
+	}
+	suffix := mediaType[i:]
+	decompressMu.RLock()
+	for _, d := range decompressors {
+		if d.suffix == suffix {
+			decompressMu.RUnlock()
+			return d.fn(r)
+		}
+	}
+	decompressMu.RUnlock()
+	return nil, fmt.Errorf("no decompressor registered for %s%.0w", mediaType, ErrUnknownType)
+}
+
+// zstdDecompress decodes synchronously, so the decoder does not leave goroutines running when the reader is not closed.
+func zstdDecompress(r io.Reader) (io.Reader, error) {
+	zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+	if err != nil {
+		return nil, err
 	}
+	return zr.IOReadCloser(), nil
 }
 
 // DetectCompression identifies the compression type based on the first few bytes
@@ -110,6 +226,8 @@ func (ct CompressType) String() string {
 		return "gzip"
 	case CompressXz:
 		return "xz"
+	case CompressZstd:
+		return "zstd"
 	}
 	return "unknown"
 }
@@ -0,0 +1,203 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDecompress(t *testing.T) {
+	content := []byte("hello world, this is a test of the decompressors")
+	gzipBuf := &bytes.Buffer{}
+	gw := gzip.NewWriter(gzipBuf)
+	_, err := gw.Write(content)
+	if err != nil {
+		t.Fatalf("failed to gzip: %v", err)
+	}
+	err = gw.Close()
+	if err != nil {
+		t.Fatalf("failed to close gzip: %v", err)
+	}
+	zstdBuf := &bytes.Buffer{}
+	zw, err := zstd.NewWriter(zstdBuf)
+	if err != nil {
+		t.Fatalf("failed to create zstd writer: %v", err)
+	}
+	_, err = zw.Write(content)
+	if err != nil {
+		t.Fatalf("failed to zstd: %v", err)
+	}
+	err = zw.Close()
+	if err != nil {
+		t.Fatalf("failed to close zstd: %v", err)
+	}
+	// a custom encoding prefixes the content with a header
+	magic := []byte("RGCTEST")
+	RegisterDecompressor("+regctest", magic, func(r io.Reader) (io.Reader, error) {
+		head := make([]byte, len(magic))
+		_, err := io.ReadFull(r, head)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	})
+	custom := append(append([]byte{}, magic...), content...)
+
+	tt := []struct {
+		name      string
+		in        []byte
+		mediaType string
+		detect    bool
+		expectErr error
+	}{
+		{
+			name:   "detect none",
+			in:     content,
+			detect: true,
+		},
+		{
+			name:   "detect gzip",
+			in:     gzipBuf.Bytes(),
+			detect: true,
+		},
+		{
+			name:   "detect custom",
+			in:     custom,
+			detect: true,
+		},
+		{
+			name:      "media type gzip",
+			in:        gzipBuf.Bytes(),
+			mediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+		},
+		{
+			name:      "media type docker gzip",
+			in:        gzipBuf.Bytes(),
+			mediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
+		},
+		{
+			name:      "media type uncompressed",
+			in:        content,
+			mediaType: "application/vnd.oci.image.layer.v1.tar",
+		},
+		{
+			name:      "media type custom",
+			in:        custom,
+			mediaType: "application/vnd.oci.image.layer.v1.tar+regctest",
+		},
+		{
+			name:   "detect zstd",
+			in:     zstdBuf.Bytes(),
+			detect: true,
+		},
+		{
+			name:      "media type zstd",
+			in:        zstdBuf.Bytes(),
+			mediaType: "application/vnd.oci.image.layer.v1.tar+zstd",
+		},
+		{
+			name:      "media type unknown",
+			in:        content,
+			mediaType: "application/vnd.oci.image.layer.v1.tar+unknown",
+			expectErr: ErrUnknownType,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var r io.Reader
+			var err error
+			if tc.detect {
+				r, err = Decompress(bytes.NewReader(tc.in))
+			} else {
+				r, err = DecompressMediaType(bytes.NewReader(tc.in), tc.mediaType)
+			}
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to decompress: %v", err)
+			}
+			out, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if !bytes.Equal(out, content) {
+				t.Errorf("unexpected content: %s", out)
+			}
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	content := []byte("hello world, this is a test of the compressors")
+	RegisterCompressor("+bzip2", func(w io.Writer) (io.WriteCloser, error) {
+		return nil, errors.New("bzip2 test compressor")
+	})
+	tt := []struct {
+		name      string
+		from      CompressType
+		to        CompressType
+		expectErr bool
+	}{
+		{name: "none to gzip", from: CompressNone, to: CompressGzip},
+		{name: "none to xz", from: CompressNone, to: CompressXz},
+		{name: "gzip to xz", from: CompressGzip, to: CompressXz},
+		{name: "xz to gzip", from: CompressXz, to: CompressGzip},
+		{name: "gzip to none", from: CompressGzip, to: CompressNone},
+		{name: "none to zstd", from: CompressNone, to: CompressZstd, expectErr: true},
+		{name: "none to bzip2 failing", from: CompressNone, to: CompressBzip2, expectErr: true},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			in, err := Compress(bytes.NewReader(content), tc.from)
+			if err != nil {
+				t.Fatalf("failed to setup input: %v", err)
+			}
+			r, err := Compress(in, tc.to)
+			if tc.expectErr {
+				// errors from the compressor are returned when reading
+				if err == nil {
+					_, err = io.ReadAll(r)
+				}
+				if err == nil {
+					t.Errorf("did not receive expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to compress: %v", err)
+			}
+			out, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			head := out
+			if len(head) > 10 {
+				head = head[:10]
+			}
+			if DetectCompression(head) != tc.to {
+				t.Errorf("unexpected compression, expected %s, received %s", tc.to, DetectCompression(head))
+			}
+			dr, err := Decompress(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("failed to decompress: %v", err)
+			}
+			out, err = io.ReadAll(dr)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if !bytes.Equal(out, content) {
+				t.Errorf("unexpected content: %s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/internal/diff"
+)
+
+// Change types returned by [Diff].
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// DiffOpts configures options for [Diff].
+type DiffOpts func(*diffOpts)
+
+type diffOpts struct {
+	contentMax int64
+	context    int
+	ignoreTime bool
+}
+
+// DiffWithContent includes a line diff of changed text files up to maxSize bytes.
+func DiffWithContent(maxSize int64) DiffOpts {
+	return func(do *diffOpts) {
+		do.contentMax = maxSize
+	}
+}
+
+// DiffWithContext sets the lines of context in a content diff, defaulting to 3.
+func DiffWithContext(lines int) DiffOpts {
+	return func(do *diffOpts) {
+		do.context = lines
+	}
+}
+
+// DiffIgnoreTime option to ignore modification times when comparing files
+func DiffIgnoreTime(do *diffOpts) {
+	do.ignoreTime = true
+}
+
+// Entry summarizes a file in a tar.
+type Entry struct {
+	Name     string        `json:"name"`
+	Type     string        `json:"type"` // "file", "dir", "symlink", "link", or "other"
+	Mode     fs.FileMode   `json:"mode"`
+	UID      int           `json:"uid"`
+	GID      int           `json:"gid"`
+	Size     int64         `json:"size"`
+	ModTime  time.Time     `json:"modTime"`
+	Linkname string        `json:"linkname,omitempty"`
+	Digest   digest.Digest `json:"digest,omitempty"`
+	content  []byte        // content of small text files for the content diff
+	text     bool
+}
+
+// Change is a difference between two tars.
+type Change struct {
+	Name string   `json:"name"`
+	Type string   `json:"type"` // [ChangeAdded], [ChangeRemoved], or [ChangeChanged]
+	Old  *Entry   `json:"old,omitempty"`
+	New  *Entry   `json:"new,omitempty"`
+	Diff []string `json:"diff,omitempty"` // line diff of text content, see [DiffWithContent]
+}
+
+// Diff reads two tar streams and returns the files added, removed, and changed, sorted by name.
+// Compressed tars are decompressed.
+// Only a digest of each file is kept in memory, along with the content of small text files with [DiffWithContent].
+func Diff(r1, r2 io.Reader, opts ...DiffOpts) ([]Change, error) {
+	do := diffOpts{context: 3}
+	for _, opt := range opts {
+		opt(&do)
+	}
+	e1, err := diffEntries(r1, do)
+	if err != nil {
+		return nil, err
+	}
+	e2, err := diffEntries(r2, do)
+	if err != nil {
+		return nil, err
+	}
+	changes := []Change{}
+	for name, old := range e1 {
+		cur, ok := e2[name]
+		if !ok {
+			changes = append(changes, Change{Name: name, Type: ChangeRemoved, Old: old})
+			continue
+		}
+		if old.equal(cur, do) {
+			continue
+		}
+		c := Change{Name: name, Type: ChangeChanged, Old: old, New: cur}
+		if old.text && cur.text && old.Digest != cur.Digest {
+			c.Diff = diff.Diff(diffLines(old.content), diffLines(cur.content), diff.WithContext(do.context, do.context))
+		}
+		changes = append(changes, c)
+	}
+	for name, cur := range e2 {
+		if _, ok := e1[name]; !ok {
+			changes = append(changes, Change{Name: name, Type: ChangeAdded, New: cur})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+// String returns a summary of the attributes that differ between the old and new entry.
+func (c Change) String() string {
+	switch c.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("A %s (%s)", c.Name, c.New.String())
+	case ChangeRemoved:
+		return fmt.Sprintf("D %s", c.Name)
+	}
+	attrs := []string{}
+	if c.Old.Type != c.New.Type {
+		attrs = append(attrs, fmt.Sprintf("type %s -> %s", c.Old.Type, c.New.Type))
+	}
+	if c.Old.Mode != c.New.Mode {
+		attrs = append(attrs, fmt.Sprintf("mode %s -> %s", c.Old.Mode.String(), c.New.Mode.String()))
+	}
+	if c.Old.UID != c.New.UID || c.Old.GID != c.New.GID {
+		attrs = append(attrs, fmt.Sprintf("owner %d/%d -> %d/%d", c.Old.UID, c.Old.GID, c.New.UID, c.New.GID))
+	}
+	if c.Old.Size != c.New.Size {
+		attrs = append(attrs, fmt.Sprintf("size %d -> %d", c.Old.Size, c.New.Size))
+	}
+	if c.Old.Digest != c.New.Digest {
+		attrs = append(attrs, fmt.Sprintf("digest %s -> %s", c.Old.Digest.String(), c.New.Digest.String()))
+	}
+	if c.Old.Linkname != c.New.Linkname {
+		attrs = append(attrs, fmt.Sprintf("link %s -> %s", c.Old.Linkname, c.New.Linkname))
+	}
+	if !c.Old.ModTime.Equal(c.New.ModTime) {
+		attrs = append(attrs, fmt.Sprintf("time %s -> %s", c.Old.ModTime.Format(time.RFC3339), c.New.ModTime.Format(time.RFC3339)))
+	}
+	return fmt.Sprintf("M %s: %s", c.Name, strings.Join(attrs, ", "))
+}
+
+// String returns the mode, owner, size, and digest of the entry.
+func (e Entry) String() string {
+	s := fmt.Sprintf("%s %d/%d %d", e.Mode.String(), e.UID, e.GID, e.Size)
+	if e.Digest != "" {
+		s += " " + e.Digest.String()
+	}
+	if e.Linkname != "" {
+		s += " -> " + e.Linkname
+	}
+	return s
+}
+
+func (e *Entry) equal(e2 *Entry, do diffOpts) bool {
+	if !do.ignoreTime && !e.ModTime.Equal(e2.ModTime) {
+		return false
+	}
+	return e.Type == e2.Type && e.Mode == e2.Mode && e.UID == e2.UID && e.GID == e2.GID &&
+		e.Size == e2.Size && e.Linkname == e2.Linkname && e.Digest == e2.Digest
+}
+
+func diffEntries(r io.Reader, do diffOpts) (map[string]*Entry, error) {
+	rd, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]*Entry{}
+	rt := tar.NewReader(rd)
+	for {
+		hdr, err := rt.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if name == "" {
+			continue
+		}
+		e := &Entry{
+			Name:     name,
+			Type:     diffType(hdr.Typeflag),
+			Mode:     hdr.FileInfo().Mode(),
+			UID:      hdr.Uid,
+			GID:      hdr.Gid,
+			Size:     hdr.Size,
+			ModTime:  hdr.ModTime.UTC(),
+			Linkname: hdr.Linkname,
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			dig := digest.Canonical.Digester()
+			w := io.Writer(dig.Hash())
+			buf := &bytes.Buffer{}
+			if do.contentMax > 0 && hdr.Size <= do.contentMax {
+				w = io.MultiWriter(w, buf)
+			}
+			n, err := io.Copy(w, rt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+			}
+			if n != hdr.Size {
+				return nil, fmt.Errorf("size mismatch for \"%s\", expected %d, read %d", hdr.Name, hdr.Size, n)
+			}
+			e.Digest = dig.Digest()
+			if do.contentMax > 0 && hdr.Size <= do.contentMax && utf8.Valid(buf.Bytes()) && !bytes.ContainsRune(buf.Bytes(), 0) {
+				e.content = buf.Bytes()
+				e.text = true
+			}
+		}
+		// later entries replace earlier entries with the same name
+		entries[name] = e
+	}
+	return entries, nil
+}
+
+func diffLines(b []byte) []string {
+	if len(b) == 0 {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+func diffType(flag byte) string {
+	switch flag {
+	case tar.TypeReg:
+		return "file"
+	case tar.TypeDir:
+		return "dir"
+	case tar.TypeSymlink:
+		return "symlink"
+	case tar.TypeLink:
+		return "link"
+	default:
+		return "other"
+	}
+}
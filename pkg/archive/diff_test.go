@@ -0,0 +1,159 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+)
+
+type diffTestFile struct {
+	name    string
+	mode    int64
+	content string
+	typ     byte
+	link    string
+	modTime time.Time
+}
+
+func diffTestTar(t *testing.T, compress bool, files []diffTestFile) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	var tw *tar.Writer
+	var gw *gzip.Writer
+	if compress {
+		gw = gzip.NewWriter(buf)
+		tw = tar.NewWriter(gw)
+	} else {
+		tw = tar.NewWriter(buf)
+	}
+	for _, f := range files {
+		typ := f.typ
+		if typ == 0 {
+			typ = tar.TypeReg
+		}
+		hdr := &tar.Header{
+			Name:     f.name,
+			Mode:     f.mode,
+			Typeflag: typ,
+			Linkname: f.link,
+			ModTime:  f.modTime,
+		}
+		if typ == tar.TypeReg {
+			hdr.Size = int64(len(f.content))
+		}
+		err := tw.WriteHeader(hdr)
+		if err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+		if typ == tar.TypeReg {
+			_, err = tw.Write([]byte(f.content))
+			if err != nil {
+				t.Fatalf("failed to write content: %v", err)
+			}
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	if gw != nil {
+		err = gw.Close()
+		if err != nil {
+			t.Fatalf("failed to close gzip: %v", err)
+		}
+	}
+	return buf
+}
+
+func TestDiff(t *testing.T) {
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	old := []diffTestFile{
+		{name: "etc/", mode: 0755, typ: tar.TypeDir, modTime: t1},
+		{name: "etc/config", mode: 0644, content: "a=1\nb=2\nc=3\n", modTime: t1},
+		{name: "./etc/removed", mode: 0644, content: "gone", modTime: t1},
+		{name: "bin/tool", mode: 0755, content: "\x00binary", modTime: t1},
+		{name: "link", typ: tar.TypeSymlink, link: "etc/config", modTime: t1},
+		{name: "touched", mode: 0644, content: "same", modTime: t1},
+	}
+	cur := []diffTestFile{
+		{name: "etc/", mode: 0755, typ: tar.TypeDir, modTime: t1},
+		{name: "etc/config", mode: 0644, content: "a=1\nb=3\nc=3\n", modTime: t1},
+		{name: "etc/added", mode: 0600, content: "new", modTime: t1},
+		{name: "bin/tool", mode: 0700, content: "\x00binary", modTime: t1},
+		{name: "link", typ: tar.TypeSymlink, link: "etc/added", modTime: t1},
+		{name: "touched", mode: 0644, content: "same", modTime: t2},
+	}
+
+	changes, err := Diff(diffTestTar(t, false, old), diffTestTar(t, true, cur), DiffWithContent(1024), DiffWithContext(0))
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	expect := []struct {
+		name string
+		typ  string
+	}{
+		{"bin/tool", ChangeChanged},
+		{"etc/added", ChangeAdded},
+		{"etc/config", ChangeChanged},
+		{"etc/removed", ChangeRemoved},
+		{"link", ChangeChanged},
+		{"touched", ChangeChanged},
+	}
+	if len(changes) != len(expect) {
+		t.Fatalf("unexpected changes, expected %d, received %v", len(expect), changes)
+	}
+	for i, e := range expect {
+		if changes[i].Name != e.name || changes[i].Type != e.typ {
+			t.Errorf("unexpected change %d, expected %s %s, received %s %s", i, e.typ, e.name, changes[i].Type, changes[i].Name)
+		}
+	}
+	if changes[0].Diff != nil {
+		t.Errorf("unexpected content diff of a binary file: %v", changes[0].Diff)
+	}
+	if changes[0].String() != "M bin/tool: mode -rwxr-xr-x -> -rwx------" {
+		t.Errorf("unexpected summary: %s", changes[0].String())
+	}
+	expectDiff := []string{"@@ -2,1 +2,1 @@", "- b=2", "+ b=3"}
+	if len(changes[2].Diff) != len(expectDiff) {
+		t.Errorf("unexpected content diff, expected %v, received %v", expectDiff, changes[2].Diff)
+	} else {
+		for i := range expectDiff {
+			if changes[2].Diff[i] != expectDiff[i] {
+				t.Errorf("unexpected content diff, expected %v, received %v", expectDiff, changes[2].Diff)
+				break
+			}
+		}
+	}
+	if changes[4].String() != "M link: link etc/config -> etc/added" {
+		t.Errorf("unexpected summary: %s", changes[4].String())
+	}
+
+	// ignoring the timestamp and without content
+	changes, err = Diff(diffTestTar(t, false, old), diffTestTar(t, false, cur), DiffIgnoreTime)
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	if len(changes) != len(expect)-1 {
+		t.Fatalf("unexpected changes ignoring timestamps: %v", changes)
+	}
+	for _, c := range changes {
+		if c.Name == "touched" {
+			t.Errorf("timestamp change was not ignored")
+		}
+		if c.Diff != nil {
+			t.Errorf("unexpected content diff for %s: %v", c.Name, c.Diff)
+		}
+	}
+
+	// identical tars
+	changes, err = Diff(diffTestTar(t, true, old), diffTestTar(t, false, old))
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+}
@@ -3,8 +3,12 @@ package archive
 import "errors"
 
 var (
+	// ErrLimitExceeded when a tar exceeds the entry or size limit on extract
+	ErrLimitExceeded = errors.New("archive limit exceeded")
 	// ErrNotImplemented used for routines that need to be developed still
 	ErrNotImplemented = errors.New("this archive routine is not implemented yet")
+	// ErrUnsafePath when a tar entry would be written outside of the extract path
+	ErrUnsafePath = errors.New("unsafe path in archive")
 	// ErrUnknownType used for unknown compression types
 	ErrUnknownType = errors.New("unknown compression type")
 	// ErrXzUnsupported because there isn't a Go package for this and I'm
@@ -2,106 +2,351 @@ package archive
 
 import (
 	"archive/tar"
-	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // TarOpts configures options for Create/Extract tar
 type TarOpts func(*tarOpts)
 
-// TODO: add support for compressed files with bzip
 type tarOpts struct {
-	// allowRelative bool // allow relative paths outside of target folder
-	compress string
+	compress    CompressType
+	maxEntries  int
+	maxSize     int64
+	modTime     time.Time
+	owner       bool
+	stripXattrs bool
+	symlinks    SymlinkPolicy
+	zeroOwner   bool
+}
+
+// SymlinkPolicy defines how symlinks are handled by [Tar] and [Extract].
+type SymlinkPolicy int
+
+const (
+	// SymlinkWithin preserves symlinks with a relative target inside of the path, and fails on other symlinks (default).
+	SymlinkWithin SymlinkPolicy = iota
+	// SymlinkSkip does not include any symlinks.
+	SymlinkSkip
+	// SymlinkAllow preserves all symlinks, including absolute targets and targets outside of the path.
+	SymlinkAllow
+	// SymlinkDeny fails on any symlink.
+	SymlinkDeny
+	// SymlinkFollow adds the target of each symlink in place of the link with [Tar].
+	// Extract handles this the same as [SymlinkWithin].
+	SymlinkFollow
+)
+
+// TarWithMaxEntries limits the number of entries in a tar for Extract.
+func TarWithMaxEntries(n int) TarOpts {
+	return func(to *tarOpts) {
+		to.maxEntries = n
+	}
+}
+
+// TarWithMaxSize limits the total size of the files in a tar for Extract.
+// The limit applies to the uncompressed content, protecting against compression bombs.
+func TarWithMaxSize(n int64) TarOpts {
+	return func(to *tarOpts) {
+		to.maxSize = n
+	}
+}
+
+// TarWithOwner sets the owner of extracted files from the tar headers and keeps the setuid, setgid, and sticky bits.
+// This typically requires running as root.
+func TarWithOwner(to *tarOpts) {
+	to.owner = true
+}
+
+// TarWithSymlinks sets the policy for symlinks in Tar and Extract.
+func TarWithSymlinks(policy SymlinkPolicy) TarOpts {
+	return func(to *tarOpts) {
+		to.symlinks = policy
+	}
 }
 
 // TarCompressGzip option to use gzip compression on tar files
 func TarCompressGzip(to *tarOpts) {
-	to.compress = "gzip"
+	to.compress = CompressGzip
+}
+
+// TarCompressXz option to use xz compression on tar files
+func TarCompressXz(to *tarOpts) {
+	to.compress = CompressXz
 }
 
 // TarUncompressed option to tar (noop)
 func TarUncompressed(to *tarOpts) {
 }
 
+// TarReproducible option to create tar files with the same digest on any machine.
+// This sets the owner to root, and strips extended attributes.
+// Combine with [TarWithModTime] and [SourceDateEpoch] to also fix the timestamps.
+func TarReproducible(to *tarOpts) {
+	to.stripXattrs = true
+	to.zeroOwner = true
+}
+
+// TarStripXattrs option to remove extended attributes from tar entries
+func TarStripXattrs(to *tarOpts) {
+	to.stripXattrs = true
+}
+
+// TarWithModTime sets the modification time of every tar entry.
+// A zero time leaves the time of each file unchanged.
+func TarWithModTime(t time.Time) TarOpts {
+	return func(to *tarOpts) {
+		to.modTime = t
+	}
+}
+
+// TarZeroOwner option to set the uid and gid of tar entries to 0 and remove the user and group names
+func TarZeroOwner(to *tarOpts) {
+	to.zeroOwner = true
+}
+
+// SourceDateEpoch returns the time from the SOURCE_DATE_EPOCH environment variable.
+// A zero time is returned when the variable is not set.
+func SourceDateEpoch() (time.Time, error) {
+	sec := os.Getenv("SOURCE_DATE_EPOCH")
+	if sec == "" {
+		return time.Time{}, nil
+	}
+	secI, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse SOURCE_DATE_EPOCH %s: %w", sec, err)
+	}
+	return time.Unix(secI, 0).UTC(), nil
+}
+
+// TarHeader applies the header options of [Tar] to an existing tar header.
+// This supports [TarWithModTime], [TarStripXattrs], and [TarZeroOwner], and returns true if the header was changed.
+func TarHeader(th *tar.Header, opts ...TarOpts) bool {
+	to := tarOpts{}
+	for _, opt := range opts {
+		opt(&to)
+	}
+	return to.header(th)
+}
+
+func (to tarOpts) header(th *tar.Header) bool {
+	changed := false
+	if to.zeroOwner && (th.Uid != 0 || th.Gid != 0 || th.Uname != "" || th.Gname != "") {
+		th.Uid, th.Gid, th.Uname, th.Gname = 0, 0, "", ""
+		changed = true
+	}
+	if !to.modTime.IsZero() {
+		if !th.ModTime.Equal(to.modTime) {
+			th.ModTime = to.modTime
+			changed = true
+		}
+		if !th.AccessTime.IsZero() || !th.ChangeTime.IsZero() {
+			th.AccessTime, th.ChangeTime = time.Time{}, time.Time{}
+			changed = true
+		}
+	}
+	if to.stripXattrs {
+		//lint:ignore SA1019 deprecated Xattrs are still parsed by archive/tar
+		if len(th.Xattrs) > 0 {
+			//lint:ignore SA1019 deprecated Xattrs are still parsed by archive/tar
+			th.Xattrs = nil
+			changed = true
+		}
+		for k := range th.PAXRecords {
+			if strings.HasPrefix(k, "SCHILY.xattr.") {
+				delete(th.PAXRecords, k)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
 // TODO: add option for full path or to adjust the relative path
 
-// Tar creation
+// Tar creation.
+// Entries are added in lexical order of the path, see [TarReproducible] for other options to create reproducible tar files.
+// Symlinks are handled according to [TarWithSymlinks], and files with multiple links to the same inode are added as hard links.
 func Tar(ctx context.Context, path string, w io.Writer, opts ...TarOpts) error {
 	to := tarOpts{}
 	for _, opt := range opts {
 		opt(&to)
 	}
 
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("tar path must be a directory: \"%s\"", path)
+	}
+
 	twOut := w
-	if to.compress == "gzip" {
-		gw := gzip.NewWriter(w)
-		defer gw.Close()
-		twOut = gw
+	if to.compress != CompressNone {
+		cw, err := NewCompressWriter(w, to.compress)
+		if err != nil {
+			return err
+		}
+		defer cw.Close()
+		twOut = cw
 	}
 
 	tw := tar.NewWriter(twOut)
 	defer tw.Close()
 
 	// walk the path performing a recursive tar
-	err := filepath.Walk(path, func(file string, fi os.FileInfo, err error) error {
-		// return any errors filepath encounters accessing the file
-		if err != nil {
-			return err
-		}
+	tc := tarCreate{
+		to:      to,
+		tw:      tw,
+		root:    root,
+		links:   map[tarFileID]string{},
+		parents: map[string]bool{root: true},
+	}
+	return tc.dir(ctx, root, "")
+}
 
-		// TODO: handle symlinks, security attributes, hard links
-		// TODO: add options for file owner and timestamps
-		// TODO: add options to override time, or disable access/change stamps
+type tarCreate struct {
+	to      tarOpts
+	tw      *tar.Writer
+	root    string
+	links   map[tarFileID]string // name of the first entry for each inode with multiple links
+	parents map[string]bool      // directories being walked, used to detect loops when following symlinks
+}
 
-		// adjust for relative path
-		relPath, err := filepath.Rel(path, file)
-		if err != nil || relPath == "." {
-			return nil
+// dir adds the content of a directory to the tar, sorted by name.
+func (tc *tarCreate) dir(ctx context.Context, dir, rel string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-
-		header, err := tar.FileInfoHeader(fi, relPath)
+		file := filepath.Join(dir, e.Name())
+		name := e.Name()
+		if rel != "" {
+			name = rel + "/" + name
+		}
+		fi, err := os.Lstat(file)
 		if err != nil {
 			return err
 		}
-
-		header.Format = tar.FormatPAX
-		header.Name = filepath.ToSlash(relPath)
-		header.AccessTime = time.Time{}
-		header.ChangeTime = time.Time{}
-		header.ModTime = header.ModTime.Truncate(time.Second)
-
-		if err = tw.WriteHeader(header); err != nil {
+		err = tc.add(ctx, file, name, fi)
+		if err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		// open file and copy contents into tar writer
-		if header.Typeflag == tar.TypeReg && header.Size > 0 {
-			//#nosec G304 filename is limited to provided path directory
-			f, err := os.Open(file)
-			if err != nil {
-				return err
-			}
-			if _, err = io.Copy(tw, f); err != nil {
-				return err
+// add writes a single entry to the tar, recursing into directories.
+func (tc *tarCreate) add(ctx context.Context, file, name string, fi fs.FileInfo) error {
+	link := ""
+	if fi.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(file)
+		if err != nil {
+			return err
+		}
+		switch tc.to.symlinks {
+		case SymlinkSkip:
+			return nil
+		case SymlinkDeny:
+			return fmt.Errorf("symlink \"%s\" is not allowed%.0w", name, ErrUnsafePath)
+		case SymlinkWithin:
+			if filepath.IsAbs(target) || !extractWithin(tc.root, filepath.Join(filepath.Dir(file), target)) {
+				return fmt.Errorf("symlink \"%s\" to \"%s\" is outside of the tar path%.0w", name, target, ErrUnsafePath)
 			}
-			err = f.Close()
+			link = target
+		case SymlinkAllow:
+			link = target
+		case SymlinkFollow:
+			fi, err = os.Stat(file)
 			if err != nil {
-				return fmt.Errorf("failed to close file: %w", err)
+				return fmt.Errorf("failed to follow symlink \"%s\": %w", name, err)
 			}
 		}
-		return nil
-	})
-	return err
+	}
+
+	header, err := tar.FileInfoHeader(fi, filepath.ToSlash(link))
+	if err != nil {
+		return err
+	}
+	header.Format = tar.FormatPAX
+	header.Name = name
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.ModTime = header.ModTime.Truncate(time.Second)
+	if fi.Mode().IsRegular() {
+		if id, ok := tarFileLinkID(fi); ok {
+			if first, ok := tc.links[id]; ok {
+				header.Typeflag = tar.TypeLink
+				header.Linkname = first
+				header.Size = 0
+			} else {
+				tc.links[id] = name
+			}
+		}
+	}
+	tc.to.header(header)
+
+	if err = tc.tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	// open file and copy contents into tar writer
+	if header.Typeflag == tar.TypeReg && header.Size > 0 {
+		//#nosec G304 filename is limited to provided path directory
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(tc.tw, f); err != nil {
+			_ = f.Close()
+			return err
+		}
+		err = f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to close file: %w", err)
+		}
+	}
+	if fi.IsDir() {
+		realDir, err := filepath.EvalSymlinks(file)
+		if err != nil {
+			return err
+		}
+		if tc.parents[realDir] {
+			return fmt.Errorf("symlink loop at \"%s\"%.0w", name, ErrUnsafePath)
+		}
+		tc.parents[realDir] = true
+		defer delete(tc.parents, realDir)
+		return tc.dir(ctx, file, name)
+	}
+	return nil
 }
 
-// Extract Tar
+// Extract a tar into a directory.
+// The compression of the tar is detected, see [Decompress].
+// Entries are limited to the path, and are never written through a symlinked parent directory.
+// Symlink targets are resolved against the extracted content when checking [SymlinkWithin].
+// File permissions and modification times are set from the tar, while ownership requires [TarWithOwner].
+// Limit the content with [TarWithMaxEntries] and [TarWithMaxSize] when extracting untrusted tars.
 func Extract(ctx context.Context, path string, r io.Reader, opts ...TarOpts) error {
 	to := tarOpts{}
 	for _, opt := range opts {
@@ -116,6 +361,14 @@ func Extract(ctx context.Context, path string, r io.Reader, opts ...TarOpts) err
 	if !fi.IsDir() {
 		return fmt.Errorf("extract path must be a directory: \"%s\"", path)
 	}
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
 
 	// decompress
 	rd, err := Decompress(r)
@@ -123,8 +376,18 @@ func Extract(ctx context.Context, path string, r io.Reader, opts ...TarOpts) err
 		return err
 	}
 
+	type dirTime struct {
+		name    string
+		modTime time.Time
+	}
+	dirTimes := []dirTime{}
+	entries := 0
+	var size int64
 	rt := tar.NewReader(rd)
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		hdr, err := rt.Next()
 		if err == io.EOF {
 			break
@@ -132,35 +395,228 @@ func Extract(ctx context.Context, path string, r io.Reader, opts ...TarOpts) err
 		if err != nil {
 			return err
 		}
+		entries++
+		if to.maxEntries > 0 && entries > to.maxEntries {
+			return fmt.Errorf("tar exceeds %d entries%.0w", to.maxEntries, ErrLimitExceeded)
+		}
 		// join a cleaned version of the filename with the path
-		fn := filepath.Join(path, filepath.Clean("/"+hdr.Name))
+		fn := filepath.Join(root, filepath.Clean("/"+hdr.Name))
+		if fn == root {
+			continue
+		}
+		err = extractParent(root, fn)
+		if err != nil {
+			return err
+		}
+		mode := hdr.FileInfo().Mode()
+		if !to.owner {
+			mode = mode & fs.ModePerm
+		}
 		switch hdr.Typeflag {
 		case tar.TypeDir:
-			err = os.MkdirAll(fn, fs.FileMode(hdr.Mode))
+			err = extractDir(fn, mode)
 			if err != nil {
 				return err
 			}
+			dirTimes = append(dirTimes, dirTime{name: fn, modTime: hdr.ModTime})
 		case tar.TypeReg:
-			// TODO: configure file mode, creation timestamp, etc
-			//#nosec G304 filename is limited to provided path directory
-			fh, err := os.Create(fn)
+			size += hdr.Size
+			if to.maxSize > 0 && size > to.maxSize {
+				return fmt.Errorf("tar exceeds %d bytes%.0w", to.maxSize, ErrLimitExceeded)
+			}
+			err = extractFile(fn, mode, rt, hdr.Size)
+			if err != nil {
+				return fmt.Errorf("failed to extract \"%s\": %w", hdr.Name, err)
+			}
+			err = os.Chtimes(fn, hdr.ModTime, hdr.ModTime)
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			switch to.symlinks {
+			case SymlinkSkip:
+				continue
+			case SymlinkDeny:
+				return fmt.Errorf("symlink \"%s\" is not allowed%.0w", hdr.Name, ErrUnsafePath)
+			case SymlinkWithin, SymlinkFollow:
+				if !extractLinkWithin(root, filepath.Dir(fn), hdr.Linkname) {
+					return fmt.Errorf("symlink \"%s\" to \"%s\" is outside of the extract path%.0w", hdr.Name, hdr.Linkname, ErrUnsafePath)
+				}
+			}
+			err = extractRemove(fn)
 			if err != nil {
 				return err
 			}
-			n, err := io.CopyN(fh, rt, hdr.Size)
-			errC := fh.Close()
+			err = os.Symlink(hdr.Linkname, fn)
 			if err != nil {
 				return err
 			}
-			if errC != nil {
-				return fmt.Errorf("failed to close file: %w", errC)
+		case tar.TypeLink:
+			target := filepath.Join(root, filepath.Clean("/"+hdr.Linkname))
+			err = extractParent(root, target)
+			if err != nil {
+				return err
+			}
+			tfi, err := os.Lstat(target)
+			if err != nil {
+				return fmt.Errorf("hard link \"%s\" target is missing: %w", hdr.Name, err)
+			}
+			if !tfi.Mode().IsRegular() {
+				return fmt.Errorf("hard link \"%s\" target \"%s\" is not a file%.0w", hdr.Name, hdr.Linkname, ErrUnsafePath)
 			}
-			if n != hdr.Size {
-				return fmt.Errorf("size mismatch extracting \"%s\", expected %d, extracted %d", hdr.Name, hdr.Size, n)
+			err = extractRemove(fn)
+			if err != nil {
+				return err
+			}
+			err = os.Link(target, fn)
+			if err != nil {
+				return err
+			}
+		default:
+			// devices and fifos are skipped
+			continue
+		}
+		if to.owner {
+			err = os.Lchown(fn, hdr.Uid, hdr.Gid)
+			if err != nil {
+				return err
 			}
-			// TODO: handle other tar types (symlinks, etc)
+		}
+	}
+	// directory times are set last since the content of a directory changes the time
+	for i := len(dirTimes) - 1; i >= 0; i-- {
+		err = os.Chtimes(dirTimes[i].name, dirTimes[i].modTime, dirTimes[i].modTime)
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
+
+// extractWithin returns true when the name is inside of the root directory.
+func extractWithin(root, name string) bool {
+	rel, err := filepath.Rel(root, name)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// extractLinkWithin returns true when a relative symlink target resolves inside of the root directory.
+// Existing symlinks in the target are followed, and a dangling symlink is treated as outside of the root.
+func extractLinkWithin(root, dir, link string) bool {
+	if filepath.IsAbs(link) {
+		return false
+	}
+	cur := dir
+	for _, part := range strings.Split(filepath.FromSlash(link), string(filepath.Separator)) {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			cur = filepath.Dir(cur)
+			continue
+		}
+		cur = filepath.Join(cur, part)
+		fi, err := os.Lstat(cur)
+		if err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+		cur, err = filepath.EvalSymlinks(cur)
+		if err != nil {
+			return false
+		}
+	}
+	return extractWithin(root, cur)
+}
+
+// extractParent verifies the parents of a file are directories and not symlinks, and creates any missing directories.
+func extractParent(root, fn string) error {
+	rel, err := filepath.Rel(root, filepath.Dir(fn))
+	if err != nil {
+		return err
+	}
+	cur := root
+	if rel == "." {
+		return nil
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		cur = filepath.Join(cur, part)
+		fi, err := os.Lstat(cur)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = os.Mkdir(cur, 0755)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("parent of \"%s\" is a symlink%.0w", fn, ErrUnsafePath)
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("parent of \"%s\" is not a directory%.0w", fn, ErrUnsafePath)
+		}
+	}
+	return nil
+}
+
+// extractRemove deletes an existing entry that is not a directory, so symlinks are replaced rather than followed.
+func extractRemove(fn string) error {
+	fi, err := os.Lstat(fn)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return os.RemoveAll(fn)
+	}
+	return os.Remove(fn)
+}
+
+func extractDir(fn string, mode fs.FileMode) error {
+	fi, err := os.Lstat(fn)
+	if err == nil && !fi.IsDir() {
+		err = os.Remove(fn)
+		if err != nil {
+			return err
+		}
+	} else if err == nil {
+		return os.Chmod(fn, mode)
+	}
+	err = os.Mkdir(fn, mode)
+	if err != nil {
+		return err
+	}
+	// the mode passed to mkdir is masked by the umask
+	return os.Chmod(fn, mode)
+}
+
+func extractFile(fn string, mode fs.FileMode, r io.Reader, size int64) error {
+	err := extractRemove(fn)
+	if err != nil {
+		return err
+	}
+	//#nosec G304 filename is limited to provided path directory
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	n, err := io.CopyN(fh, r, size)
+	errC := fh.Close()
+	if err != nil {
+		return err
+	}
+	if errC != nil {
+		return fmt.Errorf("failed to close file: %w", errC)
+	}
+	if n != size {
+		return fmt.Errorf("size mismatch, expected %d, extracted %d", size, n)
+	}
+	return os.Chmod(fn, mode)
+}
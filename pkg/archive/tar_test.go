@@ -0,0 +1,425 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	ctx := context.Background()
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	files := []diffTestFile{
+		{name: "dir/", mode: 0750, typ: tar.TypeDir, modTime: t1},
+		{name: "dir/file", mode: 0640, content: "hello", modTime: t1},
+		{name: "dir/link", typ: tar.TypeSymlink, link: "file", modTime: t1},
+		{name: "hard", typ: tar.TypeLink, link: "dir/file", modTime: t1},
+		{name: "nested/sub/file", mode: 0644, content: "nested", modTime: t1},
+	}
+
+	t.Run("Gzip", func(t *testing.T) {
+		dir := t.TempDir()
+		err := Extract(ctx, dir, diffTestTar(t, true, files))
+		if err != nil {
+			t.Fatalf("failed to extract: %v", err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "dir", "link"))
+		if err != nil || string(b) != "hello" {
+			t.Errorf("unexpected symlink content: %s, %v", b, err)
+		}
+		b, err = os.ReadFile(filepath.Join(dir, "hard"))
+		if err != nil || string(b) != "hello" {
+			t.Errorf("unexpected hard link content: %s, %v", b, err)
+		}
+		fi, err := os.Stat(filepath.Join(dir, "dir", "file"))
+		if err != nil {
+			t.Fatalf("failed to stat file: %v", err)
+		}
+		if fi.Mode().Perm() != 0640 || !fi.ModTime().Equal(t1) {
+			t.Errorf("unexpected file mode or time: %s, %s", fi.Mode(), fi.ModTime())
+		}
+		fi, err = os.Stat(filepath.Join(dir, "dir"))
+		if err != nil {
+			t.Fatalf("failed to stat dir: %v", err)
+		}
+		if fi.Mode().Perm() != 0750 || !fi.ModTime().Equal(t1) {
+			t.Errorf("unexpected dir mode or time: %s, %s", fi.Mode(), fi.ModTime())
+		}
+		b, err = os.ReadFile(filepath.Join(dir, "nested", "sub", "file"))
+		if err != nil || string(b) != "nested" {
+			t.Errorf("unexpected nested content: %s, %v", b, err)
+		}
+	})
+
+	t.Run("Xz", func(t *testing.T) {
+		src := t.TempDir()
+		err := Extract(ctx, src, diffTestTar(t, false, files))
+		if err != nil {
+			t.Fatalf("failed to extract: %v", err)
+		}
+		buf := &bytes.Buffer{}
+		err = Tar(ctx, src, buf, TarCompressXz)
+		if err != nil {
+			t.Fatalf("failed to create tar: %v", err)
+		}
+		if DetectCompression(buf.Bytes()) != CompressXz {
+			t.Errorf("tar is not xz compressed")
+		}
+		dir := t.TempDir()
+		err = Extract(ctx, dir, buf)
+		if err != nil {
+			t.Fatalf("failed to extract: %v", err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "nested", "sub", "file"))
+		if err != nil || string(b) != "nested" {
+			t.Errorf("unexpected nested content: %s, %v", b, err)
+		}
+	})
+
+	t.Run("Traversal", func(t *testing.T) {
+		parent := t.TempDir()
+		dir := filepath.Join(parent, "out")
+		err := os.Mkdir(dir, 0755)
+		if err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		err = Extract(ctx, dir, diffTestTar(t, false, []diffTestFile{
+			{name: "../../escape", mode: 0644, content: "bad"},
+		}))
+		if err != nil {
+			t.Fatalf("failed to extract: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(parent, "escape")); err == nil {
+			t.Errorf("file was written outside of the extract path")
+		}
+		if _, err := os.Stat(filepath.Join(dir, "escape")); err != nil {
+			t.Errorf("file was not written inside of the extract path: %v", err)
+		}
+	})
+
+	tt := []struct {
+		name      string
+		files     []diffTestFile
+		opts      []TarOpts
+		expectErr error
+		missing   string
+	}{
+		{
+			name: "symlink outside",
+			files: []diffTestFile{
+				{name: "link", typ: tar.TypeSymlink, link: "../../etc"},
+			},
+			expectErr: ErrUnsafePath,
+		},
+		{
+			name: "symlink absolute",
+			files: []diffTestFile{
+				{name: "link", typ: tar.TypeSymlink, link: "/etc/passwd"},
+			},
+			expectErr: ErrUnsafePath,
+		},
+		{
+			name: "write through symlink",
+			files: []diffTestFile{
+				{name: "link", typ: tar.TypeSymlink, link: "/tmp"},
+				{name: "link/escape", mode: 0644, content: "bad"},
+			},
+			opts:      []TarOpts{TarWithSymlinks(SymlinkAllow)},
+			expectErr: ErrUnsafePath,
+		},
+		{
+			name: "write through symlink inside",
+			files: []diffTestFile{
+				{name: "dir/", mode: 0755, typ: tar.TypeDir},
+				{name: "link", typ: tar.TypeSymlink, link: "dir"},
+				{name: "link/file", mode: 0644, content: "bad"},
+			},
+			expectErr: ErrUnsafePath,
+		},
+		{
+			name: "symlink resolves outside",
+			files: []diffTestFile{
+				{name: "self", typ: tar.TypeSymlink, link: "."},
+				{name: "escape", typ: tar.TypeSymlink, link: "self/.."},
+			},
+			expectErr: ErrUnsafePath,
+		},
+		{
+			name: "symlink through nested link",
+			files: []diffTestFile{
+				{name: "a/b/", mode: 0755, typ: tar.TypeDir},
+				{name: "deep", typ: tar.TypeSymlink, link: "a/b"},
+				{name: "up", typ: tar.TypeSymlink, link: "deep/../.."},
+			},
+		},
+		{
+			name: "symlink deny",
+			files: []diffTestFile{
+				{name: "link", typ: tar.TypeSymlink, link: "file"},
+			},
+			opts:      []TarOpts{TarWithSymlinks(SymlinkDeny)},
+			expectErr: ErrUnsafePath,
+		},
+		{
+			name: "symlink skip",
+			files: []diffTestFile{
+				{name: "link", typ: tar.TypeSymlink, link: "/etc/passwd"},
+			},
+			opts:    []TarOpts{TarWithSymlinks(SymlinkSkip)},
+			missing: "link",
+		},
+		{
+			name:      "max entries",
+			files:     files,
+			opts:      []TarOpts{TarWithMaxEntries(3)},
+			expectErr: ErrLimitExceeded,
+		},
+		{
+			name:      "max size",
+			files:     files,
+			opts:      []TarOpts{TarWithMaxSize(8)},
+			expectErr: ErrLimitExceeded,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			err := Extract(ctx, dir, diffTestTar(t, false, tc.files), tc.opts...)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to extract: %v", err)
+			}
+			if tc.missing != "" {
+				if _, err := os.Lstat(filepath.Join(dir, tc.missing)); err == nil {
+					t.Errorf("unexpected file: %s", tc.missing)
+				}
+			}
+		})
+	}
+}
+
+func TestTarReproducible(t *testing.T) {
+	ctx := context.Background()
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	files := []diffTestFile{
+		{name: "b/", mode: 0755, typ: tar.TypeDir},
+		{name: "b/file", mode: 0644, content: "b"},
+		{name: "a", mode: 0644, content: "a"},
+	}
+	tarDir := func(t *testing.T) []byte {
+		t.Helper()
+		dir := t.TempDir()
+		err := Extract(ctx, dir, diffTestTar(t, false, files))
+		if err != nil {
+			t.Fatalf("failed to extract: %v", err)
+		}
+		buf := &bytes.Buffer{}
+		err = Tar(ctx, dir, buf, TarCompressGzip, TarReproducible, TarWithModTime(t1))
+		if err != nil {
+			t.Fatalf("failed to create tar: %v", err)
+		}
+		return buf.Bytes()
+	}
+	b1 := tarDir(t)
+	b2 := tarDir(t)
+	if !bytes.Equal(b1, b2) {
+		t.Errorf("tar content is not reproducible")
+	}
+	dr, err := Decompress(bytes.NewReader(b1))
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	tr := tar.NewReader(dr)
+	names := []string{}
+	for {
+		th, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, th.Name)
+		if th.Uid != 0 || th.Gid != 0 || th.Uname != "" || th.Gname != "" || !th.ModTime.Equal(t1) {
+			t.Errorf("unexpected header for %s: %d/%d %s/%s %s", th.Name, th.Uid, th.Gid, th.Uname, th.Gname, th.ModTime)
+		}
+	}
+	if len(names) != 3 || names[0] != "a" || names[1] != "b" || names[2] != "b/file" {
+		t.Errorf("unexpected order of entries: %v", names)
+	}
+
+	th := &tar.Header{
+		Name:       "file",
+		Uid:        1000,
+		Uname:      "user",
+		ModTime:    time.Now(),
+		PAXRecords: map[string]string{"SCHILY.xattr.user.test": "value", "comment": "keep"},
+	}
+	if !TarHeader(th, TarReproducible) {
+		t.Errorf("header was not changed")
+	}
+	if th.Uid != 0 || th.Uname != "" || len(th.PAXRecords) != 1 || th.ModTime.Equal(t1) {
+		t.Errorf("unexpected header: %v", th)
+	}
+	if TarHeader(th, TarReproducible) {
+		t.Errorf("header changed a second time")
+	}
+}
+
+func TestTarLinks(t *testing.T) {
+	ctx := context.Background()
+	src := t.TempDir()
+	err := os.MkdirAll(filepath.Join(src, "dir"), 0755)
+	if err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(src, "dir", "file"), []byte("hello"), 0644)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	err = os.Link(filepath.Join(src, "dir", "file"), filepath.Join(src, "hard"))
+	if err != nil {
+		t.Fatalf("failed to create hard link: %v", err)
+	}
+	err = os.Symlink("dir/file", filepath.Join(src, "link"))
+	if err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	outside := t.TempDir()
+	err = os.WriteFile(filepath.Join(outside, "secret"), []byte("outside"), 0644)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	srcAbs := t.TempDir()
+	err = os.Symlink(filepath.Join(outside, "secret"), filepath.Join(srcAbs, "abs"))
+	if err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	srcLoop := t.TempDir()
+	err = os.Symlink(".", filepath.Join(srcLoop, "loop"))
+	if err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	tarEntries := func(t *testing.T, b []byte) map[string]*tar.Header {
+		t.Helper()
+		entries := map[string]*tar.Header{}
+		tr := tar.NewReader(bytes.NewReader(b))
+		for {
+			th, err := tr.Next()
+			if err != nil {
+				break
+			}
+			entries[th.Name] = th
+		}
+		return entries
+	}
+
+	t.Run("Preserve", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := Tar(ctx, src, buf)
+		if err != nil {
+			t.Fatalf("failed to create tar: %v", err)
+		}
+		entries := tarEntries(t, buf.Bytes())
+		if th, ok := entries["link"]; !ok || th.Typeflag != tar.TypeSymlink || th.Linkname != "dir/file" {
+			t.Errorf("unexpected symlink entry: %v", th)
+		}
+		if th, ok := entries["hard"]; !ok || th.Typeflag != tar.TypeLink || th.Linkname != "dir/file" {
+			t.Errorf("unexpected hard link entry: %v", th)
+		}
+		// round trip the content
+		dir := t.TempDir()
+		err = Extract(ctx, dir, buf)
+		if err != nil {
+			t.Fatalf("failed to extract: %v", err)
+		}
+		target, err := os.Readlink(filepath.Join(dir, "link"))
+		if err != nil || target != "dir/file" {
+			t.Errorf("unexpected symlink target: %s, %v", target, err)
+		}
+		fi1, err1 := os.Stat(filepath.Join(dir, "hard"))
+		fi2, err2 := os.Stat(filepath.Join(dir, "dir", "file"))
+		if err1 != nil || err2 != nil || !os.SameFile(fi1, fi2) {
+			t.Errorf("hard link was not extracted: %v, %v", err1, err2)
+		}
+	})
+
+	t.Run("Follow", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := Tar(ctx, srcAbs, buf, TarWithSymlinks(SymlinkFollow))
+		if err != nil {
+			t.Fatalf("failed to create tar: %v", err)
+		}
+		entries := tarEntries(t, buf.Bytes())
+		if th, ok := entries["abs"]; !ok || th.Typeflag != tar.TypeReg || th.Size != int64(len("outside")) {
+			t.Errorf("unexpected followed entry: %v", th)
+		}
+	})
+
+	tt := []struct {
+		name      string
+		path      string
+		opts      []TarOpts
+		expectErr error
+		missing   string
+	}{
+		{
+			name:      "absolute rejected",
+			path:      srcAbs,
+			expectErr: ErrUnsafePath,
+		},
+		{
+			name: "absolute allowed",
+			path: srcAbs,
+			opts: []TarOpts{TarWithSymlinks(SymlinkAllow)},
+		},
+		{
+			name:    "skip",
+			path:    src,
+			opts:    []TarOpts{TarWithSymlinks(SymlinkSkip)},
+			missing: "link",
+		},
+		{
+			name:      "deny",
+			path:      src,
+			opts:      []TarOpts{TarWithSymlinks(SymlinkDeny)},
+			expectErr: ErrUnsafePath,
+		},
+		{
+			name:      "loop",
+			path:      srcLoop,
+			opts:      []TarOpts{TarWithSymlinks(SymlinkFollow)},
+			expectErr: ErrUnsafePath,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := Tar(ctx, tc.path, buf, tc.opts...)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to create tar: %v", err)
+			}
+			if tc.missing != "" {
+				if _, ok := tarEntries(t, buf.Bytes())[tc.missing]; ok {
+					t.Errorf("unexpected entry: %s", tc.missing)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package archive
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+type tarFileID struct {
+	dev uint64
+	ino uint64
+}
+
+// tarFileLinkID returns the device and inode of a file with more than one link.
+func tarFileLinkID(fi fs.FileInfo) (tarFileID, bool) {
+	sysstat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || sysstat.Nlink <= 1 {
+		return tarFileID{}, false
+	}
+	return tarFileID{dev: uint64(sysstat.Dev), ino: uint64(sysstat.Ino)}, true
+}
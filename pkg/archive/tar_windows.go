@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package archive
+
+import (
+	"io/fs"
+)
+
+type tarFileID struct{}
+
+// tarFileLinkID does not detect hard links on windows.
+func tarFileLinkID(fi fs.FileInfo) (tarFileID, bool) {
+	return tarFileID{}, false
+}
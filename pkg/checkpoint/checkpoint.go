@@ -0,0 +1,149 @@
+// Package checkpoint records content that has been copied to a repository, allowing an interrupted copy to resume
+package checkpoint
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// Checkpoint tracks the digests that have been copied to each repository.
+// Implementations must be safe for concurrent use.
+type Checkpoint interface {
+	// Has returns true when the digest was previously added for the repository.
+	Has(repo string, d digest.Digest) bool
+	// Add records the digest as copied to the repository.
+	Add(repo string, d digest.Digest) error
+}
+
+// Memory is a Checkpoint held in memory, useful for retries within a single process.
+type Memory struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// NewMemory returns an empty in-memory Checkpoint.
+func NewMemory() *Memory {
+	return &Memory{
+		seen: map[string]bool{},
+	}
+}
+
+// Has returns true when the digest was previously added for the repository.
+func (m *Memory) Has(repo string, d digest.Digest) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.seen[key(repo, d)]
+}
+
+// Add records the digest as copied to the repository.
+func (m *Memory) Add(repo string, d digest.Digest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seen[key(repo, d)] = true
+	return nil
+}
+
+// File is a Checkpoint persisted to a file, allowing a copy to resume after the process exits.
+// Each entry is appended to the file as a line with the digest and repository.
+type File struct {
+	mu   sync.Mutex
+	fh   *os.File
+	seen map[string]bool
+}
+
+// NewFile opens or creates the checkpoint file, loading any existing entries.
+// Incomplete or invalid lines, e.g. from a crash during a write, are ignored.
+func NewFile(filename string) (*File, error) {
+	//#nosec G304 file is a user provided path
+	fh, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint %s: %w", filename, err)
+	}
+	f := &File{
+		fh:   fh,
+		seen: map[string]bool{},
+	}
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		d, err := digest.Parse(fields[0])
+		if err != nil {
+			continue
+		}
+		f.seen[key(fields[1], d)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		_ = fh.Close()
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", filename, err)
+	}
+	// terminate an incomplete line so the next entry is not appended to it
+	fi, err := fh.Stat()
+	if err != nil {
+		_ = fh.Close()
+		return nil, err
+	}
+	if fi.Size() > 0 {
+		last := make([]byte, 1)
+		_, err = fh.ReadAt(last, fi.Size()-1)
+		if err == nil && last[0] != '\n' {
+			_, err = fh.Write([]byte("\n"))
+		}
+		if err != nil {
+			_ = fh.Close()
+			return nil, fmt.Errorf("failed to repair checkpoint %s: %w", filename, err)
+		}
+	}
+	return f, nil
+}
+
+// Has returns true when the digest was previously added for the repository.
+func (f *File) Has(repo string, d digest.Digest) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seen[key(repo, d)]
+}
+
+// Add records the digest as copied to the repository, appending it to the file.
+func (f *File) Add(repo string, d digest.Digest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := key(repo, d)
+	if f.seen[k] {
+		return nil
+	}
+	_, err := fmt.Fprintf(f.fh, "%s %s\n", d.String(), repo)
+	if err != nil {
+		return err
+	}
+	f.seen[k] = true
+	return nil
+}
+
+// Reset removes all entries, used after a copy completes so the next copy checks all content.
+func (f *File) Reset() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.fh.Truncate(0)
+	if err != nil {
+		return err
+	}
+	f.seen = map[string]bool{}
+	return nil
+}
+
+// Close closes the checkpoint file.
+func (f *File) Close() error {
+	return f.fh.Close()
+}
+
+func key(repo string, d digest.Digest) string {
+	return repo + "@" + d.String()
+}
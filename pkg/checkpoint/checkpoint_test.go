@@ -0,0 +1,97 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+	cp := NewMemory()
+	d := digest.FromString("test")
+	if cp.Has("registry.example.org/repo", d) {
+		t.Errorf("empty checkpoint has digest")
+	}
+	err := cp.Add("registry.example.org/repo", d)
+	if err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if !cp.Has("registry.example.org/repo", d) {
+		t.Errorf("digest missing after add")
+	}
+	if cp.Has("registry.example.org/other", d) {
+		t.Errorf("digest found in a different repository")
+	}
+}
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+	filename := filepath.Join(t.TempDir(), "checkpoint")
+	d1 := digest.FromString("test 1")
+	d2 := digest.FromString("test 2")
+	repo := "registry.example.org/repo"
+	cp, err := NewFile(filename)
+	if err != nil {
+		t.Fatalf("failed to create checkpoint: %v", err)
+	}
+	err = cp.Add(repo, d1)
+	if err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	err = cp.Add(repo, d1)
+	if err != nil {
+		t.Fatalf("failed to add duplicate: %v", err)
+	}
+	err = cp.Close()
+	if err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	// simulate a crash in the middle of a write
+	fh, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("failed to open checkpoint: %v", err)
+	}
+	_, _ = fh.WriteString(d2.String()[:20])
+	_ = fh.Close()
+
+	cp, err = NewFile(filename)
+	if err != nil {
+		t.Fatalf("failed to reopen checkpoint: %v", err)
+	}
+	if !cp.Has(repo, d1) {
+		t.Errorf("digest missing after reopen")
+	}
+	if cp.Has(repo, d2) {
+		t.Errorf("partial entry was loaded")
+	}
+	err = cp.Add(repo, d2)
+	if err != nil {
+		t.Fatalf("failed to add after partial entry: %v", err)
+	}
+	_ = cp.Close()
+	cp, err = NewFile(filename)
+	if err != nil {
+		t.Fatalf("failed to reopen checkpoint: %v", err)
+	}
+	defer cp.Close()
+	if !cp.Has(repo, d1) || !cp.Has(repo, d2) {
+		t.Errorf("digests missing after reopen")
+	}
+	err = cp.Reset()
+	if err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	if cp.Has(repo, d1) {
+		t.Errorf("digest found after reset")
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read checkpoint: %v", err)
+	}
+	if len(b) != 0 {
+		t.Errorf("checkpoint file not empty after reset: %s", string(b))
+	}
+}
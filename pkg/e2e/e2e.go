@@ -0,0 +1,224 @@
+// Package e2e runs end-to-end tests of regclient against a registry.
+//
+// [Start] launches a throwaway registry, which is the in-memory [regtest] registry by default,
+// a distribution "registry" binary with [WithBinary], or an already running registry with [WithHost].
+// [Flows] returns the copy, mod, and artifact flows, and [Run] runs each as a subtest,
+// which allows downstream projects to reuse the flows in their own integration tests.
+//
+//	func TestRegistry(t *testing.T) {
+//		ctx := context.Background()
+//		reg, err := e2e.Start(ctx, e2e.WithEnv())
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		defer reg.Close()
+//		repo, _ := reg.Ref("e2e/test")
+//		e2e.Run(t, reg.RegClient(), repo)
+//	}
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/pkg/regtest"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	// EnvHost is the environment variable with the host of a running registry, used by [WithEnv].
+	EnvHost = "REGCLIENT_E2E_HOST"
+	// EnvBinary is the environment variable with the path to a distribution registry binary, used by [WithEnv].
+	EnvBinary = "REGCLIENT_E2E_REGISTRY_BIN"
+
+	// startTimeout limits the time to wait for a registry binary to respond.
+	startTimeout = 30 * time.Second
+)
+
+// Registry is a registry used for end-to-end tests.
+type Registry struct {
+	// Host is the hostname and port of the registry.
+	Host   string
+	server *httptest.Server
+	cmd    *exec.Cmd
+	dir    string
+}
+
+type opt struct {
+	host        string
+	binary      string
+	regtestOpts []regtest.Opts
+}
+
+// Opts configures the registry returned by [Start].
+type Opts func(*opt)
+
+// WithBinary runs a distribution registry binary with storage in a temporary directory.
+func WithBinary(binary string) Opts {
+	return func(o *opt) {
+		o.binary = binary
+	}
+}
+
+// WithEnv configures the registry from the [EnvHost] and [EnvBinary] environment variables.
+// The in-memory registry is used when neither is set.
+func WithEnv() Opts {
+	return func(o *opt) {
+		if host := os.Getenv(EnvHost); host != "" {
+			o.host = host
+		}
+		if binary := os.Getenv(EnvBinary); binary != "" {
+			o.binary = binary
+		}
+	}
+}
+
+// WithHost uses a registry that is already running, e.g. a container started by CI.
+// The registry is not modified on [Registry.Close], so tests should use a unique repository.
+func WithHost(host string) Opts {
+	return func(o *opt) {
+		o.host = host
+	}
+}
+
+// WithRegtestOpts passes options to the in-memory registry.
+func WithRegtestOpts(opts ...regtest.Opts) Opts {
+	return func(o *opt) {
+		o.regtestOpts = append(o.regtestOpts, opts...)
+	}
+}
+
+// Start launches the registry.
+// [Registry.Close] must be called to stop the registry.
+func Start(ctx context.Context, opts ...Opts) (*Registry, error) {
+	o := opt{}
+	for _, optFn := range opts {
+		optFn(&o)
+	}
+	switch {
+	case o.host != "":
+		return &Registry{Host: o.host}, nil
+	case o.binary != "":
+		return startBinary(ctx, o.binary)
+	default:
+		server := httptest.NewServer(regtest.New(o.regtestOpts...))
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			server.Close()
+			return nil, err
+		}
+		return &Registry{Host: u.Host, server: server}, nil
+	}
+}
+
+func startBinary(ctx context.Context, binary string) (*Registry, error) {
+	dir, err := os.MkdirTemp("", "regclient-e2e-")
+	if err != nil {
+		return nil, err
+	}
+	reg := &Registry{dir: dir}
+	// pick a free port for the registry
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		_ = reg.Close()
+		return nil, err
+	}
+	reg.Host = l.Addr().String()
+	_ = l.Close()
+	conf := fmt.Sprintf(`version: 0.1
+log:
+  level: warn
+storage:
+  filesystem:
+    rootdirectory: %s
+  delete:
+    enabled: true
+http:
+  addr: %s
+`, filepath.Join(dir, "data"), reg.Host)
+	confFile := filepath.Join(dir, "config.yml")
+	err = os.WriteFile(confFile, []byte(conf), 0600)
+	if err != nil {
+		_ = reg.Close()
+		return nil, err
+	}
+	//#nosec G204 the binary is provided by the caller
+	reg.cmd = exec.Command(binary, "serve", confFile)
+	err = reg.cmd.Start()
+	if err != nil {
+		_ = reg.Close()
+		return nil, fmt.Errorf("failed to run %s: %w", binary, err)
+	}
+	// wait for the registry to respond
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+reg.Host+"/v2/", nil)
+		if err != nil {
+			_ = reg.Close()
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusUnauthorized {
+				return reg, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			_ = reg.Close()
+			return nil, fmt.Errorf("registry did not start on %s%.0w", reg.Host, types.ErrUnavailable)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Close stops the registry and deletes any temporary storage.
+func (reg *Registry) Close() error {
+	var err error
+	if reg.server != nil {
+		reg.server.Close()
+	}
+	if reg.cmd != nil && reg.cmd.Process != nil {
+		err = reg.cmd.Process.Kill()
+		_ = reg.cmd.Wait()
+	}
+	if reg.dir != "" {
+		if errRm := os.RemoveAll(reg.dir); errRm != nil && err == nil {
+			err = errRm
+		}
+	}
+	return err
+}
+
+// RegClient returns a client configured to access the registry without TLS.
+// The request rate limit is raised for the local registry.
+func (reg *Registry) RegClient(opts ...regclient.Opt) *regclient.RegClient {
+	opts = append([]regclient.Opt{
+		regclient.WithConfigHost(config.Host{
+			Name:      reg.Host,
+			Hostname:  reg.Host,
+			TLS:       config.TLSDisabled,
+			ReqPerSec: 1000,
+		}),
+		regclient.WithRetryDelay(10*time.Millisecond, 100*time.Millisecond),
+	}, opts...)
+	return regclient.New(opts...)
+}
+
+// Ref returns a reference to a repository on the registry.
+func (reg *Registry) Ref(repo string) (ref.Ref, error) {
+	return ref.New(reg.Host + "/" + repo)
+}
@@ -0,0 +1,46 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/regclient/regclient/pkg/regtest"
+)
+
+func TestE2E(t *testing.T) {
+	ctx := context.Background()
+	tt := []struct {
+		name string
+		opts []Opts
+	}{
+		{
+			name: "env",
+			opts: []Opts{WithEnv()},
+		},
+		{
+			name: "referrers disabled",
+			opts: []Opts{WithRegtestOpts(regtest.WithReferrersDisabled())},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			reg, err := Start(ctx, tc.opts...)
+			if err != nil {
+				t.Fatalf("failed to start registry: %v", err)
+			}
+			defer reg.Close()
+			repo, err := reg.Ref("e2e/test")
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+			Run(t, reg.RegClient(), repo)
+		})
+	}
+}
+
+func TestStartBinary(t *testing.T) {
+	_, err := Start(context.Background(), WithBinary("/missing/registry"))
+	if err == nil {
+		t.Errorf("start did not fail with a missing binary")
+	}
+}
@@ -0,0 +1,36 @@
+package e2e_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/regclient/regclient/pkg/e2e"
+)
+
+func ExampleStart() {
+	ctx := context.Background()
+	// use the in-memory registry unless REGCLIENT_E2E_HOST or REGCLIENT_E2E_REGISTRY_BIN is set
+	reg, err := e2e.Start(ctx, e2e.WithEnv())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer reg.Close()
+	rc := reg.RegClient()
+	repo, err := reg.Ref("example/repo")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, f := range e2e.Flows() {
+		err = f.Run(ctx, rc, repo)
+		fmt.Printf("%s: %v\n", f.Name, err)
+	}
+	// Output:
+	// push: <nil>
+	// copy: <nil>
+	// mod: <nil>
+	// artifact: <nil>
+	// tag-delete: <nil>
+	// tag-delete-replace: <nil>
+}
@@ -0,0 +1,307 @@
+package e2e
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/mod"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Flow is an end-to-end test run against a repository.
+type Flow struct {
+	Name string
+	Run  func(ctx context.Context, rc *regclient.RegClient, repo ref.Ref) error
+}
+
+// Flows returns the end-to-end flows.
+// Each flow pushes its own content to tags in the repository, prefixed with the flow name.
+func Flows() []Flow {
+	return []Flow{
+		{Name: "push", Run: flowPush},
+		{Name: "copy", Run: flowCopy},
+		{Name: "mod", Run: flowMod},
+		{Name: "artifact", Run: flowArtifact},
+		{Name: "tag-delete", Run: flowTagDelete},
+		{Name: "tag-delete-replace", Run: flowTagDeleteReplace},
+	}
+}
+
+// Run runs each of the [Flows] as a subtest.
+func Run(t *testing.T, rc *regclient.RegClient, repo ref.Ref) {
+	t.Helper()
+	ctx := context.Background()
+	for _, f := range Flows() {
+		f := f
+		t.Run(f.Name, func(t *testing.T) {
+			err := f.Run(ctx, rc, repo)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// PushImage pushes a small single platform image with random content, returning the manifest descriptor.
+func PushImage(ctx context.Context, rc *regclient.RegClient, r ref.Ref) (types.Descriptor, error) {
+	rnd := make([]byte, 16)
+	_, err := rand.Read(rnd)
+	if err != nil {
+		return types.Descriptor{}, err
+	}
+	content := []byte(hex.EncodeToString(rnd) + "\n")
+	layerBuf := &bytes.Buffer{}
+	tw := tar.NewWriter(layerBuf)
+	err = tw.WriteHeader(&tar.Header{Name: "e2e.txt", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	if err != nil {
+		return types.Descriptor{}, err
+	}
+	_, err = tw.Write(content)
+	if err != nil {
+		return types.Descriptor{}, err
+	}
+	err = tw.Close()
+	if err != nil {
+		return types.Descriptor{}, err
+	}
+	layer := layerBuf.Bytes()
+	dLayer := types.Descriptor{
+		MediaType: types.MediaTypeOCI1Layer,
+		Digest:    digest.FromBytes(layer),
+		Size:      int64(len(layer)),
+	}
+	conf, err := json.Marshal(v1.Image{
+		Platform: platform.Platform{OS: "linux", Architecture: "amd64"},
+		RootFS:   v1.RootFS{Type: "layers", DiffIDs: []digest.Digest{dLayer.Digest}},
+	})
+	if err != nil {
+		return types.Descriptor{}, err
+	}
+	dConf := types.Descriptor{
+		MediaType: types.MediaTypeOCI1ImageConfig,
+		Digest:    digest.FromBytes(conf),
+		Size:      int64(len(conf)),
+	}
+	if _, err = rc.BlobPut(ctx, r, dLayer, bytes.NewReader(layer)); err != nil {
+		return types.Descriptor{}, fmt.Errorf("failed to push layer: %w", err)
+	}
+	if _, err = rc.BlobPut(ctx, r, dConf, bytes.NewReader(conf)); err != nil {
+		return types.Descriptor{}, fmt.Errorf("failed to push config: %w", err)
+	}
+	m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned: v1.ManifestSchemaVersion,
+		MediaType: types.MediaTypeOCI1Manifest,
+		Config:    dConf,
+		Layers:    []types.Descriptor{dLayer},
+	}))
+	if err != nil {
+		return types.Descriptor{}, err
+	}
+	err = rc.ManifestPut(ctx, r, m)
+	if err != nil {
+		return types.Descriptor{}, fmt.Errorf("failed to push manifest: %w", err)
+	}
+	return m.GetDescriptor(), nil
+}
+
+func flowPush(ctx context.Context, rc *regclient.RegClient, repo ref.Ref) error {
+	r := repo.SetTag("push")
+	d, err := PushImage(ctx, rc, r)
+	if err != nil {
+		return err
+	}
+	mh, err := rc.ManifestHead(ctx, r, regclient.WithManifestRequireDigest())
+	if err != nil {
+		return err
+	}
+	if mh.GetDescriptor().Digest != d.Digest {
+		return fmt.Errorf("digest mismatch, pushed %s, received %s%.0w", d.Digest, mh.GetDescriptor().Digest, types.ErrDigestMismatch)
+	}
+	return nil
+}
+
+func flowCopy(ctx context.Context, rc *regclient.RegClient, repo ref.Ref) error {
+	rSrc := repo.SetTag("copy-src")
+	d, err := PushImage(ctx, rc, rSrc)
+	if err != nil {
+		return err
+	}
+	// copy within the repository and to another repository
+	rTgts := []ref.Ref{repo.SetTag("copy-tgt")}
+	rOther := repo
+	rOther.Repository = repo.Repository + "-copy"
+	rTgts = append(rTgts, rOther.SetTag("copy-tgt"))
+	for _, rTgt := range rTgts {
+		err = rc.ImageCopy(ctx, rSrc, rTgt)
+		if err != nil {
+			return fmt.Errorf("failed to copy to %s: %w", rTgt.CommonName(), err)
+		}
+		mh, err := rc.ManifestHead(ctx, rTgt, regclient.WithManifestRequireDigest())
+		if err != nil {
+			return err
+		}
+		if mh.GetDescriptor().Digest != d.Digest {
+			return fmt.Errorf("digest mismatch on %s, expected %s, received %s%.0w", rTgt.CommonName(), d.Digest, mh.GetDescriptor().Digest, types.ErrDigestMismatch)
+		}
+	}
+	return nil
+}
+
+func flowMod(ctx context.Context, rc *regclient.RegClient, repo ref.Ref) error {
+	rSrc := repo.SetTag("mod-src")
+	_, err := PushImage(ctx, rc, rSrc)
+	if err != nil {
+		return err
+	}
+	rTgt := repo.SetTag("mod-tgt")
+	rOut, err := mod.Apply(ctx, rc, rSrc,
+		mod.WithRefTgt(rTgt),
+		mod.WithLabel("org.example.e2e", "mod"),
+		mod.WithAnnotation("org.example.e2e", "mod"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mod image: %w", err)
+	}
+	m, err := rc.ManifestGet(ctx, rOut)
+	if err != nil {
+		return err
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return fmt.Errorf("modified manifest is not an image%.0w", types.ErrUnsupportedMediaType)
+	}
+	ma, ok := m.(manifest.Annotator)
+	if !ok {
+		return fmt.Errorf("modified manifest does not support annotations%.0w", types.ErrUnsupportedMediaType)
+	}
+	annot, err := ma.GetAnnotations()
+	if err != nil {
+		return err
+	}
+	if annot["org.example.e2e"] != "mod" {
+		return fmt.Errorf("annotation missing from modified manifest%.0w", types.ErrMissingAnnotation)
+	}
+	dConf, err := mi.GetConfig()
+	if err != nil {
+		return err
+	}
+	conf, err := rc.BlobGetOCIConfig(ctx, rOut, dConf)
+	if err != nil {
+		return err
+	}
+	if conf.GetConfig().Config.Labels["org.example.e2e"] != "mod" {
+		return fmt.Errorf("label missing from modified config%.0w", types.ErrNotFound)
+	}
+	return nil
+}
+
+func flowArtifact(ctx context.Context, rc *regclient.RegClient, repo ref.Ref) error {
+	rSubject := repo.SetTag("artifact-subject")
+	dSubject, err := PushImage(ctx, rc, rSubject)
+	if err != nil {
+		return err
+	}
+	artifactType := "application/vnd.example.e2e"
+	content := []byte(`{"e2e":"artifact"}`)
+	dLayer := types.Descriptor{
+		MediaType: "application/json",
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	dConf := types.Descriptor{
+		MediaType: types.MediaTypeOCI1Empty,
+		Digest:    types.EmptyDigest,
+		Size:      int64(len(types.EmptyData)),
+	}
+	if _, err = rc.BlobPut(ctx, rSubject, dLayer, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("failed to push artifact: %w", err)
+	}
+	if _, err = rc.BlobPut(ctx, rSubject, dConf, bytes.NewReader(types.EmptyData)); err != nil {
+		return fmt.Errorf("failed to push config: %w", err)
+	}
+	m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned:    v1.ManifestSchemaVersion,
+		MediaType:    types.MediaTypeOCI1Manifest,
+		ArtifactType: artifactType,
+		Config:       dConf,
+		Layers:       []types.Descriptor{dLayer},
+		Subject:      &types.Descriptor{MediaType: dSubject.MediaType, Digest: dSubject.Digest, Size: dSubject.Size},
+	}))
+	if err != nil {
+		return err
+	}
+	err = rc.ManifestPut(ctx, rSubject.SetDigest(m.GetDescriptor().Digest.String()), m)
+	if err != nil {
+		return fmt.Errorf("failed to push artifact manifest: %w", err)
+	}
+	rl, err := rc.ReferrerList(ctx, rSubject)
+	if err != nil {
+		return fmt.Errorf("failed to list referrers: %w", err)
+	}
+	if len(rl.Descriptors) != 1 || rl.Descriptors[0].Digest != m.GetDescriptor().Digest || rl.Descriptors[0].ArtifactType != artifactType {
+		return fmt.Errorf("unexpected referrers %v%.0w", rl.Descriptors, types.ErrNotFound)
+	}
+
+	// copy the image with referrers
+	rTgt := repo.SetTag("artifact-copy")
+	err = rc.ImageCopy(ctx, rSubject, rTgt, regclient.ImageWithReferrers())
+	if err != nil {
+		return fmt.Errorf("failed to copy with referrers: %w", err)
+	}
+	rl, err = rc.ReferrerList(ctx, rTgt)
+	if err != nil {
+		return fmt.Errorf("failed to list referrers: %w", err)
+	}
+	if len(rl.Descriptors) != 1 || rl.Descriptors[0].Digest != m.GetDescriptor().Digest {
+		return fmt.Errorf("unexpected referrers after copy %v%.0w", rl.Descriptors, types.ErrNotFound)
+	}
+	return nil
+}
+
+func flowTagDelete(ctx context.Context, rc *regclient.RegClient, repo ref.Ref) error {
+	return tagDeleteVerify(ctx, rc, repo.SetTag("tag-delete"))
+}
+
+// flowTagDeleteReplace allows the tag to be replaced with a temporary manifest for registries without the OCI tag delete API.
+func flowTagDeleteReplace(ctx context.Context, rc *regclient.RegClient, repo ref.Ref) error {
+	return tagDeleteVerify(ctx, rc, repo.SetTag("tag-delete-replace"), scheme.WithTagManifestReplace())
+}
+
+func tagDeleteVerify(ctx context.Context, rc *regclient.RegClient, r ref.Ref, opts ...scheme.TagOpts) error {
+	_, err := PushImage(ctx, rc, r)
+	if err != nil {
+		return err
+	}
+	err = rc.TagDeleteWithOpts(ctx, r, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	tl, err := rc.TagList(ctx, r.SetTag(""))
+	if err != nil {
+		return err
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return err
+	}
+	for _, t := range tags {
+		if t == r.Tag {
+			return fmt.Errorf("tag %s was not deleted%.0w", r.Tag, types.ErrMismatch)
+		}
+	}
+	return nil
+}
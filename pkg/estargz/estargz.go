@@ -0,0 +1,603 @@
+// Package estargz reads and writes eStargz layers.
+// An eStargz layer is a gzip compressed tar where the content of each file is compressed in a separate gzip stream,
+// followed by a table of contents (TOC) with the offset of each file, allowing individual files to be read with range requests.
+// The layer remains a valid tar+gzip for tools without eStargz support.
+// Only gzip is supported, zstd:chunked layers are read as a regular layer.
+package estargz
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// crypto libraries included for go-digest
+	_ "crypto/sha256"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/pkg/archive"
+	"github.com/regclient/regclient/types"
+)
+
+const (
+	// AnnotationTOCDigest is the layer descriptor annotation with the digest of the TOC json.
+	AnnotationTOCDigest = "containerd.io/snapshot/stargz/toc.digest"
+	// AnnotationUncompressedSize is the layer descriptor annotation with the size of the uncompressed tar.
+	AnnotationUncompressedSize = "io.containers.estargz.uncompressed-size"
+	// FooterSize is the size of the gzip footer containing the offset of the TOC.
+	FooterSize = 51
+	// TOCTarName is the name of the tar entry containing the TOC json.
+	TOCTarName = "stargz.index.json"
+
+	defaultChunkSize = 4 * 1024 * 1024
+	footerMagic      = "STARGZ"
+)
+
+var (
+	// ErrInvalidFooter is returned when the blob does not end with an eStargz footer.
+	ErrInvalidFooter = errors.New("invalid eStargz footer")
+)
+
+// Opts configures options for [Build] and [Open].
+type Opts func(*config)
+
+type config struct {
+	chunkSize int64
+	tocDigest digest.Digest
+}
+
+// WithChunkSize splits files larger than the size into multiple chunks with [Build], defaulting to 4MiB.
+func WithChunkSize(size int64) Opts {
+	return func(c *config) {
+		c.chunkSize = size
+	}
+}
+
+// WithTOCDigest verifies the digest of the TOC with [Open], see [AnnotationTOCDigest].
+func WithTOCDigest(d digest.Digest) Opts {
+	return func(c *config) {
+		c.tocDigest = d
+	}
+}
+
+// TOC is the table of contents of an eStargz layer.
+type TOC struct {
+	Version int         `json:"version"`
+	Entries []*TOCEntry `json:"entries"`
+}
+
+// TOCEntry is a file or a chunk of a file in the TOC.
+type TOCEntry struct {
+	// Name is the path of the file without a leading slash.
+	Name string `json:"name"`
+	// Type is one of "dir", "reg", "symlink", "hardlink", "char", "block", "fifo", or "chunk".
+	// Chunks contain the content of a "reg" entry after the first chunk.
+	Type     string            `json:"type"`
+	Size     int64             `json:"size,omitempty"`
+	ModTime  string            `json:"modtime,omitempty"` // RFC3339 format
+	LinkName string            `json:"linkName,omitempty"`
+	Mode     int64             `json:"mode,omitempty"`
+	UID      int               `json:"uid,omitempty"`
+	GID      int               `json:"gid,omitempty"`
+	Uname    string            `json:"userName,omitempty"`
+	Gname    string            `json:"groupName,omitempty"`
+	DevMajor int64             `json:"devMajor,omitempty"`
+	DevMinor int64             `json:"devMinor,omitempty"`
+	Xattrs   map[string][]byte `json:"xattrs,omitempty"`
+	// Digest is the digest of the full content of a "reg" entry.
+	Digest string `json:"digest,omitempty"`
+	// Offset is the offset in the blob of the gzip stream containing the content of the chunk.
+	Offset int64 `json:"offset,omitempty"`
+	// ChunkOffset is the offset of the chunk in the content of the file.
+	ChunkOffset int64 `json:"chunkOffset,omitempty"`
+	// ChunkSize is the size of the chunk, zero when the chunk continues to the end of the file.
+	ChunkSize   int64  `json:"chunkSize,omitempty"`
+	ChunkDigest string `json:"chunkDigest,omitempty"`
+}
+
+// Header returns a tar header for the entry.
+func (e *TOCEntry) Header() *tar.Header {
+	th := &tar.Header{
+		Name:     e.Name,
+		Size:     e.Size,
+		Linkname: e.LinkName,
+		Mode:     e.Mode,
+		Uid:      e.UID,
+		Gid:      e.GID,
+		Uname:    e.Uname,
+		Gname:    e.Gname,
+		Devmajor: e.DevMajor,
+		Devminor: e.DevMinor,
+	}
+	if t, err := time.Parse(time.RFC3339, e.ModTime); err == nil {
+		th.ModTime = t
+	}
+	switch e.Type {
+	case "dir":
+		th.Typeflag = tar.TypeDir
+	case "symlink":
+		th.Typeflag = tar.TypeSymlink
+	case "hardlink":
+		th.Typeflag = tar.TypeLink
+	case "char":
+		th.Typeflag = tar.TypeChar
+	case "block":
+		th.Typeflag = tar.TypeBlock
+	case "fifo":
+		th.Typeflag = tar.TypeFifo
+	default:
+		th.Typeflag = tar.TypeReg
+	}
+	if len(e.Xattrs) > 0 {
+		th.PAXRecords = map[string]string{}
+		for k, v := range e.Xattrs {
+			th.PAXRecords["SCHILY.xattr."+k] = string(v)
+		}
+	}
+	return th
+}
+
+// Result describes the layer created by [Build].
+type Result struct {
+	// Size is the size of the compressed layer.
+	Size int64
+	// DiffID is the digest of the uncompressed tar.
+	DiffID digest.Digest
+	// UncompressedSize is the size of the uncompressed tar.
+	UncompressedSize int64
+	// TOCDigest is the digest of the TOC json.
+	TOCDigest digest.Digest
+}
+
+// Annotations returns the layer descriptor annotations for the result.
+func (r Result) Annotations() map[string]string {
+	return map[string]string{
+		AnnotationTOCDigest:        r.TOCDigest.String(),
+		AnnotationUncompressedSize: strconv.FormatInt(r.UncompressedSize, 10),
+	}
+}
+
+// Build converts a tar, which may be compressed, to an eStargz layer.
+// An existing TOC in the input is replaced.
+func Build(w io.Writer, r io.Reader, opts ...Opts) (Result, error) {
+	c := config{chunkSize: defaultChunkSize}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.chunkSize <= 0 {
+		c.chunkSize = defaultChunkSize
+	}
+	dr, err := archive.Decompress(r)
+	if err != nil {
+		return Result{}, err
+	}
+	b := builder{
+		cw:     &countWriter{w: w},
+		diffID: digest.Canonical.Digester(),
+	}
+	tr := tar.NewReader(dr)
+	tw := tar.NewWriter(&b)
+	toc := TOC{Version: 1, Entries: []*TOCEntry{}}
+	_ = b.newStream()
+	for {
+		th, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		name := strings.TrimPrefix(path.Clean("/"+th.Name), "/")
+		if name == TOCTarName {
+			continue
+		}
+		e := tocEntry(th, name)
+		err = tw.WriteHeader(th)
+		if err != nil {
+			return Result{}, err
+		}
+		toc.Entries = append(toc.Entries, e)
+		if e.Type != "reg" || th.Size == 0 {
+			continue
+		}
+		// each chunk of content starts a new gzip stream
+		dig := digest.Canonical.Digester()
+		chunk := e
+		for off := int64(0); off < th.Size; off += c.chunkSize {
+			size := th.Size - off
+			if size > c.chunkSize {
+				size = c.chunkSize
+			}
+			if off > 0 {
+				chunk = &TOCEntry{Name: name, Type: "chunk", ChunkOffset: off}
+				toc.Entries = append(toc.Entries, chunk)
+			}
+			if th.Size > c.chunkSize {
+				chunk.ChunkSize = size
+			}
+			err = b.newStream()
+			if err != nil {
+				return Result{}, err
+			}
+			chunk.Offset = b.cw.n
+			chunkDig := digest.Canonical.Digester()
+			_, err = io.CopyN(tw, io.TeeReader(tr, io.MultiWriter(dig.Hash(), chunkDig.Hash())), size)
+			if err != nil {
+				return Result{}, fmt.Errorf("failed to copy %s: %w", th.Name, err)
+			}
+			chunk.ChunkDigest = chunkDig.Digest().String()
+		}
+		e.Digest = dig.Digest().String()
+	}
+
+	// the TOC is the last entry in the tar, in its own gzip stream
+	tocJSON, err := json.Marshal(toc)
+	if err != nil {
+		return Result{}, err
+	}
+	// flush the padding of the last file so the TOC stream starts with the header
+	err = tw.Flush()
+	if err != nil {
+		return Result{}, err
+	}
+	err = b.newStream()
+	if err != nil {
+		return Result{}, err
+	}
+	tocOffset := b.cw.n
+	err = tw.WriteHeader(&tar.Header{
+		Name:     TOCTarName,
+		Typeflag: tar.TypeReg,
+		Mode:     0444,
+		Size:     int64(len(tocJSON)),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	_, err = tw.Write(tocJSON)
+	if err != nil {
+		return Result{}, err
+	}
+	err = tw.Close()
+	if err != nil {
+		return Result{}, err
+	}
+	err = b.gw.Close()
+	if err != nil {
+		return Result{}, err
+	}
+	_, err = b.cw.Write(footer(tocOffset))
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Size:             b.cw.n,
+		DiffID:           b.diffID.Digest(),
+		UncompressedSize: b.ucSize,
+		TOCDigest:        digest.FromBytes(tocJSON),
+	}, nil
+}
+
+// Reader provides random access to files in an eStargz layer.
+type Reader struct {
+	ra        io.ReaderAt
+	toc       *TOC
+	tocOffset int64
+	entries   map[string]*TOCEntry
+	chunks    map[string][]*TOCEntry
+	offsets   []int64 // sorted offsets of each gzip stream with content
+}
+
+// Open reads the footer and TOC of an eStargz layer of the given size.
+// Each read from ra is for a single gzip stream, allowing ra to be implemented with range requests.
+func Open(ra io.ReaderAt, size int64, opts ...Opts) (*Reader, error) {
+	c := config{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if size < FooterSize {
+		return nil, fmt.Errorf("blob is smaller than the footer%.0w", ErrInvalidFooter)
+	}
+	buf := make([]byte, FooterSize)
+	_, err := readFullAt(ra, buf, size-FooterSize)
+	if err != nil {
+		return nil, err
+	}
+	tocOffset, err := ParseFooter(buf)
+	if err != nil {
+		return nil, err
+	}
+	if tocOffset >= size-FooterSize {
+		return nil, fmt.Errorf("TOC offset %d is beyond the end of the blob%.0w", tocOffset, ErrInvalidFooter)
+	}
+	buf = make([]byte, size-FooterSize-tocOffset)
+	_, err = readFullAt(ra, buf, tocOffset)
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TOC: %w", err)
+	}
+	tr := tar.NewReader(gr)
+	th, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TOC: %w", err)
+	}
+	if th.Name != TOCTarName {
+		return nil, fmt.Errorf("unexpected TOC entry name %s%.0w", th.Name, ErrInvalidFooter)
+	}
+	tocJSON, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TOC: %w", err)
+	}
+	if c.tocDigest != "" && digest.FromBytes(tocJSON) != c.tocDigest {
+		return nil, fmt.Errorf("TOC %w, expected %s, received %s", types.ErrDigestMismatch, c.tocDigest.String(), digest.FromBytes(tocJSON).String())
+	}
+	toc := TOC{}
+	err = json.Unmarshal(tocJSON, &toc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TOC: %w", err)
+	}
+	r := Reader{
+		ra:        ra,
+		toc:       &toc,
+		tocOffset: tocOffset,
+		entries:   map[string]*TOCEntry{},
+		chunks:    map[string][]*TOCEntry{},
+		offsets:   []int64{},
+	}
+	for _, e := range toc.Entries {
+		e.Name = strings.TrimPrefix(path.Clean("/"+e.Name), "/")
+		if e.Type != "chunk" {
+			r.entries[e.Name] = e
+		}
+		if e.Type == "reg" || e.Type == "chunk" {
+			if e.Offset > 0 {
+				r.chunks[e.Name] = append(r.chunks[e.Name], e)
+				r.offsets = append(r.offsets, e.Offset)
+			}
+		}
+	}
+	sort.Slice(r.offsets, func(i, j int) bool { return r.offsets[i] < r.offsets[j] })
+	return &r, nil
+}
+
+// TOC returns the table of contents.
+func (r *Reader) TOC() *TOC {
+	return r.toc
+}
+
+// Lookup returns the entry for a file.
+func (r *Reader) Lookup(name string) (*TOCEntry, bool) {
+	e, ok := r.entries[strings.TrimPrefix(path.Clean("/"+name), "/")]
+	return e, ok
+}
+
+// OpenFile returns a reader for the content of a regular file, following hard links.
+// Each chunk is read when needed, and verified against the chunk digest.
+func (r *Reader) OpenFile(name string) (io.Reader, error) {
+	e, ok := r.Lookup(name)
+	if ok && e.Type == "hardlink" {
+		e, ok = r.Lookup(e.LinkName)
+	}
+	if !ok {
+		return nil, fmt.Errorf("file %s%.0w", name, types.ErrFileNotFound)
+	}
+	if e.Type != "reg" {
+		return nil, fmt.Errorf("file %s is not a regular file, type %s", name, e.Type)
+	}
+	chunks := r.chunks[e.Name]
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].ChunkOffset < chunks[j].ChunkOffset })
+	return &fileReader{r: r, size: e.Size, chunks: chunks}, nil
+}
+
+// ParseFooter returns the offset of the TOC from the footer of an eStargz layer.
+func ParseFooter(p []byte) (int64, error) {
+	if len(p) != FooterSize {
+		return 0, fmt.Errorf("footer size %d%.0w", len(p), ErrInvalidFooter)
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(p))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidFooter, err)
+	}
+	extra := gr.Header.Extra
+	if len(extra) != 26 || extra[0] != 'S' || extra[1] != 'G' || binary.LittleEndian.Uint16(extra[2:4]) != 22 || string(extra[20:]) != footerMagic {
+		return 0, ErrInvalidFooter
+	}
+	offset, err := strconv.ParseInt(string(extra[4:20]), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidFooter, err)
+	}
+	return offset, nil
+}
+
+// footer returns an empty gzip stream with the TOC offset in the extra header field.
+// The stream is built directly since the size of an empty deflate block depends on the compressor.
+func footer(tocOffset int64) []byte {
+	extra := []byte{'S', 'G', 0, 0}
+	binary.LittleEndian.PutUint16(extra[2:4], 22)
+	extra = append(extra, []byte(fmt.Sprintf("%016x%s", tocOffset, footerMagic))...)
+	buf := make([]byte, 0, FooterSize)
+	buf = append(buf, 0x1f, 0x8b, 8, 4, 0, 0, 0, 0, 0, 255) // gzip header with the FEXTRA flag
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(extra)))
+	buf = append(buf, extra...)
+	buf = append(buf, 1, 0, 0, 0xff, 0xff)    // final stored deflate block with no data
+	buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 0) // crc32 and size of the empty content
+	return buf
+}
+
+func tocEntry(th *tar.Header, name string) *TOCEntry {
+	e := &TOCEntry{
+		Name:     name,
+		Size:     th.Size,
+		LinkName: th.Linkname,
+		Mode:     th.Mode,
+		UID:      th.Uid,
+		GID:      th.Gid,
+		Uname:    th.Uname,
+		Gname:    th.Gname,
+		DevMajor: th.Devmajor,
+		DevMinor: th.Devminor,
+	}
+	if !th.ModTime.IsZero() {
+		e.ModTime = th.ModTime.UTC().Format(time.RFC3339)
+	}
+	switch th.Typeflag {
+	case tar.TypeDir:
+		e.Type = "dir"
+	case tar.TypeSymlink:
+		e.Type = "symlink"
+	case tar.TypeLink:
+		e.Type = "hardlink"
+		e.LinkName = strings.TrimPrefix(path.Clean("/"+th.Linkname), "/")
+	case tar.TypeChar:
+		e.Type = "char"
+	case tar.TypeBlock:
+		e.Type = "block"
+	case tar.TypeFifo:
+		e.Type = "fifo"
+	default:
+		e.Type = "reg"
+	}
+	if e.Type != "reg" {
+		e.Size = 0
+	}
+	for k, v := range th.PAXRecords {
+		if strings.HasPrefix(k, "SCHILY.xattr.") {
+			if e.Xattrs == nil {
+				e.Xattrs = map[string][]byte{}
+			}
+			e.Xattrs[strings.TrimPrefix(k, "SCHILY.xattr.")] = []byte(v)
+		}
+	}
+	return e
+}
+
+// builder writes the tar to the current gzip stream while tracking the uncompressed digest.
+type builder struct {
+	cw     *countWriter
+	gw     *gzip.Writer
+	diffID digest.Digester
+	ucSize int64
+}
+
+func (b *builder) Write(p []byte) (int, error) {
+	n, err := b.gw.Write(p)
+	b.diffID.Hash().Write(p[:n])
+	b.ucSize += int64(n)
+	return n, err
+}
+
+// newStream closes the current gzip stream and starts another.
+func (b *builder) newStream() error {
+	if b.gw != nil {
+		err := b.gw.Close()
+		if err != nil {
+			return err
+		}
+	}
+	b.gw = gzip.NewWriter(b.cw)
+	return nil
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// fileReader reads each chunk of a file from the blob.
+type fileReader struct {
+	r      *Reader
+	size   int64
+	chunks []*TOCEntry
+	cur    io.Reader
+	curDig digest.Digester
+	curExp string
+	read   int64
+}
+
+func (fr *fileReader) Read(p []byte) (int, error) {
+	for {
+		if fr.cur != nil {
+			n, err := fr.cur.Read(p)
+			fr.read += int64(n)
+			if errors.Is(err, io.EOF) {
+				if fr.curExp != "" && fr.curDig.Digest().String() != fr.curExp {
+					return n, fmt.Errorf("chunk %w, expected %s, received %s", types.ErrDigestMismatch, fr.curExp, fr.curDig.Digest().String())
+				}
+				fr.cur = nil
+				err = nil
+			}
+			if n > 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+		if len(fr.chunks) == 0 {
+			if fr.read < fr.size {
+				return 0, fmt.Errorf("read %d of %d bytes%.0w", fr.read, fr.size, io.ErrUnexpectedEOF)
+			}
+			return 0, io.EOF
+		}
+		err := fr.next()
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// next reads the gzip stream of the next chunk.
+func (fr *fileReader) next() error {
+	c := fr.chunks[0]
+	fr.chunks = fr.chunks[1:]
+	size := c.ChunkSize
+	if size == 0 {
+		size = fr.size - c.ChunkOffset
+	}
+	end := fr.r.tocOffset
+	i := sort.Search(len(fr.r.offsets), func(i int) bool { return fr.r.offsets[i] > c.Offset })
+	if i < len(fr.r.offsets) {
+		end = fr.r.offsets[i]
+	}
+	buf := make([]byte, end-c.Offset)
+	_, err := readFullAt(fr.r.ra, buf, c.Offset)
+	if err != nil {
+		return err
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		return fmt.Errorf("failed to read chunk of %s at %d: %w", c.Name, c.ChunkOffset, err)
+	}
+	fr.curDig = digest.Canonical.Digester()
+	fr.curExp = c.ChunkDigest
+	fr.cur = io.TeeReader(io.LimitReader(gr, size), fr.curDig.Hash())
+	return nil
+}
+
+func readFullAt(ra io.ReaderAt, p []byte, off int64) (int, error) {
+	n, err := ra.ReadAt(p, off)
+	if n == len(p) && errors.Is(err, io.EOF) {
+		err = nil
+	}
+	if err == nil && n < len(p) {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
@@ -0,0 +1,196 @@
+package estargz
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types"
+)
+
+// countReaderAt tracks the number of reads from the blob.
+type countReaderAt struct {
+	ra    io.ReaderAt
+	reads int
+}
+
+func (c *countReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.reads++
+	return c.ra.ReadAt(p, off)
+}
+
+func TestEStargz(t *testing.T) {
+	large := strings.Repeat("0123456789", 100)
+	files := []struct {
+		name    string
+		typ     byte
+		content string
+		link    string
+	}{
+		{name: "etc/", typ: tar.TypeDir},
+		{name: "etc/a", typ: tar.TypeReg, content: "a1"},
+		{name: "./etc/empty", typ: tar.TypeReg},
+		{name: "large", typ: tar.TypeReg, content: large},
+		{name: "etc/link", typ: tar.TypeSymlink, link: "a"},
+		{name: "hard", typ: tar.TypeLink, link: "etc/a"},
+		{name: "etc/b", typ: tar.TypeReg, content: "b1"},
+	}
+	src := &bytes.Buffer{}
+	tw := tar.NewWriter(src)
+	for _, f := range files {
+		err := tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: f.typ, Mode: 0644, Size: int64(len(f.content)), Linkname: f.link})
+		if err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+		_, err = tw.Write([]byte(f.content))
+		if err != nil {
+			t.Fatalf("failed to write content: %v", err)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+
+	out := &bytes.Buffer{}
+	res, err := Build(out, bytes.NewReader(src.Bytes()), WithChunkSize(300))
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+	if res.Size != int64(out.Len()) {
+		t.Errorf("unexpected size, expected %d, received %d", out.Len(), res.Size)
+	}
+	if res.Annotations()[AnnotationTOCDigest] != res.TOCDigest.String() {
+		t.Errorf("unexpected annotations: %v", res.Annotations())
+	}
+
+	t.Run("Valid tar", func(t *testing.T) {
+		gr, err := gzip.NewReader(bytes.NewReader(out.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to open gzip: %v", err)
+		}
+		ucDig := digest.Canonical.Digester()
+		tr := tar.NewReader(io.TeeReader(gr, ucDig.Hash()))
+		names := []string{}
+		for {
+			th, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("failed to read tar: %v", err)
+			}
+			names = append(names, th.Name)
+		}
+		_, _ = io.Copy(io.Discard, gr)
+		if len(names) != len(files)+1 || names[len(names)-1] != TOCTarName {
+			t.Errorf("unexpected entries: %v", names)
+		}
+		if ucDig.Digest() != res.DiffID {
+			t.Errorf("unexpected diffID, expected %s, received %s", ucDig.Digest().String(), res.DiffID.String())
+		}
+	})
+
+	t.Run("Rebuild", func(t *testing.T) {
+		out2 := &bytes.Buffer{}
+		res2, err := Build(out2, bytes.NewReader(out.Bytes()), WithChunkSize(300))
+		if err != nil {
+			t.Fatalf("failed to rebuild: %v", err)
+		}
+		if res2.TOCDigest != res.TOCDigest || !bytes.Equal(out.Bytes(), out2.Bytes()) {
+			t.Errorf("rebuild of an eStargz layer changed the output")
+		}
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		ra := &countReaderAt{ra: bytes.NewReader(out.Bytes())}
+		r, err := Open(ra, int64(out.Len()), WithTOCDigest(res.TOCDigest))
+		if err != nil {
+			t.Fatalf("failed to open: %v", err)
+		}
+		if ra.reads != 2 {
+			t.Errorf("unexpected reads to open, expected 2, received %d", ra.reads)
+		}
+		tt := []struct {
+			name      string
+			expect    string
+			reads     int
+			expectErr error
+		}{
+			{name: "etc/a", expect: "a1", reads: 1},
+			{name: "/etc/b", expect: "b1", reads: 1},
+			{name: "etc/empty", expect: "", reads: 0},
+			{name: "large", expect: large, reads: 4},
+			{name: "hard", expect: "a1", reads: 1},
+			{name: "missing", expectErr: types.ErrFileNotFound},
+		}
+		for _, tc := range tt {
+			t.Run(tc.name, func(t *testing.T) {
+				ra.reads = 0
+				rdr, err := r.OpenFile(tc.name)
+				if tc.expectErr != nil {
+					if !errors.Is(err, tc.expectErr) {
+						t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("failed to open file: %v", err)
+				}
+				b, err := io.ReadAll(rdr)
+				if err != nil {
+					t.Fatalf("failed to read file: %v", err)
+				}
+				if string(b) != tc.expect {
+					t.Errorf("unexpected content, expected %s, received %s", tc.expect, string(b))
+				}
+				if ra.reads != tc.reads {
+					t.Errorf("unexpected reads, expected %d, received %d", tc.reads, ra.reads)
+				}
+			})
+		}
+		e, ok := r.Lookup("etc/link")
+		if !ok || e.Type != "symlink" || e.Header().Linkname != "a" || e.Header().Typeflag != tar.TypeSymlink {
+			t.Errorf("unexpected symlink entry: %v", e)
+		}
+		_, err = r.OpenFile("etc/link")
+		if err == nil {
+			t.Errorf("open of a symlink did not fail")
+		}
+	})
+
+	t.Run("Bad TOC digest", func(t *testing.T) {
+		_, err := Open(bytes.NewReader(out.Bytes()), int64(out.Len()), WithTOCDigest(digest.FromString("bad")))
+		if !errors.Is(err, types.ErrDigestMismatch) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrDigestMismatch, err)
+		}
+	})
+
+	t.Run("Not eStargz", func(t *testing.T) {
+		gz := &bytes.Buffer{}
+		gw := gzip.NewWriter(gz)
+		_, _ = gw.Write(src.Bytes())
+		_ = gw.Close()
+		_, err := Open(bytes.NewReader(gz.Bytes()), int64(gz.Len()))
+		if !errors.Is(err, ErrInvalidFooter) {
+			t.Errorf("unexpected error, expected %v, received %v", ErrInvalidFooter, err)
+		}
+	})
+
+	t.Run("Footer", func(t *testing.T) {
+		f := footer(12345)
+		if len(f) != FooterSize {
+			t.Fatalf("unexpected footer size %d", len(f))
+		}
+		off, err := ParseFooter(f)
+		if err != nil || off != 12345 {
+			t.Errorf("unexpected offset %d: %v", off, err)
+		}
+	})
+}
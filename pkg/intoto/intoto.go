@@ -0,0 +1,174 @@
+// Package intoto parses in-toto attestations, including statements wrapped in a signed DSSE envelope.
+// Attestations are used for build provenance, SBOMs, and vulnerability scan results.
+package intoto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/regclient/regclient/types"
+)
+
+const (
+	// MediaTypeStatement is the media type of an unsigned in-toto statement.
+	MediaTypeStatement = "application/vnd.in-toto+json"
+	// MediaTypeDSSE is the media type of a DSSE envelope.
+	MediaTypeDSSE = "application/vnd.dsse.envelope.v1+json"
+	// PayloadType is the DSSE payload type of an in-toto statement.
+	PayloadType = "application/vnd.in-toto+json"
+	// AnnotationPredicateType is the layer annotation with the predicate type of the statement.
+	AnnotationPredicateType = "in-toto.io/predicate-type"
+	// AnnotationPredicateTypeCosign is the layer annotation used by cosign for the predicate type.
+	AnnotationPredicateTypeCosign = "predicateType"
+
+	// PredicateSLSAProvenance02 is the SLSA provenance v0.2 predicate type.
+	PredicateSLSAProvenance02 = "https://slsa.dev/provenance/v0.2"
+	// PredicateSLSAProvenance1 is the SLSA provenance v1 predicate type.
+	PredicateSLSAProvenance1 = "https://slsa.dev/provenance/v1"
+	// PredicateSPDX is the SPDX SBOM predicate type.
+	PredicateSPDX = "https://spdx.dev/Document"
+	// PredicateCycloneDX is the CycloneDX SBOM predicate type.
+	PredicateCycloneDX = "https://cyclonedx.org/bom"
+	// PredicateVuln is the cosign vulnerability scan predicate type.
+	PredicateVuln = "https://cosign.sigstore.dev/attestation/vuln/v1"
+
+	// StatementTypeV01 is the in-toto statement type for v0.1 statements.
+	StatementTypeV01 = "https://in-toto.io/Statement/v0.1"
+	// StatementTypeV1 is the in-toto statement type for v1 statements.
+	StatementTypeV1 = "https://in-toto.io/Statement/v1"
+)
+
+var (
+	// ErrVerifyFailed is returned when the envelope signature is not valid.
+	ErrVerifyFailed = errors.New("attestation verification failed")
+)
+
+// Statement is an in-toto statement.
+type Statement struct {
+	Type          string          `json:"_type"`
+	Subject       []Subject       `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate,omitempty"`
+}
+
+// Subject is an artifact described by a statement.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Envelope is a DSSE envelope.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     []byte      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a signature in a DSSE envelope.
+type Signature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   []byte `json:"sig"`
+}
+
+// Parse returns the statement from an in-toto statement or DSSE envelope.
+// The envelope is returned when the media type is [MediaTypeDSSE], and is nil otherwise.
+// The envelope signature is not verified, see [Envelope.Verify].
+func Parse(mediaType string, b []byte) (Statement, *Envelope, error) {
+	s := Statement{}
+	switch mediaType {
+	case MediaTypeStatement:
+		err := json.Unmarshal(b, &s)
+		if err != nil {
+			return s, nil, fmt.Errorf("failed to parse statement: %v%.0w", err, types.ErrParsingFailed)
+		}
+		return s, nil, nil
+	case MediaTypeDSSE:
+		env := Envelope{}
+		err := json.Unmarshal(b, &env)
+		if err != nil {
+			return s, nil, fmt.Errorf("failed to parse DSSE envelope: %v%.0w", err, types.ErrParsingFailed)
+		}
+		if env.PayloadType != PayloadType {
+			return s, &env, fmt.Errorf("DSSE payload type %s%.0w", env.PayloadType, types.ErrUnsupportedMediaType)
+		}
+		err = json.Unmarshal(env.Payload, &s)
+		if err != nil {
+			return s, &env, fmt.Errorf("failed to parse statement: %v%.0w", err, types.ErrParsingFailed)
+		}
+		return s, &env, nil
+	default:
+		return s, nil, fmt.Errorf("media type %s%.0w", mediaType, types.ErrUnsupportedMediaType)
+	}
+}
+
+// PAE returns the DSSE pre-authentication encoding of the payload, which is the content that is signed.
+func PAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// Verify checks that at least one signature in the envelope is valid for the public key.
+// ECDSA and RSA signatures use SHA-256, and ed25519 signatures are made over the PAE directly.
+func (env Envelope) Verify(pub crypto.PublicKey) error {
+	if len(env.Signatures) == 0 {
+		return fmt.Errorf("envelope is not signed%.0w", ErrVerifyFailed)
+	}
+	pae := PAE(env.PayloadType, env.Payload)
+	h := sha256.Sum256(pae)
+	for _, sig := range env.Signatures {
+		switch key := pub.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(key, h[:], sig.Sig) {
+				return nil
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, h[:], sig.Sig) == nil || rsa.VerifyPSS(key, crypto.SHA256, h[:], sig.Sig, nil) == nil {
+				return nil
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(key, pae, sig.Sig) {
+				return nil
+			}
+		default:
+			return fmt.Errorf("unsupported key type %T%.0w", pub, ErrVerifyFailed)
+		}
+	}
+	return fmt.Errorf("no valid signature found%.0w", ErrVerifyFailed)
+}
+
+// ParsePublicKey parses a PEM encoded public key.
+func ParsePublicKey(b []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM public key%.0w", types.ErrParsingFailed)
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %v%.0w", err, types.ErrParsingFailed)
+		}
+		return pub, nil
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %v%.0w", err, types.ErrParsingFailed)
+		}
+		return pub, nil
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %v%.0w", err, types.ErrParsingFailed)
+		}
+		return cert.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM type %s%.0w", block.Type, types.ErrParsingFailed)
+	}
+}
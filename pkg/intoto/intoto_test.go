@@ -0,0 +1,207 @@
+package intoto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/regclient/regclient/types"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	s := Statement{
+		Type:          StatementTypeV1,
+		Subject:       []Subject{{Name: "test", Digest: map[string]string{"sha256": "abcd"}}},
+		PredicateType: PredicateSLSAProvenance1,
+		Predicate:     json.RawMessage(`{"buildDefinition":{}}`),
+	}
+	sBytes, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("failed to marshal statement: %v", err)
+	}
+	envBytes, err := json.Marshal(Envelope{PayloadType: PayloadType, Payload: sBytes})
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+	envOther, err := json.Marshal(Envelope{PayloadType: "text/plain", Payload: []byte("hello")})
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+	tt := []struct {
+		name      string
+		mediaType string
+		b         []byte
+		expectEnv bool
+		expectErr error
+	}{
+		{
+			name:      "statement",
+			mediaType: MediaTypeStatement,
+			b:         sBytes,
+		},
+		{
+			name:      "envelope",
+			mediaType: MediaTypeDSSE,
+			b:         envBytes,
+			expectEnv: true,
+		},
+		{
+			name:      "envelope payload type",
+			mediaType: MediaTypeDSSE,
+			b:         envOther,
+			expectErr: types.ErrUnsupportedMediaType,
+		},
+		{
+			name:      "invalid json",
+			mediaType: MediaTypeStatement,
+			b:         []byte("{"),
+			expectErr: types.ErrParsingFailed,
+		},
+		{
+			name:      "unknown media type",
+			mediaType: "application/json",
+			b:         sBytes,
+			expectErr: types.ErrUnsupportedMediaType,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sOut, env, err := Parse(tc.mediaType, tc.b)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if tc.expectEnv != (env != nil) {
+				t.Errorf("unexpected envelope: %v", env)
+			}
+			if sOut.PredicateType != s.PredicateType || len(sOut.Subject) != 1 || sOut.Subject[0].Digest["sha256"] != "abcd" {
+				t.Errorf("unexpected statement: %v", sOut)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+	payload := []byte(`{"_type":"https://in-toto.io/Statement/v1"}`)
+	pae := PAE(PayloadType, payload)
+	h := sha256.Sum256(pae)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ecSig, err := ecKey.Sign(rand.Reader, h[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	rsaSig, err := rsaKey.Sign(rand.Reader, h[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	edSig := ed25519.Sign(edKey, pae)
+	tt := []struct {
+		name      string
+		env       Envelope
+		pub       crypto.PublicKey
+		expectErr error
+	}{
+		{
+			name: "ecdsa",
+			env:  Envelope{PayloadType: PayloadType, Payload: payload, Signatures: []Signature{{Sig: ecSig}}},
+			pub:  ecKey.Public(),
+		},
+		{
+			name: "rsa",
+			env:  Envelope{PayloadType: PayloadType, Payload: payload, Signatures: []Signature{{Sig: rsaSig}}},
+			pub:  rsaKey.Public(),
+		},
+		{
+			name: "ed25519",
+			env:  Envelope{PayloadType: PayloadType, Payload: payload, Signatures: []Signature{{Sig: edSig}}},
+			pub:  edPub,
+		},
+		{
+			name: "second signature",
+			env:  Envelope{PayloadType: PayloadType, Payload: payload, Signatures: []Signature{{Sig: rsaSig}, {Sig: ecSig}}},
+			pub:  ecKey.Public(),
+		},
+		{
+			name:      "wrong key",
+			env:       Envelope{PayloadType: PayloadType, Payload: payload, Signatures: []Signature{{Sig: ecSig}}},
+			pub:       rsaKey.Public(),
+			expectErr: ErrVerifyFailed,
+		},
+		{
+			name:      "modified payload",
+			env:       Envelope{PayloadType: PayloadType, Payload: []byte(`{}`), Signatures: []Signature{{Sig: ecSig}}},
+			pub:       ecKey.Public(),
+			expectErr: ErrVerifyFailed,
+		},
+		{
+			name:      "unsigned",
+			env:       Envelope{PayloadType: PayloadType, Payload: payload},
+			pub:       ecKey.Public(),
+			expectErr: ErrVerifyFailed,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.env.Verify(tc.pub)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("failed to verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	t.Parallel()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(ecKey.Public())
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	pub, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	if !ecKey.PublicKey.Equal(pub) {
+		t.Errorf("parsed key does not match")
+	}
+	_, err = ParsePublicKey([]byte("not a key"))
+	if !errors.Is(err, types.ErrParsingFailed) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
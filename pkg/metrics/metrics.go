@@ -0,0 +1,51 @@
+// Package metrics defines the hooks regclient calls to record metrics for registry requests.
+// Implementations may update Prometheus collectors, or the counters of any other metrics library.
+package metrics
+
+import "time"
+
+// Metrics receives callbacks for each registry request.
+// The host is the registry name from the configuration, e.g. "docker.io".
+// Callbacks are made from concurrent requests and must be safe for concurrent use.
+type Metrics interface {
+	// Request is called after each http request with the response status and request duration.
+	// The status is 0 when a response was not received.
+	Request(host, method string, status int, duration time.Duration)
+	// BytesSent is called with the size of each request body sent to the registry.
+	BytesSent(host string, n int64)
+	// BytesReceived is called as response bodies are read from the registry.
+	BytesReceived(host string, n int64)
+	// Retry is called before a failed request is retried, including retries on a mirror.
+	Retry(host, method string)
+	// TokenRefresh is called when a new auth token is received from the token server of a registry.
+	TokenRefresh(host string)
+}
+
+// RetryWaiter is an optional interface of a [Metrics] implementation.
+// RetryWait is called before sleeping for the backoff delay of a retried request,
+// with the attempt number of the next request.
+type RetryWaiter interface {
+	RetryWait(host string, attempt int, wait time.Duration)
+}
+
+// Nop implements [Metrics] without recording anything.
+// Embed Nop in a struct to implement a subset of the callbacks.
+type Nop struct{}
+
+// Request implements [Metrics].
+func (Nop) Request(host, method string, status int, duration time.Duration) {}
+
+// BytesSent implements [Metrics].
+func (Nop) BytesSent(host string, n int64) {}
+
+// BytesReceived implements [Metrics].
+func (Nop) BytesReceived(host string, n int64) {}
+
+// Retry implements [Metrics].
+func (Nop) Retry(host, method string) {}
+
+// TokenRefresh implements [Metrics].
+func (Nop) TokenRefresh(host string) {}
+
+// RetryWait implements [RetryWaiter].
+func (Nop) RetryWait(host string, attempt int, wait time.Duration) {}
@@ -0,0 +1,363 @@
+package notation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/regclient/regclient/types"
+)
+
+// COSE header labels and algorithm values, see RFC 9052 and RFC 9053.
+const (
+	coseTagSign1    = 18
+	coseLabelAlg    = 1
+	coseLabelCrit   = 2
+	coseLabelCty    = 3
+	coseLabelX5c    = 33
+	cborTagEpochSec = 1
+)
+
+var coseAlgs = map[int64]string{
+	-7:  "ES256",
+	-35: "ES384",
+	-36: "ES512",
+	-37: "PS256",
+	-38: "PS384",
+	-39: "PS512",
+}
+
+func parseCOSE(b []byte) (envelope, error) {
+	env := envelope{format: "cose"}
+	v, err := cborDecode(b)
+	if err != nil {
+		return env, fmt.Errorf("failed to parse COSE envelope: %v%.0w", err, types.ErrParsingFailed)
+	}
+	if tag, ok := v.(cborTag); ok {
+		if tag.num != coseTagSign1 {
+			return env, fmt.Errorf("unexpected COSE tag %d%.0w", tag.num, types.ErrParsingFailed)
+		}
+		v = tag.val
+	}
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) != 4 {
+		return env, fmt.Errorf("COSE envelope is not a COSE_Sign1 message%.0w", types.ErrParsingFailed)
+	}
+	protectedBytes, ok1 := arr[0].([]byte)
+	unprotected, ok2 := arr[1].(map[interface{}]interface{})
+	payload, ok3 := arr[2].([]byte)
+	signature, ok4 := arr[3].([]byte)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return env, fmt.Errorf("COSE envelope has an invalid COSE_Sign1 structure%.0w", types.ErrParsingFailed)
+	}
+	env.payload = payload
+	env.signature = signature
+	protected := map[interface{}]interface{}{}
+	if len(protectedBytes) > 0 {
+		pv, err := cborDecode(protectedBytes)
+		if err != nil {
+			return env, fmt.Errorf("failed to parse COSE protected header: %v%.0w", err, types.ErrParsingFailed)
+		}
+		protected, ok = pv.(map[interface{}]interface{})
+		if !ok {
+			return env, fmt.Errorf("COSE protected header is not a map%.0w", types.ErrParsingFailed)
+		}
+	}
+
+	// the signed content is the Sig_structure with an empty external aad
+	env.signingInput = cborEncode([]interface{}{"Signature1", protectedBytes, []byte{}, payload})
+
+	if alg, ok := protected[int64(coseLabelAlg)].(int64); ok {
+		env.alg = coseAlgs[alg]
+	}
+	if env.alg == "" {
+		return env, fmt.Errorf("unsupported COSE algorithm %v%.0w", protected[int64(coseLabelAlg)], ErrVerifyFailed)
+	}
+	env.contentType, _ = protected[int64(coseLabelCty)].(string)
+	if crit, ok := protected[int64(coseLabelCrit)].([]interface{}); ok {
+		for _, c := range crit {
+			switch c {
+			case int64(coseLabelAlg):
+				env.crit = append(env.crit, "alg")
+			case int64(coseLabelCty):
+				env.crit = append(env.crit, "cty")
+			case int64(coseLabelCrit):
+				env.crit = append(env.crit, "crit")
+			default:
+				env.crit = append(env.crit, fmt.Sprintf("%v", c))
+			}
+		}
+	}
+	env.signingScheme, _ = protected[headerSigningScheme].(string)
+	if t, ok := coseTime(protected[headerSigningTime]); ok {
+		env.signingTime = t
+	}
+	if t, ok := coseTime(protected[headerAuthenticSigningTime]); ok {
+		env.authenticTime = t
+	}
+	if t, ok := coseTime(protected[headerExpiry]); ok {
+		env.expiry = &t
+	}
+
+	// x5chain is a single certificate or an array of certificates
+	var chain []interface{}
+	switch x5c := unprotected[int64(coseLabelX5c)].(type) {
+	case []byte:
+		chain = []interface{}{x5c}
+	case []interface{}:
+		chain = x5c
+	}
+	for _, c := range chain {
+		der, ok := c.([]byte)
+		if !ok {
+			return env, fmt.Errorf("COSE certificate is not a byte string%.0w", types.ErrParsingFailed)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return env, fmt.Errorf("failed to parse COSE certificate: %v%.0w", err, types.ErrParsingFailed)
+		}
+		env.chain = append(env.chain, cert)
+	}
+	return env, nil
+}
+
+// coseTime parses a time tagged as seconds since the epoch.
+func coseTime(v interface{}) (time.Time, bool) {
+	tag, ok := v.(cborTag)
+	if !ok || tag.num != cborTagEpochSec {
+		return time.Time{}, false
+	}
+	sec, ok := tag.val.(int64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0).UTC(), true
+}
+
+// cborTag is a tagged CBOR value.
+type cborTag struct {
+	num uint64
+	val interface{}
+}
+
+// cborMaxDepth limits the nesting of decoded values.
+const cborMaxDepth = 16
+
+// cborDecode decodes a single CBOR item, with support for the definite length types used by COSE.
+// Integers are returned as int64, byte strings as []byte, text as string, arrays as []interface{}, and maps as map[interface{}]interface{}.
+func cborDecode(b []byte) (interface{}, error) {
+	d := cborDecoder{b: b}
+	v, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.b) {
+		return nil, fmt.Errorf("unexpected data after CBOR item")
+	}
+	return v, nil
+}
+
+type cborDecoder struct {
+	b   []byte
+	pos int
+}
+
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.pos >= len(d.b) {
+		return 0, 0, fmt.Errorf("unexpected end of CBOR data")
+	}
+	major := d.b[d.pos] >> 5
+	info := d.b[d.pos] & 0x1f
+	d.pos++
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("unsupported CBOR additional info %d", info)
+	}
+	if d.pos+size > len(d.b) {
+		return 0, 0, fmt.Errorf("unexpected end of CBOR data")
+	}
+	var n uint64
+	for _, c := range d.b[d.pos : d.pos+size] {
+		n = n<<8 | uint64(c)
+	}
+	d.pos += size
+	return major, n, nil
+}
+
+func (d *cborDecoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.b)-d.pos) {
+		return nil, fmt.Errorf("unexpected end of CBOR data")
+	}
+	out := d.b[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return out, nil
+}
+
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("CBOR nesting exceeds %d", cborMaxDepth)
+	}
+	major, n, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case 0:
+		if n > 1<<63-1 {
+			return nil, fmt.Errorf("CBOR integer overflow")
+		}
+		return int64(n), nil
+	case 1:
+		if n > 1<<63-1 {
+			return nil, fmt.Errorf("CBOR integer overflow")
+		}
+		return -1 - int64(n), nil
+	case 2:
+		return d.bytes(n)
+	case 3:
+		b, err := d.bytes(n)
+		return string(b), err
+	case 4:
+		if n > uint64(len(d.b)-d.pos) {
+			return nil, fmt.Errorf("CBOR array length exceeds data")
+		}
+		arr := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			v, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case 5:
+		if n > uint64(len(d.b)-d.pos) {
+			return nil, fmt.Errorf("CBOR map length exceeds data")
+		}
+		m := map[interface{}]interface{}{}
+		for i := uint64(0); i < n; i++ {
+			k, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("unsupported CBOR map key type %T", k)
+			}
+			v, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	case 6:
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return cborTag{num: n, val: v}, nil
+	case 7:
+		switch n {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unsupported CBOR simple value %d", n)
+	}
+	return nil, fmt.Errorf("unsupported CBOR major type %d", major)
+}
+
+// cborEncode encodes the types returned by cborDecode.
+// Map keys are sorted by their encoding, following the deterministic encoding rules.
+func cborEncode(v interface{}) []byte {
+	buf := &bytes.Buffer{}
+	cborEncodeTo(buf, v)
+	return buf.Bytes()
+}
+
+func cborEncodeTo(buf *bytes.Buffer, v interface{}) {
+	switch val := v.(type) {
+	case int64:
+		if val >= 0 {
+			cborHead(buf, 0, uint64(val))
+		} else {
+			cborHead(buf, 1, uint64(-1-val))
+		}
+	case int:
+		cborEncodeTo(buf, int64(val))
+	case []byte:
+		cborHead(buf, 2, uint64(len(val)))
+		buf.Write(val)
+	case string:
+		cborHead(buf, 3, uint64(len(val)))
+		buf.WriteString(val)
+	case []interface{}:
+		cborHead(buf, 4, uint64(len(val)))
+		for _, item := range val {
+			cborEncodeTo(buf, item)
+		}
+	case map[interface{}]interface{}:
+		type pair struct{ k, v []byte }
+		pairs := make([]pair, 0, len(val))
+		for k, item := range val {
+			pairs = append(pairs, pair{k: cborEncode(k), v: cborEncode(item)})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return bytes.Compare(pairs[i].k, pairs[j].k) < 0 })
+		cborHead(buf, 5, uint64(len(val)))
+		for _, p := range pairs {
+			buf.Write(p.k)
+			buf.Write(p.v)
+		}
+	case cborTag:
+		cborHead(buf, 6, val.num)
+		cborEncodeTo(buf, val.val)
+	case bool:
+		if val {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case nil:
+		buf.WriteByte(0xf6)
+	default:
+		panic(fmt.Sprintf("unsupported CBOR type %T", v))
+	}
+}
+
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	major = major << 5
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= 0xff:
+		buf.Write([]byte{major | 24, byte(n)})
+	case n <= 0xffff:
+		buf.WriteByte(major | 25)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= 0xffffffff:
+		buf.WriteByte(major | 26)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(major | 27)
+		_ = binary.Write(buf, binary.BigEndian, n)
+	}
+}
@@ -0,0 +1,84 @@
+package notation
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/regclient/regclient/types"
+)
+
+// jwsEnvelope is the flattened JSON serialization of a JWS.
+type jwsEnvelope struct {
+	Payload   string    `json:"payload"`
+	Protected string    `json:"protected"`
+	Header    jwsHeader `json:"header"`
+	Signature string    `json:"signature"`
+}
+
+type jwsHeader struct {
+	X5C          []string `json:"x5c"`
+	SigningAgent string   `json:"io.cncf.notary.signingAgent,omitempty"`
+}
+
+type jwsProtected struct {
+	Alg                  string     `json:"alg"`
+	Cty                  string     `json:"cty"`
+	Crit                 []string   `json:"crit,omitempty"`
+	SigningScheme        string     `json:"io.cncf.notary.signingScheme"`
+	SigningTime          *time.Time `json:"io.cncf.notary.signingTime,omitempty"`
+	Expiry               *time.Time `json:"io.cncf.notary.expiry,omitempty"`
+	AuthenticSigningTime *time.Time `json:"io.cncf.notary.authenticSigningTime,omitempty"`
+}
+
+func parseJWS(b []byte) (envelope, error) {
+	env := envelope{format: "jws"}
+	jws := jwsEnvelope{}
+	err := json.Unmarshal(b, &jws)
+	if err != nil {
+		return env, fmt.Errorf("failed to parse JWS envelope: %v%.0w", err, types.ErrParsingFailed)
+	}
+	protectedBytes, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil {
+		return env, fmt.Errorf("failed to decode JWS protected header: %v%.0w", err, types.ErrParsingFailed)
+	}
+	protected := jwsProtected{}
+	err = json.Unmarshal(protectedBytes, &protected)
+	if err != nil {
+		return env, fmt.Errorf("failed to parse JWS protected header: %v%.0w", err, types.ErrParsingFailed)
+	}
+	env.payload, err = base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		return env, fmt.Errorf("failed to decode JWS payload: %v%.0w", err, types.ErrParsingFailed)
+	}
+	env.signature, err = base64.RawURLEncoding.DecodeString(jws.Signature)
+	if err != nil {
+		return env, fmt.Errorf("failed to decode JWS signature: %v%.0w", err, types.ErrParsingFailed)
+	}
+	for _, c := range jws.Header.X5C {
+		der, err := base64.StdEncoding.DecodeString(c)
+		if err != nil {
+			return env, fmt.Errorf("failed to decode JWS certificate: %v%.0w", err, types.ErrParsingFailed)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return env, fmt.Errorf("failed to parse JWS certificate: %v%.0w", err, types.ErrParsingFailed)
+		}
+		env.chain = append(env.chain, cert)
+	}
+	env.signingInput = []byte(jws.Protected + "." + jws.Payload)
+	env.alg = protected.Alg
+	env.contentType = protected.Cty
+	env.crit = protected.Crit
+	env.signingScheme = protected.SigningScheme
+	if protected.SigningTime != nil {
+		env.signingTime = *protected.SigningTime
+	}
+	if protected.AuthenticSigningTime != nil {
+		env.authenticTime = *protected.AuthenticSigningTime
+	}
+	env.expiry = protected.Expiry
+	return env, nil
+}
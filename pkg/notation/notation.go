@@ -0,0 +1,319 @@
+// Package notation verifies Notation (Notary Project) signatures of images.
+// Signature envelopes in the JWS and COSE formats are verified against the certificates of a [TrustStore].
+package notation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/regclient/regclient/types"
+)
+
+const (
+	// ArtifactTypeSignature is the artifactType of Notation signatures pushed as a referrer.
+	ArtifactTypeSignature = "application/vnd.cncf.notary.signature"
+	// MediaTypeJWS is the media type of a JWS signature envelope.
+	MediaTypeJWS = "application/jose+json"
+	// MediaTypeCOSE is the media type of a COSE signature envelope.
+	MediaTypeCOSE = "application/cose"
+	// MediaTypePayload is the content type of the signed payload.
+	MediaTypePayload = "application/vnd.cncf.notary.payload.v1+json"
+	// SigningSchemeX509 uses the signing time claimed by the signer, the certificate chain must be valid at the time of verification.
+	SigningSchemeX509 = "notary.x509"
+	// SigningSchemeX509SigningAuthority validates the certificate chain at the authentic signing time from a signing authority.
+	SigningSchemeX509SigningAuthority = "notary.x509.signingAuthority"
+
+	headerSigningScheme        = "io.cncf.notary.signingScheme"
+	headerSigningTime          = "io.cncf.notary.signingTime"
+	headerExpiry               = "io.cncf.notary.expiry"
+	headerAuthenticSigningTime = "io.cncf.notary.authenticSigningTime"
+)
+
+var (
+	// ErrVerifyFailed is returned when a signature is not valid.
+	ErrVerifyFailed = errors.New("signature verification failed")
+)
+
+// Payload is the content signed by Notation.
+type Payload struct {
+	TargetArtifact types.Descriptor `json:"targetArtifact"`
+}
+
+// Result describes a verified signature.
+type Result struct {
+	Format        string           `json:"format"`
+	SigningScheme string           `json:"signingScheme"`
+	SigningTime   time.Time        `json:"signingTime"`
+	Expiry        *time.Time       `json:"expiry,omitempty"`
+	Subject       string           `json:"subject"`
+	Issuer        string           `json:"issuer"`
+	Target        types.Descriptor `json:"target"`
+}
+
+// TrustStore contains the root certificates trusted to sign images.
+type TrustStore struct {
+	pool  *x509.CertPool
+	count int
+}
+
+// NewTrustStore returns a trust store with the provided certificates.
+func NewTrustStore(certs ...*x509.Certificate) *TrustStore {
+	ts := &TrustStore{pool: x509.NewCertPool()}
+	for _, c := range certs {
+		ts.pool.AddCert(c)
+		ts.count++
+	}
+	return ts
+}
+
+// LoadTrustStore loads the certificates of the named stores in the "x509/<type>/<name>" layout used by the notation CLI.
+// Each store is "<type>:<name>" as listed in the trustStores of a [TrustPolicy], with a type of "ca" or "signingAuthority".
+// Only the ".crt", ".cer", ".pem", and ".der" files directly in each store directory are loaded.
+func LoadTrustStore(dir string, stores ...string) (*TrustStore, error) {
+	if len(stores) == 0 {
+		return nil, fmt.Errorf("no trust stores selected in %s%.0w", dir, types.ErrNotFound)
+	}
+	ts := NewTrustStore()
+	for _, store := range stores {
+		storeType, name, ok := strings.Cut(store, ":")
+		if !ok || name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+			return nil, fmt.Errorf("invalid trust store %q%.0w", store, types.ErrParsingFailed)
+		}
+		switch storeType {
+		case "ca", "signingAuthority":
+		default:
+			return nil, fmt.Errorf("unsupported trust store type %q in %s%.0w", storeType, store, types.ErrUnsupported)
+		}
+		storeDir := filepath.Join(dir, "x509", storeType, name)
+		del, err := os.ReadDir(storeDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trust store %s: %w", store, err)
+		}
+		count := ts.count
+		for _, de := range del {
+			if de.IsDir() {
+				continue
+			}
+			switch strings.ToLower(filepath.Ext(de.Name())) {
+			case ".crt", ".cer", ".pem", ".der":
+			default:
+				continue
+			}
+			p := filepath.Join(storeDir, de.Name())
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return nil, err
+			}
+			certs, err := parseCerts(b)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+			}
+			for _, c := range certs {
+				ts.pool.AddCert(c)
+				ts.count++
+			}
+		}
+		if ts.count == count {
+			return nil, fmt.Errorf("no certificates found in trust store %s%.0w", store, types.ErrNotFound)
+		}
+	}
+	return ts, nil
+}
+
+// parseCerts parses PEM encoded certificates, or a single DER certificate.
+func parseCerts(b []byte) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	rest := b
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) > 0 {
+		return certs, nil
+	}
+	c, err := x509.ParseCertificate(b)
+	if err != nil {
+		return nil, fmt.Errorf("%v%.0w", err, types.ErrParsingFailed)
+	}
+	return []*x509.Certificate{c}, nil
+}
+
+// envelope contains the parsed fields of a JWS or COSE signature envelope.
+type envelope struct {
+	format        string
+	alg           string
+	signingInput  []byte
+	signature     []byte
+	payload       []byte
+	contentType   string
+	crit          []string
+	signingScheme string
+	signingTime   time.Time
+	authenticTime time.Time
+	expiry        *time.Time
+	chain         []*x509.Certificate
+}
+
+// Verify verifies a signature envelope of the subject against the trust store.
+// The media type is the layer media type of the signature, [MediaTypeJWS] or [MediaTypeCOSE].
+func Verify(ts *TrustStore, mediaType string, b []byte, subject types.Descriptor) (Result, error) {
+	var env envelope
+	var err error
+	switch mediaType {
+	case MediaTypeJWS:
+		env, err = parseJWS(b)
+	case MediaTypeCOSE:
+		env, err = parseCOSE(b)
+	default:
+		return Result{}, fmt.Errorf("signature envelope %s%.0w", mediaType, types.ErrUnsupportedMediaType)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return env.verify(ts, subject)
+}
+
+func (env envelope) verify(ts *TrustStore, subject types.Descriptor) (Result, error) {
+	result := Result{
+		Format:        env.format,
+		SigningScheme: env.signingScheme,
+		SigningTime:   env.signingTime,
+		Expiry:        env.expiry,
+	}
+	if ts == nil || ts.count == 0 {
+		return result, fmt.Errorf("trust store is empty%.0w", ErrVerifyFailed)
+	}
+	if env.contentType != MediaTypePayload {
+		return result, fmt.Errorf("unexpected content type %s%.0w", env.contentType, types.ErrUnsupportedMediaType)
+	}
+	known := map[string]bool{
+		"alg": true, "cty": true, "crit": true,
+		headerSigningScheme: true, headerSigningTime: true, headerExpiry: true, headerAuthenticSigningTime: true,
+	}
+	for _, c := range env.crit {
+		if !known[c] {
+			return result, fmt.Errorf("unsupported critical header %s%.0w", c, ErrVerifyFailed)
+		}
+	}
+	// the signing time of notary.x509 is asserted by the signer and cannot be trusted to validate the certificate,
+	// only the authentic signing time of notary.x509.signingAuthority may be in the past
+	var verifyTime time.Time
+	switch env.signingScheme {
+	case SigningSchemeX509:
+		if env.signingTime.IsZero() {
+			return result, fmt.Errorf("signing time is missing%.0w", ErrVerifyFailed)
+		}
+		verifyTime = time.Now()
+	case SigningSchemeX509SigningAuthority:
+		if env.authenticTime.IsZero() {
+			return result, fmt.Errorf("authentic signing time is missing%.0w", ErrVerifyFailed)
+		}
+		verifyTime = env.authenticTime
+	default:
+		return result, fmt.Errorf("unsupported signing scheme %q%.0w", env.signingScheme, ErrVerifyFailed)
+	}
+	if env.expiry != nil && time.Now().After(*env.expiry) {
+		return result, fmt.Errorf("signature expired at %s%.0w", env.expiry.Format(time.RFC3339), ErrVerifyFailed)
+	}
+	if len(env.chain) == 0 {
+		return result, fmt.Errorf("certificate chain is missing%.0w", ErrVerifyFailed)
+	}
+	leaf := env.chain[0]
+	result.Subject = leaf.Subject.String()
+	result.Issuer = leaf.Issuer.String()
+	intermediates := x509.NewCertPool()
+	for _, c := range env.chain[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         ts.pool,
+		Intermediates: intermediates,
+		CurrentTime:   verifyTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return result, fmt.Errorf("untrusted certificate %s: %v%.0w", result.Subject, err, ErrVerifyFailed)
+	}
+	err = verifySignature(leaf.PublicKey, env.alg, env.signingInput, env.signature)
+	if err != nil {
+		return result, err
+	}
+	// only trust the payload after the signature is verified
+	p := Payload{}
+	err = json.Unmarshal(env.payload, &p)
+	if err != nil {
+		return result, fmt.Errorf("failed to parse payload: %v%.0w", err, types.ErrParsingFailed)
+	}
+	result.Target = p.TargetArtifact
+	if p.TargetArtifact.Digest != subject.Digest || p.TargetArtifact.Size != subject.Size {
+		return result, fmt.Errorf("signature is for %s, expected %s%.0w", p.TargetArtifact.Digest, subject.Digest, types.ErrDigestMismatch)
+	}
+	return result, nil
+}
+
+// verifySignature checks the signature of the input with the public key and JWS algorithm name.
+func verifySignature(pub crypto.PublicKey, alg string, input, sig []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "PS256", "ES256":
+		hash = crypto.SHA256
+	case "PS384", "ES384":
+		hash = crypto.SHA384
+	case "PS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported algorithm %q%.0w", alg, ErrVerifyFailed)
+	}
+	h := hash.New()
+	h.Write(input)
+	digest := h.Sum(nil)
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "PS") {
+			return fmt.Errorf("algorithm %s does not match RSA key%.0w", alg, ErrVerifyFailed)
+		}
+		err := rsa.VerifyPSS(key, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		if err != nil {
+			return fmt.Errorf("%v%.0w", err, ErrVerifyFailed)
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("algorithm %s does not match ECDSA key%.0w", alg, ErrVerifyFailed)
+		}
+		// signatures are the concatenated r and s values
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return fmt.Errorf("invalid signature length %d%.0w", len(sig), ErrVerifyFailed)
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return ErrVerifyFailed
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T%.0w", pub, ErrVerifyFailed)
+	}
+	return nil
+}
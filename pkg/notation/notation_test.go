@@ -0,0 +1,466 @@
+package notation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+type testPKI struct {
+	ca      *x509.Certificate
+	leaf    *x509.Certificate
+	leafKey crypto.Signer
+}
+
+func newTestPKI(t *testing.T, leafKey crypto.Signer) testPKI {
+	t.Helper()
+	now := time.Now()
+	return newTestPKIValid(t, leafKey, now.Add(-1*time.Hour), now.Add(time.Hour))
+}
+
+// newTestPKIValid creates a ca and leaf certificate that are valid between notBefore and notAfter.
+func newTestPKIValid(t *testing.T, leafKey crypto.Signer, notBefore, notAfter time.Time) testPKI {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to create ca: %v", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("failed to parse ca: %v", err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test signer"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to create leaf: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatalf("failed to parse leaf: %v", err)
+	}
+	return testPKI{ca: ca, leaf: leaf, leafKey: leafKey}
+}
+
+func testSign(t *testing.T, key crypto.Signer, alg string, input []byte) []byte {
+	t.Helper()
+	var hash crypto.Hash
+	switch alg {
+	case "ES256", "PS256":
+		hash = crypto.SHA256
+	case "ES384", "PS384":
+		hash = crypto.SHA384
+	default:
+		hash = crypto.SHA512
+	}
+	h := hash.New()
+	h.Write(input)
+	dig := h.Sum(nil)
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, dig)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig := make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		return sig
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPSS(rand.Reader, k, hash, dig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		return sig
+	}
+	t.Fatalf("unsupported key %T", key)
+	return nil
+}
+
+func testPayload(t *testing.T, d types.Descriptor) []byte {
+	t.Helper()
+	b, err := json.Marshal(Payload{TargetArtifact: d})
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %v", err)
+	}
+	return b
+}
+
+func testJWS(t *testing.T, pki testPKI, alg string, payload []byte, expiry *time.Time) []byte {
+	t.Helper()
+	return testJWSAt(t, pki, alg, payload, expiry, time.Now().Add(-1*time.Minute))
+}
+
+func testJWSAt(t *testing.T, pki testPKI, alg string, payload []byte, expiry *time.Time, signingTime time.Time) []byte {
+	t.Helper()
+	signingTime = signingTime.Truncate(time.Second)
+	protected := jwsProtected{
+		Alg:           alg,
+		Cty:           MediaTypePayload,
+		Crit:          []string{headerSigningScheme},
+		SigningScheme: SigningSchemeX509,
+		SigningTime:   &signingTime,
+		Expiry:        expiry,
+	}
+	if expiry != nil {
+		protected.Crit = append(protected.Crit, headerExpiry)
+	}
+	pb, err := json.Marshal(protected)
+	if err != nil {
+		t.Fatalf("failed to marshal protected header: %v", err)
+	}
+	env := jwsEnvelope{
+		Payload:   base64.RawURLEncoding.EncodeToString(payload),
+		Protected: base64.RawURLEncoding.EncodeToString(pb),
+		Header: jwsHeader{
+			X5C: []string{base64.StdEncoding.EncodeToString(pki.leaf.Raw)},
+		},
+	}
+	sig := testSign(t, pki.leafKey, alg, []byte(env.Protected+"."+env.Payload))
+	env.Signature = base64.RawURLEncoding.EncodeToString(sig)
+	b, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+	return b
+}
+
+func testCOSE(t *testing.T, pki testPKI, alg int64, payload []byte) []byte {
+	t.Helper()
+	protected := cborEncode(map[interface{}]interface{}{
+		int64(coseLabelAlg):  alg,
+		int64(coseLabelCrit): []interface{}{headerSigningScheme},
+		int64(coseLabelCty):  MediaTypePayload,
+		headerSigningScheme:  SigningSchemeX509,
+		headerSigningTime:    cborTag{num: cborTagEpochSec, val: time.Now().Add(-1 * time.Minute).Unix()},
+	})
+	input := cborEncode([]interface{}{"Signature1", protected, []byte{}, payload})
+	sig := testSign(t, pki.leafKey, coseAlgs[alg], input)
+	return cborEncode(cborTag{num: coseTagSign1, val: []interface{}{
+		protected,
+		map[interface{}]interface{}{int64(coseLabelX5c): []interface{}{pki.leaf.Raw}},
+		payload,
+		sig,
+	}})
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	pkiEC := newTestPKI(t, ecKey)
+	pkiRSA := newTestPKI(t, rsaKey)
+	pkiOther := newTestPKI(t, ecKey)
+	pkiExpired := newTestPKIValid(t, ecKey, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	subject := types.Descriptor{
+		MediaType: types.MediaTypeOCI1Manifest,
+		Digest:    digest.FromString("image"),
+		Size:      1234,
+	}
+	other := types.Descriptor{
+		MediaType: types.MediaTypeOCI1Manifest,
+		Digest:    digest.FromString("other"),
+		Size:      1234,
+	}
+	expired := time.Now().Add(-1 * time.Second)
+	// replace the signed payload with a payload for the subject
+	tamperedEnv := jwsEnvelope{}
+	err = json.Unmarshal(testJWS(t, pkiEC, "ES256", testPayload(t, other), nil), &tamperedEnv)
+	if err != nil {
+		t.Fatalf("failed to parse envelope: %v", err)
+	}
+	tamperedEnv.Payload = base64.RawURLEncoding.EncodeToString(testPayload(t, subject))
+	tampered, err := json.Marshal(tamperedEnv)
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+
+	tt := []struct {
+		name      string
+		ts        *TrustStore
+		mediaType string
+		envelope  []byte
+		expectErr error
+		format    string
+	}{
+		{
+			name:      "jws ecdsa",
+			ts:        NewTrustStore(pkiEC.ca),
+			mediaType: MediaTypeJWS,
+			envelope:  testJWS(t, pkiEC, "ES256", testPayload(t, subject), nil),
+			format:    "jws",
+		},
+		{
+			name:      "jws rsa",
+			ts:        NewTrustStore(pkiRSA.ca),
+			mediaType: MediaTypeJWS,
+			envelope:  testJWS(t, pkiRSA, "PS384", testPayload(t, subject), nil),
+			format:    "jws",
+		},
+		{
+			name:      "cose ecdsa",
+			ts:        NewTrustStore(pkiEC.ca),
+			mediaType: MediaTypeCOSE,
+			envelope:  testCOSE(t, pkiEC, -7, testPayload(t, subject)),
+			format:    "cose",
+		},
+		{
+			name:      "cose rsa",
+			ts:        NewTrustStore(pkiRSA.ca),
+			mediaType: MediaTypeCOSE,
+			envelope:  testCOSE(t, pkiRSA, -37, testPayload(t, subject)),
+			format:    "cose",
+		},
+		{
+			name:      "untrusted",
+			ts:        NewTrustStore(pkiOther.ca),
+			mediaType: MediaTypeJWS,
+			envelope:  testJWS(t, pkiEC, "ES256", testPayload(t, subject), nil),
+			expectErr: ErrVerifyFailed,
+		},
+		{
+			name:      "wrong subject",
+			ts:        NewTrustStore(pkiEC.ca),
+			mediaType: MediaTypeCOSE,
+			envelope:  testCOSE(t, pkiEC, -7, testPayload(t, other)),
+			expectErr: types.ErrDigestMismatch,
+		},
+		{
+			name:      "tampered",
+			ts:        NewTrustStore(pkiEC.ca),
+			mediaType: MediaTypeJWS,
+			envelope:  tampered,
+			expectErr: ErrVerifyFailed,
+		},
+		{
+			name:      "alg mismatch",
+			ts:        NewTrustStore(pkiEC.ca),
+			mediaType: MediaTypeJWS,
+			envelope:  testJWS(t, pkiRSA, "PS256", testPayload(t, subject), nil),
+			expectErr: ErrVerifyFailed,
+		},
+		{
+			name:      "expired",
+			ts:        NewTrustStore(pkiEC.ca),
+			mediaType: MediaTypeJWS,
+			envelope:  testJWS(t, pkiEC, "ES256", testPayload(t, subject), &expired),
+			expectErr: ErrVerifyFailed,
+		},
+		{
+			name:      "expired certificate with backdated signing time",
+			ts:        NewTrustStore(pkiExpired.ca),
+			mediaType: MediaTypeJWS,
+			envelope:  testJWSAt(t, pkiExpired, "ES256", testPayload(t, subject), nil, time.Now().Add(-36*time.Hour)),
+			expectErr: ErrVerifyFailed,
+		},
+		{
+			name:      "empty trust store",
+			ts:        NewTrustStore(),
+			mediaType: MediaTypeJWS,
+			envelope:  testJWS(t, pkiEC, "ES256", testPayload(t, subject), nil),
+			expectErr: ErrVerifyFailed,
+		},
+		{
+			name:      "invalid cose",
+			ts:        NewTrustStore(pkiEC.ca),
+			mediaType: MediaTypeCOSE,
+			envelope:  []byte{0x84, 0x40},
+			expectErr: types.ErrParsingFailed,
+		},
+		{
+			name:      "unknown media type",
+			ts:        NewTrustStore(pkiEC.ca),
+			mediaType: "application/octet-stream",
+			envelope:  []byte("{}"),
+			expectErr: types.ErrUnsupportedMediaType,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			result, err := Verify(tc.ts, tc.mediaType, tc.envelope, subject)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to verify: %v", err)
+			}
+			if result.Format != tc.format || result.Subject != "CN=test signer" || result.Target.Digest != subject.Digest {
+				t.Errorf("unexpected result: %v", result)
+			}
+		})
+	}
+}
+
+func TestLoadTrustStore(t *testing.T) {
+	t.Parallel()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	pki := newTestPKI(t, key)
+	pkiOther := newTestPKI(t, key)
+	dir := t.TempDir()
+	_, err = LoadTrustStore(dir)
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("unexpected error without stores: %v", err)
+	}
+	_, err = LoadTrustStore(dir, "ca:test")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error for missing store: %v", err)
+	}
+	for _, store := range []string{"test", "ca:", "ca:../test", "tsa:test"} {
+		_, err = LoadTrustStore(dir, store)
+		if err == nil {
+			t.Errorf("invalid store %s did not fail", store)
+		}
+	}
+	caDir := filepath.Join(dir, "x509", "ca", "test")
+	otherDir := filepath.Join(dir, "x509", "ca", "other")
+	for _, d := range []string{caDir, otherDir} {
+		err = os.MkdirAll(d, 0755)
+		if err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+	err = os.WriteFile(filepath.Join(caDir, "ca.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pki.ca.Raw}), 0644)
+	if err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(caDir, "README"), []byte("ignored"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(otherDir, "ca.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pkiOther.ca.Raw}), 0644)
+	if err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+	// a store that is not selected is never read
+	err = os.WriteFile(filepath.Join(otherDir, "bad.pem"), []byte("not a cert"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	ts, err := LoadTrustStore(dir, "ca:test")
+	if err != nil {
+		t.Fatalf("failed to load trust store: %v", err)
+	}
+	subject := types.Descriptor{MediaType: types.MediaTypeOCI1Manifest, Digest: digest.FromString("image"), Size: 10}
+	_, err = Verify(ts, MediaTypeJWS, testJWS(t, pki, "ES256", testPayload(t, subject), nil), subject)
+	if err != nil {
+		t.Errorf("failed to verify with loaded trust store: %v", err)
+	}
+	_, err = Verify(ts, MediaTypeJWS, testJWS(t, pkiOther, "ES256", testPayload(t, subject), nil), subject)
+	if !errors.Is(err, ErrVerifyFailed) {
+		t.Errorf("signature from an unselected store was not rejected: %v", err)
+	}
+	_, err = LoadTrustStore(dir, "ca:test", "ca:other")
+	if !errors.Is(err, types.ErrParsingFailed) {
+		t.Errorf("unexpected error for invalid cert: %v", err)
+	}
+}
+
+func TestTrustPolicy(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "trustpolicy.json")
+	err := os.WriteFile(file, []byte(`{
+		"version": "1.0",
+		"trustPolicies": [
+			{"name": "default", "registryScopes": ["*"], "signatureVerification": {"level": "strict"}, "trustStores": ["ca:default"], "trustedIdentities": ["*"]},
+			{"name": "app", "registryScopes": ["registry.example.org/app"], "trustStores": ["ca:app", "signingAuthority:app"]}
+		]
+	}`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	doc, err := LoadTrustPolicy(file)
+	if err != nil {
+		t.Fatalf("failed to load policy: %v", err)
+	}
+	tt := []struct {
+		ref    string
+		expect string
+	}{
+		{ref: "registry.example.org/app:v1", expect: "app"},
+		{ref: "registry.example.org/other:v1", expect: "default"},
+		{ref: "ocidir://testrepo:v1", expect: "default"},
+	}
+	for _, tc := range tt {
+		r, err := ref.New(tc.ref)
+		if err != nil {
+			t.Fatalf("failed to parse ref %s: %v", tc.ref, err)
+		}
+		tp, err := doc.PolicyFor(r)
+		if err != nil {
+			t.Errorf("failed to find policy for %s: %v", tc.ref, err)
+		} else if tp.Name != tc.expect {
+			t.Errorf("policy for %s, expected %s, received %s", tc.ref, tc.expect, tp.Name)
+		}
+	}
+	doc.TrustPolicies = doc.TrustPolicies[1:]
+	r, err := ref.New("registry.example.org/other:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	_, err = doc.PolicyFor(r)
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("unexpected error for unmatched scope: %v", err)
+	}
+	err = os.WriteFile(file, []byte("{"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	_, err = LoadTrustPolicy(file)
+	if !errors.Is(err, types.ErrParsingFailed) {
+		t.Errorf("unexpected error for invalid policy: %v", err)
+	}
+}
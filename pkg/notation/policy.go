@@ -0,0 +1,62 @@
+package notation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// TrustPolicyDocument is the trustpolicy.json used by the notation CLI.
+type TrustPolicyDocument struct {
+	Version       string        `json:"version"`
+	TrustPolicies []TrustPolicy `json:"trustPolicies"`
+}
+
+// TrustPolicy selects the trust stores for a set of repositories.
+// Only the registry scopes and trust stores are used, other fields of the notation policy are ignored.
+type TrustPolicy struct {
+	Name           string   `json:"name"`
+	RegistryScopes []string `json:"registryScopes"`
+	TrustStores    []string `json:"trustStores"`
+}
+
+// LoadTrustPolicy reads a trust policy document.
+func LoadTrustPolicy(file string) (TrustPolicyDocument, error) {
+	doc := TrustPolicyDocument{}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return doc, err
+	}
+	err = json.Unmarshal(b, &doc)
+	if err != nil {
+		return doc, fmt.Errorf("failed to parse %s: %v%.0w", file, err, types.ErrParsingFailed)
+	}
+	return doc, nil
+}
+
+// PolicyFor returns the policy with a registry scope matching the repository of the ref.
+// A scope of "registry/repository" is preferred over the "*" wildcard, and the path is used for an OCI Layout.
+func (doc TrustPolicyDocument) PolicyFor(r ref.Ref) (TrustPolicy, error) {
+	scope := r.Registry + "/" + r.Repository
+	if r.Scheme != "reg" {
+		scope = r.Path
+	}
+	var wildcard *TrustPolicy
+	for i, tp := range doc.TrustPolicies {
+		for _, s := range tp.RegistryScopes {
+			if s == scope {
+				return tp, nil
+			}
+			if s == "*" && wildcard == nil {
+				wildcard = &doc.TrustPolicies[i]
+			}
+		}
+	}
+	if wildcard != nil {
+		return *wildcard, nil
+	}
+	return TrustPolicy{}, fmt.Errorf("no trust policy for %s%.0w", scope, types.ErrNotFound)
+}
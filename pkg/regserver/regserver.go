@@ -0,0 +1,448 @@
+// Package regserver exposes regclient operations over a small REST API.
+//
+// This allows services written in other languages to copy images, inspect manifests, and manage tags
+// through a single long running client that reuses connections, auth tokens, and rate limits.
+// Every request is passed to an [AuthFunc] before running the operation.
+// Without an [AuthFunc], requests that modify a registry are rejected unless [WithInsecureNoAuth] is set.
+//
+//	rc := regclient.New(regclient.WithDockerCreds())
+//	s := regserver.New(rc, regserver.WithAuth(regserver.AuthToken("secret")))
+//	err := http.ListenAndServe(":8080", s)
+//
+// The API includes:
+//
+//	GET    /healthz                            returns 200 when the server is running
+//	POST   /v1/copy                            copy an image, the body is a [CopyRequest]
+//	GET    /v1/manifest?ref=<ref>[&platform=p] returns the manifest
+//	GET    /v1/tags?repo=<repo>                returns a [TagListResponse]
+//	DELETE /v1/tags?ref=<ref>                  deletes a tag
+//
+// Errors are returned as an [ErrorResponse].
+// Only the REST API is provided, there is no gRPC interface.
+package regserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Action identifies the operation being authorized.
+type Action string
+
+const (
+	// ActionCopy copies an image from the first ref to the second ref.
+	ActionCopy Action = "copy"
+	// ActionInspect reads a manifest.
+	ActionInspect Action = "inspect"
+	// ActionTagList lists the tags in a repository.
+	ActionTagList Action = "tag-list"
+	// ActionTagDelete deletes a tag.
+	ActionTagDelete Action = "tag-delete"
+)
+
+// modifies returns true for actions that change the content of a registry.
+func (a Action) modifies() bool {
+	switch a {
+	case ActionInspect, ActionTagList:
+		return false
+	default:
+		return true
+	}
+}
+
+// maxBodySize limits the size of a request body.
+const maxBodySize = 1024 * 1024
+
+var (
+	// ErrUnauthorized is returned by an [AuthFunc] when the request has no valid credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned by an [AuthFunc] when the credentials do not permit the action.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// AuthFunc authorizes an action on the refs before it runs.
+// Returning an error wrapping [ErrForbidden] responds with a 403, any other error responds with a 401.
+type AuthFunc func(req *http.Request, action Action, refs ...ref.Ref) error
+
+// Server is an [http.Handler] for the API.
+type Server struct {
+	rc              *regclient.RegClient
+	auth            AuthFunc
+	log             *logrus.Logger
+	allowLocal      bool
+	insecureNoAuth  bool
+	manifestReplace bool
+}
+
+// Opts is used to configure the [Server].
+type Opts func(*Server)
+
+// New returns a server using the regclient for all operations.
+// Without [WithAuth], only requests that do not modify a registry are accepted, see [WithInsecureNoAuth].
+func New(rc *regclient.RegClient, opts ...Opts) *Server {
+	s := &Server{
+		rc:  rc,
+		log: &logrus.Logger{Out: io.Discard},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithAllowLocal permits refs to local schemes like ocidir, giving clients access to the server filesystem.
+func WithAllowLocal() Opts {
+	return func(s *Server) {
+		s.allowLocal = true
+	}
+}
+
+// WithAuth sets the function used to authorize each request.
+func WithAuth(fn AuthFunc) Opts {
+	return func(s *Server) {
+		s.auth = fn
+	}
+}
+
+// WithInsecureNoAuth accepts every request when [WithAuth] is not set, including requests that modify a registry.
+// This should only be used when access to the server is restricted by other means.
+func WithInsecureNoAuth() Opts {
+	return func(s *Server) {
+		s.insecureNoAuth = true
+	}
+}
+
+// WithLog sets the logger.
+func WithLog(log *logrus.Logger) Opts {
+	return func(s *Server) {
+		s.log = log
+	}
+}
+
+// WithTagManifestReplace allows tag deletes to replace the tag with a temporary manifest that is then deleted.
+// This is needed to delete tags on registries that do not support the OCI tag delete API.
+func WithTagManifestReplace() Opts {
+	return func(s *Server) {
+		s.manifestReplace = true
+	}
+}
+
+// AuthToken accepts requests with an "Authorization: Bearer <token>" header matching one of the tokens.
+func AuthToken(tokens ...string) AuthFunc {
+	return func(req *http.Request, action Action, refs ...ref.Ref) error {
+		h := req.Header.Get("Authorization")
+		if len(h) < 7 || !strings.EqualFold(h[:7], "bearer ") {
+			return ErrUnauthorized
+		}
+		reqToken := []byte(h[7:])
+		for _, t := range tokens {
+			if subtle.ConstantTimeCompare(reqToken, []byte(t)) == 1 {
+				return nil
+			}
+		}
+		return ErrUnauthorized
+	}
+}
+
+// CopyRequest is the body of a copy request.
+type CopyRequest struct {
+	Source          string   `json:"source"`
+	Target          string   `json:"target"`
+	Referrers       bool     `json:"referrers,omitempty"`
+	DigestTags      bool     `json:"digestTags,omitempty"`
+	IncludeExternal bool     `json:"includeExternal,omitempty"`
+	ForceRecursive  bool     `json:"forceRecursive,omitempty"`
+	Platforms       []string `json:"platforms,omitempty"`
+}
+
+// CopyResponse is returned after a successful copy.
+type CopyResponse struct {
+	Source string           `json:"source"`
+	Target string           `json:"target"`
+	Desc   types.Descriptor `json:"descriptor"`
+}
+
+// TagListResponse is returned when listing tags.
+type TagListResponse struct {
+	Repository string   `json:"repository"`
+	Tags       []string `json:"tags"`
+}
+
+// ErrorResponse is returned for any failed request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP implements [http.Handler].
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.log.WithFields(logrus.Fields{
+		"method": req.Method,
+		"path":   req.URL.Path,
+		"remote": req.RemoteAddr,
+	}).Debug("API request")
+	switch req.URL.Path {
+	case "/healthz":
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	case "/v1/copy":
+		if req.Method != http.MethodPost {
+			s.writeErr(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", req.Method))
+			return
+		}
+		s.copy(w, req)
+	case "/v1/manifest":
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			s.writeErr(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", req.Method))
+			return
+		}
+		s.manifest(w, req)
+	case "/v1/tags":
+		switch req.Method {
+		case http.MethodGet:
+			s.tagList(w, req)
+		case http.MethodDelete:
+			s.tagDelete(w, req)
+		default:
+			s.writeErr(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", req.Method))
+		}
+	default:
+		s.writeErr(w, http.StatusNotFound, fmt.Errorf("path %s not found", req.URL.Path))
+	}
+}
+
+func (s *Server) copy(w http.ResponseWriter, req *http.Request) {
+	cr := CopyRequest{}
+	err := json.NewDecoder(io.LimitReader(req.Body, maxBodySize)).Decode(&cr)
+	if err != nil {
+		s.writeErr(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %w", err))
+		return
+	}
+	rSrc, ok := s.parseRef(w, cr.Source)
+	if !ok {
+		return
+	}
+	rTgt, ok := s.parseRef(w, cr.Target)
+	if !ok {
+		return
+	}
+	if !s.authorize(w, req, ActionCopy, rSrc, rTgt) {
+		return
+	}
+	opts := []regclient.ImageOpts{}
+	if cr.Referrers {
+		opts = append(opts, regclient.ImageWithReferrers())
+	}
+	if cr.DigestTags {
+		opts = append(opts, regclient.ImageWithDigestTags())
+	}
+	if cr.IncludeExternal {
+		opts = append(opts, regclient.ImageWithIncludeExternal())
+	}
+	if cr.ForceRecursive {
+		opts = append(opts, regclient.ImageWithForceRecursive())
+	}
+	if len(cr.Platforms) > 0 {
+		opts = append(opts, regclient.ImageWithPlatforms(cr.Platforms))
+	}
+	ctx := req.Context()
+	err = s.rc.ImageCopy(ctx, rSrc, rTgt, opts...)
+	if err != nil {
+		s.writeErr(w, errStatus(err), err)
+		return
+	}
+	m, err := s.rc.ManifestHead(ctx, rTgt, regclient.WithManifestRequireDigest())
+	if err != nil {
+		s.writeErr(w, errStatus(err), err)
+		return
+	}
+	s.log.WithFields(logrus.Fields{
+		"source": rSrc.CommonName(),
+		"target": rTgt.CommonName(),
+	}).Info("Image copied")
+	writeJSON(w, http.StatusOK, CopyResponse{
+		Source: rSrc.CommonName(),
+		Target: rTgt.CommonName(),
+		Desc:   m.GetDescriptor(),
+	})
+}
+
+func (s *Server) manifest(w http.ResponseWriter, req *http.Request) {
+	r, ok := s.parseRef(w, req.URL.Query().Get("ref"))
+	if !ok {
+		return
+	}
+	if !s.authorize(w, req, ActionInspect, r) {
+		return
+	}
+	ctx := req.Context()
+	m, err := s.rc.ManifestGet(ctx, r)
+	if err != nil {
+		s.writeErr(w, errStatus(err), err)
+		return
+	}
+	if pStr := req.URL.Query().Get("platform"); pStr != "" && m.IsList() {
+		p, err := platform.Parse(pStr)
+		if err != nil {
+			s.writeErr(w, http.StatusBadRequest, err)
+			return
+		}
+		d, err := manifest.GetPlatformDesc(m, &p)
+		if err != nil {
+			s.writeErr(w, errStatus(err), err)
+			return
+		}
+		m, err = s.rc.ManifestGet(ctx, r.SetDigest(d.Digest.String()))
+		if err != nil {
+			s.writeErr(w, errStatus(err), err)
+			return
+		}
+	}
+	raw, err := m.RawBody()
+	if err != nil {
+		s.writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", m.GetDescriptor().MediaType)
+	w.Header().Set("Docker-Content-Digest", m.GetDescriptor().Digest.String())
+	w.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(raw)
+	}
+}
+
+func (s *Server) tagList(w http.ResponseWriter, req *http.Request) {
+	r, ok := s.parseRef(w, req.URL.Query().Get("repo"))
+	if !ok {
+		return
+	}
+	r = r.SetTag("")
+	if !s.authorize(w, req, ActionTagList, r) {
+		return
+	}
+	tl, err := s.rc.TagList(req.Context(), r)
+	if err != nil {
+		s.writeErr(w, errStatus(err), err)
+		return
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		s.writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, TagListResponse{Repository: r.CommonName(), Tags: tags})
+}
+
+func (s *Server) tagDelete(w http.ResponseWriter, req *http.Request) {
+	r, ok := s.parseRef(w, req.URL.Query().Get("ref"))
+	if !ok {
+		return
+	}
+	if r.Tag == "" || r.Digest != "" {
+		s.writeErr(w, http.StatusBadRequest, fmt.Errorf("a tag without a digest is required: %s", r.CommonName()))
+		return
+	}
+	if !s.authorize(w, req, ActionTagDelete, r) {
+		return
+	}
+	tagOpts := []scheme.TagOpts{}
+	if s.manifestReplace {
+		tagOpts = append(tagOpts, scheme.WithTagManifestReplace())
+	}
+	err := s.rc.TagDeleteWithOpts(req.Context(), r, tagOpts...)
+	if err != nil {
+		s.writeErr(w, errStatus(err), err)
+		return
+	}
+	s.log.WithFields(logrus.Fields{
+		"ref": r.CommonName(),
+	}).Info("Tag deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseRef parses a ref from the request, rejecting local schemes unless allowed.
+func (s *Server) parseRef(w http.ResponseWriter, refStr string) (ref.Ref, bool) {
+	if refStr == "" {
+		s.writeErr(w, http.StatusBadRequest, fmt.Errorf("ref is required"))
+		return ref.Ref{}, false
+	}
+	r, err := ref.New(refStr)
+	if err != nil {
+		s.writeErr(w, http.StatusBadRequest, err)
+		return r, false
+	}
+	if r.Scheme != "reg" && !s.allowLocal {
+		s.writeErr(w, http.StatusBadRequest, fmt.Errorf("scheme %s is not allowed", r.Scheme))
+		return r, false
+	}
+	return r, true
+}
+
+func (s *Server) authorize(w http.ResponseWriter, req *http.Request, action Action, refs ...ref.Ref) bool {
+	var err error
+	if s.auth != nil {
+		err = s.auth(req, action, refs...)
+	} else if !s.insecureNoAuth && action.modifies() {
+		err = fmt.Errorf("action %s requires authentication to be configured on the server%.0w", action, ErrForbidden)
+	}
+	if err == nil {
+		return true
+	}
+	s.log.WithFields(logrus.Fields{
+		"action": action,
+		"remote": req.RemoteAddr,
+		"err":    err,
+	}).Warn("Request denied")
+	if errors.Is(err, ErrForbidden) {
+		s.writeErr(w, http.StatusForbidden, err)
+	} else {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		s.writeErr(w, http.StatusUnauthorized, err)
+	}
+	return false
+}
+
+func (s *Server) writeErr(w http.ResponseWriter, status int, err error) {
+	if status >= 500 {
+		s.log.WithFields(logrus.Fields{
+			"err": err,
+		}).Warn("API request failed")
+	}
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// errStatus returns the HTTP status for an error from regclient.
+func errStatus(err error) int {
+	switch {
+	case errors.Is(err, types.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, types.ErrInvalidReference), errors.Is(err, types.ErrMissingTag), errors.Is(err, types.ErrParsingFailed):
+		return http.StatusBadRequest
+	case errors.Is(err, types.ErrHTTPUnauthorized), errors.Is(err, types.ErrHTTPStatus):
+		return http.StatusBadGateway
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
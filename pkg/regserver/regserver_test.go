@@ -0,0 +1,192 @@
+package regserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/pkg/regtest"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+	tsReg := httptest.NewServer(regtest.New())
+	t.Cleanup(tsReg.Close)
+	regURL, _ := url.Parse(tsReg.URL)
+	rc := regclient.New(regclient.WithConfigHost(config.Host{
+		Name:      regURL.Host,
+		Hostname:  regURL.Host,
+		TLS:       config.TLSDisabled,
+		ReqPerSec: 1000,
+	}), regclient.WithRetryDelay(time.Millisecond*10, time.Millisecond*50))
+	token := "testtoken"
+	// the auth hook denies deleting tags in the "protected" repository
+	auth := func(req *http.Request, action Action, refs ...ref.Ref) error {
+		if err := AuthToken(token)(req, action, refs...); err != nil {
+			return err
+		}
+		for _, r := range refs {
+			if action == ActionTagDelete && r.Repository == "protected" {
+				return ErrForbidden
+			}
+		}
+		return nil
+	}
+	ts := httptest.NewServer(New(rc, WithAuth(auth), WithAllowLocal()))
+	t.Cleanup(ts.Close)
+	tsNoLocal := httptest.NewServer(New(rc))
+	t.Cleanup(tsNoLocal.Close)
+	tgt := regURL.Host + "/proj/repo:v2"
+
+	do := func(t *testing.T, base, method, path, tok string, body interface{}) *http.Response {
+		t.Helper()
+		var rdr *bytes.Reader
+		if body != nil {
+			b, err := json.Marshal(body)
+			if err != nil {
+				t.Fatalf("failed to marshal body: %v", err)
+			}
+			rdr = bytes.NewReader(b)
+		} else {
+			rdr = bytes.NewReader(nil)
+		}
+		req, err := http.NewRequest(method, base+path, rdr)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if tok != "" {
+			req.Header.Set("Authorization", "Bearer "+tok)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("health", func(t *testing.T) {
+		resp := do(t, ts.URL, http.MethodGet, "/healthz", "", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("unexpected status: %d", resp.StatusCode)
+		}
+	})
+	t.Run("unauthorized", func(t *testing.T) {
+		resp := do(t, ts.URL, http.MethodGet, "/v1/tags?repo="+url.QueryEscape(tgt), "wrong", nil)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected status: %d", resp.StatusCode)
+		}
+	})
+	t.Run("local denied", func(t *testing.T) {
+		resp := do(t, tsNoLocal.URL, http.MethodPost, "/v1/copy", "", CopyRequest{
+			Source: "ocidir://../../testdata/testrepo:v2",
+			Target: tgt,
+		})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("unexpected status: %d", resp.StatusCode)
+		}
+	})
+	t.Run("copy", func(t *testing.T) {
+		resp := do(t, ts.URL, http.MethodPost, "/v1/copy", token, CopyRequest{
+			Source:    "ocidir://../../testdata/testrepo:v2",
+			Target:    tgt,
+			Referrers: true,
+		})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status: %d", resp.StatusCode)
+		}
+		cr := CopyResponse{}
+		err := json.NewDecoder(resp.Body).Decode(&cr)
+		if err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		if cr.Desc.Digest == "" {
+			t.Errorf("digest missing from response")
+		}
+		// copy to a protected repository for the delete test
+		resp = do(t, ts.URL, http.MethodPost, "/v1/copy", token, CopyRequest{
+			Source: tgt,
+			Target: regURL.Host + "/protected:v2",
+		})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status: %d", resp.StatusCode)
+		}
+	})
+	t.Run("no auth", func(t *testing.T) {
+		resp := do(t, tsNoLocal.URL, http.MethodGet, "/v1/tags?repo="+url.QueryEscape(regURL.Host+"/proj/repo"), "", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("unexpected status listing tags: %d", resp.StatusCode)
+		}
+		resp = do(t, tsNoLocal.URL, http.MethodPost, "/v1/copy", "", CopyRequest{
+			Source: regURL.Host + "/proj/repo:v1",
+			Target: regURL.Host + "/proj/repo:no-auth",
+		})
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("unexpected status copying: %d", resp.StatusCode)
+		}
+		resp = do(t, tsNoLocal.URL, http.MethodDelete, "/v1/tags?ref="+url.QueryEscape(tgt), "", nil)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("unexpected status deleting a tag: %d", resp.StatusCode)
+		}
+	})
+	t.Run("manifest", func(t *testing.T) {
+		resp := do(t, ts.URL, http.MethodGet, "/v1/manifest?ref="+url.QueryEscape(tgt), token, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status: %d", resp.StatusCode)
+		}
+		if resp.Header.Get("Content-Type") != types.MediaTypeOCI1ManifestList {
+			t.Errorf("unexpected media type: %s", resp.Header.Get("Content-Type"))
+		}
+		resp = do(t, ts.URL, http.MethodGet, "/v1/manifest?platform=linux/amd64&ref="+url.QueryEscape(tgt), token, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status: %d", resp.StatusCode)
+		}
+		if resp.Header.Get("Content-Type") != types.MediaTypeOCI1Manifest {
+			t.Errorf("unexpected media type: %s", resp.Header.Get("Content-Type"))
+		}
+		resp = do(t, ts.URL, http.MethodGet, "/v1/manifest?ref="+url.QueryEscape(regURL.Host+"/proj/repo:missing"), token, nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("unexpected status for a missing manifest: %d", resp.StatusCode)
+		}
+	})
+	t.Run("tags", func(t *testing.T) {
+		resp := do(t, ts.URL, http.MethodGet, "/v1/tags?repo="+url.QueryEscape(regURL.Host+"/proj/repo"), token, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status: %d", resp.StatusCode)
+		}
+		tl := TagListResponse{}
+		err := json.NewDecoder(resp.Body).Decode(&tl)
+		if err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		found := false
+		for _, tag := range tl.Tags {
+			if tag == "v2" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("tag v2 not found: %v", tl.Tags)
+		}
+		resp = do(t, ts.URL, http.MethodDelete, "/v1/tags?ref="+url.QueryEscape(regURL.Host+"/protected:v2"), token, nil)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("unexpected status deleting a protected tag: %d", resp.StatusCode)
+		}
+		resp = do(t, ts.URL, http.MethodDelete, "/v1/tags?ref="+url.QueryEscape(tgt), token, nil)
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("unexpected status: %d", resp.StatusCode)
+		}
+		resp = do(t, ts.URL, http.MethodGet, "/v1/manifest?ref="+url.QueryEscape(tgt), token, nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("tag was not deleted, status: %d", resp.StatusCode)
+		}
+	})
+}
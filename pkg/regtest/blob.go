@@ -0,0 +1,203 @@
+package regtest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	// crypto libraries included for go-digest
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/google/uuid"
+	"github.com/opencontainers/go-digest"
+)
+
+func (reg *Registry) blobGet(w http.ResponseWriter, req *http.Request, name, dStr string) {
+	d, err := digest.Parse(dStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeDigestInvalid, "invalid digest")
+		return
+	}
+	r := reg.repoGet(name, false)
+	if r == nil {
+		writeError(w, http.StatusNotFound, errCodeNameUnknown, "repository not found")
+		return
+	}
+	b, ok := r.blobs[d]
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeBlobUnknown, "blob not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Docker-Content-Digest", d.String())
+	// ServeContent handles HEAD and range requests
+	http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(b))
+}
+
+func (reg *Registry) blobDelete(w http.ResponseWriter, name, dStr string) {
+	if !reg.deletes {
+		writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "delete is disabled")
+		return
+	}
+	d, err := digest.Parse(dStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeDigestInvalid, "invalid digest")
+		return
+	}
+	r := reg.repoGet(name, false)
+	if r == nil {
+		writeError(w, http.StatusNotFound, errCodeNameUnknown, "repository not found")
+		return
+	}
+	if _, ok := r.blobs[d]; !ok {
+		writeError(w, http.StatusNotFound, errCodeBlobUnknown, "blob not found")
+		return
+	}
+	delete(r.blobs, d)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// uploadPost handles blob mounts, monolithic uploads, and the start of chunked uploads.
+func (reg *Registry) uploadPost(w http.ResponseWriter, req *http.Request, name string, body []byte) {
+	query := req.URL.Query()
+	if mount := query.Get("mount"); mount != "" && query.Get("from") != "" {
+		d, err := digest.Parse(mount)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errCodeDigestInvalid, "invalid digest")
+			return
+		}
+		// a failed mount falls back to starting an upload
+		if from := reg.repoGet(query.Get("from"), false); from != nil {
+			if b, ok := from.blobs[d]; ok {
+				reg.repoGet(name, true).blobs[d] = b
+				blobCreated(w, name, d)
+				return
+			}
+		}
+	}
+	if dStr := query.Get("digest"); dStr != "" {
+		reg.blobStore(w, name, dStr, body)
+		return
+	}
+	id := uuid.New().String()
+	reg.uploads[id] = &upload{repo: name}
+	w.Header().Set("Location", "/v2/"+name+"/blobs/uploads/"+id)
+	w.Header().Set("Docker-Upload-UUID", id)
+	w.Header().Set("Range", "0-0")
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (reg *Registry) uploadGet(w http.ResponseWriter, name, id string) {
+	u := reg.uploadLookup(w, name, id)
+	if u == nil {
+		return
+	}
+	uploadStatus(w, name, id, u, http.StatusNoContent)
+}
+
+func (reg *Registry) uploadPatch(w http.ResponseWriter, req *http.Request, name, id string, body []byte) {
+	u := reg.uploadLookup(w, name, id)
+	if u == nil {
+		return
+	}
+	// chunks must be sent in order
+	if cr := req.Header.Get("Content-Range"); cr != "" {
+		start, _, err := parseContentRange(cr)
+		if err != nil || start != int64(u.buf.Len()) {
+			uploadStatus(w, name, id, u, http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+	}
+	u.buf.Write(body)
+	uploadStatus(w, name, id, u, http.StatusAccepted)
+}
+
+func (reg *Registry) uploadPut(w http.ResponseWriter, req *http.Request, name, id string, body []byte) {
+	u := reg.uploadLookup(w, name, id)
+	if u == nil {
+		return
+	}
+	dStr := req.URL.Query().Get("digest")
+	if dStr == "" {
+		writeError(w, http.StatusBadRequest, errCodeDigestInvalid, "digest is required to complete an upload")
+		return
+	}
+	u.buf.Write(body)
+	if reg.blobStore(w, name, dStr, u.buf.Bytes()) {
+		delete(reg.uploads, id)
+	}
+}
+
+func (reg *Registry) uploadDelete(w http.ResponseWriter, name, id string) {
+	if reg.uploadLookup(w, name, id) == nil {
+		return
+	}
+	delete(reg.uploads, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// uploadLookup returns the upload session, writing an error when it is not found.
+func (reg *Registry) uploadLookup(w http.ResponseWriter, name, id string) *upload {
+	u, ok := reg.uploads[id]
+	if !ok || u.repo != name {
+		writeError(w, http.StatusNotFound, errCodeBlobUploadUnknown, "upload not found")
+		return nil
+	}
+	return u
+}
+
+// blobStore verifies the content matches the digest and saves the blob, returning false on failure.
+func (reg *Registry) blobStore(w http.ResponseWriter, name, dStr string, b []byte) bool {
+	d, err := digest.Parse(dStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeDigestInvalid, "invalid digest")
+		return false
+	}
+	if d.Algorithm().FromBytes(b) != d {
+		writeError(w, http.StatusBadRequest, errCodeDigestInvalid, "content does not match digest")
+		return false
+	}
+	reg.repoGet(name, true).blobs[d] = append([]byte{}, b...)
+	blobCreated(w, name, d)
+	return true
+}
+
+func blobCreated(w http.ResponseWriter, name string, d digest.Digest) {
+	w.Header().Set("Location", "/v2/"+name+"/blobs/"+d.String())
+	w.Header().Set("Docker-Content-Digest", d.String())
+	w.WriteHeader(http.StatusCreated)
+}
+
+func uploadStatus(w http.ResponseWriter, name, id string, u *upload, status int) {
+	end := u.buf.Len() - 1
+	if end < 0 {
+		end = 0
+	}
+	w.Header().Set("Location", "/v2/"+name+"/blobs/uploads/"+id)
+	w.Header().Set("Docker-Upload-UUID", id)
+	w.Header().Set("Range", fmt.Sprintf("0-%d", end))
+	w.WriteHeader(status)
+}
+
+// parseContentRange parses the "start-end" value used by chunked uploads.
+func parseContentRange(cr string) (int64, int64, error) {
+	cr = strings.TrimPrefix(cr, "bytes ")
+	cr = strings.TrimPrefix(cr, "bytes=")
+	startStr, endStr, ok := strings.Cut(cr, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid content range %s", cr)
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := strconv.ParseInt(endStr, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
@@ -0,0 +1,175 @@
+package regtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types"
+)
+
+type manifestEntry struct {
+	raw     []byte
+	desc    types.Descriptor // descriptor returned by the referrers API
+	subject digest.Digest
+}
+
+// manifestFields are the fields parsed from any supported manifest media type.
+type manifestFields struct {
+	MediaType    string             `json:"mediaType"`
+	ArtifactType string             `json:"artifactType"`
+	Config       *types.Descriptor  `json:"config"`
+	Layers       []types.Descriptor `json:"layers"`
+	Manifests    []types.Descriptor `json:"manifests"`
+	Subject      *types.Descriptor  `json:"subject"`
+	Annotations  map[string]string  `json:"annotations"`
+}
+
+func (reg *Registry) manifestGet(w http.ResponseWriter, req *http.Request, name, reference string) {
+	r := reg.repoGet(name, false)
+	if r == nil {
+		writeError(w, http.StatusNotFound, errCodeNameUnknown, "repository not found")
+		return
+	}
+	d, err := digest.Parse(reference)
+	if err != nil {
+		var ok bool
+		d, ok = r.tags[reference]
+		if !ok {
+			writeError(w, http.StatusNotFound, errCodeManifestUnknown, "tag not found")
+			return
+		}
+	}
+	me, ok := r.manifests[d]
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeManifestUnknown, "manifest not found")
+		return
+	}
+	w.Header().Set("Content-Type", me.desc.MediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(me.raw)))
+	w.Header().Set("Docker-Content-Digest", d.String())
+	w.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(me.raw)
+	}
+}
+
+func (reg *Registry) manifestPut(w http.ResponseWriter, req *http.Request, name, reference string, body []byte) {
+	mf := manifestFields{}
+	if err := json.Unmarshal(body, &mf); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeManifestInvalid, "failed to parse manifest")
+		return
+	}
+	mt := req.Header.Get("Content-Type")
+	if mt == "" {
+		mt = mf.MediaType
+	}
+	if mt == "" {
+		writeError(w, http.StatusBadRequest, errCodeManifestInvalid, "media type is required")
+		return
+	}
+	var d digest.Digest
+	tag := ""
+	if dRef, err := digest.Parse(reference); err == nil {
+		d = dRef.Algorithm().FromBytes(body)
+		if d != dRef {
+			writeError(w, http.StatusBadRequest, errCodeDigestInvalid, "manifest does not match digest")
+			return
+		}
+	} else if reTag.MatchString(reference) {
+		tag = reference
+		d = digest.Canonical.FromBytes(body)
+	} else {
+		writeError(w, http.StatusBadRequest, errCodeManifestInvalid, "invalid tag")
+		return
+	}
+
+	// content referenced by the manifest must already exist in the repository
+	r := reg.repoGet(name, false)
+	blobs := []types.Descriptor{}
+	if mf.Config != nil {
+		blobs = append(blobs, *mf.Config)
+	}
+	for _, l := range mf.Layers {
+		// external layers are not pushed to the registry
+		if len(l.URLs) == 0 {
+			blobs = append(blobs, l)
+		}
+	}
+	for _, bd := range blobs {
+		if r == nil || r.blobs[bd.Digest] == nil {
+			writeError(w, http.StatusBadRequest, errCodeManifestBlobUnknown, "blob not found: "+bd.Digest.String())
+			return
+		}
+	}
+	for _, md := range mf.Manifests {
+		if r == nil || r.manifests[md.Digest] == nil {
+			writeError(w, http.StatusBadRequest, errCodeManifestUnknown, "manifest not found: "+md.Digest.String())
+			return
+		}
+	}
+
+	me := &manifestEntry{
+		raw: append([]byte{}, body...),
+		desc: types.Descriptor{
+			MediaType:    mt,
+			ArtifactType: mf.ArtifactType,
+			Size:         int64(len(body)),
+			Digest:       d,
+			Annotations:  mf.Annotations,
+		},
+	}
+	if me.desc.ArtifactType == "" && mf.Config != nil {
+		me.desc.ArtifactType = mf.Config.MediaType
+	}
+	if mf.Subject != nil {
+		me.subject = mf.Subject.Digest
+	}
+	r = reg.repoGet(name, true)
+	r.manifests[d] = me
+	if tag != "" {
+		r.tags[tag] = d
+	}
+	if me.subject != "" && reg.referrers {
+		w.Header().Set("OCI-Subject", me.subject.String())
+	}
+	w.Header().Set("Location", "/v2/"+name+"/manifests/"+d.String())
+	w.Header().Set("Docker-Content-Digest", d.String())
+	w.WriteHeader(http.StatusCreated)
+}
+
+// manifestDelete removes a tag, or a manifest and every tag pointing to it.
+func (reg *Registry) manifestDelete(w http.ResponseWriter, name, reference string) {
+	if !reg.deletes {
+		writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "delete is disabled")
+		return
+	}
+	r := reg.repoGet(name, false)
+	if r == nil {
+		writeError(w, http.StatusNotFound, errCodeNameUnknown, "repository not found")
+		return
+	}
+	d, err := digest.Parse(reference)
+	if err != nil {
+		if _, ok := r.tags[reference]; !ok {
+			writeError(w, http.StatusNotFound, errCodeManifestUnknown, "tag not found")
+			return
+		}
+		delete(r.tags, reference)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if _, ok := r.manifests[d]; !ok {
+		writeError(w, http.StatusNotFound, errCodeManifestUnknown, "manifest not found")
+		return
+	}
+	delete(r.manifests, d)
+	for tag, tagDig := range r.tags {
+		if tagDig == d {
+			delete(r.tags, tag)
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
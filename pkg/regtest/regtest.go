@@ -0,0 +1,287 @@
+// Package regtest provides an in-memory OCI distribution registry for testing.
+//
+// The registry implements the pull, push, tag listing, catalog, and referrers APIs from the OCI distribution spec,
+// including chunked uploads and cross repository blob mounts.
+// Content is only held in memory and is lost when the registry is discarded.
+//
+//	reg := regtest.New()
+//	ts := httptest.NewServer(reg)
+//	defer ts.Close()
+//	u, _ := url.Parse(ts.URL)
+//	rc := regclient.New(regclient.WithConfigHost(config.Host{
+//		Name:     u.Host,
+//		Hostname: u.Host,
+//		TLS:      config.TLSDisabled,
+//	}))
+package regtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// error codes from the OCI distribution spec
+const (
+	errCodeBlobUnknown         = "BLOB_UNKNOWN"
+	errCodeBlobUploadInvalid   = "BLOB_UPLOAD_INVALID"
+	errCodeBlobUploadUnknown   = "BLOB_UPLOAD_UNKNOWN"
+	errCodeDigestInvalid       = "DIGEST_INVALID"
+	errCodeManifestBlobUnknown = "MANIFEST_BLOB_UNKNOWN"
+	errCodeManifestInvalid     = "MANIFEST_INVALID"
+	errCodeManifestUnknown     = "MANIFEST_UNKNOWN"
+	errCodeNameInvalid         = "NAME_INVALID"
+	errCodeNameUnknown         = "NAME_UNKNOWN"
+	errCodeUnauthorized        = "UNAUTHORIZED"
+	errCodeUnsupported         = "UNSUPPORTED"
+)
+
+var (
+	reName      = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*$`)
+	reTag       = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+	reManifest  = regexp.MustCompile(`^/v2/(.+)/manifests/([^/]+)$`)
+	reUploadNew = regexp.MustCompile(`^/v2/(.+)/blobs/uploads/?$`)
+	reUpload    = regexp.MustCompile(`^/v2/(.+)/blobs/uploads/([^/]+)$`)
+	reBlob      = regexp.MustCompile(`^/v2/(.+)/blobs/([^/]+)$`)
+	reTagList   = regexp.MustCompile(`^/v2/(.+)/tags/list$`)
+	reReferrers = regexp.MustCompile(`^/v2/(.+)/referrers/([^/]+)$`)
+)
+
+// Registry is an in-memory registry implementing [http.Handler].
+type Registry struct {
+	mu        sync.Mutex
+	repos     map[string]*repo
+	uploads   map[string]*upload
+	user      string
+	pass      string
+	deletes   bool
+	referrers bool
+}
+
+type repo struct {
+	blobs     map[digest.Digest][]byte
+	manifests map[digest.Digest]*manifestEntry
+	tags      map[string]digest.Digest
+}
+
+type upload struct {
+	repo string
+	buf  bytes.Buffer
+}
+
+// Opts is used to configure the [Registry].
+type Opts func(*Registry)
+
+// WithBasicAuth requires requests to include basic auth with the user and password.
+func WithBasicAuth(user, pass string) Opts {
+	return func(reg *Registry) {
+		reg.user = user
+		reg.pass = pass
+	}
+}
+
+// WithDeleteDisabled rejects requests to delete manifests, tags, and blobs.
+func WithDeleteDisabled() Opts {
+	return func(reg *Registry) {
+		reg.deletes = false
+	}
+}
+
+// WithReferrersDisabled removes the referrers API, clients fall back to the referrers tag schema.
+func WithReferrersDisabled() Opts {
+	return func(reg *Registry) {
+		reg.referrers = false
+	}
+}
+
+// New returns an empty in-memory registry.
+func New(opts ...Opts) *Registry {
+	reg := &Registry{
+		repos:     map[string]*repo{},
+		uploads:   map[string]*upload{},
+		deletes:   true,
+		referrers: true,
+	}
+	for _, opt := range opts {
+		opt(reg)
+	}
+	return reg
+}
+
+// ServeHTTP handles requests to the registry API.
+func (reg *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	if reg.user != "" || reg.pass != "" {
+		user, pass, ok := req.BasicAuth()
+		if !ok || user != reg.user || pass != reg.pass {
+			w.Header().Set("WWW-Authenticate", `Basic realm="regtest"`)
+			writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "authentication required")
+			return
+		}
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeBlobUploadInvalid, "failed to read request body")
+		return
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	p := req.URL.Path
+	if p == "/v2/" || p == "/v2" {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "unsupported method")
+			return
+		}
+		writeJSON(w, req, http.StatusOK, "application/json", struct{}{})
+		return
+	}
+	if p == "/v2/_catalog" {
+		reg.catalogGet(w, req)
+		return
+	}
+	if m := reManifest.FindStringSubmatch(p); m != nil {
+		if !reg.nameValid(w, m[1]) {
+			return
+		}
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+			reg.manifestGet(w, req, m[1], m[2])
+		case http.MethodPut:
+			reg.manifestPut(w, req, m[1], m[2], body)
+		case http.MethodDelete:
+			reg.manifestDelete(w, m[1], m[2])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "unsupported method")
+		}
+		return
+	}
+	if m := reUploadNew.FindStringSubmatch(p); m != nil {
+		if !reg.nameValid(w, m[1]) {
+			return
+		}
+		switch req.Method {
+		case http.MethodPost:
+			reg.uploadPost(w, req, m[1], body)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "unsupported method")
+		}
+		return
+	}
+	if m := reUpload.FindStringSubmatch(p); m != nil {
+		if !reg.nameValid(w, m[1]) {
+			return
+		}
+		switch req.Method {
+		case http.MethodGet:
+			reg.uploadGet(w, m[1], m[2])
+		case http.MethodPatch:
+			reg.uploadPatch(w, req, m[1], m[2], body)
+		case http.MethodPut:
+			reg.uploadPut(w, req, m[1], m[2], body)
+		case http.MethodDelete:
+			reg.uploadDelete(w, m[1], m[2])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "unsupported method")
+		}
+		return
+	}
+	if m := reBlob.FindStringSubmatch(p); m != nil {
+		if !reg.nameValid(w, m[1]) {
+			return
+		}
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+			reg.blobGet(w, req, m[1], m[2])
+		case http.MethodDelete:
+			reg.blobDelete(w, m[1], m[2])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "unsupported method")
+		}
+		return
+	}
+	if m := reTagList.FindStringSubmatch(p); m != nil {
+		if !reg.nameValid(w, m[1]) {
+			return
+		}
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+			reg.tagList(w, req, m[1])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "unsupported method")
+		}
+		return
+	}
+	// registries without the referrers API return a 404
+	if m := reReferrers.FindStringSubmatch(p); m != nil && reg.referrers {
+		if !reg.nameValid(w, m[1]) {
+			return
+		}
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+			reg.referrersGet(w, req, m[1], m[2])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "unsupported method")
+		}
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// nameValid writes an error and returns false for an invalid repository name.
+func (reg *Registry) nameValid(w http.ResponseWriter, name string) bool {
+	if !reName.MatchString(name) {
+		writeError(w, http.StatusBadRequest, errCodeNameInvalid, "invalid repository name")
+		return false
+	}
+	return true
+}
+
+// repoGet returns the repository, creating it when create is set.
+func (reg *Registry) repoGet(name string, create bool) *repo {
+	r, ok := reg.repos[name]
+	if !ok && create {
+		r = &repo{
+			blobs:     map[digest.Digest][]byte{},
+			manifests: map[digest.Digest]*manifestEntry{},
+			tags:      map[string]digest.Digest{},
+		}
+		reg.repos[name] = r
+	}
+	return r
+}
+
+type errorResp struct {
+	Errors []errorInfo `json:"errors"`
+}
+
+type errorInfo struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResp{Errors: []errorInfo{{Code: code, Message: message}}})
+}
+
+func writeJSON(w http.ResponseWriter, req *http.Request, status int, mediaType string, data interface{}) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	w.WriteHeader(status)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(b)
+	}
+}
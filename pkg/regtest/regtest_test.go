@@ -0,0 +1,288 @@
+package regtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rSrc, err := ref.New("ocidir://../../testdata/testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	regs := map[string]*Registry{
+		"default":            New(),
+		"referrers disabled": New(WithReferrersDisabled()),
+	}
+	for name, reg := range regs {
+		reg := reg
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ts := httptest.NewServer(reg)
+			t.Cleanup(ts.Close)
+			tsURL, _ := url.Parse(ts.URL)
+			rc := regclient.New(regclient.WithConfigHost(config.Host{
+				Name:      tsURL.Host,
+				Hostname:  tsURL.Host,
+				TLS:       config.TLSDisabled,
+				BlobChunk: 1024,
+				BlobMax:   2048,
+				ReqPerSec: 1000,
+			}), regclient.WithRetryDelay(time.Millisecond*10, time.Millisecond*50))
+			rTgt, err := ref.New(tsURL.Host + "/proj/repo:v2")
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+
+			t.Run("Copy", func(t *testing.T) {
+				err := rc.ImageCopy(ctx, rSrc, rTgt, regclient.ImageWithReferrers())
+				if err != nil {
+					t.Fatalf("failed to copy image: %v", err)
+				}
+				mSrc, err := rc.ManifestHead(ctx, rSrc)
+				if err != nil {
+					t.Fatalf("failed to head source: %v", err)
+				}
+				mTgt, err := rc.ManifestHead(ctx, rTgt)
+				if err != nil {
+					t.Fatalf("failed to head target: %v", err)
+				}
+				if mSrc.GetDescriptor().Digest != mTgt.GetDescriptor().Digest {
+					t.Errorf("digest mismatch, expected %s, received %s", mSrc.GetDescriptor().Digest, mTgt.GetDescriptor().Digest)
+				}
+				rlSrc, err := rc.ReferrerList(ctx, rSrc)
+				if err != nil {
+					t.Fatalf("failed to list source referrers: %v", err)
+				}
+				rlTgt, err := rc.ReferrerList(ctx, rTgt)
+				if err != nil {
+					t.Fatalf("failed to list target referrers: %v", err)
+				}
+				if len(rlSrc.Descriptors) == 0 || len(rlSrc.Descriptors) != len(rlTgt.Descriptors) {
+					t.Errorf("referrer count mismatch, expected %d, received %d", len(rlSrc.Descriptors), len(rlTgt.Descriptors))
+				}
+			})
+
+			t.Run("Chunked", func(t *testing.T) {
+				d, b := reqresp.NewRandomBlob(5000, 1)
+				dOut, err := rc.BlobPut(ctx, rTgt, types.Descriptor{Digest: d, Size: int64(len(b))}, bytes.NewReader(b))
+				if err != nil {
+					t.Fatalf("failed to put blob: %v", err)
+				}
+				if dOut.Digest != d {
+					t.Errorf("digest mismatch, expected %s, received %s", d, dOut.Digest)
+				}
+				// a partial file is resumed with a range request
+				filename := filepath.Join(t.TempDir(), "blob")
+				err = os.WriteFile(filename+".partial", b[:1000], 0600)
+				if err != nil {
+					t.Fatalf("failed to write partial: %v", err)
+				}
+				err = rc.BlobGetFile(ctx, rTgt, dOut, filename)
+				if err != nil {
+					t.Fatalf("failed to get blob: %v", err)
+				}
+				out, err := os.ReadFile(filename)
+				if err != nil {
+					t.Fatalf("failed to read blob: %v", err)
+				}
+				if !bytes.Equal(b, out) {
+					t.Errorf("blob content mismatch")
+				}
+				// parallel range requests
+				filename = filepath.Join(t.TempDir(), "blob")
+				err = rc.BlobGetFile(ctx, rTgt, dOut, filename, regclient.BlobWithParallel(3, 1000))
+				if err != nil {
+					t.Fatalf("failed to get blob: %v", err)
+				}
+				out, err = os.ReadFile(filename)
+				if err != nil {
+					t.Fatalf("failed to read blob: %v", err)
+				}
+				if !bytes.Equal(b, out) {
+					t.Errorf("blob content mismatch")
+				}
+			})
+
+			t.Run("Mount", func(t *testing.T) {
+				d, b := reqresp.NewRandomBlob(100, 2)
+				_, err := rc.BlobPut(ctx, rTgt, types.Descriptor{Digest: d, Size: int64(len(b))}, bytes.NewReader(b))
+				if err != nil {
+					t.Fatalf("failed to put blob: %v", err)
+				}
+				rMount := rTgt.SetTag("mount")
+				rMount.Repository = "proj/mount"
+				err = rc.BlobMount(ctx, rTgt, rMount, types.Descriptor{Digest: d})
+				if err != nil {
+					t.Fatalf("failed to mount blob: %v", err)
+				}
+				_, err = rc.BlobHead(ctx, rMount, types.Descriptor{Digest: d})
+				if err != nil {
+					t.Errorf("failed to head mounted blob: %v", err)
+				}
+			})
+
+			t.Run("List", func(t *testing.T) {
+				rl, err := rc.RepoList(ctx, tsURL.Host)
+				if err != nil {
+					t.Fatalf("failed to list repositories: %v", err)
+				}
+				repos, err := rl.GetRepos()
+				if err != nil {
+					t.Fatalf("failed to get repositories: %v", err)
+				}
+				if len(repos) != 2 || repos[0] != "proj/mount" || repos[1] != "proj/repo" {
+					t.Errorf("unexpected repository list: %v", repos)
+				}
+				tl, err := rc.TagList(ctx, rTgt)
+				if err != nil {
+					t.Fatalf("failed to list tags: %v", err)
+				}
+				tags, err := tl.GetTags()
+				if err != nil {
+					t.Fatalf("failed to get tags: %v", err)
+				}
+				found := false
+				for _, tag := range tags {
+					if tag == "v2" {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("tag v2 not found: %v", tags)
+				}
+				// pagination returns a link to the next page
+				resp, err := http.Get(ts.URL + "/v2/proj/repo/tags/list?n=1")
+				if err != nil {
+					t.Fatalf("failed to list tags: %v", err)
+				}
+				defer resp.Body.Close()
+				tlResp := tagListResp{}
+				err = json.NewDecoder(resp.Body).Decode(&tlResp)
+				if err != nil {
+					t.Fatalf("failed to parse tag list: %v", err)
+				}
+				if len(tlResp.Tags) != 1 {
+					t.Errorf("unexpected tags: %v", tlResp.Tags)
+				}
+				if len(tags) > 1 && resp.Header.Get("Link") == "" {
+					t.Errorf("missing link header")
+				}
+			})
+
+			t.Run("Invalid", func(t *testing.T) {
+				d, _ := reqresp.NewRandomBlob(100, 3)
+				m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+					Versioned: v1.ManifestSchemaVersion,
+					MediaType: types.MediaTypeOCI1Manifest,
+					Config: types.Descriptor{
+						MediaType: types.MediaTypeOCI1ImageConfig,
+						Digest:    d,
+						Size:      100,
+					},
+					Layers: []types.Descriptor{},
+				}))
+				if err != nil {
+					t.Fatalf("failed to create manifest: %v", err)
+				}
+				err = rc.ManifestPut(ctx, rTgt.SetTag("invalid"), m)
+				if err == nil {
+					t.Errorf("manifest with a missing blob was accepted")
+				}
+			})
+
+			t.Run("Delete", func(t *testing.T) {
+				mTgt, err := rc.ManifestHead(ctx, rTgt)
+				if err != nil {
+					t.Fatalf("failed to head target: %v", err)
+				}
+				rDel := rTgt.SetDigest(mTgt.GetDescriptor().Digest.String())
+				err = rc.ManifestDelete(ctx, rDel)
+				if err != nil {
+					t.Fatalf("failed to delete manifest: %v", err)
+				}
+				_, err = rc.ManifestHead(ctx, rTgt)
+				if !errors.Is(err, types.ErrNotFound) {
+					t.Errorf("unexpected error for a deleted tag: %v", err)
+				}
+				_, err = rc.ManifestHead(ctx, rDel)
+				if !errors.Is(err, types.ErrNotFound) {
+					t.Errorf("unexpected error for a deleted manifest: %v", err)
+				}
+			})
+		})
+	}
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	ts := httptest.NewServer(New(WithBasicAuth("user", "pass")))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tt := []struct {
+		name      string
+		user      string
+		pass      string
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			user: "user",
+			pass: "pass",
+		},
+		{
+			name:      "invalid",
+			user:      "user",
+			pass:      "wrong",
+			expectErr: true,
+		},
+		{
+			name:      "anonymous",
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rc := regclient.New(regclient.WithConfigHost(config.Host{
+				Name:      tsURL.Host,
+				Hostname:  tsURL.Host,
+				TLS:       config.TLSDisabled,
+				User:      tc.user,
+				Pass:      tc.pass,
+				ReqPerSec: 1000,
+			}))
+			r, err := ref.New(fmt.Sprintf("%s/proj/repo", tsURL.Host))
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+			_, err = rc.Ping(ctx, r)
+			if tc.expectErr && err == nil {
+				t.Errorf("ping did not fail")
+			} else if !tc.expectErr && err != nil {
+				t.Errorf("ping failed: %v", err)
+			}
+		})
+	}
+}
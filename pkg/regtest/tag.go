@@ -0,0 +1,119 @@
+package regtest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+)
+
+type tagListResp struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+type catalogResp struct {
+	Repositories []string `json:"repositories"`
+}
+
+func (reg *Registry) tagList(w http.ResponseWriter, req *http.Request, name string) {
+	r := reg.repoGet(name, false)
+	if r == nil {
+		writeError(w, http.StatusNotFound, errCodeNameUnknown, "repository not found")
+		return
+	}
+	tags := make([]string, 0, len(r.tags))
+	for tag := range r.tags {
+		tags = append(tags, tag)
+	}
+	tags, ok := paginate(w, req, tags)
+	if !ok {
+		return
+	}
+	writeJSON(w, req, http.StatusOK, "application/json", tagListResp{Name: name, Tags: tags})
+}
+
+func (reg *Registry) catalogGet(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, errCodeUnsupported, "unsupported method")
+		return
+	}
+	repos := make([]string, 0, len(reg.repos))
+	for name := range reg.repos {
+		repos = append(repos, name)
+	}
+	repos, ok := paginate(w, req, repos)
+	if !ok {
+		return
+	}
+	writeJSON(w, req, http.StatusOK, "application/json", catalogResp{Repositories: repos})
+}
+
+// paginate sorts the list and applies the "n" and "last" query parameters.
+// A Link header is added when more entries are available.
+func paginate(w http.ResponseWriter, req *http.Request, list []string) ([]string, bool) {
+	sort.Strings(list)
+	query := req.URL.Query()
+	if last := query.Get("last"); last != "" {
+		i := sort.SearchStrings(list, last)
+		if i < len(list) && list[i] == last {
+			i++
+		}
+		list = list[i:]
+	}
+	if nStr := query.Get("n"); nStr != "" {
+		n, err := strconv.Atoi(nStr)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, errCodeUnsupported, "invalid value for n")
+			return nil, false
+		}
+		if n < len(list) {
+			list = list[:n]
+			if n > 0 {
+				next := url.URL{
+					Path: req.URL.Path,
+					RawQuery: url.Values{
+						"n":    []string{nStr},
+						"last": []string{list[n-1]},
+					}.Encode(),
+				}
+				w.Header().Set("Link", "<"+next.String()+`>; rel="next"`)
+			}
+		}
+	}
+	return list, true
+}
+
+func (reg *Registry) referrersGet(w http.ResponseWriter, req *http.Request, name, dStr string) {
+	d, err := digest.Parse(dStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeDigestInvalid, "invalid digest")
+		return
+	}
+	at := req.URL.Query().Get("artifactType")
+	// the response is an empty index when the repository or subject do not exist
+	resp := v1.Index{
+		Versioned: v1.IndexSchemaVersion,
+		MediaType: types.MediaTypeOCI1ManifestList,
+		Manifests: []types.Descriptor{},
+	}
+	if r := reg.repoGet(name, false); r != nil {
+		for _, me := range r.manifests {
+			if me.subject == d && (at == "" || me.desc.ArtifactType == at) {
+				resp.Manifests = append(resp.Manifests, me.desc)
+			}
+		}
+	}
+	sort.Slice(resp.Manifests, func(i, j int) bool {
+		return resp.Manifests[i].Digest < resp.Manifests[j].Digest
+	})
+	if at != "" {
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+	}
+	writeJSON(w, req, http.StatusOK, types.MediaTypeOCI1ManifestList, resp)
+}
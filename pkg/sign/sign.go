@@ -0,0 +1,243 @@
+// Package sign creates cosign compatible signatures of images.
+// A [Signer] signs the payload describing an image, and is implemented for local keys, key management services, and ephemeral keys with a self-signed certificate.
+package sign
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	// MediaTypeSimpleSigning is the media type of the cosign payload layer.
+	MediaTypeSimpleSigning = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// ArtifactTypeSignature is the artifactType of cosign signatures pushed as a referrer.
+	ArtifactTypeSignature = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	// AnnotationSignature contains the base64 encoded signature of the payload layer.
+	AnnotationSignature = "dev.cosignproject.cosign/signature"
+	// AnnotationCertificate contains the PEM encoded certificate of the signer.
+	AnnotationCertificate = "dev.sigstore.cosign/certificate"
+	// AnnotationChain contains the PEM encoded certificate chain of the signer.
+	AnnotationChain = "dev.sigstore.cosign/chain"
+	// PayloadType is the type of the cosign payload.
+	PayloadType = "cosign container image signature"
+)
+
+var (
+	// ErrUnsupportedKey is returned for keys that cannot be used to sign.
+	ErrUnsupportedKey = errors.New("unsupported key")
+)
+
+// Signer signs the payload of an image signature.
+// Implementations for a key management service can wrap the service with [NewKMS].
+type Signer interface {
+	// Sign returns the signature of the payload.
+	Sign(ctx context.Context, payload []byte) ([]byte, error)
+	// PublicKey returns the key used to verify signatures.
+	PublicKey(ctx context.Context) (crypto.PublicKey, error)
+}
+
+// CertSigner is an optional interface of a [Signer] with a certificate for the signing key.
+// The certificate and chain are PEM encoded and added to the signature annotations.
+type CertSigner interface {
+	Signer
+	Certificate() (cert []byte, chain []byte)
+}
+
+// Payload is the cosign simple signing payload.
+type Payload struct {
+	Critical PayloadCritical        `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// PayloadCritical contains the signed identity of the image.
+type PayloadCritical struct {
+	Identity PayloadIdentity `json:"identity"`
+	Image    PayloadImage    `json:"image"`
+	Type     string          `json:"type"`
+}
+
+// PayloadIdentity is the repository of the signed image.
+type PayloadIdentity struct {
+	DockerReference string `json:"docker-reference"`
+}
+
+// PayloadImage is the digest of the signed image.
+type PayloadImage struct {
+	DockerManifestDigest digest.Digest `json:"docker-manifest-digest"`
+}
+
+// NewPayload returns the payload to sign for an image digest.
+// The optional annotations are included in the signed payload.
+func NewPayload(r ref.Ref, d digest.Digest, annotations map[string]interface{}) ([]byte, error) {
+	p := Payload{
+		Critical: PayloadCritical{
+			Identity: PayloadIdentity{DockerReference: r.Registry + "/" + r.Repository},
+			Image:    PayloadImage{DockerManifestDigest: d},
+			Type:     PayloadType,
+		},
+		Optional: annotations,
+	}
+	return json.Marshal(p)
+}
+
+type keySigner struct {
+	key crypto.Signer
+}
+
+// NewKey returns a signer for a local private key.
+// ECDSA, RSA, and ed25519 keys are supported.
+func NewKey(key crypto.Signer) (Signer, error) {
+	switch key.(type) {
+	case *ecdsa.PrivateKey, *rsa.PrivateKey, ed25519.PrivateKey:
+	default:
+		return nil, fmt.Errorf("key type %T%.0w", key, ErrUnsupportedKey)
+	}
+	return &keySigner{key: key}, nil
+}
+
+// ParseKey returns a signer for a PEM encoded private key.
+// Unencrypted PKCS8, EC, and PKCS1 keys are supported.
+// Encrypted cosign keys are not supported, and must be decrypted before use.
+func ParseKey(b []byte) (Signer, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM key%.0w", types.ErrParsingFailed)
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "ENCRYPTED COSIGN PRIVATE KEY", "ENCRYPTED SIGSTORE PRIVATE KEY":
+		return nil, fmt.Errorf("encrypted cosign keys are not supported%.0w", types.ErrNotImplemented)
+	default:
+		return nil, fmt.Errorf("PEM type %s%.0w", block.Type, ErrUnsupportedKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key: %w", err)
+	}
+	ks, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("key type %T%.0w", key, ErrUnsupportedKey)
+	}
+	return NewKey(ks)
+}
+
+// Sign implements [Signer].
+func (s *keySigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		return s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+	h := sha256.Sum256(payload)
+	return s.key.Sign(rand.Reader, h[:], crypto.SHA256)
+}
+
+// PublicKey implements [Signer].
+func (s *keySigner) PublicKey(ctx context.Context) (crypto.PublicKey, error) {
+	return s.key.Public(), nil
+}
+
+// KMS is a key management service that signs a SHA256 digest with a key that is not available locally.
+type KMS interface {
+	// SignDigest returns the signature of the digest.
+	SignDigest(ctx context.Context, digest []byte, hash crypto.Hash) ([]byte, error)
+	// PublicKey returns the public key of the signing key.
+	PublicKey(ctx context.Context) (crypto.PublicKey, error)
+}
+
+type kmsSigner struct {
+	kms KMS
+}
+
+// NewKMS returns a signer for a key in a key management service.
+func NewKMS(kms KMS) Signer {
+	return &kmsSigner{kms: kms}
+}
+
+// Sign implements [Signer].
+func (s *kmsSigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	h := sha256.Sum256(payload)
+	return s.kms.SignDigest(ctx, h[:], crypto.SHA256)
+}
+
+// PublicKey implements [Signer].
+func (s *kmsSigner) PublicKey(ctx context.Context) (crypto.PublicKey, error) {
+	return s.kms.PublicKey(ctx)
+}
+
+type ephemeralSigner struct {
+	keySigner
+	cert []byte
+}
+
+// NewEphemeral returns a signer with a new ECDSA P-256 key and a self-signed certificate for the identity.
+// The identity is an email address or URI, added as the subject alternative name of the certificate.
+// The certificate is valid for 10 minutes, and is not issued by Fulcio or recorded in a transparency log,
+// so verifiers must trust the certificate directly.
+func NewEphemeral(identity string) (Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial: %w", err)
+	}
+	now := time.Now().UTC()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: identity},
+		NotBefore:    now.Add(-1 * time.Minute),
+		NotAfter:     now.Add(10 * time.Minute),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	if addr, err := mail.ParseAddress(identity); err == nil && addr.Address == identity {
+		tmpl.EmailAddresses = []string{identity}
+	} else if u, err := url.Parse(identity); err == nil && u.Scheme != "" {
+		tmpl.URIs = []*url.URL{u}
+	} else if identity != "" {
+		return nil, fmt.Errorf("identity must be an email address or URI: %s%.0w", identity, types.ErrParsingFailed)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	buf := &bytes.Buffer{}
+	err = pem.Encode(buf, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err != nil {
+		return nil, err
+	}
+	return &ephemeralSigner{keySigner: keySigner{key: key}, cert: buf.Bytes()}, nil
+}
+
+// Certificate implements [CertSigner].
+func (s *ephemeralSigner) Certificate() ([]byte, []byte) {
+	return s.cert, nil
+}
@@ -0,0 +1,135 @@
+package sign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/regclient/regclient/types"
+)
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ecDer, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	edDer, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	payload := []byte(`{"critical":{}}`)
+	tt := []struct {
+		name      string
+		pem       []byte
+		verify    func(sig []byte) bool
+		expectErr error
+	}{
+		{
+			name: "ec",
+			pem:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDer}),
+			verify: func(sig []byte) bool {
+				h := sha256.Sum256(payload)
+				return ecdsa.VerifyASN1(&ecKey.PublicKey, h[:], sig)
+			},
+		},
+		{
+			name: "ed25519",
+			pem:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDer}),
+			verify: func(sig []byte) bool {
+				return ed25519.Verify(edKey.Public().(ed25519.PublicKey), payload, sig)
+			},
+		},
+		{
+			name:      "encrypted cosign",
+			pem:       pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED SIGSTORE PRIVATE KEY", Bytes: []byte("data")}),
+			expectErr: types.ErrNotImplemented,
+		},
+		{
+			name:      "unknown type",
+			pem:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("data")}),
+			expectErr: ErrUnsupportedKey,
+		},
+		{
+			name:      "not pem",
+			pem:       []byte("not a key"),
+			expectErr: types.ErrParsingFailed,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := ParseKey(tc.pem)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse key: %v", err)
+			}
+			sig, err := s.Sign(ctx, payload)
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+			if !tc.verify(sig) {
+				t.Errorf("signature verification failed")
+			}
+		})
+	}
+}
+
+func TestEphemeral(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s, err := NewEphemeral("https://example.com/ci")
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	cs, ok := s.(CertSigner)
+	if !ok {
+		t.Fatalf("ephemeral signer does not have a certificate")
+	}
+	certPEM, _ := cs.Certificate()
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatalf("failed to decode certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != "https://example.com/ci" {
+		t.Errorf("unexpected URIs: %v", cert.URIs)
+	}
+	payload := []byte("payload")
+	sig, err := s.Sign(ctx, payload)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	err = cert.CheckSignature(x509.ECDSAWithSHA256, payload, sig)
+	if err != nil {
+		t.Errorf("signature verification failed: %v", err)
+	}
+	_, err = NewEphemeral("not an identity")
+	if !errors.Is(err, types.ErrParsingFailed) {
+		t.Errorf("unexpected error for invalid identity: %v", err)
+	}
+}
@@ -17,6 +17,11 @@ func (t *TimeFuncs) Now() time.Time {
 	return time.Now()
 }
 
+// Format outputs the time according to layout, e.g. "2006-01-02T15:04:05Z07:00"
+func (t *TimeFuncs) Format(layout string, value time.Time) string {
+	return value.Format(layout)
+}
+
 // Parse parses the current time according to layout
 func (t *TimeFuncs) Parse(layout string, value string) (time.Time, error) {
 	return time.Parse(layout, value)
@@ -0,0 +1,81 @@
+// Package trace defines the hooks regclient uses to trace registry operations.
+// These interfaces are implemented by an adapter to a tracing library, e.g. OpenTelemetry,
+// without regclient depending on that library.
+//
+// An OpenTelemetry adapter would start spans with the otel Tracer, converting each [Attr] to an attribute.KeyValue,
+// and propagate the trace context with:
+//
+//	func (t *otelTracer) Inject(ctx context.Context, header http.Header) {
+//		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+//	}
+package trace
+
+import (
+	"context"
+	"net/http"
+)
+
+// Attribute keys set on spans.
+const (
+	AttrHost        = "server.address"            // registry hostname
+	AttrMethod      = "http.request.method"       // http method
+	AttrStatusCode  = "http.response.status_code" // http response status
+	AttrResendCount = "http.request.resend_count" // number of times the request was previously sent
+	AttrRepository  = "regclient.repository"      // repository in the registry
+	AttrRef         = "regclient.ref"             // image reference
+	AttrRefTarget   = "regclient.ref.target"      // target image reference of a copy
+	AttrDigest      = "regclient.digest"          // digest of the manifest or blob
+	AttrMediaType   = "regclient.media_type"      // media type of the manifest or blob
+	AttrSize        = "regclient.size"            // size of the manifest or blob in bytes
+)
+
+// Tracer creates spans and propagates the trace context to registries.
+type Tracer interface {
+	// Start begins a span as a child of any span in ctx, returning a context containing the new span.
+	Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span)
+	// Inject adds headers to propagate the span in ctx to the registry, e.g. the W3C traceparent header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	// SetAttributes adds attributes to the span.
+	SetAttributes(attrs ...Attr)
+	// End completes the span, recording the error when not nil.
+	End(err error)
+}
+
+// Attr is a key/value attribute on a span.
+// The value is a string, int64, or bool.
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute.
+func String(key, value string) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Int64 returns an integer attribute.
+func Int64(key string, value int64) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Start begins a span with the tracer, returning a span that does nothing when the tracer is nil.
+func Start(ctx context.Context, t Tracer, name string, attrs ...Attr) (context.Context, Span) {
+	if t == nil {
+		return ctx, noopSpan{}
+	}
+	return t.Start(ctx, name, attrs...)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...Attr) {}
+func (noopSpan) End(err error)               {}
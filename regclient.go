@@ -2,19 +2,28 @@
 package regclient
 
 import (
+	"context"
 	"io"
 	"time"
 
 	"fmt"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/rwfs"
 	"github.com/regclient/regclient/internal/version"
+	"github.com/regclient/regclient/pkg/metrics"
+	"github.com/regclient/regclient/pkg/trace"
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/scheme/dockerdaemon"
 	"github.com/regclient/regclient/scheme/ocidir"
+	"github.com/regclient/regclient/scheme/ocitar"
 	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/scheme/s3"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
 )
 
 const (
@@ -32,13 +41,18 @@ const (
 
 // RegClient is used to access OCI distribution-spec registries.
 type RegClient struct {
-	hosts map[string]*config.Host
-	log   *logrus.Logger
+	hosts   map[string]*config.Host
+	aliases map[string]string
+	log     *logrus.Logger
 	// mu        sync.Mutex
-	regOpts   []reg.Opts
-	schemes   map[string]scheme.API
-	userAgent string
-	fs        rwfs.RWFS
+	regOpts    []reg.Opts
+	schemes    map[string]scheme.API
+	schemesExt map[string]scheme.API
+	userAgent  string
+	digestAlg  digest.Algorithm
+	fs         rwfs.RWFS
+	tracer     trace.Tracer
+	metrics    metrics.Metrics
 }
 
 // Opt functions are used by [New] to create a [*RegClient].
@@ -48,12 +62,14 @@ type Opt func(*RegClient)
 func New(opts ...Opt) *RegClient {
 	var rc = RegClient{
 		hosts:     map[string]*config.Host{},
+		aliases:   map[string]string{},
 		userAgent: DefaultUserAgent,
 		// logging is disabled by default
-		log:     &logrus.Logger{Out: io.Discard},
-		regOpts: []reg.Opts{},
-		schemes: map[string]scheme.API{},
-		fs:      rwfs.OSNew(""),
+		log:        &logrus.Logger{Out: io.Discard},
+		regOpts:    []reg.Opts{},
+		schemes:    map[string]scheme.API{},
+		schemesExt: map[string]scheme.API{},
+		fs:         rwfs.OSNew(""),
 	}
 
 	info := version.GetInfo()
@@ -80,13 +96,47 @@ func New(opts ...Opt) *RegClient {
 		reg.WithLog(rc.log),
 		reg.WithUserAgent(rc.userAgent),
 	)
+	if rc.digestAlg != "" {
+		rc.regOpts = append(rc.regOpts, reg.WithDigestAlgorithm(rc.digestAlg))
+	}
+	for registry, target := range rc.aliases {
+		rc.regOpts = append(rc.regOpts, reg.WithRegistryAlias(registry, target))
+	}
+	if rc.tracer != nil {
+		rc.regOpts = append(rc.regOpts, reg.WithTracer(rc.tracer))
+	}
+	if rc.metrics != nil {
+		rc.regOpts = append(rc.regOpts, reg.WithMetrics(rc.metrics))
+	}
 
 	// setup scheme's
 	rc.schemes["reg"] = reg.New(rc.regOpts...)
 	rc.schemes["ocidir"] = ocidir.New(
 		ocidir.WithLog(rc.log),
 		ocidir.WithFS(rc.fs),
+		ocidir.WithDigestAlgorithm(rc.digestAlg),
+	)
+	rc.schemes["ocitar"] = ocitar.New(
+		ocitar.WithLog(rc.log),
+		ocitar.WithFS(rc.fs),
+	)
+	rc.schemes["docker-daemon"] = dockerdaemon.New(
+		dockerdaemon.WithLog(rc.log),
+	)
+	rc.schemes["s3"] = s3.New(
+		s3.WithLog(rc.log),
+		s3.WithDigestAlgorithm(rc.digestAlg),
 	)
+	// external schemes may add new schemes or replace the built in implementations
+	for name, s := range rc.schemesExt {
+		if !ref.SchemeRegistered(name) {
+			rc.log.WithFields(logrus.Fields{
+				"scheme": name,
+			}).Warn("Scheme name is not registered, see ref.RegisterScheme")
+			continue
+		}
+		rc.schemes[name] = s
+	}
 
 	rc.log.WithFields(logrus.Fields{
 		"VCSRef": info.VCSRef,
@@ -137,12 +187,21 @@ func WithConfigHosts(configHosts []config.Host) Opt {
 	return WithConfigHost(configHosts...)
 }
 
+// WithDigestAlgorithm sets the default algorithm for new digests, e.g. [digest.SHA512].
+// This may be overridden per registry with the DigestAlgorithm setting in [config.Host].
+func WithDigestAlgorithm(alg digest.Algorithm) Opt {
+	return func(rc *RegClient) {
+		rc.digestAlg = alg
+	}
+}
+
 // WithDockerCerts adds certificates trusted by docker in /etc/docker/certs.d.
 func WithDockerCerts() Opt {
 	return WithCertDir(DockerCertDir)
 }
 
 // WithDockerCreds adds configuration from users docker config with registry logins.
+// Registries without a login use the docker credsStore helper when one is configured.
 // This changes the default value from the config file, and should be added after the config file is loaded.
 func WithDockerCreds() Opt {
 	return func(rc *RegClient) {
@@ -154,6 +213,16 @@ func WithDockerCreds() Opt {
 			return
 		}
 		rc.hostLoad("docker", configHosts)
+		credStore, err := config.DockerCredStore()
+		if err != nil {
+			rc.log.WithFields(logrus.Fields{
+				"err": err,
+			}).Warn("Failed to load docker credential store")
+			return
+		}
+		if credStore != "" {
+			rc.regOpts = append(rc.regOpts, reg.WithCredHelper(credStore))
+		}
 	}
 }
 
@@ -171,6 +240,14 @@ func WithLog(log *logrus.Logger) Opt {
 	}
 }
 
+// WithMetrics reports registry request counts, bytes transferred, retries, and token refreshes to the metrics hooks.
+// See [metrics.Nop] to implement a subset of the hooks.
+func WithMetrics(m metrics.Metrics) Opt {
+	return func(rc *RegClient) {
+		rc.metrics = m
+	}
+}
+
 // WithRegOpts passes through opts to the reg scheme.
 func WithRegOpts(opts ...reg.Opts) Opt {
 	return func(rc *RegClient) {
@@ -181,6 +258,16 @@ func WithRegOpts(opts ...reg.Opts) Opt {
 	}
 }
 
+// WithRegistryAlias sends requests for a registry to another host, e.g. "docker.io" to an internal mirror.
+// The alias is applied to every registry reference, so all APIs transparently use the target host and its config.
+func WithRegistryAlias(aliases map[string]string) Opt {
+	return func(rc *RegClient) {
+		for registry, target := range aliases {
+			rc.aliases[config.HostNewName(registry).Name] = config.HostNewName(target).Name
+		}
+	}
+}
+
 // WithRetryDelay specifies the time permitted for retry delays.
 //
 // Deprecated: replace with WithRegOpts(reg.WithDelay(delayInit, delayMax)), see [WithRegOpts] and [reg.WithDelay].
@@ -199,6 +286,26 @@ func WithRetryLimit(retryLimit int) Opt {
 	}
 }
 
+// WithScheme adds a custom scheme implementation, or replaces a built in scheme with the same name.
+// The name of a new scheme must first be registered with [ref.RegisterScheme], which allows references to the scheme to be parsed.
+// Registration is global to the process and is not changed by this option, unregistered names are ignored.
+func WithScheme(name string, s scheme.API) Opt {
+	return func(rc *RegClient) {
+		if s == nil {
+			return
+		}
+		rc.schemesExt[name] = s
+	}
+}
+
+// WithTracer creates spans for registry operations and http requests with the tracer.
+// The trace context is propagated to registries using the headers added by the tracer.
+func WithTracer(t trace.Tracer) Opt {
+	return func(rc *RegClient) {
+		rc.tracer = t
+	}
+}
+
 // WithUserAgent specifies the User-Agent http header.
 func WithUserAgent(ua string) Opt {
 	return func(rc *RegClient) {
@@ -262,3 +369,43 @@ func (rc *RegClient) hostSet(newHost config.Host) error {
 	}
 	return nil
 }
+
+// DigestAlgorithm returns the algorithm used for new digests pushed to the reference.
+// Registry references use the DigestAlgorithm of the host config when set,
+// otherwise this falls back to [WithDigestAlgorithm] and then sha256.
+func (rc *RegClient) DigestAlgorithm(r ref.Ref) digest.Algorithm {
+	if r.Scheme == "reg" {
+		name := config.HostNewName(r.Registry).Name
+		if alias, ok := rc.aliases[name]; ok {
+			name = alias
+		}
+		if h, ok := rc.hosts[name]; ok && h.DigestAlgorithm != "" {
+			if alg := digest.Algorithm(h.DigestAlgorithm); alg.Available() {
+				return alg
+			}
+		}
+	}
+	if rc.digestAlg != "" && rc.digestAlg.Available() {
+		return rc.digestAlg
+	}
+	return digest.Canonical
+}
+
+// traceStart begins a span for a method with the attributes of the reference.
+func (rc *RegClient) traceStart(ctx context.Context, name string, r ref.Ref, attrs ...trace.Attr) (context.Context, trace.Span) {
+	attrs = append([]trace.Attr{
+		trace.String(trace.AttrRef, r.CommonName()),
+		trace.String(trace.AttrHost, r.Registry),
+		trace.String(trace.AttrRepository, r.Repository),
+	}, attrs...)
+	return trace.Start(ctx, rc.tracer, "regclient."+name, attrs...)
+}
+
+// traceDesc returns the span attributes for a descriptor.
+func traceDesc(d types.Descriptor) []trace.Attr {
+	return []trace.Attr{
+		trace.String(trace.AttrDigest, d.Digest.String()),
+		trace.String(trace.AttrMediaType, d.MediaType),
+		trace.Int64(trace.AttrSize, d.Size),
+	}
+}
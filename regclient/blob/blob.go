@@ -2,18 +2,8 @@
 // +build !nolegacy
 
 // Package blob is a legacy package, this has been moved to the types/blob package
+//
+// Deprecated: replace with [github.com/regclient/regclient/types/blob].
 package blob
 
-import (
-	topBlob "github.com/regclient/regclient/types/blob"
-)
-
-type Blob = topBlob.Blob
-type OCIConfig = topBlob.OCIConfig
-type Common = topBlob.Common
-type Reader = topBlob.Reader
-
-var (
-	NewOCIConfig = topBlob.NewOCIConfig
-	NewReader    = topBlob.NewReader
-)
+//go:generate go run github.com/regclient/regclient/internal/legacygen
@@ -0,0 +1,29 @@
+// Code generated by legacygen. DO NOT EDIT.
+
+//go:build !nolegacy
+// +build !nolegacy
+
+package blob
+
+import (
+	topBlob "github.com/regclient/regclient/types/blob"
+)
+
+// Deprecated: replace with [github.com/regclient/regclient/types/blob.Blob].
+type Blob = topBlob.Blob
+
+// Deprecated: replace with [github.com/regclient/regclient/types/blob.OCIConfig].
+type OCIConfig = topBlob.OCIConfig
+
+// Deprecated: replace with [github.com/regclient/regclient/types/blob.Common].
+type Common = topBlob.Common
+
+// Deprecated: replace with [github.com/regclient/regclient/types/blob.Reader].
+type Reader = topBlob.Reader
+
+var (
+	// Deprecated: replace with [github.com/regclient/regclient/types/blob.NewOCIConfig].
+	NewOCIConfig = topBlob.NewOCIConfig
+	// Deprecated: replace with [github.com/regclient/regclient/types/blob.NewReader].
+	NewReader = topBlob.NewReader
+)
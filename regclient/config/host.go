@@ -2,24 +2,8 @@
 // +build !nolegacy
 
 // Package config is a legacy package, this has been moved to the config package
+//
+// Deprecated: replace with [github.com/regclient/regclient/config].
 package config
 
-import (
-	topConfig "github.com/regclient/regclient/config"
-)
-
-type TLSConf = topConfig.TLSConf
-type Host = topConfig.Host
-
-const (
-	TLSUndefined       = topConfig.TLSUndefined
-	TLSEnabled         = topConfig.TLSEnabled
-	TLSInsecure        = topConfig.TLSInsecure
-	TLSDisabled        = topConfig.TLSDisabled
-	DockerRegistry     = topConfig.DockerRegistry
-	DockerRegistryAuth = topConfig.DockerRegistryAuth
-	DockerRegistryDNS  = topConfig.DockerRegistryDNS
-)
-
-var HostNew = topConfig.HostNew
-var HostNewName = topConfig.HostNewName
+//go:generate go run github.com/regclient/regclient/internal/legacygen
@@ -0,0 +1,40 @@
+// Code generated by legacygen. DO NOT EDIT.
+
+//go:build !nolegacy
+// +build !nolegacy
+
+package config
+
+import (
+	topConfig "github.com/regclient/regclient/config"
+)
+
+// Deprecated: replace with [github.com/regclient/regclient/config.TLSConf].
+type TLSConf = topConfig.TLSConf
+
+// Deprecated: replace with [github.com/regclient/regclient/config.Host].
+type Host = topConfig.Host
+
+const (
+	// Deprecated: replace with [github.com/regclient/regclient/config.TLSUndefined].
+	TLSUndefined = topConfig.TLSUndefined
+	// Deprecated: replace with [github.com/regclient/regclient/config.TLSEnabled].
+	TLSEnabled = topConfig.TLSEnabled
+	// Deprecated: replace with [github.com/regclient/regclient/config.TLSInsecure].
+	TLSInsecure = topConfig.TLSInsecure
+	// Deprecated: replace with [github.com/regclient/regclient/config.TLSDisabled].
+	TLSDisabled = topConfig.TLSDisabled
+	// Deprecated: replace with [github.com/regclient/regclient/config.DockerRegistry].
+	DockerRegistry = topConfig.DockerRegistry
+	// Deprecated: replace with [github.com/regclient/regclient/config.DockerRegistryAuth].
+	DockerRegistryAuth = topConfig.DockerRegistryAuth
+	// Deprecated: replace with [github.com/regclient/regclient/config.DockerRegistryDNS].
+	DockerRegistryDNS = topConfig.DockerRegistryDNS
+)
+
+var (
+	// Deprecated: replace with [github.com/regclient/regclient/config.HostNew].
+	HostNew = topConfig.HostNew
+	// Deprecated: replace with [github.com/regclient/regclient/config.HostNewName].
+	HostNewName = topConfig.HostNewName
+)
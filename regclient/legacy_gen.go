@@ -0,0 +1,179 @@
+// Code generated by legacygen. DO NOT EDIT.
+
+//go:build !nolegacy
+// +build !nolegacy
+
+package regclient
+
+import (
+	rcTop "github.com/regclient/regclient"
+	topConfig "github.com/regclient/regclient/config"
+	reghttp "github.com/regclient/regclient/internal/reghttp"
+	scheme "github.com/regclient/regclient/scheme"
+	topTypes "github.com/regclient/regclient/types"
+	repo "github.com/regclient/regclient/types/repo"
+	tag "github.com/regclient/regclient/types/tag"
+)
+
+// Deprecated: replace with [github.com/regclient/regclient.RegClient].
+type RegClient = *rcTop.RegClient
+
+// Deprecated: replace with [github.com/regclient/regclient.RegClient].
+type Client = *rcTop.RegClient
+
+// Deprecated: replace with [github.com/regclient/regclient.Opt].
+type Opt = rcTop.Opt
+
+// Deprecated: replace with [github.com/regclient/regclient.ImageOpts].
+type ImageOpts = rcTop.ImageOpts
+
+// Deprecated: replace with [github.com/regclient/regclient/types/repo.RepoList].
+type RepoList = *repo.RepoList
+
+// Deprecated: replace with [github.com/regclient/regclient/types/repo.RepoRegistryList].
+type RepoDockerList = repo.RepoRegistryList
+
+// RepoOpts is a breaking change (struct to func opts)
+//
+// Deprecated: replace with [github.com/regclient/regclient/scheme.RepoOpts].
+type RepoOpts = scheme.RepoOpts
+
+// Deprecated: replace with [github.com/regclient/regclient/types/tag.List].
+type TagList = *tag.List
+
+// Deprecated: replace with [github.com/regclient/regclient/types/tag.DockerList].
+type TagDockerList = tag.DockerList
+
+// Deprecated: replace with [github.com/regclient/regclient/scheme.TagOpts].
+type TagOpts = scheme.TagOpts
+
+// Deprecated: replace with [github.com/regclient/regclient/config.Host].
+type ConfigHost = topConfig.Host
+
+// Deprecated: replace with [github.com/regclient/regclient/config.TLSConf].
+type TLSConf = topConfig.TLSConf
+
+const (
+	// Deprecated: this legacy declaration has no replacement.
+	DefaultRetryLimit = reghttp.DefaultRetryLimit
+	// Deprecated: replace with [github.com/regclient/regclient.DefaultUserAgent].
+	DefaultUserAgent = rcTop.DefaultUserAgent
+	// Deprecated: replace with [github.com/regclient/regclient.DockerCertDir].
+	DockerCertDir = rcTop.DockerCertDir
+	// Deprecated: replace with [github.com/regclient/regclient/config.DockerRegistry].
+	DockerRegistry = topConfig.DockerRegistry
+	// Deprecated: replace with [github.com/regclient/regclient/config.DockerRegistryAuth].
+	DockerRegistryAuth = topConfig.DockerRegistryAuth
+	// Deprecated: replace with [github.com/regclient/regclient/config.DockerRegistryDNS].
+	DockerRegistryDNS = topConfig.DockerRegistryDNS
+	// Deprecated: replace with [github.com/regclient/regclient/config.TLSUndefined].
+	TLSUndefined = topConfig.TLSUndefined
+	// Deprecated: replace with [github.com/regclient/regclient/config.TLSEnabled].
+	TLSEnabled = topConfig.TLSEnabled
+	// Deprecated: replace with [github.com/regclient/regclient/config.TLSInsecure].
+	TLSInsecure = topConfig.TLSInsecure
+	// Deprecated: replace with [github.com/regclient/regclient/config.TLSDisabled].
+	TLSDisabled = topConfig.TLSDisabled
+)
+
+var (
+	// Deprecated: replace with [github.com/regclient/regclient.New].
+	NewRegClient = rcTop.New
+	// Deprecated: replace with [github.com/regclient/regclient.WithCertDir].
+	WithCertDir = rcTop.WithCertDir
+	// Deprecated: replace with [github.com/regclient/regclient.WithDockerCerts].
+	WithDockerCerts = rcTop.WithDockerCerts
+	// Deprecated: replace with [github.com/regclient/regclient.WithDockerCreds].
+	WithDockerCreds = rcTop.WithDockerCreds
+	// Deprecated: replace with [github.com/regclient/regclient.WithConfigHosts].
+	WithConfigHosts = rcTop.WithConfigHosts
+	// Deprecated: replace with [github.com/regclient/regclient.WithConfigHost].
+	WithConfigHost = rcTop.WithConfigHost
+	// Deprecated: replace with [github.com/regclient/regclient.WithBlobSize].
+	WithBlobSize = rcTop.WithBlobSize
+	// Deprecated: replace with [github.com/regclient/regclient.WithLog].
+	WithLog = rcTop.WithLog
+	// Deprecated: replace with [github.com/regclient/regclient.WithRetryDelay].
+	WithRetryDelay = rcTop.WithRetryDelay
+	// Deprecated: replace with [github.com/regclient/regclient.WithRetryLimit].
+	WithRetryLimit = rcTop.WithRetryLimit
+	// Deprecated: replace with [github.com/regclient/regclient.WithUserAgent].
+	WithUserAgent = rcTop.WithUserAgent
+	// Deprecated: replace with [github.com/regclient/regclient.ImageWithForceRecursive].
+	ImageWithForceRecursive = rcTop.ImageWithForceRecursive
+	// Deprecated: replace with [github.com/regclient/regclient.ImageWithDigestTags].
+	ImageWithDigestTags = rcTop.ImageWithDigestTags
+	// Deprecated: replace with [github.com/regclient/regclient.ImageWithPlatforms].
+	ImageWithPlatforms = rcTop.ImageWithPlatforms
+	// Deprecated: replace with [github.com/regclient/regclient/scheme.WithRepoLast].
+	WithRepoLast = scheme.WithRepoLast
+	// Deprecated: replace with [github.com/regclient/regclient/scheme.WithRepoLimit].
+	WithRepoLimit = scheme.WithRepoLimit
+	// Deprecated: replace with [github.com/regclient/regclient/scheme.WithTagLast].
+	TagOptLast = scheme.WithTagLast
+	// Deprecated: replace with [github.com/regclient/regclient/scheme.WithTagLimit].
+	TagOptLimit = scheme.WithTagLimit
+	// Deprecated: replace with [github.com/regclient/regclient/config.HostNewName].
+	ConfigHostNewName = topConfig.HostNewName
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrAPINotFound].
+	ErrAPINotFound = topTypes.ErrAPINotFound
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrCanceled].
+	ErrCanceled = topTypes.ErrCanceled
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrHTTPStatus].
+	//lint:ignore ST1003 exported field cannot be changed for legacy reasons
+	ErrHttpStatus = topTypes.ErrHTTPStatus
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingDigest].
+	ErrMissingDigest = topTypes.ErrMissingDigest
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingLocation].
+	ErrMissingLocation = topTypes.ErrMissingLocation
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingName].
+	ErrMissingName = topTypes.ErrMissingName
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingTag].
+	ErrMissingTag = topTypes.ErrMissingTag
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingTagOrDigest].
+	ErrMissingTagOrDigest = topTypes.ErrMissingTagOrDigest
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMountReturnedLocation].
+	ErrMountReturnedLocation = topTypes.ErrMountReturnedLocation
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrNotFound].
+	ErrNotFound = topTypes.ErrNotFound
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrNotImplemented].
+	ErrNotImplemented = topTypes.ErrNotImplemented
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrParsingFailed].
+	ErrParsingFailed = topTypes.ErrParsingFailed
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrHTTPRateLimit].
+	ErrRateLimit = topTypes.ErrHTTPRateLimit
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnavailable].
+	ErrUnavailable = topTypes.ErrUnavailable
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrHTTPUnauthorized].
+	ErrUnauthorized = topTypes.ErrHTTPUnauthorized
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnsupportedAPI].
+	ErrUnsupportedAPI = topTypes.ErrUnsupportedAPI
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnsupportedConfigVersion].
+	ErrUnsupportedConfigVersion = topTypes.ErrUnsupportedConfigVersion
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnsupportedMediaType].
+	ErrUnsupportedMediaType = topTypes.ErrUnsupportedMediaType
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker1Manifest].
+	MediaTypeDocker1Manifest = topTypes.MediaTypeDocker1Manifest
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker1ManifestSigned].
+	MediaTypeDocker1ManifestSigned = topTypes.MediaTypeDocker1ManifestSigned
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2Manifest].
+	MediaTypeDocker2Manifest = topTypes.MediaTypeDocker2Manifest
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2ManifestList].
+	MediaTypeDocker2ManifestList = topTypes.MediaTypeDocker2ManifestList
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2ImageConfig].
+	MediaTypeDocker2ImageConfig = topTypes.MediaTypeDocker2ImageConfig
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1Manifest].
+	MediaTypeOCI1Manifest = topTypes.MediaTypeOCI1Manifest
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1ManifestList].
+	MediaTypeOCI1ManifestList = topTypes.MediaTypeOCI1ManifestList
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1ImageConfig].
+	MediaTypeOCI1ImageConfig = topTypes.MediaTypeOCI1ImageConfig
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2LayerGzip].
+	MediaTypeDocker2Layer = topTypes.MediaTypeDocker2LayerGzip
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1Layer].
+	MediaTypeOCI1Layer = topTypes.MediaTypeOCI1Layer
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1LayerGzip].
+	MediaTypeOCI1LayerGzip = topTypes.MediaTypeOCI1LayerGzip
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeBuildkitCacheConfig].
+	MediaTypeBuildkitCacheConfig = topTypes.MediaTypeBuildkitCacheConfig
+)
@@ -0,0 +1,52 @@
+// Code generated by legacygen. DO NOT EDIT.
+
+//go:build !nolegacy
+// +build !nolegacy
+
+package manifest
+
+import (
+	topTypes "github.com/regclient/regclient/types"
+	topManifest "github.com/regclient/regclient/types/manifest"
+)
+
+// Deprecated: replace with [github.com/regclient/regclient/types/manifest.Manifest].
+type Manifest = topManifest.Manifest
+
+const (
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker1Manifest].
+	MediaTypeDocker1Manifest = topTypes.MediaTypeDocker1Manifest
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker1ManifestSigned].
+	MediaTypeDocker1ManifestSigned = topTypes.MediaTypeDocker1ManifestSigned
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2Manifest].
+	MediaTypeDocker2Manifest = topTypes.MediaTypeDocker2Manifest
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2ManifestList].
+	MediaTypeDocker2ManifestList = topTypes.MediaTypeDocker2ManifestList
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2ImageConfig].
+	MediaTypeDocker2ImageConfig = topTypes.MediaTypeDocker2ImageConfig
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1Manifest].
+	MediaTypeOCI1Manifest = topTypes.MediaTypeOCI1Manifest
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1ManifestList].
+	MediaTypeOCI1ManifestList = topTypes.MediaTypeOCI1ManifestList
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1ImageConfig].
+	MediaTypeOCI1ImageConfig = topTypes.MediaTypeOCI1ImageConfig
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2LayerGzip].
+	MediaTypeDocker2Layer = topTypes.MediaTypeDocker2LayerGzip
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1Layer].
+	MediaTypeOCI1Layer = topTypes.MediaTypeOCI1Layer
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1LayerGzip].
+	MediaTypeOCI1LayerGzip = topTypes.MediaTypeOCI1LayerGzip
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeBuildkitCacheConfig].
+	MediaTypeBuildkitCacheConfig = topTypes.MediaTypeBuildkitCacheConfig
+)
+
+var (
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrNotFound].
+	ErrNotFound = topTypes.ErrNotFound
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrNotImplemented].
+	ErrNotImplemented = topTypes.ErrNotImplemented
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnavailable].
+	ErrUnavailable = topTypes.ErrUnavailable
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnsupported].
+	ErrUnsupportedMediaType = topTypes.ErrUnsupported
+)
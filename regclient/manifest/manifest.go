@@ -2,8 +2,12 @@
 // +build !nolegacy
 
 // Package manifest is a legacy package, this has been moved to the types/manifest package
+//
+// Deprecated: replace with [github.com/regclient/regclient/types/manifest].
 package manifest
 
+//go:generate go run github.com/regclient/regclient/internal/legacygen
+
 import (
 	"net/http"
 
@@ -12,30 +16,9 @@ import (
 	"github.com/regclient/regclient/types/ref"
 )
 
-const (
-	MediaTypeDocker1Manifest       = topTypes.MediaTypeDocker1Manifest
-	MediaTypeDocker1ManifestSigned = topTypes.MediaTypeDocker1ManifestSigned
-	MediaTypeDocker2Manifest       = topTypes.MediaTypeDocker2Manifest
-	MediaTypeDocker2ManifestList   = topTypes.MediaTypeDocker2ManifestList
-	MediaTypeDocker2ImageConfig    = topTypes.MediaTypeDocker2ImageConfig
-	MediaTypeOCI1Manifest          = topTypes.MediaTypeOCI1Manifest
-	MediaTypeOCI1ManifestList      = topTypes.MediaTypeOCI1ManifestList
-	MediaTypeOCI1ImageConfig       = topTypes.MediaTypeOCI1ImageConfig
-	MediaTypeDocker2Layer          = topTypes.MediaTypeDocker2LayerGzip
-	MediaTypeOCI1Layer             = topTypes.MediaTypeOCI1Layer
-	MediaTypeOCI1LayerGzip         = topTypes.MediaTypeOCI1LayerGzip
-	MediaTypeBuildkitCacheConfig   = topTypes.MediaTypeBuildkitCacheConfig
-)
-
-type Manifest = topManifest.Manifest
-
-var (
-	ErrNotFound             = topTypes.ErrNotFound
-	ErrNotImplemented       = topTypes.ErrNotImplemented
-	ErrUnavailable          = topTypes.ErrUnavailable
-	ErrUnsupportedMediaType = topTypes.ErrUnsupported
-)
-
+// New creates a manifest from the raw body and headers of a request.
+//
+// Deprecated: replace with [github.com/regclient/regclient/types/manifest.New].
 func New(mediaType string, raw []byte, r ref.Ref, header http.Header) (Manifest, error) {
 	return topManifest.New(
 		topManifest.WithDesc(topTypes.Descriptor{
@@ -47,6 +30,9 @@ func New(mediaType string, raw []byte, r ref.Ref, header http.Header) (Manifest,
 	)
 }
 
+// FromDescriptor creates a manifest from a descriptor and the raw body.
+//
+// Deprecated: replace with [github.com/regclient/regclient/types/manifest.New] using [github.com/regclient/regclient/types/manifest.WithDesc].
 func FromDescriptor(desc topTypes.Descriptor, mBytes []byte) (Manifest, error) {
 	return topManifest.New(
 		topManifest.WithDesc(desc),
@@ -54,6 +40,9 @@ func FromDescriptor(desc topTypes.Descriptor, mBytes []byte) (Manifest, error) {
 	)
 }
 
+// FromOrig creates a manifest from the original struct.
+//
+// Deprecated: replace with [github.com/regclient/regclient/types/manifest.New] using [github.com/regclient/regclient/types/manifest.WithOrig].
 func FromOrig(orig interface{}) (Manifest, error) {
 	return topManifest.New(topManifest.WithOrig(orig))
 }
@@ -0,0 +1,102 @@
+// Code generated by legacygen. DO NOT EDIT.
+
+//go:build !nolegacy
+// +build !nolegacy
+
+package types
+
+import (
+	topTypes "github.com/regclient/regclient/types"
+	topRef "github.com/regclient/regclient/types/ref"
+)
+
+// Deprecated: replace with [github.com/regclient/regclient/types.RateLimit].
+type RateLimit = topTypes.RateLimit
+
+// Deprecated: replace with [github.com/regclient/regclient/types/ref.Ref].
+type Ref = topRef.Ref
+
+const (
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker1Manifest].
+	MediaTypeDocker1Manifest = topTypes.MediaTypeDocker1Manifest
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker1ManifestSigned].
+	MediaTypeDocker1ManifestSigned = topTypes.MediaTypeDocker1ManifestSigned
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2Manifest].
+	MediaTypeDocker2Manifest = topTypes.MediaTypeDocker2Manifest
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2ManifestList].
+	MediaTypeDocker2ManifestList = topTypes.MediaTypeDocker2ManifestList
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2ImageConfig].
+	MediaTypeDocker2ImageConfig = topTypes.MediaTypeDocker2ImageConfig
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1Manifest].
+	MediaTypeOCI1Manifest = topTypes.MediaTypeOCI1Manifest
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1ManifestList].
+	MediaTypeOCI1ManifestList = topTypes.MediaTypeOCI1ManifestList
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1ImageConfig].
+	MediaTypeOCI1ImageConfig = topTypes.MediaTypeOCI1ImageConfig
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeDocker2LayerGzip].
+	MediaTypeDocker2Layer = topTypes.MediaTypeDocker2LayerGzip
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1Layer].
+	MediaTypeOCI1Layer = topTypes.MediaTypeOCI1Layer
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeOCI1LayerGzip].
+	MediaTypeOCI1LayerGzip = topTypes.MediaTypeOCI1LayerGzip
+	// Deprecated: replace with [github.com/regclient/regclient/types.MediaTypeBuildkitCacheConfig].
+	MediaTypeBuildkitCacheConfig = topTypes.MediaTypeBuildkitCacheConfig
+)
+
+var (
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrAllRequestsFailed].
+	ErrAllRequestsFailed = topTypes.ErrAllRequestsFailed
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrAPINotFound].
+	ErrAPINotFound = topTypes.ErrAPINotFound
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrBackoffLimit].
+	ErrBackoffLimit = topTypes.ErrBackoffLimit
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrCanceled].
+	ErrCanceled = topTypes.ErrCanceled
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrDigestMismatch].
+	ErrDigestMismatch = topTypes.ErrDigestMismatch
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrEmptyChallenge].
+	ErrEmptyChallenge = topTypes.ErrEmptyChallenge
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrHTTPStatus].
+	//lint:ignore ST1003 exported field cannot be changed for legacy reasons
+	ErrHttpStatus = topTypes.ErrHTTPStatus
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrInvalidChallenge].
+	ErrInvalidChallenge = topTypes.ErrInvalidChallenge
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingDigest].
+	ErrMissingDigest = topTypes.ErrMissingDigest
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingLocation].
+	ErrMissingLocation = topTypes.ErrMissingLocation
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingName].
+	ErrMissingName = topTypes.ErrMissingName
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingTag].
+	ErrMissingTag = topTypes.ErrMissingTag
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMissingTagOrDigest].
+	ErrMissingTagOrDigest = topTypes.ErrMissingTagOrDigest
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrMountReturnedLocation].
+	ErrMountReturnedLocation = topTypes.ErrMountReturnedLocation
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrNoNewChallenge].
+	ErrNoNewChallenge = topTypes.ErrNoNewChallenge
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrNotFound].
+	ErrNotFound = topTypes.ErrNotFound
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrNotImplemented].
+	ErrNotImplemented = topTypes.ErrNotImplemented
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrParsingFailed].
+	ErrParsingFailed = topTypes.ErrParsingFailed
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrHTTPRateLimit].
+	ErrRateLimit = topTypes.ErrHTTPRateLimit
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrRetryNeeded].
+	ErrRetryNeeded = topTypes.ErrRetryNeeded
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnavailable].
+	ErrUnavailable = topTypes.ErrUnavailable
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrHTTPUnauthorized].
+	ErrUnauthorized = topTypes.ErrHTTPUnauthorized
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnsupported].
+	ErrUnsupported = topTypes.ErrUnsupported
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnsupportedAPI].
+	ErrUnsupportedAPI = topTypes.ErrUnsupportedAPI
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnsupportedConfigVersion].
+	ErrUnsupportedConfigVersion = topTypes.ErrUnsupportedConfigVersion
+	// Deprecated: replace with [github.com/regclient/regclient/types.ErrUnsupportedMediaType].
+	ErrUnsupportedMediaType = topTypes.ErrUnsupportedMediaType
+	// Deprecated: replace with [github.com/regclient/regclient/types/ref.New].
+	NewRef = topRef.New
+)
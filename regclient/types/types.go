@@ -2,4 +2,8 @@
 // +build !nolegacy
 
 // Package types is a legacy package, using the top level types package is recommended
+//
+// Deprecated: replace with [github.com/regclient/regclient/types] and [github.com/regclient/regclient/types/ref].
 package types
+
+//go:generate go run github.com/regclient/regclient/internal/legacygen
@@ -0,0 +1,21 @@
+//go:build go1.21
+
+package regclient
+
+import (
+	"log/slog"
+
+	"github.com/regclient/regclient/internal/slogrus"
+)
+
+// WithSlog configures the logging with a [slog.Logger].
+// Log fields include the reference, host, repository, and digest of each request,
+// and registry requests include the operation (e.g. "manifest-get" or "blob-upload").
+// This replaces any logger from [WithLog] and requires Go 1.21 or newer.
+// Logging is still done with logrus internally, which remains a dependency while Go 1.19 is supported,
+// and each entry is forwarded to the slog handler.
+func WithSlog(logger *slog.Logger) Opt {
+	return func(rc *RegClient) {
+		rc.log = slogrus.New(logger)
+	}
+}
@@ -1,11 +1,35 @@
 package regclient
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
 	"testing"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/pkg/trace"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/scheme/ocidir"
 	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Verify RegClient implements the client interfaces.
+var (
+	_ ManifestClient = (*RegClient)(nil)
+	_ BlobClient     = (*RegClient)(nil)
+	_ TagClient      = (*RegClient)(nil)
+	_ ImageClient    = (*RegClient)(nil)
+	_ Client         = (*RegClient)(nil)
 )
 
 func TestNew(t *testing.T) {
@@ -101,3 +125,254 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+// schemeCount wraps a scheme to count manifest requests.
+type schemeCount struct {
+	scheme.API
+	heads int
+}
+
+func (s *schemeCount) ManifestHead(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	s.heads++
+	return s.API.ManifestHead(ctx, r)
+}
+
+func TestDigestAlgorithm(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsMem := rwfs.MemNew()
+	rc := New(
+		WithFS(fsMem),
+		WithDigestAlgorithm(digest.SHA512),
+		WithConfigHost(
+			config.Host{Name: "sha256.example.com", DigestAlgorithm: "sha256"},
+			config.Host{Name: "invalid.example.com", DigestAlgorithm: "md5"},
+		),
+		WithRegistryAlias(map[string]string{"alias.example.com": "sha256.example.com"}),
+	)
+	tt := []struct {
+		ref    string
+		expect digest.Algorithm
+	}{
+		{ref: "registry.example.com/repo:v1", expect: digest.SHA512},
+		{ref: "sha256.example.com/repo:v1", expect: digest.SHA256},
+		{ref: "alias.example.com/repo:v1", expect: digest.SHA256},
+		{ref: "invalid.example.com/repo:v1", expect: digest.SHA512},
+		{ref: "ocidir://repo:v1", expect: digest.SHA512},
+	}
+	for _, tc := range tt {
+		r, err := ref.New(tc.ref)
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		if alg := rc.DigestAlgorithm(r); alg != tc.expect {
+			t.Errorf("unexpected algorithm for %s, expected %s, received %s", tc.ref, tc.expect, alg)
+		}
+	}
+	// blobs pushed without a digest use the default algorithm
+	r, err := ref.New("ocidir://repo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	content := []byte("sha512 content")
+	d, err := rc.BlobPut(ctx, r, types.Descriptor{}, bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to put blob: %v", err)
+	}
+	if d.Digest != digest.SHA512.FromBytes(content) {
+		t.Errorf("unexpected digest, received %s", d.Digest)
+	}
+	br, err := rc.BlobGet(ctx, r, d)
+	if err != nil {
+		t.Fatalf("failed to get blob: %v", err)
+	}
+	_, err = io.ReadAll(br)
+	if err != nil {
+		t.Errorf("failed to read blob: %v", err)
+	}
+	_ = br.Close()
+}
+
+func TestWithScheme(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	sCustom := &schemeCount{API: ocidir.New(ocidir.WithFS(fsOS))}
+	sReplace := &schemeCount{API: ocidir.New(ocidir.WithFS(fsOS))}
+	err := ref.RegisterScheme("test-layout")
+	if err != nil {
+		t.Fatalf("failed to register scheme: %v", err)
+	}
+	rc := New(
+		WithScheme("test-layout", sCustom),
+		WithScheme("ocidir", sReplace),
+		WithScheme("Invalid_Name", ocidir.New(ocidir.WithFS(fsOS))),
+		WithScheme("test-unregistered", ocidir.New(ocidir.WithFS(fsOS))),
+	)
+	if ref.SchemeRegistered("test-unregistered") {
+		t.Errorf("WithScheme registered the scheme name")
+	}
+	rCustom, err := ref.New("test-layout://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	_, err = rc.ManifestHead(ctx, rCustom)
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	if sCustom.heads != 1 {
+		t.Errorf("custom scheme was not called, heads %d", sCustom.heads)
+	}
+	rOCI, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	_, err = rc.ManifestHead(ctx, rOCI)
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	if sReplace.heads != 1 {
+		t.Errorf("replaced scheme was not called, heads %d", sReplace.heads)
+	}
+	_, err = rc.schemeGet("Invalid_Name")
+	if !errors.Is(err, types.ErrNotImplemented) {
+		t.Errorf("invalid scheme name was registered: %v", err)
+	}
+	_, err = rc.schemeGet("test-unregistered")
+	if !errors.Is(err, types.ErrNotImplemented) {
+		t.Errorf("unregistered scheme name was added: %v", err)
+	}
+}
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	name   string
+	parent *testSpan
+	attrs  map[string]interface{}
+	ended  bool
+	err    error
+}
+
+func (s *testSpan) SetAttributes(attrs ...trace.Attr) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *testSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, attrs ...trace.Attr) (context.Context, trace.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &testSpan{name: name, attrs: map[string]interface{}{}}
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		s.parent = parent
+	}
+	s.SetAttributes(attrs...)
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, testSpanKey{}, s), s
+}
+
+func (t *testTracer) Inject(ctx context.Context, header http.Header) {
+	if s, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		header.Set("X-Test-Span", s.name)
+	}
+}
+
+func TestWithTracer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tracer := &testTracer{}
+	rc := New(WithTracer(tracer))
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://" + t.TempDir() + "/repo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	m, err := rc.ManifestGet(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	if len(tracer.spans) != 1 {
+		t.Fatalf("unexpected number of spans, expected 1, received %d", len(tracer.spans))
+	}
+	s := tracer.spans[0]
+	if s.name != "regclient.ManifestGet" || !s.ended || s.err != nil {
+		t.Errorf("unexpected span: %s, ended %t, err %v", s.name, s.ended, s.err)
+	}
+	if s.attrs[trace.AttrRef] != rSrc.CommonName() || s.attrs[trace.AttrDigest] != m.GetDescriptor().Digest.String() || s.attrs[trace.AttrSize] != m.GetDescriptor().Size {
+		t.Errorf("unexpected attributes: %v", s.attrs)
+	}
+	// child calls in a copy are nested in the copy span
+	err = rc.ImageCopy(ctx, rSrc, rTgt)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	s = tracer.spans[1]
+	if s.name != "regclient.ImageCopy" || s.attrs[trace.AttrRefTarget] != rTgt.CommonName() || !s.ended {
+		t.Errorf("unexpected copy span: %s, attrs %v", s.name, s.attrs)
+	}
+	children := 0
+	for _, child := range tracer.spans[2:] {
+		if child.parent == s {
+			children++
+		}
+		if !child.ended {
+			t.Errorf("span was not ended: %s", child.name)
+		}
+	}
+	if children == 0 {
+		t.Errorf("copy did not include child spans")
+	}
+	// errors are recorded on the span
+	_, err = rc.ManifestGet(ctx, rSrc.SetTag("missing"))
+	if err == nil {
+		t.Fatalf("get of missing manifest did not fail")
+	}
+	s = tracer.spans[len(tracer.spans)-1]
+	if s.name != "regclient.ManifestGet" || s.err == nil {
+		t.Errorf("error was not recorded on span %s", s.name)
+	}
+}
+
+func TestMirrorHealth(t *testing.T) {
+	t.Parallel()
+	rc := New(
+		WithConfigHost(
+			config.Host{Name: "registry.example.com", Mirrors: []string{"mirror.example.com"}},
+			config.Host{Name: "mirror.example.com", Priority: 5, Weight: 2},
+		),
+	)
+	r, err := ref.New("registry.example.com/repo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	health, err := rc.MirrorHealth(r)
+	if err != nil {
+		t.Fatalf("failed to get mirror health: %v", err)
+	}
+	if len(health) != 2 || health[0].Name != "registry.example.com" || health[0].Mirror ||
+		health[1].Name != "mirror.example.com" || !health[1].Mirror || health[1].Priority != 5 || health[1].Weight != 2 || !health[1].Healthy {
+		t.Errorf("unexpected mirror health: %v", health)
+	}
+	rOCI, err := ref.New("ocidir://repo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	_, err = rc.MirrorHealth(rOCI)
+	if !errors.Is(err, types.ErrUnsupported) {
+		t.Errorf("unexpected error for ocidir: %v", err)
+	}
+}
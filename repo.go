@@ -2,20 +2,34 @@ package regclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"strings"
 
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/repo"
 )
 
+type repoDeleter interface {
+	RepoDelete(ctx context.Context, r ref.Ref) error
+}
+
 type repoLister interface {
 	RepoList(ctx context.Context, hostname string, opts ...scheme.RepoOpts) (*repo.RepoList, error)
 }
 
 // RepoList returns a list of repositories on a registry.
 // Note the underlying "_catalog" API is not supported on many cloud registries.
+// Registries without the _catalog API may be listed with a provider selected by the repoList setting of the host, see [config.Host].
+// The [scheme.WithRepoNamespace], [scheme.WithRepoPrefix], [scheme.WithRepoFilter], and [scheme.WithRepoAccessible] options are applied to the returned page of repositories,
+// the raw body is not modified.
+// Use [RegClient.RepoWalk] to apply those options to every page.
 func (rc *RegClient) RepoList(ctx context.Context, hostname string, opts ...scheme.RepoOpts) (*repo.RepoList, error) {
 	i := strings.Index(hostname, "/")
 	if i > 0 {
@@ -29,5 +43,226 @@ func (rc *RegClient) RepoList(ctx context.Context, hostname string, opts ...sche
 	if !ok {
 		return nil, types.ErrNotImplemented
 	}
-	return rl.RepoList(ctx, hostname, opts...)
+	list, err := rl.RepoList(ctx, hostname, opts...)
+	if err != nil {
+		return nil, err
+	}
+	config := scheme.RepoConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	list.Repositories, err = rc.repoFilter(ctx, hostname, list.Repositories, config)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// RepoWalk calls fn with each repository on a registry, requesting every page from the repository list API.
+// The [scheme.WithRepoLimit] option sets the size of each page, and [scheme.WithRepoLast] starts after the given repository.
+// Repositories are filtered with the same options as [RegClient.RepoList].
+// An error returned by fn stops the walk and is returned.
+func (rc *RegClient) RepoWalk(ctx context.Context, hostname string, fn func(repo string) error, opts ...scheme.RepoOpts) error {
+	i := strings.Index(hostname, "/")
+	if i > 0 {
+		return fmt.Errorf("invalid hostname: %s%.0w", hostname, types.ErrParsingFailed)
+	}
+	schemeAPI, err := rc.schemeGet("reg")
+	if err != nil {
+		return err
+	}
+	rl, ok := schemeAPI.(repoLister)
+	if !ok {
+		return types.ErrNotImplemented
+	}
+	config := scheme.RepoConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	last := config.Last
+	for {
+		list, err := rl.RepoList(ctx, hostname, append(opts[:len(opts):len(opts)], scheme.WithRepoLast(last))...)
+		if err != nil {
+			return err
+		}
+		repos := list.Repositories
+		// registries without pagination return the full list on every request
+		if len(repos) == 0 || last == repos[len(repos)-1] {
+			return nil
+		}
+		last = repos[len(repos)-1]
+		repos, err = rc.repoFilter(ctx, hostname, repos, config)
+		if err != nil {
+			return err
+		}
+		for _, repo := range repos {
+			err = fn(repo)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// repoFilter applies the client side filters from the repo options.
+func (rc *RegClient) repoFilter(ctx context.Context, hostname string, repos []string, config scheme.RepoConfig) ([]string, error) {
+	if config.Namespace == "" && config.Prefix == "" && len(config.Filters) == 0 && !config.Accessible {
+		return repos, nil
+	}
+	result := []string{}
+	for _, repo := range repos {
+		if config.Namespace != "" && !strings.HasPrefix(repo, strings.TrimSuffix(config.Namespace, "/")+"/") {
+			continue
+		}
+		if !strings.HasPrefix(repo, config.Prefix) {
+			continue
+		}
+		if len(config.Filters) > 0 {
+			matched := false
+			for _, re := range config.Filters {
+				if re.MatchString(repo) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		if config.Accessible {
+			r, err := ref.New(hostname + "/" + repo)
+			if err != nil {
+				return nil, err
+			}
+			_, err = rc.TagList(ctx, r, scheme.WithTagLimit(1))
+			if errors.Is(err, types.ErrHTTPUnauthorized) || errors.Is(err, types.ErrNotFound) {
+				rc.log.WithFields(logrus.Fields{
+					"repo": r.CommonName(),
+					"err":  err,
+				}).Debug("Skipping inaccessible repository")
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list tags for %s: %w", r.CommonName(), err)
+			}
+		}
+		result = append(result, repo)
+	}
+	return result, nil
+}
+
+type repoDeleteOpt struct {
+	dryRun bool
+}
+
+// RepoDeleteOpts define options for [RegClient.RepoDelete].
+type RepoDeleteOpts func(*repoDeleteOpt)
+
+// RepoDeleteWithDryRun lists the manifests that would be deleted without modifying the repository.
+func RepoDeleteWithDryRun() RepoDeleteOpts {
+	return func(opts *repoDeleteOpt) {
+		opts.dryRun = true
+	}
+}
+
+// RepoDeleteEntry is a manifest removed by [RegClient.RepoDelete] with the tags that referenced it.
+type RepoDeleteEntry struct {
+	Digest digest.Digest `json:"digest"`
+	Tags   []string      `json:"tags"`
+}
+
+// RepoDelete deletes all tags and manifests in a repository.
+// Registries with a repoDelete setting, see [config.Host], delete the repository with the vendor API.
+// Otherwise each tagged manifest is deleted by digest, along with the referrers of that manifest.
+// The returned entries list each tagged manifest in the repository before the delete.
+func (rc *RegClient) RepoDelete(ctx context.Context, r ref.Ref, opts ...RepoDeleteOpts) ([]RepoDeleteEntry, error) {
+	opt := repoDeleteOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	r = r.SetTag("")
+	if !r.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	entries := []RepoDeleteEntry{}
+	entryIdx := map[digest.Digest]int{}
+	for _, t := range tags {
+		m, err := rc.ManifestHead(ctx, r.SetTag(t), WithManifestRequireDigest())
+		if err != nil {
+			return nil, fmt.Errorf("failed to head %s: %w", t, err)
+		}
+		d := m.GetDescriptor().Digest
+		if i, ok := entryIdx[d]; ok {
+			entries[i].Tags = append(entries[i].Tags, t)
+			continue
+		}
+		entryIdx[d] = len(entries)
+		entries = append(entries, RepoDeleteEntry{Digest: d, Tags: []string{t}})
+	}
+	if opt.dryRun {
+		return entries, nil
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	if rd, ok := schemeAPI.(repoDeleter); ok {
+		err = rd.RepoDelete(ctx, r)
+		if err == nil {
+			return entries, nil
+		} else if !errors.Is(err, types.ErrNotImplemented) {
+			return nil, err
+		}
+	}
+	for _, e := range entries {
+		err = rc.ManifestDelete(ctx, r.SetDigest(e.Digest.String()), WithManifestCheckReferrers(), WithManifestReferrerCleanup())
+		// a manifest may already be deleted as the referrer of another manifest
+		if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to delete %s: %w", e.Digest.String(), err)
+		}
+	}
+	return entries, nil
+}
+
+// RepoRename moves every tag in a repository to another repository, which may be on another registry.
+// All tags are copied and verified before any source tags are deleted.
+// Untagged manifests and blobs remain in the source repository for the registry garbage collection.
+func (rc *RegClient) RepoRename(ctx context.Context, rSrc, rTgt ref.Ref, opts ...RenameOpts) ([]RenameEntry, error) {
+	opt := renameOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	rSrc = rSrc.SetTag("")
+	rTgt = rTgt.SetTag("")
+	if !rSrc.IsSetRepo() || !rTgt.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s, %s%.0w", rSrc.CommonName(), rTgt.CommonName(), types.ErrInvalidReference)
+	}
+	if ref.EqualRepository(rSrc, rTgt) {
+		return nil, fmt.Errorf("source and target are the same: %s%.0w", rSrc.CommonName(), types.ErrInvalidReference)
+	}
+	tl, err := rc.TagList(ctx, rSrc)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]RenameEntry, 0, len(tags))
+	for _, t := range tags {
+		entries = append(entries, RenameEntry{Source: rSrc.SetTag(t), Target: rTgt.SetTag(t)})
+	}
+	err = rc.renameTags(ctx, entries, opt)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
 }
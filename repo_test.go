@@ -3,13 +3,24 @@ package regclient
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
 )
 
 func TestRepoList(t *testing.T) {
@@ -31,3 +42,295 @@ func TestRepoList(t *testing.T) {
 		t.Errorf("RepoList unexpected error on hostname with a path: expected %v, received %v", types.ErrParsingFailed, err)
 	}
 }
+
+func TestRepoWalk(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repos := []string{"library/alpine", "library/busybox", "private/app", "team/app"}
+	catalogResp := func(list []string) reqresp.RespEntry {
+		body := `{"repositories":[]}`
+		if len(list) > 0 {
+			body = fmt.Sprintf(`{"repositories":["%s"]}`, strings.Join(list, `","`))
+		}
+		return reqresp.RespEntry{
+			Status:  http.StatusOK,
+			Body:    []byte(body),
+			Headers: http.Header{"Content-Type": {"application/json"}},
+		}
+	}
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "catalog last page",
+				Method: "GET",
+				Path:   "/v2/_catalog",
+				Query:  map[string][]string{"last": {repos[3]}},
+			},
+			RespEntry: catalogResp(nil),
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "catalog second page",
+				Method: "GET",
+				Path:   "/v2/_catalog",
+				Query:  map[string][]string{"last": {repos[1]}},
+			},
+			RespEntry: catalogResp(repos[2:]),
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "catalog first page",
+				Method: "GET",
+				Path:   "/v2/_catalog",
+			},
+			RespEntry: catalogResp(repos[:2]),
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "tags forbidden",
+				Method: "GET",
+				Path:   "/v2/private/app/tags/list",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusForbidden,
+			},
+		},
+	}
+	for _, repo := range []string{repos[0], repos[1], repos[3]} {
+		rrs = append(rrs, reqresp.ReqResp{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "tags " + repo,
+				Method: "GET",
+				Path:   "/v2/" + repo + "/tags/list",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Body:    []byte(fmt.Sprintf(`{"name":"%s","tags":["latest"]}`, repo)),
+				Headers: http.Header{"Content-Type": {"application/json"}},
+			},
+		})
+	}
+	rrs = append(rrs, reqresp.BaseEntries...)
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.WarnLevel,
+	}
+	delayInit, _ := time.ParseDuration("0.05s")
+	delayMax, _ := time.ParseDuration("0.10s")
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:      tsHost,
+			Hostname:  tsHost,
+			TLS:       config.TLSDisabled,
+			ReqPerSec: 100,
+		}),
+		WithLog(log),
+		WithRetryDelay(delayInit, delayMax),
+	)
+	walk := func(opts ...scheme.RepoOpts) ([]string, error) {
+		result := []string{}
+		err := rc.RepoWalk(ctx, tsHost, func(repo string) error {
+			result = append(result, repo)
+			return nil
+		}, opts...)
+		return result, err
+	}
+	tt := []struct {
+		name   string
+		opts   []scheme.RepoOpts
+		expect []string
+	}{
+		{
+			name:   "all",
+			expect: repos,
+		},
+		{
+			name:   "prefix",
+			opts:   []scheme.RepoOpts{scheme.WithRepoPrefix("library/")},
+			expect: repos[:2],
+		},
+		{
+			name:   "filter",
+			opts:   []scheme.RepoOpts{scheme.WithRepoFilter(regexp.MustCompile(`/app$`)), scheme.WithRepoFilter(regexp.MustCompile(`alpine`))},
+			expect: []string{repos[0], repos[2], repos[3]},
+		},
+		{
+			name:   "accessible",
+			opts:   []scheme.RepoOpts{scheme.WithRepoAccessible()},
+			expect: []string{repos[0], repos[1], repos[3]},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := walk(tc.opts...)
+			if err != nil {
+				t.Fatalf("failed to walk repos: %v", err)
+			}
+			if strings.Join(result, ",") != strings.Join(tc.expect, ",") {
+				t.Errorf("unexpected repos, expected %v, received %v", tc.expect, result)
+			}
+		})
+	}
+	t.Run("stop", func(t *testing.T) {
+		errStop := errors.New("stop")
+		count := 0
+		err := rc.RepoWalk(ctx, tsHost, func(repo string) error {
+			count++
+			return errStop
+		})
+		if !errors.Is(err, errStop) || count != 1 {
+			t.Errorf("walk did not stop, count %d, err %v", count, err)
+		}
+	})
+	t.Run("list filter", func(t *testing.T) {
+		rl, err := rc.RepoList(ctx, tsHost, scheme.WithRepoFilter(regexp.MustCompile(`busybox`)))
+		if err != nil {
+			t.Fatalf("failed to list repos: %v", err)
+		}
+		result, err := rl.GetRepos()
+		if err != nil || strings.Join(result, ",") != repos[1] {
+			t.Errorf("unexpected repos, expected %s, received %v, err %v", repos[1], result, err)
+		}
+	})
+}
+
+func TestRepoRename(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	rSrc, err := ref.New("ocidir://testrepo")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://testrenamed")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	tl, err := rc.TagList(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		t.Fatalf("failed to get tags: %v", err)
+	}
+	_, err = rc.RepoRename(ctx, rSrc, rSrc)
+	if !errors.Is(err, types.ErrInvalidReference) {
+		t.Errorf("unexpected error renaming to the same repo: %v", err)
+	}
+	entries, err := rc.RepoRename(ctx, rSrc, rTgt)
+	if err != nil {
+		t.Fatalf("failed to rename: %v", err)
+	}
+	if len(entries) != len(tags) {
+		t.Errorf("unexpected number of entries, expected %d, received %d", len(tags), len(entries))
+	}
+	for _, e := range entries {
+		m, err := rc.ManifestHead(ctx, e.Target, WithManifestRequireDigest())
+		if err != nil {
+			t.Errorf("failed to head %s: %v", e.Target.CommonName(), err)
+			continue
+		}
+		if m.GetDescriptor().Digest != e.Digest {
+			t.Errorf("digest mismatch for %s", e.Target.CommonName())
+		}
+	}
+	tl, err = rc.TagList(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	tags, err = tl.GetTags()
+	if err != nil {
+		t.Fatalf("failed to get tags: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("source tags were not deleted: %v", tags)
+	}
+}
+
+func TestRepoDelete(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	rSrc, err := ref.New("ocidir://testrepo")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	r, err := ref.New("ocidir://testdelete")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	for _, tag := range []string{"v1", "v2", "v3"} {
+		err = rc.ImageCopy(ctx, rSrc.SetTag(tag), r.SetTag(tag), ImageWithReferrers())
+		if err != nil {
+			t.Fatalf("failed to copy %s: %v", tag, err)
+		}
+	}
+	// an alias shares the digest of another tag
+	err = rc.ImageCopy(ctx, rSrc.SetTag("v1"), r.SetTag("alias"))
+	if err != nil {
+		t.Fatalf("failed to copy alias: %v", err)
+	}
+	m1, err := rc.ManifestHead(ctx, r.SetTag("v1"), WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head v1: %v", err)
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		entries, err := rc.RepoDelete(ctx, r, RepoDeleteWithDryRun())
+		if err != nil {
+			t.Fatalf("failed to run delete: %v", err)
+		}
+		found := false
+		for _, e := range entries {
+			if e.Digest == m1.GetDescriptor().Digest {
+				found = true
+				if strings.Join(e.Tags, ",") != "alias,v1" {
+					t.Errorf("unexpected tags for v1: %v", e.Tags)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("v1 missing from entries: %v", entries)
+		}
+		_, err = rc.ManifestHead(ctx, r.SetTag("v1"))
+		if err != nil {
+			t.Errorf("dry run deleted v1: %v", err)
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		_, err := rc.RepoDelete(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to delete: %v", err)
+		}
+		tl, err := rc.TagList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if len(tl.Tags) > 0 {
+			t.Errorf("tags remain after delete: %v", tl.Tags)
+		}
+		_, err = rc.ManifestHead(ctx, rSrc.SetTag("v1"))
+		if err != nil {
+			t.Errorf("source repository was modified: %v", err)
+		}
+	})
+}
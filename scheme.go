@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/scheme/ocidir"
+	"github.com/regclient/regclient/scheme/reg"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -31,3 +33,55 @@ func (rc *RegClient) Close(ctx context.Context, r ref.Ref) error {
 	}
 	return sc.Close(ctx, r)
 }
+
+// OCIDirGC removes blobs from an OCI Layout that are no longer reachable from the index.json.
+// Use [ocidir.GCWithDryRun] to report the reclaimable space without deleting any blobs.
+func (rc *RegClient) OCIDirGC(ctx context.Context, r ref.Ref, opts ...ocidir.GCOpts) (ocidir.GCResult, error) {
+	if r.Scheme != "ocidir" {
+		return ocidir.GCResult{}, fmt.Errorf("garbage collection requires an ocidir reference: %s%.0w", r.CommonName(), types.ErrUnsupported)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return ocidir.GCResult{}, err
+	}
+	o, ok := schemeAPI.(*ocidir.OCIDir)
+	if !ok {
+		return ocidir.GCResult{}, fmt.Errorf("scheme does not support garbage collection: %s%.0w", r.Scheme, types.ErrNotImplemented)
+	}
+	return o.GC(ctx, r, opts...)
+}
+
+// MirrorHealth returns the health of a registry and each of its mirrors.
+// Failed mirrors are skipped for the MirrorBackoff of the host config, falling back to the next mirror or the upstream registry.
+func (rc *RegClient) MirrorHealth(r ref.Ref) ([]reg.MirrorHealth, error) {
+	if r.Scheme != "reg" {
+		return nil, fmt.Errorf("mirror health requires a registry reference: %s%.0w", r.CommonName(), types.ErrUnsupported)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	sr, ok := schemeAPI.(*reg.Reg)
+	if !ok {
+		return nil, fmt.Errorf("scheme does not support mirror health: %s%.0w", r.Scheme, types.ErrNotImplemented)
+	}
+	return sr.MirrorHealth(r), nil
+}
+
+// RegistryCapabilities verifies the login to a registry and reports the supported APIs.
+// Repository features, like the referrers API, push, and delete, are only probed when the reference includes a repository.
+// Supported manifest types and blob mounts are probed by pushing content with [reg.CapabilitiesWithPush].
+func (rc *RegClient) RegistryCapabilities(ctx context.Context, r ref.Ref, opts ...reg.CapabilitiesOpts) (reg.Capabilities, error) {
+	if r.Scheme != "reg" {
+		return reg.Capabilities{}, fmt.Errorf("registry capabilities requires a registry reference: %s%.0w", r.CommonName(), types.ErrUnsupported)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return reg.Capabilities{}, err
+	}
+	sr, ok := schemeAPI.(*reg.Reg)
+	if !ok {
+		return reg.Capabilities{}, fmt.Errorf("scheme does not support registry capabilities: %s%.0w", r.Scheme, types.ErrNotImplemented)
+	}
+	return sr.Capabilities(ctx, r, opts...)
+}
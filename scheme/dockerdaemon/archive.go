@@ -0,0 +1,262 @@
+package dockerdaemon
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/pkg/archive"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const dockerManifestFilename = "manifest.json"
+
+// dockerTarManifest is an entry in the manifest.json of a docker save/load tar.
+type dockerTarManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// imageImport converts an engine export to an OCI manifest in the layout, tagged with the ref tag.
+// The export is spooled to a temp directory first since manifest.json may follow the layers in the tar.
+func (d *DockerDaemon) imageImport(ctx context.Context, l *layout, r ref.Ref, rdr io.Reader) error {
+	tmpDir, err := os.MkdirTemp("", "regclient-dockerdaemon-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	stage := rwfs.OSNew(tmpDir)
+	err = tarExtract(rdr, stage)
+	if err != nil {
+		return err
+	}
+	dtmList := []dockerTarManifest{}
+	mjBytes, err := rwfs.ReadFile(stage, dockerManifestFilename)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dockerManifestFilename, err)
+	}
+	err = json.Unmarshal(mjBytes, &dtmList)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", dockerManifestFilename, err)
+	}
+	dtm, err := dockerTarManifestFind(dtmList, r)
+	if err != nil {
+		return err
+	}
+	rDir := dirRef(r)
+	om := v1.Manifest{
+		Versioned: v1.ManifestSchemaVersion,
+		MediaType: types.MediaTypeOCI1Manifest,
+		Layers:    []types.Descriptor{},
+	}
+	// config is pushed as is to preserve the image id
+	om.Config, err = stageBlobPut(ctx, l, rDir, stage, dtm.Config, false)
+	if err != nil {
+		return err
+	}
+	om.Config.MediaType = types.MediaTypeOCI1ImageConfig
+	// layers are exported uncompressed and gzip compressed when converted
+	for _, layerFile := range dtm.Layers {
+		desc, err := stageBlobPut(ctx, l, rDir, stage, layerFile, true)
+		if err != nil {
+			return err
+		}
+		desc.MediaType = types.MediaTypeOCI1LayerGzip
+		om.Layers = append(om.Layers, desc)
+	}
+	m, err := manifest.New(manifest.WithOrig(om))
+	if err != nil {
+		return err
+	}
+	return l.dir.ManifestPut(ctx, rDir, m)
+}
+
+// dockerTarManifestFind returns the manifest.json entry matching the ref.
+// A single entry is returned when the engine export does not include the requested tag.
+func dockerTarManifestFind(dtmList []dockerTarManifest, r ref.Ref) (dockerTarManifest, error) {
+	for _, dtm := range dtmList {
+		for _, rt := range dtm.RepoTags {
+			rCur, err := ref.New(rt)
+			if err != nil {
+				continue
+			}
+			if ref.EqualRepository(rCur, r.ToReg()) && rCur.Tag == r.Tag {
+				return dtm, nil
+			}
+		}
+	}
+	if len(dtmList) == 1 {
+		return dtmList[0], nil
+	}
+	return dockerTarManifest{}, fmt.Errorf("image %s not found in %s%.0w", engineName(r), dockerManifestFilename, types.ErrNotFound)
+}
+
+// stageBlobPut pushes a file from the staging filesystem to the layout.
+func stageBlobPut(ctx context.Context, l *layout, rDir ref.Ref, stage rwfs.RWFS, file string, compress bool) (types.Descriptor, error) {
+	name := path.Clean(strings.TrimPrefix(file, "/"))
+	fh, err := stage.Open(name)
+	if err != nil {
+		return types.Descriptor{}, fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	defer fh.Close()
+	var rdr io.Reader = fh
+	if compress {
+		rdr, err = archive.Compress(fh, archive.CompressGzip)
+		if err != nil {
+			return types.Descriptor{}, fmt.Errorf("failed to compress %s: %w", file, err)
+		}
+	}
+	return l.dir.BlobPut(ctx, rDir, types.Descriptor{}, rdr)
+}
+
+// imageSave writes a docker load tar for the pushed tags.
+// Multi-platform images are resolved to the requested platform since the engine only loads a single platform.
+func (d *DockerDaemon) imageSave(ctx context.Context, l *layout, r ref.Ref, tags []string, plat platform.Platform, w io.Writer) error {
+	dtmList := []dockerTarManifest{}
+	blobs := []digest.Digest{}
+	blobSeen := map[digest.Digest]bool{}
+	addBlob := func(dig digest.Digest) string {
+		if !blobSeen[dig] {
+			blobSeen[dig] = true
+			blobs = append(blobs, dig)
+		}
+		return blobPath(dig)
+	}
+	for _, t := range tags {
+		rDir := dirRef(r.SetTag(t))
+		m, err := l.dir.ManifestGet(ctx, rDir)
+		if err != nil {
+			return err
+		}
+		if m.IsList() {
+			desc, err := manifest.GetPlatformDesc(m, &plat)
+			if err != nil {
+				return fmt.Errorf("failed to find platform %s in %s: %w", plat.String(), engineName(r.SetTag(t)), err)
+			}
+			m, err = l.dir.ManifestGet(ctx, rDir.SetDigest(desc.Digest.String()))
+			if err != nil {
+				return err
+			}
+		}
+		mi, ok := m.(manifest.Imager)
+		if !ok {
+			return fmt.Errorf("manifest is not an image: %s%.0w", engineName(r.SetTag(t)), types.ErrUnsupportedMediaType)
+		}
+		cd, err := mi.GetConfig()
+		if err != nil {
+			return err
+		}
+		layers, err := mi.GetLayers()
+		if err != nil {
+			return err
+		}
+		dtm := dockerTarManifest{
+			Config:   addBlob(cd.Digest),
+			RepoTags: []string{engineName(r.SetTag(t))},
+			Layers:   []string{},
+		}
+		for _, ld := range layers {
+			dtm.Layers = append(dtm.Layers, addBlob(ld.Digest))
+		}
+		dtmList = append(dtmList, dtm)
+	}
+
+	tw := tar.NewWriter(w)
+	mjBytes, err := json.Marshal(dtmList)
+	if err != nil {
+		return err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     dockerManifestFilename,
+		Mode:     0644,
+		Size:     int64(len(mjBytes)),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(mjBytes)
+	if err != nil {
+		return err
+	}
+	for _, dig := range blobs {
+		file := path.Join(layoutDir, blobPath(dig))
+		fi, err := rwfs.Stat(l.mem, file)
+		if err != nil {
+			return fmt.Errorf("failed to find blob %s: %w", dig.String(), err)
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     blobPath(dig),
+			Mode:     0644,
+			Size:     fi.Size(),
+		})
+		if err != nil {
+			return err
+		}
+		fh, err := l.mem.Open(file)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, fh)
+		_ = fh.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func blobPath(dig digest.Digest) string {
+	return path.Join("blobs", dig.Algorithm().String(), dig.Encoded())
+}
+
+// tarExtract writes the regular files from a tar into a filesystem.
+func tarExtract(rdr io.Reader, rwfsTgt rwfs.RWFS) error {
+	tr := tar.NewReader(rdr)
+	for {
+		th, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		name := path.Clean(strings.TrimPrefix(th.Name, "/"))
+		if th.Typeflag != tar.TypeReg || !fs.ValidPath(name) || name == "." {
+			continue
+		}
+		if dir := path.Dir(name); dir != "." {
+			err = rwfs.MkdirAll(rwfsTgt, dir, 0777)
+			if err != nil {
+				return err
+			}
+		}
+		fh, err := rwfsTgt.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fh, tr)
+		errC := fh.Close()
+		if err != nil {
+			return err
+		}
+		if errC != nil {
+			return errC
+		}
+	}
+}
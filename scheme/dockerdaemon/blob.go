@@ -0,0 +1,38 @@
+package dockerdaemon
+
+import (
+	"context"
+	"io"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// BlobDelete removes a blob from the repository
+func (d *DockerDaemon) BlobDelete(ctx context.Context, r ref.Ref, desc types.Descriptor) error {
+	return types.ErrNotImplemented
+}
+
+// BlobGet retrieves a blob, returning a reader
+func (d *DockerDaemon) BlobGet(ctx context.Context, r ref.Ref, desc types.Descriptor) (blob.Reader, error) {
+	l := d.layoutGet(r)
+	return l.dir.BlobGet(ctx, dirRef(r), desc)
+}
+
+// BlobHead verifies the existence of a blob, the reader contains the headers but no body to read
+func (d *DockerDaemon) BlobHead(ctx context.Context, r ref.Ref, desc types.Descriptor) (blob.Reader, error) {
+	l := d.layoutGet(r)
+	return l.dir.BlobHead(ctx, dirRef(r), desc)
+}
+
+// BlobMount attempts to perform a server side copy of the blob
+func (d *DockerDaemon) BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, desc types.Descriptor) error {
+	return types.ErrUnsupported
+}
+
+// BlobPut sends a blob to the repository, returns the digest and size when successful
+func (d *DockerDaemon) BlobPut(ctx context.Context, r ref.Ref, desc types.Descriptor, rdr io.Reader) (types.Descriptor, error) {
+	l := d.layoutGet(r)
+	return l.dir.BlobPut(ctx, dirRef(r), desc, rdr)
+}
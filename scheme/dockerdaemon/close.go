@@ -0,0 +1,107 @@
+package dockerdaemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Close loads any pushed tags into the engine and frees the memory used by the repository.
+func (d *DockerDaemon) Close(ctx context.Context, r ref.Ref) error {
+	key := r.Registry + "/" + r.Repository
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	l, ok := d.layouts[key]
+	if !ok {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks > 0 {
+		// locked, skip load
+		return nil
+	}
+	if len(l.tags) > 0 {
+		tags := []string{}
+		for t := range l.tags {
+			tags = append(tags, t)
+		}
+		sort.Strings(tags)
+		err := d.imageLoad(ctx, l, r, tags)
+		if err != nil {
+			return err
+		}
+		d.log.WithFields(logrus.Fields{
+			"ref":  r.CommonName(),
+			"tags": tags,
+		}).Debug("loaded image into engine")
+	}
+	delete(d.layouts, key)
+	return nil
+}
+
+// imageLoad streams the pushed tags to the engine load API.
+func (d *DockerDaemon) imageLoad(ctx context.Context, l *layout, r ref.Ref, tags []string) error {
+	plat := d.enginePlatform(ctx)
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(d.imageSave(ctx, l, r, tags, plat, pw))
+	}()
+	resp, err := d.engineReq(ctx, http.MethodPost, "/images/load", url.Values{"quiet": []string{"1"}}, pr)
+	if err != nil {
+		_ = pr.CloseWithError(err)
+		return err
+	}
+	defer resp.Body.Close()
+	// the engine reports failures in a stream of json messages
+	dec := json.NewDecoder(resp.Body)
+	for {
+		msg := struct {
+			Stream string `json:"stream"`
+			Error  string `json:"error"`
+		}{}
+		err = dec.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to parse engine load response: %w", err)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("engine failed to load %s: %s", engineName(r), msg.Error)
+		}
+	}
+	l.tags = map[string]bool{}
+	return nil
+}
+
+// enginePlatform returns the platform of the engine, falling back to the local platform.
+func (d *DockerDaemon) enginePlatform(ctx context.Context) platform.Platform {
+	plat := platform.Local()
+	resp, err := d.engineReq(ctx, http.MethodGet, "/version", nil, nil)
+	if err != nil {
+		return plat
+	}
+	defer resp.Body.Close()
+	v := struct {
+		Os   string `json:"Os"`
+		Arch string `json:"Arch"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil || v.Os == "" || v.Arch == "" {
+		return plat
+	}
+	p, err := platform.Parse(v.Os + "/" + v.Arch)
+	if err != nil {
+		return plat
+	}
+	return p
+}
@@ -0,0 +1,245 @@
+// Package dockerdaemon implements the docker-daemon scheme using the Docker Engine API.
+// Images are exported from the engine to a temp directory on first access and converted to OCI in memory.
+// Tags pushed to the scheme are loaded into the engine when the reference is closed.
+package dockerdaemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/scheme/ocidir"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	// DefaultHost is the engine API used when DOCKER_HOST is not set.
+	DefaultHost = "unix:///var/run/docker.sock"
+	// layoutDir is the OCI Layout within the in memory filesystem of each repository.
+	layoutDir = "image"
+)
+
+// DockerDaemon is used for accessing images in a Docker Engine.
+type DockerDaemon struct {
+	host    string
+	base    string
+	client  *http.Client
+	hostErr error
+	log     *logrus.Logger
+	layouts map[string]*layout
+	mu      sync.Mutex
+}
+
+// layout holds the images of a repository in memory.
+type layout struct {
+	dir    *ocidir.OCIDir
+	mem    *rwfs.MemFS
+	loaded map[string]bool // engine names already exported
+	tags   map[string]bool // tags pushed that need to be loaded into the engine
+	locks  int
+	mu     sync.Mutex
+}
+
+type config struct {
+	host string
+	log  *logrus.Logger
+}
+
+// Opts are used for passing options to dockerdaemon.
+type Opts func(*config)
+
+// New creates a new DockerDaemon with options.
+func New(opts ...Opts) *DockerDaemon {
+	conf := config{
+		host: os.Getenv("DOCKER_HOST"),
+		log:  &logrus.Logger{Out: io.Discard},
+	}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	if conf.host == "" {
+		conf.host = DefaultHost
+	}
+	d := &DockerDaemon{
+		host:    conf.host,
+		log:     conf.log,
+		layouts: map[string]*layout{},
+	}
+	d.base, d.client, d.hostErr = engineClient(conf.host)
+	return d
+}
+
+// WithHost sets the engine API address, e.g. "unix:///var/run/docker.sock" or "tcp://127.0.0.1:2375".
+// The default uses DOCKER_HOST from the environment, falling back to [DefaultHost].
+func WithHost(host string) Opts {
+	return func(c *config) {
+		c.host = host
+	}
+}
+
+// WithLog provides a logrus logger.
+// By default logging is disabled.
+func WithLog(log *logrus.Logger) Opts {
+	return func(c *config) {
+		c.log = log
+	}
+}
+
+// GCLock is used to prevent loading the image into the engine during a put.
+func (d *DockerDaemon) GCLock(r ref.Ref) {
+	l := d.layoutGet(r)
+	l.mu.Lock()
+	l.locks++
+	l.mu.Unlock()
+}
+
+// GCUnlock removes a hold on loading the image, this must be done before the ref is closed.
+func (d *DockerDaemon) GCUnlock(r ref.Ref) {
+	l := d.layoutGet(r)
+	l.mu.Lock()
+	if l.locks > 0 {
+		l.locks--
+	}
+	l.mu.Unlock()
+}
+
+// layoutGet returns the in memory layout for a repository, creating it if needed.
+func (d *DockerDaemon) layoutGet(r ref.Ref) *layout {
+	key := r.Registry + "/" + r.Repository
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if l, ok := d.layouts[key]; ok {
+		return l
+	}
+	mem := rwfs.MemNew()
+	l := &layout{
+		mem:    mem,
+		loaded: map[string]bool{},
+		tags:   map[string]bool{},
+		dir: ocidir.New(
+			ocidir.WithFS(mem),
+			ocidir.WithGC(false),
+			ocidir.WithLog(d.log),
+		),
+	}
+	d.layouts[key] = l
+	return l
+}
+
+// layoutLoad exports a tagged image from the engine into the layout if it has not already been loaded or pushed.
+func (d *DockerDaemon) layoutLoad(ctx context.Context, r ref.Ref) (*layout, error) {
+	l := d.layoutGet(r)
+	if r.Tag == "" {
+		// digests are only available for content already in memory
+		return l, nil
+	}
+	name := engineName(r)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.loaded[name] || l.tags[r.Tag] {
+		return l, nil
+	}
+	resp, err := d.engineReq(ctx, http.MethodGet, "/images/get", url.Values{"names": []string{name}}, nil)
+	if err != nil {
+		return l, err
+	}
+	defer resp.Body.Close()
+	err = d.imageImport(ctx, l, r, resp.Body)
+	if err != nil {
+		return l, fmt.Errorf("failed to import %s from engine: %w", name, err)
+	}
+	l.loaded[name] = true
+	d.log.WithFields(logrus.Fields{
+		"ref": r.CommonName(),
+	}).Debug("exported image from engine")
+	return l, nil
+}
+
+// dirRef converts a reference to the OCI Layout in memory.
+func dirRef(r ref.Ref) ref.Ref {
+	rDir := ref.Ref{
+		Scheme: "ocidir",
+		Path:   layoutDir,
+		Tag:    r.Tag,
+		Digest: r.Digest,
+	}
+	rDir.Reference = rDir.CommonName()
+	return rDir
+}
+
+// engineName is the image name used in engine requests.
+func engineName(r ref.Ref) string {
+	return r.ToReg().CommonName()
+}
+
+// engineClient returns the base URL and http client for an engine host.
+func engineClient(host string) (string, *http.Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse docker host %s: %w", host, err)
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		return "http://docker", &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		}, nil
+	case "tcp":
+		return "http://" + u.Host, &http.Client{}, nil
+	case "http", "https":
+		return strings.TrimSuffix(host, "/"), &http.Client{}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported docker host %s%.0w", host, types.ErrUnsupported)
+	}
+}
+
+// engineReq sends a request to the engine, returning an error for any non-2xx status.
+func (d *DockerDaemon) engineReq(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	if d.hostErr != nil {
+		return nil, d.hostErr
+	}
+	u := d.base + path
+	if len(query) > 0 {
+		u = u + "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-tar")
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to docker engine %s: %w", d.host, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg := struct {
+			Message string `json:"message"`
+		}{}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1024*64)).Decode(&msg)
+		errType := types.ErrHTTPStatus
+		if resp.StatusCode == http.StatusNotFound {
+			errType = types.ErrNotFound
+		}
+		return nil, fmt.Errorf("docker engine %s %s failed, status %d: %s%.0w", method, path, resp.StatusCode, msg.Message, errType)
+	}
+	return resp, nil
+}
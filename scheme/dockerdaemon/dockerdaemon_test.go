@@ -0,0 +1,261 @@
+package dockerdaemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// testEngine is a minimal Docker Engine API that stores docker save tars by image name.
+type testEngine struct {
+	images map[string][]byte
+	mu     sync.Mutex
+}
+
+func (te *testEngine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	te.mu.Lock()
+	defer te.mu.Unlock()
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/_ping":
+		_, _ = w.Write([]byte("OK"))
+	case req.Method == http.MethodGet && req.URL.Path == "/version":
+		_, _ = w.Write([]byte(`{"Os":"linux","Arch":"amd64"}`))
+	case req.Method == http.MethodGet && req.URL.Path == "/images/get":
+		body, ok := te.images[testEngineName(req.URL.Query().Get("names"))]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"reference does not exist"}`))
+			return
+		}
+		_, _ = w.Write(body)
+	case req.Method == http.MethodGet && req.URL.Path == "/images/json":
+		list := []engineImage{}
+		for name := range te.images {
+			list = append(list, engineImage{RepoTags: []string{name}})
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	case req.Method == http.MethodPost && req.URL.Path == "/images/load":
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		dtmList := []dockerTarManifest{}
+		tr := tar.NewReader(bytes.NewReader(body))
+		for {
+			th, err := tr.Next()
+			if err != nil {
+				break
+			}
+			if th.Name == dockerManifestFilename {
+				_ = json.NewDecoder(tr).Decode(&dtmList)
+			}
+		}
+		if len(dtmList) == 0 {
+			_, _ = w.Write([]byte(`{"error":"manifest.json not found"}`))
+			return
+		}
+		for _, dtm := range dtmList {
+			for _, rt := range dtm.RepoTags {
+				te.images[testEngineName(rt)] = body
+			}
+		}
+		_, _ = w.Write([]byte(`{"stream":"Loaded image"}`))
+	case req.Method == http.MethodDelete && strings.HasPrefix(req.URL.Path, "/images/"):
+		name := testEngineName(strings.TrimPrefix(req.URL.Path, "/images/"))
+		if _, ok := te.images[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"no such image"}`))
+			return
+		}
+		delete(te.images, name)
+		_, _ = w.Write([]byte(`[]`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (te *testEngine) has(name string) bool {
+	te.mu.Lock()
+	defer te.mu.Unlock()
+	_, ok := te.images[testEngineName(name)]
+	return ok
+}
+
+func testEngineName(name string) string {
+	r, err := ref.New(name)
+	if err != nil {
+		return name
+	}
+	return r.CommonName()
+}
+
+// testSaveTar generates a docker save tar with a single uncompressed layer.
+func testSaveTar(t *testing.T, name string, config []byte) []byte {
+	t.Helper()
+	layerBuf := &bytes.Buffer{}
+	ltw := tar.NewWriter(layerBuf)
+	content := []byte("hello world\n")
+	if err := ltw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "hello.txt", Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatalf("failed to write layer header: %v", err)
+	}
+	if _, err := ltw.Write(content); err != nil {
+		t.Fatalf("failed to write layer: %v", err)
+	}
+	if err := ltw.Close(); err != nil {
+		t.Fatalf("failed to close layer: %v", err)
+	}
+	files := []struct {
+		name string
+		body []byte
+	}{
+		{name: "abc123/layer.tar", body: layerBuf.Bytes()},
+		{name: "abc123.json", body: config},
+	}
+	mj, err := json.Marshal([]dockerTarManifest{{Config: "abc123.json", RepoTags: []string{name}, Layers: []string{"abc123/layer.tar"}}})
+	if err != nil {
+		t.Fatalf("failed to marshal manifest.json: %v", err)
+	}
+	files = append(files, struct {
+		name string
+		body []byte
+	}{name: dockerManifestFilename, body: mj})
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: f.name, Mode: 0644, Size: int64(len(f.body))}); err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+		if _, err := tw.Write(f.body); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDockerDaemon(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config := []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":[]}}`)
+	te := &testEngine{images: map[string][]byte{}}
+	te.images[testEngineName("myimg:dev")] = testSaveTar(t, "myimg:dev", config)
+	ts := httptest.NewServer(te)
+	t.Cleanup(ts.Close)
+
+	d := New(WithHost(ts.URL))
+	r, err := ref.New("docker-daemon://myimg:dev")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rCopy := r.SetTag("copy")
+
+	t.Run("ping", func(t *testing.T) {
+		_, err := d.Ping(ctx, r)
+		if err != nil {
+			t.Errorf("ping failed: %v", err)
+		}
+	})
+
+	var m manifest.Manifest
+	t.Run("get", func(t *testing.T) {
+		m, err = d.ManifestGet(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		if m.GetDescriptor().MediaType != types.MediaTypeOCI1Manifest {
+			t.Errorf("unexpected media type: %s", m.GetDescriptor().MediaType)
+		}
+		mi, ok := m.(manifest.Imager)
+		if !ok {
+			t.Fatalf("manifest is not an image")
+		}
+		cd, err := mi.GetConfig()
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		if cd.Digest != digest.FromBytes(config) {
+			t.Errorf("config digest mismatch, expected %s, received %s", digest.FromBytes(config), cd.Digest)
+		}
+		layers, err := mi.GetLayers()
+		if err != nil || len(layers) != 1 || layers[0].MediaType != types.MediaTypeOCI1LayerGzip {
+			t.Fatalf("unexpected layers: %v, %v", layers, err)
+		}
+		br, err := d.BlobGet(ctx, r, layers[0])
+		if err != nil {
+			t.Fatalf("failed to get layer: %v", err)
+		}
+		_, err = io.ReadAll(br)
+		_ = br.Close()
+		if err != nil {
+			t.Errorf("failed to read layer: %v", err)
+		}
+	})
+
+	t.Run("tag list", func(t *testing.T) {
+		tl, err := d.TagList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		tags, err := tl.GetTags()
+		if err != nil || len(tags) != 1 || tags[0] != "dev" {
+			t.Errorf("unexpected tags: %v, %v", tags, err)
+		}
+	})
+
+	t.Run("put", func(t *testing.T) {
+		if m == nil {
+			t.Skip("manifest get failed")
+		}
+		_, err := d.ManifestHead(ctx, rCopy)
+		if !errors.Is(err, types.ErrNotFound) {
+			t.Errorf("missing tag did not return not found: %v", err)
+		}
+		err = d.ManifestPut(ctx, rCopy, m)
+		if err != nil {
+			t.Fatalf("failed to put manifest: %v", err)
+		}
+		err = d.Close(ctx, rCopy)
+		if err != nil {
+			t.Fatalf("failed to load image: %v", err)
+		}
+		if !te.has("myimg:copy") {
+			t.Fatalf("image not loaded into engine")
+		}
+		// a new instance exports the loaded image with the same manifest
+		d2 := New(WithHost(ts.URL))
+		m2, err := d2.ManifestGet(ctx, rCopy)
+		if err != nil {
+			t.Fatalf("failed to get loaded manifest: %v", err)
+		}
+		if m2.GetDescriptor().Digest != m.GetDescriptor().Digest {
+			t.Errorf("loaded manifest changed, expected %s, received %s", m.GetDescriptor().Digest, m2.GetDescriptor().Digest)
+		}
+	})
+
+	t.Run("tag delete", func(t *testing.T) {
+		err := d.TagDelete(ctx, rCopy)
+		if err != nil {
+			t.Fatalf("failed to delete tag: %v", err)
+		}
+		if te.has("myimg:copy") {
+			t.Errorf("tag not deleted from engine")
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package dockerdaemon
+
+import (
+	"context"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// ManifestDelete is not supported, use TagDelete to remove an image from the engine
+func (d *DockerDaemon) ManifestDelete(ctx context.Context, r ref.Ref, opts ...scheme.ManifestOpts) error {
+	return types.ErrUnsupported
+}
+
+// ManifestGet retrieves a manifest, exporting the image from the engine on first access
+func (d *DockerDaemon) ManifestGet(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	l, err := d.layoutLoad(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return l.dir.ManifestGet(ctx, dirRef(r))
+}
+
+// ManifestHead gets metadata about the manifest (existence, digest, mediatype, size)
+func (d *DockerDaemon) ManifestHead(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	l, err := d.layoutLoad(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return l.dir.ManifestHead(ctx, dirRef(r))
+}
+
+// ManifestPut sends a manifest to the repository, tagged manifests are loaded into the engine on close
+func (d *DockerDaemon) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest, opts ...scheme.ManifestOpts) error {
+	l := d.layoutGet(r)
+	err := l.dir.ManifestPut(ctx, dirRef(r), m, opts...)
+	if err != nil {
+		return err
+	}
+	if r.Tag != "" {
+		l.mu.Lock()
+		l.tags[r.Tag] = true
+		l.mu.Unlock()
+	}
+	return nil
+}
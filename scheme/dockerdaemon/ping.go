@@ -0,0 +1,21 @@
+package dockerdaemon
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/regclient/regclient/types/ping"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Ping verifies access to the engine API
+func (d *DockerDaemon) Ping(ctx context.Context, r ref.Ref) (ping.Result, error) {
+	ret := ping.Result{}
+	resp, err := d.engineReq(ctx, http.MethodGet, "/_ping", nil, nil)
+	if err != nil {
+		return ret, err
+	}
+	defer resp.Body.Close()
+	ret.Header = resp.Header
+	return ret, nil
+}
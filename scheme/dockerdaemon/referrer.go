@@ -0,0 +1,21 @@
+package dockerdaemon
+
+import (
+	"context"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
+)
+
+// ReferrerList returns a list of referrers to a given reference.
+// The engine does not store referrers, so only referrers pushed before the ref is closed are returned.
+func (d *DockerDaemon) ReferrerList(ctx context.Context, r ref.Ref, opts ...scheme.ReferrerOpts) (referrer.ReferrerList, error) {
+	l, err := d.layoutLoad(ctx, r)
+	if err != nil {
+		return referrer.ReferrerList{}, err
+	}
+	rl, err := l.dir.ReferrerList(ctx, dirRef(r), opts...)
+	rl.Subject = r
+	return rl, err
+}
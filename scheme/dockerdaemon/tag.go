@@ -0,0 +1,82 @@
+package dockerdaemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
+)
+
+// engineImage is an entry from the engine image list.
+type engineImage struct {
+	RepoTags []string `json:"RepoTags"`
+}
+
+// TagDelete removes a tag from the engine, the image is removed when no other tags remain
+func (d *DockerDaemon) TagDelete(ctx context.Context, r ref.Ref) error {
+	if r.Tag == "" {
+		return types.ErrMissingTag
+	}
+	l := d.layoutGet(r)
+	l.mu.Lock()
+	delete(l.tags, r.Tag)
+	delete(l.loaded, engineName(r))
+	l.mu.Unlock()
+	_ = l.dir.TagDelete(ctx, dirRef(r))
+	resp, err := d.engineReq(ctx, http.MethodDelete, "/images/"+engineName(r), nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// TagList returns a list of tags for the repository from the engine
+func (d *DockerDaemon) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error) {
+	rRepo := r.ToReg()
+	rRepo.Tag = ""
+	rRepo.Digest = ""
+	filters, err := json.Marshal(map[string][]string{"reference": {rRepo.CommonName()}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.engineReq(ctx, http.MethodGet, "/images/json", url.Values{"filters": []string{string(filters)}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	images := []engineImage{}
+	err = json.NewDecoder(resp.Body).Decode(&images)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image list: %w", err)
+	}
+	tl := []string{}
+	seen := map[string]bool{}
+	for _, image := range images {
+		for _, rt := range image.RepoTags {
+			rCur, err := ref.New(rt)
+			if err != nil || rCur.Tag == "" || seen[rCur.Tag] || !ref.EqualRepository(rCur, rRepo) {
+				continue
+			}
+			seen[rCur.Tag] = true
+			tl = append(tl, rCur.Tag)
+		}
+	}
+	sort.Strings(tl)
+	raw, err := json.Marshal(tag.DockerList{Name: rRepo.Repository, Tags: tl})
+	if err != nil {
+		return nil, err
+	}
+	return tag.New(
+		tag.WithRaw(raw),
+		tag.WithRef(r),
+		tag.WithMT("application/json"),
+		tag.WithHeaders(resp.Header),
+	)
+}
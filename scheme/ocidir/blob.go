@@ -12,7 +12,6 @@ import (
 	_ "crypto/sha256"
 	_ "crypto/sha512"
 
-	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 
 	"github.com/regclient/regclient/internal/rwfs"
@@ -53,6 +52,40 @@ func (o *OCIDir) BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor) (bl
 	return br, nil
 }
 
+// BlobGetRange retrieves part of a blob.
+// A negative length reads to the end of the blob.
+func (o *OCIDir) BlobGetRange(ctx context.Context, r ref.Ref, d types.Descriptor, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid blob range offset %d", offset)
+	}
+	file := path.Join(r.Path, "blobs", d.Digest.Algorithm().String(), d.Digest.Encoded())
+	fd, err := o.fs.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		if seeker, ok := fd.(io.Seeker); ok {
+			_, err = seeker.Seek(offset, io.SeekStart)
+		} else {
+			_, err = io.CopyN(io.Discard, fd, offset)
+		}
+		if err != nil {
+			_ = fd.Close()
+			return nil, fmt.Errorf("failed to seek to offset %d in %s: %w", offset, file, err)
+		}
+	}
+	if length < 0 {
+		return fd, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.LimitReader(fd, length),
+		Closer: fd,
+	}, nil
+}
+
 // BlobHead verifies the existence of a blob, the reader contains the headers but no body to read
 func (o *OCIDir) BlobHead(ctx context.Context, r ref.Ref, d types.Descriptor) (blob.Reader, error) {
 	file := path.Join(r.Path, "blobs", d.Digest.Algorithm().String(), d.Digest.Encoded())
@@ -93,7 +126,14 @@ func (o *OCIDir) BlobPut(ctx context.Context, r ref.Ref, d types.Descriptor, rdr
 	if err != nil {
 		return d, err
 	}
-	digester := digest.Canonical.Digester()
+	alg := o.digestAlg
+	if d.Digest != "" {
+		if err := d.Digest.Validate(); err != nil {
+			return d, err
+		}
+		alg = d.Digest.Algorithm()
+	}
+	digester := alg.Digester()
 	rdr = io.TeeReader(rdr, digester.Hash())
 	// write the blob to a tmp file
 	var dir, tmpPattern string
@@ -101,7 +141,7 @@ func (o *OCIDir) BlobPut(ctx context.Context, r ref.Ref, d types.Descriptor, rdr
 		dir = path.Join(r.Path, "blobs", d.Digest.Algorithm().String())
 		tmpPattern = d.Digest.Encoded() + ".*.tmp"
 	} else {
-		dir = path.Join(r.Path, "blobs", digest.Canonical.String())
+		dir = path.Join(r.Path, "blobs", alg.String())
 		tmpPattern = "*.tmp"
 	}
 	err = rwfs.MkdirAll(o.fs, dir, 0777)
@@ -2,12 +2,17 @@ package ocidir
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
+	"os"
 	"path"
+	"sort"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -25,30 +30,136 @@ func (o *OCIDir) Close(ctx context.Context, r ref.Ref) error {
 		return nil
 	}
 
-	// perform GC
+	_, err := o.gcRun(ctx, r, false)
+	if err != nil {
+		return err
+	}
+	delete(o.modRefs, r.Path)
+	return nil
+}
+
+// GCResult summarizes the blobs removed by [OCIDir.GC].
+// With a dry-run, it includes the blobs that would be removed.
+type GCResult struct {
+	DryRun  bool      `json:"dryRun"`
+	Removed []string  `json:"removed"`
+	Size    int64     `json:"size"`
+	Dedupe  *GCDedupe `json:"dedupe,omitempty"`
+}
+
+// GCDedupe reports blobs with the same digest in multiple layouts under a common root.
+// Size is the disk space used by the duplicate copies, which is saved when they are linked.
+type GCDedupe struct {
+	Root    string         `json:"root"`
+	Layouts []string       `json:"layouts"`
+	Blobs   []GCDedupeBlob `json:"blobs"`
+	Linked  bool           `json:"linked"`
+	Size    int64          `json:"size"`
+}
+
+// GCDedupeBlob is a blob found in multiple layouts.
+// The first path is the blob that other paths are linked to.
+type GCDedupeBlob struct {
+	Digest string   `json:"digest"`
+	Size   int64    `json:"size"`
+	Paths  []string `json:"paths"`
+}
+
+type gcConf struct {
+	dryRun      bool
+	dedupeIsSet bool
+	dedupeLink  bool
+	dedupeRoot  string
+}
+
+// GCOpts are used for passing options to [OCIDir.GC].
+type GCOpts func(*gcConf)
+
+// GCWithDryRun reports the blobs that would be removed without deleting them.
+func GCWithDryRun() GCOpts {
+	return func(c *gcConf) {
+		c.dryRun = true
+	}
+}
+
+// GCWithDedupe reports blobs that are duplicated across every OCI Layout found under the root directory.
+// The root is a path in the same filesystem as the layout, and normally contains the layout being collected.
+func GCWithDedupe(root string) GCOpts {
+	return func(c *gcConf) {
+		c.dedupeRoot = root
+		c.dedupeIsSet = true
+	}
+}
+
+// GCWithDedupeLink replaces duplicate blobs found with [GCWithDedupe] with hard links to a single copy.
+// The filesystem must support hard links, and the layouts must be on the same device.
+// This is skipped with a dry-run.
+func GCWithDedupeLink() GCOpts {
+	return func(c *gcConf) {
+		c.dedupeLink = true
+	}
+}
+
+// GC removes blobs from the layout that are not reachable from the index.json.
+// Unlike Close, this runs even when the layout has not been modified or GC is disabled.
+// An error is returned when a GC lock is held on the layout.
+func (o *OCIDir) GC(ctx context.Context, r ref.Ref, opts ...GCOpts) (GCResult, error) {
+	conf := gcConf{}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if gc, ok := o.modRefs[r.Path]; ok && gc.locks > 0 {
+		return GCResult{}, fmt.Errorf("gc is locked on %s%.0w", r.Path, types.ErrUnavailable)
+	}
+	result, err := o.gcRun(ctx, r, conf.dryRun)
+	if err != nil {
+		return result, err
+	}
+	if !conf.dryRun {
+		delete(o.modRefs, r.Path)
+	}
+	if conf.dedupeIsSet {
+		dedupe, err := o.gcDedupe(ctx, conf.dedupeRoot, conf.dedupeLink && !conf.dryRun)
+		result.Dedupe = &dedupe
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// gcRun performs the garbage collection, the mutex must be held by the caller.
+func (o *OCIDir) gcRun(ctx context.Context, r ref.Ref, dryRun bool) (GCResult, error) {
+	result := GCResult{
+		DryRun:  dryRun,
+		Removed: []string{},
+	}
 	o.log.WithFields(logrus.Fields{
-		"ref": r.CommonName(),
+		"ref":    r.CommonName(),
+		"dryRun": dryRun,
 	}).Debug("running GC")
 	dl := map[string]bool{}
 	// recurse through index, manifests, and blob lists, generating a digest list
 	index, err := o.readIndex(r, true)
 	if err != nil {
-		return err
+		return result, err
 	}
 	im, err := manifest.New(manifest.WithOrig(index))
 	if err != nil {
-		return err
+		return result, err
 	}
 	err = o.closeProcManifest(ctx, r, im, &dl)
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	// go through filesystem digest list, removing entries not seen in recursive pass
 	blobsPath := path.Join(r.Path, "blobs")
 	blobDirs, err := fs.ReadDir(o.fs, blobsPath)
 	if err != nil {
-		return err
+		return result, err
 	}
 	for _, blobDir := range blobDirs {
 		if !blobDir.IsDir() {
@@ -57,24 +168,162 @@ func (o *OCIDir) Close(ctx context.Context, r ref.Ref) error {
 		}
 		digestFiles, err := fs.ReadDir(o.fs, path.Join(blobsPath, blobDir.Name()))
 		if err != nil {
-			return err
+			return result, err
 		}
 		for _, digestFile := range digestFiles {
 			digest := fmt.Sprintf("%s:%s", blobDir.Name(), digestFile.Name())
-			if !dl[digest] {
-				o.log.WithFields(logrus.Fields{
-					"digest": digest,
-				}).Debug("ocidir garbage collect")
-				// delete
-				err = o.fs.Remove(path.Join(blobsPath, blobDir.Name(), digestFile.Name()))
-				if err != nil {
-					return fmt.Errorf("failed to delete %s: %w", path.Join(blobsPath, blobDir.Name(), digestFile.Name()), err)
+			if dl[digest] {
+				continue
+			}
+			o.log.WithFields(logrus.Fields{
+				"digest": digest,
+				"dryRun": dryRun,
+			}).Debug("ocidir garbage collect")
+			result.Removed = append(result.Removed, digest)
+			if fi, err := digestFile.Info(); err == nil {
+				result.Size += fi.Size()
+			}
+			if dryRun {
+				continue
+			}
+			// delete
+			err = o.fs.Remove(path.Join(blobsPath, blobDir.Name(), digestFile.Name()))
+			if err != nil {
+				return result, fmt.Errorf("failed to delete %s: %w", path.Join(blobsPath, blobDir.Name(), digestFile.Name()), err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// gcDedupe finds blobs duplicated across layouts under root, optionally replacing them with hard links.
+func (o *OCIDir) gcDedupe(ctx context.Context, root string, link bool) (GCDedupe, error) {
+	result := GCDedupe{
+		Root:    root,
+		Layouts: []string{},
+		Blobs:   []GCDedupeBlob{},
+	}
+	if root == "" {
+		root = "."
+	}
+	var lfs rwfs.LinkFS
+	if link {
+		var ok bool
+		lfs, ok = o.fs.(rwfs.LinkFS)
+		if !ok {
+			return result, fmt.Errorf("filesystem does not support hard links%.0w", types.ErrNotImplemented)
+		}
+	}
+	o.log.WithFields(logrus.Fields{
+		"root": root,
+		"link": link,
+	}).Debug("running GC dedupe")
+	// find each layout and the blobs it contains, sorted for a consistent order
+	blobPaths := map[string][]string{}
+	digests := []string{}
+	err := fs.WalkDir(o.fs, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := rwfs.Stat(o.fs, path.Join(p, imageLayoutFile)); err != nil {
+			return nil
+		}
+		result.Layouts = append(result.Layouts, p)
+		blobsPath := path.Join(p, "blobs")
+		blobDirs, err := fs.ReadDir(o.fs, blobsPath)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		for _, blobDir := range blobDirs {
+			if !blobDir.IsDir() {
+				continue
+			}
+			digestFiles, err := fs.ReadDir(o.fs, path.Join(blobsPath, blobDir.Name()))
+			if err != nil {
+				return err
+			}
+			for _, digestFile := range digestFiles {
+				if digestFile.IsDir() {
+					continue
 				}
+				dig := fmt.Sprintf("%s:%s", blobDir.Name(), digestFile.Name())
+				if _, ok := blobPaths[dig]; !ok {
+					digests = append(digests, dig)
+				}
+				blobPaths[dig] = append(blobPaths[dig], path.Join(blobsPath, blobDir.Name(), digestFile.Name()))
 			}
 		}
+		// nested layouts are not searched
+		return fs.SkipDir
+	})
+	if err != nil {
+		return result, err
 	}
-	delete(o.modRefs, r.Path)
-	return nil
+	sort.Strings(digests)
+	for _, dig := range digests {
+		paths := blobPaths[dig]
+		if len(paths) < 2 {
+			continue
+		}
+		fiSrc, err := rwfs.Stat(o.fs, paths[0])
+		if err != nil {
+			return result, err
+		}
+		blob := GCDedupeBlob{
+			Digest: dig,
+			Size:   fiSrc.Size(),
+			Paths:  []string{paths[0]},
+		}
+		for _, p := range paths[1:] {
+			fi, err := rwfs.Stat(o.fs, p)
+			if err != nil {
+				return result, err
+			}
+			if os.SameFile(fiSrc, fi) {
+				// already linked
+				continue
+			}
+			if fi.Size() != fiSrc.Size() {
+				// a partial or corrupt blob should not be replaced
+				o.log.WithFields(logrus.Fields{
+					"digest": dig,
+					"path":   p,
+				}).Warn("blob size mismatch, skipping dedupe")
+				continue
+			}
+			blob.Paths = append(blob.Paths, p)
+			result.Size += fi.Size()
+			if lfs == nil {
+				continue
+			}
+			// link to a temporary name and rename over the duplicate to avoid a missing blob
+			tmpName := p + ".link"
+			_ = o.fs.Remove(tmpName)
+			err = lfs.Link(paths[0], tmpName)
+			if err != nil {
+				return result, fmt.Errorf("failed to link %s: %w", p, err)
+			}
+			err = o.fs.Rename(tmpName, p)
+			if err != nil {
+				_ = o.fs.Remove(tmpName)
+				return result, fmt.Errorf("failed to replace %s: %w", p, err)
+			}
+		}
+		if len(blob.Paths) > 1 {
+			result.Blobs = append(result.Blobs, blob)
+		}
+	}
+	result.Linked = link
+	return result, nil
 }
 
 func (o *OCIDir) closeProcManifest(ctx context.Context, r ref.Ref, m manifest.Manifest, dl *map[string]bool) error {
@@ -2,12 +2,15 @@ package ocidir
 
 import (
 	"context"
+	"errors"
+	"os"
 	"path"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
 
 	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -84,3 +87,172 @@ func TestClose(t *testing.T) {
 		}
 	}
 }
+
+func TestGC(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.MkdirAll(fsMem, "testdata/regctl", 0777)
+	if err != nil {
+		t.Fatalf("failed to setup memfs dir: %v", err)
+	}
+	err = rwfs.CopyRecursive(fsOS, "testdata/regctl", fsMem, "testdata/regctl")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	// disable gc on close to verify GC runs independently
+	oMem := New(WithFS(fsMem), WithGC(false))
+	tRef := "ocidir://testdata/regctl"
+	r, err := ref.New(tRef)
+	if err != nil {
+		t.Fatalf("failed to parse ref %s: %v", tRef, err)
+	}
+	rmDig := "sha256:e57d957b974fb4d852aee59b9b2e9dcd7cb0f04622e9356324864a270afd18a0" // armv6
+	rmBlob := digest.Digest("sha256:7bb8aa6d91c4638208c4f0824b3482dc443f43fb72cad6b077fda0d1fc50f866")
+	rmFile := path.Join("testdata/regctl/blobs", rmBlob.Algorithm().String(), rmBlob.Encoded())
+	err = oMem.ManifestDelete(ctx, r.SetDigest(rmDig))
+	if err != nil {
+		t.Fatalf("failed to delete %s: %v", rmDig, err)
+	}
+	oMem.Close(ctx, r)
+	if _, err := rwfs.Stat(fsMem, rmFile); err != nil {
+		t.Fatalf("blob removed by close with gc disabled: %s", rmFile)
+	}
+
+	// dry-run reports without deleting
+	result, err := oMem.GC(ctx, r, GCWithDryRun())
+	if err != nil {
+		t.Fatalf("failed to run gc dry-run: %v", err)
+	}
+	if !result.DryRun || result.Size <= 0 {
+		t.Errorf("unexpected dry-run result: %v", result)
+	}
+	found := false
+	for _, d := range result.Removed {
+		if d == rmBlob.String() {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("dry-run did not report %s: %v", rmBlob, result.Removed)
+	}
+	if _, err := rwfs.Stat(fsMem, rmFile); err != nil {
+		t.Errorf("blob removed by dry-run: %s", rmFile)
+	}
+
+	// locked refs are not collected
+	oMem.GCLock(r)
+	_, err = oMem.GC(ctx, r)
+	if err == nil {
+		t.Errorf("gc did not fail with a lock")
+	}
+	oMem.GCUnlock(r)
+
+	// gc removes the blobs
+	resultGC, err := oMem.GC(ctx, r)
+	if err != nil {
+		t.Fatalf("failed to run gc: %v", err)
+	}
+	if resultGC.DryRun || len(resultGC.Removed) != len(result.Removed) || resultGC.Size != result.Size {
+		t.Errorf("gc result does not match dry-run, expected %v, received %v", result, resultGC)
+	}
+	if _, err := rwfs.Stat(fsMem, rmFile); err == nil {
+		t.Errorf("blob not removed by gc: %s", rmFile)
+	}
+}
+
+func TestGCDedupe(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsTmp := rwfs.OSNew(t.TempDir())
+	for _, dir := range []string{"layouts/a", "layouts/b"} {
+		err := rwfs.MkdirAll(fsTmp, dir, 0777)
+		if err != nil {
+			t.Fatalf("failed to setup dir: %v", err)
+		}
+		err = rwfs.CopyRecursive(fsOS, "testdata/regctl", fsTmp, dir)
+		if err != nil {
+			t.Fatalf("failed to setup copy: %v", err)
+		}
+	}
+	o := New(WithFS(fsTmp), WithGC(false))
+	r, err := ref.New("ocidir://layouts/a")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+
+	// dry-run reports duplicates without linking
+	result, err := o.GC(ctx, r, GCWithDryRun(), GCWithDedupe("layouts"), GCWithDedupeLink())
+	if err != nil {
+		t.Fatalf("failed to run gc dedupe dry-run: %v", err)
+	}
+	if result.Dedupe == nil {
+		t.Fatalf("dedupe result missing")
+	}
+	if len(result.Dedupe.Layouts) != 2 || len(result.Dedupe.Blobs) == 0 || result.Dedupe.Size <= 0 || result.Dedupe.Linked {
+		t.Errorf("unexpected dedupe result: %v", result.Dedupe)
+	}
+	for _, b := range result.Dedupe.Blobs {
+		if len(b.Paths) != 2 {
+			t.Errorf("unexpected paths for %s: %v", b.Digest, b.Paths)
+		}
+	}
+
+	// link the duplicates
+	resultLink, err := o.GC(ctx, r, GCWithDedupe("layouts"), GCWithDedupeLink())
+	if err != nil {
+		t.Fatalf("failed to run gc dedupe link: %v", err)
+	}
+	if !resultLink.Dedupe.Linked || len(resultLink.Dedupe.Blobs) == 0 {
+		t.Errorf("unexpected dedupe link result: %v", resultLink.Dedupe)
+	}
+	b := resultLink.Dedupe.Blobs[0]
+	fiA, err := rwfs.Stat(fsTmp, b.Paths[0])
+	if err != nil {
+		t.Fatalf("failed to stat %s: %v", b.Paths[0], err)
+	}
+	fiB, err := rwfs.Stat(fsTmp, b.Paths[1])
+	if err != nil {
+		t.Fatalf("failed to stat %s: %v", b.Paths[1], err)
+	}
+	if !os.SameFile(fiA, fiB) {
+		t.Errorf("blobs were not linked: %v", b.Paths)
+	}
+	rb, err := ref.New("ocidir://layouts/b")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	_, err = o.ManifestGet(ctx, rb)
+	if err != nil {
+		t.Errorf("failed to get manifest after linking: %v", err)
+	}
+
+	// linked blobs are no longer reported
+	resultAfter, err := o.GC(ctx, r, GCWithDedupe("layouts"))
+	if err != nil {
+		t.Fatalf("failed to run gc dedupe: %v", err)
+	}
+	if len(resultAfter.Dedupe.Blobs) != 0 || resultAfter.Dedupe.Size != 0 {
+		t.Errorf("linked blobs reported as duplicates: %v", resultAfter.Dedupe)
+	}
+
+	// memfs does not support links
+	fsMem := rwfs.MemNew()
+	err = rwfs.MkdirAll(fsMem, "layouts/a", 0777)
+	if err != nil {
+		t.Fatalf("failed to setup memfs dir: %v", err)
+	}
+	err = rwfs.CopyRecursive(fsOS, "testdata/regctl", fsMem, "layouts/a")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	resultMem, err := New(WithFS(fsMem), WithGC(false)).GC(ctx, r, GCWithDedupe("layouts"), GCWithDedupeLink())
+	if !errors.Is(err, types.ErrNotImplemented) {
+		t.Errorf("link did not fail without filesystem support: %v", err)
+	}
+	if resultMem.Dedupe == nil {
+		t.Errorf("dedupe result missing on error")
+	}
+}
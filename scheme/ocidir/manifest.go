@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"io/fs"
 	"path"
 
@@ -123,22 +122,19 @@ func (o *OCIDir) manifestGet(ctx context.Context, r ref.Ref) (manifest.Manifest,
 		return nil, fmt.Errorf("failed to open manifest: %w", err)
 	}
 	defer fd.Close()
-	mb, err := io.ReadAll(fd)
+	m, err := manifest.New(
+		manifest.WithRef(r),
+		manifest.WithDesc(desc),
+		manifest.WithReader(fd),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read manifest: %w", err)
 	}
-	if desc.Size == 0 {
-		desc.Size = int64(len(mb))
-	}
 	o.log.WithFields(logrus.Fields{
 		"ref":  r.CommonName(),
 		"file": file,
 	}).Debug("retrieved manifest")
-	return manifest.New(
-		manifest.WithRef(r),
-		manifest.WithDesc(desc),
-		manifest.WithRaw(mb),
-	)
+	return m, nil
 }
 
 // ManifestHead gets metadata about the manifest (existence, digest, mediatype, size)
@@ -198,6 +194,28 @@ func (o *OCIDir) ManifestHead(ctx context.Context, r ref.Ref) (manifest.Manifest
 	)
 }
 
+// RepoManifests returns the descriptor of each manifest in the index.json, including untagged manifests.
+// Each digest is only returned once, and the descriptors do not include the tag annotation.
+func (o *OCIDir) RepoManifests(ctx context.Context, r ref.Ref) ([]types.Descriptor, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	index, err := o.readIndex(r, true)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[digest.Digest]bool{}
+	dl := []types.Descriptor{}
+	for _, d := range index.Manifests {
+		if seen[d.Digest] {
+			continue
+		}
+		seen[d.Digest] = true
+		d.Annotations = nil
+		dl = append(dl, d)
+	}
+	return dl, nil
+}
+
 // ManifestPut sends a manifest to the repository
 func (o *OCIDir) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest, opts ...scheme.ManifestOpts) error {
 	o.mu.Lock()
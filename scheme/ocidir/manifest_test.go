@@ -226,3 +226,43 @@ func TestManifest(t *testing.T) {
 		t.Errorf("could not query manifest after pushing dup tag")
 	}
 }
+
+func TestRepoManifests(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "../../testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	o := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	index, err := o.readIndex(r, false)
+	if err != nil {
+		t.Fatalf("failed to read index: %v", err)
+	}
+	expect := map[digest.Digest]bool{}
+	for _, d := range index.Manifests {
+		expect[d.Digest] = true
+	}
+	dl, err := o.RepoManifests(ctx, r)
+	if err != nil {
+		t.Fatalf("failed to list manifests: %v", err)
+	}
+	if len(dl) != len(expect) {
+		t.Errorf("unexpected number of manifests, expected %d, received %d", len(expect), len(dl))
+	}
+	for _, d := range dl {
+		if !expect[d.Digest] {
+			t.Errorf("unexpected or duplicate manifest %s", d.Digest.String())
+		}
+		delete(expect, d.Digest)
+		if len(d.Annotations) > 0 {
+			t.Errorf("annotations included on %s: %v", d.Digest.String(), d.Annotations)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 
 	"github.com/regclient/regclient/internal/rwfs"
@@ -31,6 +32,7 @@ const (
 type OCIDir struct {
 	fs          rwfs.RWFS
 	log         *logrus.Logger
+	digestAlg   digest.Algorithm
 	gc          bool
 	modRefs     map[string]*ociGC
 	throttle    map[string]*throttle.Throttle
@@ -44,10 +46,11 @@ type ociGC struct {
 }
 
 type ociConf struct {
-	fs       rwfs.RWFS
-	gc       bool
-	log      *logrus.Logger
-	throttle int
+	fs        rwfs.RWFS
+	gc        bool
+	log       *logrus.Logger
+	throttle  int
+	digestAlg digest.Algorithm
 }
 
 // Opts are used for passing options to ocidir
@@ -63,9 +66,13 @@ func New(opts ...Opts) *OCIDir {
 	for _, opt := range opts {
 		opt(&conf)
 	}
+	if conf.digestAlg == "" || !conf.digestAlg.Available() {
+		conf.digestAlg = digest.Canonical
+	}
 	return &OCIDir{
 		fs:          conf.fs,
 		log:         conf.log,
+		digestAlg:   conf.digestAlg,
 		gc:          conf.gc,
 		modRefs:     map[string]*ociGC{},
 		throttle:    map[string]*throttle.Throttle{},
@@ -73,6 +80,13 @@ func New(opts ...Opts) *OCIDir {
 	}
 }
 
+// WithDigestAlgorithm sets the algorithm for blobs pushed without a digest, the default is sha256
+func WithDigestAlgorithm(alg digest.Algorithm) Opts {
+	return func(c *ociConf) {
+		c.digestAlg = alg
+	}
+}
+
 // WithFS allows the rwfs to be replaced
 // The default is to use the OS, this can be used to sandbox within a folder
 // This can also be used to pass an in-memory filesystem for testing or special use cases
@@ -81,7 +81,7 @@ func (o *OCIDir) referrerList(ctx context.Context, r ref.Ref, opts ...scheme.Ref
 	}
 	ociML, ok := m.GetOrig().(v1.Index)
 	if !ok {
-		return rl, fmt.Errorf("manifest is not an OCI index: %s", rlTag.CommonName())
+		return rl, fmt.Errorf("manifest is not an OCI index: %s%.0w", rlTag.CommonName(), types.ErrUnsupportedMediaType)
 	}
 	// update referrer list
 	rl.Manifest = m
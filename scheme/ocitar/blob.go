@@ -0,0 +1,52 @@
+package ocitar
+
+import (
+	"context"
+	"io"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// BlobDelete is not implemented, unreferenced blobs are removed by the garbage collection run when the tar file is written.
+func (o *OCITar) BlobDelete(ctx context.Context, r ref.Ref, d types.Descriptor) error {
+	return types.ErrNotImplemented
+}
+
+// BlobGet retrieves a blob, returning a reader
+func (o *OCITar) BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor) (blob.Reader, error) {
+	l, err := o.layoutGet(r, false)
+	if err != nil {
+		return nil, err
+	}
+	return l.dir.BlobGet(ctx, r, d)
+}
+
+// BlobHead verifies the existence of a blob, the reader contains the headers but no body to read
+func (o *OCITar) BlobHead(ctx context.Context, r ref.Ref, d types.Descriptor) (blob.Reader, error) {
+	l, err := o.layoutGet(r, false)
+	if err != nil {
+		return nil, err
+	}
+	return l.dir.BlobHead(ctx, r, d)
+}
+
+// BlobMount attempts to perform a server side copy of the blob
+func (o *OCITar) BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor) error {
+	return types.ErrUnsupported
+}
+
+// BlobPut sends a blob to the repository, returns the digest and size when successful
+func (o *OCITar) BlobPut(ctx context.Context, r ref.Ref, d types.Descriptor, rdr io.Reader) (types.Descriptor, error) {
+	l, err := o.layoutGet(r, true)
+	if err != nil {
+		return d, err
+	}
+	d, err = l.dir.BlobPut(ctx, r, d, rdr)
+	if err != nil {
+		return d, err
+	}
+	l.layoutMod()
+	return d, nil
+}
@@ -0,0 +1,83 @@
+package ocitar
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Close writes the tar file if the layout has been modified and frees the memory used by the layout.
+// Garbage collection is run on the layout before writing when enabled.
+func (o *OCITar) Close(ctx context.Context, r ref.Ref) error {
+	key := path.Clean(r.Path)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	l, ok := o.layouts[key]
+	if !ok {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks > 0 {
+		// locked, skip write
+		return nil
+	}
+	if l.mod {
+		err := l.dir.Close(ctx, r)
+		if err != nil {
+			return err
+		}
+		err = o.tarWrite(key, l)
+		if err != nil {
+			return err
+		}
+		o.log.WithFields(logrus.Fields{
+			"ref":  r.CommonName(),
+			"file": key,
+		}).Debug("wrote oci tar")
+	}
+	delete(o.layouts, key)
+	return nil
+}
+
+// tarWrite replaces the tar file using a temp file and rename.
+func (o *OCITar) tarWrite(key string, l *layout) error {
+	dir := path.Dir(key)
+	if dir != "." {
+		err := rwfs.MkdirAll(o.fs, dir, 0777)
+		if err != nil {
+			return fmt.Errorf("failed creating %s: %w", dir, err)
+		}
+	}
+	tmpFile, err := rwfs.CreateTemp(o.fs, dir, path.Base(key)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create tmpfile: %w", err)
+	}
+	fi, err := tmpFile.Stat()
+	if err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to stat tmpfile: %w", err)
+	}
+	tmpName := path.Join(dir, fi.Name())
+	err = tarSave(tmpFile, l.mem, l.gzip)
+	errC := tmpFile.Close()
+	if err != nil {
+		_ = o.fs.Remove(tmpName)
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if errC != nil {
+		_ = o.fs.Remove(tmpName)
+		return fmt.Errorf("failed to close %s: %w", key, errC)
+	}
+	err = o.fs.Rename(tmpName, key)
+	if err != nil {
+		return fmt.Errorf("failed to rename tmpfile to %s: %w", key, err)
+	}
+	l.mod = false
+	return nil
+}
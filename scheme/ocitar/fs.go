@@ -0,0 +1,109 @@
+package ocitar
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/regclient/regclient/internal/rwfs"
+)
+
+// prefixFS maps the path of a tar file in a reference to the root of an in memory filesystem.
+// This allows the ocidir scheme to operate on the extracted layout without changing the reference.
+type prefixFS struct {
+	prefix string
+	mem    *rwfs.MemFS
+}
+
+func newPrefixFS(prefix string, mem *rwfs.MemFS) *prefixFS {
+	return &prefixFS{
+		prefix: path.Clean(prefix),
+		mem:    mem,
+	}
+}
+
+func (p *prefixFS) Create(name string) (rwfs.WFile, error) {
+	n, err := p.join("create", name)
+	if err != nil {
+		return nil, err
+	}
+	return p.mem.Create(n)
+}
+
+func (p *prefixFS) Mkdir(name string, perm fs.FileMode) error {
+	n, err := p.join("mkdir", name)
+	if err != nil {
+		return err
+	}
+	if n == "." {
+		return nil
+	}
+	return p.mem.Mkdir(n, perm)
+}
+
+func (p *prefixFS) Open(name string) (fs.File, error) {
+	n, err := p.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return p.mem.Open(n)
+}
+
+func (p *prefixFS) OpenFile(name string, flags int, perm fs.FileMode) (rwfs.RWFile, error) {
+	n, err := p.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return p.mem.OpenFile(n, flags, perm)
+}
+
+func (p *prefixFS) Remove(name string) error {
+	n, err := p.join("remove", name)
+	if err != nil {
+		return err
+	}
+	return p.mem.Remove(n)
+}
+
+func (p *prefixFS) Rename(oldName, newName string) error {
+	o, err := p.join("rename", oldName)
+	if err != nil {
+		return err
+	}
+	n, err := p.join("rename", newName)
+	if err != nil {
+		return err
+	}
+	return p.mem.Rename(o, n)
+}
+
+func (p *prefixFS) Stat(name string) (fs.FileInfo, error) {
+	n, err := p.join("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	if n == "." {
+		// the root of the memfs is the tar file
+		return rwfs.NewFI(path.Base(p.prefix), 4096, time.Time{}, fs.ModeDir), nil
+	}
+	return p.mem.Stat(n)
+}
+
+func (p *prefixFS) join(op, name string) (string, error) {
+	name = path.Clean(name)
+	if name == p.prefix {
+		return ".", nil
+	}
+	if p.prefix == "." && fs.ValidPath(name) {
+		return name, nil
+	}
+	if strings.HasPrefix(name, p.prefix+"/") {
+		return name[len(p.prefix)+1:], nil
+	}
+	return "", &fs.PathError{
+		Op:   op,
+		Path: name,
+		Err:  fs.ErrNotExist,
+	}
+}
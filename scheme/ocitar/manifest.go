@@ -0,0 +1,55 @@
+package ocitar
+
+import (
+	"context"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// ManifestDelete removes a manifest, including all tags that point to that manifest
+func (o *OCITar) ManifestDelete(ctx context.Context, r ref.Ref, opts ...scheme.ManifestOpts) error {
+	l, err := o.layoutGet(r, false)
+	if err != nil {
+		return err
+	}
+	err = l.dir.ManifestDelete(ctx, r, opts...)
+	if err != nil {
+		return err
+	}
+	l.layoutMod()
+	return nil
+}
+
+// ManifestGet retrieves a manifest from a repository
+func (o *OCITar) ManifestGet(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	l, err := o.layoutGet(r, false)
+	if err != nil {
+		return nil, err
+	}
+	return l.dir.ManifestGet(ctx, r)
+}
+
+// ManifestHead gets metadata about the manifest (existence, digest, mediatype, size)
+func (o *OCITar) ManifestHead(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	l, err := o.layoutGet(r, false)
+	if err != nil {
+		return nil, err
+	}
+	return l.dir.ManifestHead(ctx, r)
+}
+
+// ManifestPut sends a manifest to the repository
+func (o *OCITar) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest, opts ...scheme.ManifestOpts) error {
+	l, err := o.layoutGet(r, true)
+	if err != nil {
+		return err
+	}
+	err = l.dir.ManifestPut(ctx, r, m, opts...)
+	if err != nil {
+		return err
+	}
+	l.layoutMod()
+	return nil
+}
@@ -0,0 +1,341 @@
+// Package ocitar implements the OCI Image Layout scheme packed in a tar file, optionally gzip compressed.
+// The tar file is loaded into memory on first access and written back when the reference is closed.
+// Since every blob is held in memory while the reference is open, this is limited to tar files that fit in available memory.
+// Use the ocidir scheme with an extracted layout for larger images.
+package ocitar
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/scheme/ocidir"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	imageLayoutFile = "oci-layout"
+	indexFile       = "index.json"
+)
+
+// OCITar is used for accessing OCI Image Layouts packaged in a tar file.
+type OCITar struct {
+	fs      rwfs.RWFS
+	log     *logrus.Logger
+	gc      bool
+	layouts map[string]*layout
+	mu      sync.Mutex
+}
+
+// layout is a tar file loaded into memory.
+type layout struct {
+	dir   *ocidir.OCIDir
+	mem   *rwfs.MemFS
+	gzip  bool
+	mod   bool
+	locks int
+	mu    sync.Mutex
+}
+
+type config struct {
+	fs  rwfs.RWFS
+	gc  bool
+	log *logrus.Logger
+}
+
+// Opts are used for passing options to ocitar.
+type Opts func(*config)
+
+// New creates a new OCITar with options.
+func New(opts ...Opts) *OCITar {
+	conf := config{
+		log: &logrus.Logger{Out: io.Discard},
+		gc:  true,
+	}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	if conf.fs == nil {
+		conf.fs = rwfs.OSNew("")
+	}
+	return &OCITar{
+		fs:      conf.fs,
+		log:     conf.log,
+		gc:      conf.gc,
+		layouts: map[string]*layout{},
+	}
+}
+
+// WithFS allows the rwfs containing the tar files to be replaced.
+// The default is to use the OS.
+func WithFS(fs rwfs.RWFS) Opts {
+	return func(c *config) {
+		c.fs = fs
+	}
+}
+
+// WithGC configures the garbage collection setting run before writing the tar file.
+// This defaults to enabled.
+func WithGC(gc bool) Opts {
+	return func(c *config) {
+		c.gc = gc
+	}
+}
+
+// WithLog provides a logrus logger.
+// By default logging is disabled.
+func WithLog(log *logrus.Logger) Opts {
+	return func(c *config) {
+		c.log = log
+	}
+}
+
+// GCLock is used to prevent GC and writing the tar file during a put.
+func (o *OCITar) GCLock(r ref.Ref) {
+	l, err := o.layoutGet(r, true)
+	if err != nil {
+		o.log.WithFields(logrus.Fields{
+			"ref": r.CommonName(),
+			"err": err,
+		}).Warn("failed to load tar for lock")
+		return
+	}
+	l.mu.Lock()
+	l.locks++
+	l.mu.Unlock()
+	l.dir.GCLock(r)
+}
+
+// GCUnlock removes a hold on GC of a ref, this must be done before the ref is closed.
+func (o *OCITar) GCUnlock(r ref.Ref) {
+	o.mu.Lock()
+	l, ok := o.layouts[path.Clean(r.Path)]
+	o.mu.Unlock()
+	if !ok {
+		return
+	}
+	l.mu.Lock()
+	if l.locks > 0 {
+		l.locks--
+	}
+	l.mu.Unlock()
+	l.dir.GCUnlock(r)
+}
+
+// layoutGet returns the in memory layout for a tar file, loading the file if needed.
+// When create is set, a missing file results in an empty layout.
+func (o *OCITar) layoutGet(r ref.Ref, create bool) (*layout, error) {
+	key := path.Clean(r.Path)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if l, ok := o.layouts[key]; ok {
+		return l, nil
+	}
+	mem := rwfs.MemNew()
+	l := &layout{
+		mem:  mem,
+		gzip: strings.HasSuffix(key, ".tgz") || strings.HasSuffix(key, ".gz"),
+	}
+	fh, err := o.fs.Open(key)
+	if err != nil {
+		if !create || !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to open %s: %w", key, err)
+		}
+	} else {
+		defer fh.Close()
+		l.gzip, err = tarLoad(fh, mem, o.log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+	}
+	l.dir = ocidir.New(
+		ocidir.WithFS(newPrefixFS(key, mem)),
+		ocidir.WithGC(o.gc),
+		ocidir.WithLog(o.log),
+	)
+	o.layouts[key] = l
+	return l, nil
+}
+
+// layoutMod flags the layout as modified, requiring the tar file to be written on close.
+func (l *layout) layoutMod() {
+	l.mu.Lock()
+	l.mod = true
+	l.mu.Unlock()
+}
+
+// tarLoad extracts a tar file into an in memory filesystem.
+// The returned bool indicates the tar was gzip compressed.
+func tarLoad(rdr io.Reader, mem *rwfs.MemFS, log *logrus.Logger) (bool, error) {
+	isGzip := false
+	br := bufio.NewReader(rdr)
+	head, err := br.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	rdr = br
+	if len(head) == 2 && head[0] == 0x1f && head[1] == 0x8b {
+		isGzip = true
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
+			return isGzip, err
+		}
+		defer gzr.Close()
+		rdr = gzr
+	}
+	tr := tar.NewReader(rdr)
+	for {
+		th, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return isGzip, err
+		}
+		name := path.Clean(strings.TrimPrefix(th.Name, "/"))
+		if name == "." {
+			continue
+		}
+		if !fs.ValidPath(name) {
+			log.WithFields(logrus.Fields{
+				"name": th.Name,
+			}).Warn("skipping invalid path in tar")
+			continue
+		}
+		switch th.Typeflag {
+		case tar.TypeDir:
+			err = rwfs.MkdirAll(mem, name, 0777)
+			if err != nil {
+				return isGzip, err
+			}
+		case tar.TypeReg:
+			if dir := path.Dir(name); dir != "." {
+				err = rwfs.MkdirAll(mem, dir, 0777)
+				if err != nil {
+					return isGzip, err
+				}
+			}
+			fh, err := mem.Create(name)
+			if err != nil {
+				return isGzip, err
+			}
+			_, err = io.Copy(fh, tr)
+			errC := fh.Close()
+			if err != nil {
+				return isGzip, err
+			}
+			if errC != nil {
+				return isGzip, errC
+			}
+		default:
+			log.WithFields(logrus.Fields{
+				"name": th.Name,
+				"type": th.Typeflag,
+			}).Debug("skipping unsupported tar entry")
+		}
+	}
+	return isGzip, nil
+}
+
+// tarSave writes the in memory filesystem to a tar file.
+// The oci-layout and index.json files are written first, followed by the blobs in sorted order.
+func tarSave(w io.Writer, mem *rwfs.MemFS, isGzip bool) error {
+	if isGzip {
+		gzw := gzip.NewWriter(w)
+		err := tarSave(gzw, mem, false)
+		errC := gzw.Close()
+		if err != nil {
+			return err
+		}
+		return errC
+	}
+	tw := tar.NewWriter(w)
+	files := []string{}
+	err := tarSaveList(mem, ".", &files)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return tarSaveOrder(files[i]) < tarSaveOrder(files[j])
+	})
+	for _, name := range files {
+		fi, err := rwfs.Stat(mem, name)
+		if err != nil {
+			return err
+		}
+		th := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			ModTime: fi.ModTime(),
+		}
+		if fi.IsDir() {
+			th.Typeflag = tar.TypeDir
+			th.Name = name + "/"
+			th.Mode = 0755
+			err = tw.WriteHeader(th)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		th.Typeflag = tar.TypeReg
+		th.Size = fi.Size()
+		err = tw.WriteHeader(th)
+		if err != nil {
+			return err
+		}
+		fh, err := mem.Open(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, fh)
+		_ = fh.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func tarSaveList(mem *rwfs.MemFS, dir string, files *[]string) error {
+	del, err := fs.ReadDir(mem, dir)
+	if err != nil {
+		return err
+	}
+	for _, de := range del {
+		name := path.Join(dir, de.Name())
+		// skip incomplete temp files
+		if strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		*files = append(*files, name)
+		if de.IsDir() {
+			err = tarSaveList(mem, name, files)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func tarSaveOrder(name string) int {
+	switch name {
+	case imageLayoutFile:
+		return 0
+	case indexFile:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,148 @@
+package ocitar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Verify OCITar implements various interfaces.
+var (
+	_ scheme.API      = (*OCITar)(nil)
+	_ scheme.Closer   = (*OCITar)(nil)
+	_ scheme.GCLocker = (*OCITar)(nil)
+)
+
+func TestOCITar(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.MkdirAll(fsMem, "testdata", 0777)
+	if err != nil {
+		t.Fatalf("failed to setup memfs dir: %v", err)
+	}
+	// generate a tar from the ocidir testdata
+	layoutMem := rwfs.MemNew()
+	err = rwfs.CopyRecursive(fsOS, "../ocidir/testdata/regctl", layoutMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup layout copy: %v", err)
+	}
+	for _, gz := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		err = tarSave(buf, layoutMem, gz)
+		if err != nil {
+			t.Fatalf("failed to create tar: %v", err)
+		}
+		name := "testdata/regctl.tar"
+		if gz {
+			name = "testdata/regctl.tgz"
+		}
+		err = rwfs.WriteFile(fsMem, name, buf.Bytes(), 0644)
+		if err != nil {
+			t.Fatalf("failed to write tar: %v", err)
+		}
+	}
+
+	for _, name := range []string{"testdata/regctl.tar", "testdata/regctl.tgz"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			o := New(WithFS(fsMem))
+			r, err := ref.New("ocitar://" + name + ":latest")
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+			tl, err := o.TagList(ctx, r)
+			if err != nil {
+				t.Fatalf("failed to list tags: %v", err)
+			}
+			if len(tl.Tags) == 0 {
+				t.Errorf("no tags found")
+			}
+			m, err := o.ManifestGet(ctx, r)
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			if m.GetRef().CommonName() != r.CommonName() {
+				t.Errorf("manifest ref, expected %s, received %s", r.CommonName(), m.GetRef().CommonName())
+			}
+			// push a new tag and blob
+			rNew := r.SetTag("new")
+			err = o.ManifestPut(ctx, rNew, m)
+			if err != nil {
+				t.Fatalf("failed to put manifest: %v", err)
+			}
+			blobData := []byte("hello world")
+			d, err := o.BlobPut(ctx, rNew, emptyDesc(), bytes.NewReader(blobData))
+			if err != nil {
+				t.Fatalf("failed to put blob: %v", err)
+			}
+			err = o.BlobDelete(ctx, rNew, d)
+			if !errors.Is(err, types.ErrNotImplemented) {
+				t.Errorf("blob delete, expected %v, received %v", types.ErrNotImplemented, err)
+			}
+			err = o.Close(ctx, r)
+			if err != nil {
+				t.Fatalf("failed to close: %v", err)
+			}
+			// reload and verify the tag persisted and the unreferenced blob was gc'd
+			o2 := New(WithFS(fsMem))
+			mNew, err := o2.ManifestHead(ctx, rNew)
+			if err != nil {
+				t.Fatalf("failed to head new tag: %v", err)
+			}
+			if mNew.GetDescriptor().Digest != m.GetDescriptor().Digest {
+				t.Errorf("digest mismatch, expected %s, received %s", m.GetDescriptor().Digest, mNew.GetDescriptor().Digest)
+			}
+			_, err = o2.BlobHead(ctx, rNew, d)
+			if err == nil {
+				t.Errorf("unreferenced blob was not removed")
+			}
+			raw, err := rwfs.ReadFile(fsMem, name)
+			if err != nil {
+				t.Fatalf("failed to read tar: %v", err)
+			}
+			isGzip := len(raw) > 2 && raw[0] == 0x1f && raw[1] == 0x8b
+			if isGzip != (name == "testdata/regctl.tgz") {
+				t.Errorf("compression changed on write, gzip %t", isGzip)
+			}
+			_ = o2.Close(ctx, r)
+		})
+	}
+
+	t.Run("create", func(t *testing.T) {
+		// gc is disabled since the layout has no index
+		o := New(WithFS(fsMem), WithGC(false))
+		r, err := ref.New("ocitar://testdata/new.tar:latest")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		_, err = o.ManifestGet(ctx, r)
+		if err == nil {
+			t.Errorf("manifest get on missing file did not fail")
+		}
+		_, err = o.BlobPut(ctx, r, emptyDesc(), io.LimitReader(bytes.NewReader([]byte("data")), 4))
+		if err != nil {
+			t.Fatalf("failed to put blob: %v", err)
+		}
+		err = o.Close(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to close: %v", err)
+		}
+		_, err = o.Ping(ctx, r)
+		if err != nil {
+			t.Errorf("tar file not created: %v", err)
+		}
+	})
+}
+
+func emptyDesc() types.Descriptor {
+	return types.Descriptor{}
+}
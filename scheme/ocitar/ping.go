@@ -0,0 +1,24 @@
+package ocitar
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/types/ping"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Ping for an ocitar verifies access to read the tar file.
+func (o *OCITar) Ping(ctx context.Context, r ref.Ref) (ping.Result, error) {
+	ret := ping.Result{}
+	fi, err := rwfs.Stat(o.fs, r.Path)
+	if err != nil {
+		return ret, err
+	}
+	ret.Stat = fi
+	if fi.IsDir() {
+		return ret, fmt.Errorf("failed to access %s: is a directory", r.Path)
+	}
+	return ret, nil
+}
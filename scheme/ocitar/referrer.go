@@ -0,0 +1,18 @@
+package ocitar
+
+import (
+	"context"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
+)
+
+// ReferrerList returns a list of referrers to a given reference
+func (o *OCITar) ReferrerList(ctx context.Context, r ref.Ref, opts ...scheme.ReferrerOpts) (referrer.ReferrerList, error) {
+	l, err := o.layoutGet(r, false)
+	if err != nil {
+		return referrer.ReferrerList{}, err
+	}
+	return l.dir.ReferrerList(ctx, r, opts...)
+}
@@ -0,0 +1,32 @@
+package ocitar
+
+import (
+	"context"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
+)
+
+// TagDelete removes a tag from the repository
+func (o *OCITar) TagDelete(ctx context.Context, r ref.Ref) error {
+	l, err := o.layoutGet(r, false)
+	if err != nil {
+		return err
+	}
+	err = l.dir.TagDelete(ctx, r)
+	if err != nil {
+		return err
+	}
+	l.layoutMod()
+	return nil
+}
+
+// TagList returns a list of tags from the repository
+func (o *OCITar) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error) {
+	l, err := o.layoutGet(r, false)
+	if err != nil {
+		return nil, err
+	}
+	return l.dir.TagList(ctx, r, opts...)
+}
@@ -56,6 +56,7 @@ func (reg *Reg) BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor) (blo
 				Method:     "GET",
 				Repository: r.Repository,
 				Path:       "blobs/" + d.Digest.String(),
+				BlobLen:    blobLen(d.Size),
 			},
 		},
 	}
@@ -75,6 +76,7 @@ func (reg *Reg) BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor) (blo
 						Method:     "GET",
 						Repository: r.Repository,
 						DirectURL:  u,
+						BlobLen:    blobLen(d.Size),
 					},
 				},
 				NoMirrors: true,
@@ -103,6 +105,51 @@ func (reg *Reg) BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor) (blo
 	return b, nil
 }
 
+// BlobGetRange retrieves part of a blob using an HTTP range request.
+// A negative length reads to the end of the blob.
+func (reg *Reg) BlobGetRange(ctx context.Context, r ref.Ref, d types.Descriptor, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid blob range offset %d", offset)
+	}
+	if length == 0 {
+		return io.NopCloser(bytes.NewReader([]byte{})), nil
+	}
+	rangeVal := fmt.Sprintf("bytes=%d-", offset)
+	rangeLen := blobLen(d.Size - offset)
+	if length > 0 {
+		rangeVal = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+		rangeLen = length
+	}
+	req := &reghttp.Req{
+		Host: r.Registry,
+		APIs: map[string]reghttp.ReqAPI{
+			"": {
+				Method:     "GET",
+				Repository: r.Repository,
+				Path:       "blobs/" + d.Digest.String(),
+				Headers: http.Header{
+					"Range": []string{rangeVal},
+				},
+				BlobLen: rangeLen,
+			},
+		},
+	}
+	resp, err := reg.reghttp.Do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob range, digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), err)
+	}
+	if resp.HTTPResponse().StatusCode == http.StatusOK {
+		// the registry ignored the range header and returned the full blob
+		_ = resp.Close()
+		return nil, fmt.Errorf("registry does not support range requests, digest %s, ref %s%.0w", d.Digest.String(), r.CommonName(), types.ErrUnsupported)
+	}
+	if resp.HTTPResponse().StatusCode != http.StatusPartialContent {
+		_ = resp.Close()
+		return nil, fmt.Errorf("failed to get blob range, digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
+	}
+	return resp, nil
+}
+
 // BlobHead is used to verify if a blob exists and is accessible
 func (reg *Reg) BlobHead(ctx context.Context, r ref.Ref, d types.Descriptor) (blob.Reader, error) {
 	// build/send request
@@ -162,6 +209,10 @@ func (reg *Reg) BlobHead(ctx context.Context, r ref.Ref, d types.Descriptor) (bl
 
 // BlobMount attempts to perform a server side copy/mount of the blob between repositories
 func (reg *Reg) BlobMount(ctx context.Context, rSrc ref.Ref, rTgt ref.Ref, d types.Descriptor) error {
+	err := reg.repoCreate(ctx, rTgt)
+	if err != nil {
+		return err
+	}
 	_, uuid, err := reg.blobMount(ctx, rTgt, d, rSrc)
 	// if mount fails and returns an upload location, cancel that upload
 	if err != nil {
@@ -184,6 +235,10 @@ func (reg *Reg) BlobPut(ctx context.Context, r ref.Ref, d types.Descriptor, rdr
 	if d.Size == 0 {
 		d.Size = -1
 	}
+	err = reg.repoCreate(ctx, r)
+	if err != nil {
+		return d, err
+	}
 
 	// attempt an anonymous blob mount
 	if d.Digest != "" && d.Size > 0 {
@@ -232,7 +287,11 @@ func (reg *Reg) BlobPut(ctx context.Context, r ref.Ref, d types.Descriptor, rdr
 	}
 
 	// send a chunked upload if full upload not possible or too large
-	return reg.blobPutUploadChunked(ctx, r, putURL, rdr)
+	alg := reg.digestAlgorithm(r.Registry)
+	if d.Digest != "" && d.Digest.Algorithm().Available() {
+		alg = d.Digest.Algorithm()
+	}
+	return reg.blobPutUploadChunked(ctx, r, putURL, rdr, alg)
 }
 
 func (reg *Reg) blobGetUploadURL(ctx context.Context, r ref.Ref) (*url.URL, error) {
@@ -419,6 +478,7 @@ func (reg *Reg) blobPutUploadFull(ctx context.Context, r ref.Ref, d types.Descri
 				DirectURL:  putURL,
 				BodyFunc:   bodyFunc,
 				BodyLen:    d.Size,
+				BlobLen:    blobLen(d.Size),
 				Headers:    header,
 			},
 		},
@@ -436,7 +496,7 @@ func (reg *Reg) blobPutUploadFull(ctx context.Context, r ref.Ref, d types.Descri
 	return nil
 }
 
-func (reg *Reg) blobPutUploadChunked(ctx context.Context, r ref.Ref, putURL *url.URL, rdr io.Reader) (types.Descriptor, error) {
+func (reg *Reg) blobPutUploadChunked(ctx context.Context, r ref.Ref, putURL *url.URL, rdr io.Reader, alg digest.Algorithm) (types.Descriptor, error) {
 	host := reg.hostGet(r.Registry)
 	bufSize := host.BlobChunk
 	if bufSize <= 0 {
@@ -448,7 +508,7 @@ func (reg *Reg) blobPutUploadChunked(ctx context.Context, r ref.Ref, putURL *url
 	bufChange := false
 
 	// setup buffer and digest pipe
-	digester := digest.Canonical.Digester()
+	digester := alg.Digester()
 	digestRdr := io.TeeReader(rdr, digester.Hash())
 	finalChunk := false
 	chunkStart := int64(0)
@@ -519,6 +579,7 @@ func (reg *Reg) blobPutUploadChunked(ctx context.Context, r ref.Ref, putURL *url
 						DirectURL:  &chunkURL,
 						BodyFunc:   bodyFunc,
 						BodyLen:    int64(chunkSize),
+						BlobLen:    blobLen(int64(chunkSize)),
 						Headers:    header,
 					},
 				},
@@ -689,3 +750,11 @@ func blobUploadCurBytes(resp *http.Response) (int64, error) {
 	}
 	return strconv.ParseInt(rSplit[1], 10, 64)
 }
+
+// blobLen returns the size of a blob transfer used to scale the request timeout, -1 when the size is unknown.
+func blobLen(size int64) int64 {
+	if size > 0 {
+		return size
+	}
+	return -1
+}
@@ -0,0 +1,379 @@
+package reg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/internal/reghttp"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/docker/schema2"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// capabilityProbe is a digest that will not exist in the registry, used to probe APIs without modifying content.
+var capabilityProbe = digest.FromString("regclient capability probe")
+
+// capabilityProbeTag is a tag that will not exist in the registry, used to probe the tag delete API.
+const capabilityProbeTag = "regclient-capability-probe"
+
+// Capabilities describes the login and optional APIs of a registry.
+// Repository features are only probed when the reference includes a repository,
+// and features that require pushing content are only probed with [CapabilitiesWithPush].
+type Capabilities struct {
+	Registry      string            `json:"registry"`                // Registry is the name of the probed registry.
+	Repository    string            `json:"repository,omitempty"`    // Repository is the name of the probed repository.
+	User          string            `json:"user,omitempty"`          // User is the login, "<token>" for an identity token, and empty for anonymous access.
+	CredHelper    string            `json:"credHelper,omitempty"`    // CredHelper is the credential helper or provider used for the login.
+	APIVersion    string            `json:"apiVersion,omitempty"`    // APIVersion is the Docker-Distribution-API-Version header.
+	Referrers     *bool             `json:"referrers,omitempty"`     // Referrers is true when the OCI referrers API is supported.
+	Push          *bool             `json:"push,omitempty"`          // Push is true when a blob upload may be started.
+	Delete        *bool             `json:"delete,omitempty"`        // Delete is true when the manifest delete API is enabled.
+	TagDelete     *bool             `json:"tagDelete,omitempty"`     // TagDelete is true when a tag may be deleted without deleting the manifest.
+	BlobMount     *bool             `json:"blobMount,omitempty"`     // BlobMount is true when a blob mount between repositories succeeds.
+	ManifestTypes []string          `json:"manifestTypes,omitempty"` // ManifestTypes are the media types accepted on a manifest push.
+	ChunkMin      int64             `json:"chunkMin,omitempty"`      // ChunkMin is the minimum chunk size for uploads requested by the registry.
+	ChunkSize     int64             `json:"chunkSize"`               // ChunkSize is the size of each chunk in a chunked upload.
+	ChunkMax      int64             `json:"chunkMax"`                // ChunkMax is the largest chunk size accepted from the registry.
+	BlobMax       int64             `json:"blobMax"`                 // BlobMax is the largest blob pushed in a single request, -1 for no limit.
+	RateLimit     map[string]string `json:"rateLimit,omitempty"`     // RateLimit contains the rate limit headers returned by the registry.
+}
+
+type capabilitiesConfig struct {
+	push bool
+}
+
+// CapabilitiesOpts is used to configure the capabilities probe.
+type CapabilitiesOpts func(*capabilitiesConfig)
+
+// CapabilitiesWithPush probes features that require pushing content to the repository.
+// An empty blob and untagged manifests are pushed to detect the supported manifest types and blob mounts.
+// The manifests are deleted afterwards when the registry supports the delete API.
+func CapabilitiesWithPush() CapabilitiesOpts {
+	return func(cc *capabilitiesConfig) {
+		cc.push = true
+	}
+}
+
+// Capabilities verifies the login to a registry and probes the supported APIs.
+// Probes do not modify the registry unless [CapabilitiesWithPush] is set.
+// An upload started to check push access is canceled,
+// and the delete APIs are checked with a digest and tag that do not exist.
+func (reg *Reg) Capabilities(ctx context.Context, r ref.Ref, opts ...CapabilitiesOpts) (Capabilities, error) {
+	cc := capabilitiesConfig{}
+	for _, opt := range opts {
+		opt(&cc)
+	}
+	c := Capabilities{
+		Registry:   r.Registry,
+		Repository: r.Repository,
+		ChunkSize:  reg.blobChunkSize,
+		ChunkMax:   reg.blobChunkLimit,
+		BlobMax:    reg.blobMaxPut,
+	}
+	host := reg.hostGet(r.Registry)
+	cred := host.GetCred()
+	switch {
+	case cred.User != "":
+		c.User = cred.User
+	case cred.Token != "":
+		c.User = "<token>"
+	}
+	c.CredHelper = host.CredHelper
+	if host.CredProvider != "" {
+		c.CredHelper = host.CredProvider
+	}
+	if host.BlobChunk > 0 {
+		c.ChunkSize = host.BlobChunk
+	}
+	if host.BlobMax != 0 {
+		c.BlobMax = host.BlobMax
+	}
+	result, err := reg.Ping(ctx, r)
+	if result.Header != nil {
+		c.APIVersion = result.Header.Get("Docker-Distribution-API-Version")
+		c.addRateLimit(result.Header)
+	}
+	if err != nil {
+		return c, err
+	}
+	if r.Repository == "" {
+		return c, nil
+	}
+
+	// rate limits are typically only returned on manifest requests
+	if r.Tag != "" {
+		m, err := reg.ManifestHead(ctx, r)
+		if err == nil {
+			if header, err := m.RawHeaders(); err == nil {
+				c.addRateLimit(header)
+			}
+		}
+	}
+
+	rProbe := r.SetDigest(capabilityProbe.String())
+	referrers := reg.referrerPing(ctx, rProbe)
+	c.Referrers = &referrers
+
+	push, chunkMin := reg.capabilityPush(ctx, r)
+	c.Push = &push
+	c.ChunkMin = chunkMin
+
+	del := reg.capabilityDelete(ctx, r, capabilityProbe.String())
+	c.Delete = &del
+	tagDel := reg.capabilityDelete(ctx, r, capabilityProbeTag)
+	c.TagDelete = &tagDel
+
+	if cc.push && push {
+		reg.capabilityPushContent(ctx, r, &c)
+	}
+	return c, nil
+}
+
+// addRateLimit copies the rate limit headers, e.g. RateLimit-Remaining and X-RateLimit-Limit.
+func (c *Capabilities) addRateLimit(header http.Header) {
+	for k, v := range header {
+		if len(v) == 0 || !strings.Contains(strings.ToLower(k), "ratelimit") {
+			continue
+		}
+		if c.RateLimit == nil {
+			c.RateLimit = map[string]string{}
+		}
+		c.RateLimit[k] = strings.Join(v, ", ")
+	}
+}
+
+// capabilityPushContent pushes an empty blob and untagged manifests of each media type.
+func (reg *Reg) capabilityPushContent(ctx context.Context, r ref.Ref, c *Capabilities) {
+	dEmpty := types.Descriptor{Digest: types.EmptyDigest, Size: int64(len(types.EmptyData))}
+	_, err := reg.BlobPut(ctx, r, dEmpty, bytes.NewReader(types.EmptyData))
+	if err != nil {
+		reg.log.WithFields(logrus.Fields{
+			"ref": r.CommonName(),
+			"err": err,
+		}).Debug("Failed to push empty blob")
+		return
+	}
+
+	// mount the blob from the same repository
+	putURL, uuid, err := reg.blobMount(ctx, r, dEmpty, r)
+	mount := err == nil
+	c.BlobMount = &mount
+	if putURL != nil {
+		if err := reg.capabilityCancel(ctx, r, putURL); err != nil && uuid != "" {
+			_ = reg.blobUploadCancel(ctx, r, uuid)
+		}
+	}
+
+	// push an image and an index for each schema, indexes refer to the image of the same schema
+	pushed := []ref.Ref{}
+	images := []struct {
+		orig      interface{}
+		indexType string
+	}{
+		{
+			orig: v1.Manifest{
+				Versioned: v1.ManifestSchemaVersion,
+				MediaType: types.MediaTypeOCI1Manifest,
+				Config: types.Descriptor{
+					MediaType: types.MediaTypeOCI1Empty,
+					Digest:    dEmpty.Digest,
+					Size:      dEmpty.Size,
+				},
+				Layers: []types.Descriptor{
+					{
+						MediaType: types.MediaTypeOCI1Empty,
+						Digest:    dEmpty.Digest,
+						Size:      dEmpty.Size,
+					},
+				},
+			},
+			indexType: types.MediaTypeOCI1ManifestList,
+		},
+		{
+			orig: schema2.Manifest{
+				Versioned: schema2.ManifestSchemaVersion,
+				Config: types.Descriptor{
+					MediaType: types.MediaTypeDocker2ImageConfig,
+					Digest:    dEmpty.Digest,
+					Size:      dEmpty.Size,
+				},
+				Layers: []types.Descriptor{
+					{
+						MediaType: types.MediaTypeDocker2LayerGzip,
+						Digest:    dEmpty.Digest,
+						Size:      dEmpty.Size,
+					},
+				},
+			},
+			indexType: types.MediaTypeDocker2ManifestList,
+		},
+	}
+	for _, image := range images {
+		m, err := manifest.New(manifest.WithOrig(image.orig))
+		if err != nil {
+			continue
+		}
+		rImage, ok := reg.capabilityManifestPut(ctx, r, m, c)
+		if !ok {
+			continue
+		}
+		pushed = append(pushed, rImage)
+		d := m.GetDescriptor()
+		var index interface{}
+		if image.indexType == types.MediaTypeOCI1ManifestList {
+			index = v1.Index{
+				Versioned: v1.IndexSchemaVersion,
+				MediaType: types.MediaTypeOCI1ManifestList,
+				Manifests: []types.Descriptor{{MediaType: d.MediaType, Digest: d.Digest, Size: d.Size}},
+			}
+		} else {
+			index = schema2.ManifestList{
+				Versioned: schema2.ManifestListSchemaVersion,
+				Manifests: []types.Descriptor{{MediaType: d.MediaType, Digest: d.Digest, Size: d.Size}},
+			}
+		}
+		mi, err := manifest.New(manifest.WithOrig(index))
+		if err != nil {
+			continue
+		}
+		if rIndex, ok := reg.capabilityManifestPut(ctx, r, mi, c); ok {
+			// indexes are deleted before the images they reference
+			pushed = append([]ref.Ref{rIndex}, pushed...)
+		}
+	}
+
+	// cleanup the pushed manifests
+	if c.Delete == nil || !*c.Delete {
+		return
+	}
+	for _, rm := range pushed {
+		err := reg.ManifestDelete(ctx, rm)
+		if err != nil {
+			reg.log.WithFields(logrus.Fields{
+				"ref": rm.CommonName(),
+				"err": err,
+			}).Debug("Failed to delete probe manifest")
+		}
+	}
+}
+
+// capabilityManifestPut pushes a manifest by digest, adding the media type to the capabilities on success.
+func (reg *Reg) capabilityManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest, c *Capabilities) (ref.Ref, bool) {
+	rm := r.SetDigest(m.GetDescriptor().Digest.String())
+	err := reg.ManifestPut(ctx, rm, m)
+	if err != nil {
+		reg.log.WithFields(logrus.Fields{
+			"ref":       rm.CommonName(),
+			"mediaType": manifest.GetMediaType(m),
+			"err":       err,
+		}).Debug("Failed to push probe manifest")
+		return rm, false
+	}
+	c.ManifestTypes = append(c.ManifestTypes, manifest.GetMediaType(m))
+	return rm, true
+}
+
+// capabilityPush starts and cancels a blob upload, returning if the upload was accepted and the minimum chunk size.
+func (reg *Reg) capabilityPush(ctx context.Context, r ref.Ref) (bool, int64) {
+	req := &reghttp.Req{
+		Host:      r.Registry,
+		NoMirrors: true,
+		APIs: map[string]reghttp.ReqAPI{
+			"": {
+				Method:     "POST",
+				Repository: r.Repository,
+				Path:       "blobs/uploads/",
+				IgnoreErr:  true,
+			},
+		},
+	}
+	resp, err := reg.reghttp.Do(ctx, req)
+	if err != nil {
+		return false, 0
+	}
+	_ = resp.Close()
+	if resp.HTTPResponse().StatusCode != 202 {
+		return false, 0
+	}
+	var chunkMin int64
+	if minSizeStr := resp.HTTPResponse().Header.Get(blobChunkMinHeader); minSizeStr != "" {
+		if minSize, err := strconv.ParseInt(minSizeStr, 10, 64); err == nil {
+			chunkMin = minSize
+		}
+	}
+	// cancel the upload session
+	location := resp.HTTPResponse().Header.Get("Location")
+	if location != "" {
+		var cancelURL *url.URL
+		cancelURL, err = resp.HTTPResponse().Request.URL.Parse(location)
+		if err == nil {
+			err = reg.capabilityCancel(ctx, r, cancelURL)
+		}
+	}
+	if location == "" || err != nil {
+		reg.log.WithFields(logrus.Fields{
+			"ref":      r.CommonName(),
+			"location": location,
+			"err":      err,
+		}).Debug("Failed to cancel upload")
+	}
+	return true, chunkMin
+}
+
+// capabilityCancel deletes an upload session.
+func (reg *Reg) capabilityCancel(ctx context.Context, r ref.Ref, u *url.URL) error {
+	req := &reghttp.Req{
+		Host:      r.Registry,
+		NoMirrors: true,
+		APIs: map[string]reghttp.ReqAPI{
+			"": {
+				Method:    "DELETE",
+				DirectURL: u,
+				IgnoreErr: true,
+			},
+		},
+	}
+	resp, err := reg.reghttp.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Close()
+	if resp.HTTPResponse().StatusCode != 202 && resp.HTTPResponse().StatusCode != 204 {
+		return fmt.Errorf("failed to cancel upload %s: %w", r.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
+	}
+	return nil
+}
+
+// capabilityDelete requests the delete of a manifest digest or tag that does not exist.
+// A not found response indicates the API is enabled, while disabled registries return a 405 or a permission error.
+func (reg *Reg) capabilityDelete(ctx context.Context, r ref.Ref, reference string) bool {
+	req := &reghttp.Req{
+		Host:      r.Registry,
+		NoMirrors: true,
+		APIs: map[string]reghttp.ReqAPI{
+			"": {
+				Method:     "DELETE",
+				Repository: r.Repository,
+				Path:       "manifests/" + reference,
+				IgnoreErr:  true,
+			},
+		},
+	}
+	resp, err := reg.reghttp.Do(ctx, req)
+	if err != nil {
+		return errors.Is(err, types.ErrNotFound)
+	}
+	_ = resp.Close()
+	return resp.HTTPResponse().StatusCode == 202
+}
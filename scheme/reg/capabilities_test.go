@@ -0,0 +1,302 @@
+package reg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/pkg/regtest"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repoPush := "proj/push"
+	repoRO := "proj/readonly"
+	uploadUUID := "uuid-1234"
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "ping",
+				Method: "GET",
+				Path:   "/v2/",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Docker-Distribution-API-Version": {"registry/2.0"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "referrers push",
+				Method: "GET",
+				Path:   "/v2/" + repoPush + "/referrers/" + capabilityProbe.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Type": {"application/vnd.oci.image.index.v1+json"},
+				},
+				Body: []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "upload push",
+				Method: "POST",
+				Path:   "/v2/" + repoPush + "/blobs/uploads/",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusAccepted,
+				Headers: http.Header{
+					"Location":           {"/v2/" + repoPush + "/blobs/uploads/" + uploadUUID},
+					blobChunkMinHeader:   {"5242880"},
+					"Docker-Upload-UUID": {uploadUUID},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "cancel push",
+				Method: "DELETE",
+				Path:   "/v2/" + repoPush + "/blobs/uploads/" + uploadUUID,
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusNoContent,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "delete push",
+				Method: "DELETE",
+				Path:   "/v2/" + repoPush + "/manifests/" + capabilityProbe.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusNotFound,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "head push",
+				Method: "HEAD",
+				Path:   "/v2/" + repoPush + "/manifests/latest",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Type":          {"application/vnd.oci.image.index.v1+json"},
+					"Docker-Content-Digest": {capabilityProbe.String()},
+					"Content-Length":        {"100"},
+					"Ratelimit-Limit":       {"100;w=21600"},
+					"Ratelimit-Remaining":   {"99;w=21600"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "tag delete push",
+				Method: "DELETE",
+				Path:   "/v2/" + repoPush + "/manifests/" + capabilityProbeTag,
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusNotFound,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "head readonly",
+				Method: "HEAD",
+				Path:   "/v2/" + repoRO + "/manifests/latest",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusNotFound,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "tag delete readonly",
+				Method: "DELETE",
+				Path:   "/v2/" + repoRO + "/manifests/" + capabilityProbeTag,
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusMethodNotAllowed,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "referrers readonly",
+				Method: "GET",
+				Path:   "/v2/" + repoRO + "/referrers/" + capabilityProbe.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusNotFound,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "upload readonly",
+				Method: "POST",
+				Path:   "/v2/" + repoRO + "/blobs/uploads/",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusForbidden,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "delete readonly",
+				Method: "DELETE",
+				Path:   "/v2/" + repoRO + "/manifests/" + capabilityProbe.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusMethodNotAllowed,
+			},
+		},
+	}
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			User:     "testuser",
+			Pass:     "testpass",
+		},
+	}
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.WarnLevel,
+	}
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithLog(log),
+		WithDelay(time.Millisecond*10, time.Millisecond*50),
+		WithRetryLimit(1),
+	)
+	t.Run("registry", func(t *testing.T) {
+		r, err := ref.NewHost(tsHost)
+		if err != nil {
+			t.Fatalf("failed to create ref: %v", err)
+		}
+		c, err := reg.Capabilities(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get capabilities: %v", err)
+		}
+		if c.User != "testuser" || c.APIVersion != "registry/2.0" {
+			t.Errorf("unexpected login details: %v", c)
+		}
+		if c.Referrers != nil || c.Push != nil || c.Delete != nil {
+			t.Errorf("repository features probed without a repository: %v", c)
+		}
+	})
+	t.Run("push", func(t *testing.T) {
+		r, err := ref.New(tsHost + "/" + repoPush)
+		if err != nil {
+			t.Fatalf("failed to create ref: %v", err)
+		}
+		c, err := reg.Capabilities(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get capabilities: %v", err)
+		}
+		if c.Referrers == nil || !*c.Referrers {
+			t.Errorf("referrers not detected")
+		}
+		if c.Push == nil || !*c.Push {
+			t.Errorf("push not detected")
+		}
+		if c.Delete == nil || !*c.Delete {
+			t.Errorf("delete not detected")
+		}
+		if c.TagDelete == nil || !*c.TagDelete {
+			t.Errorf("tag delete not detected")
+		}
+		if c.ChunkMin != 5242880 {
+			t.Errorf("unexpected chunk min: %d", c.ChunkMin)
+		}
+		if c.RateLimit["Ratelimit-Remaining"] != "99;w=21600" {
+			t.Errorf("rate limit not detected: %v", c.RateLimit)
+		}
+		if c.BlobMount != nil || len(c.ManifestTypes) > 0 {
+			t.Errorf("push content probed without the option: %v", c)
+		}
+	})
+	t.Run("readonly", func(t *testing.T) {
+		r, err := ref.New(tsHost + "/" + repoRO)
+		if err != nil {
+			t.Fatalf("failed to create ref: %v", err)
+		}
+		c, err := reg.Capabilities(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get capabilities: %v", err)
+		}
+		if c.Referrers == nil || *c.Referrers {
+			t.Errorf("referrers detected")
+		}
+		if c.Push == nil || *c.Push {
+			t.Errorf("push detected")
+		}
+		if c.Delete == nil || *c.Delete {
+			t.Errorf("delete detected")
+		}
+		if c.TagDelete == nil || *c.TagDelete {
+			t.Errorf("tag delete detected")
+		}
+		if c.ChunkMin != 0 {
+			t.Errorf("unexpected chunk min: %d", c.ChunkMin)
+		}
+	})
+	t.Run("push content", func(t *testing.T) {
+		tsReg := httptest.NewServer(regtest.New())
+		t.Cleanup(tsReg.Close)
+		tsRegURL, _ := url.Parse(tsReg.URL)
+		regPush := New(
+			WithConfigHosts([]*config.Host{{Name: tsRegURL.Host, Hostname: tsRegURL.Host, TLS: config.TLSDisabled}}),
+			WithLog(log),
+			WithDelay(time.Millisecond*10, time.Millisecond*50),
+			WithRetryLimit(1),
+		)
+		r, err := ref.New(tsRegURL.Host + "/proj/app")
+		if err != nil {
+			t.Fatalf("failed to create ref: %v", err)
+		}
+		c, err := regPush.Capabilities(ctx, r, CapabilitiesWithPush())
+		if err != nil {
+			t.Fatalf("failed to get capabilities: %v", err)
+		}
+		if c.BlobMount == nil || !*c.BlobMount {
+			t.Errorf("blob mount not detected")
+		}
+		expectTypes := []string{types.MediaTypeOCI1Manifest, types.MediaTypeOCI1ManifestList, types.MediaTypeDocker2Manifest, types.MediaTypeDocker2ManifestList}
+		if len(c.ManifestTypes) != len(expectTypes) {
+			t.Fatalf("unexpected manifest types: %v", c.ManifestTypes)
+		}
+		for i := range expectTypes {
+			if c.ManifestTypes[i] != expectTypes[i] {
+				t.Errorf("unexpected manifest types: %v", c.ManifestTypes)
+				break
+			}
+		}
+		// probe manifests are removed
+		tl, err := regPush.TagList(ctx, r)
+		if err == nil && len(tl.Tags) > 0 {
+			t.Errorf("tags created by the probe: %v", tl.Tags)
+		}
+	})
+}
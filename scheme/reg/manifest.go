@@ -4,13 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/sirupsen/logrus"
 
-	"github.com/regclient/regclient/internal/limitread"
 	"github.com/regclient/regclient/internal/reghttp"
 	"github.com/regclient/regclient/internal/wraperr"
 	"github.com/regclient/regclient/scheme"
@@ -129,24 +127,16 @@ func (reg *Reg) ManifestGet(ctx context.Context, r ref.Ref) (manifest.Manifest,
 	if size > 0 && reg.manifestMaxPull > 0 && int64(size) > reg.manifestMaxPull {
 		return nil, fmt.Errorf("manifest too large, received %d, limit %d: %s%.0w", size, reg.manifestMaxPull, r.CommonName(), types.ErrSizeLimitExceeded)
 	}
-	rdr := &limitread.LimitRead{
-		Reader: resp,
-		Limit:  reg.manifestMaxPull,
-	}
-
-	// read manifest
-	rawBody, err := io.ReadAll(rdr)
-	if err != nil {
-		return nil, fmt.Errorf("error reading manifest for %s: %w", r.CommonName(), err)
-	}
 
+	// read manifest, parsing as it is read to stop early on oversized or deeply nested responses
 	m, err := manifest.New(
 		manifest.WithRef(r),
 		manifest.WithHeader(resp.HTTPResponse().Header),
-		manifest.WithRaw(rawBody),
+		manifest.WithReader(resp),
+		manifest.WithMaxSize(reg.manifestMaxPull),
 	)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error reading manifest for %s: %w", r.CommonName(), err)
 	}
 	rCache := r.SetDigest(m.GetDescriptor().Digest.String())
 	reg.cacheMan.Set(rCache, m)
@@ -236,6 +226,11 @@ func (reg *Reg) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest,
 		return fmt.Errorf("manifest too large, calculated %d, limit %d: %s%.0w", len(mj), reg.manifestMaxPush, r.CommonName(), types.ErrSizeLimitExceeded)
 	}
 
+	err = reg.repoCreate(ctx, r)
+	if err != nil {
+		return err
+	}
+
 	// build/send request
 	headers := http.Header{
 		"Content-Type": []string{manifest.GetMediaType(m)},
@@ -238,7 +238,7 @@ func (reg *Reg) referrerListByTag(ctx context.Context, r ref.Ref) (referrer.Refe
 	}
 	ociML, ok := m.GetOrig().(v1.Index)
 	if !ok {
-		return rl, fmt.Errorf("manifest is not an OCI index: %s", rlTag.CommonName())
+		return rl, fmt.Errorf("manifest is not an OCI index: %s%.0w", rlTag.CommonName(), types.ErrUnsupportedMediaType)
 	}
 	// return resulting index
 	rl.Manifest = m
@@ -6,12 +6,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/cache"
 	"github.com/regclient/regclient/internal/reghttp"
 	"github.com/regclient/regclient/internal/throttle"
+	"github.com/regclient/regclient/pkg/metrics"
+	"github.com/regclient/regclient/pkg/trace"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/referrer"
@@ -38,7 +41,10 @@ type Reg struct {
 	reghttpOpts     []reghttp.Opts
 	log             *logrus.Logger
 	hosts           map[string]*config.Host
+	aliases         map[string]string
 	features        map[featureKey]*featureVal
+	credHelper      string
+	digestAlg       digest.Algorithm
 	blobChunkSize   int64
 	blobChunkLimit  int64
 	blobMaxPut      int64
@@ -46,6 +52,10 @@ type Reg struct {
 	manifestMaxPush int64
 	cacheMan        *cache.Cache[ref.Ref, manifest.Manifest]
 	cacheRL         *cache.Cache[ref.Ref, referrer.ReferrerList]
+	repoCreators    map[string]RepoCreator
+	repoDeleters    map[string]RepoDeleter
+	repoListers     map[string]RepoLister
+	reposCreated    map[featureKey]bool
 	muHost          sync.Mutex
 	muRefTag        sync.Mutex
 }
@@ -75,12 +85,29 @@ func New(opts ...Opts) *Reg {
 		manifestMaxPull: defaultManifestMaxPull,
 		manifestMaxPush: defaultManifestMaxPush,
 		hosts:           map[string]*config.Host{},
+		aliases:         map[string]string{},
 		features:        map[featureKey]*featureVal{},
+		repoCreators: map[string]RepoCreator{
+			config.RepoCreateECR: RepoCreatorFunc(repoCreateECR),
+		},
+		repoDeleters: map[string]RepoDeleter{},
+		repoListers: map[string]RepoLister{
+			config.RepoListECR: RepoListerFunc(repoListECR),
+		},
+		reposCreated: map[featureKey]bool{},
 	}
+	r.repoDeleters[config.RepoDeleteArtifactory] = RepoDeleterFunc(r.repoDeleteArtifactory)
+	r.repoDeleters[config.RepoDeleteGitLab] = RepoDeleterFunc(r.repoDeleteGitLab)
+	r.repoDeleters[config.RepoDeleteHarbor] = RepoDeleterFunc(r.repoDeleteHarbor)
+	r.repoListers[config.RepoListDockerHub] = RepoListerFunc(r.repoListDockerHub)
+	r.repoListers[config.RepoListGCR] = RepoListerFunc(r.repoListGCR)
 	r.reghttpOpts = append(r.reghttpOpts, reghttp.WithConfigHost(r.hostGet))
 	for _, opt := range opts {
 		opt(&r)
 	}
+	for _, h := range r.hosts {
+		r.hostCredHelper(h)
+	}
 	r.reghttp = reghttp.NewClient(r.reghttpOpts...)
 	return &r
 }
@@ -104,9 +131,60 @@ func (reg *Reg) Throttle(r ref.Ref, put bool) []*throttle.Throttle {
 	return tList
 }
 
+// MirrorHealth is the health of a registry or one of its mirrors.
+type MirrorHealth struct {
+	Name           string    `json:"name"`                     // Name of the registry host config.
+	Mirror         bool      `json:"mirror"`                   // Mirror is true for the mirrors of the requested registry.
+	Priority       uint      `json:"priority"`                 // Priority used to sort mirrors, highest first.
+	Weight         uint      `json:"weight"`                   // Weight used to select between mirrors with the same priority.
+	Healthy        bool      `json:"healthy"`                  // Healthy is false while a failed mirror is skipped.
+	Failures       int       `json:"failures"`                 // Failures is the count of consecutive failed requests.
+	LastError      string    `json:"lastError,omitempty"`      // LastError is the most recent failure.
+	LastFailure    time.Time `json:"lastFailure,omitempty"`    // LastFailure is the time of the most recent failure.
+	LastSuccess    time.Time `json:"lastSuccess,omitempty"`    // LastSuccess is the time of the most recent successful request.
+	UnhealthyUntil time.Time `json:"unhealthyUntil,omitempty"` // UnhealthyUntil is when a failed mirror will be attempted again.
+	BackoffUntil   time.Time `json:"backoffUntil,omitempty"`   // BackoffUntil is when the next request may be sent after a retryable error.
+}
+
+// MirrorHealth returns the health of the registry for a reference followed by each of its mirrors.
+// Mirrors are tried before the registry for pulls, and a mirror that fails with a server or network error is skipped for the MirrorBackoff of the host config.
+func (reg *Reg) MirrorHealth(r ref.Ref) []MirrorHealth {
+	hhList := reg.reghttp.HostHealth(r.Registry)
+	ret := make([]MirrorHealth, len(hhList))
+	for i, hh := range hhList {
+		ret[i] = MirrorHealth(hh)
+	}
+	return ret
+}
+
+// digestAlgorithm returns the algorithm for new digests computed for a host
+func (reg *Reg) digestAlgorithm(hostname string) digest.Algorithm {
+	host := reg.hostGet(hostname)
+	if host.DigestAlgorithm != "" {
+		alg := digest.Algorithm(host.DigestAlgorithm)
+		if alg.Available() {
+			return alg
+		}
+		reg.log.WithFields(logrus.Fields{
+			"host":      host.Name,
+			"algorithm": host.DigestAlgorithm,
+		}).Warn("Digest algorithm is not available")
+	}
+	if reg.digestAlg != "" && reg.digestAlg.Available() {
+		return reg.digestAlg
+	}
+	return digest.Canonical
+}
+
 func (reg *Reg) hostGet(hostname string) *config.Host {
 	reg.muHost.Lock()
 	defer reg.muHost.Unlock()
+	// aliases redirect all requests for a registry to another host, only a single level is resolved
+	if len(reg.aliases) > 0 {
+		if alias, ok := reg.aliases[config.HostNewName(hostname).Name]; ok {
+			hostname = alias
+		}
+	}
 	if _, ok := reg.hosts[hostname]; !ok {
 		newHost := config.HostNewName(hostname)
 		// check for normalized hostname
@@ -116,11 +194,20 @@ func (reg *Reg) hostGet(hostname string) *config.Host {
 				return h
 			}
 		}
+		reg.hostCredHelper(newHost)
 		reg.hosts[hostname] = newHost
 	}
 	return reg.hosts[hostname]
 }
 
+// hostCredHelper sets the default credential helper on hosts without any configured credentials.
+func (reg *Reg) hostCredHelper(h *config.Host) {
+	if reg.credHelper == "" || h.CredHelper != "" || h.CredProvider != "" || h.User != "" || h.Pass != "" || h.Token != "" || h.ClientSecret != "" {
+		return
+	}
+	h.CredHelper = reg.credHelper
+}
+
 // featureGet returns enabled and ok
 func (reg *Reg) featureGet(kind, registry, repo string) (bool, bool) {
 	reg.muHost.Lock()
@@ -206,6 +293,14 @@ func WithConfigHosts(configHosts []*config.Host) Opts {
 	}
 }
 
+// WithCredHelper sets the default credential helper for hosts without a configured login.
+// This is the equivalent of the credsStore setting in the docker config.
+func WithCredHelper(helper string) Opts {
+	return func(r *Reg) {
+		r.credHelper = helper
+	}
+}
+
 // WithDelay initial time to wait between retries (increased with exponential backoff)
 func WithDelay(delayInit time.Duration, delayMax time.Duration) Opts {
 	return func(r *Reg) {
@@ -213,6 +308,13 @@ func WithDelay(delayInit time.Duration, delayMax time.Duration) Opts {
 	}
 }
 
+// WithDigestAlgorithm sets the default algorithm for digests computed on upload, overridden by the host DigestAlgorithm
+func WithDigestAlgorithm(alg digest.Algorithm) Opts {
+	return func(r *Reg) {
+		r.digestAlg = alg
+	}
+}
+
 // WithHTTPClient uses a specific http client with retryable requests
 func WithHTTPClient(hc *http.Client) Opts {
 	return func(r *Reg) {
@@ -236,6 +338,52 @@ func WithManifestMax(push, pull int64) Opts {
 	}
 }
 
+// WithMetrics reports request counts, bytes transferred, retries, and token refreshes to the metrics hooks
+func WithMetrics(m metrics.Metrics) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithMetrics(m))
+	}
+}
+
+// WithRepoCreator registers a repository creator, used by hosts with the matching repoCreate setting.
+// This replaces any built-in creator with the same name.
+func WithRepoCreator(name string, rc RepoCreator) Opts {
+	return func(r *Reg) {
+		r.repoCreators[name] = rc
+	}
+}
+
+// WithRepoDeleter registers a repository deleter, used by hosts with the matching repoDelete setting.
+// This replaces any built-in deleter with the same name.
+func WithRepoDeleter(name string, rd RepoDeleter) Opts {
+	return func(r *Reg) {
+		r.repoDeleters[name] = rd
+	}
+}
+
+// WithRepoLister registers a repository lister, used by hosts with the matching repoList setting.
+// This replaces any built-in lister with the same name.
+func WithRepoLister(name string, rl RepoLister) Opts {
+	return func(r *Reg) {
+		r.repoListers[name] = rl
+	}
+}
+
+// WithRegistryAlias sends all requests for a registry to another host.
+// For example, an alias from "docker.io" to "mirror.example.com" pulls Docker Hub images from the mirror.
+// The host config of the target registry is used, including credentials, TLS, and mirrors.
+func WithRegistryAlias(registry, target string) Opts {
+	return func(r *Reg) {
+		registry = config.HostNewName(registry).Name
+		target = config.HostNewName(target).Name
+		if registry == target {
+			delete(r.aliases, registry)
+			return
+		}
+		r.aliases[registry] = target
+	}
+}
+
 // WithRetryLimit restricts the number of retries (defaults to 5)
 func WithRetryLimit(l int) Opts {
 	return func(r *Reg) {
@@ -243,6 +391,57 @@ func WithRetryLimit(l int) Opts {
 	}
 }
 
+// WithRetryBudget limits the time for a request, including retries and backoff delays
+func WithRetryBudget(d time.Duration) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithRetryBudget(d))
+	}
+}
+
+// WithRetryJitter randomizes each backoff delay by a fraction (0 to 1) of the delay
+func WithRetryJitter(jitter float64) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithRetryJitter(jitter))
+	}
+}
+
+// WithRetryFullJitter randomizes each backoff delay between 0 and the exponential delay
+func WithRetryFullJitter() Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithRetryFullJitter())
+	}
+}
+
+// WithRetryPolicy sets a function to select the http status codes that are retried with a backoff.
+// The function replaces the status codes from [WithRetryStatus] and the host configuration.
+func WithRetryPolicy(policy func(host string, statusCode int) bool) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithRetryPolicy(policy))
+	}
+}
+
+// WithRetryStatus sets the http status codes that are retried with a backoff
+func WithRetryStatus(status []int) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithRetryStatus(status))
+	}
+}
+
+// WithTracer creates a span for each http request and propagates the trace context to the registry
+func WithTracer(t trace.Tracer) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithTracer(t))
+	}
+}
+
+// WithTokenCache saves bearer tokens to a file, allowing short lived processes to reuse unexpired tokens.
+// The file is created with 0600 permissions and refresh tokens are not saved.
+func WithTokenCache(filename string) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithTokenCache(filename))
+	}
+}
+
 // WithTransport uses a specific http transport with retryable requests
 func WithTransport(t *http.Transport) Opts {
 	return func(r *Reg) {
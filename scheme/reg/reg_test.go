@@ -1,11 +1,23 @@
 package reg
 
-import "github.com/regclient/regclient/scheme"
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/ref"
+)
 
 // Verify Reg implements various interfaces.
 var (
-	_ scheme.API       = (*Reg)(nil)
-	_ scheme.Throttler = (*Reg)(nil)
+	_ scheme.API        = (*Reg)(nil)
+	_ scheme.TagDeleter = (*Reg)(nil)
+	_ scheme.Throttler  = (*Reg)(nil)
 )
 
 func stringSliceCmp(a, b []string) bool {
@@ -19,3 +31,91 @@ func stringSliceCmp(a, b []string) bool {
 	}
 	return true
 }
+
+func TestCredHelper(t *testing.T) {
+	t.Parallel()
+	hostLogin := config.HostNewName("login.example.com")
+	hostLogin.User = "user"
+	hostLogin.Pass = "pass"
+	hostTLS := config.HostNewName("tls.example.com")
+	hostTLS.TLS = config.TLSDisabled
+	r := New(
+		WithConfigHosts([]*config.Host{hostLogin, hostTLS}),
+		WithCredHelper("docker-credential-test"),
+	)
+	tt := []struct {
+		host   string
+		expect string
+	}{
+		{host: "login.example.com", expect: ""},
+		{host: "tls.example.com", expect: "docker-credential-test"},
+		{host: "new.example.com", expect: "docker-credential-test"},
+	}
+	for _, tc := range tt {
+		h := r.hostGet(tc.host)
+		if h.CredHelper != tc.expect {
+			t.Errorf("cred helper mismatch for %s, expected %s, received %s", tc.host, tc.expect, h.CredHelper)
+		}
+	}
+}
+
+func TestRegistryAlias(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Get",
+				Method: "GET",
+				Path:   "/v2/",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Length":                  {"2"},
+					"Content-Type":                    {"application/json"},
+					"Docker-Distribution-API-Version": {"registry/2.0"},
+				},
+				Body: []byte("{}"),
+			},
+		},
+	}
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	r := New(
+		WithConfigHosts([]*config.Host{
+			{
+				Name:     tsHost,
+				Hostname: tsHost,
+				TLS:      config.TLSDisabled,
+			},
+		}),
+		WithRegistryAlias("registry-1.docker.io", tsHost),
+		WithRegistryAlias("self.example.com", "self.example.com"),
+	)
+	tt := []struct {
+		host   string
+		expect string
+	}{
+		{host: "docker.io", expect: tsHost},
+		{host: "registry-1.docker.io", expect: tsHost},
+		{host: "self.example.com", expect: "self.example.com"},
+		{host: "other.example.com", expect: "other.example.com"},
+	}
+	for _, tc := range tt {
+		h := r.hostGet(tc.host)
+		if h.Name != tc.expect {
+			t.Errorf("alias mismatch for %s, expected %s, received %s", tc.host, tc.expect, h.Name)
+		}
+	}
+	rHub, err := ref.NewHost("docker.io")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	_, err = r.Ping(ctx, rHub)
+	if err != nil {
+		t.Errorf("failed to ping alias: %v", err)
+	}
+}
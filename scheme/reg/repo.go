@@ -17,12 +17,16 @@ import (
 )
 
 // RepoList returns a list of repositories on a registry
-// Note the underlying "_catalog" API is not supported on many cloud registries
+// Note the underlying "_catalog" API is not supported on many cloud registries,
+// those registries may be listed with a provider selected by the repoList setting of the host.
 func (reg *Reg) RepoList(ctx context.Context, hostname string, opts ...scheme.RepoOpts) (*repo.RepoList, error) {
 	config := scheme.RepoConfig{}
 	for _, opt := range opts {
 		opt(&config)
 	}
+	if host := reg.hostGet(hostname); host.RepoList != "" {
+		return reg.repoListProvider(ctx, host, config)
+	}
 
 	query := url.Values{}
 	if config.Last != "" {
@@ -0,0 +1,63 @@
+package reg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// RepoCreator creates a repository on registries that require the repository to exist before the first push.
+// The creator is selected with the repoCreate setting of the host.
+type RepoCreator interface {
+	// RepoCreate creates the repository, returning nil when the repository already exists.
+	RepoCreate(ctx context.Context, host *config.Host, repo string) error
+}
+
+// RepoCreatorFunc is a function that implements [RepoCreator].
+type RepoCreatorFunc func(ctx context.Context, host *config.Host, repo string) error
+
+// RepoCreate implements [RepoCreator].
+func (f RepoCreatorFunc) RepoCreate(ctx context.Context, host *config.Host, repo string) error {
+	return f(ctx, host, repo)
+}
+
+// repoCreateECR creates repositories with the AWS ECR API.
+func repoCreateECR(ctx context.Context, host *config.Host, repo string) error {
+	return config.ECRRepoCreate(ctx, host.Hostname, repo)
+}
+
+// repoCreate runs the repository creator configured for the host before the first push to a repository.
+func (reg *Reg) repoCreate(ctx context.Context, r ref.Ref) error {
+	host := reg.hostGet(r.Registry)
+	if host.RepoCreate == "" {
+		return nil
+	}
+	key := featureKey{kind: "repoCreate", reg: host.Name, repo: r.Repository}
+	reg.muHost.Lock()
+	created := reg.reposCreated[key]
+	rc, ok := reg.repoCreators[host.RepoCreate]
+	reg.muHost.Unlock()
+	if created {
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("unknown repository creator %s for host %s%.0w", host.RepoCreate, host.Name, types.ErrNotImplemented)
+	}
+	err := rc.RepoCreate(ctx, host, r.Repository)
+	if err != nil {
+		return fmt.Errorf("failed to create repository %s: %w", r.CommonName(), err)
+	}
+	reg.log.WithFields(logrus.Fields{
+		"host": host.Name,
+		"repo": r.Repository,
+	}).Debug("Repository created")
+	reg.muHost.Lock()
+	reg.reposCreated[key] = true
+	reg.muHost.Unlock()
+	return nil
+}
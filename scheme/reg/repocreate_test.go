@@ -0,0 +1,147 @@
+package reg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/docker/schema2"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestRepoCreate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	putTag := "put"
+	m := schema2.Manifest{
+		Config: types.Descriptor{
+			MediaType: types.MediaTypeDocker2ImageConfig,
+			Size:      8,
+			Digest:    digest.FromString("example1"),
+		},
+	}
+	mBody, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	mDigest := digest.FromBytes(mBody)
+	// only the created repository receives the manifest
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Put",
+				Method: "PUT",
+				Path:   "/v2/create/manifests/" + putTag,
+				Body:   mBody,
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusCreated,
+				Headers: http.Header{
+					"Docker-Content-Digest": []string{mDigest.String()},
+				},
+			},
+		},
+	}
+	rrs = append(rrs, reqresp.BaseEntries...)
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:       tsHost,
+			Hostname:   tsHost,
+			TLS:        config.TLSDisabled,
+			RepoCreate: "test",
+		},
+		{
+			Name:       "unknown." + tsHost,
+			Hostname:   tsHost,
+			TLS:        config.TLSDisabled,
+			RepoCreate: "unknown",
+		},
+	}
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.WarnLevel,
+	}
+	var mu sync.Mutex
+	created := map[string]int{}
+	errFail := errors.New("create failed")
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithLog(log),
+		WithDelay(time.Millisecond*5, time.Millisecond*10),
+		WithRepoCreator("test", RepoCreatorFunc(func(ctx context.Context, host *config.Host, repo string) error {
+			if host.Name != tsHost {
+				return fmt.Errorf("unexpected host %s", host.Name)
+			}
+			mu.Lock()
+			created[repo]++
+			mu.Unlock()
+			if repo == "fail" {
+				return errFail
+			}
+			return nil
+		})),
+	)
+	mm, err := manifest.New(manifest.WithOrig(m))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+
+	t.Run("create once", func(t *testing.T) {
+		r, err := ref.New(tsHost + "/create:" + putTag)
+		if err != nil {
+			t.Fatalf("failed to create ref: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			err = reg.ManifestPut(ctx, r, mm)
+			if err != nil {
+				t.Fatalf("failed to put manifest: %v", err)
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if created["create"] != 1 {
+			t.Errorf("repository create called %d times", created["create"])
+		}
+	})
+	t.Run("create failed", func(t *testing.T) {
+		r, err := ref.New(tsHost + "/fail:" + putTag)
+		if err != nil {
+			t.Fatalf("failed to create ref: %v", err)
+		}
+		err = reg.ManifestPut(ctx, r, mm)
+		if !errors.Is(err, errFail) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("unknown creator", func(t *testing.T) {
+		r, err := ref.New("unknown." + tsHost + "/unknown:" + putTag)
+		if err != nil {
+			t.Fatalf("failed to create ref: %v", err)
+		}
+		err = reg.ManifestPut(ctx, r, mm)
+		if !errors.Is(err, types.ErrNotImplemented) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package reg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reghttp"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// RepoDeleter deletes a repository with a vendor API.
+// The deleter is selected with the repoDelete setting of the host.
+type RepoDeleter interface {
+	// RepoDelete deletes the repository and all of the content in it.
+	RepoDelete(ctx context.Context, host *config.Host, repo string) error
+}
+
+// RepoDeleterFunc is a function that implements [RepoDeleter].
+type RepoDeleterFunc func(ctx context.Context, host *config.Host, repo string) error
+
+// RepoDelete implements [RepoDeleter].
+func (f RepoDeleterFunc) RepoDelete(ctx context.Context, host *config.Host, repo string) error {
+	return f(ctx, host, repo)
+}
+
+// RepoDelete deletes a repository with the deleter configured for the host.
+// Registries without a configured deleter return [types.ErrNotImplemented].
+func (reg *Reg) RepoDelete(ctx context.Context, r ref.Ref) error {
+	host := reg.hostGet(r.Registry)
+	if host.RepoDelete == "" {
+		return fmt.Errorf("repository delete is not configured for host %s%.0w", host.Name, types.ErrNotImplemented)
+	}
+	reg.muHost.Lock()
+	rd, ok := reg.repoDeleters[host.RepoDelete]
+	reg.muHost.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown repository deleter %s for host %s%.0w", host.RepoDelete, host.Name, types.ErrNotImplemented)
+	}
+	err := rd.RepoDelete(ctx, host, r.Repository)
+	if err != nil {
+		return fmt.Errorf("failed to delete repository %s: %w", r.CommonName(), err)
+	}
+	reg.log.WithFields(logrus.Fields{
+		"host": host.Name,
+		"repo": r.Repository,
+	}).Debug("Repository deleted")
+	return nil
+}
+
+// repoDeleteArtifactory deletes the path of a repository with the Artifactory API.
+// The first component of the repository is the Artifactory repository key.
+func (reg *Reg) repoDeleteArtifactory(ctx context.Context, host *config.Host, repo string) error {
+	key, path, ok := strings.Cut(repo, "/")
+	if !ok {
+		return fmt.Errorf("repository %s does not include the Artifactory repository key%.0w", repo, types.ErrInvalidReference)
+	}
+	u := repoDeleteURL(host, host.Hostname) + "/artifactory/" + url.PathEscape(key) + "/" + repoDeletePathEscape(path)
+	return reg.repoDeleteReq(ctx, host, http.MethodDelete, u, nil)
+}
+
+// repoDeleteGitLab deletes a repository with the GitLab container registry API.
+// The API is on the registry hostname without the "registry." prefix, e.g. gitlab.com for registry.gitlab.com.
+// The project is found by searching the repository path from the longest prefix.
+func (reg *Reg) repoDeleteGitLab(ctx context.Context, host *config.Host, repo string) error {
+	base := repoDeleteURL(host, strings.TrimPrefix(host.Hostname, "registry.")) + "/api/v4/projects/"
+	parts := strings.Split(repo, "/")
+	for i := len(parts); i > 0; i-- {
+		project := strings.Join(parts[:i], "/")
+		repos := []struct {
+			ID   int    `json:"id"`
+			Path string `json:"path"`
+		}{}
+		err := reg.repoDeleteReq(ctx, host, http.MethodGet, base+url.PathEscape(project)+"/registry/repositories?per_page=100", &repos)
+		if err != nil && errors.Is(err, types.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		for _, r := range repos {
+			if r.Path == repo {
+				return reg.repoDeleteReq(ctx, host, http.MethodDelete, base+url.PathEscape(project)+"/registry/repositories/"+strconv.Itoa(r.ID), nil)
+			}
+		}
+	}
+	return fmt.Errorf("GitLab repository %s%.0w", repo, types.ErrNotFound)
+}
+
+// repoDeleteHarbor deletes a repository with the Harbor API.
+// The first component of the repository is the Harbor project.
+func (reg *Reg) repoDeleteHarbor(ctx context.Context, host *config.Host, repo string) error {
+	project, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return fmt.Errorf("repository %s does not include the Harbor project%.0w", repo, types.ErrInvalidReference)
+	}
+	// Harbor requires the slashes in the repository name to be escaped twice
+	u := repoDeleteURL(host, host.Hostname) + "/api/v2.0/projects/" + url.PathEscape(project) + "/repositories/" + url.PathEscape(url.PathEscape(name))
+	return reg.repoDeleteReq(ctx, host, http.MethodDelete, u, nil)
+}
+
+// repoDeleteURL returns the base URL for a vendor API on the hostname.
+func repoDeleteURL(host *config.Host, hostname string) string {
+	if host.TLS == config.TLSDisabled {
+		return "http://" + hostname
+	}
+	return "https://" + hostname
+}
+
+// repoDeletePathEscape escapes each component of a path.
+func repoDeletePathEscape(path string) string {
+	parts := strings.Split(path, "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return strings.Join(parts, "/")
+}
+
+// repoDeleteReq sends a request to a vendor API with the login for the host, decoding the response into result when set.
+// The request uses the transport of the host and does not follow redirects to another host with the login.
+func (reg *Reg) repoDeleteReq(ctx context.Context, host *config.Host, method, u string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	cred := host.GetCred()
+	if host.RepoDelete == config.RepoDeleteGitLab && cred.Password != "" {
+		req.Header.Set("PRIVATE-TOKEN", cred.Password)
+	} else if cred.User != "" && cred.Password != "" {
+		req.SetBasicAuth(cred.User, cred.Password)
+	}
+	resp, err := reg.reghttp.HTTPClient(host.Name).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %w", method, u, reghttp.HTTPError(resp.StatusCode))
+	}
+	if result == nil {
+		return nil
+	}
+	err = json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(result)
+	if err != nil {
+		return fmt.Errorf("failed to parse response from %s: %w", u, err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package reg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestRepoDelete(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var mu sync.Mutex
+	reqs := []string{}
+	// tsOther is a different host that must not receive redirected requests with the login
+	otherReqs := 0
+	tsOther := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		otherReqs++
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(tsOther.Close)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqs = append(reqs, r.Method+" "+r.RequestURI)
+		mu.Unlock()
+		switch r.Method + " " + r.RequestURI {
+		case "GET /api/v4/projects/group%2Fproject%2Fimage/registry/repositories?per_page=100":
+			w.WriteHeader(http.StatusNotFound)
+		case "GET /api/v4/projects/group%2Fproject/registry/repositories?per_page=100":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[{"id":41,"path":"group/project"},{"id":42,"path":"group/project/image"}]`)
+		case "DELETE /api/v4/projects/group%2Fproject/registry/repositories/42":
+			w.WriteHeader(http.StatusAccepted)
+		case "DELETE /api/v2.0/projects/library/repositories/team%252Fapp":
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "DELETE /artifactory/docker-local/team/app":
+			w.WriteHeader(http.StatusNoContent)
+		case "DELETE /artifactory/docker-local/redirect":
+			http.Redirect(w, r, tsOther.URL+r.RequestURI, http.StatusTemporaryRedirect)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:       "harbor." + tsHost,
+			Hostname:   tsHost,
+			TLS:        config.TLSDisabled,
+			User:       "user",
+			Pass:       "pass",
+			RepoDelete: config.RepoDeleteHarbor,
+		},
+		{
+			Name:       "gitlab." + tsHost,
+			Hostname:   tsHost,
+			TLS:        config.TLSDisabled,
+			RepoDelete: config.RepoDeleteGitLab,
+		},
+		{
+			Name:       "artifactory." + tsHost,
+			Hostname:   tsHost,
+			TLS:        config.TLSDisabled,
+			RepoDelete: config.RepoDeleteArtifactory,
+		},
+		{
+			Name:       "unknown." + tsHost,
+			Hostname:   tsHost,
+			TLS:        config.TLSDisabled,
+			RepoDelete: "unknown",
+		},
+		{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.WarnLevel,
+	}
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithLog(log),
+		WithDelay(time.Millisecond*5, time.Millisecond*10),
+	)
+
+	tt := []struct {
+		name      string
+		ref       string
+		expectErr error
+		expectReq string
+	}{
+		{
+			name:      "harbor",
+			ref:       "harbor." + tsHost + "/library/team/app",
+			expectReq: "DELETE /api/v2.0/projects/library/repositories/team%252Fapp",
+		},
+		{
+			name:      "harbor missing project",
+			ref:       "harbor." + tsHost + "/app",
+			expectErr: types.ErrInvalidReference,
+		},
+		{
+			name:      "gitlab",
+			ref:       "gitlab." + tsHost + "/group/project/image",
+			expectReq: "DELETE /api/v4/projects/group%2Fproject/registry/repositories/42",
+		},
+		{
+			name:      "gitlab missing",
+			ref:       "gitlab." + tsHost + "/other/image",
+			expectErr: types.ErrNotFound,
+		},
+		{
+			name:      "artifactory",
+			ref:       "artifactory." + tsHost + "/docker-local/team/app",
+			expectReq: "DELETE /artifactory/docker-local/team/app",
+		},
+		{
+			name:      "redirect to another host",
+			ref:       "artifactory." + tsHost + "/docker-local/redirect",
+			expectErr: types.ErrRedirectDenied,
+		},
+		{
+			name:      "unknown deleter",
+			ref:       "unknown." + tsHost + "/team/app",
+			expectErr: types.ErrNotImplemented,
+		},
+		{
+			name:      "not configured",
+			ref:       tsHost + "/team/app",
+			expectErr: types.ErrNotImplemented,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ref.New(tc.ref)
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+			err = reg.RepoDelete(ctx, r)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to delete repository: %v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			found := false
+			for _, req := range reqs {
+				if req == tc.expectReq {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("request not received: %s, received %v", tc.expectReq, reqs)
+			}
+		})
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if otherReqs != 0 {
+		t.Errorf("redirect was followed to another host, %d requests received", otherReqs)
+	}
+}
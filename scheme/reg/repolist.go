@@ -0,0 +1,237 @@
+package reg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reghttp"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/repo"
+)
+
+// dockerHubAPI is the Docker Hub API used to list repositories in a namespace.
+var dockerHubAPI = "https://hub.docker.com"
+
+// RepoLister lists repositories on registries that do not support the _catalog API.
+// The lister is selected with the repoList setting of the host.
+type RepoLister interface {
+	// RepoList returns every repository on the host, limited to the namespace from the config when set.
+	// Pagination from the config is applied by the reg scheme to the returned list.
+	RepoList(ctx context.Context, host *config.Host, conf scheme.RepoConfig) ([]string, error)
+}
+
+// RepoListerFunc is a function that implements [RepoLister].
+type RepoListerFunc func(ctx context.Context, host *config.Host, conf scheme.RepoConfig) ([]string, error)
+
+// RepoList implements [RepoLister].
+func (f RepoListerFunc) RepoList(ctx context.Context, host *config.Host, conf scheme.RepoConfig) ([]string, error) {
+	return f(ctx, host, conf)
+}
+
+// repoListProvider lists repositories with the lister configured for the host.
+func (reg *Reg) repoListProvider(ctx context.Context, host *config.Host, conf scheme.RepoConfig) (*repo.RepoList, error) {
+	reg.muHost.Lock()
+	rl, ok := reg.repoListers[host.RepoList]
+	reg.muHost.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown repository lister %s for host %s%.0w", host.RepoList, host.Name, types.ErrNotImplemented)
+	}
+	repos, err := rl.RepoList(ctx, host, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories for %s: %w", host.Name, err)
+	}
+	// apply pagination to the sorted list
+	sort.Strings(repos)
+	if conf.Last != "" {
+		i := sort.SearchStrings(repos, conf.Last)
+		if i < len(repos) && repos[i] == conf.Last {
+			i++
+		}
+		repos = repos[i:]
+	}
+	if conf.Limit > 0 && len(repos) > conf.Limit {
+		repos = repos[:conf.Limit]
+	}
+	body, err := json.Marshal(repo.RepoRegistryList{Repositories: repos})
+	if err != nil {
+		return nil, err
+	}
+	return repo.New(
+		repo.WithMT("application/json"),
+		repo.WithRaw(body),
+		repo.WithHost(host.Name),
+	)
+}
+
+// repoListDockerHub lists the repositories in a Docker Hub namespace.
+// Private repositories are included when a login is configured for the host.
+// Requests use the transport of the host and do not follow redirects to another host with the login.
+func (reg *Reg) repoListDockerHub(ctx context.Context, host *config.Host, conf scheme.RepoConfig) ([]string, error) {
+	if conf.Namespace == "" {
+		return nil, fmt.Errorf("a namespace is required to list repositories on Docker Hub%.0w", types.ErrMissingName)
+	}
+	hc := reg.reghttp.HTTPClient(host.Name)
+	auth := ""
+	cred := host.GetCred()
+	if cred.User != "" && cred.Password != "" {
+		token, err := dockerHubLogin(ctx, hc, cred)
+		if err != nil {
+			return nil, err
+		}
+		auth = "Bearer " + token
+	}
+	repos := []string{}
+	next := dockerHubAPI + "/v2/repositories/" + url.PathEscape(conf.Namespace) + "/?page_size=100"
+	for next != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		page := struct {
+			Next    string `json:"next"`
+			Results []struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"results"`
+		}{}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("Docker Hub repository list for %s: %w", conf.Namespace, reghttp.HTTPError(resp.StatusCode))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Docker Hub repository list: %w", err)
+		}
+		for _, r := range page.Results {
+			ns := r.Namespace
+			if ns == "" {
+				ns = conf.Namespace
+			}
+			repos = append(repos, ns+"/"+r.Name)
+		}
+		next = page.Next
+	}
+	return repos, nil
+}
+
+// dockerHubLogin exchanges a login for a token to the Docker Hub API.
+func dockerHubLogin(ctx context.Context, hc *http.Client, cred config.Cred) (string, error) {
+	body, err := json.Marshal(struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{
+		Username: cred.User,
+		Password: cred.Password,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dockerHubAPI+"/v2/users/login", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := hc.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Docker Hub login: %w", reghttp.HTTPError(resp.StatusCode))
+	}
+	tokenResp := struct {
+		Token string `json:"token"`
+	}{}
+	err = json.NewDecoder(io.LimitReader(resp.Body, 65536)).Decode(&tokenResp)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse Docker Hub login: %w", err)
+	}
+	return tokenResp.Token, nil
+}
+
+// repoListECR lists repositories with the AWS ECR API.
+func repoListECR(ctx context.Context, host *config.Host, conf scheme.RepoConfig) ([]string, error) {
+	repos, err := config.ECRRepoList(ctx, host.Hostname)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Namespace == "" {
+		return repos, nil
+	}
+	result := []string{}
+	for _, r := range repos {
+		if strings.HasPrefix(r, conf.Namespace+"/") {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// repoListGCR lists the repositories in a project by walking the child repositories in each tag listing.
+func (reg *Reg) repoListGCR(ctx context.Context, host *config.Host, conf scheme.RepoConfig) ([]string, error) {
+	if conf.Namespace == "" {
+		return nil, fmt.Errorf("a project namespace is required to list repositories on %s%.0w", host.Name, types.ErrMissingName)
+	}
+	repos := []string{}
+	pending := []string{conf.Namespace}
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		req := &reghttp.Req{
+			Host:      host.Name,
+			NoMirrors: true,
+			APIs: map[string]reghttp.ReqAPI{
+				"": {
+					Method:     "GET",
+					Repository: name,
+					Path:       "tags/list",
+					Headers:    http.Header{"Accept": []string{"application/json"}},
+				},
+			},
+		}
+		resp, err := reg.reghttp.Do(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags for %s: %w", name, err)
+		}
+		list := struct {
+			Child    []string                   `json:"child"`
+			Manifest map[string]json.RawMessage `json:"manifest"`
+			Tags     []string                   `json:"tags"`
+		}{}
+		if resp.HTTPResponse().StatusCode != http.StatusOK {
+			resp.Close()
+			return nil, fmt.Errorf("failed to list tags for %s: %w", name, reghttp.HTTPError(resp.HTTPResponse().StatusCode))
+		}
+		err = json.NewDecoder(resp).Decode(&list)
+		resp.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tag list for %s: %w", name, err)
+		}
+		// a path with only child repositories is not a repository
+		if len(list.Tags) > 0 || len(list.Manifest) > 0 {
+			repos = append(repos, name)
+		}
+		for _, child := range list.Child {
+			pending = append(pending, name+"/"+child)
+		}
+	}
+	return repos, nil
+}
@@ -0,0 +1,203 @@
+package reg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+)
+
+func TestRepoListProvider(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GCR project",
+				Method: "GET",
+				Path:   "/v2/proj/tags/list",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   []byte(`{"child":["app","base"],"manifest":{},"name":"proj","tags":[]}`),
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GCR app",
+				Method: "GET",
+				Path:   "/v2/proj/app/tags/list",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   []byte(`{"child":["debug"],"manifest":{},"name":"proj/app","tags":["v1"]}`),
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GCR app debug",
+				Method: "GET",
+				Path:   "/v2/proj/app/debug/tags/list",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   []byte(`{"child":[],"manifest":{},"name":"proj/app/debug","tags":["latest"]}`),
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GCR base",
+				Method: "GET",
+				Path:   "/v2/proj/base/tags/list",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   []byte(`{"child":[],"manifest":{"sha256:e8f1ec4d7a5b8d2b0c9e2f9c1f2c9e2d0a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1":{}},"name":"proj/base","tags":[]}`),
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+			},
+		},
+	}
+	rrs = append(rrs, reqresp.BaseEntries...)
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			RepoList: config.RepoListGCR,
+		},
+		{
+			Name:     "test." + tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			RepoList: "test",
+		},
+		{
+			Name:     "unknown." + tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			RepoList: "unknown",
+		},
+	}
+	log := &logrus.Logger{
+		Out:       os.Stderr,
+		Formatter: new(logrus.TextFormatter),
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.WarnLevel,
+	}
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithLog(log),
+		WithDelay(time.Millisecond*5, time.Millisecond*10),
+		WithRepoLister("test", RepoListerFunc(func(ctx context.Context, host *config.Host, conf scheme.RepoConfig) ([]string, error) {
+			if host.Name != "test."+tsHost {
+				return nil, fmt.Errorf("unexpected host %s", host.Name)
+			}
+			return []string{"ns/d", "ns/b", "ns/a", "ns/c"}, nil
+		})),
+	)
+
+	t.Run("gcr", func(t *testing.T) {
+		rl, err := reg.RepoList(ctx, tsHost, scheme.WithRepoNamespace("proj"))
+		if err != nil {
+			t.Fatalf("failed to list repositories: %v", err)
+		}
+		repos, err := rl.GetRepos()
+		if err != nil {
+			t.Fatalf("failed to get repositories: %v", err)
+		}
+		expect := []string{"proj/app", "proj/app/debug", "proj/base"}
+		if !stringSliceCmp(repos, expect) {
+			t.Errorf("unexpected repositories, expected %v, received %v", expect, repos)
+		}
+	})
+	t.Run("gcr missing namespace", func(t *testing.T) {
+		_, err := reg.RepoList(ctx, tsHost)
+		if !errors.Is(err, types.ErrMissingName) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("custom paginated", func(t *testing.T) {
+		rl, err := reg.RepoList(ctx, "test."+tsHost, scheme.WithRepoLast("ns/a"), scheme.WithRepoLimit(2))
+		if err != nil {
+			t.Fatalf("failed to list repositories: %v", err)
+		}
+		repos, err := rl.GetRepos()
+		if err != nil {
+			t.Fatalf("failed to get repositories: %v", err)
+		}
+		expect := []string{"ns/b", "ns/c"}
+		if !stringSliceCmp(repos, expect) {
+			t.Errorf("unexpected repositories, expected %v, received %v", expect, repos)
+		}
+	})
+	t.Run("unknown lister", func(t *testing.T) {
+		_, err := reg.RepoList(ctx, "unknown."+tsHost)
+		if !errors.Is(err, types.ErrNotImplemented) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestRepoListDockerHub(t *testing.T) {
+	// not parallel, the Docker Hub API is replaced with a test server
+	ctx := context.Background()
+	var tsURL string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/repositories/example/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprintf(w, `{"next":"%s/v2/repositories/example/?page=2&page_size=100","results":[{"name":"web","namespace":"example"}]}`, tsURL)
+			return
+		}
+		fmt.Fprint(w, `{"next":null,"results":[{"name":"api","namespace":"example"}]}`)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL = ts.URL
+	origAPI := dockerHubAPI
+	dockerHubAPI = tsURL
+	t.Cleanup(func() { dockerHubAPI = origAPI })
+
+	reg := New()
+	repos, err := reg.repoListDockerHub(ctx, &config.Host{Name: config.DockerRegistry}, scheme.RepoConfig{Namespace: "example"})
+	if err != nil {
+		t.Fatalf("failed to list repositories: %v", err)
+	}
+	expect := []string{"example/web", "example/api"}
+	if !stringSliceCmp(repos, expect) {
+		t.Errorf("unexpected repositories, expected %v, received %v", expect, repos)
+	}
+	_, err = reg.repoListDockerHub(ctx, &config.Host{Name: config.DockerRegistry}, scheme.RepoConfig{Namespace: "missing"})
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("unexpected error for missing namespace: %v", err)
+	}
+}
@@ -31,14 +31,25 @@ import (
 	"github.com/regclient/regclient/types/tag"
 )
 
-// TagDelete removes a tag from a repository.
-// It first attempts the newer OCI API to delete by tag name (not widely supported).
-// If the OCI API fails, it falls back to pushing a unique empty manifest and deleting that.
+// TagDelete removes a tag from a repository using the OCI tag delete API.
+// See [Reg.TagDeleteWithOpts] to fall back to replacing the tag with a temporary manifest.
 func (reg *Reg) TagDelete(ctx context.Context, r ref.Ref) error {
+	return reg.TagDeleteWithOpts(ctx, r)
+}
+
+// TagDeleteWithOpts removes a tag from a repository.
+// It first attempts the newer OCI API to delete by tag name (not widely supported).
+// If the OCI API fails and [scheme.WithTagManifestReplace] is set,
+// it falls back to pushing a unique empty manifest and deleting that.
+func (reg *Reg) TagDeleteWithOpts(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) error {
 	var tempManifest manifest.Manifest
 	if r.Tag == "" {
 		return types.ErrMissingTag
 	}
+	var config scheme.TagConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
 
 	// attempt to delete the tag directly, available in OCI distribution-spec, and Hub API
 	req := &reghttp.Req{
@@ -66,6 +77,9 @@ func (reg *Reg) TagDelete(ctx context.Context, r ref.Ref) error {
 	if err == nil && resp != nil && resp.HTTPResponse().StatusCode == 202 {
 		return nil
 	}
+	if !config.ManifestReplace {
+		return fmt.Errorf("registry does not support deleting the tag %s, replacing the tag with a temporary manifest was not enabled%.0w", r.CommonName(), types.ErrUnsupportedAPI)
+	}
 	// ignore errors, fallback to creating a temporary manifest to replace the tag and deleting that manifest
 
 	// lookup the current manifest media type
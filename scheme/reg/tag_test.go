@@ -374,13 +374,25 @@ func TestTag(t *testing.T) {
 		}
 	})
 
+	// fallback is not used unless a manifest replace is allowed
+	t.Run("Delete Unsupported", func(t *testing.T) {
+		delRef, err := ref.New(tsURL.Host + repoPath + ":" + delFallbackTag)
+		if err != nil {
+			t.Errorf("failed creating delRef: %v", err)
+		}
+		err = reg.TagDelete(ctx, delRef)
+		if !errors.Is(err, types.ErrUnsupportedAPI) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrUnsupportedAPI, err)
+		}
+	})
+
 	// delete tag with fallback manifest delete
 	t.Run("Delete Fallback", func(t *testing.T) {
 		delRef, err := ref.New(tsURL.Host + repoPath + ":" + delFallbackTag)
 		if err != nil {
 			t.Errorf("failed creating delRef: %v", err)
 		}
-		err = reg.TagDelete(ctx, delRef)
+		err = reg.TagDeleteWithOpts(ctx, delRef, scheme.WithTagManifestReplace())
 		if err != nil {
 			t.Errorf("failed to delete tag: %v", err)
 			return
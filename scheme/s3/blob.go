@@ -0,0 +1,259 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	// crypto libraries included for go-digest
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// BlobDelete removes a blob from the repository
+func (s *S3) BlobDelete(ctx context.Context, r ref.Ref, d types.Descriptor) error {
+	bucket, key := bucketKey(r, blobFile(d.Digest))
+	return s.objDelete(ctx, bucket, key)
+}
+
+// BlobGet retrieves a blob, returning a reader
+func (s *S3) BlobGet(ctx context.Context, r ref.Ref, d types.Descriptor) (blob.Reader, error) {
+	bucket, key := bucketKey(r, blobFile(d.Digest))
+	resp, err := s.do(ctx, http.MethodGet, bucket, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if d.Size <= 0 {
+		d.Size = resp.ContentLength
+	}
+	br := blob.NewReader(
+		blob.WithRef(r),
+		blob.WithReader(resp.Body),
+		blob.WithDesc(d),
+	)
+	s.log.WithFields(logrus.Fields{
+		"ref": r.CommonName(),
+		"key": key,
+	}).Debug("retrieved blob")
+	return br, nil
+}
+
+// BlobGetRange retrieves part of a blob using an HTTP range request.
+// A negative length reads to the end of the blob.
+func (s *S3) BlobGetRange(ctx context.Context, r ref.Ref, d types.Descriptor, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid blob range offset %d", offset)
+	}
+	if length == 0 {
+		return io.NopCloser(bytes.NewReader([]byte{})), nil
+	}
+	headers := http.Header{}
+	if length > 0 {
+		headers.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	} else {
+		headers.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	bucket, key := bucketKey(r, blobFile(d.Digest))
+	resp, err := s.do(ctx, http.MethodGet, bucket, key, nil, headers, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("range request not supported for %s/%s%.0w", bucket, key, types.ErrUnsupported)
+	}
+	return resp.Body, nil
+}
+
+// BlobHead verifies the existence of a blob, the reader contains the headers but no body to read
+func (s *S3) BlobHead(ctx context.Context, r ref.Ref, d types.Descriptor) (blob.Reader, error) {
+	bucket, key := bucketKey(r, blobFile(d.Digest))
+	size, _, err := s.objHead(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if d.Size <= 0 {
+		d.Size = size
+	}
+	br := blob.NewReader(
+		blob.WithRef(r),
+		blob.WithDesc(d),
+	)
+	return br, nil
+}
+
+// BlobMount attempts to perform a server side copy of the blob
+func (s *S3) BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d types.Descriptor) error {
+	return types.ErrUnsupported
+}
+
+// BlobPut sends a blob to the repository, returns the digest and size when successful.
+// Blobs larger than the part size are sent with a multipart upload.
+// When the digest is not known in advance, the upload is made to a temporary key and copied after the digest is computed.
+func (s *S3) BlobPut(ctx context.Context, r ref.Ref, d types.Descriptor, rdr io.Reader) (types.Descriptor, error) {
+	t := s.throttleGet(r)
+	err := t.Acquire(ctx)
+	if err != nil {
+		return d, err
+	}
+	defer t.Release(ctx)
+
+	err = s.layoutInit(ctx, r)
+	if err != nil {
+		return d, err
+	}
+	alg := s.digestAlg
+	if d.Digest != "" {
+		if err := d.Digest.Validate(); err != nil {
+			return d, err
+		}
+		alg = d.Digest.Algorithm()
+	}
+	digester := alg.Digester()
+	bucket, _ := bucketKey(r)
+
+	// the first part is buffered to send small blobs in a single request
+	buf := make([]byte, s.partSize)
+	n, err := io.ReadFull(rdr, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return d, err
+	}
+	var key string
+	if err != nil {
+		// the entire blob fits in one part
+		_, _ = digester.Hash().Write(buf[:n])
+		d, err = blobVerify(d, digester.Digest(), int64(n))
+		if err != nil {
+			return d, err
+		}
+		_, key = bucketKey(r, blobFile(d.Digest))
+		err = s.objPut(ctx, bucket, key, buf[:n], "", "", false)
+		if err != nil {
+			return d, err
+		}
+	} else {
+		tmpKey := ""
+		if d.Digest != "" {
+			_, key = bucketKey(r, blobFile(d.Digest))
+		} else {
+			name, err := tmpName()
+			if err != nil {
+				return d, err
+			}
+			_, tmpKey = bucketKey(r, "blobs", alg.String(), name)
+			key = tmpKey
+		}
+		err = s.blobPutMultipart(ctx, bucket, key, rdr, buf, digester, func(size int64) error {
+			// verify before completing the upload so invalid content is never stored
+			var err error
+			d, err = blobVerify(d, digester.Digest(), size)
+			return err
+		})
+		if err != nil {
+			return d, err
+		}
+		if tmpKey != "" {
+			_, key = bucketKey(r, blobFile(d.Digest))
+			err = s.objCopy(ctx, bucket, tmpKey, key)
+			errD := s.objDelete(context.Background(), bucket, tmpKey)
+			if err != nil {
+				return d, fmt.Errorf("failed to copy blob from %s to %s: %w", tmpKey, key, err)
+			}
+			if errD != nil {
+				s.log.WithFields(logrus.Fields{
+					"key": tmpKey,
+					"err": errD,
+				}).Warn("failed to delete temporary blob")
+			}
+		}
+	}
+	s.log.WithFields(logrus.Fields{
+		"ref": r.CommonName(),
+		"key": key,
+	}).Debug("pushed blob")
+	return d, nil
+}
+
+// blobPutMultipart sends the buffered first part followed by the remainder of the reader.
+// The finish function is called with the total size before completing the upload, and the upload is aborted on any failure.
+func (s *S3) blobPutMultipart(ctx context.Context, bucket, key string, rdr io.Reader, buf []byte, digester digest.Digester, finish func(size int64) error) error {
+	uploadID, err := s.multipartCreate(ctx, bucket, key, "")
+	if err != nil {
+		return err
+	}
+	abort := func(err error) error {
+		errA := s.multipartAbort(context.Background(), bucket, key, uploadID)
+		if errA != nil {
+			s.log.WithFields(logrus.Fields{
+				"key": key,
+				"err": errA,
+			}).Warn("failed to abort multipart upload")
+		}
+		return err
+	}
+	parts := []completePart{}
+	size := int64(0)
+	n := len(buf)
+	for n > 0 {
+		_, _ = digester.Hash().Write(buf[:n])
+		part, err := s.multipartPart(ctx, bucket, key, uploadID, len(parts)+1, buf[:n])
+		if err != nil {
+			return abort(err)
+		}
+		parts = append(parts, part)
+		size += int64(n)
+		n, err = io.ReadFull(rdr, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return abort(err)
+		}
+	}
+	err = finish(size)
+	if err != nil {
+		return abort(err)
+	}
+	err = s.multipartComplete(ctx, bucket, key, uploadID, parts)
+	if err != nil {
+		return abort(err)
+	}
+	return nil
+}
+
+// blobVerify validates the computed digest and size against the descriptor, or fills in the descriptor when undefined.
+func blobVerify(d types.Descriptor, dig digest.Digest, size int64) (types.Descriptor, error) {
+	if d.Digest == "" {
+		d.Digest = dig
+	} else if d.Digest != dig {
+		return d, fmt.Errorf("unexpected digest, expected %s, computed %s%.0w", d.Digest, dig, types.ErrDigestMismatch)
+	}
+	if d.Size <= 0 {
+		d.Size = size
+	} else if d.Size != size {
+		return d, fmt.Errorf("unexpected blob length, expected %d, received %d%.0w", d.Size, size, types.ErrMismatch)
+	}
+	return d, nil
+}
+
+func blobFile(d digest.Digest) string {
+	return "blobs/" + d.Algorithm().String() + "/" + d.Encoded()
+}
+
+func tmpName() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b) + ".tmp", nil
+}
@@ -0,0 +1,240 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/regclient/regclient/types"
+)
+
+// errConflict is returned when a conditional write fails because the object was modified.
+var errConflict = errors.New("conditional write conflict")
+
+// s3Error is the body of an S3 error response.
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+type initiateMultipartResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completeMultipart struct {
+	XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+	Parts   []completePart `xml:"Part"`
+}
+
+type completePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// objURL returns the URL for a key in the bucket, an empty key refers to the bucket.
+func (s *S3) objURL(bucket, key string, query url.Values) (*url.URL, error) {
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endpoint %s: %w", s.endpoint, err)
+	}
+	p := "/" + key
+	if s.pathStyle {
+		p = "/" + bucket
+		if key != "" {
+			p = p + "/" + key
+		}
+	} else {
+		u.Host = bucket + "." + u.Host
+	}
+	u.Path = p
+	u.RawPath = sigEscapePath(p)
+	u.RawQuery = sigQuery(query)
+	return u, nil
+}
+
+// do sends a signed request, returning an error for any non-2xx status.
+func (s *S3) do(ctx context.Context, method, bucket, key string, query url.Values, headers http.Header, body []byte) (*http.Response, error) {
+	u, err := s.objURL(bucket, key, query)
+	if err != nil {
+		return nil, err
+	}
+	var rdr io.Reader
+	if body != nil {
+		rdr = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), rdr)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	sign(req, s.creds, s.region, sigHash(body), time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to %s: %w", u.Redacted(), err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		s3Err := s3Error{}
+		_ = xml.NewDecoder(io.LimitReader(resp.Body, 1024*64)).Decode(&s3Err)
+		var errType error
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			errType = types.ErrNotFound
+		case http.StatusPreconditionFailed, http.StatusConflict:
+			errType = errConflict
+		case http.StatusUnauthorized, http.StatusForbidden:
+			errType = types.ErrHTTPUnauthorized
+		default:
+			errType = types.ErrHTTPStatus
+		}
+		return nil, fmt.Errorf("s3 %s %s/%s failed, status %d: %s %s%.0w", method, bucket, key, resp.StatusCode, s3Err.Code, s3Err.Message, errType)
+	}
+	return resp, nil
+}
+
+// objGet returns the full contents of a small object and the ETag.
+func (s *S3) objGet(ctx context.Context, bucket, key string) ([]byte, string, error) {
+	resp, err := s.do(ctx, http.MethodGet, bucket, key, nil, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s/%s: %w", bucket, key, err)
+	}
+	return b, resp.Header.Get("ETag"), nil
+}
+
+// objHead returns the size of an object.
+func (s *S3) objHead(ctx context.Context, bucket, key string) (int64, http.Header, error) {
+	resp, err := s.do(ctx, http.MethodHead, bucket, key, nil, nil, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	_ = resp.Body.Close()
+	return resp.ContentLength, resp.Header, nil
+}
+
+// objPut writes an object.
+// The etag conditionally replaces an existing object, an empty etag with create set only writes a new object.
+func (s *S3) objPut(ctx context.Context, bucket, key string, body []byte, mediaType string, etag string, create bool) error {
+	headers := http.Header{}
+	if mediaType != "" {
+		headers.Set("Content-Type", mediaType)
+	}
+	if etag != "" {
+		headers.Set("If-Match", etag)
+	} else if create {
+		headers.Set("If-None-Match", "*")
+	}
+	if body == nil {
+		body = []byte{}
+	}
+	resp, err := s.do(ctx, http.MethodPut, bucket, key, nil, headers, body)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// objCopy performs a server side copy of an object within the bucket.
+// S3 limits a single copy request to 5GiB.
+func (s *S3) objCopy(ctx context.Context, bucket, keySrc, keyTgt string) error {
+	headers := http.Header{}
+	headers.Set("X-Amz-Copy-Source", sigEscapePath("/"+bucket+"/"+keySrc))
+	resp, err := s.do(ctx, http.MethodPut, bucket, keyTgt, nil, headers, []byte{})
+	if err != nil {
+		return err
+	}
+	return respCheck(resp, bucket, keyTgt)
+}
+
+func (s *S3) objDelete(ctx context.Context, bucket, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, bucket, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+func (s *S3) multipartCreate(ctx context.Context, bucket, key, mediaType string) (string, error) {
+	headers := http.Header{}
+	if mediaType != "" {
+		headers.Set("Content-Type", mediaType)
+	}
+	resp, err := s.do(ctx, http.MethodPost, bucket, key, url.Values{"uploads": []string{""}}, headers, []byte{})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	result := initiateMultipartResult{}
+	err = xml.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse multipart upload response: %w", err)
+	}
+	if result.UploadID == "" {
+		return "", fmt.Errorf("multipart upload id missing for %s/%s%.0w", bucket, key, types.ErrParsingFailed)
+	}
+	return result.UploadID, nil
+}
+
+func (s *S3) multipartPart(ctx context.Context, bucket, key, uploadID string, num int, body []byte) (completePart, error) {
+	query := url.Values{
+		"partNumber": []string{strconv.Itoa(num)},
+		"uploadId":   []string{uploadID},
+	}
+	resp, err := s.do(ctx, http.MethodPut, bucket, key, query, nil, body)
+	if err != nil {
+		return completePart{}, err
+	}
+	_ = resp.Body.Close()
+	return completePart{PartNumber: num, ETag: resp.Header.Get("ETag")}, nil
+}
+
+func (s *S3) multipartComplete(ctx context.Context, bucket, key, uploadID string, parts []completePart) error {
+	body, err := xml.Marshal(completeMultipart{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPost, bucket, key, url.Values{"uploadId": []string{uploadID}}, nil, body)
+	if err != nil {
+		return err
+	}
+	return respCheck(resp, bucket, key)
+}
+
+func (s *S3) multipartAbort(ctx context.Context, bucket, key, uploadID string) error {
+	resp, err := s.do(ctx, http.MethodDelete, bucket, key, url.Values{"uploadId": []string{uploadID}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// respCheck closes the response, returning an error for requests that fail after returning a 200 status.
+func respCheck(resp *http.Response, bucket, key string) error {
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1024*64))
+	if err != nil {
+		return err
+	}
+	s3Err := s3Error{}
+	if xml.Unmarshal(b, &s3Err) == nil && s3Err.Code != "" {
+		return fmt.Errorf("s3 request for %s/%s failed: %s %s%.0w", bucket, key, s3Err.Code, s3Err.Message, types.ErrHTTPStatus)
+	}
+	return nil
+}
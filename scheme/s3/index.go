@@ -0,0 +1,155 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/types"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// layoutInit creates the oci-layout file if it does not exist.
+func (s *S3) layoutInit(ctx context.Context, r ref.Ref) error {
+	bucket, key := bucketKey(r, imageLayoutFile)
+	_, _, err := s.objHead(ctx, bucket, key)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, types.ErrNotFound) {
+		return err
+	}
+	lb, err := json.Marshal(v1.ImageLayout{Version: "1.0.0"})
+	if err != nil {
+		return fmt.Errorf("cannot marshal layout: %w", err)
+	}
+	err = s.objPut(ctx, bucket, key, lb, "application/json", "", true)
+	if err != nil && !errors.Is(err, errConflict) {
+		return fmt.Errorf("cannot create %s: %w", imageLayoutFile, err)
+	}
+	return nil
+}
+
+// indexRead returns the index.json and the ETag used for conditional updates.
+func (s *S3) indexRead(ctx context.Context, r ref.Ref) (v1.Index, string, error) {
+	index := v1.Index{}
+	bucket, key := bucketKey(r, indexFile)
+	ib, etag, err := s.objGet(ctx, bucket, key)
+	if err != nil {
+		return index, "", fmt.Errorf("%s cannot be read: %w", key, err)
+	}
+	err = json.Unmarshal(ib, &index)
+	if err != nil {
+		return index, "", fmt.Errorf("%s cannot be parsed: %w", key, err)
+	}
+	return index, etag, nil
+}
+
+// indexUpdate applies a change to the index.json, retrying when another writer modifies the index.
+// The update function returns false when the index does not need to be written.
+func (s *S3) indexUpdate(ctx context.Context, r ref.Ref, update func(index *v1.Index) (bool, error)) error {
+	bucket, key := bucketKey(r, indexFile)
+	for i := 0; ; i++ {
+		index, etag, err := s.indexRead(ctx, r)
+		if err != nil && !errors.Is(err, types.ErrNotFound) {
+			return err
+		} else if err != nil {
+			index = indexCreate()
+		}
+		changed, err := update(&index)
+		if err != nil || !changed {
+			return err
+		}
+		ib, err := json.Marshal(index)
+		if err != nil {
+			return fmt.Errorf("failed to marshal index: %w", err)
+		}
+		err = s.objPut(ctx, bucket, key, ib, types.MediaTypeOCI1ManifestList, etag, true)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, errConflict) || i >= indexRetryLimit {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+		s.log.WithFields(logrus.Fields{
+			"ref":     r.CommonName(),
+			"attempt": i + 1,
+		}).Debug("index modified by another writer, retrying update")
+	}
+}
+
+func indexCreate() v1.Index {
+	return v1.Index{
+		Versioned:   v1.IndexSchemaVersion,
+		MediaType:   types.MediaTypeOCI1ManifestList,
+		Manifests:   []types.Descriptor{},
+		Annotations: map[string]string{},
+	}
+}
+
+func indexGet(index v1.Index, r ref.Ref) (types.Descriptor, error) {
+	if r.Digest == "" && r.Tag == "" {
+		r.Tag = "latest"
+	}
+	if r.Digest != "" {
+		for _, im := range index.Manifests {
+			if im.Digest.String() == r.Digest {
+				return im, nil
+			}
+		}
+	} else if r.Tag != "" {
+		for _, im := range index.Manifests {
+			if name, ok := im.Annotations[aOCIRefName]; ok && name == r.Tag {
+				return im, nil
+			}
+		}
+		// fall back to support full image name in annotation
+		for _, im := range index.Manifests {
+			if name, ok := im.Annotations[aOCIRefName]; ok && strings.HasSuffix(name, ":"+r.Tag) {
+				return im, nil
+			}
+		}
+	}
+	return types.Descriptor{}, types.ErrNotFound
+}
+
+// indexSet adds or replaces the descriptor for the tag, or the untagged digest.
+func indexSet(index *v1.Index, r ref.Ref, d types.Descriptor) {
+	if r.Tag != "" {
+		d.Annotations = map[string]string{
+			aOCIRefName: r.Tag,
+		}
+	} else if _, ok := d.Annotations[aOCIRefName]; ok {
+		// do not carry over a tag from the source of the manifest
+		annotations := map[string]string{}
+		for k, v := range d.Annotations {
+			if k != aOCIRefName {
+				annotations[k] = v
+			}
+		}
+		d.Annotations = annotations
+	}
+	if index.Manifests == nil {
+		index.Manifests = []types.Descriptor{}
+	}
+	pos := -1
+	for i := len(index.Manifests) - 1; i >= 0; i-- {
+		name := index.Manifests[i].Annotations[aOCIRefName]
+		// entries with a matching tag, or untagged entries with a matching digest are replaced
+		if (name == "" && index.Manifests[i].Digest == d.Digest) || (r.Tag != "" && name == r.Tag) {
+			if pos >= 0 {
+				index.Manifests = append(index.Manifests[:pos], index.Manifests[pos+1:]...)
+			}
+			pos = i
+		}
+	}
+	if pos >= 0 {
+		index.Manifests[pos] = d
+	} else {
+		index.Manifests = append(index.Manifests, d)
+	}
+}
@@ -0,0 +1,199 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// ManifestDelete removes a manifest, including all tags that point to that manifest
+func (s *S3) ManifestDelete(ctx context.Context, r ref.Ref, opts ...scheme.ManifestOpts) error {
+	if r.Digest == "" {
+		return fmt.Errorf("digest required to delete manifest, reference %s%.0w", r.CommonName(), types.ErrMissingDigest)
+	}
+	mc := scheme.ManifestConfig{}
+	for _, opt := range opts {
+		opt(&mc)
+	}
+
+	// always check for refers
+	if mc.Manifest == nil {
+		m, err := s.ManifestGet(ctx, r)
+		if err != nil {
+			return fmt.Errorf("failed to pull manifest for refers: %w", err)
+		}
+		mc.Manifest = m
+	}
+	if ms, ok := mc.Manifest.(manifest.Subjecter); ok {
+		sDesc, err := ms.GetSubject()
+		if err == nil && sDesc != nil && sDesc.MediaType != "" && sDesc.Size > 0 {
+			// attempt to delete the referrer, but ignore if the referrer entry wasn't found
+			err = s.referrerDelete(ctx, r, mc.Manifest)
+			if err != nil && !errors.Is(err, types.ErrNotFound) {
+				return err
+			}
+		}
+	}
+
+	// remove every entry for the digest from the index
+	err := s.indexUpdate(ctx, r, func(index *v1.Index) (bool, error) {
+		changed := false
+		for i := len(index.Manifests) - 1; i >= 0; i-- {
+			if index.Manifests[i].Digest.String() == r.Digest {
+				index.Manifests = append(index.Manifests[:i], index.Manifests[i+1:]...)
+				changed = true
+			}
+		}
+		return changed, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	bucket, key := bucketKey(r, blobFile(digest.Digest(r.Digest)))
+	err = s.objDelete(ctx, bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete manifest: %w", err)
+	}
+	return nil
+}
+
+// ManifestGet retrieves a manifest from a repository
+func (s *S3) ManifestGet(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	desc, err := s.manifestDesc(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key := bucketKey(r, blobFile(desc.Digest))
+	mb, _, err := s.objGet(ctx, bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if desc.Size == 0 {
+		desc.Size = int64(len(mb))
+	}
+	s.log.WithFields(logrus.Fields{
+		"ref": r.CommonName(),
+		"key": key,
+	}).Debug("retrieved manifest")
+	return manifest.New(
+		manifest.WithRef(r),
+		manifest.WithDesc(desc),
+		manifest.WithRaw(mb),
+	)
+}
+
+// ManifestHead gets metadata about the manifest (existence, digest, mediatype, size)
+func (s *S3) ManifestHead(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	desc, err := s.manifestDesc(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if desc.MediaType == "" {
+		// manifests missing from the index need to be pulled for the media type
+		m, err := s.ManifestGet(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		desc = m.GetDescriptor()
+	} else {
+		// verify the manifest exists
+		bucket, key := bucketKey(r, blobFile(desc.Digest))
+		_, _, err = s.objHead(ctx, bucket, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifest.New(
+		manifest.WithRef(r),
+		manifest.WithDesc(desc),
+	)
+}
+
+// manifestDesc returns the descriptor from the index, or a descriptor with only the digest when missing.
+func (s *S3) manifestDesc(ctx context.Context, r ref.Ref) (types.Descriptor, error) {
+	if r.Digest == "" && r.Tag == "" {
+		r.Tag = "latest"
+	}
+	index, _, err := s.indexRead(ctx, r)
+	if err != nil && (r.Digest == "" || !errors.Is(err, types.ErrNotFound)) {
+		return types.Descriptor{}, fmt.Errorf("unable to read oci index: %w", err)
+	}
+	desc, err := indexGet(index, r)
+	if err != nil {
+		if r.Digest == "" {
+			return desc, err
+		}
+		desc = types.Descriptor{Digest: digest.Digest(r.Digest)}
+	}
+	if desc.Digest == "" {
+		return desc, types.ErrNotFound
+	}
+	return desc, nil
+}
+
+// ManifestPut sends a manifest to the repository
+func (s *S3) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest, opts ...scheme.ManifestOpts) error {
+	config := scheme.ManifestConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if !config.Child && r.Digest == "" && r.Tag == "" {
+		r.Tag = "latest"
+	}
+	err := s.layoutInit(ctx, r)
+	if err != nil {
+		return err
+	}
+	desc := m.GetDescriptor()
+	b, err := m.RawBody()
+	if err != nil {
+		return fmt.Errorf("could not serialize manifest: %w", err)
+	}
+	if r.Tag == "" {
+		// force digest to match manifest value
+		r.Digest = desc.Digest.String()
+	}
+	bucket, key := bucketKey(r, blobFile(desc.Digest))
+	err = s.objPut(ctx, bucket, key, b, desc.MediaType, "", false)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if !config.Child {
+		err = s.indexUpdate(ctx, r, func(index *v1.Index) (bool, error) {
+			indexSet(index, r, desc)
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	s.log.WithFields(logrus.Fields{
+		"ref": r.CommonName(),
+		"key": key,
+	}).Debug("pushed manifest")
+
+	// update referrers if defined on this manifest
+	if ms, ok := m.(manifest.Subjecter); ok {
+		mDesc, err := ms.GetSubject()
+		if err != nil {
+			return err
+		}
+		if mDesc != nil && mDesc.MediaType != "" && mDesc.Size > 0 {
+			err = s.referrerPut(ctx, r, m)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/regclient/regclient/types/ping"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Ping verifies access to the bucket.
+func (s *S3) Ping(ctx context.Context, r ref.Ref) (ping.Result, error) {
+	ret := ping.Result{}
+	bucket, _ := bucketKey(r)
+	resp, err := s.do(ctx, http.MethodHead, bucket, "", nil, nil, nil)
+	if err != nil {
+		return ret, err
+	}
+	_ = resp.Body.Close()
+	ret.Header = resp.Header
+	return ret, nil
+}
@@ -0,0 +1,213 @@
+// Package s3 implements an OCI Image Layout stored in an S3 compatible bucket.
+// References are formatted as s3://bucket/prefix:tag, where the prefix is the root of the layout.
+// Blobs are uploaded with multipart requests and changes to the index.json use conditional writes,
+// allowing multiple writers to safely update the same layout.
+package s3
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/internal/throttle"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	// DefaultRegion is used when the region is not configured.
+	DefaultRegion = "us-east-1"
+	// DefaultPartSize is the size of each part in a multipart blob upload.
+	DefaultPartSize = 16 * 1024 * 1024
+	imageLayoutFile = "oci-layout"
+	indexFile       = "index.json"
+	aOCIRefName     = "org.opencontainers.image.ref.name"
+	defThrottle     = 3
+	// indexRetryLimit is the number of attempts to update the index.json when another writer changed it.
+	indexRetryLimit = 10
+)
+
+// S3 is used for accessing OCI Image Layouts in an S3 compatible bucket.
+type S3 struct {
+	endpoint  string
+	region    string
+	pathStyle bool
+	creds     Creds
+	partSize  int64
+	client    *http.Client
+	log       *logrus.Logger
+	digestAlg digest.Algorithm
+	throttle  map[string]*throttle.Throttle
+	mu        sync.Mutex
+	// referrerMu serializes updates to referrer lists within this process
+	referrerMu sync.Mutex
+}
+
+// Creds are the access keys used to sign requests.
+// Requests are sent anonymously when the AccessKey is empty.
+type Creds struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+type config struct {
+	endpoint  string
+	region    string
+	pathStyle *bool
+	creds     Creds
+	partSize  int64
+	client    *http.Client
+	log       *logrus.Logger
+	digestAlg digest.Algorithm
+}
+
+// Opts are used for passing options to s3.
+type Opts func(*config)
+
+// New creates a new S3 with options.
+// Defaults are read from the AWS_ENDPOINT_URL_S3, AWS_ENDPOINT_URL, AWS_REGION, AWS_DEFAULT_REGION,
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN environment variables.
+func New(opts ...Opts) *S3 {
+	conf := config{
+		endpoint: os.Getenv("AWS_ENDPOINT_URL_S3"),
+		region:   os.Getenv("AWS_REGION"),
+		creds: Creds{
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		partSize: DefaultPartSize,
+		log:      &logrus.Logger{Out: io.Discard},
+	}
+	if conf.endpoint == "" {
+		conf.endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if conf.region == "" {
+		conf.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	if conf.region == "" {
+		conf.region = DefaultRegion
+	}
+	// custom endpoints (minio, ceph, etc) typically require path style requests
+	pathStyle := conf.endpoint != ""
+	if conf.pathStyle != nil {
+		pathStyle = *conf.pathStyle
+	}
+	if conf.endpoint == "" {
+		conf.endpoint = "https://s3." + conf.region + ".amazonaws.com"
+	}
+	if conf.partSize <= 0 {
+		conf.partSize = DefaultPartSize
+	}
+	if conf.client == nil {
+		conf.client = &http.Client{}
+	}
+	if conf.digestAlg == "" || !conf.digestAlg.Available() {
+		conf.digestAlg = digest.Canonical
+	}
+	return &S3{
+		endpoint:  strings.TrimSuffix(conf.endpoint, "/"),
+		region:    conf.region,
+		pathStyle: pathStyle,
+		creds:     conf.creds,
+		partSize:  conf.partSize,
+		client:    conf.client,
+		log:       conf.log,
+		digestAlg: conf.digestAlg,
+		throttle:  map[string]*throttle.Throttle{},
+	}
+}
+
+// WithCreds sets the access keys used to sign requests.
+func WithCreds(creds Creds) Opts {
+	return func(c *config) {
+		c.creds = creds
+	}
+}
+
+// WithDigestAlgorithm sets the algorithm for blobs pushed without a digest.
+// The default is sha256.
+func WithDigestAlgorithm(alg digest.Algorithm) Opts {
+	return func(c *config) {
+		c.digestAlg = alg
+	}
+}
+
+// WithEndpoint sets the URL of the S3 API, e.g. "http://localhost:9000".
+// The default is the AWS endpoint for the region.
+func WithEndpoint(endpoint string) Opts {
+	return func(c *config) {
+		c.endpoint = endpoint
+	}
+}
+
+// WithHTTPClient sets the http client used for requests.
+func WithHTTPClient(hc *http.Client) Opts {
+	return func(c *config) {
+		c.client = hc
+	}
+}
+
+// WithLog provides a logrus logger.
+// By default logging is disabled.
+func WithLog(log *logrus.Logger) Opts {
+	return func(c *config) {
+		c.log = log
+	}
+}
+
+// WithPartSize sets the size of each part in a multipart blob upload.
+// S3 requires every part except the last to be at least 5MiB.
+func WithPartSize(size int64) Opts {
+	return func(c *config) {
+		c.partSize = size
+	}
+}
+
+// WithPathStyle sets requests to include the bucket in the path rather than the hostname.
+// This defaults to enabled when a custom endpoint is configured.
+func WithPathStyle(pathStyle bool) Opts {
+	return func(c *config) {
+		c.pathStyle = &pathStyle
+	}
+}
+
+// WithRegion sets the region used to sign requests and select the default endpoint.
+func WithRegion(region string) Opts {
+	return func(c *config) {
+		c.region = region
+	}
+}
+
+// Throttle is used to limit concurrency.
+func (s *S3) Throttle(r ref.Ref, put bool) []*throttle.Throttle {
+	tList := []*throttle.Throttle{
+		s.throttleGet(r),
+	}
+	return tList
+}
+
+func (s *S3) throttleGet(r ref.Ref) *throttle.Throttle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.throttle[r.Path]; ok {
+		return t
+	}
+	s.throttle[r.Path] = throttle.New(defThrottle)
+	return s.throttle[r.Path]
+}
+
+// bucketKey splits the reference path into the bucket and the key of a file in the layout.
+func bucketKey(r ref.Ref, file ...string) (string, string) {
+	bucket, prefix, _ := strings.Cut(r.Path, "/")
+	return bucket, strings.TrimPrefix(path.Join(append([]string{prefix}, file...)...), "/")
+}
@@ -0,0 +1,35 @@
+package s3
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/regclient/regclient/internal/sigv4"
+)
+
+const (
+	sigAlgorithm   = sigv4.Algorithm
+	sigService     = "s3"
+	hdrContentHash = "X-Amz-Content-Sha256"
+)
+
+// sign adds the AWS Signature Version 4 headers to a request.
+// The payload hash is the hex encoded sha256 of the request body.
+func sign(req *http.Request, creds Creds, region, payloadHash string, now time.Time) {
+	// S3 requires the payload hash header on every request
+	req.Header.Set(hdrContentHash, payloadHash)
+	sigv4.Sign(req, sigv4.Creds(creds), region, sigService, payloadHash, now)
+}
+
+func sigHash(b []byte) string {
+	return sigv4.Hash(b)
+}
+
+func sigEscapePath(p string) string {
+	return sigv4.EscapePath(p)
+}
+
+func sigQuery(q url.Values) string {
+	return sigv4.Query(q)
+}
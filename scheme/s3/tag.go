@@ -0,0 +1,75 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
+)
+
+// TagDelete removes a tag from the repository
+func (s *S3) TagDelete(ctx context.Context, r ref.Ref) error {
+	if r.Tag == "" {
+		return types.ErrMissingTag
+	}
+	err := s.indexUpdate(ctx, r, func(index *v1.Index) (bool, error) {
+		changed := false
+		for i := len(index.Manifests) - 1; i >= 0; i-- {
+			if t, ok := index.Manifests[i].Annotations[aOCIRefName]; ok && t == r.Tag {
+				index.Manifests = append(index.Manifests[:i], index.Manifests[i+1:]...)
+				changed = true
+			}
+		}
+		if !changed {
+			return false, fmt.Errorf("failed deleting %s: %w", r.CommonName(), types.ErrNotFound)
+		}
+		return true, nil
+	})
+	return err
+}
+
+// TagList returns a list of tags from the repository
+func (s *S3) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error) {
+	index, _, err := s.indexRead(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	tl := []string{}
+	for _, desc := range index.Manifests {
+		if t, ok := desc.Annotations[aOCIRefName]; ok {
+			if i := strings.LastIndex(t, ":"); i >= 0 {
+				t = t[i+1:]
+			}
+			found := false
+			for _, cur := range tl {
+				if cur == t {
+					found = true
+					break
+				}
+			}
+			if !found {
+				tl = append(tl, t)
+			}
+		}
+	}
+	sort.Strings(tl)
+	ib, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	// return listing from index
+	return tag.New(
+		tag.WithRaw(ib),
+		tag.WithRef(r),
+		tag.WithMT(types.MediaTypeOCI1ManifestList),
+		tag.WithLayoutIndex(index),
+		tag.WithTags(tl),
+	)
+}
@@ -4,6 +4,7 @@ package scheme
 import (
 	"context"
 	"io"
+	"regexp"
 
 	"github.com/regclient/regclient/internal/throttle"
 	"github.com/regclient/regclient/types"
@@ -49,6 +50,14 @@ type API interface {
 	TagList(ctx context.Context, r ref.Ref, opts ...TagOpts) (*tag.List, error)
 }
 
+// BlobRanger is used to check if a scheme supports retrieving part of a blob.
+type BlobRanger interface {
+	// BlobGetRange returns a reader for the blob content starting at the offset.
+	// A negative length reads to the end of the blob.
+	// The returned content is not verified against the descriptor digest.
+	BlobGetRange(ctx context.Context, r ref.Ref, d types.Descriptor, offset, length int64) (io.ReadCloser, error)
+}
+
 // Closer is used to check if a scheme implements the Close API.
 type Closer interface {
 	Close(ctx context.Context, r ref.Ref) error
@@ -63,6 +72,11 @@ type GCLocker interface {
 	GCUnlock(r ref.Ref)
 }
 
+// TagDeleter is used to check if a scheme supports options when deleting a tag.
+type TagDeleter interface {
+	TagDeleteWithOpts(ctx context.Context, r ref.Ref, opts ...TagOpts) error
+}
+
 // Throttler is used to indicate the scheme implements Throttle.
 type Throttler interface {
 	Throttle(r ref.Ref, put bool) []*throttle.Throttle
@@ -173,8 +187,12 @@ func ReferrerFilter(config ReferrerConfig, rlIn referrer.ReferrerList) referrer.
 
 // RepoConfig is used by schemes to import [RepoOpts].
 type RepoConfig struct {
-	Limit int
-	Last  string
+	Limit      int
+	Last       string
+	Namespace  string           // namespace to list, required by some registries without the _catalog API
+	Prefix     string           // client side filter on the repository name prefix
+	Filters    []*regexp.Regexp // client side filters, a repository is included when any expression matches
+	Accessible bool             // client side check that the tags of each repository can be listed
 }
 
 // RepoOpts is used to set options on repo APIs.
@@ -196,10 +214,45 @@ func WithRepoLast(l string) RepoOpts {
 	}
 }
 
+// WithRepoAccessible skips repositories where listing the tags fails as unauthorized or not found.
+// Some registries list every repository, including those the user cannot access.
+// This is applied by the regclient, and requires a tag listing request for each repository.
+func WithRepoAccessible() RepoOpts {
+	return func(config *RepoConfig) {
+		config.Accessible = true
+	}
+}
+
+// WithRepoFilter only includes repositories matching the regular expression.
+// When called multiple times, repositories matching any of the expressions are included.
+// This is applied by the regclient to the repositories returned by the registry.
+func WithRepoFilter(re *regexp.Regexp) RepoOpts {
+	return func(config *RepoConfig) {
+		config.Filters = append(config.Filters, re)
+	}
+}
+
+// WithRepoNamespace lists the repositories within a namespace, e.g. an organization on Docker Hub or a project on GCR.
+// Registries listed with the _catalog API are filtered by the regclient to repositories within the namespace.
+func WithRepoNamespace(ns string) RepoOpts {
+	return func(config *RepoConfig) {
+		config.Namespace = ns
+	}
+}
+
+// WithRepoPrefix only includes repositories beginning with the prefix, e.g. "library/".
+// This is applied by the regclient to the repositories returned by the registry.
+func WithRepoPrefix(prefix string) RepoOpts {
+	return func(config *RepoConfig) {
+		config.Prefix = prefix
+	}
+}
+
 // TagConfig is used by schemes to import [TagOpts].
 type TagConfig struct {
-	Limit int
-	Last  string
+	Limit           int
+	Last            string
+	ManifestReplace bool
 }
 
 // TagOpts is used to set options on tag APIs.
@@ -213,6 +266,14 @@ func WithTagLimit(limit int) TagOpts {
 	}
 }
 
+// WithTagManifestReplace allows TagDeleteWithOpts to replace the tag with a temporary manifest that is then deleted.
+// This is only used by registries that do not support the OCI tag delete API.
+func WithTagManifestReplace() TagOpts {
+	return func(t *TagConfig) {
+		t.ManifestReplace = true
+	}
+}
+
 // WithTagLast passes the last received tag for requesting the next batch of tags.
 // Registries may ignore this.
 func WithTagLast(last string) TagOpts {
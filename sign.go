@@ -0,0 +1,217 @@
+package regclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/pkg/sign"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
+)
+
+type signOpt struct {
+	annotations map[string]string
+	cosignTag   bool
+}
+
+// SignOpts define options for [RegClient.ImageSign].
+type SignOpts func(*signOpt)
+
+// SignWithAnnotations adds annotations to the signed payload.
+func SignWithAnnotations(annotations map[string]string) SignOpts {
+	return func(opt *signOpt) {
+		opt.annotations = annotations
+	}
+}
+
+// SignWithCosignTag pushes the signature to the "sha256-<hex>.sig" tag used by cosign, instead of as a referrer.
+// Existing signatures in the tag are kept, and the new signature is added as another layer.
+func SignWithCosignTag() SignOpts {
+	return func(opt *signOpt) {
+		opt.cosignTag = true
+	}
+}
+
+// ImageSign creates a cosign compatible signature of an image, returning the reference of the signature manifest.
+// The signature is pushed as a referrer to the image digest, which uses the referrers fallback tag on registries without the referrers API.
+// Use [SignWithCosignTag] for verifiers that only support the cosign signature tag.
+func (rc *RegClient) ImageSign(ctx context.Context, r ref.Ref, signer sign.Signer, opts ...SignOpts) (ref.Ref, error) {
+	opt := signOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	if !r.IsSet() {
+		return r, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	mh, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+	if err != nil {
+		return r, err
+	}
+	dImage := mh.GetDescriptor()
+	rImage := r.SetDigest(dImage.Digest.String())
+
+	// create and sign the payload
+	var annotations map[string]interface{}
+	if len(opt.annotations) > 0 {
+		annotations = map[string]interface{}{}
+		for k, v := range opt.annotations {
+			annotations[k] = v
+		}
+	}
+	payload, err := sign.NewPayload(rImage, dImage.Digest, annotations)
+	if err != nil {
+		return r, fmt.Errorf("failed to create payload: %w", err)
+	}
+	sig, err := signer.Sign(ctx, payload)
+	if err != nil {
+		return r, fmt.Errorf("failed to sign %s: %w", rImage.CommonName(), err)
+	}
+	dLayer := types.Descriptor{
+		MediaType: sign.MediaTypeSimpleSigning,
+		Digest:    digest.FromBytes(payload),
+		Size:      int64(len(payload)),
+		Annotations: map[string]string{
+			sign.AnnotationSignature: base64.StdEncoding.EncodeToString(sig),
+		},
+	}
+	if cs, ok := signer.(sign.CertSigner); ok {
+		cert, chain := cs.Certificate()
+		if len(cert) > 0 {
+			dLayer.Annotations[sign.AnnotationCertificate] = string(cert)
+		}
+		if len(chain) > 0 {
+			dLayer.Annotations[sign.AnnotationChain] = string(chain)
+		}
+	}
+	_, err = rc.BlobPut(ctx, rImage, dLayer, bytes.NewReader(payload))
+	if err != nil {
+		return r, fmt.Errorf("failed to push signature payload: %w", err)
+	}
+
+	var rSig ref.Ref
+	var mSig manifest.Manifest
+	if opt.cosignTag {
+		rSig, mSig, err = rc.imageSignTag(ctx, rImage, dLayer)
+	} else {
+		rSig, mSig, err = rc.imageSignReferrer(ctx, rImage, dImage, dLayer)
+	}
+	if err != nil {
+		return r, err
+	}
+	err = rc.ManifestPut(ctx, rSig, mSig)
+	if err != nil {
+		return r, fmt.Errorf("failed to push signature: %w", err)
+	}
+	rc.log.WithFields(logrus.Fields{
+		"ref":       rImage.CommonName(),
+		"signature": rSig.CommonName(),
+	}).Info("image signed")
+	return rSig, nil
+}
+
+// imageSignReferrer creates the signature manifest with a subject referencing the image.
+func (rc *RegClient) imageSignReferrer(ctx context.Context, rImage ref.Ref, dImage, dLayer types.Descriptor) (ref.Ref, manifest.Manifest, error) {
+	dConf := types.Descriptor{
+		MediaType: types.MediaTypeOCI1Empty,
+		Digest:    types.EmptyDigest,
+		Size:      int64(len(types.EmptyData)),
+	}
+	_, err := rc.BlobPut(ctx, rImage, dConf, bytes.NewReader(types.EmptyData))
+	if err != nil {
+		return rImage, nil, fmt.Errorf("failed to push signature config: %w", err)
+	}
+	dSubject := types.Descriptor{
+		MediaType: dImage.MediaType,
+		Digest:    dImage.Digest,
+		Size:      dImage.Size,
+	}
+	m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned:    v1.ManifestSchemaVersion,
+		MediaType:    types.MediaTypeOCI1Manifest,
+		ArtifactType: sign.ArtifactTypeSignature,
+		Config:       dConf,
+		Layers:       []types.Descriptor{dLayer},
+		Subject:      &dSubject,
+		Annotations: map[string]string{
+			types.AnnotationCreated: time.Now().UTC().Format(time.RFC3339),
+		},
+	}))
+	if err != nil {
+		return rImage, nil, err
+	}
+	return rImage.SetDigest(m.GetDescriptor().Digest.String()), m, nil
+}
+
+// imageSignTag creates the signature manifest for the cosign signature tag, keeping any existing signatures.
+func (rc *RegClient) imageSignTag(ctx context.Context, rImage ref.Ref, dLayer types.Descriptor) (ref.Ref, manifest.Manifest, error) {
+	rSig, err := referrer.FallbackTag(rImage)
+	if err != nil {
+		return rImage, nil, err
+	}
+	rSig = rSig.SetTag(rSig.Tag + ".sig")
+	layers := []types.Descriptor{}
+	mPrev, err := rc.ManifestGet(ctx, rSig)
+	if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+		return rImage, nil, fmt.Errorf("failed to get existing signatures: %w", err)
+	}
+	if err == nil {
+		mi, ok := mPrev.(manifest.Imager)
+		if !ok || mPrev.IsList() {
+			return rImage, nil, fmt.Errorf("existing signature tag is not an image: %s%.0w", rSig.CommonName(), types.ErrUnsupportedMediaType)
+		}
+		layers, err = mi.GetLayers()
+		if err != nil {
+			return rImage, nil, err
+		}
+	}
+	layers = append(layers, dLayer)
+	// cosign uses a minimal image config with each signature listed as a layer
+	conf := v1.Image{
+		RootFS: v1.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{},
+		},
+		History: []v1.History{},
+	}
+	created := time.Time{}
+	for _, l := range layers {
+		conf.RootFS.DiffIDs = append(conf.RootFS.DiffIDs, l.Digest)
+		conf.History = append(conf.History, v1.History{Created: &created})
+	}
+	conf.Created = &created
+	confBytes, err := json.Marshal(conf)
+	if err != nil {
+		return rImage, nil, err
+	}
+	dConf := types.Descriptor{
+		MediaType: types.MediaTypeOCI1ImageConfig,
+		Digest:    digest.FromBytes(confBytes),
+		Size:      int64(len(confBytes)),
+	}
+	_, err = rc.BlobPut(ctx, rSig, dConf, bytes.NewReader(confBytes))
+	if err != nil {
+		return rImage, nil, fmt.Errorf("failed to push signature config: %w", err)
+	}
+	m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned: v1.ManifestSchemaVersion,
+		MediaType: types.MediaTypeOCI1Manifest,
+		Config:    dConf,
+		Layers:    layers,
+	}))
+	if err != nil {
+		return rImage, nil, err
+	}
+	return rSig, m, nil
+}
@@ -0,0 +1,169 @@
+package regclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/pkg/sign"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
+)
+
+func TestImageSign(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+	mh, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	dig := mh.GetDescriptor().Digest
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, err := sign.NewKey(key)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	// verifySig checks the payload and signature of a signature layer
+	verifySig := func(t *testing.T, rSig ref.Ref, d types.Descriptor) {
+		t.Helper()
+		if d.MediaType != sign.MediaTypeSimpleSigning {
+			t.Errorf("unexpected layer media type: %s", d.MediaType)
+		}
+		br, err := rc.BlobGet(ctx, rSig, d)
+		if err != nil {
+			t.Fatalf("failed to get payload: %v", err)
+		}
+		defer br.Close()
+		payload, err := io.ReadAll(br)
+		if err != nil {
+			t.Fatalf("failed to read payload: %v", err)
+		}
+		p := sign.Payload{}
+		err = json.Unmarshal(payload, &p)
+		if err != nil {
+			t.Fatalf("failed to parse payload: %v", err)
+		}
+		if p.Critical.Image.DockerManifestDigest != dig || p.Critical.Type != sign.PayloadType {
+			t.Errorf("unexpected payload: %s", payload)
+		}
+		sig, err := base64.StdEncoding.DecodeString(d.Annotations[sign.AnnotationSignature])
+		if err != nil {
+			t.Fatalf("failed to decode signature: %v", err)
+		}
+		h := sha256.Sum256(payload)
+		if !ecdsa.VerifyASN1(&key.PublicKey, h[:], sig) {
+			t.Errorf("signature verification failed")
+		}
+	}
+
+	t.Run("referrer", func(t *testing.T) {
+		rSig, err := rc.ImageSign(ctx, r, signer, SignWithAnnotations(map[string]string{"env": "test"}))
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		rl, err := rc.ReferrerList(ctx, r.SetDigest(dig.String()))
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		found := false
+		for _, d := range rl.Descriptors {
+			if d.Digest.String() == rSig.Digest && d.ArtifactType == sign.ArtifactTypeSignature {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("signature %s not found in referrers", rSig.CommonName())
+		}
+		m, err := rc.ManifestGet(ctx, rSig)
+		if err != nil {
+			t.Fatalf("failed to get signature: %v", err)
+		}
+		layers, err := m.(manifest.Imager).GetLayers()
+		if err != nil || len(layers) != 1 {
+			t.Fatalf("unexpected layers: %v, %v", layers, err)
+		}
+		verifySig(t, rSig, layers[0])
+	})
+	t.Run("cosign tag", func(t *testing.T) {
+		rTag, err := referrer.FallbackTag(r.SetDigest(dig.String()))
+		if err != nil {
+			t.Fatalf("failed to get tag: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			rSig, err := rc.ImageSign(ctx, r, signer, SignWithCosignTag())
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+			if rSig.Tag != rTag.Tag+".sig" {
+				t.Errorf("unexpected signature tag: %s", rSig.Tag)
+			}
+		}
+		rSig := rTag.SetTag(rTag.Tag + ".sig")
+		m, err := rc.ManifestGet(ctx, rSig)
+		if err != nil {
+			t.Fatalf("failed to get signature: %v", err)
+		}
+		layers, err := m.(manifest.Imager).GetLayers()
+		if err != nil || len(layers) != 2 {
+			t.Fatalf("unexpected layers: %v, %v", layers, err)
+		}
+		for _, l := range layers {
+			verifySig(t, rSig, l)
+		}
+		dConf, err := m.(manifest.Imager).GetConfig()
+		if err != nil {
+			t.Fatalf("failed to get config descriptor: %v", err)
+		}
+		conf, err := rc.BlobGetOCIConfig(ctx, rSig, dConf)
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		if diffIDs := conf.GetConfig().RootFS.DiffIDs; len(diffIDs) != 2 || diffIDs[0] != layers[0].Digest || diffIDs[1] != layers[1].Digest {
+			t.Errorf("unexpected diff ids: %v", diffIDs)
+		}
+	})
+	t.Run("ephemeral", func(t *testing.T) {
+		es, err := sign.NewEphemeral("user@example.com")
+		if err != nil {
+			t.Fatalf("failed to create signer: %v", err)
+		}
+		rSig, err := rc.ImageSign(ctx, r, es)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		m, err := rc.ManifestGet(ctx, rSig)
+		if err != nil {
+			t.Fatalf("failed to get signature: %v", err)
+		}
+		layers, err := m.(manifest.Imager).GetLayers()
+		if err != nil || len(layers) != 1 {
+			t.Fatalf("unexpected layers: %v, %v", layers, err)
+		}
+		if layers[0].Annotations[sign.AnnotationCertificate] == "" {
+			t.Errorf("certificate annotation missing")
+		}
+	})
+}
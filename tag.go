@@ -2,21 +2,38 @@ package regclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
 
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/tag"
 )
 
-// TagDelete deletes a tag from the registry. Since there's no API for this,
-// you'd want to normally just delete the manifest. However multiple tags may
-// point to the same manifest, so instead you must:
+// TagDelete deletes a tag from the registry without deleting the manifest.
+// This requires the registry to support the OCI tag delete API.
+// See [RegClient.TagDeleteWithOpts] to delete tags on registries without that API.
+func (rc *RegClient) TagDelete(ctx context.Context, r ref.Ref) error {
+	return rc.TagDeleteWithOpts(ctx, r)
+}
+
+// TagDeleteWithOpts deletes a tag from the registry without deleting the manifest.
+// The OCI tag delete API is used when supported by the registry.
+// Otherwise, because multiple tags may point to the same manifest, the tag is only deleted
+// with [scheme.WithTagManifestReplace], which must:
 // 1. Make a manifest, for this we put a few labels and timestamps to be unique.
 // 2. Push that manifest to the tag.
 // 3. Delete the digest for that new manifest that is only used by that tag.
-func (rc *RegClient) TagDelete(ctx context.Context, r ref.Ref) error {
+// Options are ignored by schemes that do not implement [scheme.TagDeleter].
+func (rc *RegClient) TagDeleteWithOpts(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) error {
 	if !r.IsSet() {
 		return fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
 	}
@@ -24,9 +41,404 @@ func (rc *RegClient) TagDelete(ctx context.Context, r ref.Ref) error {
 	if err != nil {
 		return err
 	}
+	if sTD, ok := schemeAPI.(scheme.TagDeleter); ok {
+		return sTD.TagDeleteWithOpts(ctx, r, opts...)
+	}
 	return schemeAPI.TagDelete(ctx, r)
 }
 
+// TagRollback points a tag back to the digest recorded in the [types.AnnotationPreviousDigest] annotation.
+// The annotation is added when pushing with [WithManifestRecordPrevious].
+// The restored manifest is returned.
+func (rc *RegClient) TagRollback(ctx context.Context, r ref.Ref) (manifest.Manifest, error) {
+	if !r.IsSet() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	if r.Tag == "" || r.Digest != "" {
+		return nil, fmt.Errorf("rollback requires a tag without a digest: %s%.0w", r.CommonName(), types.ErrMissingTag)
+	}
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	ma, ok := m.(manifest.Annotator)
+	if !ok {
+		return nil, fmt.Errorf("manifest does not support annotations: %s%.0w", r.CommonName(), types.ErrMissingAnnotation)
+	}
+	annot, err := ma.GetAnnotations()
+	if err != nil {
+		return nil, err
+	}
+	prevDig, ok := annot[types.AnnotationPreviousDigest]
+	if !ok || prevDig == "" {
+		return nil, fmt.Errorf("previous digest not recorded for %s%.0w", r.CommonName(), types.ErrMissingAnnotation)
+	}
+	dig, err := digest.Parse(prevDig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse previous digest %s: %w", prevDig, err)
+	}
+	mPrev, err := rc.ManifestGet(ctx, r.SetDigest(dig.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous manifest %s: %w", dig.String(), err)
+	}
+	err = rc.ManifestPut(ctx, r, mPrev)
+	if err != nil {
+		return nil, err
+	}
+	return mPrev, nil
+}
+
+// TagState is a snapshot of the tags in a repository and the manifest each tag references.
+type TagState struct {
+	Repository string                      `json:"repository"`
+	Created    time.Time                   `json:"created"`
+	Tags       map[string]types.Descriptor `json:"tags"`
+}
+
+type tagImportOpt struct {
+	prune           bool
+	manifestReplace bool
+}
+
+// TagImportOpts define options for [RegClient.TagImport].
+type TagImportOpts func(*tagImportOpt)
+
+// TagImportWithPrune deletes tags in the repository that are not included in the imported state.
+func TagImportWithPrune() TagImportOpts {
+	return func(opts *tagImportOpt) {
+		opts.prune = true
+	}
+}
+
+// TagImportWithManifestReplace allows pruned tags to be replaced with a temporary manifest that is then deleted.
+// This is needed to prune tags on registries that do not support the OCI tag delete API.
+func TagImportWithManifestReplace() TagImportOpts {
+	return func(opts *tagImportOpt) {
+		opts.manifestReplace = true
+	}
+}
+
+// TagExport returns the digest referenced by each tag in a repository.
+// Only the tag pointers are included, the manifests and blobs must be copied separately.
+func (rc *RegClient) TagExport(ctx context.Context, r ref.Ref) (TagState, error) {
+	ts := TagState{
+		Created: time.Now().UTC(),
+		Tags:    map[string]types.Descriptor{},
+	}
+	r = r.SetTag("")
+	if !r.IsSetRepo() {
+		return ts, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	ts.Repository = r.CommonName()
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		return ts, err
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return ts, err
+	}
+	for _, t := range tags {
+		m, err := rc.ManifestHead(ctx, r.SetTag(t), WithManifestRequireDigest())
+		if err != nil {
+			return ts, fmt.Errorf("failed to head %s: %w", t, err)
+		}
+		d := m.GetDescriptor()
+		ts.Tags[t] = types.Descriptor{
+			MediaType: d.MediaType,
+			Digest:    d.Digest,
+			Size:      d.Size,
+		}
+	}
+	return ts, nil
+}
+
+// TagImport points each tag in the repository to the digest from a [TagState].
+// Every referenced manifest must already exist in the repository, and this is verified before any tags are changed.
+// Tags already pointing to the expected digest are not modified.
+func (rc *RegClient) TagImport(ctx context.Context, r ref.Ref, ts TagState, opts ...TagImportOpts) error {
+	opt := tagImportOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	r = r.SetTag("")
+	if !r.IsSetRepo() {
+		return fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	tags := make([]string, 0, len(ts.Tags))
+	for t := range ts.Tags {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	// verify all manifests exist before changing any tags
+	for _, t := range tags {
+		d := ts.Tags[t]
+		if d.Digest == "" {
+			return fmt.Errorf("digest missing for tag %s%.0w", t, types.ErrMissingDigest)
+		}
+		_, err := rc.ManifestHead(ctx, r.SetDigest(d.Digest.String()))
+		if err != nil {
+			return fmt.Errorf("manifest %s for tag %s not found: %w", d.Digest.String(), t, err)
+		}
+	}
+	for _, t := range tags {
+		d := ts.Tags[t]
+		rTag := r.SetTag(t)
+		mCur, err := rc.ManifestHead(ctx, rTag, WithManifestRequireDigest())
+		if err == nil && mCur.GetDescriptor().Digest == d.Digest {
+			continue
+		} else if err != nil && !errors.Is(err, types.ErrNotFound) {
+			return fmt.Errorf("failed to head %s: %w", t, err)
+		}
+		m, err := rc.ManifestGet(ctx, r.SetDigest(d.Digest.String()))
+		if err != nil {
+			return err
+		}
+		err = rc.ManifestPut(ctx, rTag, m)
+		if err != nil {
+			return fmt.Errorf("failed to set tag %s: %w", t, err)
+		}
+	}
+	if opt.prune {
+		tl, err := rc.TagList(ctx, r)
+		if err != nil {
+			return err
+		}
+		cur, err := tl.GetTags()
+		if err != nil {
+			return err
+		}
+		tagOpts := []scheme.TagOpts{}
+		if opt.manifestReplace {
+			tagOpts = append(tagOpts, scheme.WithTagManifestReplace())
+		}
+		for _, t := range cur {
+			if _, ok := ts.Tags[t]; ok {
+				continue
+			}
+			err = rc.TagDeleteWithOpts(ctx, r.SetTag(t), tagOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to delete tag %s: %w", t, err)
+			}
+		}
+	}
+	return nil
+}
+
+// TagDigestEvent reports a change to the digest of a tag from [RegClient.TagDigestWatch].
+// Previous is empty for the first poll, and Digest is empty when the tag was deleted.
+type TagDigestEvent struct {
+	Ref       string        `json:"ref"`
+	Previous  digest.Digest `json:"previous,omitempty"`
+	Digest    digest.Digest `json:"digest,omitempty"`
+	MediaType string        `json:"mediaType,omitempty"`
+	Time      time.Time     `json:"time"`
+}
+
+type tagWatchOpt struct {
+	interval time.Duration
+	count    int
+}
+
+// TagWatchOpts define options for [RegClient.TagDigestWatch].
+type TagWatchOpts func(*tagWatchOpt)
+
+// TagWatchWithInterval sets the time between each poll of the tag, defaulting to 1 minute.
+func TagWatchWithInterval(d time.Duration) TagWatchOpts {
+	return func(opts *tagWatchOpt) {
+		if d > 0 {
+			opts.interval = d
+		}
+	}
+}
+
+// TagWatchWithCount returns after the number of events have been reported, including the first poll.
+func TagWatchWithCount(count int) TagWatchOpts {
+	return func(opts *tagWatchOpt) {
+		opts.count = count
+	}
+}
+
+// TagDigestWatch polls a tag and calls fn each time the digest changes.
+// Each poll is a HEAD request, which does not pull the manifest or count against the Docker Hub pull limit.
+// The first poll always reports the current digest, and a deleted tag is reported with an empty digest.
+// Failed polls are logged and retried on the next interval.
+// The watch runs until the context is canceled, the count is reached, or fn returns an error.
+func (rc *RegClient) TagDigestWatch(ctx context.Context, r ref.Ref, fn func(TagDigestEvent) error, opts ...TagWatchOpts) error {
+	opt := tagWatchOpt{
+		interval: time.Minute,
+	}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	if r.Tag == "" || r.Digest != "" {
+		return fmt.Errorf("watch requires a tag without a digest: %s%.0w", r.CommonName(), types.ErrMissingTag)
+	}
+	var last digest.Digest
+	first := true
+	events := 0
+	for {
+		m, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+		if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			rc.log.WithFields(logrus.Fields{
+				"ref": r.CommonName(),
+				"err": err,
+			}).Warn("Failed to poll tag")
+		} else {
+			e := TagDigestEvent{
+				Ref:      r.CommonName(),
+				Previous: last,
+			}
+			if err == nil {
+				e.Digest = m.GetDescriptor().Digest
+				e.MediaType = m.GetDescriptor().MediaType
+			}
+			if first || e.Digest != last {
+				e.Time = time.Now().UTC()
+				err = fn(e)
+				if err != nil {
+					return err
+				}
+				first = false
+				last = e.Digest
+				events++
+				if opt.count > 0 && events >= opt.count {
+					return nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(opt.interval):
+		}
+	}
+}
+
+type renameOpt struct {
+	dryRun          bool
+	digestTags      bool
+	referrers       bool
+	manifestReplace bool
+}
+
+// RenameOpts define options for [RegClient.TagRename] and [RegClient.RepoRename].
+type RenameOpts func(*renameOpt)
+
+// RenameWithDryRun resolves and checks each tag without modifying the source or target.
+func RenameWithDryRun() RenameOpts {
+	return func(opts *renameOpt) {
+		opts.dryRun = true
+	}
+}
+
+// RenameWithDigestTags copies digest tags ("sha256-<digest>.*") to the target repository.
+func RenameWithDigestTags() RenameOpts {
+	return func(opts *renameOpt) {
+		opts.digestTags = true
+	}
+}
+
+// RenameWithReferrers copies referrers to the target repository.
+func RenameWithReferrers() RenameOpts {
+	return func(opts *renameOpt) {
+		opts.referrers = true
+	}
+}
+
+// RenameWithManifestReplace allows source tags to be replaced with a temporary manifest that is then deleted.
+// This is needed to rename tags on registries that do not support the OCI tag delete API.
+func RenameWithManifestReplace() RenameOpts {
+	return func(opts *renameOpt) {
+		opts.manifestReplace = true
+	}
+}
+
+// RenameEntry is a tag moved by a rename.
+type RenameEntry struct {
+	Source ref.Ref       `json:"source"`
+	Target ref.Ref       `json:"target"`
+	Digest digest.Digest `json:"digest"`
+}
+
+// TagRename moves a tag to a new name, which may be in another repository.
+// The content is copied, the target digest is verified, and then the source tag is deleted.
+// An existing target tag is only accepted when it already references the same digest.
+func (rc *RegClient) TagRename(ctx context.Context, rSrc, rTgt ref.Ref, opts ...RenameOpts) (RenameEntry, error) {
+	opt := renameOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	if rSrc.Tag == "" || rSrc.Digest != "" || rTgt.Tag == "" || rTgt.Digest != "" {
+		return RenameEntry{}, fmt.Errorf("rename requires a tag without a digest: %s, %s%.0w", rSrc.CommonName(), rTgt.CommonName(), types.ErrMissingTag)
+	}
+	if ref.EqualRepository(rSrc, rTgt) && rSrc.Tag == rTgt.Tag {
+		return RenameEntry{}, fmt.Errorf("source and target are the same: %s%.0w", rSrc.CommonName(), types.ErrInvalidReference)
+	}
+	entries := []RenameEntry{{Source: rSrc, Target: rTgt}}
+	err := rc.renameTags(ctx, entries, opt)
+	return entries[0], err
+}
+
+// renameTags copies each entry, verifies the target digests, and then deletes the source tags.
+// Every source and target is checked before any changes are made.
+func (rc *RegClient) renameTags(ctx context.Context, entries []RenameEntry, opt renameOpt) error {
+	for i, e := range entries {
+		m, err := rc.ManifestHead(ctx, e.Source, WithManifestRequireDigest())
+		if err != nil {
+			return fmt.Errorf("failed to head %s: %w", e.Source.CommonName(), err)
+		}
+		entries[i].Digest = m.GetDescriptor().Digest
+		mTgt, err := rc.ManifestHead(ctx, e.Target, WithManifestRequireDigest())
+		if err == nil && mTgt.GetDescriptor().Digest != entries[i].Digest {
+			return fmt.Errorf("target %s already exists with digest %s%.0w", e.Target.CommonName(), mTgt.GetDescriptor().Digest.String(), types.ErrMismatch)
+		} else if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+			// a missing target repository is not an error
+			return fmt.Errorf("failed to head %s: %w", e.Target.CommonName(), err)
+		}
+	}
+	if opt.dryRun {
+		return nil
+	}
+	imageOpts := []ImageOpts{}
+	if opt.digestTags {
+		imageOpts = append(imageOpts, ImageWithDigestTags())
+	}
+	if opt.referrers {
+		imageOpts = append(imageOpts, ImageWithReferrers())
+	}
+	for _, e := range entries {
+		// copy by digest in case the source tag changes during the rename
+		err := rc.ImageCopy(ctx, e.Source.SetDigest(e.Digest.String()), e.Target, imageOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to copy %s to %s: %w", e.Source.CommonName(), e.Target.CommonName(), err)
+		}
+	}
+	for _, e := range entries {
+		m, err := rc.ManifestHead(ctx, e.Target, WithManifestRequireDigest())
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", e.Target.CommonName(), err)
+		}
+		if m.GetDescriptor().Digest != e.Digest {
+			return fmt.Errorf("target %s has digest %s, expected %s%.0w", e.Target.CommonName(), m.GetDescriptor().Digest.String(), e.Digest.String(), types.ErrDigestMismatch)
+		}
+	}
+	tagOpts := []scheme.TagOpts{}
+	if opt.manifestReplace {
+		tagOpts = append(tagOpts, scheme.WithTagManifestReplace())
+	}
+	for _, e := range entries {
+		err := rc.TagDeleteWithOpts(ctx, e.Source, tagOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", e.Source.CommonName(), err)
+		}
+	}
+	return nil
+}
+
 // TagList returns a tag list from a repository
 func (rc *RegClient) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error) {
 	if !r.IsSetRepo() {
@@ -0,0 +1,269 @@
+package regclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+var (
+	// tagPruneSemverRE matches a semver release without a prerelease, e.g. v1.2.3
+	tagPruneSemverRE = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
+	// tagPruneDigestRE matches digest tags and referrer fallback tags, which are never pruned
+	tagPruneDigestRE = regexp.MustCompile(`^sha256-[0-9a-f]{64}`)
+)
+
+// TagRetention selects the tags kept by [RegClient.TagPrune].
+// A tag matching Match is kept when any of the keep rules apply, and all other tags are kept.
+type TagRetention struct {
+	// Match selects the tags that may be pruned, every tag is selected when nil.
+	// Capture groups in the expression group the tags for KeepLast, e.g. `^v(\d+)\..*$` keeps the newest tags of each major version.
+	Match *regexp.Regexp
+	// KeepLast keeps the newest number of tags in each group.
+	KeepLast int
+	// KeepNewer keeps tags created within the duration.
+	KeepNewer time.Duration
+	// KeepSemver keeps semver release tags, e.g. v1.2.3, but not prereleases like v1.2.3-rc1.
+	KeepSemver bool
+}
+
+// TagPruneEntry is a tag evaluated by [RegClient.TagPrune].
+type TagPruneEntry struct {
+	Tag     string        `json:"tag"`
+	Digest  digest.Digest `json:"digest"`
+	Created time.Time     `json:"created"`
+	Keep    bool          `json:"keep"`
+	Reason  string        `json:"reason"`
+}
+
+type tagPruneOpt struct {
+	dryRun          bool
+	manifestReplace bool
+}
+
+// TagPruneOpts define options for [RegClient.TagPrune].
+type TagPruneOpts func(*tagPruneOpt)
+
+// TagPruneWithDryRun evaluates the retention policy without deleting any tags.
+func TagPruneWithDryRun() TagPruneOpts {
+	return func(opts *tagPruneOpt) {
+		opts.dryRun = true
+	}
+}
+
+// TagPruneWithManifestReplace allows tags sharing a manifest with a kept tag to be replaced with a temporary manifest that is then deleted.
+// This is needed to prune those tags on registries that do not support the OCI tag delete API.
+func TagPruneWithManifestReplace() TagPruneOpts {
+	return func(opts *tagPruneOpt) {
+		opts.manifestReplace = true
+	}
+}
+
+// TagPrune deletes the tags in a repository that are not kept by the retention policy.
+// The creation time of each tag is read from the image config, or the created annotation for other artifacts.
+// Tags with an unknown creation time are kept when KeepLast or KeepNewer are set,
+// and digest tags ("sha256-<digest>*") are always kept.
+// A pruned manifest is only deleted when it is not referenced by a kept tag, including the child manifests and referrers of kept images,
+// otherwise only the tag is deleted.
+// Every tag is returned with the reason it was kept or pruned.
+func (rc *RegClient) TagPrune(ctx context.Context, r ref.Ref, tr TagRetention, opts ...TagPruneOpts) ([]TagPruneEntry, error) {
+	opt := tagPruneOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	r = r.SetTag("")
+	if !r.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tags)
+	now := time.Now()
+	ageRule := tr.KeepLast > 0 || tr.KeepNewer > 0
+	entries := make([]TagPruneEntry, len(tags))
+	groups := map[string][]int{}
+	groupNames := []string{}
+	for i, t := range tags {
+		entries[i].Tag = t
+		if tagPruneDigestRE.MatchString(t) {
+			entries[i].Keep, entries[i].Reason = true, "digest tag"
+			continue
+		}
+		// the digest of every tag is needed to avoid deleting a manifest that is still tagged
+		m, err := rc.ManifestHead(ctx, r.SetTag(t), WithManifestRequireDigest())
+		if err != nil {
+			return nil, fmt.Errorf("failed to head %s: %w", t, err)
+		}
+		entries[i].Digest = m.GetDescriptor().Digest
+		var match []string
+		if tr.Match != nil {
+			match = tr.Match.FindStringSubmatch(t)
+			if match == nil {
+				entries[i].Keep, entries[i].Reason = true, "not matched"
+				continue
+			}
+		}
+		if tr.KeepSemver && tagPruneSemverRE.MatchString(t) {
+			entries[i].Keep, entries[i].Reason = true, "semver release"
+		}
+		if ageRule {
+			entries[i].Created, err = rc.tagPruneCreated(ctx, r.SetDigest(entries[i].Digest.String()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the creation time of %s: %w", t, err)
+			}
+			if entries[i].Created.IsZero() && !entries[i].Keep {
+				entries[i].Keep, entries[i].Reason = true, "unknown creation time"
+			}
+		}
+		if tr.KeepNewer > 0 && !entries[i].Keep && now.Sub(entries[i].Created) < tr.KeepNewer {
+			entries[i].Keep, entries[i].Reason = true, "newer than "+tr.KeepNewer.String()
+		}
+		group := ""
+		if len(match) > 1 {
+			group = strings.Join(match[1:], "\x00")
+		}
+		if _, ok := groups[group]; !ok {
+			groupNames = append(groupNames, group)
+		}
+		groups[group] = append(groups[group], i)
+	}
+	if tr.KeepLast > 0 {
+		for _, group := range groupNames {
+			idx := groups[group]
+			// newest first, tags with the same time are sorted by name
+			sort.SliceStable(idx, func(a, b int) bool {
+				return entries[idx[a]].Created.After(entries[idx[b]].Created)
+			})
+			for n, i := range idx {
+				if n >= tr.KeepLast {
+					break
+				}
+				if !entries[i].Keep {
+					entries[i].Keep, entries[i].Reason = true, fmt.Sprintf("last %d", tr.KeepLast)
+				}
+			}
+		}
+	}
+	keepDigests := map[digest.Digest]bool{}
+	for i := range entries {
+		if entries[i].Keep {
+			if entries[i].Digest != "" {
+				keepDigests[entries[i].Digest] = true
+			}
+			continue
+		}
+		entries[i].Reason = "pruned"
+	}
+	if opt.dryRun {
+		return entries, nil
+	}
+	// child manifests and referrers of the kept tags are still referenced
+	walk := &imageGCWalk{
+		manifests: map[digest.Digest]types.Descriptor{},
+		blobs:     map[digest.Digest]int64{},
+	}
+	for d := range keepDigests {
+		err = rc.imageGCWalkDigest(ctx, r, d, walk)
+		if err != nil {
+			return nil, err
+		}
+	}
+	deleted := map[digest.Digest]bool{}
+	tagOpts := []scheme.TagOpts{}
+	if opt.manifestReplace {
+		tagOpts = append(tagOpts, scheme.WithTagManifestReplace())
+	}
+	for _, e := range entries {
+		if e.Keep || deleted[e.Digest] {
+			continue
+		}
+		if _, ok := walk.manifests[e.Digest]; ok || keepDigests[e.Digest] {
+			// the manifest is shared with a kept tag, only remove this tag
+			err = rc.TagDeleteWithOpts(ctx, r.SetTag(e.Tag), tagOpts...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete tag %s: %w", e.Tag, err)
+			}
+		} else {
+			err = rc.ManifestDelete(ctx, r.SetDigest(e.Digest.String()), WithManifestReferrerCleanup())
+			if err != nil && !errors.Is(err, types.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to delete %s: %w", e.Tag, err)
+			}
+			deleted[e.Digest] = true
+		}
+		rc.log.WithFields(logrus.Fields{
+			"repo":   r.CommonName(),
+			"tag":    e.Tag,
+			"digest": e.Digest.String(),
+		}).Debug("Pruned tag")
+	}
+	return entries, nil
+}
+
+// tagPruneCreated returns the creation time of a manifest, or a zero time when it is unknown.
+// An index uses the creation time of the first platform.
+func (rc *RegClient) tagPruneCreated(ctx context.Context, r ref.Ref) (time.Time, error) {
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ma, ok := m.(manifest.Annotator); ok {
+		annot, err := ma.GetAnnotations()
+		if err == nil && annot[types.AnnotationCreated] != "" {
+			created, err := time.Parse(time.RFC3339, annot[types.AnnotationCreated])
+			if err == nil {
+				return created, nil
+			}
+		}
+	}
+	if mi, ok := m.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, d := range dl {
+			if d.Platform != nil && d.Platform.OS != "unknown" {
+				return rc.tagPruneCreated(ctx, r.SetDigest(d.Digest.String()))
+			}
+		}
+		return time.Time{}, nil
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return time.Time{}, nil
+	}
+	d, err := mi.GetConfig()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d.MediaType != types.MediaTypeOCI1ImageConfig && d.MediaType != types.MediaTypeDocker2ImageConfig {
+		return time.Time{}, nil
+	}
+	conf, err := rc.BlobGetOCIConfig(ctx, r, d)
+	if err != nil {
+		return time.Time{}, err
+	}
+	c := conf.GetConfig()
+	if c.Created == nil {
+		return time.Time{}, nil
+	}
+	return *c.Created, nil
+}
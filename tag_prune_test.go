@@ -0,0 +1,196 @@
+package regclient
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestTagPrune(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testprune")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	now := time.Now().UTC()
+	created := map[string]time.Time{
+		"1.0.0":     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		"1.1.0-rc1": time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		"1.1.0-rc2": time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+		"2.0.0-rc1": time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+		"2.0.0-rc2": time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		"nightly-a": time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+		"nightly-b": now.Add(time.Hour * -2),
+		"nightly-c": now.Add(time.Hour * -1),
+	}
+	digests := map[string]string{}
+	for tag, c := range created {
+		m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+			Versioned:    v1.ManifestSchemaVersion,
+			MediaType:    types.MediaTypeOCI1Manifest,
+			ArtifactType: "application/example.prune",
+			Config:       types.Descriptor{MediaType: types.MediaTypeOCI1Empty, Digest: types.EmptyDigest, Size: int64(len(types.EmptyData))},
+			Layers:       []types.Descriptor{},
+			Annotations:  map[string]string{types.AnnotationCreated: c.Format(time.RFC3339)},
+		}))
+		if err != nil {
+			t.Fatalf("failed to create manifest: %v", err)
+		}
+		err = rc.ManifestPut(ctx, r.SetTag(tag), m)
+		if err != nil {
+			t.Fatalf("failed to put %s: %v", tag, err)
+		}
+		digests[tag] = m.GetDescriptor().Digest.String()
+		// latest shares the manifest of a pruned tag
+		if tag == "2.0.0-rc1" {
+			err = rc.ManifestPut(ctx, r.SetTag("latest"), m)
+			if err != nil {
+				t.Fatalf("failed to put latest: %v", err)
+			}
+		}
+	}
+	tr := TagRetention{
+		Match:      regexp.MustCompile(`^(?:(\d+)\..*|nightly-.*)$`),
+		KeepLast:   1,
+		KeepNewer:  time.Hour * 24,
+		KeepSemver: true,
+	}
+	expect := map[string]string{
+		"1.0.0":     "semver release",
+		"1.1.0-rc1": "pruned",
+		"1.1.0-rc2": "last 1",
+		"2.0.0-rc1": "pruned",
+		"2.0.0-rc2": "last 1",
+		"latest":    "not matched",
+		"nightly-a": "pruned",
+		"nightly-b": "newer than 24h0m0s",
+		"nightly-c": "newer than 24h0m0s",
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		entries, err := rc.TagPrune(ctx, r, tr, TagPruneWithDryRun())
+		if err != nil {
+			t.Fatalf("failed to prune: %v", err)
+		}
+		if len(entries) != len(expect) {
+			t.Fatalf("unexpected number of entries, expected %d, received %v", len(expect), entries)
+		}
+		for _, e := range entries {
+			if e.Reason != expect[e.Tag] || e.Keep != (expect[e.Tag] != "pruned") {
+				t.Errorf("unexpected entry for %s, expected %s, received %v", e.Tag, expect[e.Tag], e)
+			}
+		}
+		tl, err := rc.TagList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		tags, _ := tl.GetTags()
+		if len(tags) != len(expect) {
+			t.Errorf("tags deleted on dry run: %v", tags)
+		}
+	})
+	t.Run("prune", func(t *testing.T) {
+		_, err := rc.TagPrune(ctx, r, tr)
+		if err != nil {
+			t.Fatalf("failed to prune: %v", err)
+		}
+		tl, err := rc.TagList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		tags, _ := tl.GetTags()
+		found := map[string]bool{}
+		for _, tag := range tags {
+			found[tag] = true
+		}
+		for tag, reason := range expect {
+			if found[tag] == (reason == "pruned") {
+				t.Errorf("unexpected tag state for %s, expected %s, found %t", tag, reason, found[tag])
+			}
+		}
+		// the shared manifest remains with the latest tag
+		_, err = rc.ManifestHead(ctx, r.SetDigest(digests["2.0.0-rc1"]))
+		if err != nil {
+			t.Errorf("shared manifest was deleted: %v", err)
+		}
+		_, err = rc.ManifestHead(ctx, r.SetDigest(digests["1.1.0-rc1"]))
+		if err == nil {
+			t.Errorf("pruned manifest was not deleted")
+		}
+	})
+	t.Run("shared child", func(t *testing.T) {
+		rSrc, err := ref.New("ocidir://testrepo:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rChild, err := ref.New("ocidir://testprunechild")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc, rChild.SetTag("v1"))
+		if err != nil {
+			t.Fatalf("failed to copy v1: %v", err)
+		}
+		mIndex, err := rc.ManifestGet(ctx, rChild.SetTag("v1"))
+		if err != nil {
+			t.Fatalf("failed to get v1: %v", err)
+		}
+		dAMD64, err := manifest.GetPlatformDesc(mIndex, &platform.Platform{OS: "linux", Architecture: "amd64"})
+		if err != nil {
+			t.Fatalf("failed to get amd64 descriptor: %v", err)
+		}
+		mAMD64, err := rc.ManifestGet(ctx, rChild.SetDigest(dAMD64.Digest.String()))
+		if err != nil {
+			t.Fatalf("failed to get amd64 manifest: %v", err)
+		}
+		err = rc.ManifestPut(ctx, rChild.SetTag("v1-amd64"), mAMD64)
+		if err != nil {
+			t.Fatalf("failed to put v1-amd64: %v", err)
+		}
+		entries, err := rc.TagPrune(ctx, rChild, TagRetention{Match: regexp.MustCompile(`-amd64$`)})
+		if err != nil {
+			t.Fatalf("failed to prune: %v", err)
+		}
+		for _, e := range entries {
+			if e.Keep != (e.Tag == "v1") {
+				t.Errorf("unexpected entry: %v", e)
+			}
+		}
+		tl, err := rc.TagList(ctx, rChild)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		tags, _ := tl.GetTags()
+		if len(tags) != 1 || tags[0] != "v1" {
+			t.Errorf("unexpected tags after prune: %v", tags)
+		}
+		// the child of the kept index must remain
+		_, err = rc.ManifestHead(ctx, rChild.SetDigest(dAMD64.Digest.String()))
+		if err != nil {
+			t.Errorf("child manifest of a kept index was deleted: %v", err)
+		}
+	})
+	t.Run("missing repo", func(t *testing.T) {
+		_, err := rc.TagPrune(ctx, ref.Ref{}, tr)
+		if !errors.Is(err, types.ErrInvalidReference) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrInvalidReference, err)
+		}
+	})
+}
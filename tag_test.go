@@ -0,0 +1,338 @@
+package regclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestTagRollback(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r1, err := ref.New("ocidir://testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	r2, err := ref.New("ocidir://testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New("ocidir://testrepo:rollback")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	m1, err := rc.ManifestGet(ctx, r1)
+	if err != nil {
+		t.Fatalf("failed to get v1: %v", err)
+	}
+	m2, err := rc.ManifestGet(ctx, r2)
+	if err != nil {
+		t.Fatalf("failed to get v2: %v", err)
+	}
+
+	t.Run("empty layout", func(t *testing.T) {
+		rEmpty, err := ref.New("ocidir://testempty:rollback")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rc.ManifestPut(ctx, rEmpty, m1, WithManifestRecordPrevious())
+		if err != nil {
+			t.Fatalf("failed to put to an empty layout: %v", err)
+		}
+		mh, err := rc.ManifestHead(ctx, rEmpty)
+		if err != nil {
+			t.Fatalf("failed to head target: %v", err)
+		}
+		if mh.GetDescriptor().Digest != m1.GetDescriptor().Digest {
+			t.Errorf("unexpected digest, expected %s, received %s", m1.GetDescriptor().Digest, mh.GetDescriptor().Digest)
+		}
+	})
+	t.Run("missing annotation", func(t *testing.T) {
+		err := rc.ManifestPut(ctx, rTgt, m1, WithManifestRecordPrevious())
+		if err != nil {
+			t.Fatalf("failed to put v1: %v", err)
+		}
+		_, err = rc.TagRollback(ctx, rTgt)
+		if !errors.Is(err, types.ErrMissingAnnotation) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrMissingAnnotation, err)
+		}
+	})
+	t.Run("rollback", func(t *testing.T) {
+		dig2 := m2.GetDescriptor().Digest
+		err := rc.ManifestPut(ctx, rTgt, m2, WithManifestRecordPrevious())
+		if err != nil {
+			t.Fatalf("failed to put v2: %v", err)
+		}
+		if m2.GetDescriptor().Digest != dig2 {
+			t.Errorf("caller's manifest was modified, digest changed from %s to %s", dig2, m2.GetDescriptor().Digest)
+		}
+		mTgt, err := rc.ManifestGet(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to get target: %v", err)
+		}
+		annot, err := mTgt.(manifest.Annotator).GetAnnotations()
+		if err != nil {
+			t.Fatalf("failed to get annotations: %v", err)
+		}
+		if annot[types.AnnotationPreviousDigest] != m1.GetDescriptor().Digest.String() {
+			t.Errorf("previous digest annotation, expected %s, received %s", m1.GetDescriptor().Digest.String(), annot[types.AnnotationPreviousDigest])
+		}
+		mRb, err := rc.TagRollback(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to rollback: %v", err)
+		}
+		if mRb.GetDescriptor().Digest != m1.GetDescriptor().Digest {
+			t.Errorf("rollback digest, expected %s, received %s", m1.GetDescriptor().Digest, mRb.GetDescriptor().Digest)
+		}
+		mh, err := rc.ManifestHead(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to head target: %v", err)
+		}
+		if mh.GetDescriptor().Digest != m1.GetDescriptor().Digest {
+			t.Errorf("tag digest after rollback, expected %s, received %s", m1.GetDescriptor().Digest, mh.GetDescriptor().Digest)
+		}
+	})
+	t.Run("missing tag", func(t *testing.T) {
+		_, err := rc.TagRollback(ctx, rTgt.SetDigest(m1.GetDescriptor().Digest.String()))
+		if !errors.Is(err, types.ErrMissingTag) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrMissingTag, err)
+		}
+	})
+}
+
+func TestTagExportImport(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	ts, err := rc.TagExport(ctx, r)
+	if err != nil {
+		t.Fatalf("failed to export tags: %v", err)
+	}
+	if len(ts.Tags) == 0 || ts.Tags["v1"].Digest == "" || ts.Tags["v2"].Digest == "" {
+		t.Fatalf("missing tags from export: %v", ts.Tags)
+	}
+	dV1 := ts.Tags["v1"].Digest
+	dV2 := ts.Tags["v2"].Digest
+
+	t.Run("missing manifest", func(t *testing.T) {
+		tsBad := TagState{Tags: map[string]types.Descriptor{
+			"v1": {Digest: "sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+		}}
+		err := rc.TagImport(ctx, r, tsBad)
+		if err == nil {
+			t.Errorf("import with a missing manifest did not fail")
+		}
+		m, err := rc.ManifestHead(ctx, r.SetTag("v1"))
+		if err != nil {
+			t.Fatalf("failed to head v1: %v", err)
+		}
+		if m.GetDescriptor().Digest != dV1 {
+			t.Errorf("v1 was modified by a failed import")
+		}
+	})
+	t.Run("restore", func(t *testing.T) {
+		// move v1 and add a new tag before restoring the snapshot
+		m2, err := rc.ManifestGet(ctx, r.SetTag("v2"))
+		if err != nil {
+			t.Fatalf("failed to get v2: %v", err)
+		}
+		for _, tag := range []string{"v1", "extra"} {
+			err = rc.ManifestPut(ctx, r.SetTag(tag), m2)
+			if err != nil {
+				t.Fatalf("failed to put %s: %v", tag, err)
+			}
+		}
+		err = rc.TagImport(ctx, r, ts, TagImportWithPrune())
+		if err != nil {
+			t.Fatalf("failed to import tags: %v", err)
+		}
+		for tag, d := range map[string]digest.Digest{"v1": dV1, "v2": dV2} {
+			m, err := rc.ManifestHead(ctx, r.SetTag(tag))
+			if err != nil {
+				t.Fatalf("failed to head %s: %v", tag, err)
+			}
+			if m.GetDescriptor().Digest != d {
+				t.Errorf("digest mismatch for %s, expected %s, received %s", tag, d, m.GetDescriptor().Digest)
+			}
+		}
+		_, err = rc.ManifestHead(ctx, r.SetTag("extra"))
+		if err == nil {
+			t.Errorf("extra tag was not pruned")
+		}
+	})
+}
+
+func TestTagRename(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	mV1, err := rc.ManifestHead(ctx, r.SetTag("v1"), WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head v1: %v", err)
+	}
+	dV1 := mV1.GetDescriptor().Digest
+
+	t.Run("same name", func(t *testing.T) {
+		_, err := rc.TagRename(ctx, r.SetTag("v1"), r.SetTag("v1"))
+		if !errors.Is(err, types.ErrInvalidReference) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("existing target", func(t *testing.T) {
+		_, err := rc.TagRename(ctx, r.SetTag("v1"), r.SetTag("v2"))
+		if !errors.Is(err, types.ErrMismatch) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("dry run", func(t *testing.T) {
+		e, err := rc.TagRename(ctx, r.SetTag("v1"), r.SetTag("v1-dry"), RenameWithDryRun())
+		if err != nil {
+			t.Fatalf("failed to rename: %v", err)
+		}
+		if e.Digest != dV1 {
+			t.Errorf("unexpected digest, expected %s, received %s", dV1, e.Digest)
+		}
+		_, err = rc.ManifestHead(ctx, r.SetTag("v1-dry"))
+		if err == nil {
+			t.Errorf("dry run created the target tag")
+		}
+	})
+	t.Run("rename", func(t *testing.T) {
+		rTgt, err := ref.New("ocidir://testrename:v2-renamed")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		e, err := rc.TagRename(ctx, r.SetTag("v2"), rTgt, RenameWithReferrers())
+		if err != nil {
+			t.Fatalf("failed to rename: %v", err)
+		}
+		m, err := rc.ManifestHead(ctx, rTgt, WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head target: %v", err)
+		}
+		if m.GetDescriptor().Digest != e.Digest {
+			t.Errorf("unexpected digest, expected %s, received %s", e.Digest, m.GetDescriptor().Digest)
+		}
+		rl, err := rc.ReferrerList(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if len(rl.Descriptors) == 0 {
+			t.Errorf("referrers were not copied")
+		}
+		_, err = rc.ManifestHead(ctx, r.SetTag("v2"))
+		if err == nil {
+			t.Errorf("source tag was not deleted")
+		}
+	})
+}
+
+func TestTagDigestWatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo:watch")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	m1, err := rc.ManifestGet(ctx, r.SetTag("v1"))
+	if err != nil {
+		t.Fatalf("failed to get v1: %v", err)
+	}
+	m2, err := rc.ManifestGet(ctx, r.SetTag("v2"))
+	if err != nil {
+		t.Fatalf("failed to get v2: %v", err)
+	}
+	err = rc.ManifestPut(ctx, r, m1)
+	if err != nil {
+		t.Fatalf("failed to put v1: %v", err)
+	}
+
+	t.Run("missing digest", func(t *testing.T) {
+		err := rc.TagDigestWatch(ctx, r.SetDigest(m1.GetDescriptor().Digest.String()), func(e TagDigestEvent) error { return nil })
+		if !errors.Is(err, types.ErrMissingTag) {
+			t.Errorf("unexpected error, expected %v, received %v", types.ErrMissingTag, err)
+		}
+	})
+	t.Run("changes", func(t *testing.T) {
+		events := []TagDigestEvent{}
+		err := rc.TagDigestWatch(ctx, r, func(e TagDigestEvent) error {
+			events = append(events, e)
+			// each event triggers the next change to the tag
+			switch len(events) {
+			case 1:
+				return rc.ManifestPut(ctx, r, m2)
+			case 2:
+				return rc.TagDelete(ctx, r)
+			}
+			return nil
+		}, TagWatchWithInterval(time.Millisecond), TagWatchWithCount(3))
+		if err != nil {
+			t.Fatalf("failed to watch tag: %v", err)
+		}
+		if len(events) != 3 {
+			t.Fatalf("unexpected number of events, expected 3, received %d", len(events))
+		}
+		dig1, dig2 := m1.GetDescriptor().Digest, m2.GetDescriptor().Digest
+		if events[0].Previous != "" || events[0].Digest != dig1 {
+			t.Errorf("unexpected first event: %v", events[0])
+		}
+		if events[1].Previous != dig1 || events[1].Digest != dig2 {
+			t.Errorf("unexpected second event: %v", events[1])
+		}
+		if events[2].Previous != dig2 || events[2].Digest != "" {
+			t.Errorf("unexpected delete event: %v", events[2])
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctxC, cancel := context.WithCancel(ctx)
+		err := rc.TagDigestWatch(ctxC, r, func(e TagDigestEvent) error {
+			cancel()
+			return nil
+		}, TagWatchWithInterval(time.Hour))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error, expected %v, received %v", context.Canceled, err)
+		}
+	})
+}
@@ -70,3 +70,19 @@ const (
 	// AnnotationReferrersFiltersApplied is the annotation key for the comma separated list of filters applied by the registry in the referrers listing.
 	AnnotationReferrersFiltersApplied = "org.opencontainers.referrers.filtersApplied"
 )
+
+const (
+	// AnnotationPreviousDigest is the annotation key for the digest a tag referenced before it was overwritten.
+	// This is set by regclient when requested on a manifest put, and used to rollback a tag.
+	AnnotationPreviousDigest = "org.regclient.previous.digest"
+
+	// AnnotationSyncSource is the annotation key for the source reference of an image copied by regsync.
+	AnnotationSyncSource = "org.regclient.sync.source"
+	// AnnotationSyncSourceDigest is the annotation key for the source digest of an image copied by regsync.
+	// This is used to detect changes to the source since the target digest differs after annotating.
+	AnnotationSyncSourceDigest = "org.regclient.sync.source.digest"
+	// AnnotationSyncTime is the annotation key for the date and time an image was copied by regsync, conforming to RFC 3339.
+	AnnotationSyncTime = "org.regclient.sync.time"
+	// AnnotationSyncVersion is the annotation key for the version of regsync that copied the image.
+	AnnotationSyncVersion = "org.regclient.sync.version"
+)
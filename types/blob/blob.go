@@ -38,13 +38,14 @@ type Blob interface {
 }
 
 type blobConfig struct {
-	desc    types.Descriptor
-	header  http.Header
-	image   *v1.Image
-	r       ref.Ref
-	rdr     io.Reader
-	resp    *http.Response
-	rawBody []byte
+	desc      types.Descriptor
+	digestAlg digest.Algorithm
+	header    http.Header
+	image     *v1.Image
+	r         ref.Ref
+	rdr       io.Reader
+	resp      *http.Response
+	rawBody   []byte
 }
 
 // Opts is used for options to create a new blob.
@@ -57,6 +58,14 @@ func WithDesc(d types.Descriptor) Opts {
 	}
 }
 
+// WithDigestAlgorithm sets the algorithm used to compute the digest of an OCIConfig.
+// The default is the algorithm of the digest in [WithDesc], falling back to sha256.
+func WithDigestAlgorithm(alg digest.Algorithm) Opts {
+	return func(bc *blobConfig) {
+		bc.digestAlg = alg
+	}
+}
+
 // WithHeader defines the headers received when pulling a blob.
 func WithHeader(header http.Header) Opts {
 	return func(bc *blobConfig) {
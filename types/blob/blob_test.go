@@ -354,6 +354,54 @@ func TestReader(t *testing.T) {
 			t.Errorf("config bytes, expected %s, received %s", string(exBlob), string(bb))
 		}
 	})
+
+	t.Run("verify", func(t *testing.T) {
+		tt := []struct {
+			name      string
+			desc      types.Descriptor
+			verify    bool
+			expectErr error
+		}{
+			{
+				name:   "valid",
+				desc:   types.Descriptor{Digest: digest.FromBytes(exBlob), Size: int64(len(exBlob))},
+				verify: true,
+			},
+			{
+				name:      "bad digest",
+				desc:      types.Descriptor{Digest: digest.FromString("bad digest"), Size: int64(len(exBlob))},
+				verify:    true,
+				expectErr: types.ErrDigestMismatch,
+			},
+			{
+				name:      "short",
+				desc:      types.Descriptor{Digest: digest.FromBytes(exBlob), Size: int64(len(exBlob)) + 10},
+				verify:    true,
+				expectErr: types.ErrShortRead,
+			},
+			{
+				name: "bad digest unverified",
+				desc: types.Descriptor{Digest: digest.FromString("bad digest"), Size: int64(len(exBlob))},
+			},
+		}
+		for _, tc := range tt {
+			t.Run(tc.name, func(t *testing.T) {
+				b := NewReader(WithReader(bytes.NewReader(exBlob)), WithDesc(tc.desc))
+				b.SetVerify(tc.verify)
+				// partial read before close
+				_, err := b.Read(make([]byte, 10))
+				if err != nil {
+					t.Fatalf("read err: %v", err)
+				}
+				err = b.Close()
+				if tc.expectErr == nil && err != nil {
+					t.Errorf("unexpected error on close: %v", err)
+				} else if tc.expectErr != nil && !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+			})
+		}
+	})
 }
 
 func TestOCI(t *testing.T) {
@@ -478,6 +526,51 @@ func TestOCI(t *testing.T) {
 			t.Errorf("config bytes unchanged, received %s", string(raw))
 		}
 	})
+	t.Run("LegacyConfig", func(t *testing.T) {
+		legacyBlob := []byte(`{"architecture":"AMD64","os":"Linux","rootfs":{"diff_ids":[]},"history":[{"created_by":"/bin/sh"}]}`)
+		oc := NewOCIConfig(WithRawBody(legacyBlob))
+		ociC := oc.GetConfig()
+		if ociC.Architecture != "amd64" || ociC.OS != "linux" {
+			t.Errorf("platform not normalized, received %s/%s", ociC.OS, ociC.Architecture)
+		}
+		if ociC.RootFS.Type != "layers" {
+			t.Errorf("rootfs type, expected layers, received %s", ociC.RootFS.Type)
+		}
+		if len(ociC.History) != 1 || ociC.History[0].Created != nil {
+			t.Errorf("history modified: %v", ociC.History)
+		}
+		if len(oc.GetWarnings()) != 4 {
+			t.Errorf("unexpected warnings: %v", oc.GetWarnings())
+		}
+		if oc.GetDescriptor().Digest != digest.FromBytes(legacyBlob) {
+			t.Errorf("digest changed, expected %s, received %s", digest.FromBytes(legacyBlob), oc.GetDescriptor().Digest)
+		}
+		ocJSON := NewOCIConfig()
+		err := ocJSON.UnmarshalJSON(legacyBlob)
+		if err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if ocJSON.GetConfig().Architecture != "amd64" || len(ocJSON.GetWarnings()) != 4 {
+			t.Errorf("unmarshal did not normalize, received %s, warnings %v", ocJSON.GetConfig().Architecture, ocJSON.GetWarnings())
+		}
+	})
+	t.Run("DigestAlgorithm", func(t *testing.T) {
+		oc := NewOCIConfig(WithRawBody(exBlob), WithDigestAlgorithm(digest.SHA512))
+		if oc.GetDescriptor().Digest != digest.SHA512.FromBytes(exBlob) {
+			t.Errorf("unexpected digest, received %s", oc.GetDescriptor().Digest)
+		}
+		// changes keep the algorithm
+		ociC := oc.GetConfig()
+		ociC.Author = "test"
+		oc.SetConfig(ociC)
+		raw, err := oc.RawBody()
+		if err != nil {
+			t.Fatalf("failed to get body: %v", err)
+		}
+		if oc.GetDescriptor().Digest != digest.SHA512.FromBytes(raw) {
+			t.Errorf("unexpected digest after change, received %s", oc.GetDescriptor().Digest)
+		}
+	})
 }
 
 func TestTarReader(t *testing.T) {
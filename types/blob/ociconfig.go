@@ -3,13 +3,12 @@ package blob
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	// crypto libraries included for go-digest
 	_ "crypto/sha256"
 	_ "crypto/sha512"
 
-	"github.com/opencontainers/go-digest"
-
 	"github.com/regclient/regclient/types"
 	v1 "github.com/regclient/regclient/types/oci/v1"
 )
@@ -20,8 +19,9 @@ type OCIConfig = *BOCIConfig
 // BOCIConfig includes an OCI Image Config struct that may be extracted from or pushed to a blob.
 type BOCIConfig struct {
 	BCommon
-	rawBody []byte
-	image   v1.Image
+	rawBody  []byte
+	image    v1.Image
+	warnings []string
 }
 
 // NewOCIConfig creates a new BOCIConfig.
@@ -38,16 +38,23 @@ func NewOCIConfig(opts ...Opts) *BOCIConfig {
 			bc.rawBody = []byte{}
 		}
 	}
+	var warnings []string
 	if len(bc.rawBody) > 0 {
 		if bc.image == nil {
 			bc.image = &v1.Image{}
 			err := json.Unmarshal(bc.rawBody, bc.image)
 			if err != nil {
 				bc.image = nil
+			} else {
+				warnings = ociConfigNormalize(bc.image)
 			}
 		}
 		// force descriptor to match raw body, even if we generated the raw body
-		bc.desc.Digest = digest.FromBytes(bc.rawBody)
+		alg := bc.digestAlg
+		if alg == "" || !alg.Available() {
+			alg = descAlgorithm(bc.desc)
+		}
+		bc.desc.Digest = alg.FromBytes(bc.rawBody)
 		bc.desc.Size = int64(len(bc.rawBody))
 		if bc.desc.MediaType == "" {
 			bc.desc.MediaType = types.MediaTypeOCI1ImageConfig
@@ -60,7 +67,8 @@ func NewOCIConfig(opts ...Opts) *BOCIConfig {
 			rawHeader: bc.header,
 			resp:      bc.resp,
 		},
-		rawBody: bc.rawBody,
+		rawBody:  bc.rawBody,
+		warnings: warnings,
 	}
 	if bc.image != nil {
 		b.image = *bc.image
@@ -74,6 +82,13 @@ func (oc *BOCIConfig) GetConfig() v1.Image {
 	return oc.image
 }
 
+// GetWarnings returns any issues that were corrected when parsing the config.
+// Configs from legacy builders may have missing fields or unexpected values that are normalized in [GetConfig].
+// The raw body and digest are unchanged until the config is modified with [SetConfig].
+func (oc *BOCIConfig) GetWarnings() []string {
+	return oc.warnings
+}
+
 // RawBody returns the original body from the request.
 func (oc *BOCIConfig) RawBody() ([]byte, error) {
 	var err error
@@ -93,7 +108,7 @@ func (oc *BOCIConfig) SetConfig(image v1.Image) {
 	if oc.desc.MediaType == "" {
 		oc.desc.MediaType = types.MediaTypeOCI1ImageConfig
 	}
-	oc.desc.Digest = digest.FromBytes(oc.rawBody)
+	oc.desc.Digest = descAlgorithm(oc.desc).FromBytes(oc.rawBody)
 	oc.desc.Size = int64(len(oc.rawBody))
 	oc.blobSet = true
 }
@@ -116,13 +131,47 @@ func (oc *BOCIConfig) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	oc.warnings = ociConfigNormalize(&image)
+	oc.image = image
 	oc.rawBody = make([]byte, len(data))
 	copy(oc.rawBody, data)
 	if oc.desc.MediaType == "" {
 		oc.desc.MediaType = types.MediaTypeOCI1ImageConfig
 	}
-	oc.desc.Digest = digest.FromBytes(oc.rawBody)
+	oc.desc.Digest = descAlgorithm(oc.desc).FromBytes(oc.rawBody)
 	oc.desc.Size = int64(len(oc.rawBody))
 	oc.blobSet = true
 	return nil
 }
+
+// ociConfigNormalize corrects quirks found in configs from legacy builders, returning a list of warnings.
+func ociConfigNormalize(image *v1.Image) []string {
+	warnings := []string{}
+	if image.RootFS.Type == "" {
+		image.RootFS.Type = "layers"
+		warnings = append(warnings, "config is missing rootfs.type, defaulting to \"layers\"")
+	}
+	if lc := strings.ToLower(image.OS); lc != image.OS {
+		warnings = append(warnings, fmt.Sprintf("config os %q converted to lower case", image.OS))
+		image.OS = lc
+	}
+	if lc := strings.ToLower(image.Architecture); lc != image.Architecture {
+		warnings = append(warnings, fmt.Sprintf("config architecture %q converted to lower case", image.Architecture))
+		image.Architecture = lc
+	}
+	if lc := strings.ToLower(image.Variant); lc != image.Variant {
+		warnings = append(warnings, fmt.Sprintf("config variant %q converted to lower case", image.Variant))
+		image.Variant = lc
+	}
+	missingCreated := 0
+	for _, h := range image.History {
+		if h.Created == nil {
+			missingCreated++
+		}
+	}
+	if missingCreated > 0 {
+		// timestamps are not invented, consumers must handle a nil created value
+		warnings = append(warnings, fmt.Sprintf("config history has %d entries without a created timestamp", missingCreated))
+	}
+	return warnings
+}
@@ -25,6 +25,8 @@ type BReader struct {
 	reader    io.Reader
 	origRdr   io.Reader
 	digester  digest.Digester
+	eof       bool
+	verify    bool
 }
 
 // NewReader creates a new BReader.
@@ -66,7 +68,7 @@ func NewReader(opts ...Opts) *BReader {
 	}
 	if bc.rdr != nil {
 		br.blobSet = true
-		br.digester = digest.Canonical.Digester()
+		br.digester = readerDigester(br.desc)
 		rdr := bc.rdr
 		if br.desc.Size > 0 {
 			rdr = &limitread.LimitRead{
@@ -80,16 +82,31 @@ func NewReader(opts ...Opts) *BReader {
 }
 
 // Close attempts to close the reader and populates/validates the digest.
+// When [BReader.SetVerify] is enabled, any unread content is read first, and a size or digest mismatch is returned.
 func (r *BReader) Close() error {
+	var err error
+	if r.verify && !r.eof && r.reader != nil {
+		_, err = io.Copy(io.Discard, r)
+	}
 	if r.origRdr == nil {
-		return nil
+		return err
 	}
 	// attempt to close if available in original reader
 	bc, ok := r.origRdr.(io.Closer)
 	if !ok {
-		return nil
+		return err
+	}
+	errC := bc.Close()
+	if err != nil {
+		return err
 	}
-	return bc.Close()
+	return errC
+}
+
+// SetVerify configures [BReader.Close] to verify the size and digest of the blob when the content was not read to EOF.
+// Without this, the content is only verified by a Read that reaches the EOF.
+func (r *BReader) SetVerify(verify bool) {
+	r.verify = verify
 }
 
 // RawBody returns the original body from the request.
@@ -105,6 +122,7 @@ func (r *BReader) Read(p []byte) (int, error) {
 	size, err := r.reader.Read(p)
 	r.readBytes = r.readBytes + int64(size)
 	if err == io.EOF {
+		r.eof = true
 		// check/save size
 		if r.desc.Size == 0 {
 			r.desc.Size = r.readBytes
@@ -148,10 +166,11 @@ func (r *BReader) Seek(offset int64, whence int) (int64, error) {
 			Limit:  r.desc.Size,
 		}
 	}
-	digester := digest.Canonical.Digester()
+	digester := readerDigester(r.desc)
 	r.reader = io.TeeReader(rdr, digester.Hash())
 	r.digester = digester
 	r.readBytes = 0
+	r.eof = false
 
 	return 0, nil
 }
@@ -197,3 +216,16 @@ func (r *BReader) ToTarReader() (*BTarReader, error) {
 		WithReader(r.reader),
 	), nil
 }
+
+// readerDigester returns a digester for the algorithm of the descriptor, defaulting to the canonical algorithm.
+func readerDigester(d types.Descriptor) digest.Digester {
+	return descAlgorithm(d).Digester()
+}
+
+// descAlgorithm returns the digest algorithm of the descriptor, defaulting to the canonical algorithm.
+func descAlgorithm(d types.Descriptor) digest.Algorithm {
+	if d.Digest != "" && d.Digest.Algorithm().Available() {
+		return d.Digest.Algorithm()
+	}
+	return digest.Canonical
+}
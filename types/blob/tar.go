@@ -45,7 +45,7 @@ func NewTarReader(opts ...Opts) *BTarReader {
 	}
 	if bc.rdr != nil {
 		tr.blobSet = true
-		tr.digester = digest.Canonical.Digester()
+		tr.digester = readerDigester(tr.desc)
 		rdr := bc.rdr
 		if tr.desc.Size > 0 {
 			rdr = &limitread.LimitRead{
@@ -73,7 +73,7 @@ func (tr *BTarReader) GetTarReader() (*tar.Reader, error) {
 		return nil, fmt.Errorf("blob has no reader defined")
 	}
 	if tr.tr == nil {
-		dr, err := archive.Decompress(tr.reader)
+		dr, err := archive.DecompressMediaType(tr.reader, tr.desc.MediaType)
 		if err != nil {
 			return nil, err
 		}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 	"text/tabwriter"
@@ -51,6 +52,9 @@ var EmptyDigest = digest.FromBytes(EmptyData)
 var emptyDigest = digest.FromBytes([]byte{})
 var mtToOCI map[string]string
 
+// mediaTypeRe matches the type/subtype syntax of RFC 6838 section 4.2, parameters are not allowed.
+var mediaTypeRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9!#$&^_.+-]{0,126}/[A-Za-z0-9][A-Za-z0-9!#$&^_.+-]{0,126}$`)
+
 func init() {
 	mtToOCI = map[string]string{
 		MediaTypeDocker2ManifestList: MediaTypeOCI1ManifestList,
@@ -146,6 +150,82 @@ func (d Descriptor) Same(d2 Descriptor) bool {
 	return true
 }
 
+// ValidateOpt defines the constraints for validating a descriptor
+type ValidateOpt struct {
+	Algorithms   []digest.Algorithm // Allowed digest algorithms, defaults to sha256 and sha512
+	AnyAlgorithm bool               // Allow any available digest algorithm, ignoring Algorithms
+	MaxSize      int64              // Maximum size of the referenced content, 0 disables the limit
+	AllowUnset   bool               // Allow an unset media type, digest, and size, e.g. before a blob is pushed
+}
+
+// Validate verifies the descriptor follows the OCI constraints.
+// Failures are returned as a [DescriptorError] with the invalid field.
+func (d Descriptor) Validate(opt ValidateOpt) error {
+	// digest
+	if d.Digest == "" {
+		if !opt.AllowUnset {
+			return &DescriptorError{Field: "digest", Err: fmt.Errorf("digest is not set%.0w", ErrParsingFailed)}
+		}
+	} else {
+		algos := opt.Algorithms
+		if len(algos) == 0 {
+			algos = []digest.Algorithm{digest.SHA256, digest.SHA512}
+		}
+		alg := d.Digest.Algorithm()
+		found := false
+		if opt.AnyAlgorithm {
+			found = alg.Available()
+		} else {
+			for _, a := range algos {
+				if a == alg {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return &DescriptorError{Field: "digest", Err: fmt.Errorf("digest algorithm %s is not allowed%.0w", alg, ErrUnsupported)}
+		}
+		if err := d.Digest.Validate(); err != nil {
+			return &DescriptorError{Field: "digest", Err: fmt.Errorf("digest %s is invalid: %v%.0w", d.Digest, err, ErrParsingFailed)}
+		}
+	}
+	// size
+	if d.Size < 0 {
+		return &DescriptorError{Field: "size", Err: fmt.Errorf("size %d is negative%.0w", d.Size, ErrParsingFailed)}
+	}
+	if opt.MaxSize > 0 && d.Size > opt.MaxSize {
+		return &DescriptorError{Field: "size", Err: fmt.Errorf("size %d exceeds the limit %d%.0w", d.Size, opt.MaxSize, ErrSizeLimitExceeded)}
+	}
+	// media type and artifact type
+	if d.MediaType == "" {
+		if !opt.AllowUnset {
+			return &DescriptorError{Field: "mediaType", Err: fmt.Errorf("media type is not set%.0w", ErrParsingFailed)}
+		}
+	} else if !mediaTypeRe.MatchString(d.MediaType) {
+		return &DescriptorError{Field: "mediaType", Err: fmt.Errorf("media type %q is invalid%.0w", d.MediaType, ErrParsingFailed)}
+	}
+	if d.ArtifactType != "" && !mediaTypeRe.MatchString(d.ArtifactType) {
+		return &DescriptorError{Field: "artifactType", Err: fmt.Errorf("artifact type %q is invalid%.0w", d.ArtifactType, ErrParsingFailed)}
+	}
+	// embedded data must match the size and digest
+	if len(d.Data) > 0 {
+		if int64(len(d.Data)) != d.Size {
+			return &DescriptorError{Field: "data", Err: fmt.Errorf("data length %d does not match size %d%.0w", len(d.Data), d.Size, ErrMismatch)}
+		}
+		if d.Digest != "" && d.Digest.Validate() == nil && d.Digest.Algorithm().FromBytes(d.Data) != d.Digest {
+			return &DescriptorError{Field: "data", Err: fmt.Errorf("data does not match digest %s%.0w", d.Digest, ErrDigestMismatch)}
+		}
+	}
+	// platform requires the os and architecture
+	if d.Platform != nil {
+		if d.Platform.OS == "" || d.Platform.Architecture == "" {
+			return &DescriptorError{Field: "platform", Err: fmt.Errorf("platform %s is missing the os or architecture%.0w", d.Platform.String(), ErrParsingFailed)}
+		}
+	}
+	return nil
+}
+
 func (d Descriptor) MarshalPrettyTW(tw *tabwriter.Writer, prefix string) error {
 	fmt.Fprintf(tw, "%sDigest:\t%s\n", prefix, string(d.Digest))
 	fmt.Fprintf(tw, "%sMediaType:\t%s\n", prefix, d.MediaType)
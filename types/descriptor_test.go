@@ -448,6 +448,144 @@ func TestDescriptorEq(t *testing.T) {
 	}
 }
 
+func TestDescriptorValidate(t *testing.T) {
+	t.Parallel()
+	valid := Descriptor{
+		MediaType: MediaTypeOCI1Empty,
+		Digest:    EmptyDigest,
+		Size:      int64(len(EmptyData)),
+	}
+	tt := []struct {
+		name      string
+		d         Descriptor
+		opt       ValidateOpt
+		expectErr error
+		field     string
+	}{
+		{
+			name: "valid",
+			d:    valid,
+		},
+		{
+			name: "valid with data and platform",
+			d: Descriptor{
+				MediaType:    MediaTypeOCI1Manifest,
+				ArtifactType: "application/vnd.example+type",
+				Digest:       EmptyDigest,
+				Size:         int64(len(EmptyData)),
+				Data:         EmptyData,
+				Platform:     &platform.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			},
+		},
+		{
+			name: "unset allowed",
+			d:    Descriptor{},
+			opt:  ValidateOpt{AllowUnset: true},
+		},
+		{
+			name:      "missing digest",
+			d:         Descriptor{MediaType: MediaTypeOCI1Empty, Size: 2},
+			expectErr: ErrParsingFailed,
+			field:     "digest",
+		},
+		{
+			name:      "algorithm not allowed",
+			d:         valid,
+			opt:       ValidateOpt{Algorithms: []digest.Algorithm{digest.SHA512}},
+			expectErr: ErrUnsupported,
+			field:     "digest",
+		},
+		{
+			name: "any algorithm",
+			d:    Descriptor{MediaType: MediaTypeOCI1Empty, Digest: digest.SHA384.FromBytes(EmptyData), Size: int64(len(EmptyData))},
+			opt:  ValidateOpt{AnyAlgorithm: true},
+		},
+		{
+			name:      "any algorithm unavailable",
+			d:         Descriptor{MediaType: MediaTypeOCI1Empty, Digest: "md5:1234", Size: 2},
+			opt:       ValidateOpt{AnyAlgorithm: true},
+			expectErr: ErrUnsupported,
+			field:     "digest",
+		},
+		{
+			name:      "invalid digest",
+			d:         Descriptor{MediaType: MediaTypeOCI1Empty, Digest: "sha256:1234", Size: 2},
+			expectErr: ErrParsingFailed,
+			field:     "digest",
+		},
+		{
+			name:      "negative size",
+			d:         Descriptor{MediaType: MediaTypeOCI1Empty, Digest: EmptyDigest, Size: -1},
+			expectErr: ErrParsingFailed,
+			field:     "size",
+		},
+		{
+			name:      "size limit",
+			d:         valid,
+			opt:       ValidateOpt{MaxSize: 1},
+			expectErr: ErrSizeLimitExceeded,
+			field:     "size",
+		},
+		{
+			name:      "missing media type",
+			d:         Descriptor{Digest: EmptyDigest, Size: 2},
+			expectErr: ErrParsingFailed,
+			field:     "mediaType",
+		},
+		{
+			name:      "invalid media type",
+			d:         Descriptor{MediaType: "application/json; charset=utf-8", Digest: EmptyDigest, Size: 2},
+			expectErr: ErrParsingFailed,
+			field:     "mediaType",
+		},
+		{
+			name:      "invalid artifact type",
+			d:         Descriptor{MediaType: MediaTypeOCI1Manifest, ArtifactType: "example", Digest: EmptyDigest, Size: 2},
+			expectErr: ErrParsingFailed,
+			field:     "artifactType",
+		},
+		{
+			name:      "data size",
+			d:         Descriptor{MediaType: MediaTypeOCI1Empty, Digest: EmptyDigest, Size: 3, Data: EmptyData},
+			expectErr: ErrMismatch,
+			field:     "data",
+		},
+		{
+			name:      "data digest",
+			d:         Descriptor{MediaType: MediaTypeOCI1Empty, Digest: EmptyDigest, Size: 2, Data: []byte("[]")},
+			expectErr: ErrDigestMismatch,
+			field:     "data",
+		},
+		{
+			name:      "platform missing arch",
+			d:         Descriptor{MediaType: MediaTypeOCI1Manifest, Digest: EmptyDigest, Size: 2, Platform: &platform.Platform{OS: "linux"}},
+			expectErr: ErrParsingFailed,
+			field:     "platform",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.d.Validate(tc.opt)
+			if tc.expectErr == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.expectErr) || !errors.Is(err, ErrInvalidDescriptor) {
+				t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+			}
+			de := &DescriptorError{}
+			if !errors.As(err, &de) {
+				t.Fatalf("error is not a DescriptorError: %v", err)
+			}
+			if de.Field != tc.field {
+				t.Errorf("unexpected field, expected %s, received %s", tc.field, de.Field)
+			}
+		})
+	}
+}
+
 func TestDataJSON(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
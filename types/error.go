@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"strings"
+	"time"
 )
 
 var (
@@ -15,6 +17,8 @@ var (
 	ErrBackoffLimit = errors.New("backoff limit reached")
 	// ErrCanceled if the context was canceled
 	ErrCanceled = errors.New("context was canceled")
+	// ErrDigestAmbiguous if a digest prefix matches more than one digest
+	ErrDigestAmbiguous = errors.New("digest prefix is ambiguous")
 	// ErrDigestMismatch if the expected digest wasn't received
 	ErrDigestMismatch = errors.New("digest mismatch")
 	// ErrEmptyChallenge indicates an issue with the received challenge in the WWW-Authenticate header
@@ -27,6 +31,8 @@ var (
 	ErrHTTPStatus = errors.New("unexpected http status code")
 	// ErrInvalidChallenge indicates an issue with the received challenge in the WWW-Authenticate header
 	ErrInvalidChallenge = errors.New("invalid challenge header")
+	// ErrInvalidDescriptor indicates a descriptor does not follow the OCI constraints, see [DescriptorError]
+	ErrInvalidDescriptor = errors.New("invalid descriptor")
 	// ErrInvalidReference indicates the reference to an image is has an invalid syntax
 	ErrInvalidReference = errors.New("invalid reference")
 	// ErrLoopDetected indicates a child node points back to the parent
@@ -35,6 +41,8 @@ var (
 	ErrManifestNotSet = errors.New("manifest not set")
 	// ErrMissingAnnotation returned when a needed annotation is not found
 	ErrMissingAnnotation = errors.New("annotation is missing")
+	// ErrMissingContent returned when content referenced by a manifest is not found, see [MissingContentError]
+	ErrMissingContent = errors.New("referenced content is missing")
 	// ErrMissingDigest returned when image reference does not include a digest
 	ErrMissingDigest = errors.New("digest missing from image reference")
 	// ErrMissingLocation returned when the location header is missing
@@ -57,6 +65,8 @@ var (
 	ErrNotImplemented = errors.New("not implemented")
 	// ErrParsingFailed when a string cannot be parsed
 	ErrParsingFailed = errors.New("parsing failed")
+	// ErrRedirectDenied when an http redirect is not permitted by the host configuration
+	ErrRedirectDenied = errors.New("redirect denied")
 	// ErrRetryNeeded indicates a request needs to be retried
 	ErrRetryNeeded = errors.New("retry needed")
 	// ErrShortRead if contents are less than expected the size
@@ -82,3 +92,66 @@ var (
 	// ErrHTTPUnauthorized when authentication fails
 	ErrHTTPUnauthorized = fmt.Errorf("unauthorized%.0w", ErrHTTPStatus)
 )
+
+// RetryError is returned when a request fails after being retried.
+// Use [errors.As] to access the number of attempts and the time spent in backoff delays.
+type RetryError struct {
+	Attempts int           // number of requests sent, including mirrors
+	Wait     time.Duration // cumulative backoff delay between requests
+	Err      error         // error from the last request
+}
+
+// Error includes the attempts and wait time with the error from the last request.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (attempts %d, waited %s)", e.Err, e.Attempts, e.Wait.String())
+}
+
+// Unwrap returns the error from the last request.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// MissingContentError is returned when a manifest references content that does not exist.
+// Use [errors.As] to access the descriptors of the missing manifests and blobs.
+type MissingContentError struct {
+	Descriptors []Descriptor
+}
+
+// Error lists the digests of the missing content.
+func (e *MissingContentError) Error() string {
+	list := make([]string, len(e.Descriptors))
+	for i, d := range e.Descriptors {
+		list[i] = d.Digest.String()
+		if d.MediaType != "" {
+			list[i] += " (" + d.MediaType + ")"
+		}
+	}
+	return fmt.Sprintf("%d referenced descriptors are missing: %s", len(e.Descriptors), strings.Join(list, ", "))
+}
+
+// Unwrap returns [ErrMissingContent].
+func (e *MissingContentError) Unwrap() error {
+	return ErrMissingContent
+}
+
+// DescriptorError is returned when a descriptor fails validation.
+// Use [errors.As] to access the invalid field, and [errors.Is] to check the cause.
+type DescriptorError struct {
+	Field string // name of the invalid field, e.g. "digest" or "mediaType"
+	Err   error  // cause of the failure
+}
+
+// Error includes the invalid field and the cause.
+func (e *DescriptorError) Error() string {
+	return fmt.Sprintf("invalid descriptor %s: %v", e.Field, e.Err)
+}
+
+// Is matches [ErrInvalidDescriptor].
+func (e *DescriptorError) Is(target error) bool {
+	return target == ErrInvalidDescriptor
+}
+
+// Unwrap returns the cause of the failure.
+func (e *DescriptorError) Unwrap() error {
+	return e.Err
+}
@@ -24,6 +24,14 @@ type common struct {
 	rawBody   []byte
 }
 
+// digestAlg returns the algorithm of an existing digest when available, falling back to the canonical algorithm.
+func digestAlg(d digest.Digest) digest.Algorithm {
+	if d != "" && d.Algorithm().Available() {
+		return d.Algorithm()
+	}
+	return digest.Canonical
+}
+
 // GetDigest returns the digest
 func (m *common) GetDigest() digest.Digest {
 	return m.desc.Digest
@@ -201,7 +201,7 @@ func (m *docker1Manifest) SetOrig(origIn interface{}) error {
 	m.rawBody = mj
 	m.desc = types.Descriptor{
 		MediaType: types.MediaTypeDocker1Manifest,
-		Digest:    digest.FromBytes(mj),
+		Digest:    digestAlg(m.desc.Digest).FromBytes(mj),
 		Size:      int64(len(mj)),
 	}
 	m.Manifest = orig
@@ -226,7 +226,7 @@ func (m *docker1SignedManifest) SetOrig(origIn interface{}) error {
 	m.rawBody = mj
 	m.desc = types.Descriptor{
 		MediaType: types.MediaTypeDocker1ManifestSigned,
-		Digest:    digest.FromBytes(mj),
+		Digest:    digestAlg(m.desc.Digest).FromBytes(mj),
 		Size:      int64(len(mj)),
 	}
 	m.SignedManifest = orig
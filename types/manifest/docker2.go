@@ -328,7 +328,7 @@ func (m *docker2Manifest) updateDesc() error {
 	m.rawBody = mj
 	m.desc = types.Descriptor{
 		MediaType: types.MediaTypeDocker2Manifest,
-		Digest:    digest.FromBytes(mj),
+		Digest:    digestAlg(m.desc.Digest).FromBytes(mj),
 		Size:      int64(len(mj)),
 	}
 	return nil
@@ -341,7 +341,7 @@ func (m *docker2ManifestList) updateDesc() error {
 	m.rawBody = mj
 	m.desc = types.Descriptor{
 		MediaType: types.MediaTypeDocker2ManifestList,
-		Digest:    digest.FromBytes(mj),
+		Digest:    digestAlg(m.desc.Digest).FromBytes(mj),
 		Size:      int64(len(mj)),
 	}
 	return nil
@@ -0,0 +1,91 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/regclient/regclient/internal/limitread"
+	"github.com/regclient/regclient/types"
+)
+
+const (
+	// DefaultMaxSize is the default limit on the size of a manifest read with [WithReader].
+	DefaultMaxSize int64 = 1024 * 1024 * 8
+	// DefaultMaxDepth is the default limit on nested JSON objects and arrays in a manifest.
+	DefaultMaxDepth = 32
+)
+
+// WithMaxDepth limits the nesting of JSON objects and arrays in the manifest, 0 or less disables the limit.
+func WithMaxDepth(depth int) Opts {
+	return func(mc *manifestConfig) {
+		mc.maxDepth = depth
+	}
+}
+
+// WithMaxSize limits the size of the manifest, 0 or less disables the limit.
+func WithMaxSize(size int64) Opts {
+	return func(mc *manifestConfig) {
+		mc.maxSize = size
+	}
+}
+
+// WithReader provides the manifest from a reader, e.g. an HTTP response body.
+// The reader is parsed as it is read, failing early when the size or depth limits are exceeded.
+func WithReader(rdr io.Reader) Opts {
+	return func(mc *manifestConfig) {
+		mc.rdr = rdr
+	}
+}
+
+// readLimit returns the content of the reader after verifying it is a single JSON value within the limits.
+func readLimit(rdr io.Reader, maxSize int64, maxDepth int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if maxSize > 0 {
+		rdr = &limitread.LimitRead{Reader: rdr, Limit: maxSize}
+	}
+	err := jsonLimit(io.TeeReader(rdr, buf), maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonLimit tokenizes a JSON value from the reader, returning an error when the depth is exceeded or more than one value is found.
+func jsonLimit(rdr io.Reader, maxDepth int) error {
+	dec := json.NewDecoder(rdr)
+	depth := 0
+	values := 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if depth == 0 {
+			values++
+			if values > 1 {
+				return fmt.Errorf("manifest contains more than one JSON value%.0w", types.ErrParsingFailed)
+			}
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+				if maxDepth > 0 && depth > maxDepth {
+					return fmt.Errorf("manifest exceeds the depth limit of %d%.0w", maxDepth, types.ErrSizeLimitExceeded)
+				}
+			case '}', ']':
+				depth--
+			}
+		}
+	}
+	if values == 0 {
+		return fmt.Errorf("manifest is empty%.0w", types.ErrParsingFailed)
+	}
+	return nil
+}
@@ -3,8 +3,10 @@
 package manifest
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -90,20 +92,46 @@ type Subjecter interface {
 }
 
 type manifestConfig struct {
-	r      ref.Ref
-	desc   types.Descriptor
-	raw    []byte
-	orig   interface{}
-	header http.Header
+	r         ref.Ref
+	desc      types.Descriptor
+	raw       []byte
+	rdr       io.Reader
+	orig      interface{}
+	header    http.Header
+	maxSize   int64
+	maxDepth  int
+	digestAlg digest.Algorithm
 }
 type Opts func(*manifestConfig)
 
 // New creates a new manifest based on provided options.
+// Manifests from [WithRaw] or [WithReader] are limited to [DefaultMaxSize] and [DefaultMaxDepth] unless changed.
 func New(opts ...Opts) (Manifest, error) {
-	mc := manifestConfig{}
+	mc := manifestConfig{
+		maxSize:  DefaultMaxSize,
+		maxDepth: DefaultMaxDepth,
+	}
 	for _, opt := range opts {
 		opt(&mc)
 	}
+	if size := int64(len(mc.raw)); mc.maxSize > 0 && (size > mc.maxSize || mc.desc.Size > mc.maxSize) {
+		if mc.desc.Size > size {
+			size = mc.desc.Size
+		}
+		return nil, fmt.Errorf("manifest too large, size %d, limit %d%.0w", size, mc.maxSize, types.ErrSizeLimitExceeded)
+	}
+	if mc.rdr != nil {
+		raw, err := readLimit(mc.rdr, mc.maxSize, mc.maxDepth)
+		if err != nil {
+			return nil, err
+		}
+		mc.raw = raw
+	} else if len(mc.raw) > 0 && mc.maxDepth > 0 {
+		err := jsonLimit(bytes.NewReader(mc.raw), mc.maxDepth)
+		if err != nil {
+			return nil, err
+		}
+	}
 	c := common{
 		r:         mc.r,
 		desc:      mc.desc,
@@ -124,10 +152,26 @@ func New(opts ...Opts) (Manifest, error) {
 		}
 		c.setRateLimit(mc.header)
 	}
+	// compute new digests with the requested algorithm, the algorithm of the expected digest, or the canonical algorithm
+	alg := mc.digestAlg
+	if alg == "" {
+		alg = digestAlg(c.desc.Digest)
+	}
+	if !alg.Available() {
+		return nil, fmt.Errorf("digest algorithm %s is not available%.0w", alg, types.ErrUnsupported)
+	}
 	if mc.orig != nil {
-		return fromOrig(c, mc.orig)
+		return fromOrig(c, mc.orig, alg)
+	}
+	return fromCommon(c, alg)
+}
+
+// WithDigestAlgorithm sets the algorithm used to compute the manifest digest.
+// The default is the algorithm of the digest in [WithDesc] or [WithHeader], falling back to sha256.
+func WithDigestAlgorithm(alg digest.Algorithm) Opts {
+	return func(mc *manifestConfig) {
+		mc.digestAlg = alg
 	}
-	return fromCommon(c)
 }
 
 // WithDesc specifies the descriptor for the manifest.
@@ -177,6 +221,33 @@ func GetMediaType(m Manifest) string {
 	return d.MediaType
 }
 
+// GetMemberDesc returns the descriptor of a child manifest from an index.
+// The member is either the zero based position in the list or a platform, e.g. "linux/arm64".
+// The platform "local" selects the platform of the running system.
+func GetMemberDesc(m Manifest, member string) (*types.Descriptor, error) {
+	dl, err := m.GetManifestList()
+	if err != nil {
+		return nil, err
+	}
+	if i, err := strconv.Atoi(member); err == nil {
+		if i < 0 || i >= len(dl) {
+			return nil, fmt.Errorf("member %d is outside of the manifest list with %d entries%.0w", i, len(dl), types.ErrNotFound)
+		}
+		d := dl[i]
+		return &d, nil
+	}
+	var p platform.Platform
+	if member == "local" {
+		p = platform.Local()
+	} else {
+		p, err = platform.Parse(member)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse member %s: %w", member, err)
+		}
+	}
+	return GetPlatformDesc(m, &p)
+}
+
 // GetPlatformDesc returns the descriptor for a specific platform from an index.
 func GetPlatformDesc(m Manifest, p *platform.Platform) (*types.Descriptor, error) {
 	dl, err := m.GetManifestList()
@@ -359,7 +430,7 @@ func OCIManifestToAny(ociM v1.Manifest, origP interface{}) error {
 
 // FromOrig creates a new manifest from the original upstream manifest type.
 // This method should be used if you are creating a new manifest rather than pulling one from a registry.
-func fromOrig(c common, orig interface{}) (Manifest, error) {
+func fromOrig(c common, orig interface{}, alg digest.Algorithm) (Manifest, error) {
 	var mt string
 	var m Manifest
 	origDigest := c.desc.Digest
@@ -373,7 +444,7 @@ func fromOrig(c common, orig interface{}) (Manifest, error) {
 		c.rawBody = mj
 	}
 	if _, ok := orig.(schema1.SignedManifest); !ok {
-		c.desc.Digest = digest.FromBytes(mj)
+		c.desc.Digest = alg.FromBytes(mj)
 	}
 	if c.desc.Size == 0 {
 		c.desc.Size = int64(len(mj))
@@ -391,7 +462,7 @@ func fromOrig(c common, orig interface{}) (Manifest, error) {
 		mt = mOrig.MediaType
 		c.desc.MediaType = types.MediaTypeDocker1ManifestSigned
 		// recompute digest on the canonical data
-		c.desc.Digest = digest.FromBytes(mOrig.Canonical)
+		c.desc.Digest = alg.FromBytes(mOrig.Canonical)
 		m = &docker1SignedManifest{
 			common:         c,
 			SignedManifest: mOrig,
@@ -447,7 +518,7 @@ func fromOrig(c common, orig interface{}) (Manifest, error) {
 }
 
 // fromCommon is used to create a manifest when the underlying manifest struct is not provided.
-func fromCommon(c common) (Manifest, error) {
+func fromCommon(c common, alg digest.Algorithm) (Manifest, error) {
 	var err error
 	var m Manifest
 	var mt string
@@ -487,7 +558,7 @@ func fromCommon(c common) (Manifest, error) {
 		}
 		// compute digest
 		if c.desc.MediaType != types.MediaTypeDocker1ManifestSigned {
-			d := digest.FromBytes(c.rawBody)
+			d := alg.FromBytes(c.rawBody)
 			c.desc.Digest = d
 			c.desc.Size = int64(len(c.rawBody))
 		}
@@ -505,7 +576,7 @@ func fromCommon(c common) (Manifest, error) {
 		if len(c.rawBody) > 0 {
 			err = json.Unmarshal(c.rawBody, &mOrig)
 			mt = mOrig.MediaType
-			d := digest.FromBytes(mOrig.Canonical)
+			d := alg.FromBytes(mOrig.Canonical)
 			c.desc.Digest = d
 			c.desc.Size = int64(len(mOrig.Canonical))
 		}
@@ -549,7 +620,7 @@ func fromCommon(c common) (Manifest, error) {
 		return nil, fmt.Errorf("%w: \"%s\"", types.ErrUnsupportedMediaType, c.desc.MediaType)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling manifest for %s: %w", c.r.CommonName(), err)
+		return nil, fmt.Errorf("error unmarshaling manifest%s: %w", errRefName(c.r), err)
 	}
 	// verify media type
 	err = verifyMT(c.desc.MediaType, mt)
@@ -560,9 +631,56 @@ func fromCommon(c common) (Manifest, error) {
 	if origDigest != "" && origDigest != c.desc.Digest {
 		return nil, fmt.Errorf("manifest digest mismatch, expected %s, computed %s", origDigest, c.desc.Digest)
 	}
+	// verify descriptors in the parsed content, schema1 layers do not include a media type
+	if len(c.rawBody) > 0 && c.desc.MediaType != types.MediaTypeDocker1Manifest && c.desc.MediaType != types.MediaTypeDocker1ManifestSigned {
+		err = verifyDescriptors(m)
+		if err != nil {
+			return nil, fmt.Errorf("manifest%s: %w", errRefName(c.r), err)
+		}
+	}
 	return m, nil
 }
 
+// verifyDescriptors validates each descriptor referenced by the manifest.
+// Any available digest algorithm is accepted, callers may validate with an allowlist.
+func verifyDescriptors(m Manifest) error {
+	dl := []types.Descriptor{}
+	if mi, ok := m.(Indexer); ok {
+		ml, err := mi.GetManifestList()
+		if err == nil {
+			dl = append(dl, ml...)
+		}
+	}
+	if mi, ok := m.(Imager); ok {
+		if d, err := mi.GetConfig(); err == nil && d.Digest != "" {
+			dl = append(dl, d)
+		}
+		if ll, err := mi.GetLayers(); err == nil {
+			dl = append(dl, ll...)
+		}
+	}
+	if ms, ok := m.(Subjecter); ok {
+		if d, err := ms.GetSubject(); err == nil && d != nil {
+			dl = append(dl, *d)
+		}
+	}
+	for _, d := range dl {
+		err := d.Validate(types.ValidateOpt{AnyAlgorithm: true})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errRefName returns the reference for an error message, empty when the reference is not set.
+func errRefName(r ref.Ref) string {
+	if r.IsZero() {
+		return ""
+	}
+	return " for " + r.CommonName()
+}
+
 func verifyMT(expected, received string) error {
 	if received != "" && expected != received {
 		return fmt.Errorf("manifest contains an unexpected media type: expected %s, received %s", expected, received)
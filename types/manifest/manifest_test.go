@@ -1,10 +1,12 @@
 package manifest
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -1076,6 +1078,200 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestDigestAlgorithm(t *testing.T) {
+	t.Parallel()
+	// parse raw content with the algorithm of the descriptor
+	dig := digest.SHA512.FromBytes(rawDockerSchema2)
+	m, err := New(WithRaw(rawDockerSchema2), WithDesc(types.Descriptor{Digest: dig}))
+	if err != nil {
+		t.Fatalf("failed to parse manifest: %v", err)
+	}
+	if m.GetDescriptor().Digest != dig {
+		t.Errorf("unexpected digest, expected %s, received %s", dig, m.GetDescriptor().Digest)
+	}
+	_, err = New(WithRaw(rawDockerSchema2), WithDesc(types.Descriptor{Digest: digest.SHA512.FromString("other")}))
+	if err == nil {
+		t.Errorf("digest mismatch did not fail")
+	}
+	// create from an orig with a requested algorithm, changes keep the algorithm
+	orig := m.GetOrig()
+	m, err = New(WithOrig(orig), WithDigestAlgorithm(digest.SHA512))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	if m.GetDescriptor().Digest.Algorithm() != digest.SHA512 {
+		t.Errorf("unexpected digest: %s", m.GetDescriptor().Digest)
+	}
+	err = m.(Annotator).SetAnnotation("test", "value")
+	if err != nil {
+		t.Fatalf("failed to set annotation: %v", err)
+	}
+	raw, err := m.RawBody()
+	if err != nil {
+		t.Fatalf("failed to get body: %v", err)
+	}
+	if m.GetDescriptor().Digest != digest.SHA512.FromBytes(raw) {
+		t.Errorf("unexpected digest after change: %s", m.GetDescriptor().Digest)
+	}
+	_, err = New(WithOrig(orig), WithDigestAlgorithm("md5"))
+	if !errors.Is(err, types.ErrUnsupported) {
+		t.Errorf("unexpected error for unavailable algorithm: %v", err)
+	}
+}
+
+func TestNewLimits(t *testing.T) {
+	t.Parallel()
+	rawDeep := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","annotations":{"a":"b"},"x":` + strings.Repeat("[", 40) + strings.Repeat("]", 40) + `}`)
+	tt := []struct {
+		name      string
+		opts      []Opts
+		expectErr error
+	}{
+		{
+			name: "reader",
+			opts: []Opts{WithReader(bytes.NewReader(rawDockerSchema2))},
+		},
+		{
+			name:      "reader over size",
+			opts:      []Opts{WithReader(bytes.NewReader(rawDockerSchema2)), WithMaxSize(100)},
+			expectErr: types.ErrSizeLimitExceeded,
+		},
+		{
+			name:      "raw over size",
+			opts:      []Opts{WithRaw(rawDockerSchema2), WithMaxSize(100)},
+			expectErr: types.ErrSizeLimitExceeded,
+		},
+		{
+			name:      "descriptor over size",
+			opts:      []Opts{WithReader(bytes.NewReader(rawDockerSchema2)), WithDesc(types.Descriptor{Size: DefaultMaxSize + 1})},
+			expectErr: types.ErrSizeLimitExceeded,
+		},
+		{
+			name:      "reader over depth",
+			opts:      []Opts{WithReader(bytes.NewReader(rawDeep))},
+			expectErr: types.ErrSizeLimitExceeded,
+		},
+		{
+			name:      "raw over depth",
+			opts:      []Opts{WithRaw(rawDeep)},
+			expectErr: types.ErrSizeLimitExceeded,
+		},
+		{
+			name: "limits disabled",
+			opts: []Opts{WithReader(bytes.NewReader(rawDeep)), WithMaxDepth(0), WithMaxSize(0)},
+		},
+		{
+			name: "sha384 descriptor",
+			opts: []Opts{WithRaw([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha384:d2a23bc783e3aa38f401e13c7488505137c4954a7fd88331f1597c5ff71111dc807c7370a5b282c6da541c56ede69f30","size":2},"layers":[]}`))},
+		},
+		{
+			name:      "invalid descriptor",
+			opts:      []Opts{WithRaw([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"md5:1234","size":10}]}`))},
+			expectErr: types.ErrInvalidDescriptor,
+		},
+		{
+			name:      "multiple values",
+			opts:      []Opts{WithReader(bytes.NewReader(append(append([]byte{}, rawDockerSchema2...), rawDockerSchema2...)))},
+			expectErr: types.ErrParsingFailed,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := New(tc.opts...)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to create manifest: %v", err)
+			}
+			raw, err := m.RawBody()
+			if err != nil {
+				t.Fatalf("failed to get raw body: %v", err)
+			}
+			if int64(len(raw)) != m.GetDescriptor().Size || digest.FromBytes(raw) != m.GetDescriptor().Digest {
+				t.Errorf("descriptor does not match the body: %v", m.GetDescriptor())
+			}
+		})
+	}
+}
+
+func TestGetMemberDesc(t *testing.T) {
+	t.Parallel()
+	mList, err := New(WithRaw(rawDockerSchema2List), WithDesc(types.Descriptor{
+		MediaType: types.MediaTypeDocker2ManifestList,
+		Digest:    digestDockerSchema2List,
+		Size:      int64(len(rawDockerSchema2List)),
+	}))
+	if err != nil {
+		t.Fatalf("failed to create manifest list: %v", err)
+	}
+	mImage, err := New(WithRaw(rawOCIImage))
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+	tt := []struct {
+		name      string
+		m         Manifest
+		member    string
+		expect    digest.Digest
+		expectErr error
+	}{
+		{
+			name:   "position",
+			m:      mList,
+			member: "1",
+			expect: "sha256:41b9947d8f19e154a5415c88ef71b851d37fa3ceb1de56ffe88d1b616ce503d9",
+		},
+		{
+			name:   "platform",
+			m:      mList,
+			member: "linux/arm/v7",
+			expect: "sha256:5536e52b2508b905c7f37bf120435c3c75684bab53c04467b61904be1febe5f8",
+		},
+		{
+			name:      "position out of range",
+			m:         mList,
+			member:    "42",
+			expectErr: types.ErrNotFound,
+		},
+		{
+			name:      "platform not found",
+			m:         mList,
+			member:    "linux/riscv64",
+			expectErr: types.ErrNotFound,
+		},
+		{
+			name:      "not a list",
+			m:         mImage,
+			member:    "0",
+			expectErr: types.ErrUnsupportedMediaType,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := GetMemberDesc(tc.m, tc.member)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not fail")
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get member: %v", err)
+			}
+			if d.Digest != tc.expect {
+				t.Errorf("unexpected digest, expected %s, received %s", tc.expect, d.Digest)
+			}
+		})
+	}
+}
+
 func TestModify(t *testing.T) {
 	t.Parallel()
 	addDigest := digest.FromString("new layer digest")
@@ -546,7 +546,7 @@ func (m *oci1Manifest) updateDesc() error {
 	m.rawBody = mj
 	m.desc = types.Descriptor{
 		MediaType: types.MediaTypeOCI1Manifest,
-		Digest:    digest.FromBytes(mj),
+		Digest:    digestAlg(m.desc.Digest).FromBytes(mj),
 		Size:      int64(len(mj)),
 	}
 	return nil
@@ -559,7 +559,7 @@ func (m *oci1Index) updateDesc() error {
 	m.rawBody = mj
 	m.desc = types.Descriptor{
 		MediaType: types.MediaTypeOCI1ManifestList,
-		Digest:    digest.FromBytes(mj),
+		Digest:    digestAlg(m.desc.Digest).FromBytes(mj),
 		Size:      int64(len(mj)),
 	}
 	return nil
@@ -572,7 +572,7 @@ func (m *oci1Artifact) updateDesc() error {
 	m.rawBody = mj
 	m.desc = types.Descriptor{
 		MediaType: types.MediaTypeOCI1Artifact,
-		Digest:    digest.FromBytes(mj),
+		Digest:    digestAlg(m.desc.Digest).FromBytes(mj),
 		Size:      int64(len(mj)),
 	}
 	return nil
@@ -21,6 +21,8 @@ const (
 	MediaTypeOCI1ManifestList = "application/vnd.oci.image.index.v1+json"
 	// MediaTypeOCI1ImageConfig OCI v1 configuration json object media type.
 	MediaTypeOCI1ImageConfig = "application/vnd.oci.image.config.v1+json"
+	// MediaTypeDocker2Layer is the uncompressed layer for docker schema2.
+	MediaTypeDocker2Layer = "application/vnd.docker.image.rootfs.diff.tar"
 	// MediaTypeDocker2LayerGzip is the default compressed layer for docker schema2.
 	MediaTypeDocker2LayerGzip = "application/vnd.docker.image.rootfs.diff.tar.gzip"
 	// MediaTypeDocker2ForeignLayer is the default compressed layer for foreign layers in docker schema2.
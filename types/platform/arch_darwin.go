@@ -0,0 +1,21 @@
+//go:build darwin
+// +build darwin
+
+package platform
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// localArch returns the architecture of the host.
+// An amd64 binary translated by Rosetta on Apple Silicon reports the arm64 host.
+func localArch() string {
+	if runtime.GOARCH == "amd64" {
+		if translated, err := unix.SysctlUint32("sysctl.proc_translated"); err == nil && translated == 1 {
+			return "arm64"
+		}
+	}
+	return runtime.GOARCH
+}
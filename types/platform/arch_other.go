@@ -0,0 +1,11 @@
+//go:build !darwin
+// +build !darwin
+
+package platform
+
+import "runtime"
+
+// localArch returns the architecture of the host.
+func localArch() string {
+	return runtime.GOARCH
+}
@@ -30,7 +30,7 @@ var cpuVariantOnce sync.Once
 
 func cpuVariant() string {
 	cpuVariantOnce.Do(func() {
-		switch runtime.GOARCH {
+		switch localArch() {
 		case "arm", "arm64":
 			cpuVariantValue = getCPUVariant()
 		}
@@ -69,8 +69,8 @@ func getCPUInfo(pattern string) (info string) {
 func getCPUVariant() string {
 	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
 		// Windows/Darwin only supports v7 for ARM32 and v8 for ARM64 and so we can use
-		// runtime.GOARCH to determine the variants
-		switch runtime.GOARCH {
+		// the architecture to determine the variants
+		switch localArch() {
 		case "arm64":
 			return "v8"
 		case "arm":
@@ -90,6 +90,11 @@ func getCPUVariant() string {
 		}
 	}
 
+	// a 32-bit userland on a 64-bit kernel (e.g. Raspberry Pi OS) reports "CPU architecture: 8" but runs arm/v7 binaries
+	if runtime.GOARCH == "arm" && variant == "8" {
+		variant = "7"
+	}
+
 	switch strings.ToLower(variant) {
 	case "8", "aarch64":
 		variant = "v8"
@@ -0,0 +1,149 @@
+package platform
+
+import "strings"
+
+// knownPlatforms lists the os/arch/variant combinations commonly found in images.
+var knownPlatforms = []Platform{
+	{OS: "aix", Architecture: "ppc64"},
+	{OS: "darwin", Architecture: "amd64"},
+	{OS: "darwin", Architecture: "arm64"},
+	{OS: "freebsd", Architecture: "386"},
+	{OS: "freebsd", Architecture: "amd64"},
+	{OS: "freebsd", Architecture: "arm", Variant: "v6"},
+	{OS: "freebsd", Architecture: "arm", Variant: "v7"},
+	{OS: "freebsd", Architecture: "arm64"},
+	{OS: "freebsd", Architecture: "riscv64"},
+	{OS: "illumos", Architecture: "amd64"},
+	{OS: "linux", Architecture: "386"},
+	{OS: "linux", Architecture: "amd64"},
+	{OS: "linux", Architecture: "amd64", Variant: "v2"},
+	{OS: "linux", Architecture: "amd64", Variant: "v3"},
+	{OS: "linux", Architecture: "amd64", Variant: "v4"},
+	{OS: "linux", Architecture: "arm", Variant: "v5"},
+	{OS: "linux", Architecture: "arm", Variant: "v6"},
+	{OS: "linux", Architecture: "arm", Variant: "v7"},
+	{OS: "linux", Architecture: "arm64"},
+	{OS: "linux", Architecture: "loong64"},
+	{OS: "linux", Architecture: "mips"},
+	{OS: "linux", Architecture: "mips64"},
+	{OS: "linux", Architecture: "mips64le"},
+	{OS: "linux", Architecture: "mipsle"},
+	{OS: "linux", Architecture: "ppc64"},
+	{OS: "linux", Architecture: "ppc64le"},
+	{OS: "linux", Architecture: "riscv64"},
+	{OS: "linux", Architecture: "s390x"},
+	{OS: "netbsd", Architecture: "amd64"},
+	{OS: "netbsd", Architecture: "arm64"},
+	{OS: "openbsd", Architecture: "amd64"},
+	{OS: "openbsd", Architecture: "arm64"},
+	{OS: "solaris", Architecture: "amd64"},
+	{OS: "wasip1", Architecture: "wasm"},
+	{OS: "windows", Architecture: "386"},
+	{OS: "windows", Architecture: "amd64"},
+	{OS: "windows", Architecture: "arm64"},
+}
+
+// Known returns the os/arch/variant combinations commonly found in images.
+func Known() []Platform {
+	ret := make([]Platform, len(knownPlatforms))
+	copy(ret, knownPlatforms)
+	return ret
+}
+
+// IsKnown indicates if the os, architecture, and variant of a platform is a known combination.
+// Other fields, like the OS version, are not compared.
+func IsKnown(p Platform) bool {
+	p.normalize()
+	for _, k := range knownPlatforms {
+		if k.OS == p.OS && k.Architecture == p.Architecture && k.Variant == p.Variant {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnownOS indicates if the os is found in the known platforms.
+func isKnownOS(os string) bool {
+	for _, k := range knownPlatforms {
+		if k.OS == os {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnownArch indicates if the architecture is found in the known platforms.
+func isKnownArch(arch string) bool {
+	for _, k := range knownPlatforms {
+		if k.Architecture == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize returns the platform with common aliases converted to their canonical value.
+// For example, "macos" becomes "darwin", "x86_64" becomes "amd64", and "arm32v7" becomes "arm" with the "v7" variant.
+func Normalize(p Platform) Platform {
+	p.normalize()
+	return p
+}
+
+// NormalizeArch converts an architecture and variant to their canonical values.
+// Architectures that include the variant, like "arm64v8" or "armv7l", are split into the two fields.
+// The default variant of an architecture is removed, e.g. "arm64/v8" becomes "arm64".
+func NormalizeArch(arch, variant string) (string, string) {
+	arch = strings.ToLower(arch)
+	variant = strings.ToLower(variant)
+	switch arch {
+	case "i386", "i486", "i586", "i686", "x86", "386":
+		return "386", ""
+	case "x86_64", "x86-64", "amd64":
+		switch variant {
+		case "v1", "1":
+			variant = ""
+		case "2", "3", "4":
+			variant = "v" + variant
+		}
+		return "amd64", variant
+	case "amd64v2", "amd64v3", "amd64v4":
+		return "amd64", strings.TrimPrefix(arch, "amd64")
+	case "aarch64", "arm64", "arm64v8", "armv8", "armv8l":
+		switch variant {
+		case "8", "v8":
+			variant = ""
+		}
+		return "arm64", variant
+	case "armhf":
+		return "arm", "v7"
+	case "armel":
+		return "arm", "v6"
+	case "arm32v5", "armv5", "armv5l", "armv5tel":
+		return "arm", "v5"
+	case "arm32v6", "armv6", "armv6l":
+		return "arm", "v6"
+	case "arm32v7", "armv7", "armv7l", "armv7hf":
+		return "arm", "v7"
+	case "arm":
+		switch variant {
+		case "", "7":
+			variant = "v7"
+		case "5", "6", "8":
+			variant = "v" + variant
+		}
+		return "arm", variant
+	case "ppc64el":
+		return "ppc64le", variant
+	}
+	return arch, variant
+}
+
+// normalizeOS converts an operating system to its canonical value.
+func normalizeOS(os string) string {
+	os = strings.ToLower(os)
+	switch os {
+	case "macos", "osx":
+		return "darwin"
+	}
+	return os
+}
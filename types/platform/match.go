@@ -0,0 +1,168 @@
+package platform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// matchAny is the wildcard value for a component of a match expression.
+const matchAny = "*"
+
+// Matcher selects platforms with a list of match expressions, see [NewMatcher].
+type Matcher struct {
+	include []matchExpr
+	exclude []matchExpr
+}
+
+type matchExpr struct {
+	exact      *Platform // set when the expression has no operators
+	os         string
+	arch       string
+	variant    string
+	osVersion  string
+	variantMin int // minimum variant number, 0 when the variant is compared directly
+}
+
+// NewMatcher parses a list of platform match expressions.
+// An expression without operators is parsed with [Parse] and compared with [Match], e.g. "linux/amd64".
+// A "*" component matches any value, and components omitted from a wildcard or negated expression also match any value, e.g. "linux/*" or "*/arm64".
+// A variant ending with "+" matches that variant or newer, e.g. "linux/arm/v7+" matches arm/v7 and arm/v8.
+// An expression beginning with "!" excludes matching platforms, e.g. "!windows".
+// A platform matches when no excluded expression matches, and any included expression matches or no included expressions were given.
+func NewMatcher(exprs ...string) (Matcher, error) {
+	m := Matcher{}
+	for _, expr := range exprs {
+		expr = strings.TrimSpace(expr)
+		negate := strings.HasPrefix(expr, "!")
+		if negate {
+			expr = expr[1:]
+		}
+		me, err := parseMatchExpr(expr, negate)
+		if err != nil {
+			return Matcher{}, err
+		}
+		if negate {
+			m.exclude = append(m.exclude, me)
+		} else {
+			m.include = append(m.include, me)
+		}
+	}
+	return m, nil
+}
+
+// Match reports if the platform is selected by the matcher.
+func (m Matcher) Match(p Platform) bool {
+	(&p).normalize()
+	for _, me := range m.exclude {
+		if me.match(p) {
+			return false
+		}
+	}
+	if len(m.include) == 0 {
+		return true
+	}
+	for _, me := range m.include {
+		if me.match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseMatchExpr(expr string, negate bool) (matchExpr, error) {
+	if !negate && !strings.Contains(expr, matchAny) && !strings.HasSuffix(expr, "+") {
+		p, err := Parse(expr)
+		if err != nil {
+			return matchExpr{}, err
+		}
+		return matchExpr{exact: &p}, nil
+	}
+	me := matchExpr{os: matchAny, arch: matchAny, variant: matchAny, osVersion: matchAny}
+	parts := strings.Split(expr, "/")
+	if len(parts) > 3 {
+		return me, fmt.Errorf("too many components in platform expression %s", expr)
+	}
+	for i, part := range parts {
+		part = strings.ToLower(part)
+		if i == 2 && strings.HasSuffix(part, "+") && parts[0] != "windows" {
+			part = strings.TrimSuffix(part, "+")
+			me.variantMin = variantNum("", part)
+			if me.variantMin <= 0 {
+				return me, fmt.Errorf("invalid variant %s in platform expression %s", part, expr)
+			}
+		}
+		if part != matchAny {
+			if i == 2 && parts[0] == "windows" {
+				if !verRE.MatchString(part) {
+					return me, fmt.Errorf("invalid platform component %s in %s", part, expr)
+				}
+			} else if !partRE.MatchString(part) {
+				return me, fmt.Errorf("invalid platform component %s in %s", part, expr)
+			}
+		}
+		switch i {
+		case 0:
+			me.os = part
+		case 1:
+			me.arch = part
+		case 2:
+			if me.os == "windows" {
+				me.osVersion = part
+			} else {
+				me.variant = part
+			}
+		}
+	}
+	if me.os == "macos" {
+		me.os = "darwin"
+	}
+	// normalize the architecture, the wildcard variant is preserved except for aliases like armhf
+	if me.arch != matchAny {
+		p := Platform{Architecture: me.arch, Variant: me.variant}
+		p.normalize()
+		me.arch, me.variant = p.Architecture, p.Variant
+	}
+	if me.variantMin > 0 {
+		me.variant = matchAny
+	}
+	return me, nil
+}
+
+func (me matchExpr) match(p Platform) bool {
+	if me.exact != nil {
+		return Match(*me.exact, p)
+	}
+	if me.os != matchAny && me.os != p.OS {
+		return false
+	}
+	if me.arch != matchAny && me.arch != p.Architecture {
+		return false
+	}
+	if me.osVersion != matchAny && prefix(me.osVersion) != prefix(p.OSVersion) {
+		return false
+	}
+	if me.variantMin > 0 {
+		return variantNum(p.Architecture, p.Variant) >= me.variantMin
+	}
+	return me.variant == matchAny || me.variant == p.Variant
+}
+
+// variantNum returns the number of a variant like "v7", with defaults for architectures that normalize the variant away.
+// A variant that is not numeric returns -1.
+func variantNum(arch, variant string) int {
+	if variant == "" {
+		switch arch {
+		case "amd64":
+			return 1
+		case "arm64":
+			return 8
+		}
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(variant, "v"))
+	if err != nil {
+		return -1
+	}
+	return n
+}
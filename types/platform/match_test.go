@@ -0,0 +1,120 @@
+package platform
+
+import "testing"
+
+func TestMatcher(t *testing.T) {
+	pAMD64 := Platform{OS: "linux", Architecture: "amd64"}
+	pARM64 := Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}
+	pARMv6 := Platform{OS: "linux", Architecture: "arm", Variant: "v6"}
+	pARMv7 := Platform{OS: "linux", Architecture: "arm", Variant: "v7"}
+	pDarwin := Platform{OS: "darwin", Architecture: "arm64"}
+	pWin := Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.2114"}
+	all := []Platform{pAMD64, pARM64, pARMv6, pARMv7, pDarwin, pWin}
+	tests := []struct {
+		name      string
+		exprs     []string
+		expect    []Platform
+		expectErr bool
+	}{
+		{
+			name:   "empty",
+			exprs:  []string{},
+			expect: all,
+		},
+		{
+			name:   "exact",
+			exprs:  []string{"linux/amd64", "linux/arm/v7"},
+			expect: []Platform{pAMD64, pARMv7},
+		},
+		{
+			name:   "exact normalized",
+			exprs:  []string{"linux/aarch64", "linux/armhf"},
+			expect: []Platform{pARM64, pARMv7},
+		},
+		{
+			name:   "os wildcard",
+			exprs:  []string{"linux/*"},
+			expect: []Platform{pAMD64, pARM64, pARMv6, pARMv7},
+		},
+		{
+			name:   "arch wildcard",
+			exprs:  []string{"*/arm64"},
+			expect: []Platform{pARM64, pDarwin},
+		},
+		{
+			name:   "variant wildcard",
+			exprs:  []string{"linux/arm/*"},
+			expect: []Platform{pARMv6, pARMv7},
+		},
+		{
+			name:   "variant minimum",
+			exprs:  []string{"linux/arm/v7+"},
+			expect: []Platform{pARMv7},
+		},
+		{
+			name:   "variant minimum any arch",
+			exprs:  []string{"linux/*/v7+"},
+			expect: []Platform{pARM64, pARMv7},
+		},
+		{
+			name:   "exclude",
+			exprs:  []string{"!windows"},
+			expect: []Platform{pAMD64, pARM64, pARMv6, pARMv7, pDarwin},
+		},
+		{
+			name:   "include and exclude",
+			exprs:  []string{"linux/*", "!linux/arm"},
+			expect: []Platform{pAMD64, pARM64},
+		},
+		{
+			name:   "windows version",
+			exprs:  []string{"windows/*/10.0.17763"},
+			expect: []Platform{pWin},
+		},
+		{
+			name:      "invalid component",
+			exprs:     []string{"linux/a.b/*"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid variant minimum",
+			exprs:     []string{"linux/arm/beta+"},
+			expectErr: true,
+		},
+		{
+			name:      "empty exclude",
+			exprs:     []string{"!"},
+			expectErr: true,
+		},
+		{
+			name:      "too many components",
+			exprs:     []string{"linux/arm/v7/*"},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMatcher(tt.exprs...)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("did not receive expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, p := range all {
+				expect := false
+				for _, pe := range tt.expect {
+					if Match(p, pe) {
+						expect = true
+					}
+				}
+				if m.Match(p) != expect {
+					t.Errorf("unexpected match result for %s, expected %t", p.String(), expect)
+				}
+			}
+		})
+	}
+}
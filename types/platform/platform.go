@@ -20,7 +20,6 @@ import (
 	"fmt"
 	"path"
 	"regexp"
-	"runtime"
 	"strings"
 )
 
@@ -143,19 +142,27 @@ func Parse(platStr string) (Platform, error) {
 	}
 	// extrapolate missing fields and normalize
 	platLocal := Local()
+	if len(platSplit) == 1 && !isKnownOS(normalizeOS(plat.OS)) {
+		// a single architecture, e.g. "arm64v8", is combined with the local OS
+		if arch, _ := NormalizeArch(plat.OS, ""); isKnownArch(arch) {
+			plat.Architecture = plat.OS
+			plat.OS = platLocal.OS
+			if plat.OS == "windows" {
+				plat.OSVersion = platLocal.OSVersion
+			}
+		}
+	}
 	if plat.OS == "" || plat.OS == "local" {
 		// assume local OS
 		plat.OS = platLocal.OS
 	}
-	switch plat.OS {
-	case "macos":
-		plat.OS = "darwin"
-	}
-	if len(platSplit) < 2 && plat.OS == runtime.GOOS {
+	plat.OS = normalizeOS(plat.OS)
+	if len(platSplit) < 2 && plat.Architecture == "" && plat.OS == platLocal.OS {
 		switch plat.OS {
 		case "linux", "darwin":
-			// automatically expand local architecture with recognized OS
+			// automatically expand local architecture and variant with recognized OS
 			plat.Architecture = platLocal.Architecture
+			plat.Variant = platLocal.Variant
 		case "windows":
 			plat.Architecture = platLocal.Architecture
 			plat.OSVersion = platLocal.OSVersion
@@ -167,35 +174,8 @@ func Parse(platStr string) (Platform, error) {
 }
 
 func (p *Platform) normalize() {
-	switch p.Architecture {
-	case "i386":
-		p.Architecture = "386"
-		p.Variant = ""
-	case "x86_64", "x86-64", "amd64":
-		p.Architecture = "amd64"
-		if p.Variant == "v1" {
-			p.Variant = ""
-		}
-	case "aarch64", "arm64":
-		p.Architecture = "arm64"
-		switch p.Variant {
-		case "8", "v8":
-			p.Variant = ""
-		}
-	case "armhf":
-		p.Architecture = "arm"
-		p.Variant = "v7"
-	case "armel":
-		p.Architecture = "arm"
-		p.Variant = "v6"
-	case "arm":
-		switch p.Variant {
-		case "", "7":
-			p.Variant = "v7"
-		case "5", "6", "8":
-			p.Variant = "v" + p.Variant
-		}
-	}
+	p.OS = normalizeOS(p.OS)
+	p.Architecture, p.Variant = NormalizeArch(p.Architecture, p.Variant)
 }
 
 func prefix(platVer string) string {
@@ -9,7 +9,7 @@ import "runtime"
 func Local() Platform {
 	return Platform{
 		OS:           runtime.GOOS,
-		Architecture: runtime.GOARCH,
+		Architecture: localArch(),
 		Variant:      cpuVariant(),
 	}
 }
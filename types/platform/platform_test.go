@@ -153,6 +153,31 @@ func TestPlatformParse(t *testing.T) {
 			parse: "local",
 			goal:  Local(),
 		},
+		{
+			name:  "linux arm64v8",
+			parse: "linux/arm64v8",
+			goal:  Platform{OS: "linux", Architecture: "arm64"},
+		},
+		{
+			name:  "linux x86_64",
+			parse: "linux/x86_64",
+			goal:  Platform{OS: "linux", Architecture: "amd64"},
+		},
+		{
+			name:  "linux arm32v6",
+			parse: "linux/arm32v6",
+			goal:  Platform{OS: "linux", Architecture: "arm", Variant: "v6"},
+		},
+		{
+			name:  "macos arm64",
+			parse: "macos/arm64",
+			goal:  Platform{OS: "darwin", Architecture: "arm64"},
+		},
+		{
+			name:  "arch only",
+			parse: "arm64v8",
+			goal:  Platform{OS: Local().OS, Architecture: "arm64", OSVersion: Local().OSVersion},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -215,3 +240,83 @@ func TestPlatformString(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Platform
+		goal Platform
+	}{
+		{
+			name: "x86_64",
+			p:    Platform{OS: "Linux", Architecture: "x86_64"},
+			goal: Platform{OS: "linux", Architecture: "amd64"},
+		},
+		{
+			name: "arm64v8",
+			p:    Platform{OS: "linux", Architecture: "arm64v8"},
+			goal: Platform{OS: "linux", Architecture: "arm64"},
+		},
+		{
+			name: "armv7l",
+			p:    Platform{OS: "linux", Architecture: "armv7l"},
+			goal: Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+		},
+		{
+			name: "arm default variant",
+			p:    Platform{OS: "linux", Architecture: "arm"},
+			goal: Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+		},
+		{
+			name: "i686",
+			p:    Platform{OS: "windows", Architecture: "i686", OSVersion: "10.0.17763"},
+			goal: Platform{OS: "windows", Architecture: "386", OSVersion: "10.0.17763"},
+		},
+		{
+			name: "macos",
+			p:    Platform{OS: "macos", Architecture: "aarch64", Variant: "v8"},
+			goal: Platform{OS: "darwin", Architecture: "arm64"},
+		},
+		{
+			name: "amd64v3",
+			p:    Platform{OS: "linux", Architecture: "amd64v3"},
+			goal: Platform{OS: "linux", Architecture: "amd64", Variant: "v3"},
+		},
+		{
+			name: "unknown",
+			p:    Platform{OS: "plan9", Architecture: "sparc"},
+			goal: Platform{OS: "plan9", Architecture: "sparc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Normalize(tt.p)
+			if result.OS != tt.goal.OS || result.Architecture != tt.goal.Architecture || result.Variant != tt.goal.Variant || result.OSVersion != tt.goal.OSVersion {
+				t.Errorf("unexpected result, expected %v, received %v", tt.goal, result)
+			}
+		})
+	}
+}
+
+func TestKnown(t *testing.T) {
+	known := Known()
+	if len(known) == 0 {
+		t.Fatalf("no known platforms")
+	}
+	for _, p := range known {
+		if !IsKnown(p) {
+			t.Errorf("known platform is not normalized: %v", p)
+		}
+	}
+	// modifying the result does not change the table
+	known[0].OS = "changed"
+	if Known()[0].OS == "changed" {
+		t.Errorf("known table was modified")
+	}
+	if !IsKnown(Platform{OS: "linux", Architecture: "aarch64", Variant: "v8"}) {
+		t.Errorf("linux/arm64/v8 was not known")
+	}
+	if IsKnown(Platform{OS: "linux", Architecture: "arm", Variant: "v4"}) {
+		t.Errorf("linux/arm/v4 was known")
+	}
+}
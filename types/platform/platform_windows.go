@@ -15,7 +15,7 @@ func Local() Platform {
 	major, minor, build := windows.RtlGetNtVersionNumbers()
 	return Platform{
 		OS:           runtime.GOOS,
-		Architecture: runtime.GOARCH,
+		Architecture: localArch(),
 		Variant:      cpuVariant(),
 		OSVersion:    fmt.Sprintf("%d.%d.%d", major, minor, build),
 	}
@@ -8,6 +8,9 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
 
 	"github.com/regclient/regclient/types"
 )
@@ -20,6 +23,8 @@ const (
 	dockerRegistryLegacy = "index.docker.io"
 	// dockerRegistryDNS is the host to connect to for Hub.
 	dockerRegistryDNS = "registry-1.docker.io"
+	// strictNameMax is the limit on the registry and repository length used by many clients.
+	strictNameMax = 255
 )
 
 var (
@@ -32,31 +37,112 @@ var (
 	pathS       = `[/a-zA-Z0-9_\-. ]+`
 	tagS        = `[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}`
 	digestS     = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*[:][[:xdigit:]]{32,}`
-	schemeRE    = regexp.MustCompile(`^([a-z]+)://(.+)$`)
+	schemeRE    = regexp.MustCompile(`^([a-z][a-z0-9]*(?:-[a-z0-9]+)*)://(.+)$`)
 	registryRE  = regexp.MustCompile(`^(` + registryS + `)$`)
 	refRE       = regexp.MustCompile(`^(?:(` + registryS + `)` + regexp.QuoteMeta(`/`) + `)?` +
 		`(` + repoPartS + `(?:` + regexp.QuoteMeta(`/`) + repoPartS + `)*)` +
 		`(?:` + regexp.QuoteMeta(`:`) + `(` + tagS + `))?` +
 		`(?:` + regexp.QuoteMeta(`@`) + `(` + digestS + `))?$`)
-	ocidirRE = regexp.MustCompile(`^(` + pathS + `)` +
+	s3BucketRE   = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	schemeNameRE = regexp.MustCompile(`^[a-z][a-z0-9]*(?:-[a-z0-9]+)*$`)
+	ocidirRE     = regexp.MustCompile(`^(` + pathS + `)` +
 		`(?:` + regexp.QuoteMeta(`:`) + `(` + tagS + `))?` +
 		`(?:` + regexp.QuoteMeta(`@`) + `(` + digestS + `))?$`)
+	tagRE          = regexp.MustCompile(`^` + tagS + `$`)
+	digestRE       = regexp.MustCompile(`^` + digestS + `$`)
+	strictRegistry = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]*[a-z0-9])?)*(?::[0-9]+)?$`)
+)
+
+var (
+	schemesMu      sync.RWMutex
+	schemesCustom  = map[string]bool{}
+	schemesBuiltin = map[string]bool{
+		"reg": true, "ocidir": true, "ocifile": true, "ocitar": true,
+		"s3": true, "docker-daemon": true,
+	}
+)
+
+// RegisterScheme allows references with a custom scheme to be parsed.
+// References to a custom scheme are parsed like "ocidir", a path with an optional tag and digest.
+// Registration applies to all reference parsing in the process, and is typically done in an init function.
+// Registering a built in scheme has no effect.
+func RegisterScheme(scheme string) error {
+	if !schemeNameRE.MatchString(scheme) {
+		return fmt.Errorf("%w, invalid scheme name \"%s\"", types.ErrInvalidReference, scheme)
+	}
+	if schemesBuiltin[scheme] {
+		return nil
+	}
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	schemesCustom[scheme] = true
+	return nil
+}
+
+// SchemeRegistered returns true for built in schemes and schemes added with [RegisterScheme].
+func SchemeRegistered(scheme string) bool {
+	return schemesBuiltin[scheme] || isSchemeCustom(scheme)
+}
+
+// isSchemeCustom returns true for schemes added with RegisterScheme.
+func isSchemeCustom(scheme string) bool {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	return schemesCustom[scheme]
+}
+
+// schemeLayout returns the built in scheme with the same layout, custom schemes are handled like "ocidir".
+func schemeLayout(scheme string) string {
+	if isSchemeCustom(scheme) {
+		return "ocidir"
+	}
+	return scheme
+}
+
+// Validation defines how strictly references are checked by [New].
+type Validation int
+
+const (
+	// ValidationRelaxed accepts any reference supported by regclient, including registry names with uppercase characters or a trailing dot.
+	// This is the default.
+	ValidationRelaxed Validation = iota
+	// ValidationStrict requires the OCI distribution-spec naming rules.
+	// Registry names must be lowercase with an optional port, the registry and repository are limited to 255 characters, and digests must use a supported algorithm and encoding.
+	ValidationStrict
 )
 
+// Opt is used to configure [New].
+type Opt func(*refOpt)
+
+type refOpt struct {
+	validation Validation
+}
+
+// WithValidation sets the strictness level for parsing a reference.
+func WithValidation(v Validation) Opt {
+	return func(o *refOpt) {
+		o.validation = v
+	}
+}
+
 // Ref is a reference to a registry/repository.
 // Direct access to the contents of this struct should not be assumed.
 type Ref struct {
 	Scheme     string // Scheme is the type of reference, "reg" or "ocidir".
 	Reference  string // Reference is the unparsed string or common name.
-	Registry   string // Registry is the server for the "reg" scheme.
-	Repository string // Repository is the path on the registry for the "reg" scheme.
+	Registry   string // Registry is the server for the "reg" and "docker-daemon" schemes.
+	Repository string // Repository is the path on the registry for the "reg" and "docker-daemon" schemes.
 	Tag        string // Tag is a mutable tag for a reference.
 	Digest     string // Digest is an immutable hash for a reference.
-	Path       string // Path is the directory of the OCI Layout for "ocidir".
+	Path       string // Path is the directory of the OCI Layout for "ocidir", the file for "ocitar", or the bucket and prefix for "s3".
 }
 
 // New returns a reference based on the scheme (defaulting to "reg").
-func New(parse string) (Ref, error) {
+func New(parse string, opts ...Opt) (Ref, error) {
+	opt := refOpt{}
+	for _, fn := range opts {
+		fn(&opt)
+	}
 	scheme := ""
 	tail := parse
 	matchScheme := schemeRE.FindStringSubmatch(parse)
@@ -103,7 +189,7 @@ func New(parse string) (Ref, error) {
 			return Ref{}, fmt.Errorf("%w \"%s\"", types.ErrInvalidReference, tail)
 		}
 
-	case "ocidir", "ocifile":
+	case "ocidir", "ocifile", "ocitar":
 		matchPath := ocidirRE.FindStringSubmatch(tail)
 		if matchPath == nil || len(matchPath) < 2 || matchPath[1] == "" {
 			return Ref{}, fmt.Errorf("%w, invalid path for scheme \"%s\": %s", types.ErrInvalidReference, scheme, tail)
@@ -116,12 +202,76 @@ func New(parse string) (Ref, error) {
 			ret.Digest = matchPath[3]
 		}
 
+	case "s3":
+		// s3 refs are a bucket with an optional prefix to the OCI Layout
+		matchPath := ocidirRE.FindStringSubmatch(tail)
+		if matchPath == nil || len(matchPath) < 2 || !s3PathValid(matchPath[1]) {
+			return Ref{}, fmt.Errorf("%w, invalid bucket for scheme \"%s\": %s", types.ErrInvalidReference, scheme, tail)
+		}
+		ret.Path = strings.TrimSuffix(matchPath[1], "/")
+		if len(matchPath) > 2 && matchPath[2] != "" {
+			ret.Tag = matchPath[2]
+		}
+		if len(matchPath) > 3 && matchPath[3] != "" {
+			ret.Digest = matchPath[3]
+		}
+
+	case "docker-daemon":
+		// docker-daemon refs are an image name as seen by the engine
+		rImg, err := New(tail, opts...)
+		if err != nil {
+			return Ref{}, err
+		}
+		if rImg.Scheme != "reg" {
+			return Ref{}, fmt.Errorf("%w, invalid image name for scheme \"%s\": %s", types.ErrInvalidReference, scheme, tail)
+		}
+		ret.Registry = rImg.Registry
+		ret.Repository = rImg.Repository
+		ret.Tag = rImg.Tag
+		ret.Digest = rImg.Digest
+
 	default:
-		return Ref{}, fmt.Errorf("%w, unknown scheme \"%s\" in \"%s\"", types.ErrInvalidReference, scheme, parse)
+		if !isSchemeCustom(scheme) {
+			return Ref{}, fmt.Errorf("%w, unknown scheme \"%s\" in \"%s\"", types.ErrInvalidReference, scheme, parse)
+		}
+		matchPath := ocidirRE.FindStringSubmatch(tail)
+		if matchPath == nil || len(matchPath) < 2 || matchPath[1] == "" {
+			return Ref{}, fmt.Errorf("%w, invalid path for scheme \"%s\": %s", types.ErrInvalidReference, scheme, tail)
+		}
+		ret.Path = matchPath[1]
+		if len(matchPath) > 2 && matchPath[2] != "" {
+			ret.Tag = matchPath[2]
+		}
+		if len(matchPath) > 3 && matchPath[3] != "" {
+			ret.Digest = matchPath[3]
+		}
+	}
+	if opt.validation == ValidationStrict {
+		if err := ret.validateStrict(); err != nil {
+			return Ref{}, err
+		}
 	}
 	return ret, nil
 }
 
+// validateStrict checks a parsed reference against the OCI distribution-spec naming rules.
+func (r Ref) validateStrict() error {
+	if r.Scheme == "reg" {
+		if !strictRegistry.MatchString(r.Registry) {
+			return fmt.Errorf("%w, registry \"%s\" is not a lowercase hostname with an optional port", types.ErrInvalidReference, r.Registry)
+		}
+		if len(r.Registry)+1+len(r.Repository) > strictNameMax {
+			return fmt.Errorf("%w, name \"%s/%s\" exceeds %d characters", types.ErrInvalidReference, r.Registry, r.Repository, strictNameMax)
+		}
+	}
+	if r.Digest != "" {
+		if err := digest.Digest(r.Digest).Validate(); err != nil {
+			return fmt.Errorf("%w, digest \"%s\": %v", types.ErrInvalidReference, r.Digest, err)
+		}
+	}
+	return nil
+}
+
 // NewHost returns a Reg for a registry hostname or equivalent.
 // The ocidir schema equivalent is the path.
 func NewHost(parse string) (Ref, error) {
@@ -148,23 +298,46 @@ func NewHost(parse string) (Ref, error) {
 			return Ref{}, fmt.Errorf("%w \"%s\"", types.ErrParsingFailed, tail)
 		}
 
-	case "ocidir", "ocifile":
+	case "ocidir", "ocifile", "ocitar":
 		matchPath := ocidirRE.FindStringSubmatch(tail)
 		if matchPath == nil || len(matchPath) < 2 || matchPath[1] == "" {
 			return Ref{}, fmt.Errorf("%w, invalid path for scheme \"%s\": %s", types.ErrParsingFailed, scheme, tail)
 		}
 		ret.Path = matchPath[1]
 
+	case "s3":
+		matchPath := ocidirRE.FindStringSubmatch(tail)
+		if matchPath == nil || len(matchPath) < 2 || !s3PathValid(matchPath[1]) {
+			return Ref{}, fmt.Errorf("%w, invalid bucket for scheme \"%s\": %s", types.ErrParsingFailed, scheme, tail)
+		}
+		ret.Path = strings.TrimSuffix(matchPath[1], "/")
+
+	case "docker-daemon":
+		matchReg := registryRE.FindStringSubmatch(tail)
+		if matchReg == nil || len(matchReg) < 2 || matchReg[1] == "" {
+			return Ref{}, fmt.Errorf("%w \"%s\"", types.ErrParsingFailed, tail)
+		}
+		ret.Registry = matchReg[1]
+
 	default:
-		return Ref{}, fmt.Errorf("%w, unknown scheme \"%s\" in \"%s\"", types.ErrParsingFailed, scheme, parse)
+		if !isSchemeCustom(scheme) {
+			return Ref{}, fmt.Errorf("%w, unknown scheme \"%s\" in \"%s\"", types.ErrParsingFailed, scheme, parse)
+		}
+		matchPath := ocidirRE.FindStringSubmatch(tail)
+		if matchPath == nil || len(matchPath) < 2 || matchPath[1] == "" {
+			return Ref{}, fmt.Errorf("%w, invalid path for scheme \"%s\": %s", types.ErrParsingFailed, scheme, tail)
+		}
+		ret.Path = matchPath[1]
 	}
 	return ret, nil
 }
 
 // CommonName outputs a parsable name from a reference.
+// Registry references include the default registry and "library" repository.
+// Both the tag and digest are included when set.
 func (r Ref) CommonName() string {
 	cn := ""
-	switch r.Scheme {
+	switch schemeLayout(r.Scheme) {
 	case "reg":
 		if r.Registry != "" {
 			cn = r.Registry + "/"
@@ -179,8 +352,19 @@ func (r Ref) CommonName() string {
 		if r.Digest != "" {
 			cn = cn + "@" + r.Digest
 		}
-	case "ocidir":
-		cn = fmt.Sprintf("ocidir://%s", r.Path)
+	case "docker-daemon":
+		if r.Repository == "" {
+			return ""
+		}
+		cn = r.Scheme + "://" + r.Registry + "/" + r.Repository
+		if r.Tag != "" {
+			cn = cn + ":" + r.Tag
+		}
+		if r.Digest != "" {
+			cn = cn + "@" + r.Digest
+		}
+	case "ocidir", "ocitar", "s3":
+		cn = fmt.Sprintf("%s://%s", r.Scheme, r.Path)
 		if r.Tag != "" {
 			cn = cn + ":" + r.Tag
 		}
@@ -191,13 +375,48 @@ func (r Ref) CommonName() string {
 	return cn
 }
 
+// FamiliarName outputs a parsable name from a reference without the defaults.
+// The Docker Hub registry, the "library" prefix for official images, and the "latest" tag without a digest are removed.
+// Parsing the familiar name with New returns an equivalent reference.
+func (r Ref) FamiliarName() string {
+	switch schemeLayout(r.Scheme) {
+	case "reg", "docker-daemon":
+	default:
+		return r.CommonName()
+	}
+	if r.Repository == "" {
+		return ""
+	}
+	name := r.Registry + "/" + r.Repository
+	if r.Registry == dockerRegistry || r.Registry == "" {
+		name = r.Repository
+		if strings.HasPrefix(name, dockerLibrary+"/") && strings.Count(name, "/") == 1 {
+			name = strings.TrimPrefix(name, dockerLibrary+"/")
+		}
+		// a first path component that looks like a registry must keep the registry prefix
+		if first, _, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+			name = dockerRegistry + "/" + name
+		}
+	}
+	if r.Tag != "" && (r.Tag != "latest" || r.Digest != "") {
+		name = name + ":" + r.Tag
+	}
+	if r.Digest != "" {
+		name = name + "@" + r.Digest
+	}
+	if r.Scheme == "docker-daemon" {
+		name = r.Scheme + "://" + name
+	}
+	return name
+}
+
 // IsSet returns true if needed values are defined for a specific reference.
 func (r Ref) IsSet() bool {
 	if !r.IsSetRepo() {
 		return false
 	}
 	// Registry requires a tag or digest, OCI Layout doesn't require these.
-	if r.Scheme == "reg" && r.Tag == "" && r.Digest == "" {
+	if (r.Scheme == "reg" || r.Scheme == "docker-daemon") && r.Tag == "" && r.Digest == "" {
 		return false
 	}
 	return true
@@ -205,12 +424,12 @@ func (r Ref) IsSet() bool {
 
 // IsSetRepo returns true when the ref includes values for a specific repository.
 func (r Ref) IsSetRepo() bool {
-	switch r.Scheme {
-	case "reg":
+	switch schemeLayout(r.Scheme) {
+	case "reg", "docker-daemon":
 		if r.Registry != "" && r.Repository != "" {
 			return true
 		}
-	case "ocidir":
+	case "ocidir", "ocitar", "s3":
 		if r.Path != "" {
 			return true
 		}
@@ -228,6 +447,7 @@ func (r Ref) IsZero() bool {
 
 // SetDigest returns a ref with the requested digest set.
 // The tag will be unset and the reference value will be reset.
+// Use Builder to set the digest while retaining the tag.
 func (r Ref) SetDigest(digest string) Ref {
 	r.Digest = digest
 	r.Tag = ""
@@ -237,6 +457,7 @@ func (r Ref) SetDigest(digest string) Ref {
 
 // SetTag returns a ref with the requested tag set.
 // The digest will be unset and the reference value will be reset.
+// Use Builder to set the tag while retaining the digest.
 func (r Ref) SetTag(tag string) Ref {
 	r.Tag = tag
 	r.Digest = ""
@@ -244,10 +465,53 @@ func (r Ref) SetTag(tag string) Ref {
 	return r
 }
 
+// Builder is used to modify the tag and digest of a reference.
+// Unlike SetTag and SetDigest, the other value is retained, and each value is validated.
+type Builder struct {
+	r   Ref
+	err error
+}
+
+// NewBuilder returns a Builder initialized from an existing reference.
+func NewBuilder(r Ref) *Builder {
+	return &Builder{r: r}
+}
+
+// Tag sets the tag, leaving any digest unchanged.
+// An empty string removes the tag.
+func (b *Builder) Tag(tag string) *Builder {
+	if b.err == nil && tag != "" && !tagRE.MatchString(tag) {
+		b.err = fmt.Errorf("%w, invalid tag \"%s\"", types.ErrInvalidReference, tag)
+	}
+	b.r.Tag = tag
+	return b
+}
+
+// Digest sets the digest, leaving any tag unchanged.
+// An empty string removes the digest.
+func (b *Builder) Digest(digest string) *Builder {
+	if b.err == nil && digest != "" && !digestRE.MatchString(digest) {
+		b.err = fmt.Errorf("%w, invalid digest \"%s\"", types.ErrInvalidReference, digest)
+	}
+	b.r.Digest = digest
+	return b
+}
+
+// Ref returns the resulting reference with the reference value reset.
+// The first validation error from the Builder is returned.
+func (b *Builder) Ref() (Ref, error) {
+	if b.err != nil {
+		return Ref{}, b.err
+	}
+	r := b.r
+	r.Reference = r.CommonName()
+	return r, nil
+}
+
 // ToReg converts a reference to a registry like syntax.
 func (r Ref) ToReg() Ref {
-	switch r.Scheme {
-	case "ocidir":
+	switch schemeLayout(r.Scheme) {
+	case "ocidir", "ocitar", "s3":
 		r.Scheme = "reg"
 		r.Registry = "localhost"
 		// clean the path to strip leading ".."
@@ -256,6 +520,9 @@ func (r Ref) ToReg() Ref {
 		// convert any unsupported characters to "-" in the path
 		re := regexp.MustCompile(`[^/a-z0-9]+`)
 		r.Repository = string(re.ReplaceAll([]byte(r.Repository), []byte("-")))
+	case "docker-daemon":
+		r.Scheme = "reg"
+		r.Path = ""
 	}
 	return r
 }
@@ -265,10 +532,10 @@ func EqualRegistry(a, b Ref) bool {
 	if a.Scheme != b.Scheme {
 		return false
 	}
-	switch a.Scheme {
-	case "reg":
+	switch schemeLayout(a.Scheme) {
+	case "reg", "docker-daemon":
 		return a.Registry == b.Registry
-	case "ocidir":
+	case "ocidir", "ocitar", "s3":
 		return a.Path == b.Path
 	case "":
 		// both undefined
@@ -283,10 +550,10 @@ func EqualRepository(a, b Ref) bool {
 	if a.Scheme != b.Scheme {
 		return false
 	}
-	switch a.Scheme {
-	case "reg":
+	switch schemeLayout(a.Scheme) {
+	case "reg", "docker-daemon":
 		return a.Registry == b.Registry && a.Repository == b.Repository
-	case "ocidir":
+	case "ocidir", "ocitar", "s3":
 		return a.Path == b.Path
 	case "":
 		// both undefined
@@ -295,3 +562,17 @@ func EqualRepository(a, b Ref) bool {
 		return false
 	}
 }
+
+// s3PathValid verifies the path begins with a valid bucket name.
+func s3PathValid(p string) bool {
+	bucket, prefix, _ := strings.Cut(p, "/")
+	if !s3BucketRE.MatchString(bucket) || strings.Contains(bucket, "..") {
+		return false
+	}
+	for _, part := range strings.Split(strings.TrimSuffix(prefix, "/"), "/") {
+		if part == "." || part == ".." || (part == "" && prefix != "") {
+			return false
+		}
+	}
+	return true
+}
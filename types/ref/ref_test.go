@@ -232,6 +232,28 @@ func TestNew(t *testing.T) {
 			path:       "",
 			wantE:      nil,
 		},
+		{
+			name:       "Tag and digest",
+			ref:        "registry:5000/group/image:v42@" + testDigest,
+			scheme:     "reg",
+			registry:   "registry:5000",
+			repository: "group/image",
+			tag:        "v42",
+			digest:     testDigest,
+			path:       "",
+			wantE:      nil,
+		},
+		{
+			name:       "Docker library tag and digest",
+			ref:        "alpine:3@" + testDigest,
+			scheme:     "reg",
+			registry:   "docker.io",
+			repository: "library/alpine",
+			tag:        "3",
+			digest:     testDigest,
+			path:       "",
+			wantE:      nil,
+		},
 		{
 			name:       "OCI file",
 			ref:        "ocifile://path/to/file.tgz",
@@ -303,6 +325,48 @@ func TestNew(t *testing.T) {
 			path:       "path/2/dir",
 			wantE:      nil,
 		},
+		{
+			name:   "s3 bucket",
+			ref:    "s3://my-bucket:v1",
+			scheme: "s3",
+			tag:    "v1",
+			path:   "my-bucket",
+			wantE:  nil,
+		},
+		{
+			name:   "s3 with prefix and digest",
+			ref:    "s3://my-bucket/mirror/app@" + testDigest,
+			scheme: "s3",
+			digest: testDigest,
+			path:   "my-bucket/mirror/app",
+			wantE:  nil,
+		},
+		{
+			name:  "s3 invalid bucket",
+			ref:   "s3://My_Bucket/app:v1",
+			wantE: types.ErrInvalidReference,
+		},
+		{
+			name:  "s3 parent path",
+			ref:   "s3://my-bucket/../app:v1",
+			wantE: types.ErrInvalidReference,
+		},
+		{
+			name:       "docker daemon",
+			ref:        "docker-daemon://myimg:dev",
+			scheme:     "docker-daemon",
+			registry:   "docker.io",
+			repository: "library/myimg",
+			tag:        "dev",
+			digest:     "",
+			path:       "",
+			wantE:      nil,
+		},
+		{
+			name:  "docker daemon invalid name",
+			ref:   "docker-daemon://Upper/Case:tag",
+			wantE: types.ErrInvalidReference,
+		},
 		{
 			name:  "invalid scheme",
 			ref:   "unknown://repo:tag",
@@ -424,6 +488,80 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewValidation(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name       string
+		ref        string
+		errRelaxed bool
+		errStrict  bool
+	}{
+		{
+			name: "docker hub",
+			ref:  "alpine:3@" + testDigest,
+		},
+		{
+			name: "localhost port",
+			ref:  "localhost:5000/repo:v1",
+		},
+		{
+			name: "localhost",
+			ref:  "localhost/repo:v1",
+		},
+		{
+			name:      "uppercase registry",
+			ref:       "Registry.Example.com/repo:v1",
+			errStrict: true,
+		},
+		{
+			name:      "trailing dot",
+			ref:       "registry.example.com./repo:v1",
+			errStrict: true,
+		},
+		{
+			name:      "long name",
+			ref:       "registry.example.com/" + strings.Repeat("a/", 120) + "repo",
+			errStrict: true,
+		},
+		{
+			name:      "unknown digest algorithm",
+			ref:       "registry.example.com/repo@md5:0123456789abcdef0123456789abcdef",
+			errStrict: true,
+		},
+		{
+			name:      "short sha256",
+			ref:       "ocidir://path@sha256:0123456789abcdef0123456789abcdef",
+			errStrict: true,
+		},
+		{
+			name:      "docker-daemon uppercase",
+			ref:       "docker-daemon://Registry.Example.com/repo",
+			errStrict: true,
+		},
+		{
+			name:       "uppercase repo",
+			ref:        "registry.example.com/Repo",
+			errRelaxed: true,
+			errStrict:  true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.ref)
+			if tc.errRelaxed != (err != nil) {
+				t.Errorf("relaxed parse of %s, expected error %t, received %v", tc.ref, tc.errRelaxed, err)
+			}
+			_, err = New(tc.ref, WithValidation(ValidationStrict))
+			if tc.errStrict != (err != nil) {
+				t.Errorf("strict parse of %s, expected error %t, received %v", tc.ref, tc.errStrict, err)
+			}
+			if err != nil && !errors.Is(err, types.ErrInvalidReference) {
+				t.Errorf("unexpected error type: %v", err)
+			}
+		})
+	}
+}
+
 func TestNewHost(t *testing.T) {
 	t.Parallel()
 	var tt = []struct {
@@ -625,14 +763,30 @@ func TestCommon(t *testing.T) {
 			name: "ref with digest",
 			str:  "docker.io/group/image@" + testDigest,
 		},
+		{
+			name: "ref with tag and digest",
+			str:  "docker.io/group/image:tag@" + testDigest,
+		},
 		{
 			name: "ocidir with tag",
 			str:  "ocidir:///tmp/image:tag",
 		},
+		{
+			name: "ocidir with tag and digest",
+			str:  "ocidir:///tmp/image:tag@" + testDigest,
+		},
 		{
 			name: "ocidir with digest",
 			str:  "ocidir://image@" + testDigest,
 		},
+		{
+			name: "docker daemon with tag",
+			str:  "docker-daemon://localhost:5000/image:tag",
+		},
+		{
+			name: "s3 with tag",
+			str:  "s3://bucket/prefix:tag",
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -649,6 +803,80 @@ func TestCommon(t *testing.T) {
 	}
 }
 
+func TestFamiliarName(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name   string
+		str    string
+		expect string
+	}{
+		{
+			name:   "docker library",
+			str:    "docker.io/library/alpine:latest",
+			expect: "alpine",
+		},
+		{
+			name:   "docker library tag",
+			str:    "index.docker.io/library/alpine:3",
+			expect: "alpine:3",
+		},
+		{
+			name:   "docker library latest and digest",
+			str:    "alpine:latest@" + testDigest,
+			expect: "alpine:latest@" + testDigest,
+		},
+		{
+			name:   "docker project",
+			str:    "docker.io/regclient/regctl:edge",
+			expect: "regclient/regctl:edge",
+		},
+		{
+			name:   "docker library nested",
+			str:    "docker.io/library/group/image:v1",
+			expect: "library/group/image:v1",
+		},
+		{
+			name:   "docker project with registry like name",
+			str:    "docker.io/example.com/image:v1",
+			expect: "docker.io/example.com/image:v1",
+		},
+		{
+			name:   "other registry",
+			str:    "registry.example.com/library/alpine@" + testDigest,
+			expect: "registry.example.com/library/alpine@" + testDigest,
+		},
+		{
+			name:   "docker daemon",
+			str:    "docker-daemon://alpine",
+			expect: "docker-daemon://alpine",
+		},
+		{
+			name:   "ocidir",
+			str:    "ocidir://path:latest",
+			expect: "ocidir://path:latest",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := New(tc.str)
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", tc.str, err)
+			}
+			fn := r.FamiliarName()
+			if fn != tc.expect {
+				t.Errorf("familiar name mismatch, expected %s, received %s", tc.expect, fn)
+			}
+			rFamiliar, err := New(fn)
+			if err != nil {
+				t.Fatalf("failed to parse familiar name %s: %v", fn, err)
+			}
+			if rFamiliar.CommonName() != r.CommonName() {
+				t.Errorf("familiar name does not round trip, expected %s, received %s", r.CommonName(), rFamiliar.CommonName())
+			}
+		})
+	}
+}
+
 func TestEqual(t *testing.T) {
 	t.Parallel()
 	tt := []struct {
@@ -926,6 +1154,59 @@ func TestSet(t *testing.T) {
 	}
 }
 
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+	r, err := New("example.com/repo:v1")
+	if err != nil {
+		t.Fatalf("unexpected parse failure: %v", err)
+	}
+	t.Run("digest", func(t *testing.T) {
+		rOut, err := NewBuilder(r).Digest(testDigest).Ref()
+		if err != nil {
+			t.Fatalf("failed to build: %v", err)
+		}
+		if rOut.Tag != "v1" || rOut.Digest != testDigest {
+			t.Errorf("unexpected tag or digest: %s, %s", rOut.Tag, rOut.Digest)
+		}
+		if rOut.Reference != "example.com/repo:v1@"+testDigest {
+			t.Errorf("unexpected reference: %s", rOut.Reference)
+		}
+		if r.Digest != "" {
+			t.Errorf("original ref was modified: %s", r.CommonName())
+		}
+	})
+	t.Run("tag", func(t *testing.T) {
+		rOut, err := NewBuilder(r).Digest(testDigest).Tag("v2").Ref()
+		if err != nil {
+			t.Fatalf("failed to build: %v", err)
+		}
+		if rOut.Reference != "example.com/repo:v2@"+testDigest {
+			t.Errorf("unexpected reference: %s", rOut.Reference)
+		}
+	})
+	t.Run("clear", func(t *testing.T) {
+		rOut, err := NewBuilder(r).Digest(testDigest).Tag("").Ref()
+		if err != nil {
+			t.Fatalf("failed to build: %v", err)
+		}
+		if rOut.Reference != "example.com/repo@"+testDigest {
+			t.Errorf("unexpected reference: %s", rOut.Reference)
+		}
+	})
+	t.Run("invalid tag", func(t *testing.T) {
+		_, err := NewBuilder(r).Tag("bad:tag").Digest(testDigest).Ref()
+		if !errors.Is(err, types.ErrInvalidReference) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("invalid digest", func(t *testing.T) {
+		_, err := NewBuilder(r).Digest("sha256:1234").Ref()
+		if !errors.Is(err, types.ErrInvalidReference) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
 func TestToReg(t *testing.T) {
 	t.Parallel()
 	tt := []struct {
@@ -968,3 +1249,51 @@ func TestToReg(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterScheme(t *testing.T) {
+	t.Parallel()
+	_, err := New("test-custom://path/to/repo:v1")
+	if !errors.Is(err, types.ErrInvalidReference) {
+		t.Errorf("unregistered scheme did not fail: %v", err)
+	}
+	err = RegisterScheme("Invalid_Name")
+	if !errors.Is(err, types.ErrInvalidReference) {
+		t.Errorf("invalid scheme name did not fail: %v", err)
+	}
+	err = RegisterScheme("test-custom")
+	if err != nil {
+		t.Fatalf("failed to register scheme: %v", err)
+	}
+	r, err := New("test-custom://path/to/repo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	if r.Scheme != "test-custom" || r.Path != "path/to/repo" || r.Tag != "v1" {
+		t.Errorf("unexpected ref: %#v", r)
+	}
+	if r.CommonName() != "test-custom://path/to/repo:v1" {
+		t.Errorf("unexpected common name: %s", r.CommonName())
+	}
+	if !r.IsSet() {
+		t.Errorf("ref is not set")
+	}
+	if !EqualRepository(r, r.SetDigest(testDigest)) {
+		t.Errorf("repository does not match after setting digest")
+	}
+	rHost, err := NewHost("test-custom://path/to/repo")
+	if err != nil {
+		t.Fatalf("failed to parse host: %v", err)
+	}
+	if !EqualRegistry(r, rHost) {
+		t.Errorf("registry does not match host")
+	}
+	// built in schemes are not changed
+	err = RegisterScheme("reg")
+	if err != nil {
+		t.Errorf("failed to register built in scheme: %v", err)
+	}
+	_, err = New("reg://docker.io/library/alpine:latest")
+	if !errors.Is(err, types.ErrInvalidReference) {
+		t.Errorf("reg scheme did not fail: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package regclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/regclient/regclient/pkg/notation"
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// notationEnvelopeMax limits the size of a signature envelope that is pulled.
+const notationEnvelopeMax = 4 * 1024 * 1024
+
+// NotationResult is the result of verifying one Notation signature with [RegClient.ImageVerifyNotation].
+// Error is set when the signature is not valid.
+type NotationResult struct {
+	notation.Result
+	Signature string `json:"signature"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ImageVerifyNotation verifies the Notation signatures attached to an image as referrers.
+// A result is returned for each signature found, and an error is returned unless at least one signature is valid.
+func (rc *RegClient) ImageVerifyNotation(ctx context.Context, r ref.Ref, ts *notation.TrustStore) ([]NotationResult, error) {
+	results := []NotationResult{}
+	if !r.IsSet() {
+		return results, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), types.ErrInvalidReference)
+	}
+	mh, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+	if err != nil {
+		return results, err
+	}
+	dImage := mh.GetDescriptor()
+	rImage := r.SetDigest(dImage.Digest.String())
+	rl, err := rc.ReferrerList(ctx, rImage, scheme.WithReferrerMatchOpt(types.MatchOpt{ArtifactType: notation.ArtifactTypeSignature}))
+	if err != nil {
+		return results, fmt.Errorf("failed to list signatures: %w", err)
+	}
+	if len(rl.Descriptors) == 0 {
+		return results, fmt.Errorf("no notation signatures found for %s%.0w", rImage.CommonName(), types.ErrNotFound)
+	}
+	subject := types.Descriptor{
+		MediaType: dImage.MediaType,
+		Digest:    dImage.Digest,
+		Size:      dImage.Size,
+	}
+	var lastErr error
+	verified := false
+	for _, d := range rl.Descriptors {
+		rSig := rImage.SetDigest(d.Digest.String())
+		result, err := rc.imageVerifyNotationSig(ctx, rSig, ts, subject)
+		result.Signature = d.Digest.String()
+		if err != nil {
+			result.Error = err.Error()
+			lastErr = err
+			rc.log.WithFields(logrus.Fields{
+				"signature": rSig.CommonName(),
+				"err":       err,
+			}).Debug("notation signature not valid")
+		} else {
+			verified = true
+		}
+		results = append(results, result)
+	}
+	if !verified {
+		return results, fmt.Errorf("no valid notation signatures for %s: %w", rImage.CommonName(), lastErr)
+	}
+	return results, nil
+}
+
+// imageVerifyNotationSig pulls and verifies a single signature manifest.
+func (rc *RegClient) imageVerifyNotationSig(ctx context.Context, rSig ref.Ref, ts *notation.TrustStore, subject types.Descriptor) (NotationResult, error) {
+	result := NotationResult{}
+	m, err := rc.ManifestGet(ctx, rSig)
+	if err != nil {
+		return result, err
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return result, fmt.Errorf("signature is not an image manifest%.0w", types.ErrUnsupportedMediaType)
+	}
+	layers, err := mi.GetLayers()
+	if err != nil {
+		return result, err
+	}
+	if len(layers) != 1 {
+		return result, fmt.Errorf("signature has %d layers, expected 1%.0w", len(layers), notation.ErrVerifyFailed)
+	}
+	if layers[0].Size > notationEnvelopeMax {
+		return result, fmt.Errorf("signature envelope size %d exceeds %d%.0w", layers[0].Size, notationEnvelopeMax, types.ErrSizeLimitExceeded)
+	}
+	br, err := rc.BlobGet(ctx, rSig, layers[0])
+	if err != nil {
+		return result, err
+	}
+	defer br.Close()
+	b, err := io.ReadAll(br)
+	if err != nil {
+		return result, err
+	}
+	result.Result, err = notation.Verify(ts, layers[0].MediaType, b, subject)
+	return result, err
+}
@@ -0,0 +1,190 @@
+package regclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/internal/rwfs"
+	"github.com/regclient/regclient/pkg/notation"
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestImageVerifyNotation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsOS := rwfs.OSNew("")
+	fsMem := rwfs.MemNew()
+	err := rwfs.CopyRecursive(fsOS, "testdata", fsMem, ".")
+	if err != nil {
+		t.Fatalf("failed to setup memfs copy: %v", err)
+	}
+	rc := New(WithFS(fsMem))
+	r, err := ref.New("ocidir://testrepo:v3")
+	if err != nil {
+		t.Fatalf("failed to setup ref: %v", err)
+	}
+	mh, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	dImage := mh.GetDescriptor()
+
+	// setup a CA and signing certificate
+	now := time.Now()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             now.Add(-1 * time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to create ca: %v", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("failed to parse ca: %v", err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test signer"},
+		NotBefore:    now.Add(-1 * time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to create leaf: %v", err)
+	}
+
+	_, err = rc.ImageVerifyNotation(ctx, r, notation.NewTrustStore(ca))
+	if !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("unexpected error without signatures: %v", err)
+	}
+
+	// push a JWS signature as a referrer
+	payload, err := json.Marshal(notation.Payload{TargetArtifact: dImage})
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %v", err)
+	}
+	protected, err := json.Marshal(map[string]interface{}{
+		"alg":                          "ES256",
+		"cty":                          notation.MediaTypePayload,
+		"crit":                         []string{"io.cncf.notary.signingScheme"},
+		"io.cncf.notary.signingScheme": notation.SigningSchemeX509,
+		"io.cncf.notary.signingTime":   now.Add(-1 * time.Minute).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal protected header: %v", err)
+	}
+	input := base64.RawURLEncoding.EncodeToString(protected) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	h := sha256.Sum256([]byte(input))
+	sigR, sigS, err := ecdsa.Sign(rand.Reader, leafKey, h[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	sig := make([]byte, 64)
+	sigR.FillBytes(sig[:32])
+	sigS.FillBytes(sig[32:])
+	envelope, err := json.Marshal(map[string]interface{}{
+		"payload":   base64.RawURLEncoding.EncodeToString(payload),
+		"protected": base64.RawURLEncoding.EncodeToString(protected),
+		"header":    map[string]interface{}{"x5c": []string{base64.StdEncoding.EncodeToString(leafDER)}},
+		"signature": base64.RawURLEncoding.EncodeToString(sig),
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+	dLayer := types.Descriptor{
+		MediaType: notation.MediaTypeJWS,
+		Digest:    digest.FromBytes(envelope),
+		Size:      int64(len(envelope)),
+	}
+	_, err = rc.BlobPut(ctx, r, dLayer, bytes.NewReader(envelope))
+	if err != nil {
+		t.Fatalf("failed to push envelope: %v", err)
+	}
+	dConf := types.Descriptor{
+		MediaType: types.MediaTypeOCI1Empty,
+		Digest:    types.EmptyDigest,
+		Size:      int64(len(types.EmptyData)),
+	}
+	_, err = rc.BlobPut(ctx, r, dConf, bytes.NewReader(types.EmptyData))
+	if err != nil {
+		t.Fatalf("failed to push config: %v", err)
+	}
+	m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned:    v1.ManifestSchemaVersion,
+		MediaType:    types.MediaTypeOCI1Manifest,
+		ArtifactType: notation.ArtifactTypeSignature,
+		Config:       dConf,
+		Layers:       []types.Descriptor{dLayer},
+		Subject:      &types.Descriptor{MediaType: dImage.MediaType, Digest: dImage.Digest, Size: dImage.Size},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	rSig := r.SetDigest(m.GetDescriptor().Digest.String())
+	err = rc.ManifestPut(ctx, rSig, m)
+	if err != nil {
+		t.Fatalf("failed to push signature: %v", err)
+	}
+
+	results, err := rc.ImageVerifyNotation(ctx, r, notation.NewTrustStore(ca))
+	if err != nil {
+		t.Fatalf("failed to verify: %v", err)
+	}
+	if len(results) != 1 || results[0].Signature != rSig.Digest || results[0].Error != "" || results[0].Format != "jws" {
+		t.Errorf("unexpected results: %v", results)
+	}
+
+	// an untrusted CA fails
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, otherKey.Public(), otherKey)
+	if err != nil {
+		t.Fatalf("failed to create ca: %v", err)
+	}
+	otherCA, err := x509.ParseCertificate(otherDER)
+	if err != nil {
+		t.Fatalf("failed to parse ca: %v", err)
+	}
+	results, err = rc.ImageVerifyNotation(ctx, r, notation.NewTrustStore(otherCA))
+	if !errors.Is(err, notation.ErrVerifyFailed) {
+		t.Errorf("unexpected error with untrusted ca: %v", err)
+	}
+	if len(results) != 1 || results[0].Error == "" {
+		t.Errorf("unexpected results: %v", results)
+	}
+}